@@ -0,0 +1,92 @@
+// Command encrypt-cpf encrypts the cpf of every account under the active field
+// key and fills in its blind index: the rows stored before the cpf was
+// encrypted, and the rows of a key being rotated out. It can run with the API
+// up, as the API reads both, and a row changed while it runs is left for the
+// next run rather than overwritten. Once a run finds nothing to do, the old key
+// can be dropped from the config.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/diegoclair/go_boilerplate/infra/config"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/logger"
+)
+
+const appName = "boilerplate"
+
+func main() {
+	batchSize := flag.Int64("batch-size", 500, "how many accounts are read at a time")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.GetConfigEnvironment(ctx, appName)
+	if err != nil {
+		log.Fatalf("Error to load config: %v", err)
+	}
+	defer cfg.Close()
+
+	log := cfg.GetLogger()
+
+	encrypted, skipped, err := encryptCPFs(ctx, cfg.GetDataManager(), cfg.GetCrypto(), *batchSize)
+	if err != nil {
+		log.Error(ctx, "error to encrypt cpfs", logger.Err(err))
+		return
+	}
+
+	log.Info(ctx, fmt.Sprintf("Encrypted %d cpfs, %d changed while running and are left for the next run", encrypted, skipped))
+}
+
+// encryptCPFs walks the accounts batchSize at a time, each row written on its
+// own so nothing is locked for longer than one update.
+func encryptCPFs(ctx context.Context, dm contract.DataManager, crypto contract.Crypto, batchSize int64) (encrypted, skipped int64, err error) {
+	var afterID int64
+
+	for {
+		accounts, err := dm.Account().GetAccountsAfterID(ctx, afterID, batchSize)
+		if err != nil {
+			return encrypted, skipped, err
+		}
+
+		for _, account := range accounts {
+			afterID = account.ID
+
+			if !crypto.NeedsReencryption(account.CPF) && account.CPFIndex != "" {
+				continue
+			}
+
+			storedCPF := account.CPF
+
+			cpf, err := crypto.DecryptField(storedCPF)
+			if err != nil {
+				return encrypted, skipped, fmt.Errorf("account %s: %w", account.UUID, err)
+			}
+
+			account.CPF, err = crypto.EncryptField(cpf)
+			if err != nil {
+				return encrypted, skipped, fmt.Errorf("account %s: %w", account.UUID, err)
+			}
+			account.CPFIndex = crypto.BlindIndex(cpf)
+
+			updated, err := dm.Account().UpdateAccountCPF(ctx, account, storedCPF)
+			if err != nil {
+				return encrypted, skipped, fmt.Errorf("account %s: %w", account.UUID, err)
+			}
+
+			if !updated {
+				skipped++
+				continue
+			}
+			encrypted++
+		}
+
+		if int64(len(accounts)) < batchSize {
+			return encrypted, skipped, nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Command grant-role sets the role of an account, which is how the first
+// admin is made: the API only lets an admin near the accounts of others. The
+// account is signed out of every session, and gets the role when it logs in
+// again.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/diegoclair/go_boilerplate/infra/config"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/application/service"
+	"github.com/diegoclair/go_boilerplate/internal/domain"
+	"github.com/diegoclair/logger"
+)
+
+const appName = "boilerplate"
+
+func main() {
+	accountUUID := flag.String("account", "", "uuid of the account")
+	role := flag.String("role", "", "customer, support or admin")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	cfg, err := config.GetConfigEnvironment(ctx, appName)
+	if err != nil {
+		log.Fatalf("Error to load config: %v", err)
+	}
+	defer cfg.Close()
+
+	log := cfg.GetLogger()
+
+	infra := domain.NewInfrastructureServices(
+		domain.WithCacheManager(cfg.GetCacheManager()),
+		domain.WithDataManager(cfg.GetDataManager()),
+		domain.WithLogger(log),
+		domain.WithCrypto(cfg.GetCrypto()),
+		domain.WithNotifier(cfg.GetNotifier()),
+		domain.WithValidator(cfg.GetValidator()),
+	)
+
+	transferLimits, err := cfg.App.TransferLimits.ToEntity()
+	if err != nil {
+		log.Error(ctx, "error to read transfer limits", logger.Err(err))
+		return
+	}
+
+	loginThrottle, err := cfg.App.Auth.LoginThrottle.ToEntity()
+	if err != nil {
+		log.Error(ctx, "error to read login throttle", logger.Err(err))
+		return
+	}
+
+	apps, err := service.New(infra, cfg.App.Auth.AccessTokenDuration, cfg.App.Auth.PasswordResetTokenDuration, transferLimits,
+		loginThrottle, cfg.App.Name, cfg.App.Auth.MaxSessions)
+	if err != nil {
+		log.Error(ctx, "error to get domain services", logger.Err(err))
+		return
+	}
+
+	err = apps.AccountService.ChangeRole(ctx, dto.RoleInput{AccountUUID: *accountUUID, Role: *role})
+	if err != nil {
+		log.Error(ctx, "error to change the account role", logger.Err(err))
+		return
+	}
+
+	log.Info(ctx, fmt.Sprintf("Account %s is now %s", *accountUUID, *role))
+}
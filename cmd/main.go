@@ -11,6 +11,7 @@ import (
 	"github.com/diegoclair/go_boilerplate/internal/application/service"
 	"github.com/diegoclair/go_boilerplate/internal/domain"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest"
+	"github.com/diegoclair/go_boilerplate/internal/transport/worker"
 	pgMigrator "github.com/diegoclair/go_boilerplate/migrator/postgres"
 	"github.com/diegoclair/logger"
 )
@@ -36,6 +37,7 @@ func main() {
 		domain.WithDataManager(cfg.GetDataManager()),
 		domain.WithLogger(log),
 		domain.WithCrypto(cfg.GetCrypto()),
+		domain.WithNotifier(cfg.GetNotifier()),
 		domain.WithValidator(cfg.GetValidator()),
 	)
 
@@ -47,13 +49,40 @@ func main() {
 	}
 	log.Info(ctx, "Migrations completed successfully")
 
-	apps, err := service.New(infra, cfg.App.Auth.AccessTokenDuration)
+	transferLimits, err := cfg.App.TransferLimits.ToEntity()
+	if err != nil {
+		log.Error(ctx, "error to read transfer limits", logger.Err(err))
+		return
+	}
+
+	loginThrottle, err := cfg.App.Auth.LoginThrottle.ToEntity()
+	if err != nil {
+		log.Error(ctx, "error to read login throttle", logger.Err(err))
+		return
+	}
+
+	trustedProxies, err := cfg.App.ParseTrustedProxies()
+	if err != nil {
+		log.Error(ctx, "error to read trusted proxies", logger.Err(err))
+		return
+	}
+
+	apps, err := service.New(infra, cfg.App.Auth.AccessTokenDuration, cfg.App.Auth.PasswordResetTokenDuration, transferLimits,
+		loginThrottle, cfg.App.Name, cfg.App.Auth.MaxSessions)
 	if err != nil {
 		log.Error(ctx, "error to get domain services", logger.Err(err))
 		return
 	}
 
-	server := rest.StartRestServer(ctx, cfg, infra, apps, appName, cfg.GetHttpPort())
+	server := rest.StartRestServer(ctx, cfg, infra, apps, appName, cfg.GetHttpPort(), trustedProxies)
+
+	shutdownOpts := []shutdown.ShutdownOptions{shutdown.WithRestServer(server.Router.Echo())}
+
+	if !cfg.App.ScheduledTransfer.Disabled {
+		scheduledTransferWorker := worker.StartScheduledTransferWorker(ctx, apps.ScheduledTransferService, apps.RecurringTransferService, log,
+			cfg.App.ScheduledTransfer.PollInterval, cfg.App.ScheduledTransfer.BatchSize)
+		shutdownOpts = append(shutdownOpts, shutdown.WithWorker(scheduledTransferWorker))
+	}
 
-	shutdown.GracefulShutdown(ctx, log, shutdown.WithRestServer(server.Router.Echo()))
+	shutdown.GracefulShutdown(ctx, log, shutdownOpts...)
 }
@@ -0,0 +1,35 @@
+// Command rebuild-balances recomputes the balance cached on tab_account from
+// the ledger, for when the two have drifted. Run it with the API stopped: a
+// transfer committing while the sums are taken would be overwritten.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/diegoclair/go_boilerplate/infra/config"
+	"github.com/diegoclair/logger"
+)
+
+const appName = "boilerplate"
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := config.GetConfigEnvironment(ctx, appName)
+	if err != nil {
+		log.Fatalf("Error to load config: %v", err)
+	}
+	defer cfg.Close()
+
+	log := cfg.GetLogger()
+
+	rebuilt, err := cfg.GetDataManager().Ledger().RebuildAccountBalances(ctx)
+	if err != nil {
+		log.Error(ctx, "error to rebuild account balances", logger.Err(err))
+		return
+	}
+
+	log.Info(ctx, fmt.Sprintf("Rebuilt %d account balances from the ledger", rebuilt))
+}
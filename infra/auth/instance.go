@@ -16,17 +16,22 @@ const (
 var (
 	accessTokenDurationTime  time.Duration
 	refreshTokenDurationTime time.Duration
+	mfaTokenDurationTime     time.Duration
 )
 
 var (
 	errExpiredToken      = errors.New("token has expired")
 	errInvalidToken      = errors.New("token is invalid")
+	errWrongPurpose      = errors.New("token is not for this use")
 	errInvalidPrivateKey = fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
 )
 
-func NewAuthToken(accessTokenDuration, refreshTokenDuration time.Duration, pasetoSymmetricKey string, log logger.Logger) (contract.AuthToken, error) {
+// NewAuthToken returns the token maker. mfaTokenDuration is how long a login
+// waits for its second factor.
+func NewAuthToken(accessTokenDuration, refreshTokenDuration, mfaTokenDuration time.Duration, pasetoSymmetricKey string, log logger.Logger) (contract.AuthToken, error) {
 	accessTokenDurationTime = accessTokenDuration
 	refreshTokenDurationTime = refreshTokenDuration
+	mfaTokenDurationTime = mfaTokenDuration
 
 	return newPasetoAuth(pasetoSymmetricKey, log)
 }
@@ -37,7 +37,7 @@ func newPasetoAuth(symmetricKey string, log logger.Logger) (*pasetoAuth, error)
 }
 
 func (p *pasetoAuth) CreateAccessToken(ctx context.Context, input contract.TokenPayloadInput) (tokenString string, resp contract.TokenPayload, err error) {
-	payload := newPayload(fromContractTokenPayloadInput(input), accessTokenDurationTime)
+	payload := newPayload(fromContractTokenPayloadInput(input), accessTokenDurationTime, "")
 
 	tokenString, err = p.createToken(ctx, payload)
 	if err != nil {
@@ -48,7 +48,18 @@ func (p *pasetoAuth) CreateAccessToken(ctx context.Context, input contract.Token
 }
 
 func (p *pasetoAuth) CreateRefreshToken(ctx context.Context, input contract.TokenPayloadInput) (tokenString string, resp contract.TokenPayload, err error) {
-	payload := newPayload(fromContractTokenPayloadInput(input), refreshTokenDurationTime)
+	payload := newPayload(fromContractTokenPayloadInput(input), refreshTokenDurationTime, "")
+
+	tokenString, err = p.createToken(ctx, payload)
+	if err != nil {
+		return tokenString, resp, err
+	}
+
+	return tokenString, payload.toContract(), nil
+}
+
+func (p *pasetoAuth) CreateMFAToken(ctx context.Context, input contract.TokenPayloadInput) (tokenString string, resp contract.TokenPayload, err error) {
+	payload := newPayload(fromContractTokenPayloadInput(input), mfaTokenDurationTime, purposeMFA)
 
 	tokenString, err = p.createToken(ctx, payload)
 	if err != nil {
@@ -59,6 +70,14 @@ func (p *pasetoAuth) CreateRefreshToken(ctx context.Context, input contract.Toke
 }
 
 func (p *pasetoAuth) VerifyToken(ctx context.Context, tokenStr string) (resp contract.TokenPayload, err error) {
+	return p.verifyToken(ctx, tokenStr, "")
+}
+
+func (p *pasetoAuth) VerifyMFAToken(ctx context.Context, tokenStr string) (resp contract.TokenPayload, err error) {
+	return p.verifyToken(ctx, tokenStr, purposeMFA)
+}
+
+func (p *pasetoAuth) verifyToken(ctx context.Context, tokenStr, purpose string) (resp contract.TokenPayload, err error) {
 	if strings.TrimSpace(tokenStr) == "" {
 		return resp, apperr.ErrTokenInvalid
 	}
@@ -75,6 +94,11 @@ func (p *pasetoAuth) VerifyToken(ctx context.Context, tokenStr string) (resp con
 		return resp, apperr.ErrTokenInvalid
 	}
 
+	if payload.Purpose != purpose {
+		p.log.Error(ctx, "error to validate token", logger.Err(errWrongPurpose))
+		return resp, apperr.ErrTokenInvalid
+	}
+
 	if err := payload.Valid(); err != nil {
 		if errors.Is(err, errExpiredToken) {
 			p.log.Warn(ctx, "token has expired")
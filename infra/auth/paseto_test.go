@@ -20,6 +20,7 @@ func TestPasetoTokenMaker(t *testing.T) {
 				payload: contract.TokenPayloadInput{
 					AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
 					SessionUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+					Role:        "admin",
 				},
 				accessTokenDuration:  time.Second,
 				refreshTokenDuration: time.Second * 2,
@@ -88,8 +89,52 @@ func Test_paseto_VerifyToken(t *testing.T) {
 			}
 			require.Equal(t, tt.args.payload.SessionUUID, gotPayload.SessionUUID)
 			require.Equal(t, tt.args.payload.AccountUUID, gotPayload.AccountUUID)
+			require.Equal(t, tt.args.payload.Role, gotPayload.Role)
 			require.WithinDuration(t, tokenPayload.IssuedAt, gotPayload.IssuedAt, 1*time.Second)
 			require.WithinDuration(t, tokenPayload.ExpiredAt, gotPayload.ExpiredAt, 1*time.Second)
 		})
 	}
 }
+
+func Test_paseto_MFAToken(t *testing.T) {
+	ctx := context.Background()
+	args := utilArgs{
+		payload: contract.TokenPayloadInput{
+			AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		},
+	}
+
+	maker, err := getTokenAuth(getConfig(t, args))
+	require.NoError(t, err)
+
+	mfaToken, mfaPayload, err := maker.CreateMFAToken(ctx, args.payload)
+	require.NoError(t, err)
+	require.NotEmpty(t, mfaToken)
+	require.WithinDuration(t, time.Now().Add(5*time.Minute), mfaPayload.ExpiredAt, time.Second)
+
+	t.Run("Should verify a mfa token as one", func(t *testing.T) {
+		gotPayload, err := maker.VerifyMFAToken(ctx, mfaToken)
+		require.NoError(t, err)
+		require.Equal(t, args.payload.AccountUUID, gotPayload.AccountUUID)
+		require.Equal(t, mfaPayload.ID, gotPayload.ID)
+	})
+
+	t.Run("Should give each token an ID of its own", func(t *testing.T) {
+		_, otherPayload, err := maker.CreateMFAToken(ctx, args.payload)
+		require.NoError(t, err)
+		require.NotEmpty(t, mfaPayload.ID)
+		require.NotEqual(t, mfaPayload.ID, otherPayload.ID)
+	})
+
+	t.Run("Should refuse a mfa token as an access token", func(t *testing.T) {
+		_, err := maker.VerifyToken(ctx, mfaToken)
+		require.Error(t, err)
+	})
+
+	t.Run("Should refuse an access token as a mfa token", func(t *testing.T) {
+		accessToken, _ := createTestAccessToken(ctx, t, maker, args)
+
+		_, err := maker.VerifyMFAToken(ctx, accessToken)
+		require.Error(t, err)
+	})
+}
@@ -4,24 +4,36 @@ import (
 	"time"
 
 	"github.com/diegoclair/go_boilerplate/infra/contract"
+	"github.com/google/uuid"
 )
 
 type tokenPayloadInput struct {
 	AccountUUID string
 	SessionUUID string
+	Role        string
 }
 
 func fromContractTokenPayloadInput(input contract.TokenPayloadInput) tokenPayloadInput {
 	return tokenPayloadInput{
 		AccountUUID: input.AccountUUID,
 		SessionUUID: input.SessionUUID,
+		Role:        input.Role,
 	}
 }
 
-// tokenPayload represents the payload of a JWT token
+// purposeMFA is the purpose of a token of a login waiting for its second
+// factor. The access and refresh tokens have none.
+const purposeMFA = "mfa"
+
+// tokenPayload represents the payload of a JWT token. Purpose keeps a token
+// from being used for something else than it was issued for, and ID tells
+// apart two tokens issued for the same input.
 type tokenPayload struct {
+	ID           string
 	AccountUUID  string
 	SessionUUID  string
+	Role         string
+	Purpose      string
 	RefreshToken string
 	IssuedAt     time.Time
 	ExpiredAt    time.Time
@@ -29,18 +41,23 @@ type tokenPayload struct {
 
 func (t *tokenPayload) toContract() contract.TokenPayload {
 	return contract.TokenPayload{
+		ID:           t.ID,
 		AccountUUID:  t.AccountUUID,
 		SessionUUID:  t.SessionUUID,
+		Role:         t.Role,
 		RefreshToken: t.RefreshToken,
 		IssuedAt:     t.IssuedAt,
 		ExpiredAt:    t.ExpiredAt,
 	}
 }
 
-func newPayload(input tokenPayloadInput, duration time.Duration) *tokenPayload {
+func newPayload(input tokenPayloadInput, duration time.Duration, purpose string) *tokenPayload {
 	return &tokenPayload{
+		ID:          uuid.Must(uuid.NewV7()).String(),
 		SessionUUID: input.SessionUUID,
 		AccountUUID: input.AccountUUID,
+		Role:        input.Role,
+		Purpose:     purpose,
 		IssuedAt:    time.Now(),
 		ExpiredAt:   time.Now().Add(duration),
 	}
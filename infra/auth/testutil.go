@@ -66,6 +66,7 @@ func validateTokenCreation(t *testing.T, args utilArgs, token string, tokenPaylo
 
 	require.Equal(t, args.payload.AccountUUID, tokenPayload.AccountUUID)
 	require.Equal(t, args.payload.SessionUUID, tokenPayload.SessionUUID)
+	require.Equal(t, args.payload.Role, tokenPayload.Role)
 	require.NotZero(t, tokenPayload.IssuedAt)
 	require.NotZero(t, tokenPayload.ExpiredAt)
 }
@@ -90,6 +91,7 @@ func validateTokenMaker(t *testing.T, args utilArgs) {
 func getTokenAuth(cfg *configmock.ConfigMock) (contract.AuthToken, error) {
 	return NewAuthToken(cfg.Auth.AccessTokenDuration,
 		cfg.Auth.RefreshTokenDuration,
+		cfg.Auth.MFATokenDuration,
 		cfg.Auth.PasetoSymmetricKey,
 		cfg.GetLogger(),
 	)
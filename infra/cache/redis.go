@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/diegoclair/logger"
+	"github.com/google/uuid"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -17,9 +18,12 @@ import (
 type IRedisCache interface {
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd
 	TTL(ctx context.Context, key string) *redis.DurationCmd
 	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
 	Incr(ctx context.Context, key string) *redis.IntCmd
+	Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *redis.Cmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
 	Keys(ctx context.Context, pattern string) *redis.StringSliceCmd
 }
@@ -54,30 +58,47 @@ func NewRedisCache(ctx context.Context, addr string, password string, db int, de
 // Set stores a value in cache. Accepts string, []byte, int, int64 or any struct (JSON marshaled).
 // Expiration is optional: omit for default, pass for custom.
 func (r *CacheManager) Set(ctx context.Context, key string, data any, expiration ...time.Duration) error {
-	exp := r.defaultExpiration
+	bytes, err := encode(data)
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Set(ctx, key, bytes, r.expiration(expiration)).Err()
+}
+
+// SetNX stores a value in cache like Set, but only when the key isn't there
+// yet, so it never overwrites a value written in the meantime.
+func (r *CacheManager) SetNX(ctx context.Context, key string, data any, expiration ...time.Duration) (set bool, err error) {
+	bytes, err := encode(data)
+	if err != nil {
+		return false, err
+	}
+
+	return r.redis.SetNX(ctx, key, bytes, r.expiration(expiration)).Result()
+}
+
+func (r *CacheManager) expiration(expiration []time.Duration) time.Duration {
 	if len(expiration) > 0 {
-		exp = expiration[0]
+		return expiration[0]
 	}
 
-	var bytes []byte
+	return r.defaultExpiration
+}
+
+// encode turns the data given to Set into the bytes stored.
+func encode(data any) ([]byte, error) {
 	switch v := data.(type) {
 	case []byte:
-		bytes = v
+		return v, nil
 	case string:
-		bytes = []byte(v)
+		return []byte(v), nil
 	case int:
-		bytes = []byte(strconv.Itoa(v))
+		return []byte(strconv.Itoa(v)), nil
 	case int64:
-		bytes = []byte(strconv.FormatInt(v, 10))
+		return []byte(strconv.FormatInt(v, 10)), nil
 	default:
-		var err error
-		bytes, err = json.Marshal(data)
-		if err != nil {
-			return err
-		}
+		return json.Marshal(data)
 	}
-
-	return r.redis.Set(ctx, key, bytes, exp).Err()
 }
 
 // Get returns raw bytes from cache
@@ -161,6 +182,66 @@ func (r *CacheManager) Increase(ctx context.Context, key string) (err error) {
 	return nil
 }
 
+// rateLimitSource keeps the hits of KEYS[1] in a sorted set scored by their
+// time in milliseconds. It drops those older than the window, adds the hit
+// if the window has room for it and returns {allowed, hits, reset}.
+const rateLimitSource = `
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local hits = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if hits < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	hits = hits + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #oldest > 0 then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, hits, reset}
+`
+
+// rateLimitScript runs rateLimitSource by its hash, so the source is only sent
+// again when redis lost it.
+var rateLimitScript = redis.NewScript(rateLimitSource)
+
+// RateLimit counts a hit of key in a sliding window unless it is full, in a
+// single script so concurrent hits can't both take the last room.
+func (r *CacheManager) RateLimit(ctx context.Context, key string, limit int64, window time.Duration) (allowed bool, remaining int64, resetAfter time.Duration, err error) {
+	now := time.Now()
+	keys := []string{key}
+	// the hits are members of a set, so each needs its own
+	args := []any{now.UnixMilli(), window.Milliseconds(), limit, uuid.NewString()}
+
+	cmd := r.redis.EvalSha(ctx, rateLimitScript.Hash(), keys, args...)
+	if redis.HasErrorPrefix(cmd.Err(), "NOSCRIPT") {
+		cmd = r.redis.Eval(ctx, rateLimitSource, keys, args...)
+	}
+
+	result, err := cmd.Int64Slice()
+	if err != nil {
+		return allowed, remaining, resetAfter, err
+	}
+	if len(result) != 3 {
+		return allowed, remaining, resetAfter, fmt.Errorf("unexpected rate limit result: %v", result)
+	}
+
+	allowed = result[0] == 1
+	remaining = max(limit-result[1], 0)
+	resetAfter = time.Duration(result[2]) * time.Millisecond
+
+	return allowed, remaining, resetAfter, nil
+}
+
 // Delete removes a list of keys from the cache
 func (r *CacheManager) Delete(ctx context.Context, keys ...string) (err error) {
 	err = r.redis.Del(ctx, keys...).Err()
@@ -165,6 +165,43 @@ func TestRedisCache_Set(t *testing.T) {
 	}
 }
 
+func TestRedisCache_SetNX(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should set the key only when it isn't there", func(t *testing.T) {
+		key := "set_nx_key"
+
+		set, err := testRedis.SetNX(ctx, key, "first", time.Minute)
+		require.NoError(t, err)
+		require.True(t, set)
+
+		set, err = testRedis.SetNX(ctx, key, "second", time.Minute)
+		require.NoError(t, err)
+		require.False(t, set)
+
+		value, err := testRedis.GetString(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "first", value)
+	})
+
+	t.Run("Should return error when fail to marshal data", func(t *testing.T) {
+		_, err := testRedis.SetNX(ctx, "set_nx_marshal_error_key", make(chan int))
+		require.EqualError(t, err, "json: unsupported type: chan int")
+	})
+
+	t.Run("Should return error when redis fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockedRedis, redisMock := getRedisCacheMock(ctrl)
+
+		redisMock.EXPECT().SetNX(gomock.Any(), "set_nx_key", []byte("value"), time.Minute).
+			Return(redis.NewBoolResult(false, errors.New("some error")))
+
+		_, err := mockedRedis.SetNX(ctx, "set_nx_key", "value", time.Minute)
+		require.Equal(t, errors.New("some error"), err)
+	})
+}
+
 func TestRedisCache_GetInt(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
@@ -348,6 +385,59 @@ func Test_redisCache_Increase(t *testing.T) {
 	})
 }
 
+func TestRedisCache_RateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should take hits until the window is full", func(t *testing.T) {
+		key := "rate_limit_key_1"
+
+		for i := int64(1); i <= 3; i++ {
+			allowed, remaining, resetAfter, err := testRedis.RateLimit(ctx, key, 3, time.Minute)
+			require.NoError(t, err)
+			require.True(t, allowed)
+			require.Equal(t, 3-i, remaining)
+			require.LessOrEqual(t, resetAfter, time.Minute)
+			require.Greater(t, resetAfter, time.Duration(0))
+		}
+
+		allowed, remaining, resetAfter, err := testRedis.RateLimit(ctx, key, 3, time.Minute)
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.Zero(t, remaining)
+		require.Greater(t, resetAfter, time.Duration(0))
+	})
+
+	t.Run("Should take hits again once the oldest leave the window", func(t *testing.T) {
+		key := "rate_limit_key_2"
+
+		allowed, _, _, err := testRedis.RateLimit(ctx, key, 1, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		allowed, _, _, err = testRedis.RateLimit(ctx, key, 1, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		time.Sleep(250 * time.Millisecond)
+
+		allowed, _, _, err = testRedis.RateLimit(ctx, key, 1, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("Should return error when the script fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockedRedis, redisMock := getRedisCacheMock(ctrl)
+
+		redisMock.EXPECT().EvalSha(gomock.Any(), gomock.Any(), []string{"rate_limit_key"}, gomock.Any()).
+			Return(redis.NewCmdResult(nil, errors.New("some error")))
+
+		_, _, _, err := mockedRedis.RateLimit(ctx, "rate_limit_key", 1, time.Minute)
+		require.Equal(t, errors.New("some error"), err)
+	})
+}
+
 func TestRedisCache_GetStruct(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
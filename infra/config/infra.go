@@ -9,6 +9,7 @@ import (
 	infraContract "github.com/diegoclair/go_boilerplate/infra/contract"
 	"github.com/diegoclair/go_boilerplate/infra/crypto"
 	"github.com/diegoclair/go_boilerplate/infra/data/postgres"
+	"github.com/diegoclair/go_boilerplate/infra/notifier"
 	infraLogger "github.com/diegoclair/go_boilerplate/infra/logger"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
 	"github.com/diegoclair/logger"
@@ -57,6 +58,7 @@ func (c *Config) GetAuthToken() infraContract.AuthToken {
 		authToken, err = auth.NewAuthToken(
 			c.App.Auth.AccessTokenDuration,
 			c.App.Auth.RefreshTokenDuration,
+			c.App.Auth.MFATokenDuration,
 			c.App.Auth.PasetoSymmetricKey,
 			log,
 		)
@@ -112,7 +114,17 @@ var (
 // GetCrypto returns a new crypto or panics if it fails
 func (c *Config) GetCrypto() contract.Crypto {
 	cryptoOnce.Do(func() {
-		cryptoClient = crypto.NewCrypto()
+		log := c.GetLogger()
+
+		keys, err := c.App.FieldEncryption.ToFieldKeys()
+		if err != nil {
+			log.Fatal(c.ctx, "Failed to read the field encryption keys", logger.Err(err))
+		}
+
+		cryptoClient, err = crypto.NewCrypto(keys)
+		if err != nil {
+			log.Fatal(c.ctx, "Failed to create crypto", logger.Err(err))
+		}
 	})
 
 	return cryptoClient
@@ -149,6 +161,20 @@ func (c *Config) GetDataManager() contract.DataManager {
 	return dataManager
 }
 
+var (
+	notifierInst contract.Notifier
+	notifierOnce sync.Once
+)
+
+// GetNotifier returns a new notifier
+func (c *Config) GetNotifier() contract.Notifier {
+	notifierOnce.Do(func() {
+		notifierInst = notifier.NewLogNotifier(c.GetLogger(), c.App.Notifier.Path)
+	})
+
+	return notifierInst
+}
+
 var (
 	l       logger.Logger
 	logOnce sync.Once
@@ -2,9 +2,17 @@ package config
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"sync"
 	"time"
+
+	// the limits are counted in a time zone, and the image may not ship one
+	_ "time/tzdata"
+
+	"github.com/diegoclair/go_boilerplate/infra/crypto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 )
 
 type Config struct {
@@ -34,15 +42,195 @@ func (c *Config) Close() {
 }
 
 type AppConfig struct {
-	Name        string     `mapstructure:"name"`
-	Environment string     `mapstructure:"environment"`
-	Port        string     `mapstructure:"port"`
-	Auth        AuthConfig `mapstructure:"auth"`
+	Name        string `mapstructure:"name"`
+	Environment string `mapstructure:"environment"`
+	Port        string `mapstructure:"port"`
+	// TrustedProxies are the ip ranges, in CIDR notation, of the proxies in
+	// front of the app. Only they are believed about the ip of the client in
+	// X-Forwarded-For; with none the ip is the one of the connection.
+	TrustedProxies    []string                `mapstructure:"trusted-proxies"`
+	Auth              AuthConfig              `mapstructure:"auth"`
+	FieldEncryption   FieldEncryptionConfig   `mapstructure:"field-encryption"`
+	Notifier          NotifierConfig          `mapstructure:"notifier"`
+	ScheduledTransfer ScheduledTransferConfig `mapstructure:"scheduled-transfer"`
+	TransferLimits    TransferLimitsConfig    `mapstructure:"transfer-limits"`
+}
+
+// ParseTrustedProxies parses the ranges of TrustedProxies, failing on one
+// that isn't in CIDR notation rather than leaving the proxy out.
+func (c AppConfig) ParseTrustedProxies() (ranges []*net.IPNet, err error) {
+	for _, proxy := range c.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		ranges = append(ranges, ipRange)
+	}
+
+	return ranges, nil
 }
+
 type AuthConfig struct {
 	AccessTokenDuration  time.Duration `mapstructure:"access-token-duration"`
 	RefreshTokenDuration time.Duration `mapstructure:"refresh-token-duration"`
 	PasetoSymmetricKey   string        `mapstructure:"paseto-symmetric-key"`
+	// MFATokenDuration is how long a login waits for its second factor
+	MFATokenDuration time.Duration `mapstructure:"mfa-token-duration"`
+	// PasswordResetTokenDuration is how long a password reset token can be
+	// used for
+	PasswordResetTokenDuration time.Duration `mapstructure:"password-reset-token-duration"`
+	// MaxSessions is how many sessions an account can have at once; a login
+	// past it signs the oldest one out. Zero doesn't cap them.
+	MaxSessions int64 `mapstructure:"max-sessions"`
+	// SessionCheckFailOpen lets the requests through on their access token
+	// alone when the state of their session can't be read, as when the cache
+	// is down. Otherwise they are refused until it can.
+	SessionCheckFailOpen bool                `mapstructure:"session-check-fail-open"`
+	LoginThrottle        LoginThrottleConfig `mapstructure:"login-throttle"`
+}
+
+// LoginThrottleConfig slows down the guessing of passwords, see
+// entity.LoginThrottle. A zero lockout-attempts never locks the login out.
+type LoginThrottleConfig struct {
+	FreeAttempts      int64         `mapstructure:"free-attempts"`
+	BaseDelay         time.Duration `mapstructure:"base-delay"`
+	MaxDelay          time.Duration `mapstructure:"max-delay"`
+	LockoutAttempts   int64         `mapstructure:"lockout-attempts"`
+	LockoutDuration   time.Duration `mapstructure:"lockout-duration"`
+	IPLockoutAttempts int64         `mapstructure:"ip-lockout-attempts"`
+	Window            time.Duration `mapstructure:"window"`
+}
+
+// ToEntity checks the thresholds, failing on one that would leave the login
+// unprotected by mistake rather than running that way.
+func (c LoginThrottleConfig) ToEntity() (throttle entity.LoginThrottle, err error) {
+	if c.FreeAttempts < 0 || c.LockoutAttempts < 0 || c.IPLockoutAttempts < 0 {
+		return throttle, fmt.Errorf("invalid login throttle attempts: they can't be negative")
+	}
+
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay {
+		return throttle, fmt.Errorf("invalid login throttle delays %s to %s", c.BaseDelay, c.MaxDelay)
+	}
+
+	if c.LockoutAttempts > 0 && c.LockoutDuration <= 0 {
+		return throttle, fmt.Errorf("invalid login throttle lockout duration %s", c.LockoutDuration)
+	}
+
+	// the failures are forgotten after the window, so a window shorter than
+	// the wait they cause would never get to a lockout
+	if c.Window < c.MaxDelay || c.Window <= 0 {
+		return throttle, fmt.Errorf("invalid login throttle window %s, it must be longer than the max delay", c.Window)
+	}
+
+	return entity.LoginThrottle{
+		FreeAttempts:      c.FreeAttempts,
+		BaseDelay:         c.BaseDelay,
+		MaxDelay:          c.MaxDelay,
+		LockoutAttempts:   c.LockoutAttempts,
+		LockoutDuration:   c.LockoutDuration,
+		Window:            c.Window,
+		IPLockoutAttempts: c.IPLockoutAttempts,
+	}, nil
+}
+
+// FieldEncryptionConfig holds the keys that encrypt personal data at rest,
+// each one 32 bytes in base64. New values are encrypted with the key named by
+// ActiveKeyID; a rotated key must stay in Keys until the encrypt-cpf command
+// has moved every row off it.
+type FieldEncryptionConfig struct {
+	ActiveKeyID   string            `mapstructure:"active-key-id"`
+	Keys          map[string]string `mapstructure:"keys"`
+	BlindIndexKey string            `mapstructure:"blind-index-key"`
+}
+
+// ToFieldKeys decodes the keys. Their sizes are checked by crypto.NewCrypto.
+func (c FieldEncryptionConfig) ToFieldKeys() (keys crypto.FieldKeys, err error) {
+	keys.ActiveKeyID = c.ActiveKeyID
+	keys.Keys = make(map[string][]byte, len(c.Keys))
+
+	for id, encoded := range c.Keys {
+		keys.Keys[id], err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return keys, fmt.Errorf("invalid field encryption key %q: %w", id, err)
+		}
+	}
+
+	keys.BlindIndexKey, err = base64.StdEncoding.DecodeString(c.BlindIndexKey)
+	if err != nil {
+		return keys, fmt.Errorf("invalid blind index key: %w", err)
+	}
+
+	return keys, nil
+}
+
+// NotifierConfig sets where notifications go. Only the log notifier exists
+// yet: it writes them to Path as lines of JSON, or to the log when Path is
+// empty.
+type NotifierConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// ScheduledTransferConfig tunes the worker that executes scheduled transfers.
+// Every instance runs one; they split the due transfers between them.
+type ScheduledTransferConfig struct {
+	Disabled     bool          `mapstructure:"disabled"`
+	PollInterval time.Duration `mapstructure:"poll-interval"`
+	BatchSize    int64         `mapstructure:"batch-size"`
+}
+
+// TransferLimitsConfig are the limits of every account without an override in
+// tab_account_limit. Amounts are decimal strings such as "1000.00", and an
+// empty one is no limit.
+type TransferLimitsConfig struct {
+	PerTransaction string `mapstructure:"per-transaction"`
+	Daily          string `mapstructure:"daily"`
+	Monthly        string `mapstructure:"monthly"`
+	NightTime      string `mapstructure:"night-time"`
+	NightStartHour int    `mapstructure:"night-start-hour"`
+	NightEndHour   int    `mapstructure:"night-end-hour"`
+	TimeZone       string `mapstructure:"time-zone"`
+}
+
+// ToEntity parses the limits, failing on an amount, an hour or a time zone
+// that doesn't make sense rather than running without the limit.
+func (c TransferLimitsConfig) ToEntity() (limits entity.TransferLimits, err error) {
+	amounts := []struct {
+		name  string
+		value string
+		dest  *entity.Money
+	}{
+		{"per-transaction", c.PerTransaction, &limits.PerTransaction},
+		{"daily", c.Daily, &limits.Daily},
+		{"monthly", c.Monthly, &limits.Monthly},
+		{"night-time", c.NightTime, &limits.NightTime},
+	}
+
+	for _, amount := range amounts {
+		if amount.value == "" {
+			continue
+		}
+
+		*amount.dest, err = entity.ParseMoney(amount.value, entity.DefaultCurrency)
+		if err != nil || amount.dest.IsNegative() {
+			return limits, fmt.Errorf("invalid transfer limit %s %q", amount.name, amount.value)
+		}
+	}
+
+	if c.NightStartHour < 0 || c.NightStartHour > 23 || c.NightEndHour < 0 || c.NightEndHour > 23 {
+		return limits, fmt.Errorf("invalid transfer limit night hours %d to %d", c.NightStartHour, c.NightEndHour)
+	}
+
+	limits.NightStartHour = c.NightStartHour
+	limits.NightEndHour = c.NightEndHour
+
+	if c.TimeZone != "" {
+		limits.Location, err = time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return limits, fmt.Errorf("invalid transfer limit time zone %q: %w", c.TimeZone, err)
+		}
+	}
+
+	return limits, nil
 }
 
 type CacheConfig struct {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 func TestConfig_AddCloser(t *testing.T) {
@@ -39,3 +40,111 @@ func TestConfig_Close(t *testing.T) {
 		t.Errorf("Close failed to call the closer function")
 	}
 }
+
+func TestTransferLimitsConfig_ToEntity(t *testing.T) {
+	limits, err := TransferLimitsConfig{
+		PerTransaction: "5000.00",
+		Daily:          "20000",
+		NightTime:      "1000.50",
+		NightStartHour: 20,
+		NightEndHour:   6,
+		TimeZone:       "America/Sao_Paulo",
+	}.ToEntity()
+	if err != nil {
+		t.Fatalf("ToEntity() error = %v", err)
+	}
+
+	if limits.PerTransaction.Amount() != 500000 || limits.Daily.Amount() != 2000000 || limits.NightTime.Amount() != 100050 {
+		t.Errorf("ToEntity() amounts = %+v", limits)
+	}
+	if !limits.Monthly.IsZero() {
+		t.Errorf("ToEntity() monthly = %v, want no limit", limits.Monthly)
+	}
+	if limits.Location == nil || limits.Location.String() != "America/Sao_Paulo" {
+		t.Errorf("ToEntity() location = %v", limits.Location)
+	}
+
+	invalid := []TransferLimitsConfig{
+		{Daily: "a lot"},
+		{Monthly: "-1.00"},
+		{NightStartHour: 24},
+		{TimeZone: "Nowhere/Else"},
+	}
+	for _, c := range invalid {
+		if _, err := c.ToEntity(); err == nil {
+			t.Errorf("ToEntity(%+v) error = nil, want error", c)
+		}
+	}
+}
+
+func TestLoginThrottleConfig_ToEntity(t *testing.T) {
+	valid := LoginThrottleConfig{
+		FreeAttempts:      3,
+		BaseDelay:         time.Second,
+		MaxDelay:          time.Minute,
+		LockoutAttempts:   10,
+		LockoutDuration:   15 * time.Minute,
+		IPLockoutAttempts: 100,
+		Window:            time.Hour,
+	}
+
+	throttle, err := valid.ToEntity()
+	if err != nil {
+		t.Fatalf("ToEntity() error = %v", err)
+	}
+	if throttle.LockoutAttempts != 10 || throttle.IPLockoutAttempts != 100 || throttle.Window != time.Hour {
+		t.Errorf("ToEntity() = %+v", throttle)
+	}
+
+	invalid := []func(c *LoginThrottleConfig){
+		func(c *LoginThrottleConfig) { c.FreeAttempts = -1 },
+		func(c *LoginThrottleConfig) { c.MaxDelay = time.Millisecond },
+		func(c *LoginThrottleConfig) { c.LockoutDuration = 0 },
+		func(c *LoginThrottleConfig) { c.Window = 30 * time.Second },
+	}
+	for _, change := range invalid {
+		c := valid
+		change(&c)
+		if _, err := c.ToEntity(); err == nil {
+			t.Errorf("ToEntity(%+v) error = nil, want error", c)
+		}
+	}
+}
+
+func TestFieldEncryptionConfig_ToFieldKeys(t *testing.T) {
+	keys, err := FieldEncryptionConfig{
+		ActiveKeyID:   "k1",
+		Keys:          map[string]string{"k1": "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="},
+		BlindIndexKey: "CQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQk=",
+	}.ToFieldKeys()
+	if err != nil {
+		t.Fatalf("ToFieldKeys() error = %v", err)
+	}
+	if keys.ActiveKeyID != "k1" || len(keys.Keys["k1"]) != 32 || len(keys.BlindIndexKey) != 32 {
+		t.Errorf("ToFieldKeys() = %+v", keys)
+	}
+
+	invalid := []FieldEncryptionConfig{
+		{Keys: map[string]string{"k1": "not base64!"}},
+		{BlindIndexKey: "not base64!"},
+	}
+	for _, c := range invalid {
+		if _, err := c.ToFieldKeys(); err == nil {
+			t.Errorf("ToFieldKeys(%+v) error = nil, want error", c)
+		}
+	}
+}
+
+func TestAppConfig_ParseTrustedProxies(t *testing.T) {
+	ranges, err := AppConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::/32"}}.ParseTrustedProxies()
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	if len(ranges) != 2 || ranges[0].String() != "10.0.0.0/8" || ranges[1].String() != "2001:db8::/32" {
+		t.Errorf("ParseTrustedProxies() = %v", ranges)
+	}
+
+	if _, err := (AppConfig{TrustedProxies: []string{"10.0.0.1"}}).ParseTrustedProxies(); err == nil {
+		t.Errorf("ParseTrustedProxies() error = nil, want error")
+	}
+}
@@ -21,6 +21,7 @@ type ConfigMock struct {
 type AuthConfig struct {
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
+	MFATokenDuration     time.Duration
 	PasetoSymmetricKey   string
 }
 
@@ -48,6 +49,7 @@ func New() *ConfigMock {
 		Auth: AuthConfig{
 			AccessTokenDuration:  time.Minute * 15,
 			RefreshTokenDuration: time.Hour * 24,
+			MFATokenDuration:     time.Minute * 5,
 			PasetoSymmetricKey:   "d152a3402-4d32-85ad-19df4c9934cd",
 		},
 		DB: DBConfig{
@@ -100,6 +102,10 @@ func (c *ConfigMock) GetValidator(t *testing.T) apperrmap.Validator {
 	return v
 }
 
+func (c *ConfigMock) GetNotifier(ctrl *gomock.Controller) *mocks.MockNotifier {
+	return mocks.NewMockNotifier(ctrl)
+}
+
 func (c *ConfigMock) GetCrypto(ctrl *gomock.Controller) *mocks.MockCrypto {
 	return mocks.NewMockCrypto(ctrl)
 }
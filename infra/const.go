@@ -7,11 +7,25 @@ func (k Key) String() string {
 }
 
 const (
-	AccountUUIDKey Key = "AccountUUID"
-	TokenKey       Key = "user-token"
-	SessionKey     Key = "Session"
+	AccountUUIDKey     Key = "AccountUUID"
+	TokenKey           Key = "user-token"
+	SessionKey         Key = "Session"
+	RoleKey            Key = "Role"
+	IdempotencyKey     Key = "Idempotency-Key"
+	IdempotentReplayed Key = "Idempotent-Replayed"
+
+	RateLimitLimit     Key = "X-RateLimit-Limit"
+	RateLimitRemaining Key = "X-RateLimit-Remaining"
+	RateLimitReset     Key = "X-RateLimit-Reset"
 )
 
+// SessionStateKey is the cache key of the state of a session, which the
+// access tokens it handed out are checked against.
+func SessionStateKey(sessionUUID string) string {
+	return "session-state:" + sessionUUID
+}
+
 const (
-	TokenKeyDescription = "User access token"
+	TokenKeyDescription       = "User access token"
+	IdempotencyKeyDescription = "Unique key for the request; a retry with the same key and body gets the original response instead of running again"
 )
@@ -8,11 +8,15 @@ import (
 type TokenPayloadInput struct {
 	AccountUUID string
 	SessionUUID string
+	Role        string
 }
 
 type TokenPayload struct {
+	// ID is unique to each token, for keeping count of the uses of one
+	ID           string
 	AccountUUID  string
 	SessionUUID  string
+	Role         string
 	RefreshToken string
 	IssuedAt     time.Time
 	ExpiredAt    time.Time
@@ -21,5 +25,11 @@ type TokenPayload struct {
 type AuthToken interface {
 	CreateAccessToken(ctx context.Context, input TokenPayloadInput) (tokenString string, payload TokenPayload, err error)
 	CreateRefreshToken(ctx context.Context, input TokenPayloadInput) (tokenString string, payload TokenPayload, err error)
+	// VerifyToken verifies an access or a refresh token. A token of
+	// CreateMFAToken is refused.
 	VerifyToken(ctx context.Context, token string) (payload TokenPayload, err error)
+	// CreateMFAToken issues the token of a login waiting for its second
+	// factor, good only for VerifyMFAToken
+	CreateMFAToken(ctx context.Context, input TokenPayloadInput) (tokenString string, payload TokenPayload, err error)
+	VerifyMFAToken(ctx context.Context, token string) (payload TokenPayload, err error)
 }
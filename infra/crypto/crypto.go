@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"crypto/cipher"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
@@ -20,11 +21,28 @@ const (
 	argonKeyLength   = 32
 )
 
-type Client struct{}
+type Client struct {
+	activeKeyID   string
+	fieldCiphers  map[string]cipher.AEAD
+	blindIndexKey []byte
+}
+
+// NewCrypto returns a new crypto client, which encrypts fields with keys
+func NewCrypto(keys FieldKeys) (*Client, error) {
+	if err := keys.validate(); err != nil {
+		return nil, err
+	}
+
+	fieldCiphers, err := newFieldCiphers(keys.Keys)
+	if err != nil {
+		return nil, err
+	}
 
-// NewCrypto returns a new crypto client
-func NewCrypto() *Client {
-	return &Client{}
+	return &Client{
+		activeKeyID:   keys.ActiveKeyID,
+		fieldCiphers:  fieldCiphers,
+		blindIndexKey: keys.BlindIndexKey,
+	}, nil
 }
 
 // HashPassword returns the Argon2id hash of the password.
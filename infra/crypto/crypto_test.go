@@ -8,7 +8,7 @@ import (
 )
 
 func TestHashPassword(t *testing.T) {
-	c := NewCrypto()
+	c := newTestCrypto(t, "k1")
 
 	t.Run("Should return an argon2id hash", func(t *testing.T) {
 		hash, err := c.HashPassword("123456")
@@ -35,7 +35,7 @@ func TestHashPassword(t *testing.T) {
 }
 
 func TestCheckPassword(t *testing.T) {
-	c := NewCrypto()
+	c := newTestCrypto(t, "k1")
 
 	t.Run("Should verify correct password with argon2id hash", func(t *testing.T) {
 		password := "my-secure-password"
@@ -61,7 +61,7 @@ func TestCheckPassword(t *testing.T) {
 }
 
 func TestCheckPassword_RoundTrip(t *testing.T) {
-	c := NewCrypto()
+	c := newTestCrypto(t, "k1")
 
 	passwords := []string{
 		"simple",
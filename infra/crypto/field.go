@@ -0,0 +1,145 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// fieldPrefix marks a value encrypted by EncryptField. It is followed by the
+// id of the key and the base64 of the nonce and the sealed value:
+// enc:v1:<key id>:<nonce|ciphertext>
+const fieldPrefix = "enc:v1:"
+
+const fieldKeyLength = 32 // AES-256
+
+// FieldKeys are the keys of the field encryption.
+type FieldKeys struct {
+	// ActiveKeyID names the key new values are encrypted with
+	ActiveKeyID string
+	// Keys are all the keys a stored value may be encrypted with, by id. A
+	// rotated key stays here until no value is encrypted with it anymore.
+	Keys map[string][]byte
+	// BlindIndexKey is the HMAC key of the blind indexes. Changing it changes
+	// every index, so it isn't rotated along with Keys.
+	BlindIndexKey []byte
+}
+
+func (k FieldKeys) validate() error {
+	if _, ok := k.Keys[k.ActiveKeyID]; !ok {
+		return fmt.Errorf("the active field key %q is not one of the keys", k.ActiveKeyID)
+	}
+
+	for id, key := range k.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return fmt.Errorf("invalid field key id %q", id)
+		}
+		if len(key) != fieldKeyLength {
+			return fmt.Errorf("the field key %q must have %d bytes, it has %d", id, fieldKeyLength, len(key))
+		}
+	}
+
+	if len(k.BlindIndexKey) < fieldKeyLength {
+		return fmt.Errorf("the blind index key must have at least %d bytes", fieldKeyLength)
+	}
+
+	return nil
+}
+
+func newFieldCiphers(keys map[string][]byte) (map[string]cipher.AEAD, error) {
+	ciphers := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field key %q: %w", id, err)
+		}
+
+		ciphers[id], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field key %q: %w", id, err)
+		}
+	}
+
+	return ciphers, nil
+}
+
+// EncryptField encrypts value with AES-GCM under the active key. The id of
+// the key goes along with the result, so it still decrypts once the key is
+// rotated.
+func (c *Client) EncryptField(value string) (string, error) {
+	aead := c.fieldCiphers[c.activeKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// the key id is authenticated too, so a value can't be passed off as
+	// encrypted with another key
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(c.activeKeyID))
+
+	return fieldPrefix + c.activeKeyID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField reverses EncryptField. A value without the prefix of an
+// encrypted one was stored before the field was encrypted, and is returned as
+// it is until the encrypt-cpf command gets to it.
+func (c *Client) DecryptField(encrypted string) (string, error) {
+	rest, ok := strings.CutPrefix(encrypted, fieldPrefix)
+	if !ok {
+		return encrypted, nil
+	}
+
+	keyID, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid encrypted field format")
+	}
+
+	aead, ok := c.fieldCiphers[keyID]
+	if !ok {
+		return "", fmt.Errorf("unknown field key %q", keyID)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted field: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted field: too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	value, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+
+	return string(value), nil
+}
+
+// NeedsReencryption tells whether encrypted is still in plain text or under a
+// key other than the active one.
+func (c *Client) NeedsReencryption(encrypted string) bool {
+	rest, ok := strings.CutPrefix(encrypted, fieldPrefix)
+	if !ok {
+		return true
+	}
+
+	keyID, _, _ := strings.Cut(rest, ":")
+	return keyID != c.activeKeyID
+}
+
+// BlindIndex returns the HMAC-SHA256 of value, in hex. It is the same for the
+// same value, which makes an encrypted field searchable for an exact match
+// without decrypting it.
+func (c *Client) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, c.blindIndexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,123 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testFieldKeys = map[string][]byte{
+	"k1": bytes.Repeat([]byte{1}, fieldKeyLength),
+	"k2": bytes.Repeat([]byte{2}, fieldKeyLength),
+}
+
+func newTestCrypto(t *testing.T, activeKeyID string) *Client {
+	t.Helper()
+
+	c, err := NewCrypto(FieldKeys{
+		ActiveKeyID:   activeKeyID,
+		Keys:          testFieldKeys,
+		BlindIndexKey: bytes.Repeat([]byte{9}, fieldKeyLength),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestNewCrypto(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, fieldKeyLength)
+	indexKey := bytes.Repeat([]byte{9}, fieldKeyLength)
+
+	invalid := map[string]FieldKeys{
+		"Should refuse an active key that isn't one of the keys": {ActiveKeyID: "k2", Keys: map[string][]byte{"k1": key}, BlindIndexKey: indexKey},
+		"Should refuse a key of the wrong size":                  {ActiveKeyID: "k1", Keys: map[string][]byte{"k1": key[:16]}, BlindIndexKey: indexKey},
+		"Should refuse a key id with a colon":                    {ActiveKeyID: "k1", Keys: map[string][]byte{"k1": key, "k:2": key}, BlindIndexKey: indexKey},
+		"Should refuse a short blind index key":                  {ActiveKeyID: "k1", Keys: map[string][]byte{"k1": key}, BlindIndexKey: indexKey[:8]},
+	}
+	for name, keys := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewCrypto(keys)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptField(t *testing.T) {
+	c := newTestCrypto(t, "k1")
+
+	t.Run("Should round trip with the key id in the value", func(t *testing.T) {
+		encrypted, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(encrypted, "enc:v1:k1:"), encrypted)
+		require.NotContains(t, encrypted, "12345678909")
+
+		value, err := c.DecryptField(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "12345678909", value)
+	})
+
+	t.Run("Should use a new nonce every time", func(t *testing.T) {
+		encrypted1, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+		encrypted2, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+
+		require.NotEqual(t, encrypted1, encrypted2)
+	})
+
+	t.Run("Should decrypt a value of a rotated key", func(t *testing.T) {
+		encrypted, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+
+		rotated := newTestCrypto(t, "k2")
+		require.True(t, rotated.NeedsReencryption(encrypted))
+
+		value, err := rotated.DecryptField(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "12345678909", value)
+
+		reencrypted, err := rotated.EncryptField(value)
+		require.NoError(t, err)
+		require.False(t, rotated.NeedsReencryption(reencrypted))
+	})
+
+	t.Run("Should pass a value stored before encryption through", func(t *testing.T) {
+		require.True(t, c.NeedsReencryption("12345678909"))
+
+		value, err := c.DecryptField("12345678909")
+		require.NoError(t, err)
+		require.Equal(t, "12345678909", value)
+	})
+
+	t.Run("Should refuse a tampered value", func(t *testing.T) {
+		encrypted, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+
+		last := encrypted[len(encrypted)-1]
+		tampered := encrypted[:len(encrypted)-1] + string(last^1)
+		_, err = c.DecryptField(tampered)
+		require.Error(t, err)
+	})
+
+	t.Run("Should refuse a value moved to another key id", func(t *testing.T) {
+		encrypted, err := c.EncryptField("12345678909")
+		require.NoError(t, err)
+
+		_, err = c.DecryptField(strings.Replace(encrypted, ":k1:", ":k2:", 1))
+		require.Error(t, err)
+	})
+
+	t.Run("Should refuse an unknown key id", func(t *testing.T) {
+		_, err := c.DecryptField("enc:v1:k9:AAAA")
+		require.Error(t, err)
+	})
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := newTestCrypto(t, "k1")
+
+	require.Equal(t, c.BlindIndex("12345678909"), newTestCrypto(t, "k2").BlindIndex("12345678909"), "the index doesn't depend on the active key")
+	require.NotEqual(t, c.BlindIndex("12345678909"), c.BlindIndex("12345678900"))
+	require.Len(t, c.BlindIndex("12345678909"), 64)
+}
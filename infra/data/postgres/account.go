@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 )
@@ -24,10 +24,14 @@ const querySelectBase string = `
 			ta.account_uuid,
 			ta.name,
 			ta.cpf,
+			COALESCE(ta.cpf_index, ''),
 			ta.balance,
+			ta.currency,
 			ta.secret,
 			ta.created_at,
-			ta.active
+			ta.active,
+			ta.closed_at,
+			ta.role
 
 		FROM tab_account 				ta
 		`
@@ -46,41 +50,94 @@ func (r *accountRepo) scanAccountPage(total *int64) func(scanner) (entity.Accoun
 }
 
 func (r *accountRepo) parseAccount(row scanner, total ...*int64) (account entity.Account, err error) {
+	var balance int64
+	var currency string
+
 	dests := []any{
 		&account.ID,
 		&account.UUID,
 		&account.Name,
 		&account.CPF,
-		&account.Balance,
+		&account.CPFIndex,
+		&balance,
+		&currency,
 		&account.Password,
 		&account.CreatedAT,
 		&account.Active,
+		&account.ClosedAt,
+		&account.Role,
 	}
 
 	if len(total) > 0 && total[0] != nil {
 		dests = append(dests, total[0])
 	}
 
-	return account, row.Scan(dests...)
+	err = row.Scan(dests...)
+	if err != nil {
+		return account, err
+	}
+
+	account.Balance = entity.NewMoney(balance, entity.Currency(currency))
+	return account, nil
+}
+
+func (r *accountRepo) AddAccountStatusChange(ctx context.Context, change entity.AccountStatusChange) (changeID int64, err error) {
+	query := `
+		INSERT INTO tab_account_status_change (
+			account_id,
+			action,
+			reason,
+			sweep_transfer_id
+		)
+		VALUES ($1, $2, $3, NULLIF($4::INT, 0))
+		RETURNING account_status_change_id;
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		change.AccountID,
+		string(change.Action),
+		change.Reason,
+		change.SweepTransferID,
+	).Scan(&changeID)
+	if err != nil {
+		return changeID, handleDBError(err)
+	}
+
+	return changeID, nil
 }
 
-func (r *accountRepo) AddTransfer(ctx context.Context, transferUUID string, accountOriginID, accountDestinationID int64, amount float64) (transferID int64, err error) {
+// AddTransfer records transfer with the status and timestamps it carries. A
+// reversal is checked against its original by the caller, holding the lock
+// from GetTransferByUUIDForUpdate.
+func (r *accountRepo) AddTransfer(ctx context.Context, transfer entity.Transfer) (transferID int64, err error) {
 	query := `
 		INSERT INTO tab_transfer (
 			transfer_uuid,
 			account_origin_id,
 			account_destination_id,
-			amount
+			amount,
+			currency,
+			status,
+			failure_reason,
+			completed_at,
+			failed_at,
+			reversed_transfer_id
 		)
-		VALUES ($1, $2, $3, $4)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, NULLIF($10::INT, 0))
 		RETURNING transfer_id;
 	`
 
 	err = r.db.QueryRow(ctx, query,
-		transferUUID,
-		accountOriginID,
-		accountDestinationID,
-		amount,
+		transfer.TransferUUID,
+		transfer.AccountOriginID,
+		transfer.AccountDestinationID,
+		transfer.Amount.Amount(),
+		string(transfer.Amount.Currency()),
+		string(transfer.Status),
+		transfer.FailureReason,
+		transfer.CompletedAt,
+		transfer.FailedAt,
+		transfer.ReversedTransferID,
 	).Scan(&transferID)
 	if err != nil {
 		return transferID, handleDBError(err)
@@ -95,9 +152,11 @@ func (r *accountRepo) CreateAccount(ctx context.Context, account entity.Account)
 			account_uuid,
 			name,
 			cpf,
-			secret
+			cpf_index,
+			secret,
+			role
 		)
-		VALUES ($1, $2, $3, $4)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING account_id;
 	`
 
@@ -105,7 +164,9 @@ func (r *accountRepo) CreateAccount(ctx context.Context, account entity.Account)
 		account.UUID,
 		account.Name,
 		account.CPF,
+		account.CPFIndex,
 		account.Password,
+		account.Role,
 	).Scan(&createdID)
 	if err != nil {
 		return createdID, handleDBError(err)
@@ -114,40 +175,100 @@ func (r *accountRepo) CreateAccount(ctx context.Context, account entity.Account)
 	return createdID, nil
 }
 
-func (r *accountRepo) GetAccountByDocument(ctx context.Context, encryptedCPF string) (account entity.Account, err error) {
+func (r *accountRepo) GetAccountByDocument(ctx context.Context, cpfIndex, legacyCPF string) (account entity.Account, err error) {
 	query := querySelectBase + `
-		WHERE  	ta.cpf 	= $1
+		WHERE  	ta.cpf_index = $1
+			OR (ta.cpf_index IS NULL AND ta.cpf = $2)
 	`
 
-	return r.queryOne(ctx, query, r.scanAccount, encryptedCPF)
+	return r.queryOne(ctx, query, r.scanAccount, cpfIndex, legacyCPF)
 }
 
-func (r *accountRepo) GetAccounts(ctx context.Context, take, skip int64) (accounts []entity.Account, totalRecords int64, err error) {
-	var params = []any{}
-	paramIndex := 1
+func (r *accountRepo) GetAccountsAfterID(ctx context.Context, afterID, take int64) (accounts []entity.Account, err error) {
+	query := querySelectBase + `
+		WHERE 		ta.account_id > $1
+		ORDER BY 	ta.account_id
+		LIMIT 		$2
+	`
 
-	query := querySelectBase
+	return r.queryList(ctx, query, r.scanAccount, afterID, take)
+}
 
-	if take > 0 {
-		query += fmt.Sprintf(`
-			LIMIT $%d
-		`, paramIndex)
-		params = append(params, take)
-		paramIndex++
+// accountSortColumns are the columns a list of accounts can be sorted by.
+var accountSortColumns = map[string]string{
+	"name":       "ta.name",
+	"created_at": "ta.created_at",
+	"balance":    "ta.balance",
+}
+
+// accountSearchQuery selects the accounts that pass filter, leaving the
+// ordering and the paging to the caller.
+func accountSearchQuery(filter entity.AccountFilter) *sqlBuilder {
+	b := newSQLBuilder(querySelectBase).Where("NOT ta.is_system")
+
+	if filter.Name != "" {
+		b.Where("ta.name ILIKE " + b.Arg(containsPattern(filter.Name)))
 	}
 
-	if skip > 0 {
-		query += fmt.Sprintf(`
-			OFFSET $%d
-		`, paramIndex)
-		params = append(params, skip)
+	if filter.CPFIndex != "" {
+		b.Where("ta.cpf_index = " + b.Arg(filter.CPFIndex))
 	}
 
-	accounts, err = r.queryList(ctx, withCount(query), r.scanAccountPage(&totalRecords), params...)
+	if filter.Active != nil {
+		b.Where("ta.active = " + b.Arg(*filter.Active))
+	}
+
+	if filter.CreatedFrom != nil {
+		b.Where("ta.created_at >= " + b.Arg(*filter.CreatedFrom))
+	}
+
+	if filter.CreatedTo != nil {
+		b.Where("ta.created_at < " + b.Arg(*filter.CreatedTo))
+	}
+
+	return b
+}
+
+func (r *accountRepo) GetAccounts(ctx context.Context, filter entity.AccountFilter, take, skip int64) (accounts []entity.Account, totalRecords int64, err error) {
+	orderBy, err := sortColumns(filter.Sort, accountSortColumns, "ta.account_id")
+	if err != nil {
+		return accounts, totalRecords, err
+	}
+
+	b := accountSearchQuery(filter).OrderBy(orderBy...).Limit(take).Offset(skip)
+
+	accounts, err = r.queryList(ctx, withCount(b.Query()), r.scanAccountPage(&totalRecords), b.Args()...)
 
 	return accounts, totalRecords, err
 }
 
+func (r *accountRepo) GetAccountsByCursor(ctx context.Context, filter entity.AccountFilter, page entity.CursorPage) (accounts []entity.Account, result entity.CursorResult, err error) {
+	b := accountSearchQuery(filter)
+
+	if page.WithCount {
+		total, err := r.count(ctx, b.Query(), b.Args()...)
+		if err != nil {
+			return accounts, result, err
+		}
+		result.TotalRecords = &total
+	}
+
+	b.Keyset(page, "ta.created_at", "ta.account_id")
+
+	accounts, err = r.queryList(ctx, b.Query(), r.scanAccount, b.Args()...)
+	if err != nil {
+		return accounts, result, err
+	}
+
+	total := result.TotalRecords
+	accounts, result = keysetPage(accounts, page, func(account entity.Account) entity.Cursor {
+		return entity.Cursor{CreatedAt: account.CreatedAT, ID: account.ID}
+	})
+	result.TotalRecords = total
+
+	return accounts, result, nil
+}
+
 func (r *accountRepo) GetAccountByUUID(ctx context.Context, accountUUID string) (account entity.Account, err error) {
 	query := querySelectBase + `
 		WHERE ta.account_uuid = $1
@@ -156,6 +277,26 @@ func (r *accountRepo) GetAccountByUUID(ctx context.Context, accountUUID string)
 	return r.queryOne(ctx, query, r.scanAccount, accountUUID)
 }
 
+// GetAccountsByIDForUpdate reads the accounts and locks their rows until the
+// transaction ends. Rows are locked in account_id order whatever order the IDs
+// come in, so two transfers between the same accounts in opposite directions
+// wait for each other instead of deadlocking. Outside WithTransaction the lock
+// is released as soon as the statement returns.
+func (r *accountRepo) GetAccountsByIDForUpdate(ctx context.Context, accountIDs []int64) (accounts []entity.Account, err error) {
+	placeholders, params := buildInPlaceholders(nil, accountIDs, 1)
+	if placeholders == "" {
+		return []entity.Account{}, nil
+	}
+
+	query := querySelectBase + `
+		WHERE 	ta.account_id IN (` + placeholders + `)
+		ORDER BY ta.account_id
+		FOR UPDATE
+	`
+
+	return r.queryList(ctx, query, r.scanAccount, params...)
+}
+
 func (r *accountRepo) GetAccountIDByUUID(ctx context.Context, accountUUID string) (accountID int64, err error) {
 	query := `
 		SELECT
@@ -173,18 +314,134 @@ func (r *accountRepo) GetAccountIDByUUID(ctx context.Context, accountUUID string
 	return accountID, nil
 }
 
-func (r *accountRepo) GetTransfersByAccountID(ctx context.Context, accountID, take, skip int64, origin bool) (transfers []entity.Transfer, totalRecords int64, err error) {
-	var params = []any{}
-	paramIndex := 1
+func (r *accountRepo) GetAccountLimit(ctx context.Context, accountID int64) (limit entity.AccountLimit, err error) {
+	query := `
+		SELECT
+			account_id,
+			currency,
+			per_transaction,
+			daily,
+			monthly,
+			night_time
+
+		FROM 	tab_account_limit
+		WHERE	account_id 	= 	$1
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.AccountLimit, error) {
+		var limit entity.AccountLimit
+		var currency string
+		var perTransaction, daily, monthly, nightTime *int64
+
+		err := row.Scan(&limit.AccountID, &currency, &perTransaction, &daily, &monthly, &nightTime)
+		if err != nil {
+			return limit, err
+		}
+
+		limit.PerTransaction = limitAmount(perTransaction, currency)
+		limit.Daily = limitAmount(daily, currency)
+		limit.Monthly = limitAmount(monthly, currency)
+		limit.NightTime = limitAmount(nightTime, currency)
+		return limit, nil
+	}, accountID)
+}
+
+// limitAmount keeps a NULL cap of tab_account_limit as nil, for the default to
+// apply.
+func limitAmount(amount *int64, currency string) *entity.Money {
+	if amount == nil {
+		return nil
+	}
 
+	limit := entity.NewMoney(*amount, entity.Currency(currency))
+	return &limit
+}
+
+// GetTransferUsage counts the transfers the account made, and not the ones
+// that were declined or that give back a transfer it received. A period that
+// didn't start, as the night during the day, counts nothing.
+func (r *accountRepo) GetTransferUsage(ctx context.Context, accountID int64, currency entity.Currency, periods entity.TransferPeriods) (usage entity.TransferUsage, err error) {
 	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $4), 0),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $5), 0)
+
+		FROM 	tab_transfer
+
+		WHERE	account_origin_id 		= 	$1
+		  AND	currency 				= 	$2
+		  AND	status 					IN ($6, $7)
+		  AND	reversed_transfer_id 	IS 	NULL
+		  AND	created_at 				>= 	LEAST($3, $4, $5)
+	`
+
+	var daily, monthly, nightTime int64
+
+	err = r.db.QueryRow(ctx, query,
+		accountID,
+		string(currency),
+		periods.DayStart,
+		periods.MonthStart,
+		periods.NightStart,
+		string(entity.TransferCompleted),
+		string(entity.TransferReversed),
+	).Scan(&daily, &monthly, &nightTime)
+	if err != nil {
+		return usage, handleDBError(err)
+	}
+
+	usage.Daily = entity.NewMoney(daily, currency)
+	usage.Monthly = entity.NewMoney(monthly, currency)
+	usage.NightTime = entity.NewMoney(nightTime, currency)
+	return usage, nil
+}
+
+// GetReversedAmount sums every reversal of transferID, in the currency of the
+// transfer itself.
+func (r *accountRepo) GetReversedAmount(ctx context.Context, transferID int64) (reversed entity.Money, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(rev.amount), 0),
+			tt.currency
+
+		FROM 	tab_transfer 			tt
+
+		LEFT JOIN tab_transfer rev
+			ON rev.reversed_transfer_id = tt.transfer_id
+
+		WHERE	tt.transfer_id 			= 	$1
+
+		GROUP BY tt.currency
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.Money, error) {
+		var amount int64
+		var currency string
+
+		err := row.Scan(&amount, &currency)
+		return entity.NewMoney(amount, entity.Currency(currency)), err
+	}, transferID)
+}
+
+const queryTransferSelectBase string = `
 		SELECT
 			tt.transfer_id,
 			tt.transfer_uuid,
+			tt.account_origin_id,
 			origin.account_uuid AS account_origin_uuid,
+			tt.account_destination_id,
 			dest.account_uuid AS account_destination_uuid,
 			tt.amount,
-			tt.created_at
+			tt.currency,
+			tt.created_at,
+			tt.status,
+			COALESCE(tt.failure_reason, ''),
+			tt.completed_at,
+			tt.failed_at,
+			tt.reversed_at,
+			COALESCE(tt.reversed_transfer_id, 0),
+			COALESCE(reversed.transfer_uuid::VARCHAR, '')
 
 		FROM 	tab_transfer 			tt
 
@@ -194,65 +451,384 @@ func (r *accountRepo) GetTransfersByAccountID(ctx context.Context, accountID, ta
 		INNER JOIN tab_account dest
 			ON dest.account_id = tt.account_destination_id
 
-	`
+		LEFT JOIN tab_transfer reversed
+			ON reversed.transfer_id = tt.reversed_transfer_id
+		`
 
-	if origin {
-		query += fmt.Sprintf(`WHERE	tt.account_origin_id 		= 	$%d `, paramIndex)
-	} else {
-		query += fmt.Sprintf(`WHERE	tt.account_destination_id 	= 	$%d `, paramIndex)
+// parseTransfer scans a row of queryTransferSelectBase, and the count column
+// withCount appends when total is given.
+func (r *accountRepo) parseTransfer(row scanner, total ...*int64) (transfer entity.Transfer, err error) {
+	var amount int64
+	var currency string
+	var status string
+
+	dests := []any{
+		&transfer.ID,
+		&transfer.TransferUUID,
+		&transfer.AccountOriginID,
+		&transfer.AccountOriginUUID,
+		&transfer.AccountDestinationID,
+		&transfer.AccountDestinationUUID,
+		&amount,
+		&currency,
+		&transfer.CreatedAt,
+		&status,
+		&transfer.FailureReason,
+		&transfer.CompletedAt,
+		&transfer.FailedAt,
+		&transfer.ReversedAt,
+		&transfer.ReversedTransferID,
+		&transfer.ReversedTransferUUID,
+	}
+
+	if len(total) > 0 && total[0] != nil {
+		dests = append(dests, total[0])
+	}
+
+	err = row.Scan(dests...)
+	if err != nil {
+		return transfer, err
 	}
 
-	params = append(params, accountID)
-	paramIndex++
+	transfer.Amount = entity.NewMoney(amount, entity.Currency(currency))
+	transfer.Status = entity.TransferStatus(status)
+	return transfer, nil
+}
 
-	query += `
-		ORDER BY tt.created_at desc
+func (r *accountRepo) GetTransferByUUID(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error) {
+	query := queryTransferSelectBase + `
+		WHERE	tt.transfer_uuid 		= 	$1
 	`
 
-	if take > 0 {
-		query += fmt.Sprintf(`
-			LIMIT $%d
-		`, paramIndex)
-		params = append(params, take)
-		paramIndex++
-	}
-
-	if skip > 0 {
-		query += fmt.Sprintf(`
-			OFFSET $%d
-		`, paramIndex)
-		params = append(params, skip)
-	}
-
-	transfers, err = r.queryList(ctx, withCount(query), func(row scanner) (transfer entity.Transfer, err error) {
-		return transfer, row.Scan(
-			&transfer.ID,
-			&transfer.TransferUUID,
-			&transfer.AccountOriginUUID,
-			&transfer.AccountDestinationUUID,
-			&transfer.Amount,
-			&transfer.CreatedAt,
-			&totalRecords,
-		)
-	}, params...)
+	return r.queryOne(ctx, query, func(row scanner) (entity.Transfer, error) {
+		return r.parseTransfer(row)
+	}, transferUUID)
+}
+
+// GetTransferByUUIDForUpdate reads a transfer and locks its row until the end
+// of the transaction, so two reversals of it can't both see the same amount
+// left to reverse.
+func (r *accountRepo) GetTransferByUUIDForUpdate(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error) {
+	query := queryTransferSelectBase + `
+		WHERE	tt.transfer_uuid 		= 	$1
+
+		FOR UPDATE OF tt
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.Transfer, error) {
+		return r.parseTransfer(row)
+	}, transferUUID)
+}
+
+// transferHistoryQuery selects the transfers the account sent or received
+// that pass filter, leaving the ordering and the paging to the caller.
+func transferHistoryQuery(accountID int64, filter entity.TransferFilter) *sqlBuilder {
+	b := newSQLBuilder(queryTransferSelectBase)
+	account := b.Arg(accountID)
+
+	switch filter.Direction {
+	case entity.TransferSent:
+		b.Where("tt.account_origin_id = " + account)
+	case entity.TransferReceived:
+		b.Where("tt.account_destination_id = " + account)
+	default:
+		b.Where("(tt.account_origin_id = " + account + " OR tt.account_destination_id = " + account + ")")
+	}
+
+	if filter.CounterpartyUUID != "" {
+		b.Where(`CASE WHEN tt.account_origin_id = ` + account + `
+				THEN dest.account_uuid
+				ELSE origin.account_uuid
+			END = ` + b.Arg(filter.CounterpartyUUID))
+	}
+
+	if filter.CreatedFrom != nil {
+		b.Where("tt.created_at >= " + b.Arg(*filter.CreatedFrom))
+	}
+
+	if filter.CreatedTo != nil {
+		b.Where("tt.created_at < " + b.Arg(*filter.CreatedTo))
+	}
+
+	if filter.MinAmount.IsPositive() {
+		b.Where("tt.currency = " + b.Arg(string(filter.MinAmount.Currency())))
+		b.Where("tt.amount >= " + b.Arg(filter.MinAmount.Amount()))
+	}
+
+	if filter.MaxAmount.IsPositive() {
+		b.Where("tt.currency = " + b.Arg(string(filter.MaxAmount.Currency())))
+		b.Where("tt.amount <= " + b.Arg(filter.MaxAmount.Amount()))
+	}
+
+	return b
+}
+
+func (r *accountRepo) GetTransfersByAccountID(ctx context.Context, accountID int64, filter entity.TransferFilter, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error) {
+	// the id breaks ties between transfers made at the same time, so pages
+	// never overlap nor skip one of them
+	b := transferHistoryQuery(accountID, filter).
+		OrderBy("tt.created_at DESC", "tt.transfer_id DESC").
+		Limit(take).
+		Offset(skip)
+
+	transfers, err = r.queryList(ctx, withCount(b.Query()), func(row scanner) (entity.Transfer, error) {
+		return r.parseTransfer(row, &totalRecords)
+	}, b.Args()...)
 
 	return transfers, totalRecords, err
 }
 
-func (r *accountRepo) UpdateAccountBalance(ctx context.Context, accountID int64, balance float64) (err error) {
+func (r *accountRepo) GetTransfersByAccountIDCursor(ctx context.Context, accountID int64, filter entity.TransferFilter, page entity.CursorPage) (transfers []entity.Transfer, result entity.CursorResult, err error) {
+	b := transferHistoryQuery(accountID, filter)
+
+	if page.WithCount {
+		total, err := r.count(ctx, b.Query(), b.Args()...)
+		if err != nil {
+			return transfers, result, err
+		}
+		result.TotalRecords = &total
+	}
+
+	b.Keyset(page, "tt.created_at", "tt.transfer_id")
+
+	transfers, err = r.queryList(ctx, b.Query(), func(row scanner) (entity.Transfer, error) {
+		return r.parseTransfer(row)
+	}, b.Args()...)
+	if err != nil {
+		return transfers, result, err
+	}
+
+	total := result.TotalRecords
+	transfers, result = keysetPage(transfers, page, func(transfer entity.Transfer) entity.Cursor {
+		return entity.Cursor{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+	})
+	result.TotalRecords = total
+
+	return transfers, result, nil
+}
+
+func (r *accountRepo) UpdateAccountPassword(ctx context.Context, accountID int64, hashedPassword string) (err error) {
 	query := `
-		UPDATE 	tab_account
+		UPDATE tab_account
+		SET secret 		= $2,
+			update_at 	= NOW()
+		WHERE account_id = $1;
+	`
+
+	result, err := r.db.Exec(ctx, query, accountID, hashedPassword)
+	if err != nil {
+		return handleDBError(err)
+	}
 
-		SET 	balance 	= $1,
-				update_at 	= NOW()
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
 
-		WHERE  	account_id 	= $2
+	return nil
+}
+
+func (r *accountRepo) UpdateAccountCPF(ctx context.Context, account entity.Account, fromCPF string) (updated bool, err error) {
+	query := `
+		UPDATE tab_account
+		SET cpf 		= $2,
+			cpf_index 	= $3,
+			update_at 	= NOW()
+		WHERE account_id = $1
+			AND cpf = $4;
+	`
+
+	result, err := r.db.Exec(ctx, query, account.ID, account.CPF, account.CPFIndex, fromCPF)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *accountRepo) UpdateAccountProfile(ctx context.Context, account entity.Account) (err error) {
+	query := `
+		UPDATE tab_account
+		SET name 		= $2,
+			update_at 	= NOW()
+		WHERE account_id = $1;
+	`
+
+	result, err := r.db.Exec(ctx, query, account.ID, account.Name)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *accountRepo) UpdateAccountRole(ctx context.Context, accountID int64, role entity.Role) (err error) {
+	query := `
+		UPDATE tab_account
+		SET role 		= $2,
+			update_at 	= NOW()
+		WHERE account_id = $1;
 	`
 
-	_, err = r.db.Exec(ctx, query, balance, accountID)
+	result, err := r.db.Exec(ctx, query, accountID, role)
 	if err != nil {
 		return handleDBError(err)
 	}
 
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
+
 	return nil
 }
+
+func (r *accountRepo) UpdateAccountStatus(ctx context.Context, account entity.Account) (err error) {
+	query := `
+		UPDATE tab_account
+		SET active 		= $2,
+			closed_at 	= $3,
+			update_at 	= NOW()
+		WHERE account_id = $1;
+	`
+
+	result, err := r.db.Exec(ctx, query, account.ID, account.Active, account.ClosedAt)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UpdateTransferStatus writes the status transfer moved to, with its
+// timestamps and failure reason, as long as the row is still in from. updated
+// is false when it isn't: someone else moved it first.
+func (r *accountRepo) UpdateTransferStatus(ctx context.Context, transfer entity.Transfer, from entity.TransferStatus) (updated bool, err error) {
+	query := `
+		UPDATE tab_transfer
+		SET status 			= $2,
+			failure_reason 	= NULLIF($3, ''),
+			completed_at 	= $4,
+			failed_at 		= $5,
+			reversed_at 	= $6,
+			update_at 		= NOW()
+		WHERE transfer_id 	= $1
+		  AND status 		= $7;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		transfer.ID,
+		string(transfer.Status),
+		transfer.FailureReason,
+		transfer.CompletedAt,
+		transfer.FailedAt,
+		transfer.ReversedAt,
+		string(from),
+	)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// CreditAccountBalance posts entry as a credit to its account and adds the
+// amount to the cached balance in the same statement. The balance is changed
+// relatively, so concurrent credits can't overwrite each other. The posting
+// needs its counterpart in the same transaction, or the commit fails.
+func (r *accountRepo) CreditAccountBalance(ctx context.Context, entry entity.LedgerEntry) (err error) {
+	query := `
+		WITH updated AS (
+			UPDATE 	tab_account
+
+			SET 	balance 	= balance + $1,
+					update_at 	= NOW()
+
+			WHERE  	account_id 	= $2
+			  AND 	currency 	= $3
+
+			RETURNING account_id
+		)
+
+		INSERT INTO tab_ledger_entry (
+			journal_uuid,
+			account_id,
+			transfer_id,
+			entry_type,
+			amount,
+			currency
+		)
+		SELECT $4::UUID, account_id, NULLIF($5::INT, 0), $6::VARCHAR, $1, $3
+		FROM updated
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		entry.Amount.Amount(),
+		entry.AccountID,
+		string(entry.Amount.Currency()),
+		entry.JournalUUID,
+		entry.TransferID,
+		string(entry.Type),
+	)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DebitAccountBalance posts entry as a debit to its account and subtracts the
+// amount from the cached balance, only while the balance covers it. debited is
+// false when it doesn't, and neither the posting nor the balance is written.
+// System accounts have no such floor: the funding account is negative by design.
+func (r *accountRepo) DebitAccountBalance(ctx context.Context, entry entity.LedgerEntry) (debited bool, err error) {
+	query := `
+		WITH updated AS (
+			UPDATE 	tab_account
+
+			SET 	balance 	= balance - $1,
+					update_at 	= NOW()
+
+			WHERE  	account_id 	= $2
+			  AND 	currency 	= $3
+			  AND 	(balance >= $1 OR is_system)
+
+			RETURNING account_id
+		)
+
+		INSERT INTO tab_ledger_entry (
+			journal_uuid,
+			account_id,
+			transfer_id,
+			entry_type,
+			amount,
+			currency
+		)
+		SELECT $4::UUID, account_id, NULLIF($5::INT, 0), $6::VARCHAR, -$1::BIGINT, $3
+		FROM updated
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		entry.Amount.Amount(),
+		entry.AccountID,
+		string(entry.Amount.Currency()),
+		entry.JournalUUID,
+		entry.TransferID,
+		string(entry.Type),
+	)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
@@ -2,27 +2,55 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/infra/configmock"
 	"github.com/diegoclair/go_boilerplate/infra/crypto"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/application/service"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/mocks"
 	"github.com/diegoclair/go_boilerplate/util/random"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
+// newTestCrypto encrypts with keys of zeros, as the repo only stores what it
+// is given.
+func newTestCrypto(t *testing.T) *crypto.Client {
+	c, err := crypto.NewCrypto(crypto.FieldKeys{
+		ActiveKeyID:   "test",
+		Keys:          map[string][]byte{"test": make([]byte, 32)},
+		BlindIndexKey: make([]byte, 32),
+	})
+	require.NoError(t, err)
+	return c
+}
+
 func createRandomAccount(t *testing.T) entity.Account {
+	c := newTestCrypto(t)
+	cpf := random.RandomCPF()
+
 	args := entity.Account{
-		UUID: uuid.Must(uuid.NewV7()).String(),
-		Name: random.RandomName(),
-		CPF:  random.RandomCPF(),
+		UUID:     uuid.Must(uuid.NewV7()).String(),
+		Name:     random.RandomName(),
+		CPFIndex: c.BlindIndex(cpf),
+		Role:     entity.RoleCustomer,
 	}
 
-	var (
-		c   = crypto.NewCrypto()
-		err error
-	)
+	var err error
+
+	args.CPF, err = c.EncryptField(cpf)
+	require.NoError(t, err)
 
 	args.Password, err = c.HashPassword(random.RandomPassword())
 	require.NoError(t, err)
@@ -40,11 +68,13 @@ func createRandomAccount(t *testing.T) entity.Account {
 }
 
 func validateTwoAccounts(t *testing.T, accountExpected entity.Account, accountToCompare entity.Account) {
-	require.Equal(t, float64(0), accountExpected.Balance)
+	require.True(t, accountExpected.Balance.IsZero())
 	require.Equal(t, accountExpected.UUID, accountToCompare.UUID)
 	require.Equal(t, accountExpected.Name, accountToCompare.Name)
 	require.Equal(t, accountExpected.CPF, accountToCompare.CPF)
+	require.Equal(t, accountExpected.CPFIndex, accountToCompare.CPFIndex)
 	require.Equal(t, accountExpected.Password, accountToCompare.Password)
+	require.Equal(t, accountExpected.Role, accountToCompare.Role)
 	require.NotZero(t, accountToCompare.ID)
 	require.WithinDuration(t, time.Now(), accountToCompare.CreatedAT, 2*time.Second)
 }
@@ -53,14 +83,196 @@ func TestCreateAccount(t *testing.T) {
 	createRandomAccount(t)
 }
 
+func TestGetAccountsByCursor(t *testing.T) {
+	ctx := context.Background()
+
+	a := createRandomAccount(t)
+	b := createRandomAccount(t)
+	c := createRandomAccount(t)
+
+	accountUUIDs := func(list []entity.Account) (uuids []string) {
+		for _, account := range list {
+			uuids = append(uuids, account.UUID)
+		}
+		return uuids
+	}
+
+	first, result, err := testDB.Account().GetAccountsByCursor(ctx, entity.AccountFilter{}, entity.CursorPage{Limit: 1, WithCount: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{c.UUID}, accountUUIDs(first))
+	require.NotNil(t, result.TotalRecords)
+	require.LessOrEqual(t, int64(3), *result.TotalRecords)
+	require.Nil(t, result.Prev)
+	require.NotNil(t, result.Next)
+
+	after := entity.Cursor{CreatedAt: c.CreatedAT, ID: c.ID}
+	page, result, err := testDB.Account().GetAccountsByCursor(ctx, entity.AccountFilter{}, entity.CursorPage{After: &after, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{b.UUID, a.UUID}, accountUUIDs(page))
+	require.Nil(t, result.TotalRecords)
+	require.Equal(t, b.ID, result.Prev.ID)
+
+	// and back from the last one read
+	before := entity.Cursor{CreatedAt: a.CreatedAT, ID: a.ID}
+	page, result, err = testDB.Account().GetAccountsByCursor(ctx, entity.AccountFilter{}, entity.CursorPage{Before: &before, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{c.UUID, b.UUID}, accountUUIDs(page))
+	require.Nil(t, result.Prev)
+	require.Equal(t, b.ID, result.Next.ID)
+}
+
+func TestGetAccountsSearch(t *testing.T) {
+	ctx := context.Background()
+
+	// a name no other test makes, to only find the accounts made here
+	surname := "Zq" + uuid.Must(uuid.NewV7()).String()[24:]
+
+	names := []string{"Ana " + surname, "Bruno " + surname, "Carla " + surname}
+	accounts := make([]entity.Account, 0, len(names))
+	for _, name := range names {
+		account := createRandomAccount(t)
+		_, err := testDB.(*PostgresConn).Pool().Exec(ctx, `UPDATE tab_account SET name = $1 WHERE account_id = $2`, name, account.ID)
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	_, err := testDB.(*PostgresConn).Pool().Exec(ctx, `UPDATE tab_account SET active = false WHERE account_id = $1`, accounts[2].ID)
+	require.NoError(t, err)
+
+	accountIDs := func(list []entity.Account) (ids []int64) {
+		for _, account := range list {
+			ids = append(ids, account.ID)
+		}
+		return ids
+	}
+
+	t.Run("Should match part of the name whatever the case", func(t *testing.T) {
+		list, total, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{Name: strings.ToUpper(surname)}, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), total)
+		require.ElementsMatch(t, accountIDs(accounts), accountIDs(list))
+	})
+
+	t.Run("Should sort by the fields asked for", func(t *testing.T) {
+		list, _, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{
+			Name: surname,
+			Sort: []entity.SortField{{Field: "name", Descending: true}},
+		}, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, []int64{accounts[2].ID, accounts[1].ID, accounts[0].ID}, accountIDs(list))
+	})
+
+	t.Run("Should filter by the active flag", func(t *testing.T) {
+		inactive := false
+		list, _, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{Name: surname, Active: &inactive}, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, []int64{accounts[2].ID}, accountIDs(list))
+	})
+
+	t.Run("Should filter by the blind index of the cpf", func(t *testing.T) {
+		list, _, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{Name: surname, CPFIndex: accounts[1].CPFIndex}, 10, 0)
+		require.NoError(t, err)
+		require.Equal(t, []int64{accounts[1].ID}, accountIDs(list))
+	})
+
+	t.Run("Should take wildcards in the name literally", func(t *testing.T) {
+		list, total, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{Name: "%"}, 10, 0)
+		require.NoError(t, err)
+		require.Zero(t, total)
+		require.Empty(t, list)
+	})
+
+	t.Run("Should filter by creation date when paging by cursor", func(t *testing.T) {
+		from := accounts[1].CreatedAT
+		list, result, err := testDB.Account().GetAccountsByCursor(ctx, entity.AccountFilter{Name: surname, CreatedFrom: &from}, entity.CursorPage{Limit: 10, WithCount: true})
+		require.NoError(t, err)
+		require.Equal(t, []int64{accounts[2].ID, accounts[1].ID}, accountIDs(list))
+		require.Equal(t, int64(2), *result.TotalRecords)
+	})
+
+	t.Run("Should refuse a sort it does not know", func(t *testing.T) {
+		_, _, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{Sort: []entity.SortField{{Field: "secret"}}}, 10, 0)
+		require.Error(t, err)
+	})
+}
+
+// createLegacyAccount stores an account the way it was before the cpf was
+// encrypted: in plain text, without an index.
+func createLegacyAccount(t *testing.T) entity.Account {
+	args := entity.Account{
+		UUID:     uuid.Must(uuid.NewV7()).String(),
+		Name:     random.RandomName(),
+		CPF:      random.RandomCPF(),
+		Password: "secret",
+		Role:     entity.RoleCustomer,
+	}
+
+	_, err := testDB.Account().CreateAccount(context.Background(), args)
+	require.NoError(t, err)
+
+	account, err := testDB.Account().GetAccountByUUID(context.Background(), args.UUID)
+	require.NoError(t, err)
+	require.Empty(t, account.CPFIndex)
+
+	return account
+}
+
 func TestGetAccountByDocument(t *testing.T) {
-	account := createRandomAccount(t)
+	ctx := context.Background()
+	c := newTestCrypto(t)
 
-	account2, err := testDB.Account().GetAccountByDocument(context.Background(), account.CPF)
+	t.Run("Should find the account by the blind index", func(t *testing.T) {
+		account := createRandomAccount(t)
+
+		account2, err := testDB.Account().GetAccountByDocument(ctx, account.CPFIndex, "")
+		require.NoError(t, err)
+		validateTwoAccounts(t, account, account2)
+	})
+
+	t.Run("Should find an account not indexed yet by its plain cpf", func(t *testing.T) {
+		account := createLegacyAccount(t)
+
+		account2, err := testDB.Account().GetAccountByDocument(ctx, c.BlindIndex(account.CPF), account.CPF)
+		require.NoError(t, err)
+		require.Equal(t, account.ID, account2.ID)
+	})
+
+	t.Run("Should not match an encrypted cpf by plain text", func(t *testing.T) {
+		account := createRandomAccount(t)
+
+		_, err := testDB.Account().GetAccountByDocument(ctx, "unknown", account.CPF)
+		require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+	})
+}
+
+func TestUpdateAccountCPF(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCrypto(t)
+
+	legacy := createLegacyAccount(t)
+
+	accounts, err := testDB.Account().GetAccountsAfterID(ctx, legacy.ID-1, 1)
 	require.NoError(t, err)
-	require.NotEmpty(t, account2)
+	require.Len(t, accounts, 1)
+	require.Equal(t, legacy.ID, accounts[0].ID)
 
-	validateTwoAccounts(t, account, account2)
+	encrypted := legacy
+	encrypted.CPF, err = c.EncryptField(legacy.CPF)
+	require.NoError(t, err)
+	encrypted.CPFIndex = c.BlindIndex(legacy.CPF)
+
+	updated, err := testDB.Account().UpdateAccountCPF(ctx, encrypted, "changed meanwhile")
+	require.NoError(t, err)
+	require.False(t, updated)
+
+	updated, err = testDB.Account().UpdateAccountCPF(ctx, encrypted, legacy.CPF)
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	found, err := testDB.Account().GetAccountByDocument(ctx, encrypted.CPFIndex, legacy.CPF)
+	require.NoError(t, err)
+	require.Equal(t, encrypted.CPF, found.CPF)
+	require.Equal(t, encrypted.CPFIndex, found.CPFIndex)
 }
 
 func TestGetAccounts(t *testing.T) {
@@ -70,14 +282,14 @@ func TestGetAccounts(t *testing.T) {
 	ctx := context.Background()
 
 	// assert first account created
-	accounts, totalRecords, err := testDB.Account().GetAccounts(ctx, 10, 0)
+	accounts, totalRecords, err := testDB.Account().GetAccounts(ctx, entity.AccountFilter{}, 10, 0)
 	require.NoError(t, err)
 	require.NotEmpty(t, accounts)
 
 	require.LessOrEqual(t, 2, len(accounts))
 	require.NotZero(t, totalRecords)
 
-	require.Equal(t, float64(0), accounts[0].Balance)
+	require.Equal(t, entity.NewMoney(0, entity.BRL), accounts[0].Balance)
 	require.NotEmpty(t, accounts[0].UUID)
 	require.NotEmpty(t, accounts[0].Name)
 	require.NotEmpty(t, accounts[0].CPF)
@@ -86,14 +298,14 @@ func TestGetAccounts(t *testing.T) {
 	require.NotZero(t, accounts[0].CreatedAT)
 
 	// assert second account created
-	accounts, totalRecords, err = testDB.Account().GetAccounts(ctx, 10, 1)
+	accounts, totalRecords, err = testDB.Account().GetAccounts(ctx, entity.AccountFilter{}, 10, 1)
 	require.NoError(t, err)
 	require.NotEmpty(t, accounts)
 
 	require.LessOrEqual(t, 1, len(accounts))
 	require.NotZero(t, totalRecords)
 
-	require.Equal(t, float64(0), accounts[0].Balance)
+	require.Equal(t, entity.NewMoney(0, entity.BRL), accounts[0].Balance)
 	require.NotEmpty(t, accounts[0].UUID)
 	require.NotEmpty(t, accounts[0].Name)
 	require.NotEmpty(t, accounts[0].CPF)
@@ -121,28 +333,354 @@ func TestGetAccountIDByUUID(t *testing.T) {
 	require.Equal(t, account.ID, accountID)
 }
 
+func TestUpdateAccountProfile(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	account.Name = "Renamed " + account.Name
+	require.NoError(t, testDB.Account().UpdateAccountProfile(ctx, account))
+
+	got, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.Equal(t, account.Name, got.Name)
+
+	err = testDB.Account().UpdateAccountProfile(ctx, entity.Account{ID: -1, Name: "nobody"})
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestUpdateAccountRole(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	require.NoError(t, testDB.Account().UpdateAccountRole(ctx, account.ID, entity.RoleAdmin))
+
+	got, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleAdmin, got.Role)
+
+	err = testDB.Account().UpdateAccountRole(ctx, -1, entity.RoleAdmin)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestUpdateAccountPassword(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	require.NoError(t, testDB.Account().UpdateAccountPassword(ctx, account.ID, "new-hashed-password"))
+
+	got, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.Equal(t, "new-hashed-password", got.Password)
+
+	err = testDB.Account().UpdateAccountPassword(ctx, -1, "new-hashed-password")
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestAccountStatusLifecycle(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	require.True(t, account.Active)
+	require.Nil(t, account.ClosedAt)
+
+	account.Active = false
+	require.NoError(t, testDB.Account().UpdateAccountStatus(ctx, account))
+
+	changeID, err := testDB.Account().AddAccountStatusChange(ctx, entity.AccountStatusChange{
+		AccountID: account.ID,
+		Action:    entity.AccountDeactivated,
+		Reason:    "customer asked",
+	})
+	require.NoError(t, err)
+	require.NotZero(t, changeID)
+
+	got, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.False(t, got.Active)
+	require.Nil(t, got.ClosedAt)
+
+	closedAt := time.Now()
+	account.ClosedAt = &closedAt
+	require.NoError(t, testDB.Account().UpdateAccountStatus(ctx, account))
+
+	got, err = testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.True(t, got.IsClosed())
+	require.WithinDuration(t, closedAt, *got.ClosedAt, time.Second)
+
+	err = testDB.Account().UpdateAccountStatus(ctx, entity.Account{ID: -1})
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestCloseAccountWithBalanceIsRefused(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	depositToAccount(t, account.ID, entity.NewMoney(100, entity.BRL))
+
+	// the table itself keeps a closed account from holding money
+	closedAt := time.Now()
+	account.Active = false
+	account.ClosedAt = &closedAt
+	require.Error(t, testDB.Account().UpdateAccountStatus(ctx, account))
+}
+
+func newCompletedTransfer(transferUUID string, fromID, toID int64, amount entity.Money) entity.Transfer {
+	completedAt := time.Now()
+	return entity.Transfer{
+		TransferUUID:         transferUUID,
+		AccountOriginID:      fromID,
+		AccountDestinationID: toID,
+		Amount:               amount,
+		Status:               entity.TransferCompleted,
+		CompletedAt:          &completedAt,
+	}
+}
+
 func TestAddTransfer(t *testing.T) {
 	account := createRandomAccount(t)
 	account2 := createRandomAccount(t)
 
 	transferUUID := uuid.Must(uuid.NewV7()).String()
-	transferID, err := testDB.Account().AddTransfer(context.Background(), transferUUID, account.ID, account2.ID, 50)
+	transferID, err := testDB.Account().AddTransfer(context.Background(), newCompletedTransfer(transferUUID, account.ID, account2.ID, entity.NewMoney(5000, entity.BRL)))
 	require.NoError(t, err)
 	require.NotZero(t, transferID)
 }
 
-func TestUpdateBalance(t *testing.T) {
+func TestTransferStatusLifecycle(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	pending := entity.Transfer{
+		TransferUUID:         uuid.Must(uuid.NewV7()).String(),
+		AccountOriginID:      account.ID,
+		AccountDestinationID: account2.ID,
+		Amount:               entity.NewMoney(5000, entity.BRL),
+		Status:               entity.TransferPending,
+	}
+
+	transferID, err := testDB.Account().AddTransfer(ctx, pending)
+	require.NoError(t, err)
+	pending.ID = transferID
+
+	transfer, err := testDB.Account().GetTransferByUUID(ctx, pending.TransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TransferPending, transfer.Status)
+	require.Equal(t, account.UUID, transfer.AccountOriginUUID)
+	require.Equal(t, account2.UUID, transfer.AccountDestinationUUID)
+	require.Nil(t, transfer.CompletedAt)
+	require.Nil(t, transfer.FailedAt)
+
+	failed := pending
+	require.NoError(t, failed.Fail("insufficient funds", time.Now()))
+
+	updated, err := testDB.Account().UpdateTransferStatus(ctx, failed, entity.TransferPending)
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	// the transfer is no longer pending, so a second move from pending is a no-op
+	completed := pending
+	require.NoError(t, completed.TransitionTo(entity.TransferCompleted, time.Now()))
+
+	updated, err = testDB.Account().UpdateTransferStatus(ctx, completed, entity.TransferPending)
+	require.NoError(t, err)
+	require.False(t, updated)
+
+	transfer, err = testDB.Account().GetTransferByUUID(ctx, pending.TransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TransferFailed, transfer.Status)
+	require.Equal(t, "insufficient funds", transfer.FailureReason)
+	require.NotNil(t, transfer.FailedAt)
+	require.Nil(t, transfer.CompletedAt)
+
+	_, err = testDB.Account().GetTransferByUUID(ctx, uuid.Must(uuid.NewV7()).String())
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestCreditAndDebitAccountBalance(t *testing.T) {
 	ctx := context.Background()
 	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
 
-	var balance float64 = 12
-	err := testDB.Account().UpdateAccountBalance(ctx, account.ID, balance)
+	depositToAccount(t, account.ID, entity.NewMoney(1200, entity.BRL))
+
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		posting := entity.LedgerEntry{
+			JournalUUID: uuid.Must(uuid.NewV7()).String(),
+			Type:        entity.LedgerEntryTransfer,
+			Amount:      entity.NewMoney(200, entity.BRL),
+		}
+
+		posting.AccountID = account.ID
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+		require.NoError(t, err)
+		require.True(t, debited)
+
+		// the guard keeps the balance from going below zero
+		overdraft := posting
+		overdraft.Amount = entity.NewMoney(1001, entity.BRL)
+		debited, err = tx.Account().DebitAccountBalance(ctx, overdraft)
+		require.NoError(t, err)
+		require.False(t, debited)
+
+		posting.AccountID = account2.ID
+		return tx.Account().CreditAccountBalance(ctx, posting)
+	})
 	require.NoError(t, err)
 
 	updatedAccount, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
 	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(1000, entity.BRL), updatedAccount.Balance)
+
+	updatedAccount2, err := testDB.Account().GetAccountByUUID(ctx, account2.UUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(200, entity.BRL), updatedAccount2.Balance)
+
+	// the refused debit left no posting behind
+	require.Len(t, ledgerEntries(t, account.ID), 2)
+
+	err = testDB.Account().CreditAccountBalance(ctx, entity.LedgerEntry{
+		JournalUUID: uuid.Must(uuid.NewV7()).String(),
+		AccountID:   -1,
+		Type:        entity.LedgerEntryDeposit,
+		Amount:      entity.NewMoney(1200, entity.BRL),
+	})
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestGetAccountsByIDForUpdate(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		accounts, err := tx.Account().GetAccountsByIDForUpdate(ctx, []int64{account2.ID, account.ID})
+		require.NoError(t, err)
+		require.Len(t, accounts, 2)
+
+		// rows come back, and are locked, in account_id order
+		require.Equal(t, account.ID, accounts[0].ID)
+		require.Equal(t, account2.ID, accounts[1].ID)
+		validateTwoAccounts(t, account, accounts[0])
+		return nil
+	})
+	require.NoError(t, err)
 
-	require.Equal(t, balance, updatedAccount.Balance)
+	accounts, err := testDB.Account().GetAccountsByIDForUpdate(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
+// newTestTransferApp is the real transfer service over the test database,
+// with no transfer limits. A transfer reaches none of the mocked dependencies.
+func newTestTransferApp(t *testing.T) contract.TransferApp {
+	ctrl := gomock.NewController(t)
+	cfg := configmock.New()
+
+	infraMock := mocks.NewMockInfrastructure(ctrl)
+	infraMock.EXPECT().DataManager().Return(testDB).AnyTimes()
+	infraMock.EXPECT().Logger().Return(cfg.GetLogger()).AnyTimes()
+	infraMock.EXPECT().CacheManager().Return(cfg.GetCacheManager(ctrl)).AnyTimes()
+	infraMock.EXPECT().Crypto().Return(cfg.GetCrypto(ctrl)).AnyTimes()
+	infraMock.EXPECT().Notifier().Return(cfg.GetNotifier(ctrl)).AnyTimes()
+	infraMock.EXPECT().Validator().Return(cfg.GetValidator(t)).AnyTimes()
+
+	apps, err := service.New(infraMock, time.Minute, time.Minute, entity.TransferLimits{}, entity.LoginThrottle{}, "test", 0)
+	require.NoError(t, err)
+
+	return apps.TransferService
+}
+
+func TestConcurrentTransfersConserveTotal(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		transfers      = 40
+		initialBalance = 1000
+	)
+
+	accounts := []entity.Account{createRandomAccount(t), createRandomAccount(t), createRandomAccount(t)}
+	for _, account := range accounts {
+		depositToAccount(t, account.ID, entity.NewMoney(initialBalance, entity.BRL))
+	}
+
+	transferApp := newTestTransferApp(t)
+	amount := entity.NewMoney(300, entity.BRL)
+
+	transfer := func(from, to entity.Account) error {
+		// the service sends from the logged account
+		loggedCtx := context.WithValue(ctx, infra.AccountUUIDKey, from.UUID)
+		_, err := transferApp.CreateTransfer(loggedCtx, dto.TransferInput{AccountDestinationUUID: to.UUID, Amount: amount})
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, transfers)
+	start := make(chan struct{})
+
+	for i := range transfers {
+		// every pair of accounts is transferred in both directions at once, the
+		// case that deadlocks when rows are locked in request order
+		from, to := accounts[i%3], accounts[(i+1)%3]
+		if i%2 == 1 {
+			from, to = to, from
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- transfer(from, to)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		if errors.Is(err, errcodes.ErrInsufficientFunds) {
+			continue
+		}
+		require.NoError(t, err)
+		succeeded++
+	}
+	require.NotZero(t, succeeded)
+
+	// a declined transfer is recorded too, as failed
+	completed := func(transfers []entity.Transfer) (count int64) {
+		for _, transfer := range transfers {
+			if transfer.Status == entity.TransferCompleted {
+				count++
+			}
+		}
+		return count
+	}
+
+	var total int64
+	var recorded int64
+	for _, account := range accounts {
+		current, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+		require.NoError(t, err)
+		require.False(t, current.Balance.IsNegative())
+		total += current.Balance.Amount()
+
+		// the balance is exactly what the completed transfers add up to
+		made, _, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{Direction: entity.TransferSent}, 0, 0)
+		require.NoError(t, err)
+		received, _, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{Direction: entity.TransferReceived}, 0, 0)
+		require.NoError(t, err)
+
+		expected := int64(initialBalance) + completed(received)*amount.Amount() - completed(made)*amount.Amount()
+		require.Equal(t, expected, current.Balance.Amount())
+		recorded += completed(made)
+
+		// and the cached balance agrees with the ledger
+		require.Equal(t, current.Balance, ledgerBalance(t, account.ID))
+	}
+
+	require.Equal(t, int64(len(accounts)*initialBalance), total)
+	require.Equal(t, int64(succeeded), recorded)
 }
 
 func TestGetTransfersByAccountID(t *testing.T) {
@@ -152,30 +690,242 @@ func TestGetTransfersByAccountID(t *testing.T) {
 	account3 := createRandomAccount(t)
 
 	transferUUID := uuid.Must(uuid.NewV7()).String()
-	_, err := testDB.Account().AddTransfer(context.Background(), transferUUID, account.ID, account2.ID, 50)
+	_, err := testDB.Account().AddTransfer(context.Background(), newCompletedTransfer(transferUUID, account.ID, account2.ID, entity.NewMoney(5000, entity.BRL)))
 	require.NoError(t, err)
 
 	transferUUID = uuid.Must(uuid.NewV7()).String()
 
-	_, err = testDB.Account().AddTransfer(context.Background(), transferUUID, account3.ID, account2.ID, 50)
+	_, err = testDB.Account().AddTransfer(context.Background(), newCompletedTransfer(transferUUID, account3.ID, account2.ID, entity.NewMoney(5000, entity.BRL)))
 	require.NoError(t, err)
 
-	transfersMade, totalRecordMade, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, 0, 0, true)
+	transfersMade, totalRecordMade, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{Direction: entity.TransferSent}, 0, 0)
 	require.NoError(t, err)
 
 	require.Len(t, transfersMade, 1)
 	require.Equal(t, 1, int(totalRecordMade))
 
-	transfersReceived, totalRecordReceived, err := testDB.Account().GetTransfersByAccountID(ctx, account2.ID, 10, 0, false)
+	transfersReceived, totalRecordReceived, err := testDB.Account().GetTransfersByAccountID(ctx, account2.ID, entity.TransferFilter{Direction: entity.TransferReceived}, 10, 0)
 	require.NoError(t, err)
 
 	require.Len(t, transfersReceived, 2)
 	require.Equal(t, 2, int(totalRecordReceived))
 
 	//if skip one record, should return only one record
-	transfersReceived, totalRecordReceived, err = testDB.Account().GetTransfersByAccountID(ctx, account2.ID, 10, 1, false)
+	transfersReceived, totalRecordReceived, err = testDB.Account().GetTransfersByAccountID(ctx, account2.ID, entity.TransferFilter{Direction: entity.TransferReceived}, 10, 1)
 	require.NoError(t, err)
 
 	require.Len(t, transfersReceived, 1)
 	require.Equal(t, 2, int(totalRecordReceived))
 }
+
+func TestGetTransfersByAccountIDBothDirections(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	// sent, received, sent and received again, each later than the one before
+	transfers := []entity.Transfer{
+		newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account.ID, account2.ID, entity.NewMoney(1000, entity.BRL)),
+		newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account2.ID, account.ID, entity.NewMoney(2000, entity.BRL)),
+		newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account.ID, account3.ID, entity.NewMoney(3000, entity.BRL)),
+		newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account3.ID, account.ID, entity.NewMoney(4000, entity.BRL)),
+	}
+	for _, transfer := range transfers {
+		_, err := testDB.Account().AddTransfer(ctx, transfer)
+		require.NoError(t, err)
+	}
+
+	newest := func(indexes ...int) (uuids []string) {
+		for i := len(indexes) - 1; i >= 0; i-- {
+			uuids = append(uuids, transfers[indexes[i]].TransferUUID)
+		}
+		return uuids
+	}
+
+	transferUUIDs := func(list []entity.Transfer) (uuids []string) {
+		for _, transfer := range list {
+			uuids = append(uuids, transfer.TransferUUID)
+		}
+		return uuids
+	}
+
+	t.Run("Should page through both directions newest first", func(t *testing.T) {
+		firstPage, total, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{}, 3, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), total)
+		require.Equal(t, newest(1, 2, 3), transferUUIDs(firstPage))
+
+		secondPage, total, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{}, 3, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), total)
+		require.Equal(t, newest(0), transferUUIDs(secondPage))
+	})
+
+	t.Run("Should page through both directions by cursor", func(t *testing.T) {
+		firstPage, result, err := testDB.Account().GetTransfersByAccountIDCursor(ctx, account.ID, entity.TransferFilter{}, entity.CursorPage{Limit: 3, WithCount: true})
+		require.NoError(t, err)
+		require.Equal(t, newest(1, 2, 3), transferUUIDs(firstPage))
+		require.Equal(t, int64(4), *result.TotalRecords)
+		require.Nil(t, result.Prev)
+
+		secondPage, result, err := testDB.Account().GetTransfersByAccountIDCursor(ctx, account.ID, entity.TransferFilter{}, entity.CursorPage{After: result.Next, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, newest(0), transferUUIDs(secondPage))
+		require.Nil(t, result.Next)
+
+		backPage, result, err := testDB.Account().GetTransfersByAccountIDCursor(ctx, account.ID, entity.TransferFilter{}, entity.CursorPage{Before: result.Prev, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, newest(1, 2, 3), transferUUIDs(backPage))
+		require.Nil(t, result.Prev)
+	})
+
+	t.Run("Should filter by counterparty in both directions", func(t *testing.T) {
+		list, total, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{CounterpartyUUID: account3.UUID}, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), total)
+		require.Equal(t, newest(2, 3), transferUUIDs(list))
+
+		list, _, err = testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{Direction: entity.TransferReceived, CounterpartyUUID: account2.UUID}, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, newest(1), transferUUIDs(list))
+	})
+
+	t.Run("Should filter by amount range", func(t *testing.T) {
+		list, _, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{
+			MinAmount: entity.NewMoney(2000, entity.BRL),
+			MaxAmount: entity.NewMoney(3000, entity.BRL),
+		}, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, newest(1, 2), transferUUIDs(list))
+	})
+
+	t.Run("Should filter by date range", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		list, total, err := testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{CreatedFrom: &future}, 0, 0)
+		require.NoError(t, err)
+		require.Zero(t, total)
+		require.Empty(t, list)
+
+		past := time.Now().Add(-time.Hour)
+		list, _, err = testDB.Account().GetTransfersByAccountID(ctx, account.ID, entity.TransferFilter{CreatedFrom: &past, CreatedTo: &future}, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 4)
+	})
+}
+
+func TestTransferReversal(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferUUID := uuid.Must(uuid.NewV7()).String()
+	transferID, err := testDB.Account().AddTransfer(ctx, newCompletedTransfer(transferUUID, account.ID, account2.ID, entity.NewMoney(5000, entity.BRL)))
+	require.NoError(t, err)
+
+	reversed, err := testDB.Account().GetReversedAmount(ctx, transferID)
+	require.NoError(t, err)
+	require.True(t, reversed.IsZero())
+	require.Equal(t, entity.BRL, reversed.Currency())
+
+	for _, amount := range []int64{1000, 1500} {
+		reversal := newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account2.ID, account.ID, entity.NewMoney(amount, entity.BRL))
+		reversal.ReversedTransferID = transferID
+
+		_, err = testDB.Account().AddTransfer(ctx, reversal)
+		require.NoError(t, err)
+	}
+
+	reversed, err = testDB.Account().GetReversedAmount(ctx, transferID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2500), reversed.Amount())
+
+	err = testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		transfer, err := tx.Account().GetTransferByUUIDForUpdate(ctx, transferUUID)
+		require.NoError(t, err)
+		require.Equal(t, transferID, transfer.ID)
+		require.Equal(t, account.ID, transfer.AccountOriginID)
+		require.Equal(t, account2.ID, transfer.AccountDestinationID)
+		require.Equal(t, int64(5000), transfer.Amount.Amount())
+		require.False(t, transfer.IsReversal())
+		require.Equal(t, entity.TransferCompleted, transfer.Status)
+		return nil
+	})
+	require.NoError(t, err)
+
+	reversals, _, err := testDB.Account().GetTransfersByAccountID(ctx, account2.ID, entity.TransferFilter{Direction: entity.TransferSent}, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+	for _, reversal := range reversals {
+		require.Equal(t, transferID, reversal.ReversedTransferID)
+		require.Equal(t, transferUUID, reversal.ReversedTransferUUID)
+	}
+
+	_, err = testDB.Account().GetTransferByUUIDForUpdate(ctx, uuid.Must(uuid.NewV7()).String())
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestGetAccountLimit(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	_, err := testDB.Account().GetAccountLimit(ctx, account.ID)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	_, err = testDB.(*PostgresConn).Pool().Exec(ctx,
+		`INSERT INTO tab_account_limit (account_id, daily, night_time) VALUES ($1, 50000, 0)`, account.ID)
+	require.NoError(t, err)
+
+	limit, err := testDB.Account().GetAccountLimit(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, limit.AccountID)
+	require.Nil(t, limit.PerTransaction)
+	require.Nil(t, limit.Monthly)
+	require.Equal(t, entity.NewMoney(50000, entity.BRL), *limit.Daily)
+	require.Equal(t, entity.NewMoney(0, entity.BRL), *limit.NightTime)
+}
+
+func TestGetTransferUsage(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	addTransfer := func(transfer entity.Transfer) int64 {
+		transferID, err := testDB.Account().AddTransfer(ctx, transfer)
+		require.NoError(t, err)
+		return transferID
+	}
+
+	addTransfer(newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account.ID, account2.ID, entity.NewMoney(100, entity.BRL)))
+
+	// made before today, it only counts for the month
+	yesterdayID := addTransfer(newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account.ID, account2.ID, entity.NewMoney(200, entity.BRL)))
+	_, err := testDB.(*PostgresConn).Pool().Exec(ctx,
+		`UPDATE tab_transfer SET created_at = NOW() - INTERVAL '1 day' WHERE transfer_id = $1`, yesterdayID)
+	require.NoError(t, err)
+
+	// declined and received transfers never count
+	failed := newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account.ID, account2.ID, entity.NewMoney(400, entity.BRL))
+	failed.Status = entity.TransferFailed
+	failed.CompletedAt = nil
+	addTransfer(failed)
+	addTransfer(newCompletedTransfer(uuid.Must(uuid.NewV7()).String(), account2.ID, account.ID, entity.NewMoney(800, entity.BRL)))
+
+	periods := entity.TransferPeriods{
+		DayStart:   time.Now().Add(-time.Hour),
+		MonthStart: time.Now().Add(-48 * time.Hour),
+	}
+
+	usage, err := testDB.Account().GetTransferUsage(ctx, account.ID, entity.BRL, periods)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(100, entity.BRL), usage.Daily)
+	require.Equal(t, entity.NewMoney(300, entity.BRL), usage.Monthly)
+	require.Equal(t, entity.NewMoney(0, entity.BRL), usage.NightTime)
+
+	nightStart := time.Now().Add(-30 * time.Hour)
+	periods.NightStart = &nightStart
+
+	usage, err = testDB.Account().GetTransferUsage(ctx, account.ID, entity.BRL, periods)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(300, entity.BRL), usage.NightTime)
+}
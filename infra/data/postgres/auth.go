@@ -5,6 +5,7 @@ import (
 
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 )
 
 type authRepo struct {
@@ -22,7 +23,7 @@ func (r *authRepo) CreateSession(ctx context.Context, session dto.Session) (sess
 		INSERT INTO tab_session (
 			session_uuid,
 			account_id,
-			refresh_token,
+			refresh_token_hash,
 			user_agent,
 			client_ip,
 			is_blocked,
@@ -35,7 +36,7 @@ func (r *authRepo) CreateSession(ctx context.Context, session dto.Session) (sess
 	err = r.db.QueryRow(ctx, query,
 		session.SessionUUID,
 		session.AccountID,
-		session.RefreshToken,
+		session.RefreshTokenHash,
 		session.UserAgent,
 		session.ClientIP,
 		session.IsBlocked,
@@ -48,17 +49,43 @@ func (r *authRepo) CreateSession(ctx context.Context, session dto.Session) (sess
 	return sessionID, nil
 }
 
+func (r *authRepo) GetActiveSessionsByAccountID(ctx context.Context, accountID int64) (sessions []dto.Session, err error) {
+	query := `
+		SELECT
+			ts.session_id,
+			ts.session_uuid,
+			ts.account_id,
+			ts.refresh_token_hash,
+			ts.user_agent,
+			ts.client_ip,
+			ts.is_blocked,
+			ts.refresh_token_expires_at,
+			ts.created_at
+
+		FROM 	tab_session 			ts
+
+		WHERE	ts.account_id 			= 	$1
+		  AND	NOT ts.is_blocked
+		  AND	ts.refresh_token_expires_at > NOW()
+
+		ORDER BY ts.session_id
+	`
+
+	return r.queryList(ctx, query, scanSession, accountID)
+}
+
 func (r *authRepo) GetSessionByUUID(ctx context.Context, sessionUUID string) (session dto.Session, err error) {
 	query := `
 		SELECT
 			ts.session_id,
 			ts.session_uuid,
 			ta.account_id,
-			ts.refresh_token,
+			ts.refresh_token_hash,
 			ts.user_agent,
 			ts.client_ip,
 			ts.is_blocked,
-			ts.refresh_token_expires_at
+			ts.refresh_token_expires_at,
+			ts.created_at
 
 		FROM 	tab_session 			ts
 
@@ -68,18 +95,76 @@ func (r *authRepo) GetSessionByUUID(ctx context.Context, sessionUUID string) (se
 		WHERE	ts.session_uuid 		= 	$1
 	`
 
-	return r.queryOne(ctx, query, func(row scanner) (session dto.Session, err error) {
-		return session, row.Scan(
-			&session.SessionID,
-			&session.SessionUUID,
-			&session.AccountID,
-			&session.RefreshToken,
-			&session.UserAgent,
-			&session.ClientIP,
-			&session.IsBlocked,
-			&session.RefreshTokenExpiredAt,
+	return r.queryOne(ctx, query, scanSession, sessionUUID)
+}
+
+func scanSession(row scanner) (session dto.Session, err error) {
+	return session, row.Scan(
+		&session.SessionID,
+		&session.SessionUUID,
+		&session.AccountID,
+		&session.RefreshTokenHash,
+		&session.UserAgent,
+		&session.ClientIP,
+		&session.IsBlocked,
+		&session.RefreshTokenExpiredAt,
+		&session.CreatedAt,
+	)
+}
+
+func (r *authRepo) RotateSessionRefreshToken(ctx context.Context, rotation dto.RefreshTokenRotation) (rotated bool, err error) {
+	query := `
+		WITH rotated AS (
+			UPDATE tab_session
+			SET refresh_token_hash 			= $3,
+				refresh_token_expires_at 	= $4,
+				update_at 					= NOW()
+			WHERE session_uuid 				= $1
+			  AND refresh_token_hash 		= $2
+			  AND NOT is_blocked
+			  AND refresh_token_expires_at 	> NOW()
+			RETURNING session_id
+		)
+		INSERT INTO tab_session_rotated_token (
+			session_id,
+			token_hash
 		)
-	}, sessionUUID)
+		SELECT session_id, $2 FROM rotated;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		rotation.SessionUUID,
+		rotation.RefreshTokenHash,
+		rotation.NewRefreshTokenHash,
+		rotation.NewRefreshTokenExpiredAt,
+	)
+	if err != nil {
+		return rotated, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *authRepo) IsRotatedRefreshToken(ctx context.Context, sessionUUID, refreshTokenHash string) (rotated bool, err error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM 	tab_session_rotated_token 	tsrt
+
+			INNER JOIN tab_session ts
+				ON ts.session_id = tsrt.session_id
+
+			WHERE	ts.session_uuid 			= 	$1
+			  AND	tsrt.token_hash 			= 	$2
+		)
+	`
+
+	err = r.db.QueryRow(ctx, query, sessionUUID, refreshTokenHash).Scan(&rotated)
+	if err != nil {
+		return rotated, handleDBError(err)
+	}
+
+	return rotated, nil
 }
 
 func (r *authRepo) SetSessionAsBlocked(ctx context.Context, sessionUUID string) (err error) {
@@ -97,3 +182,221 @@ func (r *authRepo) SetSessionAsBlocked(ctx context.Context, sessionUUID string)
 
 	return nil
 }
+
+func (r *authRepo) SetSessionsAsBlockedByAccountID(ctx context.Context, accountID int64) (sessionUUIDs []string, err error) {
+	query := `
+		UPDATE tab_session
+		SET is_blocked = true,
+			update_at  = NOW()
+		WHERE account_id = $1
+		  AND NOT is_blocked
+		RETURNING session_uuid;
+	`
+
+	return r.queryList(ctx, query, func(row scanner) (sessionUUID string, err error) {
+		return sessionUUID, row.Scan(&sessionUUID)
+	}, accountID)
+}
+
+func (r *authRepo) CreatePasswordReset(ctx context.Context, reset entity.PasswordReset) (resetID int64, err error) {
+	query := `
+		INSERT INTO tab_password_reset (
+			account_id,
+			token_hash,
+			expires_at
+		)
+		VALUES ($1, $2, $3)
+		RETURNING password_reset_id;
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		reset.AccountID,
+		reset.TokenHash,
+		reset.ExpiresAt,
+	).Scan(&resetID)
+	if err != nil {
+		return resetID, handleDBError(err)
+	}
+
+	return resetID, nil
+}
+
+func (r *authRepo) ConsumePasswordReset(ctx context.Context, tokenHash string) (accountID int64, err error) {
+	query := `
+		UPDATE tab_password_reset
+		SET consumed_at = NOW()
+		WHERE token_hash 	= $1
+		  AND consumed_at 	IS NULL
+		  AND expires_at 	> NOW()
+		RETURNING account_id;
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (accountID int64, err error) {
+		return accountID, row.Scan(&accountID)
+	}, tokenHash)
+}
+
+func (r *authRepo) VoidPasswordResets(ctx context.Context, accountID int64) (err error) {
+	query := `
+		UPDATE tab_password_reset
+		SET consumed_at = NOW()
+		WHERE account_id 	= $1
+		  AND consumed_at 	IS NULL;
+	`
+
+	_, err = r.db.Exec(ctx, query, accountID)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	return nil
+}
+
+func (r *authRepo) AddSecurityEvent(ctx context.Context, event entity.SecurityEvent) (eventID int64, err error) {
+	query := `
+		INSERT INTO tab_security_event (
+			event_type,
+			account_id,
+			cpf_index,
+			client_ip,
+			session_uuid,
+			locked_until
+		)
+		VALUES ($1, NULLIF($2::INT, 0), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5::TEXT, '')::UUID, $6)
+		RETURNING security_event_id;
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		event.Type,
+		event.AccountID,
+		event.CPFIndex,
+		event.ClientIP,
+		event.SessionUUID,
+		event.LockedUntil,
+	).Scan(&eventID)
+	if err != nil {
+		return eventID, handleDBError(err)
+	}
+
+	return eventID, nil
+}
+
+func (r *authRepo) GetAccountMFA(ctx context.Context, accountID int64) (mfa entity.AccountMFA, err error) {
+	query := `
+		SELECT
+			tam.account_mfa_id,
+			tam.account_id,
+			tam.secret,
+			tam.confirmed_at,
+			tam.last_used_step,
+			tam.created_at
+
+		FROM 	tab_account_mfa 		tam
+
+		WHERE	tam.account_id 			= 	$1
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (mfa entity.AccountMFA, err error) {
+		return mfa, row.Scan(
+			&mfa.ID,
+			&mfa.AccountID,
+			&mfa.Secret,
+			&mfa.ConfirmedAt,
+			&mfa.LastUsedStep,
+			&mfa.CreatedAt,
+		)
+	}, accountID)
+}
+
+func (r *authRepo) SetPendingAccountMFA(ctx context.Context, accountID int64, secret string) (set bool, err error) {
+	query := `
+		INSERT INTO tab_account_mfa (
+			account_id,
+			secret
+		)
+		VALUES ($1, $2)
+		ON CONFLICT (account_id) DO UPDATE
+		SET secret 			= EXCLUDED.secret,
+			last_used_step 	= 0,
+			created_at 		= NOW(),
+			update_at 		= NOW()
+		WHERE tab_account_mfa.confirmed_at IS NULL;
+	`
+
+	result, err := r.db.Exec(ctx, query, accountID, secret)
+	if err != nil {
+		return set, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *authRepo) ConfirmAccountMFA(ctx context.Context, accountID, step int64) (confirmed bool, err error) {
+	query := `
+		UPDATE tab_account_mfa
+		SET confirmed_at 	= NOW(),
+			last_used_step 	= $2,
+			update_at 		= NOW()
+		WHERE account_id 	= $1
+		  AND confirmed_at 	IS NULL;
+	`
+
+	result, err := r.db.Exec(ctx, query, accountID, step)
+	if err != nil {
+		return confirmed, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *authRepo) UseAccountMFAStep(ctx context.Context, accountID, step int64) (used bool, err error) {
+	query := `
+		UPDATE tab_account_mfa
+		SET last_used_step 	= $2,
+			update_at 		= NOW()
+		WHERE account_id 	= $1
+		  AND confirmed_at 	IS NOT NULL
+		  AND last_used_step < $2;
+	`
+
+	result, err := r.db.Exec(ctx, query, accountID, step)
+	if err != nil {
+		return used, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *authRepo) AddMFARecoveryCodes(ctx context.Context, accountID int64, codeHashes []string) (err error) {
+	query := `
+		INSERT INTO tab_mfa_recovery_code (
+			account_id,
+			code_hash
+		)
+		SELECT $1, UNNEST($2::TEXT[]);
+	`
+
+	_, err = r.db.Exec(ctx, query, accountID, codeHashes)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	return nil
+}
+
+func (r *authRepo) UseMFARecoveryCode(ctx context.Context, accountID int64, codeHash string) (used bool, err error) {
+	query := `
+		UPDATE tab_mfa_recovery_code
+		SET used_at = NOW()
+		WHERE account_id 	= $1
+		  AND code_hash 	= $2
+		  AND used_at 		IS NULL;
+	`
+
+	result, err := r.db.Exec(ctx, query, accountID, codeHash)
+	if err != nil {
+		return used, handleDBError(err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
@@ -2,18 +2,24 @@ package postgres
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 	"time"
 
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/util/tokenhash"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func validateTwoSessions(t *testing.T, sessionExpected dto.Session, sessionToCompare dto.Session) {
 	require.NotZero(t, sessionToCompare.AccountID)
+	require.NotZero(t, sessionToCompare.CreatedAt)
 	require.Equal(t, sessionExpected.SessionUUID, sessionToCompare.SessionUUID)
-	require.Equal(t, sessionExpected.RefreshToken, sessionToCompare.RefreshToken)
+	require.Equal(t, sessionExpected.RefreshTokenHash, sessionToCompare.RefreshTokenHash)
 	require.Equal(t, sessionExpected.UserAgent, sessionToCompare.UserAgent)
 	require.Equal(t, sessionExpected.ClientIP, sessionToCompare.ClientIP)
 	require.Equal(t, sessionExpected.IsBlocked, sessionToCompare.IsBlocked)
@@ -27,7 +33,7 @@ func TestCreateAndGetSession(t *testing.T) {
 	session := dto.Session{
 		SessionUUID:           uuid.Must(uuid.NewV7()).String(),
 		AccountID:             account.ID,
-		RefreshToken:          uuid.Must(uuid.NewV7()).String(),
+		RefreshTokenHash:      tokenhash.Sum(uuid.Must(uuid.NewV7()).String()),
 		UserAgent:             "user-agent",
 		ClientIP:              "client-ip",
 		IsBlocked:             false,
@@ -51,7 +57,7 @@ func TestSetSessionAsBlocked(t *testing.T) {
 	session1 := dto.Session{
 		SessionUUID:           uuid.Must(uuid.NewV7()).String(),
 		AccountID:             account.ID,
-		RefreshToken:          uuid.Must(uuid.NewV7()).String(),
+		RefreshTokenHash:      tokenhash.Sum(uuid.Must(uuid.NewV7()).String()),
 		UserAgent:             "user-agent-1",
 		ClientIP:              "client-ip-1",
 		IsBlocked:             false,
@@ -61,7 +67,7 @@ func TestSetSessionAsBlocked(t *testing.T) {
 	session2 := dto.Session{
 		SessionUUID:           uuid.Must(uuid.NewV7()).String(),
 		AccountID:             account.ID,
-		RefreshToken:          uuid.Must(uuid.NewV7()).String(),
+		RefreshTokenHash:      tokenhash.Sum(uuid.Must(uuid.NewV7()).String()),
 		UserAgent:             "user-agent-2",
 		ClientIP:              "client-ip-2",
 		IsBlocked:             false,
@@ -88,3 +94,289 @@ func TestSetSessionAsBlocked(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, got2.IsBlocked)
 }
+
+func TestSetSessionsAsBlockedByAccountID(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	newSession := func(accountID int64) dto.Session {
+		session := dto.Session{
+			SessionUUID:           uuid.Must(uuid.NewV7()).String(),
+			AccountID:             accountID,
+			RefreshTokenHash:      tokenhash.Sum(uuid.Must(uuid.NewV7()).String()),
+			UserAgent:             "user-agent",
+			ClientIP:              "client-ip",
+			RefreshTokenExpiredAt: time.Now().Add(24 * time.Hour),
+		}
+
+		_, err := testDB.Auth().CreateSession(ctx, session)
+		require.NoError(t, err)
+		return session
+	}
+
+	session1 := newSession(account.ID)
+	session2 := newSession(account.ID)
+	otherSession := newSession(other.ID)
+
+	blocked, err := testDB.Auth().SetSessionsAsBlockedByAccountID(ctx, account.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{session1.SessionUUID, session2.SessionUUID}, blocked)
+
+	for _, session := range []dto.Session{session1, session2} {
+		got, err := testDB.Auth().GetSessionByUUID(ctx, session.SessionUUID)
+		require.NoError(t, err)
+		require.True(t, got.IsBlocked)
+	}
+
+	got, err := testDB.Auth().GetSessionByUUID(ctx, otherSession.SessionUUID)
+	require.NoError(t, err)
+	require.False(t, got.IsBlocked)
+
+	// the ones already blocked aren't returned again
+	blocked, err = testDB.Auth().SetSessionsAsBlockedByAccountID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Empty(t, blocked)
+}
+
+func TestGetActiveSessionsByAccountID(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	newSession := func(expiresAt time.Time) dto.Session {
+		session := dto.Session{
+			SessionUUID:           uuid.Must(uuid.NewV7()).String(),
+			AccountID:             account.ID,
+			RefreshTokenHash:      tokenhash.Sum(uuid.Must(uuid.NewV7()).String()),
+			UserAgent:             "user-agent",
+			ClientIP:              "client-ip",
+			RefreshTokenExpiredAt: expiresAt,
+		}
+
+		_, err := testDB.Auth().CreateSession(ctx, session)
+		require.NoError(t, err)
+		return session
+	}
+
+	active1 := newSession(time.Now().Add(24 * time.Hour))
+	active2 := newSession(time.Now().Add(24 * time.Hour))
+	blocked := newSession(time.Now().Add(24 * time.Hour))
+	newSession(time.Now().Add(-time.Hour))
+
+	require.NoError(t, testDB.Auth().SetSessionAsBlocked(ctx, blocked.SessionUUID))
+
+	sessions, err := testDB.Auth().GetActiveSessionsByAccountID(ctx, account.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	validateTwoSessions(t, active1, sessions[0])
+	validateTwoSessions(t, active2, sessions[1])
+
+	sessions, err = testDB.Auth().GetActiveSessionsByAccountID(ctx, -1)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestPasswordResetLifecycle(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	newReset := func(expiresAt time.Time) entity.PasswordReset {
+		tokenHash := sha256.Sum256([]byte(uuid.Must(uuid.NewV7()).String()))
+		reset := entity.PasswordReset{
+			AccountID: account.ID,
+			TokenHash: hex.EncodeToString(tokenHash[:]),
+			ExpiresAt: expiresAt,
+		}
+
+		resetID, err := testDB.Auth().CreatePasswordReset(ctx, reset)
+		require.NoError(t, err)
+		require.NotZero(t, resetID)
+		return reset
+	}
+
+	reset := newReset(time.Now().Add(time.Hour))
+
+	accountID, err := testDB.Auth().ConsumePasswordReset(ctx, reset.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, accountID)
+
+	// a token is single use
+	_, err = testDB.Auth().ConsumePasswordReset(ctx, reset.TokenHash)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	expired := newReset(time.Now().Add(-time.Minute))
+	_, err = testDB.Auth().ConsumePasswordReset(ctx, expired.TokenHash)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	voided := newReset(time.Now().Add(time.Hour))
+	require.NoError(t, testDB.Auth().VoidPasswordResets(ctx, account.ID))
+	_, err = testDB.Auth().ConsumePasswordReset(ctx, voided.TokenHash)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+}
+
+func TestAddSecurityEvent(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	lockedUntil := time.Now().Add(15 * time.Minute)
+
+	eventID, err := testDB.Auth().AddSecurityEvent(ctx, entity.SecurityEvent{
+		Type:        entity.SecurityEventLoginLockedCPF,
+		AccountID:   account.ID,
+		CPFIndex:    account.CPFIndex,
+		ClientIP:    "203.0.113.7",
+		LockedUntil: &lockedUntil,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, eventID)
+
+	eventID, err = testDB.Auth().AddSecurityEvent(ctx, entity.SecurityEvent{
+		Type:        entity.SecurityEventRefreshTokenReused,
+		AccountID:   account.ID,
+		ClientIP:    "203.0.113.7",
+		SessionUUID: uuid.Must(uuid.NewV7()).String(),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, eventID)
+
+	// an ip locked out has neither an account nor a cpf
+	eventID, err = testDB.Auth().AddSecurityEvent(ctx, entity.SecurityEvent{
+		Type:        entity.SecurityEventLoginLockedIP,
+		ClientIP:    "2001:db8::1",
+		LockedUntil: &lockedUntil,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, eventID)
+}
+
+func TestAccountMFALifecycle(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	_, err := testDB.Auth().GetAccountMFA(ctx, account.ID)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	set, err := testDB.Auth().SetPendingAccountMFA(ctx, account.ID, "secret-1")
+	require.NoError(t, err)
+	require.True(t, set)
+
+	// a pending one is started over
+	set, err = testDB.Auth().SetPendingAccountMFA(ctx, account.ID, "secret-2")
+	require.NoError(t, err)
+	require.True(t, set)
+
+	mfa, err := testDB.Auth().GetAccountMFA(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, mfa.AccountID)
+	require.Equal(t, "secret-2", mfa.Secret)
+	require.False(t, mfa.Enabled())
+
+	// a code can't be taken before it is confirmed
+	used, err := testDB.Auth().UseAccountMFAStep(ctx, account.ID, 100)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	confirmed, err := testDB.Auth().ConfirmAccountMFA(ctx, account.ID, 100)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+
+	confirmed, err = testDB.Auth().ConfirmAccountMFA(ctx, account.ID, 101)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+
+	// a confirmed one is never replaced
+	set, err = testDB.Auth().SetPendingAccountMFA(ctx, account.ID, "secret-3")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	mfa, err = testDB.Auth().GetAccountMFA(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, "secret-2", mfa.Secret)
+	require.True(t, mfa.Enabled())
+	require.Equal(t, int64(100), mfa.LastUsedStep)
+
+	// the step of the confirmation, or one before it, can't be taken again
+	used, err = testDB.Auth().UseAccountMFAStep(ctx, account.ID, 100)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	used, err = testDB.Auth().UseAccountMFAStep(ctx, account.ID, 101)
+	require.NoError(t, err)
+	require.True(t, used)
+}
+
+func TestMFARecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	_, err := testDB.Auth().SetPendingAccountMFA(ctx, account.ID, "secret")
+	require.NoError(t, err)
+
+	err = testDB.Auth().AddMFARecoveryCodes(ctx, account.ID, []string{"hash-1", "hash-2"})
+	require.NoError(t, err)
+
+	used, err := testDB.Auth().UseMFARecoveryCode(ctx, account.ID, "hash-1")
+	require.NoError(t, err)
+	require.True(t, used)
+
+	// a recovery code is single use
+	used, err = testDB.Auth().UseMFARecoveryCode(ctx, account.ID, "hash-1")
+	require.NoError(t, err)
+	require.False(t, used)
+
+	other := createRandomAccount(t)
+	used, err = testDB.Auth().UseMFARecoveryCode(ctx, other.ID, "hash-2")
+	require.NoError(t, err)
+	require.False(t, used)
+}
+
+func TestRotateSessionRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	session := dto.Session{
+		SessionUUID:           uuid.Must(uuid.NewV7()).String(),
+		AccountID:             account.ID,
+		RefreshTokenHash:      tokenhash.Sum("token-1"),
+		UserAgent:             "user-agent",
+		ClientIP:              "client-ip",
+		RefreshTokenExpiredAt: time.Now().Add(time.Hour),
+	}
+	_, err := testDB.Auth().CreateSession(ctx, session)
+	require.NoError(t, err)
+
+	rotation := dto.RefreshTokenRotation{
+		SessionUUID:              session.SessionUUID,
+		RefreshTokenHash:         tokenhash.Sum("token-1"),
+		NewRefreshTokenHash:      tokenhash.Sum("token-2"),
+		NewRefreshTokenExpiredAt: time.Now().Add(24 * time.Hour),
+	}
+
+	rotated, err := testDB.Auth().RotateSessionRefreshToken(ctx, rotation)
+	require.NoError(t, err)
+	require.True(t, rotated)
+
+	got, err := testDB.Auth().GetSessionByUUID(ctx, session.SessionUUID)
+	require.NoError(t, err)
+	require.Equal(t, tokenhash.Sum("token-2"), got.RefreshTokenHash)
+	require.WithinDuration(t, rotation.NewRefreshTokenExpiredAt, got.RefreshTokenExpiredAt, 2*time.Second)
+
+	reused, err := testDB.Auth().IsRotatedRefreshToken(ctx, session.SessionUUID, tokenhash.Sum("token-1"))
+	require.NoError(t, err)
+	require.True(t, reused)
+
+	// the old token can't be rotated again
+	rotated, err = testDB.Auth().RotateSessionRefreshToken(ctx, rotation)
+	require.NoError(t, err)
+	require.False(t, rotated)
+
+	// nor can the token of a blocked session
+	require.NoError(t, testDB.Auth().SetSessionAsBlocked(ctx, session.SessionUUID))
+	rotation.RefreshTokenHash = tokenhash.Sum("token-2")
+	rotated, err = testDB.Auth().RotateSessionRefreshToken(ctx, rotation)
+	require.NoError(t, err)
+	require.False(t, rotated)
+
+	reused, err = testDB.Auth().IsRotatedRefreshToken(ctx, session.SessionUUID, tokenhash.Sum("token-2"))
+	require.NoError(t, err)
+	require.False(t, reused)
+}
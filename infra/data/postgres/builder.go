@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+)
+
+// sqlBuilder puts a query together from a base select and the conditions a
+// filter asks for. Values only ever go in as params, numbered in the order
+// they are added, so no input ends up in the SQL text. Conditions must be
+// added before the ordering and the paging.
+type sqlBuilder struct {
+	sql      strings.Builder
+	args     []any
+	hasWhere bool
+}
+
+func newSQLBuilder(base string) *sqlBuilder {
+	b := &sqlBuilder{}
+	b.sql.WriteString(base)
+	return b
+}
+
+// Arg adds value as a param and returns its placeholder.
+func (b *sqlBuilder) Arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where adds condition, joined to the ones before it by AND.
+func (b *sqlBuilder) Where(condition string) *sqlBuilder {
+	if b.hasWhere {
+		b.sql.WriteString("\n\t\t\tAND ")
+	} else {
+		b.sql.WriteString("\n\t\tWHERE ")
+		b.hasWhere = true
+	}
+	b.sql.WriteString(condition)
+	return b
+}
+
+// OrderBy sorts by columns, each one optionally followed by its direction.
+func (b *sqlBuilder) OrderBy(columns ...string) *sqlBuilder {
+	if len(columns) > 0 {
+		b.sql.WriteString("\n\t\tORDER BY " + strings.Join(columns, ", "))
+	}
+	return b
+}
+
+// Limit is left out when take isn't positive.
+func (b *sqlBuilder) Limit(take int64) *sqlBuilder {
+	if take > 0 {
+		b.sql.WriteString("\n\t\tLIMIT " + b.Arg(take))
+	}
+	return b
+}
+
+// Offset is left out when skip isn't positive.
+func (b *sqlBuilder) Offset(skip int64) *sqlBuilder {
+	if skip > 0 {
+		b.sql.WriteString("\n\t\tOFFSET " + b.Arg(skip))
+	}
+	return b
+}
+
+func (b *sqlBuilder) Query() string {
+	return b.sql.String()
+}
+
+func (b *sqlBuilder) Args() []any {
+	return b.args
+}
+
+// Keyset narrows and orders the query to the page asked for, over the
+// createdAt and id columns of a list ordered newest first. A read before a
+// cursor walks the list backwards, so keysetPage must put it back in order.
+// One more row than the limit is read, to know whether there is a page beyond.
+func (b *sqlBuilder) Keyset(page entity.CursorPage, createdAtColumn, idColumn string) *sqlBuilder {
+	keyset := fmt.Sprintf("(%s, %s)", createdAtColumn, idColumn)
+
+	switch {
+	case page.Before != nil:
+		b.Where(fmt.Sprintf("%s > (%s, %s)", keyset, b.Arg(page.Before.CreatedAt), b.Arg(page.Before.ID)))
+		b.OrderBy(createdAtColumn+" ASC", idColumn+" ASC")
+	case page.After != nil:
+		b.Where(fmt.Sprintf("%s < (%s, %s)", keyset, b.Arg(page.After.CreatedAt), b.Arg(page.After.ID)))
+		fallthrough
+	default:
+		b.OrderBy(createdAtColumn+" DESC", idColumn+" DESC")
+	}
+
+	return b.Limit(page.Limit + 1)
+}
+
+// likeEscaper keeps the wildcards a user types from matching anything.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern is a LIKE pattern matching value anywhere.
+func containsPattern(value string) string {
+	return "%" + likeEscaper.Replace(value) + "%"
+}
+
+// sortColumns turns the fields of sort into the columns they stand for,
+// refusing a field that isn't in columns, and breaks ties with tiebreak so
+// pages never overlap.
+func sortColumns(sort []entity.SortField, columns map[string]string, tiebreak string) ([]string, error) {
+	orderBy := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("can not sort by %q", field.Field)
+		}
+
+		direction := "ASC"
+		if field.Descending {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, column+" "+direction)
+	}
+
+	return append(orderBy, tiebreak), nil
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// normalizeSQL collapses the whitespace of a query, to compare it on one line.
+func normalizeSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func TestSQLBuilder(t *testing.T) {
+	t.Run("Should number the params in the order they are added", func(t *testing.T) {
+		b := newSQLBuilder("SELECT id FROM tab_test")
+		b.Where("name ILIKE " + b.Arg("%john%"))
+		b.Where("active = " + b.Arg(true))
+		b.OrderBy("name ASC", "id").Limit(10).Offset(20)
+
+		require.Equal(t, "SELECT id FROM tab_test WHERE name ILIKE $1 AND active = $2 ORDER BY name ASC, id LIMIT $3 OFFSET $4", normalizeSQL(b.Query()))
+		require.Equal(t, []any{"%john%", true, int64(10), int64(20)}, b.Args())
+	})
+
+	t.Run("Should reuse a placeholder for the same value", func(t *testing.T) {
+		b := newSQLBuilder("SELECT id FROM tab_test")
+		id := b.Arg(int64(7))
+		b.Where("(origin_id = " + id + " OR destination_id = " + id + ")")
+
+		require.Equal(t, "SELECT id FROM tab_test WHERE (origin_id = $1 OR destination_id = $1)", normalizeSQL(b.Query()))
+		require.Equal(t, []any{int64(7)}, b.Args())
+	})
+
+	t.Run("Should leave out the paging that wasn't asked for", func(t *testing.T) {
+		b := newSQLBuilder("SELECT id FROM tab_test").Limit(0).Offset(0)
+
+		require.Equal(t, "SELECT id FROM tab_test", normalizeSQL(b.Query()))
+		require.Empty(t, b.Args())
+	})
+
+	t.Run("Should keep a user's input out of the query", func(t *testing.T) {
+		b := newSQLBuilder("SELECT id FROM tab_test")
+		b.Where("name = " + b.Arg("'; DROP TABLE tab_test; --"))
+
+		require.NotContains(t, b.Query(), "DROP")
+	})
+}
+
+func TestSQLBuilder_Keyset(t *testing.T) {
+	cursor := &entity.Cursor{CreatedAt: time.Date(2026, time.March, 10, 15, 0, 0, 0, time.UTC), ID: 7}
+
+	newBuilder := func() *sqlBuilder {
+		b := newSQLBuilder("SELECT id FROM tab_test")
+		return b.Where("kind = " + b.Arg("x"))
+	}
+
+	t.Run("Should read the first page newest first", func(t *testing.T) {
+		b := newBuilder().Keyset(entity.CursorPage{Limit: 10}, "t.created_at", "t.id")
+
+		require.Equal(t, "SELECT id FROM tab_test WHERE kind = $1 ORDER BY t.created_at DESC, t.id DESC LIMIT $2", normalizeSQL(b.Query()))
+		require.Equal(t, []any{"x", int64(11)}, b.Args())
+	})
+
+	t.Run("Should read after the cursor newest first", func(t *testing.T) {
+		b := newBuilder().Keyset(entity.CursorPage{After: cursor, Limit: 10}, "t.created_at", "t.id")
+
+		require.Equal(t, "SELECT id FROM tab_test WHERE kind = $1 AND (t.created_at, t.id) < ($2, $3) ORDER BY t.created_at DESC, t.id DESC LIMIT $4", normalizeSQL(b.Query()))
+		require.Equal(t, []any{"x", cursor.CreatedAt, int64(7), int64(11)}, b.Args())
+	})
+
+	t.Run("Should read before the cursor backwards", func(t *testing.T) {
+		b := newBuilder().Keyset(entity.CursorPage{Before: cursor, Limit: 10}, "t.created_at", "t.id")
+
+		require.Equal(t, "SELECT id FROM tab_test WHERE kind = $1 AND (t.created_at, t.id) > ($2, $3) ORDER BY t.created_at ASC, t.id ASC LIMIT $4", normalizeSQL(b.Query()))
+		require.Equal(t, []any{"x", cursor.CreatedAt, int64(7), int64(11)}, b.Args())
+	})
+}
+
+func TestLikePatterns(t *testing.T) {
+	require.Equal(t, `%john%`, containsPattern("john"))
+	require.Equal(t, `%50\%\_off\\%`, containsPattern(`50%_off\`))
+}
+
+func TestSortColumns(t *testing.T) {
+	columns := map[string]string{"name": "ta.name", "created_at": "ta.created_at"}
+
+	orderBy, err := sortColumns([]entity.SortField{{Field: "name"}, {Field: "created_at", Descending: true}}, columns, "ta.account_id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ta.name ASC", "ta.created_at DESC", "ta.account_id"}, orderBy)
+
+	orderBy, err = sortColumns(nil, columns, "ta.account_id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ta.account_id"}, orderBy)
+
+	_, err = sortColumns([]entity.SortField{{Field: "cpf; DROP TABLE tab_account"}}, columns, "ta.account_id")
+	require.Error(t, err)
+}
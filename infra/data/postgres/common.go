@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -100,6 +102,54 @@ func withCount(baseQuery string) string {
 	return beforeFrom + ",\n\t\tCOUNT(*) OVER() as total_count\n\t\t" + fromAndAfter
 }
 
+// count runs q as a subquery and returns how many rows it has. A keyset read
+// can't use withCount, as its window only sees the rows past the cursor.
+func (c queries) count(ctx context.Context, q string, args ...any) (total int64, err error) {
+	return c.queryOne(ctx, "SELECT COUNT(*) FROM ("+q+") AS counted", func(row scanner) (total int64, err error) {
+		err = row.Scan(&total)
+		return total, err
+	}, args...)
+}
+
+// keysetPage trims the extra row sqlBuilder.Keyset reads, puts a backward read
+// back in newest first order and sets the cursors of the pages around.
+func keysetPage[T any](items []T, page entity.CursorPage, cursorOf func(T) entity.Cursor) ([]T, entity.CursorResult) {
+	var result entity.CursorResult
+
+	hasMore := int64(len(items)) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+
+	if page.Before != nil {
+		slices.Reverse(items)
+	}
+
+	if len(items) == 0 {
+		return items, result
+	}
+
+	first, last := cursorOf(items[0]), cursorOf(items[len(items)-1])
+
+	if page.Before != nil {
+		// the cursor came from a page further down the list
+		result.Next = &last
+		if hasMore {
+			result.Prev = &first
+		}
+		return items, result
+	}
+
+	if hasMore {
+		result.Next = &last
+	}
+	if page.After != nil {
+		result.Prev = &first
+	}
+
+	return items, result
+}
+
 // buildInPlaceholders creates SQL IN clause placeholders and appends values to args
 // Example: buildInPlaceholders([]any{1}, []string{"a", "b"}, 2) returns ("$2, $3", []any{1, "a", "b"})
 func buildInPlaceholders[T any](args []any, values []T, startIndex int) (placeholders string, newArgs []any) {
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/stretchr/testify/require"
 )
 
@@ -159,3 +160,83 @@ func TestBuildInPlaceholders(t *testing.T) {
 		require.Equal(t, []any{"preserved", 123, "new"}, newArgs)
 	})
 }
+
+func TestKeysetPage(t *testing.T) {
+	cursorOf := func(id int64) entity.Cursor { return entity.Cursor{ID: id} }
+	cursor := &entity.Cursor{ID: 100}
+
+	tests := []struct {
+		name      string
+		items     []int64
+		page      entity.CursorPage
+		wantItems []int64
+		wantNext  *int64
+		wantPrev  *int64
+	}{
+		{
+			name:      "Should have a next page when the first page is full",
+			items:     []int64{9, 8, 7},
+			page:      entity.CursorPage{Limit: 2},
+			wantItems: []int64{9, 8},
+			wantNext:  ptr(int64(8)),
+		},
+		{
+			name:      "Should have no page around a short first page",
+			items:     []int64{9, 8},
+			page:      entity.CursorPage{Limit: 2},
+			wantItems: []int64{9, 8},
+		},
+		{
+			name:      "Should have a previous page after a cursor",
+			items:     []int64{7, 6},
+			page:      entity.CursorPage{After: cursor, Limit: 2},
+			wantItems: []int64{7, 6},
+			wantPrev:  ptr(int64(7)),
+		},
+		{
+			name:      "Should put a backward read in order",
+			items:     []int64{10, 11, 12},
+			page:      entity.CursorPage{Before: cursor, Limit: 2},
+			wantItems: []int64{11, 10},
+			wantNext:  ptr(int64(10)),
+			wantPrev:  ptr(int64(11)),
+		},
+		{
+			name:      "Should have no previous page when a backward read reaches the top",
+			items:     []int64{10},
+			page:      entity.CursorPage{Before: cursor, Limit: 2},
+			wantItems: []int64{10},
+			wantNext:  ptr(int64(10)),
+		},
+		{
+			name:      "Should have no page around an empty read",
+			items:     []int64{},
+			page:      entity.CursorPage{After: cursor, Limit: 2},
+			wantItems: []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, result := keysetPage(tt.items, tt.page, cursorOf)
+
+			require.Equal(t, tt.wantItems, items)
+			require.Nil(t, result.TotalRecords)
+
+			if tt.wantNext == nil {
+				require.Nil(t, result.Next)
+			} else {
+				require.Equal(t, *tt.wantNext, result.Next.ID)
+			}
+
+			if tt.wantPrev == nil {
+				require.Nil(t, result.Prev)
+			} else {
+				require.Equal(t, *tt.wantPrev, result.Prev.ID)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
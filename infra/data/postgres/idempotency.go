@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+type idempotencyRepo struct {
+	queries
+}
+
+func newIdempotencyRepo(db dbConn) contract.IdempotencyRepo {
+	return &idempotencyRepo{
+		queries: queries{db: db},
+	}
+}
+
+// ClaimIdempotencyKey stores request as in flight unless the key is already
+// taken. An expired key is taken over, and so is an in-flight one whose lease
+// ran out: the request holding it crashed or failed to settle it, and would
+// otherwise keep the key from being retried until it expires.
+func (r *idempotencyRepo) ClaimIdempotencyKey(ctx context.Context, request entity.IdempotentRequest) (claimed bool, err error) {
+	query := `
+		INSERT INTO tab_idempotency_key (
+			scope,
+			idempotency_key,
+			fingerprint,
+			state,
+			expires_at,
+			in_flight_until
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET fingerprint 			= EXCLUDED.fingerprint,
+			state 					= EXCLUDED.state,
+			response_status 		= NULL,
+			response_content_type 	= NULL,
+			response_body 			= NULL,
+			expires_at 				= EXCLUDED.expires_at,
+			in_flight_until 		= EXCLUDED.in_flight_until,
+			created_at 				= NOW(),
+			update_at 				= NOW()
+		WHERE tab_idempotency_key.expires_at < NOW()
+		   OR (tab_idempotency_key.state = $4 AND tab_idempotency_key.in_flight_until < NOW())
+
+		RETURNING idempotency_key_id;
+	`
+
+	var id int64
+	err = r.db.QueryRow(ctx, query,
+		request.Scope,
+		request.Key,
+		request.Fingerprint,
+		string(entity.IdempotencyInFlight),
+		request.ExpiresAt,
+		request.InFlightUntil,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, handleDBError(err)
+	}
+
+	return true, nil
+}
+
+func (r *idempotencyRepo) CompleteIdempotentRequest(ctx context.Context, request entity.IdempotentRequest) (err error) {
+	query := `
+		UPDATE tab_idempotency_key
+		SET state 					= $3,
+			response_status 		= $4,
+			response_content_type 	= $5,
+			response_body 			= $6,
+			in_flight_until 		= NULL,
+			update_at 				= NOW()
+		WHERE scope 			= $1
+		  AND idempotency_key 	= $2
+		  AND state 			= $7;
+	`
+
+	_, err = r.db.Exec(ctx, query,
+		request.Scope,
+		request.Key,
+		string(entity.IdempotencyCompleted),
+		request.ResponseStatus,
+		request.ResponseContentType,
+		request.ResponseBody,
+		string(entity.IdempotencyInFlight),
+	)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepo) GetIdempotentRequest(ctx context.Context, scope, key string) (request entity.IdempotentRequest, err error) {
+	query := `
+		SELECT
+			tik.scope,
+			tik.idempotency_key,
+			tik.fingerprint,
+			tik.state,
+			COALESCE(tik.response_status, 0),
+			COALESCE(tik.response_content_type, ''),
+			tik.response_body,
+			tik.expires_at
+
+		FROM 	tab_idempotency_key 	tik
+
+		WHERE	tik.scope 				= 	$1
+		  AND	tik.idempotency_key 	= 	$2
+		  AND	tik.expires_at 			> 	NOW()
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (request entity.IdempotentRequest, err error) {
+		var state string
+
+		err = row.Scan(
+			&request.Scope,
+			&request.Key,
+			&request.Fingerprint,
+			&state,
+			&request.ResponseStatus,
+			&request.ResponseContentType,
+			&request.ResponseBody,
+			&request.ExpiresAt,
+		)
+
+		request.State = entity.IdempotencyState(state)
+		return request, err
+	}, scope, key)
+}
+
+// ReleaseIdempotencyKey drops an in-flight claim so the key can be retried. A
+// completed request is left alone.
+func (r *idempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, scope, key string) (err error) {
+	query := `
+		DELETE FROM tab_idempotency_key
+		WHERE scope 			= $1
+		  AND idempotency_key 	= $2
+		  AND state 			= $3;
+	`
+
+	_, err = r.db.Exec(ctx, query, scope, key, string(entity.IdempotencyInFlight))
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotentRequest(expiresAt time.Time) entity.IdempotentRequest {
+	return entity.IdempotentRequest{
+		Scope:         "account:" + uuid.Must(uuid.NewV7()).String(),
+		Key:           uuid.Must(uuid.NewV7()).String(),
+		Fingerprint:   strings.Repeat("a", 64),
+		State:         entity.IdempotencyInFlight,
+		ExpiresAt:     expiresAt,
+		InFlightUntil: time.Now().Add(time.Minute),
+	}
+}
+
+func TestClaimAndCompleteIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	request := newIdempotentRequest(time.Now().Add(time.Hour))
+
+	claimed, err := testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// the key is held, even with another body
+	other := request
+	other.Fingerprint = strings.Repeat("b", 64)
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, other)
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	stored, err := testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+	require.Equal(t, entity.IdempotencyInFlight, stored.State)
+	require.Equal(t, request.Fingerprint, stored.Fingerprint)
+
+	request.ResponseStatus = http.StatusCreated
+	request.ResponseContentType = "application/json"
+	request.ResponseBody = []byte(`{"ok":true}`)
+	err = testDB.Idempotency().CompleteIdempotentRequest(ctx, request)
+	require.NoError(t, err)
+
+	stored, err = testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+	require.True(t, stored.IsCompleted())
+	require.Equal(t, http.StatusCreated, stored.ResponseStatus)
+	require.Equal(t, "application/json", stored.ResponseContentType)
+	require.Equal(t, request.ResponseBody, stored.ResponseBody)
+
+	// a completed request is not released
+	err = testDB.Idempotency().ReleaseIdempotencyKey(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+
+	stored, err = testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+	require.True(t, stored.IsCompleted())
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	request := newIdempotentRequest(time.Now().Add(time.Hour))
+
+	claimed, err := testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	err = testDB.Idempotency().ReleaseIdempotencyKey(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+
+	_, err = testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+}
+
+func TestClaimExpiredIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	request := newIdempotentRequest(time.Now().Add(-time.Minute))
+
+	claimed, err := testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	_, err = testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.ErrorIs(t, err, apperr.ErrRecordNotFound)
+
+	request.ExpiresAt = time.Now().Add(time.Hour)
+	request.Fingerprint = strings.Repeat("b", 64)
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	stored, err := testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+	require.Equal(t, request.Fingerprint, stored.Fingerprint)
+}
+
+func TestClaimOrphanedIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+
+	// a request that died without settling its claim leaves it in flight
+	request := newIdempotentRequest(time.Now().Add(time.Hour))
+	request.InFlightUntil = time.Now().Add(-time.Second)
+
+	claimed, err := testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	retry := request
+	retry.InFlightUntil = time.Now().Add(time.Minute)
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, retry)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// the retry holds a lease of its own now
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, retry)
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	retry.ResponseStatus = http.StatusCreated
+	err = testDB.Idempotency().CompleteIdempotentRequest(ctx, retry)
+	require.NoError(t, err)
+
+	stored, err := testDB.Idempotency().GetIdempotentRequest(ctx, request.Scope, request.Key)
+	require.NoError(t, err)
+	require.True(t, stored.IsCompleted())
+}
+
+func TestClaimIdempotencyKeyIsScoped(t *testing.T) {
+	ctx := context.Background()
+	request := newIdempotentRequest(time.Now().Add(time.Hour))
+
+	claimed, err := testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	request.Scope = "account:" + uuid.Must(uuid.NewV7()).String()
+	claimed, err = testDB.Idempotency().ClaimIdempotencyKey(ctx, request)
+	require.NoError(t, err)
+	require.True(t, claimed)
+}
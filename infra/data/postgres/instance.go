@@ -20,8 +20,13 @@ var (
 type PostgresConn struct {
 	pool *pgxpool.Pool
 
-	accountRepo contract.AccountRepo
-	authRepo    contract.AuthRepo
+	accountRepo     contract.AccountRepo
+	authRepo        contract.AuthRepo
+	idempotencyRepo contract.IdempotencyRepo
+	ledgerRepo      contract.LedgerRepo
+
+	recurringTransferRepo contract.RecurringTransferRepo
+	scheduledTransferRepo contract.ScheduledTransferRepo
 }
 
 // Instance returns an instance of a PostgresConn
@@ -59,8 +64,13 @@ func Instance(ctx context.Context, dsn string, log logger.Logger) (*PostgresConn
 
 func repoInstances(db dbConn) *PostgresConn {
 	return &PostgresConn{
-		accountRepo: newAccountRepo(db),
-		authRepo:    newAuthRepo(db),
+		accountRepo:     newAccountRepo(db),
+		authRepo:        newAuthRepo(db),
+		idempotencyRepo: newIdempotencyRepo(db),
+		ledgerRepo:      newLedgerRepo(db),
+
+		recurringTransferRepo: newRecurringTransferRepo(db),
+		scheduledTransferRepo: newScheduledTransferRepo(db),
 	}
 }
 
@@ -103,3 +113,19 @@ func (c *PostgresConn) Account() contract.AccountRepo {
 func (c *PostgresConn) Auth() contract.AuthRepo {
 	return c.authRepo
 }
+
+func (c *PostgresConn) Idempotency() contract.IdempotencyRepo {
+	return c.idempotencyRepo
+}
+
+func (c *PostgresConn) Ledger() contract.LedgerRepo {
+	return c.ledgerRepo
+}
+
+func (c *PostgresConn) RecurringTransfer() contract.RecurringTransferRepo {
+	return c.recurringTransferRepo
+}
+
+func (c *PostgresConn) ScheduledTransfer() contract.ScheduledTransferRepo {
+	return c.scheduledTransferRepo
+}
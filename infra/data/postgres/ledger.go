@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+)
+
+type ledgerRepo struct {
+	queries
+}
+
+func newLedgerRepo(db dbConn) contract.LedgerRepo {
+	return &ledgerRepo{
+		queries: queries{db: db},
+	}
+}
+
+// RebuildAccountBalances rewrites every cached balance that drifted from the
+// ledger and returns how many it had to fix. Meant for a quiet database: a
+// posting that commits while the sums are taken is missed and overwritten.
+func (r *ledgerRepo) RebuildAccountBalances(ctx context.Context) (rebuilt int64, err error) {
+	query := `
+		UPDATE 	tab_account ta
+
+		SET 	balance 	= ledger.amount,
+				update_at 	= NOW()
+
+		FROM (
+			SELECT
+				ta.account_id,
+				COALESCE(SUM(tle.amount), 0) AS amount
+
+			FROM 	tab_account 		ta
+
+			LEFT JOIN tab_ledger_entry tle
+				ON tle.account_id = ta.account_id
+
+			GROUP BY ta.account_id
+		) ledger
+
+		WHERE 	ledger.account_id 	= ta.account_id
+		  AND 	ledger.amount 		<> ta.balance
+	`
+
+	result, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, handleDBError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// depositToAccount posts a deposit journal against the funding account, the
+// way accountService.AddBalance does.
+func depositToAccount(t *testing.T, accountID int64, amount entity.Money) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		fundingAccountID, err := tx.Account().GetAccountIDByUUID(ctx, entity.FundingAccountUUID)
+		require.NoError(t, err)
+
+		posting := entity.LedgerEntry{
+			JournalUUID: uuid.Must(uuid.NewV7()).String(),
+			AccountID:   fundingAccountID,
+			Type:        entity.LedgerEntryDeposit,
+			Amount:      amount,
+		}
+
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+		require.NoError(t, err)
+		require.True(t, debited)
+
+		posting.AccountID = accountID
+		return tx.Account().CreditAccountBalance(ctx, posting)
+	})
+	require.NoError(t, err)
+}
+
+// ledgerEntries reads the postings of accountID, newest first.
+func ledgerEntries(t *testing.T, accountID int64) (entries []entity.LedgerEntry) {
+	t.Helper()
+
+	rows, err := testDB.(*PostgresConn).Pool().Query(context.Background(), `
+		SELECT
+			tle.journal_uuid,
+			tle.account_id,
+			COALESCE(tle.transfer_id, 0),
+			tle.entry_type,
+			tle.amount,
+			tle.currency
+
+		FROM 	tab_ledger_entry 		tle
+
+		WHERE	tle.account_id 			= 	$1
+
+		ORDER BY tle.created_at DESC, tle.ledger_entry_id DESC
+	`, accountID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry entity.LedgerEntry
+		var entryType, currency string
+		var amount int64
+
+		err = rows.Scan(&entry.JournalUUID, &entry.AccountID, &entry.TransferID, &entryType, &amount, &currency)
+		require.NoError(t, err)
+
+		entry.Type = entity.LedgerEntryType(entryType)
+		entry.Amount = entity.NewMoney(amount, entity.Currency(currency))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, rows.Err())
+
+	return entries
+}
+
+// ledgerBalance sums the postings of accountID, the balance tab_account caches.
+func ledgerBalance(t *testing.T, accountID int64) entity.Money {
+	t.Helper()
+
+	var amount int64
+	var currency string
+	err := testDB.(*PostgresConn).Pool().QueryRow(context.Background(), `
+		SELECT
+			COALESCE(SUM(tle.amount), 0),
+			ta.currency
+
+		FROM 	tab_account 			ta
+
+		LEFT JOIN tab_ledger_entry tle
+			ON tle.account_id = ta.account_id
+
+		WHERE	ta.account_id 			= 	$1
+
+		GROUP BY ta.currency
+	`, accountID).Scan(&amount, &currency)
+	require.NoError(t, err)
+
+	return entity.NewMoney(amount, entity.Currency(currency))
+}
+
+func TestDepositPostsAgainstFundingAccount(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	fundingAccountID, err := testDB.Account().GetAccountIDByUUID(ctx, entity.FundingAccountUUID)
+	require.NoError(t, err)
+
+	fundingBefore := ledgerBalance(t, fundingAccountID)
+
+	depositToAccount(t, account.ID, entity.NewMoney(2500, entity.BRL))
+
+	fundingAfter := ledgerBalance(t, fundingAccountID)
+	require.Equal(t, fundingBefore.Amount()-2500, fundingAfter.Amount())
+
+	entries := ledgerEntries(t, account.ID)
+	require.Len(t, entries, 1)
+	require.Equal(t, entity.LedgerEntryDeposit, entries[0].Type)
+	require.Equal(t, entity.NewMoney(2500, entity.BRL), entries[0].Amount)
+	require.Equal(t, account.ID, entries[0].AccountID)
+	require.Zero(t, entries[0].TransferID)
+	require.NotEmpty(t, entries[0].JournalUUID)
+}
+
+func TestUnbalancedJournalIsRejected(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	// a credit without its debit fails when the transaction commits
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		return tx.Account().CreditAccountBalance(ctx, entity.LedgerEntry{
+			JournalUUID: uuid.Must(uuid.NewV7()).String(),
+			AccountID:   account.ID,
+			Type:        entity.LedgerEntryDeposit,
+			Amount:      entity.NewMoney(100, entity.BRL),
+		})
+	})
+	require.Error(t, err)
+
+	current, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.True(t, current.Balance.IsZero())
+
+	require.Empty(t, ledgerEntries(t, account.ID))
+}
+
+func TestTransferPostsToBothAccounts(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	depositToAccount(t, account.ID, entity.NewMoney(1000, entity.BRL))
+
+	transferUUID := uuid.Must(uuid.NewV7()).String()
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		transferID, err := tx.Account().AddTransfer(ctx, newCompletedTransfer(transferUUID, account.ID, account2.ID, entity.NewMoney(300, entity.BRL)))
+		require.NoError(t, err)
+
+		posting := entity.LedgerEntry{
+			JournalUUID: transferUUID,
+			AccountID:   account.ID,
+			TransferID:  transferID,
+			Type:        entity.LedgerEntryTransfer,
+			Amount:      entity.NewMoney(300, entity.BRL),
+		}
+
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+		require.NoError(t, err)
+		require.True(t, debited)
+
+		posting.AccountID = account2.ID
+		return tx.Account().CreditAccountBalance(ctx, posting)
+	})
+	require.NoError(t, err)
+
+	entries := ledgerEntries(t, account.ID)
+	require.Len(t, entries, 2)
+
+	// newest first, debits negative
+	require.Equal(t, transferUUID, entries[0].JournalUUID)
+	require.Equal(t, entity.LedgerEntryTransfer, entries[0].Type)
+	require.Equal(t, entity.NewMoney(-300, entity.BRL), entries[0].Amount)
+	require.NotZero(t, entries[0].TransferID)
+	require.Equal(t, entity.LedgerEntryDeposit, entries[1].Type)
+
+	received := ledgerEntries(t, account2.ID)
+	require.Len(t, received, 1)
+	require.Equal(t, entity.NewMoney(300, entity.BRL), received[0].Amount)
+
+	require.Equal(t, entity.NewMoney(700, entity.BRL), ledgerBalance(t, account.ID))
+}
+
+func TestRebuildAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	depositToAccount(t, account.ID, entity.NewMoney(1500, entity.BRL))
+
+	// drift the cache behind the ledger's back
+	_, err := testDB.(*PostgresConn).Pool().Exec(ctx,
+		`UPDATE tab_account SET balance = 1 WHERE account_id = $1`, account.ID)
+	require.NoError(t, err)
+
+	rebuilt, err := testDB.Ledger().RebuildAccountBalances(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rebuilt)
+
+	current, err := testDB.Account().GetAccountByUUID(ctx, account.UUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.NewMoney(1500, entity.BRL), current.Balance)
+
+	rebuilt, err = testDB.Ledger().RebuildAccountBalances(ctx)
+	require.NoError(t, err)
+	require.Zero(t, rebuilt)
+}
@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+)
+
+type recurringTransferRepo struct {
+	queries
+}
+
+func newRecurringTransferRepo(db dbConn) contract.RecurringTransferRepo {
+	return &recurringTransferRepo{
+		queries: queries{db: db},
+	}
+}
+
+const queryRecurringTransferSelectBase string = `
+		SELECT
+			rt.recurring_transfer_id,
+			rt.recurring_transfer_uuid,
+			rt.account_origin_id,
+			origin.account_uuid AS account_origin_uuid,
+			rt.account_destination_id,
+			dest.account_uuid AS account_destination_uuid,
+			rt.amount,
+			rt.currency,
+			rt.frequency,
+			COALESCE(rt.interval_days, 0),
+			COALESCE(rt.day_of_month, 0),
+			rt.starts_at,
+			rt.ends_at,
+			COALESCE(rt.max_occurrences, 0),
+			rt.insufficient_funds_policy,
+			rt.max_retries,
+			rt.status,
+			rt.occurrences,
+			rt.next_run_at,
+			rt.canceled_at,
+			rt.created_at
+
+		FROM 	tab_recurring_transfer 	rt
+
+		INNER JOIN tab_account origin
+			ON origin.account_id = rt.account_origin_id
+
+		INNER JOIN tab_account dest
+			ON dest.account_id = rt.account_destination_id
+		`
+
+// parseRecurringTransfer scans a row of queryRecurringTransferSelectBase, and
+// the count column withCount appends when total is given.
+func (r *recurringTransferRepo) parseRecurringTransfer(row scanner, total ...*int64) (recurring entity.RecurringTransfer, err error) {
+	var amount int64
+	var currency string
+	var frequency string
+	var policy string
+	var status string
+
+	dests := []any{
+		&recurring.ID,
+		&recurring.RecurringTransferUUID,
+		&recurring.AccountOriginID,
+		&recurring.AccountOriginUUID,
+		&recurring.AccountDestinationID,
+		&recurring.AccountDestinationUUID,
+		&amount,
+		&currency,
+		&frequency,
+		&recurring.Rule.IntervalDays,
+		&recurring.Rule.DayOfMonth,
+		&recurring.Rule.StartsAt,
+		&recurring.Rule.EndsAt,
+		&recurring.Rule.MaxOccurrences,
+		&policy,
+		&recurring.MaxRetries,
+		&status,
+		&recurring.Occurrences,
+		&recurring.NextRunAt,
+		&recurring.CanceledAt,
+		&recurring.CreatedAt,
+	}
+
+	if len(total) > 0 && total[0] != nil {
+		dests = append(dests, total[0])
+	}
+
+	err = row.Scan(dests...)
+	if err != nil {
+		return recurring, err
+	}
+
+	recurring.Amount = entity.NewMoney(amount, entity.Currency(currency))
+	recurring.Rule.Frequency = entity.RecurrenceFrequency(frequency)
+	recurring.InsufficientFunds = entity.InsufficientFundsPolicy(policy)
+	recurring.Status = entity.RecurringTransferStatus(status)
+	return recurring, nil
+}
+
+func (r *recurringTransferRepo) AddRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) (recurringID int64, err error) {
+	query := `
+		INSERT INTO tab_recurring_transfer (
+			recurring_transfer_uuid,
+			account_origin_id,
+			account_destination_id,
+			amount,
+			currency,
+			frequency,
+			interval_days,
+			day_of_month,
+			starts_at,
+			ends_at,
+			max_occurrences,
+			insufficient_funds_policy,
+			max_retries,
+			status,
+			next_run_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NULLIF($8, 0), $9, $10, NULLIF($11, 0), $12, $13, $14, $15)
+		RETURNING recurring_transfer_id;
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		recurring.RecurringTransferUUID,
+		recurring.AccountOriginID,
+		recurring.AccountDestinationID,
+		recurring.Amount.Amount(),
+		string(recurring.Amount.Currency()),
+		string(recurring.Rule.Frequency),
+		recurring.Rule.IntervalDays,
+		recurring.Rule.DayOfMonth,
+		recurring.Rule.StartsAt,
+		recurring.Rule.EndsAt,
+		recurring.Rule.MaxOccurrences,
+		string(recurring.InsufficientFunds),
+		recurring.MaxRetries,
+		string(recurring.Status),
+		recurring.NextRunAt,
+	).Scan(&recurringID)
+	if err != nil {
+		return recurringID, handleDBError(err)
+	}
+
+	return recurringID, nil
+}
+
+// CancelRecurringTransfersByAccountID cancels the active standing orders that
+// move money out of or into accountID.
+func (r *recurringTransferRepo) CancelRecurringTransfersByAccountID(ctx context.Context, accountID int64) (canceled int64, err error) {
+	query := `
+		UPDATE tab_recurring_transfer
+		SET status 		= $2,
+			next_run_at = NULL,
+			canceled_at = NOW(),
+			update_at 	= NOW()
+		WHERE (account_origin_id = $1 OR account_destination_id = $1)
+		  AND status = $3;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		accountID,
+		string(entity.RecurringTransferCanceled),
+		string(entity.RecurringTransferActive),
+	)
+	if err != nil {
+		return canceled, handleDBError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *recurringTransferRepo) GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (recurring entity.RecurringTransfer, err error) {
+	query := queryRecurringTransferSelectBase + `
+		WHERE	rt.recurring_transfer_uuid 	= 	$1
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.RecurringTransfer, error) {
+		return r.parseRecurringTransfer(row)
+	}, recurringTransferUUID)
+}
+
+// GetRecurringTransfersByAccountID lists the standing orders of accountID,
+// newest first.
+func (r *recurringTransferRepo) GetRecurringTransfersByAccountID(ctx context.Context, accountID int64, take, skip int64) (recurring []entity.RecurringTransfer, totalRecords int64, err error) {
+	b := newSQLBuilder(queryRecurringTransferSelectBase)
+	b.Where("rt.account_origin_id = " + b.Arg(accountID))
+	b.OrderBy("rt.created_at DESC", "rt.recurring_transfer_id DESC").Limit(take).Offset(skip)
+
+	recurring, err = r.queryList(ctx, withCount(b.Query()), func(row scanner) (entity.RecurringTransfer, error) {
+		return r.parseRecurringTransfer(row, &totalRecords)
+	}, b.Args()...)
+
+	return recurring, totalRecords, err
+}
+
+// LockDueRecurringTransfers only locks the rules, not the accounts they join:
+// OF rt keeps a scheduler from holding up transfers of those accounts.
+func (r *recurringTransferRepo) LockDueRecurringTransfers(ctx context.Context, limit int64) (due []entity.RecurringTransfer, err error) {
+	query := queryRecurringTransferSelectBase + `
+		WHERE	rt.status 		= 	$2
+		  AND	rt.next_run_at 	<= 	NOW()
+
+		ORDER BY rt.next_run_at
+		LIMIT $1
+
+		FOR UPDATE OF rt SKIP LOCKED
+	`
+
+	return r.queryList(ctx, query, func(row scanner) (entity.RecurringTransfer, error) {
+		return r.parseRecurringTransfer(row)
+	}, limit, string(entity.RecurringTransferActive))
+}
+
+// LockRecurringTransfer waits for the rule if the scheduler holds it, so a
+// change made here is never lost to an occurrence generated meanwhile.
+func (r *recurringTransferRepo) LockRecurringTransfer(ctx context.Context, recurringID int64) (recurring entity.RecurringTransfer, err error) {
+	query := queryRecurringTransferSelectBase + `
+		WHERE	rt.recurring_transfer_id 	= 	$1
+
+		FOR UPDATE OF rt
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.RecurringTransfer, error) {
+		return r.parseRecurringTransfer(row)
+	}, recurringID)
+}
+
+// UpdateRecurringTransfer writes whatever of the rule may change after it is
+// created.
+func (r *recurringTransferRepo) UpdateRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) (err error) {
+	query := `
+		UPDATE tab_recurring_transfer
+		SET amount 						= $2,
+			ends_at 					= $3,
+			max_occurrences 			= NULLIF($4, 0),
+			insufficient_funds_policy 	= $5,
+			max_retries 				= $6,
+			status 						= $7,
+			occurrences 				= $8,
+			next_run_at 				= $9,
+			canceled_at 				= $10,
+			update_at 					= NOW()
+		WHERE recurring_transfer_id = $1;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		recurring.ID,
+		recurring.Amount.Amount(),
+		recurring.Rule.EndsAt,
+		recurring.Rule.MaxOccurrences,
+		string(recurring.InsufficientFunds),
+		recurring.MaxRetries,
+		string(recurring.Status),
+		recurring.Occurrences,
+		recurring.NextRunAt,
+		recurring.CanceledAt,
+	)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperr.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRecurringTransfer(t *testing.T, from, to entity.Account, startsAt time.Time) entity.RecurringTransfer {
+	ctx := context.Background()
+
+	args := entity.RecurringTransfer{
+		RecurringTransferUUID: uuid.Must(uuid.NewV7()).String(),
+		AccountOriginID:       from.ID,
+		AccountDestinationID:  to.ID,
+		Amount:                entity.NewMoney(100, entity.BRL),
+		Rule:                  entity.RecurrenceRule{Frequency: entity.RecurrenceWeekly, StartsAt: startsAt, MaxOccurrences: 3},
+		InsufficientFunds:     entity.InsufficientFundsRetry,
+		MaxRetries:            2,
+	}
+	args.Reschedule()
+
+	id, err := testDB.RecurringTransfer().AddRecurringTransfer(ctx, args)
+	require.NoError(t, err)
+	require.NotZero(t, id)
+
+	recurring, err := testDB.RecurringTransfer().GetRecurringTransferByUUID(ctx, args.RecurringTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, id, recurring.ID)
+	require.Equal(t, from.UUID, recurring.AccountOriginUUID)
+	require.Equal(t, to.UUID, recurring.AccountDestinationUUID)
+	require.Equal(t, args.Amount, recurring.Amount)
+	require.Equal(t, entity.RecurrenceWeekly, recurring.Rule.Frequency)
+	require.Equal(t, 3, recurring.Rule.MaxOccurrences)
+	require.Equal(t, entity.InsufficientFundsRetry, recurring.InsufficientFunds)
+	require.Equal(t, entity.RecurringTransferActive, recurring.Status)
+	require.NotNil(t, recurring.NextRunAt)
+	require.WithinDuration(t, startsAt, *recurring.NextRunAt, time.Second)
+
+	return recurring
+}
+
+// generateDue does in one transaction what the scheduler does for every due
+// rule, and returns the occurrences it added
+func generateDue(t *testing.T) map[int64]entity.ScheduledTransfer {
+	ctx := context.Background()
+	generated := map[int64]entity.ScheduledTransfer{}
+
+	err := testDB.WithTransaction(ctx, func(tx contract.Repos) error {
+		due, err := tx.RecurringTransfer().LockDueRecurringTransfers(ctx, 1000)
+		if err != nil {
+			return err
+		}
+
+		for _, recurring := range due {
+			occurrence := recurring.NextOccurrence()
+			occurrence.ScheduledTransferUUID = uuid.Must(uuid.NewV7()).String()
+			occurrence.TransferUUID = uuid.Must(uuid.NewV7()).String()
+
+			if _, err = tx.ScheduledTransfer().AddScheduledTransfer(ctx, occurrence); err != nil {
+				return err
+			}
+
+			recurring.Advance()
+			if err = tx.RecurringTransfer().UpdateRecurringTransfer(ctx, recurring); err != nil {
+				return err
+			}
+
+			generated[recurring.ID] = occurrence
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	return generated
+}
+
+func TestLockDueRecurringTransfers(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	due := createRecurringTransfer(t, from, to, time.Now().Add(-time.Minute))
+	notDue := createRecurringTransfer(t, from, to, time.Now().Add(time.Hour))
+
+	generated := generateDue(t)
+	require.Contains(t, generated, due.ID)
+	require.NotContains(t, generated, notDue.ID)
+	require.Equal(t, 1, generated[due.ID].Occurrence)
+
+	// the rule moved on a week, so it is not due again
+	require.NotContains(t, generateDue(t), due.ID)
+
+	got, err := testDB.RecurringTransfer().GetRecurringTransferByUUID(ctx, due.RecurringTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Occurrences)
+	require.WithinDuration(t, due.NextRunAt.AddDate(0, 0, 7), *got.NextRunAt, time.Second)
+
+	occurrence, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, generated[due.ID].ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, due.ID, occurrence.RecurringTransferID)
+	require.Equal(t, due.RecurringTransferUUID, occurrence.RecurringTransferUUID)
+	require.Equal(t, 1, occurrence.Occurrence)
+	require.Equal(t, 2, occurrence.MaxRetries)
+}
+
+func TestAddScheduledTransfer_occurrenceOnlyOnce(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	recurring := createRecurringTransfer(t, from, to, time.Now().Add(-time.Minute))
+
+	occurrence := recurring.NextOccurrence()
+	occurrence.ScheduledTransferUUID = uuid.Must(uuid.NewV7()).String()
+	occurrence.TransferUUID = uuid.Must(uuid.NewV7()).String()
+
+	_, err := testDB.ScheduledTransfer().AddScheduledTransfer(context.Background(), occurrence)
+	require.NoError(t, err)
+
+	occurrence.ScheduledTransferUUID = uuid.Must(uuid.NewV7()).String()
+	occurrence.TransferUUID = uuid.Must(uuid.NewV7()).String()
+
+	_, err = testDB.ScheduledTransfer().AddScheduledTransfer(context.Background(), occurrence)
+	require.Error(t, err)
+}
+
+func TestUpdateRecurringTransfer(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	recurring := createRecurringTransfer(t, from, to, time.Now().Add(time.Hour))
+
+	canceledAt := time.Now()
+	recurring.Amount = entity.NewMoney(250, entity.BRL)
+	recurring.InsufficientFunds = entity.InsufficientFundsSkip
+	recurring.MaxRetries = 0
+	recurring.Status = entity.RecurringTransferCanceled
+	recurring.NextRunAt = nil
+	recurring.CanceledAt = &canceledAt
+
+	err := testDB.RecurringTransfer().UpdateRecurringTransfer(ctx, recurring)
+	require.NoError(t, err)
+
+	got, err := testDB.RecurringTransfer().GetRecurringTransferByUUID(ctx, recurring.RecurringTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, recurring.Amount, got.Amount)
+	require.Equal(t, entity.InsufficientFundsSkip, got.InsufficientFunds)
+	require.Equal(t, entity.RecurringTransferCanceled, got.Status)
+	require.Nil(t, got.NextRunAt)
+	require.NotNil(t, got.CanceledAt)
+
+	// a canceled rule is never due
+	require.NotContains(t, generateDue(t), recurring.ID)
+}
+
+func TestCancelScheduledTransfersByRecurringID(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	recurring := createRecurringTransfer(t, from, to, time.Now().Add(-time.Minute))
+	occurrence := generateDue(t)[recurring.ID]
+
+	canceled, err := testDB.ScheduledTransfer().CancelScheduledTransfersByRecurringID(ctx, recurring.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), canceled)
+
+	got, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, occurrence.ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ScheduledTransferCanceled, got.Status)
+}
+
+func TestCancelRecurringTransfersByAccountID(t *testing.T) {
+	ctx := context.Background()
+	closing := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	outgoing := createRecurringTransfer(t, closing, other, time.Now().Add(time.Hour))
+	incoming := createRecurringTransfer(t, other, closing, time.Now().Add(time.Hour))
+	unrelated := createRecurringTransfer(t, other, createRandomAccount(t), time.Now().Add(time.Hour))
+
+	canceled, err := testDB.RecurringTransfer().CancelRecurringTransfersByAccountID(ctx, closing.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), canceled)
+
+	for _, recurring := range []entity.RecurringTransfer{outgoing, incoming} {
+		got, err := testDB.RecurringTransfer().GetRecurringTransferByUUID(ctx, recurring.RecurringTransferUUID)
+		require.NoError(t, err)
+		require.Equal(t, entity.RecurringTransferCanceled, got.Status)
+		require.Nil(t, got.NextRunAt)
+		require.NotNil(t, got.CanceledAt)
+	}
+
+	got, err := testDB.RecurringTransfer().GetRecurringTransferByUUID(ctx, unrelated.RecurringTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RecurringTransferActive, got.Status)
+}
+
+func TestGetRecurringTransfersByAccountID(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	older := createRecurringTransfer(t, from, to, time.Now().Add(time.Hour))
+	newer := createRecurringTransfer(t, from, to, time.Now().Add(time.Hour))
+
+	recurring, totalRecords, err := testDB.RecurringTransfer().GetRecurringTransfersByAccountID(ctx, from.ID, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), totalRecords)
+	require.Len(t, recurring, 2)
+	require.Equal(t, newer.ID, recurring[0].ID)
+	require.Equal(t, older.ID, recurring[1].ID)
+
+	recurring, totalRecords, err = testDB.RecurringTransfer().GetRecurringTransfersByAccountID(ctx, to.ID, 10, 0)
+	require.NoError(t, err)
+	require.Zero(t, totalRecords)
+	require.Empty(t, recurring)
+}
@@ -0,0 +1,375 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+)
+
+type scheduledTransferRepo struct {
+	queries
+}
+
+func newScheduledTransferRepo(db dbConn) contract.ScheduledTransferRepo {
+	return &scheduledTransferRepo{
+		queries: queries{db: db},
+	}
+}
+
+const queryScheduledTransferSelectBase string = `
+		SELECT
+			ts.scheduled_transfer_id,
+			ts.scheduled_transfer_uuid,
+			ts.account_origin_id,
+			origin.account_uuid AS account_origin_uuid,
+			ts.account_destination_id,
+			dest.account_uuid AS account_destination_uuid,
+			ts.amount,
+			ts.currency,
+			ts.scheduled_for,
+			ts.transfer_uuid,
+			ts.created_at,
+			ts.status,
+			COALESCE(ts.failure_reason, ''),
+			ts.attempts,
+			ts.executed_at,
+			ts.canceled_at,
+			COALESCE(ts.recurring_transfer_id, 0),
+			COALESCE(rt.recurring_transfer_uuid::text, ''),
+			COALESCE(ts.occurrence, 0),
+			ts.max_retries,
+			ts.retries
+
+		FROM 	tab_scheduled_transfer 	ts
+
+		INNER JOIN tab_account origin
+			ON origin.account_id = ts.account_origin_id
+
+		INNER JOIN tab_account dest
+			ON dest.account_id = ts.account_destination_id
+
+		LEFT JOIN tab_recurring_transfer rt
+			ON rt.recurring_transfer_id = ts.recurring_transfer_id
+		`
+
+// parseScheduledTransfer scans a row of queryScheduledTransferSelectBase, and
+// the count column withCount appends when total is given.
+func (r *scheduledTransferRepo) parseScheduledTransfer(row scanner, total ...*int64) (scheduled entity.ScheduledTransfer, err error) {
+	var amount int64
+	var currency string
+	var status string
+
+	dests := []any{
+		&scheduled.ID,
+		&scheduled.ScheduledTransferUUID,
+		&scheduled.AccountOriginID,
+		&scheduled.AccountOriginUUID,
+		&scheduled.AccountDestinationID,
+		&scheduled.AccountDestinationUUID,
+		&amount,
+		&currency,
+		&scheduled.ScheduledFor,
+		&scheduled.TransferUUID,
+		&scheduled.CreatedAt,
+		&status,
+		&scheduled.FailureReason,
+		&scheduled.Attempts,
+		&scheduled.ExecutedAt,
+		&scheduled.CanceledAt,
+		&scheduled.RecurringTransferID,
+		&scheduled.RecurringTransferUUID,
+		&scheduled.Occurrence,
+		&scheduled.MaxRetries,
+		&scheduled.Retries,
+	}
+
+	if len(total) > 0 && total[0] != nil {
+		dests = append(dests, total[0])
+	}
+
+	err = row.Scan(dests...)
+	if err != nil {
+		return scheduled, err
+	}
+
+	scheduled.Amount = entity.NewMoney(amount, entity.Currency(currency))
+	scheduled.Status = entity.ScheduledTransferStatus(status)
+	return scheduled, nil
+}
+
+func (r *scheduledTransferRepo) AddScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer) (scheduledID int64, err error) {
+	query := `
+		INSERT INTO tab_scheduled_transfer (
+			scheduled_transfer_uuid,
+			account_origin_id,
+			account_destination_id,
+			amount,
+			currency,
+			scheduled_for,
+			transfer_uuid,
+			status,
+			recurring_transfer_id,
+			occurrence,
+			max_retries
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), NULLIF($10, 0), $11)
+		RETURNING scheduled_transfer_id;
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		scheduled.ScheduledTransferUUID,
+		scheduled.AccountOriginID,
+		scheduled.AccountDestinationID,
+		scheduled.Amount.Amount(),
+		string(scheduled.Amount.Currency()),
+		scheduled.ScheduledFor,
+		scheduled.TransferUUID,
+		string(entity.ScheduledTransferPending),
+		scheduled.RecurringTransferID,
+		scheduled.Occurrence,
+		scheduled.MaxRetries,
+	).Scan(&scheduledID)
+	if err != nil {
+		return scheduledID, handleDBError(err)
+	}
+
+	return scheduledID, nil
+}
+
+// CancelScheduledTransfer calls the schedule off as long as no worker claimed
+// it yet. canceled is false when one did.
+func (r *scheduledTransferRepo) CancelScheduledTransfer(ctx context.Context, scheduledID int64) (canceled bool, err error) {
+	query := `
+		UPDATE tab_scheduled_transfer
+		SET status 		= $2,
+			canceled_at = NOW(),
+			update_at 	= NOW()
+		WHERE scheduled_transfer_id = $1
+		  AND status 				= $3;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		scheduledID,
+		string(entity.ScheduledTransferCanceled),
+		string(entity.ScheduledTransferPending),
+	)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// CancelScheduledTransfersByAccountID calls off the schedules no worker claimed
+// yet that move money out of or into accountID.
+func (r *scheduledTransferRepo) CancelScheduledTransfersByAccountID(ctx context.Context, accountID int64) (canceled int64, err error) {
+	query := `
+		UPDATE tab_scheduled_transfer
+		SET status 		= $2,
+			canceled_at = NOW(),
+			update_at 	= NOW()
+		WHERE (account_origin_id = $1 OR account_destination_id = $1)
+		  AND status = $3;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		accountID,
+		string(entity.ScheduledTransferCanceled),
+		string(entity.ScheduledTransferPending),
+	)
+	if err != nil {
+		return canceled, handleDBError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CancelScheduledTransfersByRecurringID calls off the occurrences of a standing
+// order that no worker claimed yet.
+func (r *scheduledTransferRepo) CancelScheduledTransfersByRecurringID(ctx context.Context, recurringID int64) (canceled int64, err error) {
+	query := `
+		UPDATE tab_scheduled_transfer
+		SET status 		= $2,
+			canceled_at = NOW(),
+			update_at 	= NOW()
+		WHERE recurring_transfer_id = $1
+		  AND status 				= $3;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		recurringID,
+		string(entity.ScheduledTransferCanceled),
+		string(entity.ScheduledTransferPending),
+	)
+	if err != nil {
+		return canceled, handleDBError(err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ClaimDueScheduledTransfers marks up to limit due schedules as processing and
+// returns them. Rows another worker holds are skipped rather than waited for,
+// so workers polling together split the batch between them. A row left in
+// processing since before staleClaimBefore belongs to a worker that died, and
+// is claimed again.
+func (r *scheduledTransferRepo) ClaimDueScheduledTransfers(ctx context.Context, limit int64, staleClaimBefore time.Time) (claimed []entity.ScheduledTransfer, err error) {
+	// the claimed rows are only visible through the RETURNING of the update,
+	// so the accounts are joined on that and not on the table
+	query := `
+		WITH due AS (
+			SELECT 	scheduled_transfer_id
+
+			FROM 	tab_scheduled_transfer
+
+			WHERE	(status = $2 AND scheduled_for 	<= 	NOW())
+			   OR	(status = $3 AND claimed_at 	< 	$4)
+
+			ORDER BY scheduled_for
+			LIMIT $1
+
+			FOR UPDATE SKIP LOCKED
+		), ts AS (
+			UPDATE tab_scheduled_transfer st
+			SET status 		= $3,
+				attempts 	= st.attempts + 1,
+				claimed_at 	= NOW(),
+				update_at 	= NOW()
+			FROM due
+			WHERE st.scheduled_transfer_id = due.scheduled_transfer_id
+			RETURNING st.*
+		)
+		SELECT
+			ts.scheduled_transfer_id,
+			ts.scheduled_transfer_uuid,
+			ts.account_origin_id,
+			origin.account_uuid AS account_origin_uuid,
+			ts.account_destination_id,
+			dest.account_uuid AS account_destination_uuid,
+			ts.amount,
+			ts.currency,
+			ts.scheduled_for,
+			ts.transfer_uuid,
+			ts.created_at,
+			ts.status,
+			COALESCE(ts.failure_reason, ''),
+			ts.attempts,
+			ts.executed_at,
+			ts.canceled_at,
+			COALESCE(ts.recurring_transfer_id, 0),
+			COALESCE(rt.recurring_transfer_uuid::text, ''),
+			COALESCE(ts.occurrence, 0),
+			ts.max_retries,
+			ts.retries
+
+		FROM 	ts
+
+		INNER JOIN tab_account origin
+			ON origin.account_id = ts.account_origin_id
+
+		INNER JOIN tab_account dest
+			ON dest.account_id = ts.account_destination_id
+
+		LEFT JOIN tab_recurring_transfer rt
+			ON rt.recurring_transfer_id = ts.recurring_transfer_id
+
+		ORDER BY ts.scheduled_for
+	`
+
+	return r.queryList(ctx, query, func(row scanner) (entity.ScheduledTransfer, error) {
+		return r.parseScheduledTransfer(row)
+	},
+		limit,
+		string(entity.ScheduledTransferPending),
+		string(entity.ScheduledTransferProcessing),
+		staleClaimBefore,
+	)
+}
+
+func (r *scheduledTransferRepo) GetScheduledTransferByUUID(ctx context.Context, scheduledTransferUUID string) (scheduled entity.ScheduledTransfer, err error) {
+	query := queryScheduledTransferSelectBase + `
+		WHERE	ts.scheduled_transfer_uuid 	= 	$1
+	`
+
+	return r.queryOne(ctx, query, func(row scanner) (entity.ScheduledTransfer, error) {
+		return r.parseScheduledTransfer(row)
+	}, scheduledTransferUUID)
+}
+
+// GetScheduledTransfersByAccountID lists what accountID scheduled, soonest
+// first. An empty status lists them all.
+func (r *scheduledTransferRepo) GetScheduledTransfersByAccountID(ctx context.Context, accountID int64, status entity.ScheduledTransferStatus, take, skip int64) (scheduled []entity.ScheduledTransfer, totalRecords int64, err error) {
+	b := newSQLBuilder(queryScheduledTransferSelectBase)
+	b.Where("ts.account_origin_id = " + b.Arg(accountID))
+
+	if status != "" {
+		b.Where("ts.status = " + b.Arg(string(status)))
+	}
+
+	b.OrderBy("ts.scheduled_for", "ts.scheduled_transfer_id").Limit(take).Offset(skip)
+
+	scheduled, err = r.queryList(ctx, withCount(b.Query()), func(row scanner) (entity.ScheduledTransfer, error) {
+		return r.parseScheduledTransfer(row, &totalRecords)
+	}, b.Args()...)
+
+	return scheduled, totalRecords, err
+}
+
+// UpdateScheduledTransferStatus settles a claimed schedule, as long as it is
+// still in from. updated is false when it isn't.
+func (r *scheduledTransferRepo) UpdateScheduledTransferStatus(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (updated bool, err error) {
+	query := `
+		UPDATE tab_scheduled_transfer
+		SET status 			= $2,
+			failure_reason 	= NULLIF($3, ''),
+			executed_at 	= $4,
+			update_at 		= NOW()
+		WHERE scheduled_transfer_id = $1
+		  AND status 				= $5;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		scheduled.ID,
+		string(scheduled.Status),
+		scheduled.FailureReason,
+		scheduled.ExecutedAt,
+		string(from),
+	)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *scheduledTransferRepo) RescheduleScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (updated bool, err error) {
+	query := `
+		UPDATE tab_scheduled_transfer
+		SET status 			= $2,
+			scheduled_for 	= $3,
+			transfer_uuid 	= $4,
+			retries 		= $5,
+			failure_reason 	= NULLIF($6, ''),
+			claimed_at 		= NULL,
+			update_at 		= NOW()
+		WHERE scheduled_transfer_id = $1
+		  AND status 				= $7;
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		scheduled.ID,
+		string(scheduled.Status),
+		scheduled.ScheduledFor,
+		scheduled.TransferUUID,
+		scheduled.Retries,
+		scheduled.FailureReason,
+		string(from),
+	)
+	if err != nil {
+		return false, handleDBError(err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
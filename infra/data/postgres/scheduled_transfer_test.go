@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createScheduledTransfer(t *testing.T, from, to entity.Account, scheduledFor time.Time) entity.ScheduledTransfer {
+	ctx := context.Background()
+
+	args := entity.ScheduledTransfer{
+		ScheduledTransferUUID: uuid.Must(uuid.NewV7()).String(),
+		AccountOriginID:       from.ID,
+		AccountDestinationID:  to.ID,
+		Amount:                entity.NewMoney(100, entity.BRL),
+		ScheduledFor:          scheduledFor,
+		TransferUUID:          uuid.Must(uuid.NewV7()).String(),
+	}
+
+	id, err := testDB.ScheduledTransfer().AddScheduledTransfer(ctx, args)
+	require.NoError(t, err)
+	require.NotZero(t, id)
+
+	scheduled, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, args.ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, id, scheduled.ID)
+	require.Equal(t, from.UUID, scheduled.AccountOriginUUID)
+	require.Equal(t, to.UUID, scheduled.AccountDestinationUUID)
+	require.Equal(t, args.Amount, scheduled.Amount)
+	require.Equal(t, args.TransferUUID, scheduled.TransferUUID)
+	require.Equal(t, entity.ScheduledTransferPending, scheduled.Status)
+	require.WithinDuration(t, scheduledFor, scheduled.ScheduledFor, time.Second)
+
+	return scheduled
+}
+
+// claimedIDs claims everything due and returns the ids claimed, which may
+// include rows of other tests sharing the database
+func claimedIDs(t *testing.T, staleClaimBefore time.Time) map[int64]entity.ScheduledTransfer {
+	claimed, err := testDB.ScheduledTransfer().ClaimDueScheduledTransfers(context.Background(), 1000, staleClaimBefore)
+	require.NoError(t, err)
+
+	ids := make(map[int64]entity.ScheduledTransfer, len(claimed))
+	for _, scheduled := range claimed {
+		ids[scheduled.ID] = scheduled
+	}
+	return ids
+}
+
+func TestClaimDueScheduledTransfers(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	due := createScheduledTransfer(t, from, to, time.Now().Add(-time.Minute))
+	notDue := createScheduledTransfer(t, from, to, time.Now().Add(time.Hour))
+
+	claimed := claimedIDs(t, time.Now().Add(-time.Hour))
+	require.Contains(t, claimed, due.ID)
+	require.NotContains(t, claimed, notDue.ID)
+	require.Equal(t, entity.ScheduledTransferProcessing, claimed[due.ID].Status)
+	require.Equal(t, 1, claimed[due.ID].Attempts)
+
+	// a fresh claim is left with the worker that holds it
+	claimed = claimedIDs(t, time.Now().Add(-time.Hour))
+	require.NotContains(t, claimed, due.ID)
+
+	// a stale one is taken over
+	claimed = claimedIDs(t, time.Now().Add(time.Minute))
+	require.Contains(t, claimed, due.ID)
+	require.Equal(t, 2, claimed[due.ID].Attempts)
+}
+
+func TestClaimDueScheduledTransfersConcurrently(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	created := map[int64]bool{}
+	for i := 0; i < 10; i++ {
+		scheduled := createScheduledTransfer(t, from, to, time.Now().Add(-time.Minute))
+		created[scheduled.ID] = true
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claims  = map[int64]int{}
+		workers = 4
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			claimed, err := testDB.ScheduledTransfer().ClaimDueScheduledTransfers(context.Background(), 3, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, scheduled := range claimed {
+				claims[scheduled.ID]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	for id, count := range claims {
+		require.Equal(t, 1, count, "scheduled transfer %d claimed by more than one worker", id)
+	}
+
+	// whatever the workers above didn't get is still there to be claimed
+	for id := range claimedIDs(t, time.Now().Add(-time.Hour)) {
+		claims[id]++
+	}
+	for id := range created {
+		require.Equal(t, 1, claims[id], "scheduled transfer %d", id)
+	}
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	scheduled := createScheduledTransfer(t, from, to, time.Now().Add(time.Hour))
+
+	canceled, err := testDB.ScheduledTransfer().CancelScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.True(t, canceled)
+
+	got, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, scheduled.ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ScheduledTransferCanceled, got.Status)
+	require.NotNil(t, got.CanceledAt)
+
+	// only a pending one can be canceled
+	canceled, err = testDB.ScheduledTransfer().CancelScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.False(t, canceled)
+}
+
+func TestCancelScheduledTransfersByAccountID(t *testing.T) {
+	ctx := context.Background()
+	closing := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	outgoing := createScheduledTransfer(t, closing, other, time.Now().Add(time.Hour))
+	incoming := createScheduledTransfer(t, other, closing, time.Now().Add(time.Hour))
+	unrelated := createScheduledTransfer(t, other, createRandomAccount(t), time.Now().Add(time.Hour))
+
+	canceled, err := testDB.ScheduledTransfer().CancelScheduledTransfersByAccountID(ctx, closing.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), canceled)
+
+	for _, scheduled := range []entity.ScheduledTransfer{outgoing, incoming} {
+		got, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, scheduled.ScheduledTransferUUID)
+		require.NoError(t, err)
+		require.Equal(t, entity.ScheduledTransferCanceled, got.Status)
+		require.NotNil(t, got.CanceledAt)
+	}
+
+	got, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, unrelated.ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ScheduledTransferPending, got.Status)
+}
+
+func TestUpdateScheduledTransferStatus(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	scheduled := createScheduledTransfer(t, from, to, time.Now().Add(-time.Minute))
+	scheduled = claimedIDs(t, time.Now().Add(-time.Hour))[scheduled.ID]
+	require.Equal(t, entity.ScheduledTransferProcessing, scheduled.Status)
+
+	executedAt := time.Now()
+	scheduled.Status = entity.ScheduledTransferFailed
+	scheduled.FailureReason = "insufficient funds"
+	scheduled.ExecutedAt = &executedAt
+
+	updated, err := testDB.ScheduledTransfer().UpdateScheduledTransferStatus(ctx, scheduled, entity.ScheduledTransferProcessing)
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	got, err := testDB.ScheduledTransfer().GetScheduledTransferByUUID(ctx, scheduled.ScheduledTransferUUID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ScheduledTransferFailed, got.Status)
+	require.Equal(t, "insufficient funds", got.FailureReason)
+	require.NotNil(t, got.ExecutedAt)
+
+	// settling twice finds it no longer processing
+	updated, err = testDB.ScheduledTransfer().UpdateScheduledTransferStatus(ctx, scheduled, entity.ScheduledTransferProcessing)
+	require.NoError(t, err)
+	require.False(t, updated)
+}
+
+func TestGetScheduledTransfersByAccountID(t *testing.T) {
+	ctx := context.Background()
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	later := createScheduledTransfer(t, from, to, time.Now().Add(2*time.Hour))
+	sooner := createScheduledTransfer(t, from, to, time.Now().Add(time.Hour))
+	canceled := createScheduledTransfer(t, from, to, time.Now().Add(3*time.Hour))
+
+	_, err := testDB.ScheduledTransfer().CancelScheduledTransfer(ctx, canceled.ID)
+	require.NoError(t, err)
+
+	pending, totalRecords, err := testDB.ScheduledTransfer().GetScheduledTransfersByAccountID(ctx, from.ID, entity.ScheduledTransferPending, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), totalRecords)
+	require.Len(t, pending, 2)
+	require.Equal(t, sooner.ID, pending[0].ID)
+	require.Equal(t, later.ID, pending[1].ID)
+
+	all, totalRecords, err := testDB.ScheduledTransfer().GetScheduledTransfersByAccountID(ctx, from.ID, "", 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), totalRecords)
+	require.Len(t, all, 1)
+
+	// nothing is listed for the destination
+	received, totalRecords, err := testDB.ScheduledTransfer().GetScheduledTransfersByAccountID(ctx, to.ID, "", 10, 0)
+	require.NoError(t, err)
+	require.Zero(t, totalRecords)
+	require.Empty(t, received)
+}
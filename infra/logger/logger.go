@@ -7,13 +7,15 @@ import (
 	"github.com/diegoclair/logger"
 )
 
+// NewLogger returns a logger that redacts, with RedactField, both the
+// attributes taken from the context and the ones given to each call.
 func NewLogger(appName string, debugLevel bool) logger.Logger {
 	params := logger.Params{
 		AppName:          appName,
 		DebugLevel:       debugLevel,
-		ContextExtractor: addDefaultAttributesToLogger,
+		ContextExtractor: withRedaction(addDefaultAttributesToLogger, RedactField),
 	}
-	return logger.New(params)
+	return &redactingLogger{Logger: logger.New(params), redact: RedactField}
 }
 
 func addDefaultAttributesToLogger(ctx context.Context) []logger.Field {
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/diegoclair/go_boilerplate/util/mask"
+	"github.com/diegoclair/logger"
+)
+
+const redacted = "[REDACTED]"
+
+// Redactor rewrites an attribute before it is logged, to keep personal data
+// and secrets out of the logs.
+type Redactor func(field logger.Field) logger.Field
+
+var (
+	// secretKeys are attributes never logged, whatever their value
+	secretKeys = []string{"password", "secret", "token", "authorization", "cookie", "user_agent", "recovery_code", "totp"}
+
+	cpfPattern    = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	pasetoPattern = regexp.MustCompile(`\bv[1-4]\.(local|public)\.[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)?`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+\S+`)
+	// opaquePattern is a token from rand.Text, as refresh and reset tokens are
+	opaquePattern = regexp.MustCompile(`\b[A-Z2-7]{26}\b`)
+)
+
+// RedactField masks the CPFs and the ip addresses, and drops the passwords
+// and tokens, of a field, going by its key first and by what its text looks
+// like after that. Only text and errors are looked into; other values are
+// logged as they are.
+func RedactField(field logger.Field) logger.Field {
+	key := strings.ToLower(field.Key)
+	value := field.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		attrs := value.Group()
+		redactedAttrs := make([]any, len(attrs))
+		for i, attr := range attrs {
+			redactedAttrs[i] = RedactField(attr)
+		}
+		return slog.Group(field.Key, redactedAttrs...)
+	}
+
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(field.Key, redacted)
+		}
+	}
+
+	switch {
+	case key == "cpf" || strings.HasSuffix(key, "_cpf"):
+		return slog.String(field.Key, mask.CPF(value.String()))
+	case key == "ip" || strings.HasSuffix(key, "_ip"):
+		return slog.String(field.Key, mask.IP(value.String()))
+	}
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(field.Key, RedactText(value.String()))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok && err != nil {
+			return slog.String(field.Key, RedactText(err.Error()))
+		}
+	}
+
+	return field
+}
+
+// RedactText masks the CPFs and drops the tokens found in text.
+func RedactText(text string) string {
+	text = pasetoPattern.ReplaceAllString(text, redacted)
+	text = bearerPattern.ReplaceAllString(text, "Bearer "+redacted)
+	text = opaquePattern.ReplaceAllString(text, redacted)
+	return cpfPattern.ReplaceAllStringFunc(text, mask.CPF)
+}
+
+// withRedaction runs redact over what extract adds to every log.
+func withRedaction(extract func(ctx context.Context) []logger.Field, redact Redactor) func(ctx context.Context) []logger.Field {
+	return func(ctx context.Context) []logger.Field {
+		return redactFields(extract(ctx), redact)
+	}
+}
+
+func redactFields(fields []logger.Field, redact Redactor) []logger.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redactedFields := make([]logger.Field, len(fields))
+	for i, field := range fields {
+		redactedFields[i] = redact(field)
+	}
+	return redactedFields
+}
+
+// redactingLogger runs redact over the fields of every call, which the
+// context extractor never sees.
+type redactingLogger struct {
+	logger.Logger
+	redact Redactor
+}
+
+func (l *redactingLogger) Info(ctx context.Context, msg string, fields ...logger.Field) {
+	l.Logger.Info(ctx, RedactText(msg), redactFields(fields, l.redact)...)
+}
+
+func (l *redactingLogger) Debug(ctx context.Context, msg string, fields ...logger.Field) {
+	l.Logger.Debug(ctx, RedactText(msg), redactFields(fields, l.redact)...)
+}
+
+func (l *redactingLogger) Warn(ctx context.Context, msg string, fields ...logger.Field) {
+	l.Logger.Warn(ctx, RedactText(msg), redactFields(fields, l.redact)...)
+}
+
+func (l *redactingLogger) Error(ctx context.Context, msg string, fields ...logger.Field) {
+	l.Logger.Error(ctx, RedactText(msg), redactFields(fields, l.redact)...)
+}
+
+func (l *redactingLogger) Fatal(ctx context.Context, msg string, fields ...logger.Field) {
+	l.Logger.Fatal(ctx, RedactText(msg), redactFields(fields, l.redact)...)
+}
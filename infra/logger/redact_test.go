@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field logger.Field
+		want  string
+	}{
+		{
+			name:  "Should mask a cpf attribute",
+			field: logger.Attr("cpf", "12345678909"),
+			want:  "***.456.789-**",
+		},
+		{
+			name:  "Should mask an attribute ending in _cpf",
+			field: logger.Attr("destination_cpf", "123.456.789-09"),
+			want:  "***.456.789-**",
+		},
+		{
+			name:  "Should drop a password whatever its value",
+			field: logger.Attr("new_password", "12345678"),
+			want:  redacted,
+		},
+		{
+			name:  "Should drop a token whatever its value",
+			field: logger.Attr("refresh_token", "anything"),
+			want:  redacted,
+		},
+		{
+			name:  "Should drop a secret whatever the case of the key",
+			field: logger.Attr("TOTP_Secret", 123),
+			want:  redacted,
+		},
+		{
+			name:  "Should mask an ip to its network",
+			field: logger.Attr("client_ip", "189.40.12.7"),
+			want:  "189.40.12.0/24",
+		},
+		{
+			name:  "Should drop the user agent",
+			field: logger.Attr("user_agent", "Mozilla/5.0"),
+			want:  redacted,
+		},
+		{
+			name:  "Should mask a cpf within text",
+			field: logger.Attr("reason", "holder 123.456.789-09 asked for it"),
+			want:  "holder ***.456.789-** asked for it",
+		},
+		{
+			name:  "Should mask a cpf within an error",
+			field: logger.Err(errors.New(`duplicate key value (cpf)=(12345678909)`)),
+			want:  `duplicate key value (cpf)=(***.456.789-**)`,
+		},
+		{
+			name:  "Should drop a paseto token within text",
+			field: logger.Attr("data", "got v4.local.abc_DEF-123.eyJraWQiOiJ4In0 from the client"),
+			want:  "got " + redacted + " from the client",
+		},
+		{
+			name:  "Should drop a bearer credential within text",
+			field: logger.Attr("header", "Authorization: Bearer abc.def"),
+			want:  "Authorization: Bearer " + redacted,
+		},
+		{
+			name:  "Should drop an opaque token within text",
+			field: logger.Attr("data", "reset with ABCDEFGHIJKLMNOPQRSTUVWXYZ now"),
+			want:  "reset with " + redacted + " now",
+		},
+		{
+			name:  "Should leave anything else as it is",
+			field: logger.Attr("account_uuid", "d152a340-9a87-4d32-85ad-19df4c9934cd"),
+			want:  "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactField(tt.field)
+			require.Equal(t, tt.field.Key, got.Key)
+			require.Equal(t, tt.want, got.Value.String())
+		})
+	}
+
+	t.Run("Should redact within a group", func(t *testing.T) {
+		got := RedactField(slog.Group("login", slog.String("cpf", "12345678909"), slog.String("password", "12345678"), slog.Int("attempt", 2)))
+
+		attrs := got.Value.Group()
+		require.Len(t, attrs, 3)
+		require.Equal(t, "***.456.789-**", attrs[0].Value.String())
+		require.Equal(t, redacted, attrs[1].Value.String())
+		require.Equal(t, int64(2), attrs[2].Value.Int64())
+	})
+
+	t.Run("Should keep a value that isn't text", func(t *testing.T) {
+		got := RedactField(logger.Attr("retry", 3))
+		require.Equal(t, int64(3), got.Value.Int64())
+	})
+}
+
+func TestWithRedaction(t *testing.T) {
+	extract := withRedaction(func(context.Context) []logger.Field {
+		return []logger.Field{logger.Attr("cpf", "12345678909")}
+	}, RedactField)
+
+	fields := extract(context.Background())
+	require.Len(t, fields, 1)
+	require.Equal(t, "***.456.789-**", fields[0].Value.String())
+
+	// the default attributes hold nothing to redact
+	ctx := context.WithValue(context.Background(), infra.SessionKey, "sessionCode")
+	require.Equal(t, addDefaultAttributesToLogger(ctx), withRedaction(addDefaultAttributesToLogger, RedactField)(ctx))
+}
+
+// recordingLogger keeps the fields it is given, to see what reaches the
+// wrapped logger.
+type recordingLogger struct {
+	logger.Logger
+	msg    string
+	fields []logger.Field
+}
+
+func (l *recordingLogger) Error(_ context.Context, msg string, fields ...logger.Field) {
+	l.msg, l.fields = msg, fields
+}
+
+func TestRedactingLogger(t *testing.T) {
+	recorder := &recordingLogger{Logger: logger.NewNoop()}
+	log := &redactingLogger{Logger: recorder, redact: RedactField}
+
+	log.Error(context.Background(), "login failed for 12345678909", logger.Attr("password", "12345678"))
+
+	require.Equal(t, "login failed for ***.456.789-**", recorder.msg)
+	require.Len(t, recorder.fields, 1)
+	require.Equal(t, redacted, recorder.fields[0].Value.String())
+}
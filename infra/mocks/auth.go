@@ -57,6 +57,22 @@ func (mr *MockAuthTokenMockRecorder) CreateAccessToken(ctx, input any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockAuthToken)(nil).CreateAccessToken), ctx, input)
 }
 
+// CreateMFAToken mocks base method.
+func (m *MockAuthToken) CreateMFAToken(ctx context.Context, input contract.TokenPayloadInput) (string, contract.TokenPayload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAToken", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(contract.TokenPayload)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateMFAToken indicates an expected call of CreateMFAToken.
+func (mr *MockAuthTokenMockRecorder) CreateMFAToken(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAToken", reflect.TypeOf((*MockAuthToken)(nil).CreateMFAToken), ctx, input)
+}
+
 // CreateRefreshToken mocks base method.
 func (m *MockAuthToken) CreateRefreshToken(ctx context.Context, input contract.TokenPayloadInput) (string, contract.TokenPayload, error) {
 	m.ctrl.T.Helper()
@@ -73,6 +89,21 @@ func (mr *MockAuthTokenMockRecorder) CreateRefreshToken(ctx, input any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockAuthToken)(nil).CreateRefreshToken), ctx, input)
 }
 
+// VerifyMFAToken mocks base method.
+func (m *MockAuthToken) VerifyMFAToken(ctx context.Context, token string) (contract.TokenPayload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFAToken", ctx, token)
+	ret0, _ := ret[0].(contract.TokenPayload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFAToken indicates an expected call of VerifyMFAToken.
+func (mr *MockAuthTokenMockRecorder) VerifyMFAToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFAToken", reflect.TypeOf((*MockAuthToken)(nil).VerifyMFAToken), ctx, token)
+}
+
 // VerifyToken mocks base method.
 func (m *MockAuthToken) VerifyToken(ctx context.Context, token string) (contract.TokenPayload, error) {
 	m.ctrl.T.Helper()
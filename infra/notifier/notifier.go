@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/logger"
+)
+
+// LogNotifier is the notifier for local development and tests. It delivers
+// nothing: each notification is written to the log or, with a path, appended
+// to that file as a line of JSON. Notifications carry secrets such as reset
+// tokens, so it has no place in production.
+type LogNotifier struct {
+	log  logger.Logger
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogNotifier returns a notifier that writes to path, or to log when path
+// is empty.
+func NewLogNotifier(log logger.Logger, path string) *LogNotifier {
+	return &LogNotifier{
+		log:  log,
+		path: path,
+	}
+}
+
+// record is a notification as a line of the file.
+type record struct {
+	Kind        entity.NotificationKind `json:"kind"`
+	AccountUUID string                  `json:"account_uuid"`
+	Name        string                  `json:"name"`
+	Data        map[string]string       `json:"data"`
+	SentAt      time.Time               `json:"sent_at"`
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	if n.path == "" {
+		n.log.Info(ctx, "notification sent",
+			logger.Attr("kind", notification.Kind),
+			logger.Attr("account_uuid", notification.AccountUUID),
+			logger.Attr("data", notification.Data),
+		)
+		return nil
+	}
+
+	line, err := json.Marshal(record{
+		Kind:        notification.Kind,
+		AccountUUID: notification.AccountUUID,
+		Name:        notification.Name,
+		Data:        notification.Data,
+		SentAt:      time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
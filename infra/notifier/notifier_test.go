@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogNotifier_Notify(t *testing.T) {
+	ctx := context.Background()
+	notification := entity.Notification{
+		Kind:        entity.NotificationPasswordReset,
+		AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		Name:        "John Doe",
+		Data:        map[string]string{"token": "reset-token"},
+	}
+
+	t.Run("Should append each notification to the file as a line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notifications.jsonl")
+		n := NewLogNotifier(logger.NewNoop(), path)
+
+		require.NoError(t, n.Notify(ctx, notification))
+		require.NoError(t, n.Notify(ctx, notification))
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		lines := 0
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines++
+
+			var got record
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+			require.Equal(t, notification.Kind, got.Kind)
+			require.Equal(t, notification.AccountUUID, got.AccountUUID)
+			require.Equal(t, "reset-token", got.Data["token"])
+			require.False(t, got.SentAt.IsZero())
+		}
+		require.NoError(t, scanner.Err())
+		require.Equal(t, 2, lines)
+	})
+
+	t.Run("Should write to the log without a path", func(t *testing.T) {
+		n := NewLogNotifier(logger.NewNoop(), "")
+		require.NoError(t, n.Notify(ctx, notification))
+	})
+
+	t.Run("Should return error when the file can't be opened", func(t *testing.T) {
+		n := NewLogNotifier(logger.NewNoop(), filepath.Join(t.TempDir(), "missing", "notifications.jsonl"))
+		require.Error(t, n.Notify(ctx, notification))
+	})
+}
@@ -17,10 +17,17 @@ const gracefulShutdownTimeout = 10 * time.Second
 
 type ShutdownOptions func(s *shutdown)
 
+// Worker is a background process that has to finish what it is doing before
+// the process exits.
+type Worker interface {
+	Stop(ctx context.Context) error
+}
+
 type shutdown struct {
 	restServer *echo.Echo
 	grpcServer *grpc.Server
 	listener   net.Listener
+	workers    []Worker
 }
 
 func GracefulShutdown(ctx context.Context, log logger.Logger, opts ...ShutdownOptions) {
@@ -56,6 +63,18 @@ func GracefulShutdown(ctx context.Context, log logger.Logger, opts ...ShutdownOp
 		s.grpcServer.GracefulStop()
 	}
 
+	// stopped after the servers, so nothing new is scheduled while they drain
+	for _, worker := range s.workers {
+		ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTimeout)
+
+		err := worker.Stop(ctx)
+		if err != nil {
+			log.Error(ctx, "Failed to stop worker", logger.Err(err))
+		}
+
+		cancel()
+	}
+
 	if s.listener != nil {
 		s.listener.Close()
 	}
@@ -78,3 +97,9 @@ func WithListener(listener net.Listener) ShutdownOptions {
 		s.listener = listener
 	}
 }
+
+func WithWorker(worker Worker) ShutdownOptions {
+	return func(s *shutdown) {
+		s.workers = append(s.workers, worker)
+	}
+}
@@ -2,7 +2,11 @@ package dto
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/util/number"
 	"github.com/diegoclair/appvalidator/apperrmap"
@@ -33,11 +37,159 @@ func (a *AccountInput) ToEntityValidate(ctx context.Context, v apperrmap.Validat
 }
 
 type AddBalanceInput struct {
-	AccountUUID string  `validate:"required,uuid"`
-	Amount      float64 `validate:"required,gt=0"`
+	AccountUUID string `validate:"required,uuid"`
+	Amount      entity.Money
 }
 
 // Validate validate the input
 func (a *AddBalanceInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	err := v.ValidateStruct(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	return validateAmount(a.Amount)
+}
+
+// validateAmount is the amount check the validator can't do: Money is a struct,
+// so tags like gt=0 don't apply to it.
+func validateAmount(amount entity.Money) error {
+	if !amount.IsPositive() {
+		return apperr.ErrInvalidInput.WithMessage("amount must be greater than zero")
+	}
+	return nil
+}
+
+var cpfPunctuation = strings.NewReplacer(".", "", "-", "")
+
+// accountSortFields are what a list of accounts can be sorted by.
+var accountSortFields = []string{"name", "created_at", "balance"}
+
+type AccountSearchInput struct {
+	Name        string `validate:"max=100"`
+	Active      *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// CPF matches a whole one: it is stored encrypted, which leaves no way to
+	// match part of it
+	CPF string `validate:"omitempty,numeric,len=11"`
+	// Sort lists the fields to sort by, each one optionally followed by :asc
+	// or :desc, as in created_at:desc
+	Sort []string
+}
+
+// ToEntityValidate validate the input and return the entity
+func (a *AccountSearchInput) ToEntityValidate(ctx context.Context, v apperrmap.Validator) (filter entity.AccountFilter, err error) {
+	a.Name = strings.TrimSpace(a.Name)
+	// a formatted cpf, as 123.456.789-09, is fine; anything else but digits
+	// is not
+	a.CPF = cpfPunctuation.Replace(a.CPF)
+
+	err = v.ValidateStruct(ctx, a)
+	if err != nil {
+		return filter, err
+	}
+
+	if a.CreatedFrom != nil && a.CreatedTo != nil && !a.CreatedFrom.Before(*a.CreatedTo) {
+		return filter, apperr.ErrInvalidInput.WithMessage("from must be before to")
+	}
+
+	filter = entity.AccountFilter{
+		Name:        a.Name,
+		Active:      a.Active,
+		CreatedFrom: a.CreatedFrom,
+		CreatedTo:   a.CreatedTo,
+	}
+
+	for _, raw := range a.Sort {
+		field, direction, _ := strings.Cut(raw, ":")
+
+		if !slices.Contains(accountSortFields, field) {
+			return filter, apperr.ErrInvalidInput.WithMessage("can not sort by " + field + ", only by " + strings.Join(accountSortFields, ", "))
+		}
+
+		if direction != "" && direction != "asc" && direction != "desc" {
+			return filter, apperr.ErrInvalidInput.WithMessage("sort direction must be asc or desc")
+		}
+
+		filter.Sort = append(filter.Sort, entity.SortField{Field: field, Descending: direction == "desc"})
+	}
+
+	return filter, nil
+}
+
+// AccountStatusInput asks to deactivate or reactivate an account, saying why.
+type AccountStatusInput struct {
+	AccountUUID string `validate:"required,uuid"`
+	Reason      string `validate:"required,max=500"`
+}
+
+// Validate validate the input
+func (a *AccountStatusInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	a.Reason = strings.TrimSpace(a.Reason)
 	return v.ValidateStruct(ctx, a)
 }
+
+// RoleInput asks to change what an account is allowed to do.
+type RoleInput struct {
+	AccountUUID string `validate:"required,uuid"`
+	Role        string `validate:"required,oneof=customer support admin"`
+}
+
+// Validate validate the input
+func (r *RoleInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	r.Role = strings.ToLower(strings.TrimSpace(r.Role))
+	return v.ValidateStruct(ctx, r)
+}
+
+// CloseAccountInput asks to close an account for good. An account with a
+// balance can only be closed with SweepAccountUUID, the account the balance
+// is moved to.
+type CloseAccountInput struct {
+	AccountUUID      string `validate:"required,uuid"`
+	Reason           string `validate:"required,max=500"`
+	SweepAccountUUID string `validate:"omitempty,uuid,nefield=AccountUUID"`
+}
+
+// Validate validate the input
+func (c *CloseAccountInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	c.Reason = strings.TrimSpace(c.Reason)
+	return v.ValidateStruct(ctx, c)
+}
+
+// ProfileInput changes the profile of the logged account. A nil field is kept
+// as it is.
+type ProfileInput struct {
+	Name *string `validate:"omitnil,min=3"`
+}
+
+// Apply validates the input and writes it over account.
+func (p *ProfileInput) Apply(ctx context.Context, v apperrmap.Validator, account *entity.Account) (err error) {
+	if p.Name == nil {
+		return apperr.ErrInvalidInput.WithMessage("there is nothing to update")
+	}
+
+	name := strings.TrimSpace(*p.Name)
+	p.Name = &name
+
+	err = v.ValidateStruct(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	account.Name = name
+	return nil
+}
+
+// PasswordChangeInput changes the password of the logged account, which has
+// to prove it knows the current one. The new one follows the same rule as
+// AccountInput.Password.
+type PasswordChangeInput struct {
+	CurrentPassword string `validate:"required"`
+	NewPassword     string `validate:"required,min=8,nefield=CurrentPassword"`
+}
+
+// Validate validate the input
+func (p *PasswordChangeInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	return v.ValidateStruct(ctx, p)
+}
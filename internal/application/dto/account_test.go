@@ -3,6 +3,7 @@ package dto
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/appvalidator/apperrmap"
@@ -135,7 +136,7 @@ func TestAddBalanceInput_Validate(t *testing.T) {
 			name: "Should return without error",
 			fields: AddBalanceInput{
 				AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				Amount:      5.0,
+				Amount:      entity.NewMoney(500, entity.BRL),
 			},
 			wantErr: false,
 		},
@@ -143,7 +144,7 @@ func TestAddBalanceInput_Validate(t *testing.T) {
 			name: "Should return error if account uuid is empty",
 			fields: AddBalanceInput{
 				AccountUUID: "",
-				Amount:      5.0,
+				Amount:      entity.NewMoney(500, entity.BRL),
 			},
 			wantErr: true,
 		},
@@ -151,7 +152,7 @@ func TestAddBalanceInput_Validate(t *testing.T) {
 			name: "Should return error if account uuid is invalid",
 			fields: AddBalanceInput{
 				AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934c",
-				Amount:      5.0,
+				Amount:      entity.NewMoney(500, entity.BRL),
 			},
 			wantErr: true,
 		},
@@ -159,7 +160,15 @@ func TestAddBalanceInput_Validate(t *testing.T) {
 			name: "Should return error if amount is empty",
 			fields: AddBalanceInput{
 				AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				Amount:      0,
+				Amount:      entity.Money{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error if amount is negative",
+			fields: AddBalanceInput{
+				AccountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+				Amount:      entity.NewMoney(-500, entity.BRL),
 			},
 			wantErr: true,
 		},
@@ -175,3 +184,212 @@ func TestAddBalanceInput_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountSearchInput_ToEntityValidate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	active := true
+	from := time.Now().Add(-24 * time.Hour)
+	to := time.Now()
+
+	tests := []struct {
+		name       string
+		input      AccountSearchInput
+		wantFilter entity.AccountFilter
+		wantErr    bool
+	}{
+		{
+			name: "Should list everything without filters",
+		},
+		{
+			name: "Should return the filter with every field",
+			input: AccountSearchInput{
+				Name:        " john ",
+				CPF:         "123.456.789-09",
+				Active:      &active,
+				CreatedFrom: &from,
+				CreatedTo:   &to,
+				Sort:        []string{"name", "created_at:desc"},
+			},
+			wantFilter: entity.AccountFilter{
+				Name:        "john",
+				Active:      &active,
+				CreatedFrom: &from,
+				CreatedTo:   &to,
+				Sort:        []entity.SortField{{Field: "name"}, {Field: "created_at", Descending: true}},
+			},
+		},
+		{
+			name:    "Should return error if the cpf has letters",
+			input:   AccountSearchInput{CPF: "123.456.789-0a"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the cpf is only part of one",
+			input:   AccountSearchInput{CPF: "123.456"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the dates are reversed",
+			input:   AccountSearchInput{CreatedFrom: &to, CreatedTo: &from},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the sort field is not allowed",
+			input:   AccountSearchInput{Sort: []string{"cpf"}},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the sort direction is unknown",
+			input:   AccountSearchInput{Sort: []string{"name:up"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.input.ToEntityValidate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AccountSearchInput.ToEntityValidate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantFilter, filter)
+			}
+		})
+	}
+}
+
+func TestCloseAccountInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	const accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	tests := []struct {
+		name    string
+		fields  CloseAccountInput
+		wantErr bool
+	}{
+		{
+			name:   "Should return without error without a sweep account",
+			fields: CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked"},
+		},
+		{
+			name:   "Should return without error with a sweep account",
+			fields: CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1"},
+		},
+		{
+			name:    "Should return error if the reason is blank",
+			fields:  CloseAccountInput{AccountUUID: accountUUID, Reason: "  "},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the sweep account is invalid",
+			fields:  CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: "not-a-uuid"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the sweep account is the account itself",
+			fields:  CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: accountUUID},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CloseAccountInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileInput_Apply(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	name := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		fields   ProfileInput
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "Should write the trimmed name",
+			fields:   ProfileInput{Name: name("  Jane Doe ")},
+			wantName: "Jane Doe",
+		},
+		{
+			name:    "Should return error if there is nothing to update",
+			fields:  ProfileInput{},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the name is less than 3 characters",
+			fields:  ProfileInput{Name: name(" Jo ")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := entity.Account{Name: "John Doe"}
+
+			err := tt.fields.Apply(ctx, v, &account)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProfileInput.Apply() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantName, account.Name)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fields  PasswordChangeInput
+		wantErr bool
+	}{
+		{
+			name:   "Should return without error",
+			fields: PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "87654321"},
+		},
+		{
+			name:    "Should return error if the current password is empty",
+			fields:  PasswordChangeInput{NewPassword: "87654321"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the new password is less than 8 characters",
+			fields:  PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "1234567"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the new password is the current one",
+			fields:  PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "12345678"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PasswordChangeInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
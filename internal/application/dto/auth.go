@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
 	"github.com/diegoclair/go_boilerplate/util/number"
@@ -12,20 +13,39 @@ type Session struct {
 	SessionID             int64
 	SessionUUID           string `validate:"required,uuid"`
 	AccountID             int64  `validate:"required"`
-	RefreshToken          string `validate:"required"`
+	RefreshTokenHash      string `validate:"required"`
 	UserAgent             string
 	ClientIP              string
 	IsBlocked             bool
 	RefreshTokenExpiredAt time.Time
+	CreatedAt             time.Time
+	// Current is set by ListSessions on the session of the caller
+	Current bool
 }
 
 func (s *Session) Validate(ctx context.Context, v apperrmap.Validator) error {
 	return v.ValidateStruct(ctx, s)
 }
 
+// RefreshTokenRotation replaces the refresh token of a session with a new
+// one, presented by the client at ClientIP. Both tokens go by their sums.
+type RefreshTokenRotation struct {
+	SessionUUID              string `validate:"required,uuid"`
+	RefreshTokenHash         string `validate:"required"`
+	NewRefreshTokenHash      string `validate:"required"`
+	NewRefreshTokenExpiredAt time.Time
+	ClientIP                 string `validate:"omitempty,ip"`
+}
+
+// Validate validate the input
+func (r *RefreshTokenRotation) Validate(ctx context.Context, v apperrmap.Validator) error {
+	return v.ValidateStruct(ctx, r)
+}
+
 type LoginInput struct {
 	CPF      string `validate:"required,cpf"`
 	Password string `validate:"required,min=8"`
+	ClientIP string `validate:"omitempty,ip"`
 }
 
 // Validate validate the input
@@ -33,3 +53,64 @@ func (l *LoginInput) Validate(ctx context.Context, v apperrmap.Validator) error
 	l.CPF = number.CleanNumber(l.CPF)
 	return v.ValidateStruct(ctx, l)
 }
+
+// PasswordResetInput asks for a token to reset the password of the account
+// with the CPF. ClientIP is where the request comes from, for the login
+// throttle.
+type PasswordResetInput struct {
+	CPF      string `validate:"required,cpf"`
+	ClientIP string `validate:"omitempty,ip"`
+}
+
+// Validate validate the input
+func (p *PasswordResetInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	p.CPF = number.CleanNumber(p.CPF)
+	return v.ValidateStruct(ctx, p)
+}
+
+// PasswordResetConfirmInput sets a new password with a reset token. The new
+// one follows the same rule as AccountInput.Password.
+type PasswordResetConfirmInput struct {
+	Token       string `validate:"required"`
+	NewPassword string `validate:"required,min=8"`
+}
+
+// Validate validate the input
+func (p *PasswordResetConfirmInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	return v.ValidateStruct(ctx, p)
+}
+
+// MFAEnrollment is a new TOTP secret, to be added to an authenticator app by
+// hand or through its ProvisioningURI.
+type MFAEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// MFACodeInput confirms the enrollment with a first code of the app.
+type MFACodeInput struct {
+	Code string `validate:"required,numeric,len=6"`
+}
+
+// Validate validate the input
+func (m *MFACodeInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	m.Code = strings.TrimSpace(m.Code)
+	return v.ValidateStruct(ctx, m)
+}
+
+// LoginMFAInput is the second step of the login of an account with MFA. Code
+// is either a code of the app or one of the recovery codes, and TokenID is the
+// ID of the token the first step handed out, which expires at TokenExpiresAt.
+type LoginMFAInput struct {
+	AccountUUID    string `validate:"required,uuid"`
+	Code           string `validate:"required,max=32"`
+	ClientIP       string `validate:"omitempty,ip"`
+	TokenID        string `validate:"required"`
+	TokenExpiresAt time.Time
+}
+
+// Validate validate the input
+func (l *LoginMFAInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	l.Code = strings.TrimSpace(l.Code)
+	return v.ValidateStruct(ctx, l)
+}
@@ -21,25 +21,25 @@ func TestSession_Validate(t *testing.T) {
 		{
 			name: "Valid session",
 			fields: Session{
-				SessionUUID:  "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				AccountID:    1,
-				RefreshToken: "token",
+				SessionUUID:      "d152a340-9a87-4d32-85ad-19df4c9934cd",
+				AccountID:        1,
+				RefreshTokenHash: "token",
 			},
 			wantErr: false,
 		},
 		{
 			name: "Should return error if session uuid is empty",
 			fields: Session{
-				AccountID:    1,
-				RefreshToken: "token",
+				AccountID:        1,
+				RefreshTokenHash: "token",
 			},
 			wantErr: true,
 		},
 		{
 			name: "Should return error if account id is empty",
 			fields: Session{
-				SessionUUID:  "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				RefreshToken: "token",
+				SessionUUID:      "d152a340-9a87-4d32-85ad-19df4c9934cd",
+				RefreshTokenHash: "token",
 			},
 			wantErr: true,
 		},
@@ -122,3 +122,110 @@ func TestLoginInput_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordResetInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fields  PasswordResetInput
+		wantCPF string
+		wantErr bool
+	}{
+		{
+			name:    "Should clean a formatted cpf",
+			fields:  PasswordResetInput{CPF: "012.345.678-90"},
+			wantCPF: "01234567890",
+		},
+		{
+			name:    "Should return error if cpf is empty",
+			fields:  PasswordResetInput{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PasswordResetInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				require.Equal(t, tt.wantCPF, tt.fields.CPF)
+			}
+		})
+	}
+}
+
+func TestPasswordResetConfirmInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fields  PasswordResetConfirmInput
+		wantErr bool
+	}{
+		{
+			name:   "Valid confirmation",
+			fields: PasswordResetConfirmInput{Token: "token", NewPassword: "12345678"},
+		},
+		{
+			name:    "Should return error if token is empty",
+			fields:  PasswordResetConfirmInput{NewPassword: "12345678"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the new password is less than 8 characters",
+			fields:  PasswordResetConfirmInput{Token: "token", NewPassword: "1234567"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PasswordResetConfirmInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMFACodeInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fields  MFACodeInput
+		wantErr bool
+	}{
+		{
+			name:   "Valid code",
+			fields: MFACodeInput{Code: " 123456 "},
+		},
+		{
+			name:    "Should return error if the code isn't six digits",
+			fields:  MFACodeInput{Code: "12345"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the code isn't numeric",
+			fields:  MFACodeInput{Code: "12345a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MFACodeInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
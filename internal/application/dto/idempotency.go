@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"context"
+
+	"github.com/diegoclair/appvalidator/apperrmap"
+)
+
+type IdempotencyInput struct {
+	Scope       string `validate:"required"`
+	Key         string `validate:"required,max=255"`
+	Fingerprint string `validate:"required,len=64"`
+}
+
+func (i *IdempotencyInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	return v.ValidateStruct(ctx, i)
+}
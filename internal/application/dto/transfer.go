@@ -1,14 +1,17 @@
 package dto
 
 import (
+	"time"
+
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/appvalidator/apperrmap"
 	"golang.org/x/net/context"
 )
 
 type TransferInput struct {
-	AccountDestinationUUID string  `validate:"required,uuid"`
-	Amount                 float64 `validate:"required"`
+	AccountDestinationUUID string `validate:"required,uuid"`
+	Amount                 entity.Money
 }
 
 // ToEntityValidate validate the input and return the entity
@@ -18,8 +21,210 @@ func (t *TransferInput) ToEntityValidate(ctx context.Context, v apperrmap.Valida
 		return transfer, err
 	}
 
+	err = validateAmount(t.Amount)
+	if err != nil {
+		return transfer, err
+	}
+
 	return entity.Transfer{
 		AccountDestinationUUID: t.AccountDestinationUUID,
 		Amount:                 t.Amount,
 	}, nil
 }
+
+type ScheduledTransferInput struct {
+	TransferInput
+	ScheduledFor time.Time
+}
+
+// ToEntityValidate validate the input and return the entity
+func (t *ScheduledTransferInput) ToEntityValidate(ctx context.Context, v apperrmap.Validator) (scheduled entity.ScheduledTransfer, err error) {
+	transfer, err := t.TransferInput.ToEntityValidate(ctx, v)
+	if err != nil {
+		return scheduled, err
+	}
+
+	if !t.ScheduledFor.After(time.Now()) {
+		return scheduled, apperr.ErrInvalidInput.WithMessage("scheduled_for must be in the future")
+	}
+
+	return entity.ScheduledTransfer{
+		AccountDestinationUUID: transfer.AccountDestinationUUID,
+		Amount:                 transfer.Amount,
+		ScheduledFor:           t.ScheduledFor,
+	}, nil
+}
+
+// defaultRecurringMaxRetries is how many times a standing order that retries
+// tries an occurrence again when none was asked for
+const defaultRecurringMaxRetries = 3
+
+type RecurringTransferInput struct {
+	TransferInput
+	Frequency string `validate:"required,oneof=weekly monthly interval"`
+	// IntervalDays is required by the interval frequency
+	IntervalDays int `validate:"gte=0,lte=366"`
+	// DayOfMonth is required by the monthly frequency
+	DayOfMonth int `validate:"gte=0,lte=31"`
+	StartsAt   time.Time
+	// EndsAt and MaxOccurrences are optional; with neither the rule runs
+	// until it is canceled
+	EndsAt         *time.Time
+	MaxOccurrences int    `validate:"gte=0"`
+	OnInsufficient string `validate:"omitempty,oneof=skip retry"`
+	MaxRetries     int    `validate:"gte=0,lte=10"`
+}
+
+// ToEntityValidate validate the input and return the entity
+func (t *RecurringTransferInput) ToEntityValidate(ctx context.Context, v apperrmap.Validator) (recurring entity.RecurringTransfer, err error) {
+	err = v.ValidateStruct(ctx, t)
+	if err != nil {
+		return recurring, err
+	}
+
+	transfer, err := t.TransferInput.ToEntityValidate(ctx, v)
+	if err != nil {
+		return recurring, err
+	}
+
+	rule := entity.RecurrenceRule{
+		Frequency:      entity.RecurrenceFrequency(t.Frequency),
+		IntervalDays:   t.IntervalDays,
+		DayOfMonth:     t.DayOfMonth,
+		StartsAt:       t.StartsAt,
+		EndsAt:         t.EndsAt,
+		MaxOccurrences: t.MaxOccurrences,
+	}
+
+	switch {
+	case rule.Frequency == entity.RecurrenceInterval && rule.IntervalDays == 0:
+		return recurring, apperr.ErrInvalidInput.WithMessage("interval_days is required by the interval frequency")
+	case rule.Frequency == entity.RecurrenceMonthly && rule.DayOfMonth == 0:
+		return recurring, apperr.ErrInvalidInput.WithMessage("day_of_month is required by the monthly frequency")
+	case !rule.StartsAt.After(time.Now()):
+		return recurring, apperr.ErrInvalidInput.WithMessage("starts_at must be in the future")
+	}
+
+	if _, ok := rule.Occurrence(0); !ok {
+		return recurring, apperr.ErrInvalidInput.WithMessage("the rule ends before its first occurrence")
+	}
+
+	recurring = entity.RecurringTransfer{
+		AccountDestinationUUID: transfer.AccountDestinationUUID,
+		Amount:                 transfer.Amount,
+		Rule:                   rule,
+	}
+	recurring.InsufficientFunds, recurring.MaxRetries = insufficientFundsPolicy(t.OnInsufficient, t.MaxRetries)
+
+	return recurring, nil
+}
+
+// insufficientFundsPolicy defaults to skipping, and to a few retries when
+// retrying is asked for without saying how many.
+func insufficientFundsPolicy(onInsufficient string, maxRetries int) (entity.InsufficientFundsPolicy, int) {
+	if onInsufficient != string(entity.InsufficientFundsRetry) {
+		return entity.InsufficientFundsSkip, 0
+	}
+
+	if maxRetries == 0 {
+		maxRetries = defaultRecurringMaxRetries
+	}
+
+	return entity.InsufficientFundsRetry, maxRetries
+}
+
+// RecurringTransferUpdateInput replaces what may change of a standing order
+// once it is running. To change when it runs, cancel it and create another.
+type RecurringTransferUpdateInput struct {
+	RecurringTransferUUID string `validate:"required,uuid"`
+	Amount                entity.Money
+	EndsAt                *time.Time
+	MaxOccurrences        int    `validate:"gte=0"`
+	OnInsufficient        string `validate:"omitempty,oneof=skip retry"`
+	MaxRetries            int    `validate:"gte=0,lte=10"`
+}
+
+// Apply validates the input and writes it over recurring.
+func (t *RecurringTransferUpdateInput) Apply(ctx context.Context, v apperrmap.Validator, recurring *entity.RecurringTransfer) (err error) {
+	err = v.ValidateStruct(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	err = validateAmount(t.Amount)
+	if err != nil {
+		return err
+	}
+
+	recurring.Amount = t.Amount
+	recurring.Rule.EndsAt = t.EndsAt
+	recurring.Rule.MaxOccurrences = t.MaxOccurrences
+	recurring.InsufficientFunds, recurring.MaxRetries = insufficientFundsPolicy(t.OnInsufficient, t.MaxRetries)
+
+	return nil
+}
+
+type TransferReversalInput struct {
+	TransferUUID string `validate:"required,uuid"`
+	// Amount is how much to give back; zero reverses whatever is left
+	Amount entity.Money
+}
+
+func (t *TransferReversalInput) Validate(ctx context.Context, v apperrmap.Validator) error {
+	err := v.ValidateStruct(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if t.Amount.IsZero() {
+		return nil
+	}
+
+	return validateAmount(t.Amount)
+}
+
+// TransferHistoryInput filters the transfers the logged account sent or
+// received. Every field is optional.
+type TransferHistoryInput struct {
+	Direction        string `validate:"omitempty,oneof=sent received"`
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+	MinAmount        entity.Money
+	MaxAmount        entity.Money
+	CounterpartyUUID string `validate:"omitempty,uuid"`
+}
+
+// ToEntityValidate validate the input and return the entity
+func (t *TransferHistoryInput) ToEntityValidate(ctx context.Context, v apperrmap.Validator) (filter entity.TransferFilter, err error) {
+	err = v.ValidateStruct(ctx, t)
+	if err != nil {
+		return filter, err
+	}
+
+	if t.CreatedFrom != nil && t.CreatedTo != nil && !t.CreatedFrom.Before(*t.CreatedTo) {
+		return filter, apperr.ErrInvalidInput.WithMessage("from must be before to")
+	}
+
+	if t.MinAmount.IsNegative() || t.MaxAmount.IsNegative() {
+		return filter, apperr.ErrInvalidInput.WithMessage("amounts can not be negative")
+	}
+
+	if t.MinAmount.IsPositive() && t.MaxAmount.IsPositive() {
+		cmp, err := t.MinAmount.Cmp(t.MaxAmount)
+		if err != nil {
+			return filter, apperr.ErrInvalidInput.WithMessage("min_amount and max_amount must be in the same currency")
+		}
+		if cmp > 0 {
+			return filter, apperr.ErrInvalidInput.WithMessage("min_amount can not be greater than max_amount")
+		}
+	}
+
+	return entity.TransferFilter{
+		Direction:        entity.TransferDirection(t.Direction),
+		CreatedFrom:      t.CreatedFrom,
+		CreatedTo:        t.CreatedTo,
+		MinAmount:        t.MinAmount,
+		MaxAmount:        t.MaxAmount,
+		CounterpartyUUID: t.CounterpartyUUID,
+	}, nil
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/appvalidator/apperrmap"
@@ -17,7 +18,7 @@ func TestTransferInput_ToEntityValidate(t *testing.T) {
 
 	type fields struct {
 		AccountDestinationUUID string
-		Amount                 float64
+		Amount                 entity.Money
 	}
 
 	tests := []struct {
@@ -30,12 +31,12 @@ func TestTransferInput_ToEntityValidate(t *testing.T) {
 			name: "Should return transfer entity without error",
 			fields: fields{
 				AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				Amount:                 5.0,
+				Amount:                 entity.NewMoney(500, entity.BRL),
 			},
 
 			wantTransfer: entity.Transfer{
 				AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				Amount:                 5.0,
+				Amount:                 entity.NewMoney(500, entity.BRL),
 			},
 			wantErr: false,
 		},
@@ -43,7 +44,7 @@ func TestTransferInput_ToEntityValidate(t *testing.T) {
 			name: "Should return error if account destination uuid is empty",
 			fields: fields{
 				AccountDestinationUUID: "",
-				Amount:                 5.0,
+				Amount:                 entity.NewMoney(500, entity.BRL),
 			},
 			wantTransfer: entity.Transfer{},
 			wantErr:      true,
@@ -52,7 +53,16 @@ func TestTransferInput_ToEntityValidate(t *testing.T) {
 			name: "Should return error if amount is empty",
 			fields: fields{
 				AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-				Amount:                 0,
+				Amount:                 entity.Money{},
+			},
+			wantTransfer: entity.Transfer{},
+			wantErr:      true,
+		},
+		{
+			name: "Should return error if amount is negative",
+			fields: fields{
+				AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+				Amount:                 entity.NewMoney(-500, entity.BRL),
 			},
 			wantTransfer: entity.Transfer{},
 			wantErr:      true,
@@ -74,3 +84,297 @@ func TestTransferInput_ToEntityValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestTransferReversalInput_Validate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   TransferReversalInput
+		wantErr bool
+	}{
+		{
+			name:  "Should accept a reversal without amount",
+			input: TransferReversalInput{TransferUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd"},
+		},
+		{
+			name:  "Should accept a partial reversal",
+			input: TransferReversalInput{TransferUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", Amount: entity.NewMoney(100, entity.BRL)},
+		},
+		{
+			name:    "Should return error if the amount is negative",
+			input:   TransferReversalInput{TransferUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", Amount: entity.NewMoney(-100, entity.BRL)},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the transfer uuid is invalid",
+			input:   TransferReversalInput{TransferUUID: "invalid"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TransferReversalInput.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduledTransferInput_ToEntityValidate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	transfer := TransferInput{
+		AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		Amount:                 entity.NewMoney(500, entity.BRL),
+	}
+
+	tests := []struct {
+		name    string
+		input   ScheduledTransferInput
+		wantErr bool
+	}{
+		{
+			name:  "Should return scheduled transfer entity without error",
+			input: ScheduledTransferInput{TransferInput: transfer, ScheduledFor: time.Now().Add(time.Hour)},
+		},
+		{
+			name:    "Should return error if scheduled for the past",
+			input:   ScheduledTransferInput{TransferInput: transfer, ScheduledFor: time.Now().Add(-time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if scheduled_for is missing",
+			input:   ScheduledTransferInput{TransferInput: transfer},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the transfer is invalid",
+			input:   ScheduledTransferInput{ScheduledFor: time.Now().Add(time.Hour)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduled, err := tt.input.ToEntityValidate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ScheduledTransferInput.ToEntityValidate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Equal(t, tt.input.AccountDestinationUUID, scheduled.AccountDestinationUUID)
+				assert.Equal(t, tt.input.Amount, scheduled.Amount)
+				assert.Equal(t, tt.input.ScheduledFor, scheduled.ScheduledFor)
+			}
+		})
+	}
+}
+
+func TestRecurringTransferInput_ToEntityValidate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	transfer := TransferInput{
+		AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		Amount:                 entity.NewMoney(500, entity.BRL),
+	}
+	startsAt := time.Now().Add(time.Hour)
+	endsBeforeStart := startsAt.Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		input          RecurringTransferInput
+		wantPolicy     entity.InsufficientFundsPolicy
+		wantMaxRetries int
+		wantErr        bool
+	}{
+		{
+			name:       "Should return a weekly recurring transfer that skips by default",
+			input:      RecurringTransferInput{TransferInput: transfer, Frequency: "weekly", StartsAt: startsAt},
+			wantPolicy: entity.InsufficientFundsSkip,
+		},
+		{
+			name:           "Should retry a few times when no max retries is given",
+			input:          RecurringTransferInput{TransferInput: transfer, Frequency: "weekly", StartsAt: startsAt, OnInsufficient: "retry"},
+			wantPolicy:     entity.InsufficientFundsRetry,
+			wantMaxRetries: defaultRecurringMaxRetries,
+		},
+		{
+			name:           "Should keep the max retries given",
+			input:          RecurringTransferInput{TransferInput: transfer, Frequency: "monthly", DayOfMonth: 10, StartsAt: startsAt, OnInsufficient: "retry", MaxRetries: 5},
+			wantPolicy:     entity.InsufficientFundsRetry,
+			wantMaxRetries: 5,
+		},
+		{
+			name:       "Should ignore max retries when skipping",
+			input:      RecurringTransferInput{TransferInput: transfer, Frequency: "interval", IntervalDays: 3, StartsAt: startsAt, MaxRetries: 5},
+			wantPolicy: entity.InsufficientFundsSkip,
+		},
+		{
+			name:    "Should return error if the frequency is unknown",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "daily", StartsAt: startsAt},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if interval days is missing",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "interval", StartsAt: startsAt},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if day of month is missing",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "monthly", StartsAt: startsAt},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if day of month is out of range",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "monthly", DayOfMonth: 32, StartsAt: startsAt},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if it starts in the past",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "weekly", StartsAt: time.Now().Add(-time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if it ends before its first occurrence",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "weekly", StartsAt: startsAt, EndsAt: &endsBeforeStart},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the policy is unknown",
+			input:   RecurringTransferInput{TransferInput: transfer, Frequency: "weekly", StartsAt: startsAt, OnInsufficient: "wait"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the transfer is invalid",
+			input:   RecurringTransferInput{Frequency: "weekly", StartsAt: startsAt},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurring, err := tt.input.ToEntityValidate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTransferInput.ToEntityValidate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Equal(t, tt.input.AccountDestinationUUID, recurring.AccountDestinationUUID)
+				assert.Equal(t, tt.input.Amount, recurring.Amount)
+				assert.Equal(t, entity.RecurrenceFrequency(tt.input.Frequency), recurring.Rule.Frequency)
+				assert.Equal(t, tt.wantPolicy, recurring.InsufficientFunds)
+				assert.Equal(t, tt.wantMaxRetries, recurring.MaxRetries)
+			}
+		})
+	}
+}
+
+func TestRecurringTransferUpdateInput_Apply(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	recurring := entity.RecurringTransfer{
+		Amount:            entity.NewMoney(500, entity.BRL),
+		InsufficientFunds: entity.InsufficientFundsRetry,
+		MaxRetries:        5,
+	}
+
+	input := RecurringTransferUpdateInput{
+		RecurringTransferUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		Amount:                entity.NewMoney(700, entity.BRL),
+		MaxOccurrences:        4,
+	}
+	require.NoError(t, input.Apply(ctx, v, &recurring))
+	assert.Equal(t, input.Amount, recurring.Amount)
+	assert.Equal(t, 4, recurring.Rule.MaxOccurrences)
+	assert.Equal(t, entity.InsufficientFundsSkip, recurring.InsufficientFunds)
+	assert.Zero(t, recurring.MaxRetries)
+
+	input.Amount = entity.Money{}
+	require.Error(t, input.Apply(ctx, v, &recurring))
+
+	input = RecurringTransferUpdateInput{RecurringTransferUUID: "invalid", Amount: entity.NewMoney(700, entity.BRL)}
+	require.Error(t, input.Apply(ctx, v, &recurring))
+}
+
+func TestTransferHistoryInput_ToEntityValidate(t *testing.T) {
+	ctx := context.Background()
+	v, err := apperrmap.NewValidator()
+	require.NoError(t, err)
+
+	from := time.Now().Add(-24 * time.Hour)
+	to := time.Now()
+
+	tests := []struct {
+		name    string
+		input   TransferHistoryInput
+		wantErr bool
+	}{
+		{
+			name: "Should list everything without filters",
+		},
+		{
+			name: "Should return the filter with every field",
+			input: TransferHistoryInput{
+				Direction:        "received",
+				CreatedFrom:      &from,
+				CreatedTo:        &to,
+				MinAmount:        entity.NewMoney(100, entity.BRL),
+				MaxAmount:        entity.NewMoney(500, entity.BRL),
+				CounterpartyUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+			},
+		},
+		{
+			name:    "Should return error if the direction is unknown",
+			input:   TransferHistoryInput{Direction: "both"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the dates are reversed",
+			input:   TransferHistoryInput{CreatedFrom: &to, CreatedTo: &from},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the min amount is over the max amount",
+			input:   TransferHistoryInput{MinAmount: entity.NewMoney(500, entity.BRL), MaxAmount: entity.NewMoney(100, entity.BRL)},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if an amount is negative",
+			input:   TransferHistoryInput{MinAmount: entity.NewMoney(-1, entity.BRL)},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the counterparty uuid is invalid",
+			input:   TransferHistoryInput{CounterpartyUUID: "invalid"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.input.ToEntityValidate(ctx, v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TransferHistoryInput.ToEntityValidate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Equal(t, entity.TransferDirection(tt.input.Direction), filter.Direction)
+				assert.Equal(t, tt.input.CreatedFrom, filter.CreatedFrom)
+				assert.Equal(t, tt.input.CreatedTo, filter.CreatedTo)
+				assert.Equal(t, tt.input.MinAmount, filter.MinAmount)
+				assert.Equal(t, tt.input.MaxAmount, filter.MaxAmount)
+				assert.Equal(t, tt.input.CounterpartyUUID, filter.CounterpartyUUID)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/infra"
@@ -17,18 +18,22 @@ import (
 )
 
 type accountService struct {
-	crypto    contract.Crypto
-	dm        contract.DataManager
-	log       logger.Logger
-	validator apperrmap.Validator
+	cache               contract.CacheManager
+	crypto              contract.Crypto
+	dm                  contract.DataManager
+	log                 logger.Logger
+	validator           apperrmap.Validator
+	accessTokenDuration time.Duration
 }
 
-func newAccountService(infra domain.Infrastructure) *accountService {
+func newAccountService(infra domain.Infrastructure, accessTokenDuration time.Duration) *accountService {
 	return &accountService{
-		crypto:    infra.Crypto(),
-		dm:        infra.DataManager(),
-		log:       infra.Logger(),
-		validator: infra.Validator(),
+		cache:               infra.CacheManager(),
+		crypto:              infra.Crypto(),
+		dm:                  infra.DataManager(),
+		log:                 infra.Logger(),
+		validator:           infra.Validator(),
+		accessTokenDuration: accessTokenDuration,
 	}
 }
 
@@ -39,7 +44,9 @@ func (s *accountService) CreateAccount(ctx context.Context, input dto.AccountInp
 		return err
 	}
 
-	_, err = s.dm.Account().GetAccountByDocument(ctx, account.CPF)
+	account.CPFIndex = s.crypto.BlindIndex(account.CPF)
+
+	_, err = s.dm.Account().GetAccountByDocument(ctx, account.CPFIndex, account.CPF)
 	if err != nil && !apperr.IsNotFound(err) {
 		s.log.Error(ctx, "error to get account by document", logger.Err(err))
 		return err
@@ -48,12 +55,19 @@ func (s *accountService) CreateAccount(ctx context.Context, input dto.AccountInp
 		return errcodes.ErrCPFAlreadyInUse
 	}
 
+	account.CPF, err = s.crypto.EncryptField(account.CPF)
+	if err != nil {
+		s.log.Error(ctx, "error to encrypt cpf", logger.Err(err))
+		return err
+	}
+
 	account.Password, err = s.crypto.HashPassword(account.Password)
 	if err != nil {
 		s.log.Error(ctx, "error to hash password", logger.Err(err))
 		return err
 	}
 	account.UUID = uuid.Must(uuid.NewV7()).String()
+	account.Role = entity.RoleCustomer
 	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", account.UUID))
 
 	_, err = s.dm.Account().CreateAccount(ctx, account)
@@ -77,30 +91,535 @@ func (s *accountService) AddBalance(ctx context.Context, input dto.AddBalanceInp
 	account, err := s.dm.Account().GetAccountByUUID(ctx, input.AccountUUID)
 	if err != nil {
 		s.log.Error(ctx, "error to get account by uuid", logger.Err(err))
+		return errcodes.NewUnappliedError(err)
+	}
+
+	// The in-memory balance is stale by the time it's written, so it only
+	// catches a currency mismatch or an overflow; the stored balance is
+	// credited relatively below.
+	err = account.AddBalance(input.Amount)
+	if err != nil {
+		s.log.Error(ctx, "error to add balance", logger.Err(err))
+		return err
+	}
+
+	posting := entity.LedgerEntry{
+		JournalUUID: uuid.Must(uuid.NewV7()).String(),
+		Type:        entity.LedgerEntryDeposit,
+		Amount:      input.Amount,
+	}
+	ctx = logger.WithAttrs(ctx, logger.Attr("journal_uuid", posting.JournalUUID))
+
+	return withRollback(ctx, s.dm, func(tx contract.Repos) error {
+		// the account could be closed since it was read, and only the lock
+		// keeps it from closing before the deposit commits
+		locked, err := tx.Account().GetAccountsByIDForUpdate(ctx, []int64{account.ID})
+		if err != nil {
+			s.log.Error(ctx, "error to lock account", logger.Err(err))
+			return err
+		}
+
+		if len(locked) == 0 {
+			s.log.Error(ctx, "account not found while locking")
+			return errcodes.ErrAccountNotFound
+		}
+
+		if locked[0].IsClosed() {
+			return errcodes.ErrDestinationAccountClosed
+		}
+
+		fundingAccountID, err := tx.Account().GetAccountIDByUUID(ctx, entity.FundingAccountUUID)
+		if err != nil {
+			s.log.Error(ctx, "error to get funding account id", logger.Err(err))
+			return err
+		}
+
+		// the deposit's money comes out of the funding account, so the ledger
+		// records where every cent came from
+		posting.AccountID = fundingAccountID
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+		if err != nil {
+			s.log.Error(ctx, "error to debit funding account balance", logger.Err(err))
+			return err
+		}
+
+		if !debited {
+			s.log.Error(ctx, "funding account refused the deposit debit")
+			return errors.New("funding account refused the deposit debit")
+		}
+
+		posting.AccountID = account.ID
+		err = tx.Account().CreditAccountBalance(ctx, posting)
+		if err != nil {
+			s.log.Error(ctx, "error to credit account balance", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeactivateAccount keeps the account from logging in until it is
+// reactivated, and blocks every session it has right away.
+func (s *accountService) DeactivateAccount(ctx context.Context, input dto.AccountStatusInput) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", input.AccountUUID))
+
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	accountID, err := s.getStatusChangeAccountID(ctx, input.AccountUUID)
+	if err != nil {
+		return err
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		accounts, err := s.lockStatusChangeAccounts(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+
+		account := accounts[accountID]
+		if !account.Active {
+			return errcodes.ErrAccountAlreadyDeactivated
+		}
+
+		account.Active = false
+		err = s.changeAccountStatus(ctx, tx, account, entity.AccountStatusChange{Action: entity.AccountDeactivated, Reason: input.Reason})
+		if err != nil {
+			return err
+		}
+
+		sessionUUIDs, err = s.blockAccountSessions(ctx, tx, account.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
+	return nil
+}
+
+// ReactivateAccount lets a deactivated account log in again. Its sessions
+// stay blocked: it has to log in anew.
+func (s *accountService) ReactivateAccount(ctx context.Context, input dto.AccountStatusInput) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", input.AccountUUID))
+
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	accountID, err := s.getStatusChangeAccountID(ctx, input.AccountUUID)
+	if err != nil {
+		return err
+	}
+
+	return s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		accounts, err := s.lockStatusChangeAccounts(ctx, tx, accountID)
+		if err != nil {
+			return err
+		}
+
+		account := accounts[accountID]
+		if account.Active {
+			return errcodes.ErrAccountAlreadyActive
+		}
+
+		account.Active = true
+		return s.changeAccountStatus(ctx, tx, account, entity.AccountStatusChange{Action: entity.AccountReactivated, Reason: input.Reason})
+	})
+}
+
+// ChangeRole sets the role of the account. The role is read at login and
+// carried in the tokens, so every session of the account is revoked for the
+// new role to take hold at once.
+func (s *accountService) ChangeRole(ctx context.Context, input dto.RoleInput) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", input.AccountUUID))
+
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	accountID, err := s.getStatusChangeAccountID(ctx, input.AccountUUID)
+	if err != nil {
+		return err
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		err := tx.Account().UpdateAccountRole(ctx, accountID, entity.Role(input.Role))
+		if err != nil {
+			s.log.Error(ctx, "error to update account role", logger.Err(err))
+			return err
+		}
+
+		sessions, err := tx.Auth().GetActiveSessionsByAccountID(ctx, accountID)
+		if err != nil {
+			s.log.Error(ctx, "error to get active sessions", logger.Err(err))
+			return err
+		}
+
+		sessionUUIDs = make([]string, 0, len(sessions))
+		for _, session := range sessions {
+			sessionUUIDs = append(sessionUUIDs, session.SessionUUID)
+		}
+
+		err = revokeSessions(ctx, tx, sessionUUIDs)
+		if err != nil {
+			s.log.Error(ctx, "error to revoke sessions", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
+	return nil
+}
+
+// CloseAccount closes the account for good. An account with a balance is
+// only closed along with a sweep account, and then its whole balance is
+// transferred there in the same transaction, so the account never closes with
+// money in it.
+func (s *accountService) CloseAccount(ctx context.Context, input dto.CloseAccountInput) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", input.AccountUUID))
+
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	accountID, err := s.getStatusChangeAccountID(ctx, input.AccountUUID)
+	if err != nil {
+		return err
+	}
+
+	lockIDs := []int64{accountID}
+
+	var sweepAccountID int64
+	if input.SweepAccountUUID != "" {
+		ctx = logger.WithAttrs(ctx, logger.Attr("sweep_account_uuid", input.SweepAccountUUID))
+
+		sweepAccountID, err = s.getStatusChangeAccountID(ctx, input.SweepAccountUUID)
+		if err != nil {
+			if errors.Is(err, errcodes.ErrAccountNotFound) {
+				return errcodes.ErrInvalidSweepAccount
+			}
+			return err
+		}
+
+		// the input only compares the uuids as written, so it is the id that
+		// tells whether the sweep account is the account itself
+		if sweepAccountID == accountID {
+			return errcodes.ErrInvalidSweepAccount
+		}
+
+		lockIDs = append(lockIDs, sweepAccountID)
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		// both rows are locked at once, in the order transfers lock them
+		accounts, err := s.lockStatusChangeAccounts(ctx, tx, lockIDs...)
+		if err != nil {
+			return err
+		}
+
+		account := accounts[accountID]
+		change := entity.AccountStatusChange{Action: entity.AccountClosed, Reason: input.Reason}
+
+		if !account.Balance.IsZero() {
+			if sweepAccountID == 0 {
+				return errcodes.ErrAccountBalanceNotZero
+			}
+
+			change.SweepTransferID, err = s.sweepBalance(ctx, tx, account, accounts[sweepAccountID])
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		account.Active = false
+		account.ClosedAt = &now
+
+		err = s.changeAccountStatus(ctx, tx, account, change)
+		if err != nil {
+			return err
+		}
+
+		err = s.cancelAccountSchedules(ctx, tx, account.ID)
+		if err != nil {
+			return err
+		}
+
+		sessionUUIDs, err = s.blockAccountSessions(ctx, tx, account.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
+	return nil
+}
+
+// getStatusChangeAccountID returns the ID of an account whose status can
+// change, which the system accounts' can't.
+func (s *accountService) getStatusChangeAccountID(ctx context.Context, accountUUID string) (accountID int64, err error) {
+	if accountUUID == entity.FundingAccountUUID {
+		return accountID, errcodes.ErrAccountNotFound
+	}
+
+	accountID, err = s.dm.Account().GetAccountIDByUUID(ctx, accountUUID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return accountID, errcodes.ErrAccountNotFound
+		}
+		s.log.Error(ctx, "error to get account id by uuid", logger.Err(err))
+		return accountID, err
+	}
+
+	return accountID, nil
+}
+
+// lockStatusChangeAccounts locks the accounts for the rest of tx and returns
+// them by ID, refusing a closed one: nothing changes a closed account.
+func (s *accountService) lockStatusChangeAccounts(ctx context.Context, tx contract.Repos, accountIDs ...int64) (accounts map[int64]entity.Account, err error) {
+	locked, err := tx.Account().GetAccountsByIDForUpdate(ctx, accountIDs)
+	if err != nil {
+		s.log.Error(ctx, "error to lock accounts", logger.Err(err))
+		return nil, err
+	}
+
+	accounts = make(map[int64]entity.Account, len(locked))
+	for _, account := range locked {
+		accounts[account.ID] = account
+	}
+
+	for i, accountID := range accountIDs {
+		account, ok := accounts[accountID]
+		if !ok {
+			s.log.Error(ctx, "account not found while locking")
+			return nil, errcodes.ErrAccountNotFound
+		}
+
+		if account.IsClosed() {
+			if i > 0 {
+				// any other account is where money goes to
+				return nil, errcodes.ErrDestinationAccountClosed
+			}
+			return nil, errcodes.ErrAccountClosed
+		}
+	}
+
+	return accounts, nil
+}
+
+// sweepBalance moves the whole balance of account to sweepAccount, as a
+// transfer made and settled within tx, and returns the transfer's ID.
+func (s *accountService) sweepBalance(ctx context.Context, tx contract.Repos, account, sweepAccount entity.Account) (transferID int64, err error) {
+	if account.Balance.Currency() != sweepAccount.Balance.Currency() {
+		return transferID, errcodes.ErrInvalidSweepAccount.WithMessage("the sweep account must be in the currency of the account")
+	}
+
+	now := time.Now()
+	sweep := entity.Transfer{
+		TransferUUID:           uuid.Must(uuid.NewV7()).String(),
+		AccountOriginID:        account.ID,
+		AccountOriginUUID:      account.UUID,
+		AccountDestinationID:   sweepAccount.ID,
+		AccountDestinationUUID: sweepAccount.UUID,
+		Amount:                 account.Balance,
+		Status:                 entity.TransferCompleted,
+		CompletedAt:            &now,
+		CreatedAt:              now,
+	}
+	ctx = logger.WithAttrs(ctx, logger.Attr("transfer_uuid", sweep.TransferUUID))
+
+	sweep.ID, err = tx.Account().AddTransfer(ctx, sweep)
+	if err != nil {
+		s.log.Error(ctx, "error to add sweep transfer", logger.Err(err))
+		return transferID, err
+	}
+
+	posting := entity.LedgerEntry{
+		JournalUUID: sweep.TransferUUID,
+		TransferID:  sweep.ID,
+		Type:        entity.LedgerEntryTransfer,
+		Amount:      sweep.Amount,
+	}
+
+	posting.AccountID = account.ID
+	debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+	if err != nil {
+		s.log.Error(ctx, "error to debit closing account balance", logger.Err(err))
+		return transferID, err
+	}
+
+	// the row is locked, so the balance read is the one debited
+	if !debited {
+		s.log.Error(ctx, "closing account refused the sweep debit")
+		return transferID, errors.New("closing account refused the sweep debit")
+	}
+
+	posting.AccountID = sweepAccount.ID
+	err = tx.Account().CreditAccountBalance(ctx, posting)
+	if err != nil {
+		s.log.Error(ctx, "error to credit sweep account balance", logger.Err(err))
+		return transferID, err
+	}
+
+	return sweep.ID, nil
+}
+
+// changeAccountStatus writes the status account was changed to, along with
+// change, the record of why.
+func (s *accountService) changeAccountStatus(ctx context.Context, tx contract.Repos, account entity.Account, change entity.AccountStatusChange) error {
+	err := tx.Account().UpdateAccountStatus(ctx, account)
+	if err != nil {
+		s.log.Error(ctx, "error to update account status", logger.Err(err))
 		return err
 	}
 
-	account.AddBalance(input.Amount)
+	change.AccountID = account.ID
+	_, err = tx.Account().AddAccountStatusChange(ctx, change)
+	if err != nil {
+		s.log.Error(ctx, "error to add account status change", logger.Err(err))
+		return err
+	}
 
-	err = s.dm.Account().UpdateAccountBalance(ctx, account.ID, account.Balance)
+	return nil
+}
+
+// cancelAccountSchedules calls off, within tx, the standing orders and the
+// schedules not yet claimed that move money out of or into the account, so
+// none of them runs against it once it is closed.
+func (s *accountService) cancelAccountSchedules(ctx context.Context, tx contract.Repos, accountID int64) (err error) {
+	_, err = tx.RecurringTransfer().CancelRecurringTransfersByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Error(ctx, "error to cancel account recurring transfers", logger.Err(err))
+		return err
+	}
+
+	_, err = tx.ScheduledTransfer().CancelScheduledTransfersByAccountID(ctx, accountID)
 	if err != nil {
-		s.log.Error(ctx, "error to update account balance", logger.Err(err))
+		s.log.Error(ctx, "error to cancel account scheduled transfers", logger.Err(err))
 		return err
 	}
 
 	return nil
 }
 
-func (s *accountService) GetAccounts(ctx context.Context, take, skip int64) (accounts []entity.Account, totalRecords int64, err error) {
-	accounts, totalRecords, err = s.dm.Account().GetAccounts(ctx, take, skip)
+// blockAccountSessions blocks every session of the account within tx and
+// returns them, to be marked revoked once tx commits.
+func (s *accountService) blockAccountSessions(ctx context.Context, tx contract.Repos, accountID int64) (sessionUUIDs []string, err error) {
+	sessionUUIDs, err = tx.Auth().SetSessionsAsBlockedByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Error(ctx, "error to block account sessions", logger.Err(err))
+		return nil, err
+	}
+
+	return sessionUUIDs, nil
+}
+
+// decryptCPF puts the CPF of accounts read from the database back in plain
+// text.
+func (s *accountService) decryptCPF(ctx context.Context, accounts ...*entity.Account) (err error) {
+	for _, account := range accounts {
+		account.CPF, err = s.crypto.DecryptField(account.CPF)
+		if err != nil {
+			s.log.Error(ctx, "error to decrypt cpf", logger.Attr("account_uuid", account.UUID), logger.Err(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *accountService) decryptCPFs(ctx context.Context, accounts []entity.Account) error {
+	pointers := make([]*entity.Account, len(accounts))
+	for i := range accounts {
+		pointers[i] = &accounts[i]
+	}
+
+	return s.decryptCPF(ctx, pointers...)
+}
+
+// searchFilter validates input into a filter, matching the CPF by its blind
+// index.
+func (s *accountService) searchFilter(ctx context.Context, input dto.AccountSearchInput) (filter entity.AccountFilter, err error) {
+	filter, err = input.ToEntityValidate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return filter, err
+	}
+
+	if input.CPF != "" {
+		filter.CPFIndex = s.crypto.BlindIndex(input.CPF)
+	}
+
+	return filter, nil
+}
+
+func (s *accountService) GetAccounts(ctx context.Context, input dto.AccountSearchInput, take, skip int64) (accounts []entity.Account, totalRecords int64, err error) {
+	filter, err := s.searchFilter(ctx, input)
+	if err != nil {
+		return accounts, totalRecords, err
+	}
+
+	accounts, totalRecords, err = s.dm.Account().GetAccounts(ctx, filter, take, skip)
 	if err != nil {
 		s.log.Error(ctx, "error to get accounts", logger.Err(err))
 		return accounts, totalRecords, err
 	}
 
+	err = s.decryptCPFs(ctx, accounts)
+	if err != nil {
+		return nil, totalRecords, err
+	}
+
 	return accounts, totalRecords, nil
 }
 
+// GetAccountsByCursor lists the accounts newest first, the only order a
+// cursor can follow, so a sort is refused.
+func (s *accountService) GetAccountsByCursor(ctx context.Context, input dto.AccountSearchInput, page entity.CursorPage) (accounts []entity.Account, result entity.CursorResult, err error) {
+	filter, err := s.searchFilter(ctx, input)
+	if err != nil {
+		return accounts, result, err
+	}
+
+	if len(filter.Sort) > 0 {
+		return accounts, result, apperr.ErrInvalidInput.WithMessage("sort can not be used when paging by cursor")
+	}
+
+	accounts, result, err = s.dm.Account().GetAccountsByCursor(ctx, filter, page)
+	if err != nil {
+		s.log.Error(ctx, "error to get accounts", logger.Err(err))
+		return accounts, result, err
+	}
+
+	err = s.decryptCPFs(ctx, accounts)
+	if err != nil {
+		return nil, result, err
+	}
+
+	return accounts, result, nil
+}
+
 func (s *accountService) GetAccountByUUID(ctx context.Context, accountUUID string) (account entity.Account, err error) {
 	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", accountUUID))
 
@@ -110,6 +629,11 @@ func (s *accountService) GetAccountByUUID(ctx context.Context, accountUUID strin
 		return account, err
 	}
 
+	err = s.decryptCPF(ctx, &account)
+	if err != nil {
+		return entity.Account{}, err
+	}
+
 	return account, nil
 }
 
@@ -140,6 +664,21 @@ func (s *accountService) GetLoggedAccountID(ctx context.Context) (accountID int6
 }
 
 func (s *accountService) GetLoggedAccount(ctx context.Context) (account entity.Account, err error) {
+	account, err = s.getLoggedAccount(ctx)
+	if err != nil {
+		return account, err
+	}
+
+	err = s.decryptCPF(ctx, &account)
+	if err != nil {
+		return entity.Account{}, err
+	}
+
+	return account, nil
+}
+
+// getLoggedAccount reads the logged account as stored, its CPF encrypted.
+func (s *accountService) getLoggedAccount(ctx context.Context) (account entity.Account, err error) {
 	loggedAccountUUID, err := s.getLoggedAccountUUID(ctx)
 	if err != nil {
 		return account, err
@@ -153,3 +692,92 @@ func (s *accountService) GetLoggedAccount(ctx context.Context) (account entity.A
 
 	return account, nil
 }
+
+func (s *accountService) UpdateProfile(ctx context.Context, input dto.ProfileInput) (account entity.Account, err error) {
+	account, err = s.GetLoggedAccount(ctx)
+	if err != nil {
+		return account, err
+	}
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", account.UUID))
+
+	err = input.Apply(ctx, s.validator, &account)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return account, err
+	}
+
+	err = s.dm.Account().UpdateAccountProfile(ctx, account)
+	if err != nil {
+		s.log.Error(ctx, "error to update account profile", logger.Err(err))
+		return account, err
+	}
+
+	return account, nil
+}
+
+// ChangePassword replaces the password of the logged account and blocks
+// every session but the one asking, so whoever else knew the old password is
+// signed out.
+func (s *accountService) ChangePassword(ctx context.Context, input dto.PasswordChangeInput) (err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	currentSessionUUID, _ := ctx.Value(infra.SessionKey).(string)
+
+	account, err := s.getLoggedAccount(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_uuid", account.UUID))
+
+	err = s.crypto.CheckPassword(input.CurrentPassword, account.Password)
+	if err != nil {
+		s.log.Error(ctx, "wrong current password")
+		return errcodes.ErrWrongCurrentPassword
+	}
+
+	hashedPassword, err := s.crypto.HashPassword(input.NewPassword)
+	if err != nil {
+		s.log.Error(ctx, "error to hash password", logger.Err(err))
+		return err
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		err := tx.Account().UpdateAccountPassword(ctx, account.ID, hashedPassword)
+		if err != nil {
+			s.log.Error(ctx, "error to update account password", logger.Err(err))
+			return err
+		}
+
+		sessions, err := tx.Auth().GetActiveSessionsByAccountID(ctx, account.ID)
+		if err != nil {
+			s.log.Error(ctx, "error to get active sessions", logger.Err(err))
+			return err
+		}
+
+		sessionUUIDs = []string{}
+		for _, session := range sessions {
+			if session.SessionUUID != currentSessionUUID {
+				sessionUUIDs = append(sessionUUIDs, session.SessionUUID)
+			}
+		}
+
+		err = revokeSessions(ctx, tx, sessionUUIDs)
+		if err != nil {
+			s.log.Error(ctx, "error to revoke sessions", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
+	return nil
+}
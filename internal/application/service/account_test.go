@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -19,9 +22,9 @@ func Test_newAccountService(t *testing.T) {
 	m, ctrl := newServiceTestMock(t)
 	defer ctrl.Finish()
 
-	want := &accountService{dm: m.mockDataManager, crypto: m.mockCrypto, log: m.mockLogger, validator: m.mockValidator}
+	want := &accountService{cache: m.mockCacheManager, dm: m.mockDataManager, crypto: m.mockCrypto, log: m.mockLogger, validator: m.mockValidator, accessTokenDuration: time.Minute}
 
-	if got := newAccountService(m.mockDomain); !reflect.DeepEqual(got, want) {
+	if got := newAccountService(m.mockDomain, time.Minute); !reflect.DeepEqual(got, want) {
 		t.Errorf("newAccountService() = %v, want %v", got, want)
 	}
 }
@@ -45,12 +48,15 @@ func Test_accountService_CreateAccount(t *testing.T) {
 			}},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(args.account.CPF).Return("encrypted-cpf", nil).Times(1),
 					mocks.mockCrypto.EXPECT().HashPassword(args.account.Password).Return("123", nil).Times(1),
 
 					mocks.mockAccountRepo.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, account entity.Account) (int64, error) {
 						require.Equal(t, args.account.Name, account.Name)
-						require.Equal(t, args.account.CPF, account.CPF)
+						require.Equal(t, "encrypted-cpf", account.CPF)
+						require.Equal(t, "cpf-index", account.CPFIndex)
 						require.NotEmpty(t, account.Password)
 						return int64(0), nil
 					}).Times(1),
@@ -65,7 +71,10 @@ func Test_accountService_CreateAccount(t *testing.T) {
 				Password: "01234567890",
 			}},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.account.CPF).Return(entity.Account{}, errors.New("some error")).Times(1)
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, errors.New("some error")).Times(1),
+				)
 			},
 			wantErr: true,
 		},
@@ -78,7 +87,9 @@ func Test_accountService_CreateAccount(t *testing.T) {
 			}},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(args.account.CPF).Return("encrypted-cpf", nil).Times(1),
 					mocks.mockCrypto.EXPECT().HashPassword(args.account.Password).Return("123", nil).Times(1),
 					mocks.mockAccountRepo.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("some error")).Times(1),
 				)
@@ -94,12 +105,30 @@ func Test_accountService_CreateAccount(t *testing.T) {
 			}},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(args.account.CPF).Return("encrypted-cpf", nil).Times(1),
 					mocks.mockCrypto.EXPECT().HashPassword(args.account.Password).Return("", errors.New("some error")).Times(1),
 				)
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should return error with there is some error to encrypt the cpf",
+			args: args{account: dto.AccountInput{
+				Name:     "name",
+				CPF:      "01234567890",
+				Password: "01234567890",
+			}},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(args.account.CPF).Return("", errors.New("some error")).Times(1),
+				)
+			},
+			wantErr: true,
+		},
 		{
 			name: "Should return error cpf already in use",
 			args: args{account: dto.AccountInput{
@@ -108,7 +137,10 @@ func Test_accountService_CreateAccount(t *testing.T) {
 				Password: "01234567890",
 			}},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.account.CPF).Return(entity.Account{}, nil).Times(1)
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().BlindIndex(args.account.CPF).Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.account.CPF).Return(entity.Account{}, nil).Times(1),
+				)
 			},
 			wantErr: true,
 		},
@@ -125,7 +157,7 @@ func Test_accountService_CreateAccount(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
@@ -140,60 +172,155 @@ func Test_accountService_CreateAccount(t *testing.T) {
 func Test_accountService_AddBalance(t *testing.T) {
 	type args struct {
 		accountUUID string
-		amount      float64
+		amount      entity.Money
 	}
+
+	const fundingAccountID = int64(99)
+
+	// posting matches one leg of the deposit's journal, whose UUID is only
+	// known once the service generates it
+	posting := func(accountID int64, amount entity.Money) gomock.Matcher {
+		return gomock.Cond(func(entry entity.LedgerEntry) bool {
+			return entry.JournalUUID != "" &&
+				entry.AccountID == accountID &&
+				entry.Type == entity.LedgerEntryDeposit &&
+				entry.Amount == amount
+		})
+	}
+
 	tests := []struct {
 		name      string
 		buildMock func(ctx context.Context, mocks allMocks, args args)
 		args      args
 		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name: "Should add balance without any errors",
-			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: 7.32},
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{result}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), entity.FundingAccountUUID).Return(fundingAccountID, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(fundingAccountID, args.amount)).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(result.ID, args.amount)).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error with there is some error to get account by uuid",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: 50}
+				result := entity.Account{}
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, assert.AnError).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error with there is some error to lock the account",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
 				gomock.InOrder(
 					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), result.ID, result.Balance+args.amount).Return(nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return(nil, assert.AnError).Times(1),
 				)
 			},
+			wantErr: true,
 		},
 		{
-			name: "Should add balance validating floating point",
-			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: 0.1},
+			name: "Should refuse the deposit when the account is closed once locked",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: 0.2}
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(0, entity.BRL)}
+				closedAt := time.Now()
+				closed := result
+				closed.ClosedAt = &closedAt
 				gomock.InOrder(
 					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), result.ID, 0.3).Return(nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{closed}, nil).Times(1),
 				)
 			},
+			wantErr:   true,
+			wantErrIs: errcodes.ErrDestinationAccountClosed,
 		},
 		{
-			name: "Should return error with there is some error to get account by uuid",
-			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: 7.32},
+			name: "Should return error with there is some error to get the funding account",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := entity.Account{}
-				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, assert.AnError).Times(1)
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{result}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), entity.FundingAccountUUID).Return(int64(0), assert.AnError).Times(1),
+				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error with there is some error to update account balance",
-			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: 7.32},
+			name: "Should return error with there is some error to debit the funding account",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: 50}
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
 				gomock.InOrder(
 					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), result.ID, result.Balance+args.amount).Return(assert.AnError).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{result}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), entity.FundingAccountUUID).Return(fundingAccountID, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(fundingAccountID, args.amount)).Return(false, assert.AnError).Times(1),
 				)
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should return error when the funding account refuses the debit",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{result}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), entity.FundingAccountUUID).Return(fundingAccountID, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(fundingAccountID, args.amount)).Return(false, nil).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error with there is some error to credit account balance",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, entity.BRL)},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{result.ID}).Return([]entity.Account{result}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), entity.FundingAccountUUID).Return(fundingAccountID, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(fundingAccountID, args.amount)).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(result.ID, args.amount)).Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error when the amount currency differs from the account currency",
+			args: args{accountUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", amount: entity.NewMoney(732, "USD")},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 12, UUID: args.accountUUID, Balance: entity.NewMoney(5000, entity.BRL)}
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1)
+			},
+			wantErr: true,
+		},
 		{
 			name:    "Should return error with invalid input",
-			args:    args{accountUUID: "", amount: 0},
+			args:    args{accountUUID: "", amount: entity.Money{}},
 			wantErr: true,
 		},
 	}
@@ -204,7 +331,7 @@ func Test_accountService_AddBalance(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
@@ -215,17 +342,499 @@ func Test_accountService_AddBalance(t *testing.T) {
 				Amount:      tt.args.amount,
 			}
 
-			if err := s.AddBalance(ctx, input); (err != nil) != tt.wantErr {
+			err := s.AddBalance(ctx, input)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("AddBalance() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantErrIs != nil {
+				require.ErrorIs(t, err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func Test_accountService_DeactivateAccount(t *testing.T) {
+	const accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	validInput := dto.AccountStatusInput{AccountUUID: accountUUID, Reason: "customer asked"}
+
+	deactivated := gomock.Cond(func(account entity.Account) bool {
+		return account.ID == 12 && !account.Active && account.ClosedAt == nil
+	})
+
+	change := entity.AccountStatusChange{AccountID: 12, Action: entity.AccountDeactivated, Reason: "customer asked"}
+
+	tests := []struct {
+		name      string
+		input     dto.AccountStatusInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should deactivate the account and block its sessions",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), deactivated).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(), change).Return(int64(1), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionsAsBlockedByAccountID(gomock.Any(), int64(12)).Return([]string{"session-1", "session-2"}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-1"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-2"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the account is already deactivated",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountAlreadyDeactivated,
+		},
+		{
+			name:  "Should return error if the account is closed",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				closedAt := time.Now()
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, ClosedAt: &closedAt}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountClosed,
+		},
+		{
+			name:  "Should return error if the account is not found",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(0), apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrAccountNotFound,
+		},
+		{
+			name:    "Should not change the status of the funding account",
+			input:   dto.AccountStatusInput{AccountUUID: entity.FundingAccountUUID, Reason: "customer asked"},
+			wantErr: errcodes.ErrAccountNotFound,
+		},
+		{
+			name:  "Should return error if the sessions can't be blocked",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), deactivated).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(), change).Return(int64(1), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionsAsBlockedByAccountID(gomock.Any(), int64(12)).Return(nil, assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:  "Should deactivate the account even if the sessions can't be marked as revoked",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), deactivated).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(), change).Return(int64(1), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionsAsBlockedByAccountID(gomock.Any(), int64(12)).Return([]string{"session-1"}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-1"), sessionStateRevoked, gomock.Any()).Return(assert.AnError).Times(1),
+				)
+			},
+		},
+		{
+			name:    "Should return error without a reason",
+			input:   dto.AccountStatusInput{AccountUUID: accountUUID, Reason: "   "},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			err := s.DeactivateAccount(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_accountService_ReactivateAccount(t *testing.T) {
+	const accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	validInput := dto.AccountStatusInput{AccountUUID: accountUUID, Reason: "fraud check cleared"}
+
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should reactivate the account",
+			buildMock: func(mocks allMocks) {
+				reactivated := gomock.Cond(func(account entity.Account) bool {
+					return account.ID == 12 && account.Active
+				})
+
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), reactivated).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(),
+						entity.AccountStatusChange{AccountID: 12, Action: entity.AccountReactivated, Reason: validInput.Reason}).
+						Return(int64(1), nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error if the account is already active",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountAlreadyActive,
+		},
+		{
+			name: "Should not reactivate a closed account",
+			buildMock: func(mocks allMocks) {
+				closedAt := time.Now()
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, ClosedAt: &closedAt}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountClosed,
+		},
+		{
+			name: "Should return error if the status can't be updated",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			err := s.ReactivateAccount(ctx, validInput)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_accountService_CloseAccount(t *testing.T) {
+	const (
+		accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+		sweepUUID   = "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1"
+	)
+
+	brl := func(amount int64) entity.Money { return entity.NewMoney(amount, entity.BRL) }
+
+	closed := gomock.Cond(func(account entity.Account) bool {
+		return account.ID == 12 && !account.Active && account.ClosedAt != nil
+	})
+
+	// sweep matches the transfer moving the whole balance out of account 12
+	sweep := gomock.Cond(func(transfer entity.Transfer) bool {
+		return transfer.TransferUUID != "" &&
+			transfer.AccountOriginID == 12 &&
+			transfer.AccountDestinationID == 30 &&
+			transfer.Amount == brl(750) &&
+			transfer.Status == entity.TransferCompleted
+	})
+
+	posting := func(accountID int64) gomock.Matcher {
+		return gomock.Cond(func(entry entity.LedgerEntry) bool {
+			return entry.JournalUUID != "" &&
+				entry.AccountID == accountID &&
+				entry.TransferID == 40 &&
+				entry.Type == entity.LedgerEntryTransfer &&
+				entry.Amount == brl(750)
+		})
+	}
+
+	tests := []struct {
+		name      string
+		input     dto.CloseAccountInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should close an account with zero balance",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked"},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true, Balance: brl(0)}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), closed).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(),
+						entity.AccountStatusChange{AccountID: 12, Action: entity.AccountClosed, Reason: "customer asked"}).
+						Return(int64(1), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().CancelRecurringTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(2), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionsAsBlockedByAccountID(gomock.Any(), int64(12)).Return([]string{"session-1"}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-1"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should sweep the balance to the sweep account before closing",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(30), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12, 30}).
+						Return([]entity.Account{
+							{ID: 12, Active: true, Balance: brl(750)},
+							{ID: 30, Active: true, Balance: brl(100)},
+						}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), sweep).Return(int64(40), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(12)).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(30)).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), closed).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(),
+						entity.AccountStatusChange{AccountID: 12, Action: entity.AccountClosed, Reason: "customer asked", SweepTransferID: 40}).
+						Return(int64(1), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().CancelRecurringTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(2), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionsAsBlockedByAccountID(gomock.Any(), int64(12)).Return([]string{}, nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the schedules of the account can't be canceled",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked"},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true, Balance: brl(0)}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountStatus(gomock.Any(), closed).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddAccountStatusChange(gomock.Any(),
+						entity.AccountStatusChange{AccountID: 12, Action: entity.AccountClosed, Reason: "customer asked"}).
+						Return(int64(1), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().CancelRecurringTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfersByAccountID(gomock.Any(), int64(12)).Return(int64(0), assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:  "Should not close an account with a balance and no sweep account",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked"},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Active: true, Balance: brl(750)}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountBalanceNotZero,
+		},
+		{
+			name:  "Should not sweep to a closed account",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				closedAt := time.Now()
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(30), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12, 30}).
+						Return([]entity.Account{
+							{ID: 12, Active: true, Balance: brl(750)},
+							{ID: 30, Balance: brl(0), ClosedAt: &closedAt},
+						}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrDestinationAccountClosed,
+		},
+		{
+			name:  "Should not sweep to an account in another currency",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(30), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12, 30}).
+						Return([]entity.Account{
+							{ID: 12, Active: true, Balance: brl(750)},
+							{ID: 30, Active: true, Balance: entity.NewMoney(0, "USD")},
+						}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrInvalidSweepAccount,
+		},
+		{
+			name:  "Should return error if the sweep account is not found",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(0), apperr.ErrRecordNotFound).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrInvalidSweepAccount,
+		},
+		{
+			name:  "Should not close an account twice",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked"},
+			buildMock: func(mocks allMocks) {
+				closedAt := time.Now()
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12}).
+						Return([]entity.Account{{ID: 12, Balance: brl(0), ClosedAt: &closedAt}}, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrAccountClosed,
+		},
+		{
+			name:  "Should return error if the sweep can't be credited",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(30), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{12, 30}).
+						Return([]entity.Account{
+							{ID: 12, Active: true, Balance: brl(750)},
+							{ID: 30, Active: true, Balance: brl(100)},
+						}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), sweep).Return(int64(40), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(12)).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(30)).Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "Should return error if the sweep account is the account itself",
+			input:   dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: accountUUID},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:    "Should return error if the sweep account is the account itself in another case",
+			input:   dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: strings.ToUpper(accountUUID)},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:  "Should return error if the sweep account resolves to the account itself",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: sweepUUID},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), sweepUUID).Return(int64(12), nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrInvalidSweepAccount,
+		},
+		{
+			name:  "Should not sweep to the funding account",
+			input: dto.CloseAccountInput{AccountUUID: accountUUID, Reason: "customer asked", SweepAccountUUID: entity.FundingAccountUUID},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1)
+			},
+			wantErr: errcodes.ErrInvalidSweepAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			err := s.CloseAccount(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
 		})
 	}
 }
 
 func Test_accountService_GetAccounts(t *testing.T) {
 	type args struct {
-		take int64
-		skip int64
+		input dto.AccountSearchInput
+		take  int64
+		skip  int64
 	}
 	tests := []struct {
 		name      string
@@ -239,18 +848,59 @@ func Test_accountService_GetAccounts(t *testing.T) {
 			name: "Should return accounts without any errors",
 			args: args{take: 10, skip: 0},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := []entity.Account{{ID: 1, UUID: "123", Name: "name"}}
-				mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, args.take, args.skip).Return(result, int64(1), nil).Times(1)
+				result := []entity.Account{{ID: 1, UUID: "123", Name: "name", CPF: "encrypted-cpf"}}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, entity.AccountFilter{}, args.take, args.skip).Return(result, int64(1), nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+				)
 			},
-			want:    []entity.Account{{ID: 1, UUID: "123", Name: "name"}},
+			want:    []entity.Account{{ID: 1, UUID: "123", Name: "name", CPF: "01234567890"}},
 			want1:   1,
 			wantErr: false,
 		},
+		{
+			name: "Should pass the filter to the repository",
+			args: args{input: dto.AccountSearchInput{Name: "john", Sort: []string{"name:desc"}}, take: 10, skip: 0},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				filter := entity.AccountFilter{Name: "john", Sort: []entity.SortField{{Field: "name", Descending: true}}}
+				mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, filter, args.take, args.skip).Return([]entity.Account{}, int64(0), nil).Times(1)
+			},
+			want: []entity.Account{},
+		},
+		{
+			name: "Should filter by the blind index of the cpf",
+			args: args{input: dto.AccountSearchInput{CPF: "012.345.678-90"}, take: 10, skip: 0},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().BlindIndex("01234567890").Return("cpf-index").Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, entity.AccountFilter{CPFIndex: "cpf-index"}, args.take, args.skip).Return([]entity.Account{}, int64(0), nil).Times(1),
+				)
+			},
+			want: []entity.Account{},
+		},
+		{
+			name: "Should return error if a cpf can't be decrypted",
+			args: args{take: 10, skip: 0},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := []entity.Account{{ID: 1, UUID: "123", Name: "name", CPF: "encrypted-cpf"}}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, entity.AccountFilter{}, args.take, args.skip).Return(result, int64(1), nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("", errors.New("some error")).Times(1),
+				)
+			},
+			want1:   1,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error if the filter is invalid",
+			args:    args{input: dto.AccountSearchInput{Sort: []string{"password"}}, take: 10, skip: 0},
+			wantErr: true,
+		},
 		{
 			name: "Should return error with there is some error to get accounts",
 			args: args{take: 10, skip: 0},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, args.take, args.skip).Return([]entity.Account{}, int64(0), errors.New("some error")).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetAccounts(ctx, entity.AccountFilter{}, args.take, args.skip).Return([]entity.Account{}, int64(0), errors.New("some error")).Times(1)
 			},
 			want:    []entity.Account{},
 			want1:   0,
@@ -264,13 +914,13 @@ func Test_accountService_GetAccounts(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
 			}
 
-			got, got1, err := s.GetAccounts(ctx, tt.args.take, tt.args.skip)
+			got, got1, err := s.GetAccounts(ctx, tt.args.input, tt.args.take, tt.args.skip)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("accountService.GetAccounts() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -285,6 +935,67 @@ func Test_accountService_GetAccounts(t *testing.T) {
 	}
 }
 
+func Test_accountService_GetAccountsByCursor(t *testing.T) {
+	page := entity.CursorPage{After: &entity.Cursor{CreatedAt: time.Now(), ID: 3}, Limit: 10}
+	next := entity.Cursor{CreatedAt: time.Now(), ID: 1}
+
+	tests := []struct {
+		name      string
+		input     dto.AccountSearchInput
+		buildMock func(ctx context.Context, mocks allMocks)
+		want      entity.CursorResult
+		wantErr   bool
+	}{
+		{
+			name: "Should return the page and the cursors around it",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountsByCursor(ctx, entity.AccountFilter{}, page).
+						Return([]entity.Account{{ID: 2, CPF: "encrypted-2"}, {ID: 1, CPF: "encrypted-1"}}, entity.CursorResult{Next: &next}, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-2").Return("01234567890", nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-1").Return("12345678909", nil).Times(1),
+				)
+			},
+			want: entity.CursorResult{Next: &next},
+		},
+		{
+			name:    "Should return error if a sort is asked for",
+			input:   dto.AccountSearchInput{Sort: []string{"name"}},
+			wantErr: true,
+		},
+		{
+			name: "Should return error with there is some error to get accounts",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountsByCursor(ctx, entity.AccountFilter{}, page).
+					Return(nil, entity.CursorResult{}, errors.New("some error")).Times(1)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			if tt.buildMock != nil {
+				tt.buildMock(ctx, m)
+			}
+
+			_, got, err := s.GetAccountsByCursor(ctx, tt.input, page)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("accountService.GetAccountsByCursor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("accountService.GetAccountsByCursor() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_accountService_GetAccountByUUID(t *testing.T) {
 
 	type args struct {
@@ -303,12 +1014,29 @@ func Test_accountService_GetAccountByUUID(t *testing.T) {
 				accountUUID: "123",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				result := entity.Account{ID: 1, UUID: "123", Name: "name"}
-				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1)
+				result := entity.Account{ID: 1, UUID: "123", Name: "name", CPF: "encrypted-cpf"}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+				)
 			},
-			wantAccount: entity.Account{ID: 1, UUID: "123", Name: "name"},
+			wantAccount: entity.Account{ID: 1, UUID: "123", Name: "name", CPF: "01234567890"},
 			wantErr:     false,
 		},
+		{
+			name: "Should return error if the cpf can't be decrypted",
+			args: args{
+				accountUUID: "123",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				result := entity.Account{ID: 1, UUID: "123", Name: "name", CPF: "encrypted-cpf"}
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.accountUUID).Return(result, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("", errors.New("some error")).Times(1),
+				)
+			},
+			wantErr: true,
+		},
 		{
 			name: "Should error if database return some error",
 			args: args{
@@ -328,7 +1056,7 @@ func Test_accountService_GetAccountByUUID(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
@@ -388,7 +1116,7 @@ func Test_accountService_GetLoggedAccountID(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(tt.args.ctx, m)
@@ -421,9 +1149,12 @@ func Test_accountService_GetLoggedAccount(t *testing.T) {
 			name: "Should return logged account without any errors",
 			args: args{ctx: context.WithValue(context.Background(), infra.AccountUUIDKey, "123")},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, "123").Return(entity.Account{ID: 1, UUID: "123", Name: "name"}, nil).Times(1)
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, "123").Return(entity.Account{ID: 1, UUID: "123", Name: "name", CPF: "encrypted-cpf"}, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+				)
 			},
-			want:    entity.Account{ID: 1, UUID: "123", Name: "name"},
+			want:    entity.Account{ID: 1, UUID: "123", Name: "name", CPF: "01234567890"},
 			wantErr: false,
 		},
 		{
@@ -448,7 +1179,7 @@ func Test_accountService_GetLoggedAccount(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newAccountService(m.mockDomain)
+			s := newAccountService(m.mockDomain, time.Minute)
 
 			if tt.buildMock != nil {
 				tt.buildMock(tt.args.ctx, m, tt.args)
@@ -465,3 +1196,260 @@ func Test_accountService_GetLoggedAccount(t *testing.T) {
 		})
 	}
 }
+
+func Test_accountService_UpdateProfile(t *testing.T) {
+	const accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	name := func(s string) *string { return &s }
+	account := entity.Account{ID: 12, UUID: accountUUID, Name: "John Doe", CPF: "encrypted-cpf"}
+	renamed := entity.Account{ID: 12, UUID: accountUUID, Name: "Jane Doe", CPF: "01234567890"}
+
+	tests := []struct {
+		name      string
+		input     dto.ProfileInput
+		buildMock func(mocks allMocks)
+		want      entity.Account
+		wantErr   error
+	}{
+		{
+			name:  "Should update the profile",
+			input: dto.ProfileInput{Name: name(" Jane Doe ")},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountProfile(gomock.Any(), renamed).Return(nil).Times(1),
+				)
+			},
+			want: renamed,
+		},
+		{
+			name:  "Should return error if there is nothing to update",
+			input: dto.ProfileInput{},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+				)
+			},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:  "Should return error if the profile can't be updated",
+			input: dto.ProfileInput{Name: name("Jane Doe")},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("01234567890", nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountProfile(gomock.Any(), renamed).Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), infra.AccountUUIDKey, accountUUID)
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			got, err := s.UpdateProfile(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_accountService_ChangePassword(t *testing.T) {
+	const (
+		accountUUID    = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+		currentSession = "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1"
+		otherSession   = "5b0e3a52-7a1c-4f0e-8d7e-2f6d9c1b4e3a"
+	)
+
+	validInput := dto.PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "87654321"}
+	account := entity.Account{ID: 12, UUID: accountUUID, Password: "hashed-current"}
+	sessions := []dto.Session{{SessionUUID: currentSession}, {SessionUUID: otherSession}}
+
+	tests := []struct {
+		name      string
+		input     dto.PasswordChangeInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should change the password and revoke every other session",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword("12345678", "hashed-current").Return(nil).Times(1),
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountPassword(gomock.Any(), int64(12), "hashed-new").Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(gomock.Any(), int64(12)).Return(sessions, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), otherSession).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey(otherSession), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the current password is wrong",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword("12345678", "hashed-current").Return(errors.New("mismatch")).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrWrongCurrentPassword,
+		},
+		{
+			name:    "Should return error if the new password is too short",
+			input:   dto.PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "1234567"},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:  "Should return error if the password can't be updated",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword("12345678", "hashed-current").Return(nil).Times(1),
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountPassword(gomock.Any(), int64(12), "hashed-new").Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:  "Should change the password even if a session can't be marked as revoked",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), accountUUID).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword("12345678", "hashed-current").Return(nil).Times(1),
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountPassword(gomock.Any(), int64(12), "hashed-new").Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(gomock.Any(), int64(12)).Return(sessions, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), otherSession).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey(otherSession), sessionStateRevoked, gomock.Any()).Return(assert.AnError).Times(1),
+				)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), infra.AccountUUIDKey, accountUUID)
+			ctx = context.WithValue(ctx, infra.SessionKey, currentSession)
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			err := s.ChangePassword(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_accountService_ChangeRole(t *testing.T) {
+	const (
+		accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+		sessionUUID = "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1"
+	)
+
+	sessions := []dto.Session{{SessionUUID: sessionUUID}}
+
+	tests := []struct {
+		name      string
+		input     dto.RoleInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should change the role and revoke every session",
+			input: dto.RoleInput{AccountUUID: accountUUID, Role: " Admin "},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountRole(gomock.Any(), int64(12), entity.RoleAdmin).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(gomock.Any(), int64(12)).Return(sessions, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), sessionUUID).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey(sessionUUID), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:    "Should return error for an unknown role",
+			input:   dto.RoleInput{AccountUUID: accountUUID, Role: "root"},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:  "Should return error if the account doesn't exist",
+			input: dto.RoleInput{AccountUUID: accountUUID, Role: "support"},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(0), apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrAccountNotFound,
+		},
+		{
+			name:  "Should return error if the role can't be updated",
+			input: dto.RoleInput{AccountUUID: accountUUID, Role: "support"},
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), accountUUID).Return(int64(12), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountRole(gomock.Any(), int64(12), entity.RoleSupport).Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAccountService(m.mockDomain, time.Minute)
+
+			err := s.ChangeRole(context.Background(), tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/diegoclair/apperr"
@@ -11,32 +12,55 @@ import (
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/util/tokenhash"
 	"github.com/diegoclair/logger"
 	"github.com/diegoclair/appvalidator/apperrmap"
 )
 
+// accessTokenGrace is how long past its duration an access token stays
+// denied, so a clock a little behind on the server that checks it can't let it
+// back in.
+const accessTokenGrace = 3 * time.Minute
+
 type authApp struct {
-	cache               contract.CacheManager
-	crypto              contract.Crypto
-	dm                  contract.DataManager
-	log                 logger.Logger
-	validator           apperrmap.Validator
-	accountSvc          contract.AccountApp
-	accessTokenDuration time.Duration
+	cache                 contract.CacheManager
+	crypto                contract.Crypto
+	dm                    contract.DataManager
+	log                   logger.Logger
+	notifier              contract.Notifier
+	validator             apperrmap.Validator
+	accountSvc            contract.AccountApp
+	accessTokenDuration   time.Duration
+	passwordResetDuration time.Duration
+	loginThrottle         entity.LoginThrottle
+	mfaIssuer             string
+	maxSessions           int64
+
+	// background is the work still running after its request was answered
+	background sync.WaitGroup
 }
 
-func newAuthApp(infra domain.Infrastructure, accountSvc contract.AccountApp, accessTokenDuration time.Duration) *authApp {
+func newAuthApp(infra domain.Infrastructure, accountSvc contract.AccountApp, accessTokenDuration, passwordResetDuration time.Duration,
+	loginThrottle entity.LoginThrottle, mfaIssuer string, maxSessions int64) *authApp {
 	return &authApp{
-		cache:               infra.CacheManager(),
-		crypto:              infra.Crypto(),
-		dm:                  infra.DataManager(),
-		log:                 infra.Logger(),
-		validator:           infra.Validator(),
-		accountSvc:          accountSvc,
-		accessTokenDuration: accessTokenDuration,
+		cache:                 infra.CacheManager(),
+		crypto:                infra.Crypto(),
+		dm:                    infra.DataManager(),
+		log:                   infra.Logger(),
+		notifier:              infra.Notifier(),
+		validator:             infra.Validator(),
+		accountSvc:            accountSvc,
+		accessTokenDuration:   accessTokenDuration,
+		passwordResetDuration: passwordResetDuration,
+		loginThrottle:         loginThrottle,
+		mfaIssuer:             mfaIssuer,
+		maxSessions:           maxSessions,
 	}
 }
 
+// Login counts the failures of the CPF and of the ip, and refuses them with
+// an AccountLockedError while they have to wait. An unknown CPF counts the
+// same as a wrong password, so the wait tells nothing about the account.
 func (s *authApp) Login(ctx context.Context, input dto.LoginInput) (account entity.Account, err error) {
 	err = input.Validate(ctx, s.validator)
 	if err != nil {
@@ -44,9 +68,21 @@ func (s *authApp) Login(ctx context.Context, input dto.LoginInput) (account enti
 		return account, err
 	}
 
-	account, err = s.dm.Account().GetAccountByDocument(ctx, input.CPF)
+	cpfIndex := s.crypto.BlindIndex(input.CPF)
+	scopes := s.loginScopes(cpfIndex, input.ClientIP)
+
+	retryAfter := s.loginRetryAfter(ctx, scopes)
+	if retryAfter > 0 {
+		s.log.Warn(ctx, "login refused while locked")
+		return account, errcodes.NewAccountLockedError(retryAfter)
+	}
+
+	account, err = s.dm.Account().GetAccountByDocument(ctx, cpfIndex, input.CPF)
 	if err != nil {
 		s.log.Error(ctx, "error getting account by document", logger.Err(err))
+		if apperr.IsNotFound(err) {
+			s.recordLoginFailure(ctx, scopes, 0)
+		}
 		return account, errcodes.ErrInvalidCredentials
 	}
 
@@ -61,9 +97,21 @@ func (s *authApp) Login(ctx context.Context, input dto.LoginInput) (account enti
 	err = s.crypto.CheckPassword(input.Password, account.Password)
 	if err != nil {
 		s.log.Error(ctx, "wrong password")
+		s.recordLoginFailure(ctx, scopes, account.ID)
 		return account, errcodes.ErrInvalidCredentials
 	}
 
+	// with MFA the login is only through once LoginMFA takes a code. Clearing
+	// the failures here would let the password reset the count of wrong codes.
+	mfaEnabled, err := s.IsMFAEnabled(ctx, account.ID)
+	if err != nil {
+		return account, err
+	}
+
+	if !mfaEnabled {
+		s.clearLoginFailures(ctx, scopes)
+	}
+
 	return account, nil
 }
 
@@ -74,12 +122,161 @@ func (s *authApp) CreateSession(ctx context.Context, session dto.Session) (err e
 		return err
 	}
 
-	_, err = s.dm.Auth().CreateSession(ctx, session)
+	var evicted []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		_, err := tx.Auth().CreateSession(ctx, session)
+		if err != nil {
+			s.log.Error(ctx, "error creating session", logger.Err(err))
+			return err
+		}
+
+		evicted, err = s.evictSessions(ctx, tx, session.AccountID)
+		return err
+	})
 	if err != nil {
-		s.log.Error(ctx, "error creating session", logger.Err(err))
 		return err
 	}
 
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, evicted)
+	return nil
+}
+
+// evictSessions signs the account out of its oldest sessions while it has
+// more than maxSessions, returning the ones it revoked.
+func (s *authApp) evictSessions(ctx context.Context, tx contract.Repos, accountID int64) (sessionUUIDs []string, err error) {
+	if s.maxSessions <= 0 {
+		return nil, nil
+	}
+
+	sessions, err := tx.Auth().GetActiveSessionsByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Error(ctx, "error getting active sessions", logger.Err(err))
+		return nil, err
+	}
+
+	excess := int64(len(sessions)) - s.maxSessions
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	// the sessions come oldest first
+	sessionUUIDs = make([]string, 0, excess)
+	for _, session := range sessions[:excess] {
+		sessionUUIDs = append(sessionUUIDs, session.SessionUUID)
+	}
+
+	s.log.Info(ctx, "signing out of the oldest sessions past the cap", logger.Attr("sessions", len(sessionUUIDs)))
+
+	err = revokeSessions(ctx, tx, sessionUUIDs)
+	if err != nil {
+		s.log.Error(ctx, "error revoking sessions", logger.Err(err))
+		return nil, err
+	}
+
+	return sessionUUIDs, nil
+}
+
+// ListSessions lists the active sessions of the logged account, oldest
+// first, with the one of the caller marked as Current.
+func (s *authApp) ListSessions(ctx context.Context) (sessions []dto.Session, err error) {
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err = s.dm.Auth().GetActiveSessionsByAccountID(ctx, accountID)
+	if err != nil {
+		s.log.Error(ctx, "error getting active sessions", logger.Err(err))
+		return nil, err
+	}
+
+	currentSessionUUID, _ := ctx.Value(infra.SessionKey).(string)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionUUID == currentSessionUUID
+	}
+
+	return sessions, nil
+}
+
+func (s *authApp) RevokeSession(ctx context.Context, sessionUUID string) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("session_uuid", sessionUUID))
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.dm.Auth().GetSessionByUUID(ctx, sessionUUID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return errcodes.ErrUnknownSession
+		}
+		s.log.Error(ctx, "error getting session", logger.Err(err))
+		return err
+	}
+
+	// a session of another account is as unknown as a missing one
+	if session.AccountID != accountID {
+		return errcodes.ErrUnknownSession
+	}
+
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		err := revokeSessions(ctx, tx, []string{sessionUUID})
+		if err != nil {
+			s.log.Error(ctx, "error revoking sessions", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, []string{sessionUUID})
+	return nil
+}
+
+func (s *authApp) RevokeOtherSessions(ctx context.Context) (err error) {
+	currentSessionUUID, ok := ctx.Value(infra.SessionKey).(string)
+	if !ok || currentSessionUUID == "" {
+		s.log.Error(ctx, "session UUID not found in context")
+		return errcodes.ErrSessionNotFound
+	}
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		sessions, err := tx.Auth().GetActiveSessionsByAccountID(ctx, accountID)
+		if err != nil {
+			s.log.Error(ctx, "error getting active sessions", logger.Err(err))
+			return err
+		}
+
+		sessionUUIDs = make([]string, 0, len(sessions))
+		for _, session := range sessions {
+			if session.SessionUUID != currentSessionUUID {
+				sessionUUIDs = append(sessionUUIDs, session.SessionUUID)
+			}
+		}
+
+		err = revokeSessions(ctx, tx, sessionUUIDs)
+		if err != nil {
+			s.log.Error(ctx, "error revoking sessions", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
 	return nil
 }
 
@@ -98,26 +295,283 @@ func (s *authApp) GetSessionByUUID(ctx context.Context, sessionUUID string) (ses
 	return session, nil
 }
 
-func (s *authApp) Logout(ctx context.Context, accessToken string) (err error) {
+func (s *authApp) RotateRefreshToken(ctx context.Context, input dto.RefreshTokenRotation) (err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("session_uuid", input.SessionUUID))
+
+	session, err := s.GetSessionByUUID(ctx, input.SessionUUID)
+	if err != nil {
+		return err
+	}
+
+	// the update only takes the same hash as well, but it is compared here
+	// first, in constant time, and the update is left to settle a race
+	active := !session.IsBlocked && time.Now().Before(session.RefreshTokenExpiredAt)
+	if active && tokenhash.Equal(session.RefreshTokenHash, input.RefreshTokenHash) {
+		rotated, err := s.dm.Auth().RotateSessionRefreshToken(ctx, input)
+		if err != nil {
+			s.log.Error(ctx, "error rotating refresh token", logger.Err(err))
+			return err
+		}
+
+		if rotated {
+			return nil
+		}
+	}
+
+	return s.refreshRefused(ctx, session, input)
+}
+
+// refreshRefused tells why the refresh token of input didn't rotate session,
+// and signs the session out when it was one rotated out already.
+func (s *authApp) refreshRefused(ctx context.Context, session dto.Session, input dto.RefreshTokenRotation) error {
+	reused, err := s.dm.Auth().IsRotatedRefreshToken(ctx, input.SessionUUID, input.RefreshTokenHash)
+	if err != nil {
+		s.log.Error(ctx, "error checking rotated refresh token", logger.Err(err))
+		return err
+	}
+
+	if reused {
+		return s.signOutReusedRefreshToken(ctx, session, input.ClientIP)
+	}
+
+	if session.IsBlocked {
+		return errcodes.ErrSessionBlocked
+	}
+
+	if time.Now().After(session.RefreshTokenExpiredAt) {
+		return errcodes.ErrSessionExpired
+	}
+
+	return errcodes.ErrSessionTokenMismatch
+}
+
+// signOutReusedRefreshToken handles a refresh token presented again after it
+// was rotated out. Either the owner or whoever stole it has the new one, and
+// there is no telling which, so the session is signed out for both.
+func (s *authApp) signOutReusedRefreshToken(ctx context.Context, session dto.Session, clientIP string) error {
+	s.log.Warn(ctx, "rotated out refresh token reused, signing the session out")
+
+	err := s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		return revokeSessions(ctx, tx, []string{session.SessionUUID})
+	})
+	if err != nil {
+		s.log.Error(ctx, "error revoking sessions", logger.Err(err))
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, []string{session.SessionUUID})
+
+	_, err = s.dm.Auth().AddSecurityEvent(ctx, entity.SecurityEvent{
+		Type:        entity.SecurityEventRefreshTokenReused,
+		AccountID:   session.AccountID,
+		ClientIP:    clientIP,
+		SessionUUID: session.SessionUUID,
+	})
+	if err != nil {
+		s.log.Error(ctx, "error adding security event", logger.Err(err))
+	}
+
+	return errcodes.ErrRefreshTokenReused
+}
+
+func (s *authApp) Logout(ctx context.Context) (err error) {
 	sessionUUID, ok := ctx.Value(infra.SessionKey).(string)
 	if !ok || sessionUUID == "" {
 		s.log.Error(ctx, "session UUID not found in context")
 		return errcodes.ErrSessionNotFound
 	}
 
-	// access token will be on cache for 3 minutes after it duration
-	// this is to avoid the user to login again with the same access token (used in the middleware)
-	err = s.cache.Set(ctx, accessToken, "true", s.accessTokenDuration+3*time.Minute)
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		err := revokeSessions(ctx, tx, []string{sessionUUID})
+		if err != nil {
+			s.log.Error(ctx, "error logging out", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
-		s.log.Error(ctx, "error logging out", logger.Err(err))
 		return err
 	}
 
-	err = s.dm.Auth().SetSessionAsBlocked(ctx, sessionUUID)
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, []string{sessionUUID})
+	return nil
+}
+
+// RequestPasswordReset only looks the account up before answering: the token
+// is written and sent in the background, so an account that exists takes no
+// longer to answer for than one that doesn't.
+func (s *authApp) RequestPasswordReset(ctx context.Context, input dto.PasswordResetInput) (err error) {
+	err = input.Validate(ctx, s.validator)
 	if err != nil {
-		s.log.Error(ctx, "error logging out", logger.Err(err))
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
 		return err
 	}
 
+	cpfIndex := s.crypto.BlindIndex(input.CPF)
+
+	// the login throttle counts unknown CPFs as well, so being refused here
+	// tells nothing about the account either
+	retryAfter := s.loginRetryAfter(ctx, s.loginScopes(cpfIndex, input.ClientIP))
+	if retryAfter > 0 {
+		s.log.Warn(ctx, "password reset refused while locked")
+		return errcodes.NewAccountLockedError(retryAfter)
+	}
+
+	account, err := s.dm.Account().GetAccountByDocument(ctx, cpfIndex, input.CPF)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return nil
+		}
+		s.log.Error(ctx, "error getting account by document", logger.Err(err))
+		return err
+	}
+
+	// a deactivated account can't log in even with a new password, and a
+	// closed one is gone for good
+	if !account.Active {
+		return nil
+	}
+
+	// the client doesn't wait for it, so neither does its context
+	ctx = logger.WithAttrs(context.WithoutCancel(ctx), logger.Attr("account_uuid", account.UUID))
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.sendPasswordReset(ctx, account)
+	}()
+
+	return nil
+}
+
+// sendPasswordReset replaces the reset tokens of the account with a new one
+// and sends it to the owner. Its failures would tell an existing account
+// from a missing one, so they are only logged; the owner can ask again.
+func (s *authApp) sendPasswordReset(ctx context.Context, account entity.Account) {
+	token := newOpaqueToken()
+	reset := entity.PasswordReset{
+		AccountID: account.ID,
+		TokenHash: tokenhash.Sum(token),
+		ExpiresAt: time.Now().Add(s.passwordResetDuration),
+	}
+
+	// only the newest token works, so a link sent earlier can't be used
+	// after the owner asked again
+	err := s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		err := tx.Auth().VoidPasswordResets(ctx, account.ID)
+		if err != nil {
+			s.log.Error(ctx, "error voiding password resets", logger.Err(err))
+			return err
+		}
+
+		_, err = tx.Auth().CreatePasswordReset(ctx, reset)
+		if err != nil {
+			s.log.Error(ctx, "error creating password reset", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	err = s.notifier.Notify(ctx, entity.Notification{
+		Kind:        entity.NotificationPasswordReset,
+		AccountUUID: account.UUID,
+		Name:        account.Name,
+		Data: map[string]string{
+			"token":      token,
+			"expires_at": reset.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		s.log.Error(ctx, "error sending password reset notification", logger.Err(err))
+	}
+}
+
+func (s *authApp) ConfirmPasswordReset(ctx context.Context, input dto.PasswordResetConfirmInput) (err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return err
+	}
+
+	hashedPassword, err := s.crypto.HashPassword(input.NewPassword)
+	if err != nil {
+		s.log.Error(ctx, "error hashing password", logger.Err(err))
+		return err
+	}
+
+	var sessionUUIDs []string
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		accountID, err := tx.Auth().ConsumePasswordReset(ctx, tokenhash.Sum(input.Token))
+		if err != nil {
+			if apperr.IsNotFound(err) {
+				return errcodes.ErrInvalidResetToken
+			}
+			s.log.Error(ctx, "error consuming password reset", logger.Err(err))
+			return err
+		}
+
+		ctx := logger.WithAttrs(ctx, logger.Attr("account_id", accountID))
+
+		err = tx.Auth().VoidPasswordResets(ctx, accountID)
+		if err != nil {
+			s.log.Error(ctx, "error voiding password resets", logger.Err(err))
+			return err
+		}
+
+		err = tx.Account().UpdateAccountPassword(ctx, accountID, hashedPassword)
+		if err != nil {
+			s.log.Error(ctx, "error updating account password", logger.Err(err))
+			return err
+		}
+
+		sessions, err := tx.Auth().GetActiveSessionsByAccountID(ctx, accountID)
+		if err != nil {
+			s.log.Error(ctx, "error getting active sessions", logger.Err(err))
+			return err
+		}
+
+		sessionUUIDs = make([]string, 0, len(sessions))
+		for _, session := range sessions {
+			sessionUUIDs = append(sessionUUIDs, session.SessionUUID)
+		}
+
+		err = revokeSessions(ctx, tx, sessionUUIDs)
+		if err != nil {
+			s.log.Error(ctx, "error revoking sessions", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	markSessionsRevoked(ctx, s.cache, s.log, s.accessTokenDuration, sessionUUIDs)
+	return nil
+}
+
+// revokeSessions blocks the sessions within tx, so they can't be refreshed.
+// The access tokens they already handed out are refused once
+// markSessionsRevoked runs, after tx commits.
+func revokeSessions(ctx context.Context, tx contract.Repos, sessionUUIDs []string) error {
+	for _, sessionUUID := range sessionUUIDs {
+		err := tx.Auth().SetSessionAsBlocked(ctx, sessionUUID)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -7,9 +7,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/infra/cache"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/util/tokenhash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -18,39 +24,77 @@ func Test_newAuthApp(t *testing.T) {
 	defer ctrl.Finish()
 
 	want := &authApp{cache: m.mockCacheManager,
-		crypto:              m.mockCrypto,
-		dm:                  m.mockDataManager,
-		log:                 m.mockLogger,
-		validator:           m.mockValidator,
-		accountSvc:          m.mockAccountSvc,
-		accessTokenDuration: time.Minute,
+		crypto:                m.mockCrypto,
+		dm:                    m.mockDataManager,
+		log:                   m.mockLogger,
+		notifier:              m.mockNotifier,
+		validator:             m.mockValidator,
+		accountSvc:            m.mockAccountSvc,
+		accessTokenDuration:   time.Minute,
+		passwordResetDuration: time.Hour,
+		loginThrottle:         testLoginThrottle,
+		mfaIssuer:             "test",
 	}
 
-	if got := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute); !reflect.DeepEqual(got, want) {
+	if got := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0); !reflect.DeepEqual(got, want) {
 		t.Errorf("newAuthService() = %v, want %v", got, want)
 	}
 }
 
+var testLoginThrottle = entity.LoginThrottle{
+	FreeAttempts:      3,
+	BaseDelay:         time.Second,
+	MaxDelay:          time.Minute,
+	LockoutAttempts:   10,
+	LockoutDuration:   15 * time.Minute,
+	Window:            time.Hour,
+	IPLockoutAttempts: 100,
+}
+
+// expectLoginNotLocked expects the locks of the cpf-index and of the ip to be
+// checked and found expired.
+func expectLoginNotLocked(ctx context.Context, mocks allMocks, clientIP string) {
+	mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:cpf:cpf-index").Return(time.Duration(-2), nil).Times(1)
+	mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:ip:"+clientIP).Return(time.Duration(-2), nil).Times(1)
+}
+
+// expectLoginFailure expects a failure to be counted against key, which then
+// has failures of them.
+func expectLoginFailure(mocks allMocks, key string, failures int64) {
+	gomock.InOrder(
+		mocks.mockCacheManager.EXPECT().Increase(gomock.Any(), key).Return(nil).Times(1),
+		mocks.mockCacheManager.EXPECT().SetExpiration(gomock.Any(), key, time.Hour).Return(nil).Times(1),
+		mocks.mockCacheManager.EXPECT().GetInt(gomock.Any(), key).Return(failures, nil).Times(1),
+	)
+}
+
 func Test_authService_Login(t *testing.T) {
 	type args struct {
 		cpf      string
 		password string
+		clientIP string
 	}
 	tests := []struct {
 		name      string
 		buildMock func(ctx context.Context, mocks allMocks, args args)
 		args      args
 		wantErr   bool
+		// wantRetryAfter is the retry after of the AccountLockedError, when
+		// the login is expected to be locked
+		wantRetryAfter time.Duration
 	}{
 		{
 			name: "Should login without any errors",
 			args: args{
 				cpf:      "01234567890",
 				password: "01234567890",
+				clientIP: "10.0.0.1",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
 				gomock.InOrder(
-					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.cpf).Return(entity.Account{
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
 						ID:       1,
 						UUID:     "uuid",
 						Name:     "name",
@@ -60,17 +104,118 @@ func Test_authService_Login(t *testing.T) {
 					}, nil).Times(1),
 
 					mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCacheManager.EXPECT().Delete(gomock.Any(), "login-failures:cpf:cpf-index").Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should login without an ip to count by",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:cpf:cpf-index").Return(time.Duration(-2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
+						ID:       1,
+						UUID:     "uuid",
+						Password: args.password,
+						Active:   true,
+					}, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCacheManager.EXPECT().Delete(gomock.Any(), "login-failures:cpf:cpf-index").Return(nil).Times(1),
 				)
 			},
 		},
+		{
+			name: "Should login when the cache fails to tell the locks",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, gomock.Any()).Return(time.Duration(0), errors.New("some error")).Times(2)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
+					ID:       1,
+					UUID:     "uuid",
+					Password: args.password,
+					Active:   true,
+				}, nil).Times(1)
+				mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, apperr.ErrRecordNotFound).Times(1)
+				mocks.mockCacheManager.EXPECT().Delete(gomock.Any(), "login-failures:cpf:cpf-index").Return(nil).Times(1)
+			},
+		},
+		{
+			name: "Should leave the failures of an account with mfa for its second step",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				confirmedAt := time.Now()
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
+						ID:       1,
+						UUID:     "uuid",
+						Password: args.password,
+						Active:   true,
+					}, nil).Times(1),
+					mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{AccountID: 1, ConfirmedAt: &confirmedAt}, nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return the account locked error while the cpf is locked",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:cpf:cpf-index").Return(10*time.Minute, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:ip:"+args.clientIP).Return(time.Duration(-2), nil).Times(1)
+			},
+			wantErr:        true,
+			wantRetryAfter: 10 * time.Minute,
+		},
+		{
+			name: "Should return the longest wait when both the cpf and the ip are locked",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:cpf:cpf-index").Return(2*time.Second, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:ip:"+args.clientIP).Return(5*time.Minute, nil).Times(1)
+			},
+			wantErr:        true,
+			wantRetryAfter: 5 * time.Minute,
+		},
 		{
 			name: "Should return error when the account is not active",
 			args: args{
 				cpf:      "01234567890",
 				password: "01234567890",
+				clientIP: "10.0.0.1",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.cpf).Return(entity.Account{
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
 					ID:       1,
 					UUID:     "uuid",
 					Name:     "name",
@@ -86,21 +231,44 @@ func Test_authService_Login(t *testing.T) {
 			args: args{
 				cpf:      "01234567890",
 				password: "01234567890",
+				clientIP: "10.0.0.1",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.cpf).
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).
 					Return(entity.Account{}, errors.New("some error")).Times(1)
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should count a failure when no account has the cpf",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).
+					Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+args.clientIP, 1)
+			},
+			wantErr: true,
+		},
 		{
 			name: "Should return error when the password is wrong",
 			args: args{
 				cpf:      "01234567890",
 				password: "01234567890",
+				clientIP: "10.0.0.1",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, args.cpf).Return(entity.Account{
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
 					ID:       1,
 					UUID:     "uuid",
 					Name:     "name",
@@ -110,6 +278,93 @@ func Test_authService_Login(t *testing.T) {
 				}, nil).Times(1)
 
 				mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(errors.New("some error")).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+args.clientIP, 1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should make the next login wait once the free attempts are gone",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
+					ID:       1,
+					UUID:     "uuid",
+					Password: args.password,
+					Active:   true,
+				}, nil).Times(1)
+
+				mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(errors.New("some error")).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 5)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "login-lock:cpf:cpf-index", "true", 2*time.Second).Return(nil).Times(1)
+				expectLoginFailure(mocks, "login-failures:ip:"+args.clientIP, 2)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should lock the cpf out and log it once it reaches the lockout attempts",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).Return(entity.Account{
+					ID:       1,
+					UUID:     "uuid",
+					Password: args.password,
+					Active:   true,
+				}, nil).Times(1)
+
+				mocks.mockCrypto.EXPECT().CheckPassword(args.password, args.password).Return(errors.New("some error")).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 10)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "login-lock:cpf:cpf-index", "true", 15*time.Minute).Return(nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().AddSecurityEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, event entity.SecurityEvent) (int64, error) {
+						assert.Equal(t, entity.SecurityEventLoginLockedCPF, event.Type)
+						assert.Equal(t, int64(1), event.AccountID)
+						assert.Equal(t, "cpf-index", event.CPFIndex)
+						assert.Equal(t, args.clientIP, event.ClientIP)
+						require.NotNil(t, event.LockedUntil)
+						assert.WithinDuration(t, time.Now().Add(15*time.Minute), *event.LockedUntil, time.Minute)
+						return 1, nil
+					}).Times(1)
+				expectLoginFailure(mocks, "login-failures:ip:"+args.clientIP, 10)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "login-lock:ip:"+args.clientIP, "true", time.Minute).Return(nil).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should lock the ip out without the cpf it tried last",
+			args: args{
+				cpf:      "01234567890",
+				password: "01234567890",
+				clientIP: "10.0.0.1",
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockCrypto.EXPECT().BlindIndex(args.cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, args.clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(ctx, "cpf-index", args.cpf).
+					Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+args.clientIP, 100)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "login-lock:ip:"+args.clientIP, "true", 15*time.Minute).Return(nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().AddSecurityEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, event entity.SecurityEvent) (int64, error) {
+						assert.Equal(t, entity.SecurityEventLoginLockedIP, event.Type)
+						assert.Zero(t, event.AccountID)
+						assert.Empty(t, event.CPFIndex)
+						assert.Equal(t, args.clientIP, event.ClientIP)
+						return 0, errors.New("some error")
+					}).Times(1)
 			},
 			wantErr: true,
 		},
@@ -130,11 +385,12 @@ func Test_authService_Login(t *testing.T) {
 				tt.buildMock(ctx, m, tt.args)
 			}
 
-			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute)
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
 
 			input := dto.LoginInput{
 				CPF:      tt.args.cpf,
 				Password: tt.args.password,
+				ClientIP: tt.args.clientIP,
 			}
 
 			_, err := s.Login(ctx, input)
@@ -142,6 +398,15 @@ func Test_authService_Login(t *testing.T) {
 				t.Errorf("authService.Login() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+
+			var lockedErr *errcodes.AccountLockedError
+			if tt.wantRetryAfter > 0 {
+				require.ErrorAs(t, err, &lockedErr)
+				assert.Equal(t, tt.wantRetryAfter, lockedErr.RetryAfter)
+				assert.ErrorIs(t, err, errcodes.ErrAccountLocked)
+				return
+			}
+			assert.False(t, errors.As(err, &lockedErr))
 		})
 	}
 }
@@ -150,35 +415,76 @@ func Test_authService_CreateSession(t *testing.T) {
 	type args struct {
 		session dto.Session
 	}
+
+	session := dto.Session{
+		AccountID:        1,
+		SessionUUID:      "d152a340-9a87-4d32-85ad-19df4c9934cd",
+		RefreshTokenHash: "token",
+	}
+	active := []dto.Session{{SessionUUID: "oldest"}, {SessionUUID: "older"}, {SessionUUID: session.SessionUUID}}
+
 	tests := []struct {
-		name      string
-		buildMock func(ctx context.Context, mocks allMocks, args args)
-		args      args
-		wantErr   bool
+		name        string
+		buildMock   func(ctx context.Context, mocks allMocks, args args)
+		args        args
+		maxSessions int64
+		wantErr     bool
 	}{
 		{
 			name: "Should create session without any errors",
-			args: args{
-				session: dto.Session{
-					AccountID:    1,
-					SessionUUID:  "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					RefreshToken: "token",
-				},
-			},
+			args: args{session: session},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				withTransaction(mocks)
 				mocks.mockAuthRepo.EXPECT().CreateSession(ctx, args.session).Return(int64(0), nil).Times(1)
 			},
 		},
 		{
-			name: "Should return error when there is some error to create session",
-			args: args{
-				session: dto.Session{
-					AccountID:    1,
-					SessionUUID:  "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					RefreshToken: "token",
-				},
+			name:        "Should sign out of the oldest sessions past the cap",
+			args:        args{session: session},
+			maxSessions: 1,
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().CreateSession(ctx, args.session).Return(int64(0), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).Return(active, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "oldest").Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "older").Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, infra.SessionStateKey("oldest"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, infra.SessionStateKey("older"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
 			},
+		},
+		{
+			name:        "Should not sign out of any session within the cap",
+			args:        args{session: session},
+			maxSessions: 3,
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().CreateSession(ctx, args.session).Return(int64(0), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).Return(active, nil).Times(1),
+				)
+			},
+		},
+		{
+			name:        "Should return error when the oldest sessions can't be signed out",
+			args:        args{session: session},
+			maxSessions: 2,
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().CreateSession(ctx, args.session).Return(int64(0), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).Return(active, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "oldest").Return(errors.New("some error")).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error when there is some error to create session",
+			args: args{session: session},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				withTransaction(mocks)
 				mocks.mockAuthRepo.EXPECT().CreateSession(ctx, args.session).Return(int64(0), errors.New("some error")).Times(1)
 			},
 			wantErr: true,
@@ -200,7 +506,7 @@ func Test_authService_CreateSession(t *testing.T) {
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
 			}
-			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute)
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", tt.maxSessions)
 			if err := s.CreateSession(ctx, tt.args.session); (err != nil) != tt.wantErr {
 				t.Errorf("authService.CreateSession() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -253,7 +559,7 @@ func Test_authService_GetSessionByUUID(t *testing.T) {
 			if tt.buildMock != nil {
 				tt.buildMock(ctx, m, tt.args)
 			}
-			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute)
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
 			gotSession, err := s.GetSessionByUUID(ctx, tt.args.sessionUUID)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("authService.GetSessionByUUID() error = %v, wantErr %v", err, tt.wantErr)
@@ -266,51 +572,302 @@ func Test_authService_GetSessionByUUID(t *testing.T) {
 	}
 }
 
-func Test_authService_Logout(t *testing.T) {
-	type args struct {
-		accessToken string
+func Test_authService_IsSessionActive(t *testing.T) {
+	const sessionUUID = "session-uuid"
+	key := infra.SessionStateKey(sessionUUID)
+
+	tests := []struct {
+		name       string
+		buildMock  func(ctx context.Context, mocks allMocks)
+		wantActive bool
+		wantErr    error
+	}{
+		{
+			name: "Should take an active state from the cache",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return(sessionStateActive, nil).Times(1)
+			},
+			wantActive: true,
+		},
+		{
+			name: "Should take a revoked state from the cache",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return(sessionStateRevoked, nil).Times(1)
+			},
+		},
+		{
+			name: "Should read an active session from the database on a miss and cache it",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{SessionUUID: sessionUUID}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().SetNX(ctx, key, sessionStateActive, sessionStateTTL).Return(true, nil).Times(1),
+				)
+			},
+			wantActive: true,
+		},
+		{
+			name: "Should leave alone a revoked state written since the session was read",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{SessionUUID: sessionUUID}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().SetNX(ctx, key, sessionStateActive, sessionStateTTL).Return(false, nil).Times(1),
+				)
+			},
+			wantActive: true,
+		},
+		{
+			name: "Should read a blocked session from the database on a miss and cache it",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{SessionUUID: sessionUUID, IsBlocked: true}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, key, sessionStateRevoked, sessionStateTTL).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should take a session that doesn't exist as revoked",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, key, sessionStateRevoked, sessionStateTTL).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should still answer when the state can't be cached",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{SessionUUID: sessionUUID}, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().SetNX(ctx, key, sessionStateActive, sessionStateTTL).Return(false, assert.AnError).Times(1),
+				)
+			},
+			wantActive: true,
+		},
+		{
+			name: "Should return error without going to the database when the cache fails",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name: "Should return error when the database fails",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCacheManager.EXPECT().GetString(ctx, key).Return("", cache.ErrCacheMiss).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(ctx, sessionUUID).Return(dto.Session{}, assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(ctx, m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+			active, err := s.IsSessionActive(ctx, sessionUUID)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantActive, active)
+		})
+	}
+}
+
+func Test_authService_RotateRefreshToken(t *testing.T) {
+	const sessionUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	rotation := dto.RefreshTokenRotation{
+		SessionUUID:              sessionUUID,
+		RefreshTokenHash:         tokenhash.Sum("old-token"),
+		NewRefreshTokenHash:      tokenhash.Sum("new-token"),
+		NewRefreshTokenExpiredAt: time.Now().Add(24 * time.Hour),
+		ClientIP:                 "10.0.0.1",
+	}
+	// the session as it is before the rotation, and after it
+	current := dto.Session{SessionUUID: sessionUUID, AccountID: 1, RefreshTokenHash: tokenhash.Sum("old-token"), RefreshTokenExpiredAt: time.Now().Add(time.Hour)}
+	rotated := current
+	rotated.RefreshTokenHash = tokenhash.Sum("new-token")
+
+	signOut := func(mocks allMocks) []any {
+		return []any{
+			withTransaction(mocks),
+			mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), sessionUUID).Return(nil).Times(1),
+			mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey(sessionUUID), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+			mocks.mockAuthRepo.EXPECT().AddSecurityEvent(gomock.Any(), entity.SecurityEvent{
+				Type:        entity.SecurityEventRefreshTokenReused,
+				AccountID:   1,
+				ClientIP:    "10.0.0.1",
+				SessionUUID: sessionUUID,
+			}).Return(int64(1), nil).Times(1),
+		}
+	}
+
 	tests := []struct {
 		name      string
-		buildMock func(ctx context.Context, mocks allMocks, args args)
-		args      args
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should rotate the refresh token",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(current, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().RotateSessionRefreshToken(gomock.Any(), rotation).Return(true, nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should sign the session out and record it when a rotated out token is reused",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(append([]any{
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(rotated, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().IsRotatedRefreshToken(gomock.Any(), sessionUUID, tokenhash.Sum("old-token")).Return(true, nil).Times(1),
+				}, signOut(mocks)...)...)
+			},
+			wantErr: errcodes.ErrRefreshTokenReused,
+		},
+		{
+			name: "Should take the token as reused when a request in between rotated it",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(append([]any{
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(current, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().RotateSessionRefreshToken(gomock.Any(), rotation).Return(false, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().IsRotatedRefreshToken(gomock.Any(), sessionUUID, tokenhash.Sum("old-token")).Return(true, nil).Times(1),
+				}, signOut(mocks)...)...)
+			},
+			wantErr: errcodes.ErrRefreshTokenReused,
+		},
+		{
+			name: "Should return the mismatch error when the token was never of the session",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(rotated, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().IsRotatedRefreshToken(gomock.Any(), sessionUUID, tokenhash.Sum("old-token")).Return(false, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrSessionTokenMismatch,
+		},
+		{
+			name: "Should return the blocked error when the session is blocked",
+			buildMock: func(mocks allMocks) {
+				blocked := current
+				blocked.IsBlocked = true
+				gomock.InOrder(
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(blocked, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().IsRotatedRefreshToken(gomock.Any(), sessionUUID, tokenhash.Sum("old-token")).Return(false, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrSessionBlocked,
+		},
+		{
+			name: "Should return the expired error when the session expired",
+			buildMock: func(mocks allMocks) {
+				expired := current
+				expired.RefreshTokenExpiredAt = time.Now().Add(-time.Minute)
+				gomock.InOrder(
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(expired, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().IsRotatedRefreshToken(gomock.Any(), sessionUUID, tokenhash.Sum("old-token")).Return(false, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrSessionExpired,
+		},
+		{
+			name: "Should return error when the session doesn't exist",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(dto.Session{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrSessionNotFound,
+		},
+		{
+			name: "Should return error when the rotation fails",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(current, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().RotateSessionRefreshToken(gomock.Any(), rotation).Return(false, assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			err := s.RotateRefreshToken(context.Background(), rotation)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_authService_Logout(t *testing.T) {
+	tests := []struct {
+		name      string
+		buildMock func(ctx context.Context, mocks allMocks)
 		noSession bool
 		wantErr   bool
 	}{
 		{
 			name: "Should logout without any errors",
-			args: args{
-				accessToken: "token",
-			},
-			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockCacheManager.EXPECT().Set(ctx, args.accessToken, "true", gomock.Any()).Return(nil).Times(1)
-				mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(nil).Times(1)
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, infra.SessionStateKey("session-uuid"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
 			},
 		},
 		{
 			name:      "Should return error when session UUID is not in context",
-			args:      args{accessToken: "token"},
 			noSession: true,
 			wantErr:   true,
 		},
 		{
-			name: "Should return error when there is some error to set string with expiration",
-			args: args{
-				accessToken: "token",
-			},
-			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockCacheManager.EXPECT().Set(ctx, args.accessToken, "true", gomock.Any()).Return(errors.New("some error")).Times(1)
+			name: "Should return error when there is some error to set blocked session",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(errors.New("some error")).Times(1),
+				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error when there is some error to set blocked session",
-			args: args{
-				accessToken: "token",
+			name: "Should still log out when the session can't be marked as revoked",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(ctx, infra.SessionStateKey("session-uuid"), sessionStateRevoked, gomock.Any()).Return(errors.New("some error")).Times(1),
+				)
 			},
-			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockCacheManager.EXPECT().Set(ctx, args.accessToken, "true", gomock.Any()).Return(nil).Times(1)
-				mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(errors.New("some error")).Times(1)
+		},
+		{
+			name: "Should not mark the session as revoked when the transaction doesn't commit",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				gomock.InOrder(
+					withFailedCommit(mocks, assert.AnError),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "session-uuid").Return(nil).Times(1),
+				)
 			},
 			wantErr: true,
 		},
@@ -327,12 +884,370 @@ func Test_authService_Logout(t *testing.T) {
 			defer ctrl.Finish()
 
 			if tt.buildMock != nil {
-				tt.buildMock(ctx, m, tt.args)
+				tt.buildMock(ctx, m)
 			}
-			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute)
-			if err := s.Logout(ctx, tt.args.accessToken); (err != nil) != tt.wantErr {
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+			if err := s.Logout(ctx); (err != nil) != tt.wantErr {
 				t.Errorf("authService.Logout() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_authService_RequestPasswordReset(t *testing.T) {
+	const (
+		cpf      = "01234567890"
+		clientIP = "10.0.0.1"
+	)
+
+	account := entity.Account{ID: 12, UUID: "d152a340-9a87-4d32-85ad-19df4c9934cd", Name: "John Doe", Active: true}
+
+	tests := []struct {
+		name       string
+		input      dto.PasswordResetInput
+		buildMock  func(ctx context.Context, mocks allMocks)
+		wantErr    error
+		wantLocked bool
+	}{
+		{
+			name:  "Should store the hash of the token and send the token",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				var tokenHash string
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(account, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().VoidPasswordResets(gomock.Any(), int64(12)).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ context.Context, reset entity.PasswordReset) (int64, error) {
+							require.Equal(t, int64(12), reset.AccountID)
+							require.WithinDuration(t, time.Now().Add(time.Hour), reset.ExpiresAt, time.Minute)
+							tokenHash = reset.TokenHash
+							return 1, nil
+						}).Times(1),
+					mocks.mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ context.Context, notification entity.Notification) error {
+							require.Equal(t, entity.NotificationPasswordReset, notification.Kind)
+							require.Equal(t, account.UUID, notification.AccountUUID)
+							require.NotEqual(t, tokenHash, notification.Data["token"])
+							require.Equal(t, tokenHash, tokenhash.Sum(notification.Data["token"]))
+							return nil
+						}).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should not tell that the account doesn't exist",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1)
+			},
+		},
+		{
+			name:  "Should not send a token to a deactivated account",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(entity.Account{ID: 12}, nil).Times(1)
+			},
+		},
+		{
+			name:  "Should not tell that the notification failed",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(account, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().VoidPasswordResets(gomock.Any(), int64(12)).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should not tell that the reset couldn't be stored",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				gomock.InOrder(
+					mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(account, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().VoidPasswordResets(gomock.Any(), int64(12)).Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Return(int64(0), assert.AnError).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the account can't be looked up",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				expectLoginNotLocked(ctx, mocks, clientIP)
+				mocks.mockAccountRepo.EXPECT().GetAccountByDocument(gomock.Any(), "cpf-index", cpf).Return(entity.Account{}, assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:  "Should refuse while the login of the cpf is locked",
+			input: dto.PasswordResetInput{CPF: cpf, ClientIP: clientIP},
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockCrypto.EXPECT().BlindIndex(cpf).Return("cpf-index").Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:cpf:cpf-index").Return(10*time.Minute, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(ctx, "login-lock:ip:"+clientIP).Return(time.Duration(-2), nil).Times(1)
+			},
+			wantLocked: true,
+		},
+		{
+			name:    "Should return error with an invalid cpf",
+			input:   dto.PasswordResetInput{CPF: "123"},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(ctx, m)
+			}
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			err := s.RequestPasswordReset(ctx, tt.input)
+			s.background.Wait()
+
+			if tt.wantLocked {
+				var lockedErr *errcodes.AccountLockedError
+				require.ErrorAs(t, err, &lockedErr)
+				require.Equal(t, 10*time.Minute, lockedErr.RetryAfter)
+				return
+			}
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_authService_ConfirmPasswordReset(t *testing.T) {
+	const token = "reset-token"
+
+	validInput := dto.PasswordResetConfirmInput{Token: token, NewPassword: "87654321"}
+	sessions := []dto.Session{{SessionUUID: "session-1"}, {SessionUUID: "session-2"}}
+
+	tests := []struct {
+		name      string
+		input     dto.PasswordResetConfirmInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should set the new password and revoke every session",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().ConsumePasswordReset(gomock.Any(), tokenhash.Sum(token)).Return(int64(12), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().VoidPasswordResets(gomock.Any(), int64(12)).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountPassword(gomock.Any(), int64(12), "hashed-new").Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(gomock.Any(), int64(12)).Return(sessions, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), "session-1").Return(nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), "session-2").Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-1"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey("session-2"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the token is spent, expired or unknown",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().ConsumePasswordReset(gomock.Any(), tokenhash.Sum(token)).Return(int64(0), apperr.ErrRecordNotFound).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrInvalidResetToken,
+		},
+		{
+			name:  "Should return error if the password can't be updated",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockCrypto.EXPECT().HashPassword("87654321").Return("hashed-new", nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().ConsumePasswordReset(gomock.Any(), tokenhash.Sum(token)).Return(int64(12), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().VoidPasswordResets(gomock.Any(), int64(12)).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateAccountPassword(gomock.Any(), int64(12), "hashed-new").Return(assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "Should return error if the new password is too short",
+			input:   dto.PasswordResetConfirmInput{Token: token, NewPassword: "1234567"},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			err := s.ConfirmPasswordReset(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_authService_ListSessions(t *testing.T) {
+	ctx := context.WithValue(context.Background(), infra.SessionKey, "current")
+
+	t.Run("Should list the active sessions with the current one marked", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		m.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).Return(int64(1), nil).Times(1)
+		m.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).
+			Return([]dto.Session{{SessionUUID: "other"}, {SessionUUID: "current"}}, nil).Times(1)
+
+		s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+		sessions, err := s.ListSessions(ctx)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+	})
+
+	t.Run("Should return error when the sessions can't be read", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		m.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).Return(int64(1), nil).Times(1)
+		m.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).Return(nil, assert.AnError).Times(1)
+
+		s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+		_, err := s.ListSessions(ctx)
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func Test_authService_RevokeSession(t *testing.T) {
+	const sessionUUID = "session-1"
+
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should sign out of the session",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(dto.Session{SessionUUID: sessionUUID, AccountID: 1}, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().SetSessionAsBlocked(gomock.Any(), sessionUUID).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), infra.SessionStateKey(sessionUUID), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error when the session is of another account",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(dto.Session{SessionUUID: sessionUUID, AccountID: 2}, nil).Times(1)
+			},
+			wantErr: errcodes.ErrUnknownSession,
+		},
+		{
+			name: "Should return error when the session doesn't exist",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetSessionByUUID(gomock.Any(), sessionUUID).Return(dto.Session{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrUnknownSession,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			err := s.RevokeSession(context.Background(), sessionUUID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_authService_RevokeOtherSessions(t *testing.T) {
+	t.Run("Should sign out of every session but the current one", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), infra.SessionKey, "current")
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		gomock.InOrder(
+			m.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).Return(int64(1), nil).Times(1),
+			withTransaction(m),
+			m.mockAuthRepo.EXPECT().GetActiveSessionsByAccountID(ctx, int64(1)).
+				Return([]dto.Session{{SessionUUID: "other"}, {SessionUUID: "current"}}, nil).Times(1),
+			m.mockAuthRepo.EXPECT().SetSessionAsBlocked(ctx, "other").Return(nil).Times(1),
+			m.mockCacheManager.EXPECT().Set(ctx, infra.SessionStateKey("other"), sessionStateRevoked, time.Minute+accessTokenGrace).Return(nil).Times(1),
+		)
+
+		s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+		require.NoError(t, s.RevokeOtherSessions(ctx))
+	})
+
+	t.Run("Should return error without a current session", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+		require.ErrorIs(t, s.RevokeOtherSessions(context.Background()), errcodes.ErrSessionNotFound)
+	})
+}
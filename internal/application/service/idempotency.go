@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/logger"
+	"github.com/diegoclair/appvalidator/apperrmap"
+)
+
+// idempotencyKeyTTL is how long a key keeps replaying its response. A client
+// retrying after that is making a new request.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyLease is how long a claim holds the key before a retry may take
+// it over. It outlasts any request, so only a claim whose request died without
+// settling it is ever taken over.
+const idempotencyLease = time.Minute
+
+type idempotencyService struct {
+	cache     contract.CacheManager
+	dm        contract.DataManager
+	log       logger.Logger
+	validator apperrmap.Validator
+}
+
+func newIdempotencyService(infra domain.Infrastructure) *idempotencyService {
+	return &idempotencyService{
+		cache:     infra.CacheManager(),
+		dm:        infra.DataManager(),
+		log:       infra.Logger(),
+		validator: infra.Validator(),
+	}
+}
+
+// Begin returns the stored request with replay set when the key already has a
+// response for this same request. Otherwise the key is now claimed by the
+// caller and the returned request is the claim to settle.
+func (s *idempotencyService) Begin(ctx context.Context, input dto.IdempotencyInput) (request entity.IdempotentRequest, replay bool, err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return request, false, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("idempotency_key", input.Key))
+
+	// only completed requests are cached, so a hit can be answered right away
+	err = s.cache.GetStruct(ctx, idempotencyCacheKey(input.Scope, input.Key), &request)
+	if err == nil {
+		return checkStoredRequest(request, input.Fingerprint)
+	}
+
+	now := time.Now()
+	request = entity.IdempotentRequest{
+		Scope:         input.Scope,
+		Key:           input.Key,
+		Fingerprint:   input.Fingerprint,
+		State:         entity.IdempotencyInFlight,
+		ExpiresAt:     now.Add(idempotencyKeyTTL),
+		InFlightUntil: now.Add(idempotencyLease),
+	}
+
+	claimed, err := s.dm.Idempotency().ClaimIdempotencyKey(ctx, request)
+	if err != nil {
+		s.log.Error(ctx, "error to claim idempotency key", logger.Err(err))
+		return request, false, err
+	}
+
+	if claimed {
+		return request, false, nil
+	}
+
+	stored, err := s.dm.Idempotency().GetIdempotentRequest(ctx, input.Scope, input.Key)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			// released or expired since the claim failed: the request holding
+			// it has just settled, so the client can simply try again
+			return request, false, errcodes.ErrIdempotencyKeyInFlight
+		}
+		s.log.Error(ctx, "error to get idempotent request", logger.Err(err))
+		return request, false, err
+	}
+
+	if stored.IsCompleted() {
+		s.cacheCompleted(ctx, stored)
+	}
+
+	return checkStoredRequest(stored, input.Fingerprint)
+}
+
+// Complete stores the response the claimed request got, so every retry under
+// the same key replays it.
+func (s *idempotencyService) Complete(ctx context.Context, request entity.IdempotentRequest) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("idempotency_key", request.Key))
+
+	request.State = entity.IdempotencyCompleted
+
+	err = s.dm.Idempotency().CompleteIdempotentRequest(ctx, request)
+	if err != nil {
+		s.log.Error(ctx, "error to complete idempotent request", logger.Err(err))
+		return err
+	}
+
+	s.cacheCompleted(ctx, request)
+
+	return nil
+}
+
+// Release gives the key up without a response, for a request that failed
+// before doing anything a retry could repeat.
+func (s *idempotencyService) Release(ctx context.Context, scope, key string) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("idempotency_key", key))
+
+	err = s.dm.Idempotency().ReleaseIdempotencyKey(ctx, scope, key)
+	if err != nil {
+		s.log.Error(ctx, "error to release idempotency key", logger.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// cacheCompleted is best effort: postgres already has the response, the cache
+// only saves the round trip on a retry.
+func (s *idempotencyService) cacheCompleted(ctx context.Context, request entity.IdempotentRequest) {
+	ttl := time.Until(request.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	err := s.cache.Set(ctx, idempotencyCacheKey(request.Scope, request.Key), request, ttl)
+	if err != nil {
+		s.log.Error(ctx, "error to cache idempotent request", logger.Err(err))
+	}
+}
+
+func checkStoredRequest(stored entity.IdempotentRequest, fingerprint string) (request entity.IdempotentRequest, replay bool, err error) {
+	if !stored.MatchesRequest(fingerprint) {
+		return stored, false, errcodes.ErrIdempotencyKeyReused
+	}
+
+	if !stored.IsCompleted() {
+		return stored, false, errcodes.ErrIdempotencyKeyInFlight
+	}
+
+	return stored, true, nil
+}
+
+func idempotencyCacheKey(scope, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", scope, key)
+}
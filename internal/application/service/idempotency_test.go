@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_newIdempotencyService(t *testing.T) {
+	m, ctrl := newServiceTestMock(t)
+	defer ctrl.Finish()
+
+	want := &idempotencyService{
+		cache:     m.mockCacheManager,
+		dm:        m.mockDataManager,
+		log:       m.mockLogger,
+		validator: m.mockValidator,
+	}
+
+	if got := newIdempotencyService(m.mockDomain); !reflect.DeepEqual(got, want) {
+		t.Errorf("newIdempotencyService() = %v, want %v", got, want)
+	}
+}
+
+func Test_idempotencyService_Begin(t *testing.T) {
+	fingerprint := strings.Repeat("a", 64)
+	otherFingerprint := strings.Repeat("b", 64)
+
+	input := dto.IdempotencyInput{
+		Scope:       "account:uuid",
+		Key:         "key",
+		Fingerprint: fingerprint,
+	}
+
+	completed := entity.IdempotentRequest{
+		Scope:          input.Scope,
+		Key:            input.Key,
+		Fingerprint:    fingerprint,
+		State:          entity.IdempotencyCompleted,
+		ResponseStatus: http.StatusCreated,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	someErr := errors.New("some error")
+
+	cacheMiss := func(mocks allMocks) {
+		mocks.mockCacheManager.EXPECT().GetStruct(gomock.Any(), "idempotency:account:uuid:key", gomock.Any()).
+			Return(errors.New("cache miss")).Times(1)
+	}
+
+	tests := []struct {
+		name       string
+		input      dto.IdempotencyInput
+		buildMock  func(mocks allMocks)
+		wantReplay bool
+		wantErr    error
+	}{
+		{
+			name:  "Should claim the key when it was never used",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Cond(func(r entity.IdempotentRequest) bool {
+					return r.Scope == input.Scope && r.Key == input.Key && r.Fingerprint == fingerprint &&
+						r.State == entity.IdempotencyInFlight && r.ExpiresAt.After(time.Now()) &&
+						r.InFlightUntil.After(time.Now()) && r.InFlightUntil.Before(r.ExpiresAt)
+				})).Return(true, nil).Times(1)
+			},
+		},
+		{
+			name:  "Should replay from the cache without touching the database",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				mocks.mockCacheManager.EXPECT().GetStruct(gomock.Any(), "idempotency:account:uuid:key", gomock.Any()).
+					SetArg(2, completed).Return(nil).Times(1)
+			},
+			wantReplay: true,
+		},
+		{
+			name:  "Should replay a completed request found in the database and cache it",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				gomock.InOrder(
+					mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).Times(1),
+					mocks.mockIdempotencyRepo.EXPECT().GetIdempotentRequest(gomock.Any(), input.Scope, input.Key).Return(completed, nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "idempotency:account:uuid:key", completed, gomock.Any()).Return(nil).Times(1),
+				)
+			},
+			wantReplay: true,
+		},
+		{
+			name:  "Should return in flight when the key is held by a running request",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				inFlight := completed
+				inFlight.State = entity.IdempotencyInFlight
+				mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+				mocks.mockIdempotencyRepo.EXPECT().GetIdempotentRequest(gomock.Any(), input.Scope, input.Key).Return(inFlight, nil).Times(1)
+			},
+			wantErr: errcodes.ErrIdempotencyKeyInFlight,
+		},
+		{
+			name:  "Should return in flight when the key was released after the claim failed",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+				mocks.mockIdempotencyRepo.EXPECT().GetIdempotentRequest(gomock.Any(), input.Scope, input.Key).Return(entity.IdempotentRequest{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrIdempotencyKeyInFlight,
+		},
+		{
+			name: "Should return reused when the cached request has another body",
+			input: dto.IdempotencyInput{
+				Scope:       input.Scope,
+				Key:         input.Key,
+				Fingerprint: otherFingerprint,
+			},
+			buildMock: func(mocks allMocks) {
+				mocks.mockCacheManager.EXPECT().GetStruct(gomock.Any(), "idempotency:account:uuid:key", gomock.Any()).
+					SetArg(2, completed).Return(nil).Times(1)
+			},
+			wantErr: errcodes.ErrIdempotencyKeyReused,
+		},
+		{
+			name: "Should return reused when the request in flight has another body",
+			input: dto.IdempotencyInput{
+				Scope:       input.Scope,
+				Key:         input.Key,
+				Fingerprint: otherFingerprint,
+			},
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				inFlight := completed
+				inFlight.State = entity.IdempotencyInFlight
+				mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+				mocks.mockIdempotencyRepo.EXPECT().GetIdempotentRequest(gomock.Any(), input.Scope, input.Key).Return(inFlight, nil).Times(1)
+			},
+			wantErr: errcodes.ErrIdempotencyKeyReused,
+		},
+		{
+			name:  "Should return error when the claim fails",
+			input: input,
+			buildMock: func(mocks allMocks) {
+				cacheMiss(mocks)
+				mocks.mockIdempotencyRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, someErr).Times(1)
+			},
+			wantErr: someErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newIdempotencyService(m.mockDomain)
+
+			_, replay, err := s.Begin(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReplay, replay)
+		})
+	}
+
+	t.Run("Should return error when the key is too long", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		s := newIdempotencyService(m.mockDomain)
+
+		input.Key = strings.Repeat("k", 256)
+		_, _, err := s.Begin(context.Background(), input)
+		require.Error(t, err)
+	})
+}
+
+func Test_idempotencyService_Complete(t *testing.T) {
+	request := entity.IdempotentRequest{
+		Scope:          "account:uuid",
+		Key:            "key",
+		Fingerprint:    strings.Repeat("a", 64),
+		State:          entity.IdempotencyInFlight,
+		ResponseStatus: http.StatusCreated,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	completed := request
+	completed.State = entity.IdempotencyCompleted
+
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantErr   bool
+	}{
+		{
+			name: "Should store the response and cache it",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockIdempotencyRepo.EXPECT().CompleteIdempotentRequest(gomock.Any(), completed).Return(nil).Times(1),
+					mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "idempotency:account:uuid:key", completed, gomock.Any()).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should not fail when only the cache fails",
+			buildMock: func(mocks allMocks) {
+				mocks.mockIdempotencyRepo.EXPECT().CompleteIdempotentRequest(gomock.Any(), completed).Return(nil).Times(1)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("some error")).Times(1)
+			},
+		},
+		{
+			name: "Should return error and skip the cache when the database fails",
+			buildMock: func(mocks allMocks) {
+				mocks.mockIdempotencyRepo.EXPECT().CompleteIdempotentRequest(gomock.Any(), completed).Return(errors.New("some error")).Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newIdempotencyService(m.mockDomain)
+
+			err := s.Complete(ctx, request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("idempotencyService.Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_idempotencyService_Release(t *testing.T) {
+	m, ctrl := newServiceTestMock(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	s := newIdempotencyService(m.mockDomain)
+
+	m.mockIdempotencyRepo.EXPECT().ReleaseIdempotencyKey(gomock.Any(), "account:uuid", "key").Return(nil).Times(1)
+	require.NoError(t, s.Release(ctx, "account:uuid", "key"))
+
+	m.mockIdempotencyRepo.EXPECT().ReleaseIdempotencyKey(gomock.Any(), "account:uuid", "key").Return(errors.New("some error")).Times(1)
+	require.Error(t, s.Release(ctx, "account:uuid", "key"))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/logger"
+)
+
+// loginScope is what the failed logins are counted by: the CPF tried, or the
+// ip they come from. The CPF goes by its blind index, so the cache never
+// holds one.
+type loginScope struct {
+	name            string
+	id              string
+	lockoutAttempts int64
+	lockoutEvent    entity.SecurityEventType
+}
+
+func loginFailuresKey(scope loginScope) string {
+	return "login-failures:" + scope.name + ":" + scope.id
+}
+
+// loginLockKey is there while the login of the scope has to wait, and
+// expires when it can be tried again.
+func loginLockKey(scope loginScope) string {
+	return "login-lock:" + scope.name + ":" + scope.id
+}
+
+// loginScopes are the scopes of a login. cpfIndex is the blind index of the
+// CPF even for an account stored before it had one, see accountCPFIndex, so
+// both steps of a login are counted against the same cpf scope.
+func (s *authApp) loginScopes(cpfIndex, clientIP string) []loginScope {
+	var scopes []loginScope
+
+	if cpfIndex != "" {
+		scopes = append(scopes, loginScope{
+			name:            "cpf",
+			id:              cpfIndex,
+			lockoutAttempts: s.loginThrottle.LockoutAttempts,
+			lockoutEvent:    entity.SecurityEventLoginLockedCPF,
+		})
+	}
+
+	if clientIP != "" {
+		scopes = append(scopes, loginScope{
+			name:            "ip",
+			id:              clientIP,
+			lockoutAttempts: s.loginThrottle.IPLockoutAttempts,
+			lockoutEvent:    entity.SecurityEventLoginLockedIP,
+		})
+	}
+
+	return scopes
+}
+
+// accountCPFIndex is the blind index of the CPF of account. A row stored
+// before the CPF had one still holds the CPF in plain text, which decrypts to
+// itself, so its index is worked out the same way the login's is.
+func (s *authApp) accountCPFIndex(ctx context.Context, account entity.Account) (cpfIndex string, err error) {
+	if account.CPFIndex != "" {
+		return account.CPFIndex, nil
+	}
+
+	cpf, err := s.crypto.DecryptField(account.CPF)
+	if err != nil {
+		s.log.Error(ctx, "error to decrypt cpf", logger.Err(err))
+		return cpfIndex, err
+	}
+
+	return s.crypto.BlindIndex(cpf), nil
+}
+
+// loginRetryAfter is how long until a login of the scopes can be tried
+// again, zero when it can be now. The throttle is kept in the cache only, so
+// a cache that fails lets the login through rather than taking it down.
+func (s *authApp) loginRetryAfter(ctx context.Context, scopes []loginScope) (retryAfter time.Duration) {
+	for _, scope := range scopes {
+		ttl, err := s.cache.GetExpiration(ctx, loginLockKey(scope))
+		if err != nil {
+			s.log.Error(ctx, "error getting login lock", logger.Err(err))
+			continue
+		}
+
+		retryAfter = max(retryAfter, ttl)
+	}
+
+	return retryAfter
+}
+
+// recordLoginFailure counts a failed login against each of the scopes, and
+// makes the next one wait when a scope is past its free attempts. A lockout
+// goes to the security log as well. accountID is zero when no account has
+// the CPF.
+func (s *authApp) recordLoginFailure(ctx context.Context, scopes []loginScope, accountID int64) {
+	for _, scope := range scopes {
+		failures, err := s.countLoginFailure(ctx, scope)
+		if err != nil {
+			s.log.Error(ctx, "error counting login failure", logger.Err(err))
+			continue
+		}
+
+		delay, lockout := s.loginThrottle.Delay(failures, scope.lockoutAttempts)
+		if delay <= 0 {
+			continue
+		}
+
+		err = s.cache.Set(ctx, loginLockKey(scope), "true", delay)
+		if err != nil {
+			s.log.Error(ctx, "error setting login lock", logger.Err(err))
+			continue
+		}
+
+		if lockout {
+			s.addLoginLockoutEvent(ctx, scopes, scope, accountID, delay)
+		}
+	}
+}
+
+func (s *authApp) countLoginFailure(ctx context.Context, scope loginScope) (failures int64, err error) {
+	key := loginFailuresKey(scope)
+
+	err = s.cache.Increase(ctx, key)
+	if err != nil {
+		return failures, err
+	}
+
+	// every failure pushes the window back, so the count only goes away
+	// after a window without any
+	err = s.cache.SetExpiration(ctx, key, s.loginThrottle.Window)
+	if err != nil {
+		return failures, err
+	}
+
+	return s.cache.GetInt(ctx, key)
+}
+
+func (s *authApp) addLoginLockoutEvent(ctx context.Context, scopes []loginScope, locked loginScope, accountID int64, lockout time.Duration) {
+	lockedUntil := time.Now().Add(lockout)
+	event := entity.SecurityEvent{
+		Type:        locked.lockoutEvent,
+		LockedUntil: &lockedUntil,
+	}
+
+	for _, scope := range scopes {
+		switch scope.name {
+		case "ip":
+			event.ClientIP = scope.id
+		case "cpf":
+			// an ip is locked out over many CPFs, none of them its own
+			if locked.name == "cpf" {
+				event.CPFIndex = scope.id
+				event.AccountID = accountID
+			}
+		}
+	}
+
+	s.log.Warn(ctx, "login locked out after too many failures", logger.Attr("scope", locked.name))
+
+	_, err := s.dm.Auth().AddSecurityEvent(ctx, event)
+	if err != nil {
+		s.log.Error(ctx, "error adding security event", logger.Err(err))
+	}
+}
+
+// clearLoginFailures forgets the failures of the CPF once the login is
+// through. Those of the ip are left to expire: anyone could reset them by
+// logging in to an account of their own in between guesses.
+func (s *authApp) clearLoginFailures(ctx context.Context, scopes []loginScope) {
+	for _, scope := range scopes {
+		if scope.name != "cpf" {
+			continue
+		}
+
+		err := s.cache.Delete(ctx, loginFailuresKey(scope))
+		if err != nil {
+			s.log.Error(ctx, "error clearing login failures", logger.Err(err))
+		}
+	}
+}
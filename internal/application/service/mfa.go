@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/util/totp"
+	"github.com/diegoclair/logger"
+)
+
+const (
+	// mfaSkew is how many steps off a code is still taken, for a phone whose
+	// clock is a little off
+	mfaSkew = 1
+
+	// mfaRecoveryCodes is how many recovery codes an enrollment hands out
+	mfaRecoveryCodes = 10
+	// mfaRecoveryCodeSize is the length of a recovery code, without the dash
+	// it is shown with
+	mfaRecoveryCodeSize = 10
+
+	// mfaTokenAttempts is how many codes the token of the first step of a
+	// login takes. The code that is accepted spends what is left of it.
+	mfaTokenAttempts = 5
+)
+
+// mfaTokenAttemptsKey counts the codes given with the token of id.
+func mfaTokenAttemptsKey(tokenID string) string {
+	return "mfa-token-attempts:" + tokenID
+}
+
+func (s *authApp) IsMFAEnabled(ctx context.Context, accountID int64) (enabled bool, err error) {
+	mfa, err := s.dm.Auth().GetAccountMFA(ctx, accountID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return false, nil
+		}
+		s.log.Error(ctx, "error getting account mfa", logger.Err(err))
+		return false, err
+	}
+
+	return mfa.Enabled(), nil
+}
+
+func (s *authApp) EnrollMFA(ctx context.Context) (enrollment dto.MFAEnrollment, err error) {
+	account, err := s.accountSvc.GetLoggedAccount(ctx)
+	if err != nil {
+		return enrollment, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_id", account.ID))
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.log.Error(ctx, "error generating mfa secret", logger.Err(err))
+		return enrollment, err
+	}
+
+	encryptedSecret, err := s.crypto.EncryptField(secret)
+	if err != nil {
+		s.log.Error(ctx, "error encrypting mfa secret", logger.Err(err))
+		return enrollment, err
+	}
+
+	set, err := s.dm.Auth().SetPendingAccountMFA(ctx, account.ID, encryptedSecret)
+	if err != nil {
+		s.log.Error(ctx, "error setting pending account mfa", logger.Err(err))
+		return enrollment, err
+	}
+
+	if !set {
+		return enrollment, errcodes.ErrMFAAlreadyEnabled
+	}
+
+	return dto.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.mfaIssuer, account.Name, secret),
+	}, nil
+}
+
+func (s *authApp) ConfirmMFA(ctx context.Context, input dto.MFACodeInput) (recoveryCodes []string, err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return nil, err
+	}
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_id", accountID))
+
+	mfa, err := s.getAccountMFA(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mfa.Enabled() {
+		return nil, errcodes.ErrMFAAlreadyEnabled
+	}
+
+	step, ok, err := totp.Validate(mfa.Secret, input.Code, time.Now(), mfaSkew)
+	if err != nil {
+		s.log.Error(ctx, "error validating mfa code", logger.Err(err))
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errcodes.ErrWrongMFACode
+	}
+
+	recoveryCodes = make([]string, 0, mfaRecoveryCodes)
+	codeHashes := make([]string, 0, mfaRecoveryCodes)
+	for range mfaRecoveryCodes {
+		code := newRecoveryCode()
+		recoveryCodes = append(recoveryCodes, code)
+		codeHashes = append(codeHashes, s.crypto.BlindIndex(normalizeRecoveryCode(code)))
+	}
+
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		confirmed, err := tx.Auth().ConfirmAccountMFA(ctx, accountID, step)
+		if err != nil {
+			s.log.Error(ctx, "error confirming account mfa", logger.Err(err))
+			return err
+		}
+
+		// it was confirmed in between, by another request with a code
+		if !confirmed {
+			return errcodes.ErrMFAAlreadyEnabled
+		}
+
+		err = tx.Auth().AddMFARecoveryCodes(ctx, accountID, codeHashes)
+		if err != nil {
+			s.log.Error(ctx, "error adding mfa recovery codes", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// LoginMFA is the second step of the login of an account with MFA, which
+// already passed the first one. Its wrong codes count as failed logins of the
+// account and of the ip, the same as wrong passwords do.
+func (s *authApp) LoginMFA(ctx context.Context, input dto.LoginMFAInput) (account entity.Account, err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return account, err
+	}
+
+	account, err = s.dm.Account().GetAccountByUUID(ctx, input.AccountUUID)
+	if err != nil {
+		s.log.Error(ctx, "error getting account by uuid", logger.Err(err))
+		return account, errcodes.ErrInvalidCredentials
+	}
+
+	ctx = context.WithValue(ctx, infra.AccountUUIDKey, account.UUID)
+	ctx = logger.WithAttrs(ctx, logger.Attr("account_id", account.ID))
+
+	cpfIndex, err := s.accountCPFIndex(ctx, account)
+	if err != nil {
+		return account, err
+	}
+
+	scopes := s.loginScopes(cpfIndex, input.ClientIP)
+
+	retryAfter := s.loginRetryAfter(ctx, scopes)
+	if retryAfter > 0 {
+		s.log.Warn(ctx, "login refused while locked")
+		return account, errcodes.NewAccountLockedError(retryAfter)
+	}
+
+	if !account.Active {
+		s.log.Error(ctx, "account is not active")
+		return account, errcodes.ErrDeactivatedAccount
+	}
+
+	mfa, err := s.getAccountMFA(ctx, account.ID)
+	if err != nil {
+		return account, err
+	}
+
+	if !mfa.Enabled() {
+		return account, errcodes.ErrMFANotEnrolled
+	}
+
+	err = s.countMFATokenAttempt(ctx, input)
+	if err != nil {
+		return account, err
+	}
+
+	ok, err := s.useMFACode(ctx, mfa, input.Code)
+	if err != nil {
+		return account, err
+	}
+
+	if !ok {
+		s.log.Error(ctx, "wrong mfa code")
+		s.recordLoginFailure(ctx, scopes, account.ID)
+		return account, errcodes.ErrInvalidMFACode
+	}
+
+	s.spendMFAToken(ctx, input)
+	s.clearLoginFailures(ctx, scopes)
+
+	return account, nil
+}
+
+// countMFATokenAttempt counts a code against the token of the login, and
+// refuses it once the token took mfaTokenAttempts of them. The count lives
+// as long as the token does.
+func (s *authApp) countMFATokenAttempt(ctx context.Context, input dto.LoginMFAInput) error {
+	key := mfaTokenAttemptsKey(input.TokenID)
+
+	err := s.cache.Increase(ctx, key)
+	if err != nil {
+		s.log.Error(ctx, "error counting mfa token attempt", logger.Err(err))
+		return err
+	}
+
+	err = s.cache.SetExpiration(ctx, key, mfaTokenTTL(input))
+	if err != nil {
+		s.log.Error(ctx, "error counting mfa token attempt", logger.Err(err))
+		return err
+	}
+
+	attempts, err := s.cache.GetInt(ctx, key)
+	if err != nil {
+		s.log.Error(ctx, "error counting mfa token attempt", logger.Err(err))
+		return err
+	}
+
+	if attempts > mfaTokenAttempts {
+		s.log.Warn(ctx, "mfa token past its attempts")
+		return errcodes.ErrMFATokenSpent
+	}
+
+	return nil
+}
+
+// spendMFAToken uses up the attempts left of the token of a login that went
+// through, so it can't log in again.
+func (s *authApp) spendMFAToken(ctx context.Context, input dto.LoginMFAInput) {
+	err := s.cache.Set(ctx, mfaTokenAttemptsKey(input.TokenID), mfaTokenAttempts, mfaTokenTTL(input))
+	if err != nil {
+		s.log.Error(ctx, "error spending mfa token", logger.Err(err))
+	}
+}
+
+// mfaTokenTTL is how long the token of input has left. The token was just
+// verified, but a second is kept at least so its count can't vanish on the
+// way.
+func mfaTokenTTL(input dto.LoginMFAInput) time.Duration {
+	return max(time.Until(input.TokenExpiresAt), time.Second)
+}
+
+// getAccountMFA reads the MFA of the account with its secret decrypted.
+func (s *authApp) getAccountMFA(ctx context.Context, accountID int64) (mfa entity.AccountMFA, err error) {
+	mfa, err = s.dm.Auth().GetAccountMFA(ctx, accountID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return mfa, errcodes.ErrMFANotEnrolled
+		}
+		s.log.Error(ctx, "error getting account mfa", logger.Err(err))
+		return mfa, err
+	}
+
+	mfa.Secret, err = s.crypto.DecryptField(mfa.Secret)
+	if err != nil {
+		s.log.Error(ctx, "error decrypting mfa secret", logger.Err(err))
+		return mfa, err
+	}
+
+	return mfa, nil
+}
+
+// useMFACode takes code once: a code of the app can't be given again, nor
+// can one of an earlier step, and a recovery code is spent.
+func (s *authApp) useMFACode(ctx context.Context, mfa entity.AccountMFA, code string) (ok bool, err error) {
+	if !isTOTPCode(code) {
+		ok, err = s.dm.Auth().UseMFARecoveryCode(ctx, mfa.AccountID, s.crypto.BlindIndex(normalizeRecoveryCode(code)))
+		if err != nil {
+			s.log.Error(ctx, "error using mfa recovery code", logger.Err(err))
+			return false, err
+		}
+
+		if ok {
+			s.log.Warn(ctx, "login with an mfa recovery code")
+		}
+		return ok, nil
+	}
+
+	step, ok, err := totp.Validate(mfa.Secret, code, time.Now(), mfaSkew)
+	if err != nil {
+		s.log.Error(ctx, "error validating mfa code", logger.Err(err))
+		return false, err
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	ok, err = s.dm.Auth().UseAccountMFAStep(ctx, mfa.AccountID, step)
+	if err != nil {
+		s.log.Error(ctx, "error using account mfa step", logger.Err(err))
+		return false, err
+	}
+
+	return ok, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRecoveryCode returns a recovery code as it is shown, two groups of
+// five characters.
+func newRecoveryCode() string {
+	code := strings.ToLower(rand.Text()[:mfaRecoveryCodeSize])
+	return code[:mfaRecoveryCodeSize/2] + "-" + code[mfaRecoveryCodeSize/2:]
+}
+
+// normalizeRecoveryCode is what is hashed of a recovery code, so it is
+// taken whatever the case and with or without the dash.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
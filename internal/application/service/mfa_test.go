@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/util/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testMFASecret = "JBSWY3DPEHPK3PXP"
+
+// currentMFACode is the code of testMFASecret now, and its step.
+func currentMFACode(t *testing.T) (code string, step int64) {
+	step = totp.Step(time.Now())
+	code, err := totp.Code(testMFASecret, step)
+	require.NoError(t, err)
+	return code, step
+}
+
+func Test_authService_IsMFAEnabled(t *testing.T) {
+	confirmedAt := time.Now()
+
+	tests := []struct {
+		name        string
+		buildMock   func(mocks allMocks)
+		wantEnabled bool
+		wantErr     error
+	}{
+		{
+			name: "Should be enabled once confirmed",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{ConfirmedAt: &confirmedAt}, nil).Times(1)
+			},
+			wantEnabled: true,
+		},
+		{
+			name: "Should not be enabled while pending",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, nil).Times(1)
+			},
+		},
+		{
+			name: "Should not be enabled without an enrollment",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, apperr.ErrRecordNotFound).Times(1)
+			},
+		},
+		{
+			name: "Should return error when the repo fails",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			enabled, err := s.IsMFAEnabled(context.Background(), 1)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnabled, enabled)
+		})
+	}
+}
+
+func Test_authService_EnrollMFA(t *testing.T) {
+	account := entity.Account{ID: 1, UUID: "uuid", Name: "John"}
+
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should store the secret encrypted and return its provisioning uri",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(gomock.Any()).Return("encrypted-secret", nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetPendingAccountMFA(gomock.Any(), int64(1), "encrypted-secret").Return(true, nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error when it is already enabled",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(gomock.Any()).Return("encrypted-secret", nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().SetPendingAccountMFA(gomock.Any(), int64(1), "encrypted-secret").Return(false, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrMFAAlreadyEnabled,
+		},
+		{
+			name: "Should return error when the secret can't be encrypted",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).Return(account, nil).Times(1),
+					mocks.mockCrypto.EXPECT().EncryptField(gomock.Any()).Return("", assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "Bank", 0)
+
+			enrollment, err := s.EnrollMFA(context.Background())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEmpty(t, enrollment.Secret)
+			assert.Equal(t, totp.ProvisioningURI("Bank", "John", enrollment.Secret), enrollment.ProvisioningURI)
+		})
+	}
+}
+
+func Test_authService_ConfirmMFA(t *testing.T) {
+	code, step := currentMFACode(t)
+	confirmedAt := time.Now()
+
+	tests := []struct {
+		name      string
+		input     dto.MFACodeInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should turn it on and hand out the recovery codes",
+			input: dto.MFACodeInput{Code: code},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{AccountID: 1, Secret: "encrypted-secret"}, nil).Times(1)
+				mocks.mockCrypto.EXPECT().DecryptField("encrypted-secret").Return(testMFASecret, nil).Times(1)
+				mocks.mockCrypto.EXPECT().BlindIndex(gomock.Any()).Return("code-hash").Times(mfaRecoveryCodes)
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockAuthRepo.EXPECT().ConfirmAccountMFA(gomock.Any(), int64(1), step).Return(true, nil).Times(1),
+					mocks.mockAuthRepo.EXPECT().AddMFARecoveryCodes(gomock.Any(), int64(1), gomock.Len(mfaRecoveryCodes)).Return(nil).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error when the code is wrong",
+			input: dto.MFACodeInput{Code: wrongCode(code)},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{AccountID: 1, Secret: "encrypted-secret"}, nil).Times(1)
+				mocks.mockCrypto.EXPECT().DecryptField("encrypted-secret").Return(testMFASecret, nil).Times(1)
+			},
+			wantErr: errcodes.ErrWrongMFACode,
+		},
+		{
+			name:  "Should return error when it wasn't enrolled",
+			input: dto.MFACodeInput{Code: code},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrMFANotEnrolled,
+		},
+		{
+			name:  "Should return error when it is already enabled",
+			input: dto.MFACodeInput{Code: code},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(entity.AccountMFA{AccountID: 1, Secret: "encrypted-secret", ConfirmedAt: &confirmedAt}, nil).Times(1)
+				mocks.mockCrypto.EXPECT().DecryptField("encrypted-secret").Return(testMFASecret, nil).Times(1)
+			},
+			wantErr: errcodes.ErrMFAAlreadyEnabled,
+		},
+		{
+			name:    "Should return error when the code isn't six digits",
+			input:   dto.MFACodeInput{Code: "12ab56"},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			recoveryCodes, err := s.ConfirmMFA(context.Background(), tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, recoveryCodes, mfaRecoveryCodes)
+			for _, recoveryCode := range recoveryCodes {
+				assert.Len(t, recoveryCode, mfaRecoveryCodeSize+1)
+				assert.Equal(t, recoveryCode, strings.ToLower(recoveryCode))
+			}
+		})
+	}
+}
+
+func Test_authService_LoginMFA(t *testing.T) {
+	const (
+		accountUUID = "a3a1f0d2-6a7e-4a47-bb4e-7e5b8b2c8f10"
+		clientIP    = "10.0.0.1"
+	)
+
+	code, step := currentMFACode(t)
+	confirmedAt := time.Now()
+	account := entity.Account{ID: 1, UUID: accountUUID, CPFIndex: "cpf-index", Active: true}
+	mfa := entity.AccountMFA{AccountID: 1, Secret: "encrypted-secret", ConfirmedAt: &confirmedAt}
+
+	expectMFALoginNotLocked := func(mocks allMocks, clientIP string) {
+		mocks.mockCacheManager.EXPECT().GetExpiration(gomock.Any(), "login-lock:cpf:cpf-index").Return(time.Duration(-2), nil).Times(1)
+		mocks.mockCacheManager.EXPECT().GetExpiration(gomock.Any(), "login-lock:ip:"+clientIP).Return(time.Duration(-2), nil).Times(1)
+	}
+
+	expectMFA := func(mocks allMocks, mfa entity.AccountMFA) {
+		mocks.mockAuthRepo.EXPECT().GetAccountMFA(gomock.Any(), int64(1)).Return(mfa, nil).Times(1)
+		mocks.mockCrypto.EXPECT().DecryptField("encrypted-secret").Return(testMFASecret, nil).Times(1)
+	}
+
+	expectMFATokenAttempt := func(mocks allMocks, attempts int64) {
+		key := "mfa-token-attempts:token-id"
+		mocks.mockCacheManager.EXPECT().Increase(gomock.Any(), key).Return(nil).Times(1)
+		mocks.mockCacheManager.EXPECT().SetExpiration(gomock.Any(), key, gomock.Any()).Return(nil).Times(1)
+		mocks.mockCacheManager.EXPECT().GetInt(gomock.Any(), key).Return(attempts, nil).Times(1)
+	}
+
+	tests := []struct {
+		name      string
+		code      string
+		buildMock func(ctx context.Context, mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should login with a code of the app",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				mocks.mockAuthRepo.EXPECT().UseAccountMFAStep(gomock.Any(), int64(1), step).Return(true, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "mfa-token-attempts:token-id", mfaTokenAttempts, gomock.Any()).Return(nil).Times(1)
+				mocks.mockCacheManager.EXPECT().Delete(gomock.Any(), "login-failures:cpf:cpf-index").Return(nil).Times(1)
+			},
+		},
+		{
+			name: "Should login with a recovery code, whatever its case",
+			code: "ABCDE-fghij",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				mocks.mockCrypto.EXPECT().BlindIndex("abcdefghij").Return("code-hash").Times(1)
+				mocks.mockAuthRepo.EXPECT().UseMFARecoveryCode(gomock.Any(), int64(1), "code-hash").Return(true, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().Set(gomock.Any(), "mfa-token-attempts:token-id", mfaTokenAttempts, gomock.Any()).Return(nil).Times(1)
+				mocks.mockCacheManager.EXPECT().Delete(gomock.Any(), "login-failures:cpf:cpf-index").Return(nil).Times(1)
+			},
+		},
+		{
+			name: "Should count a failed login when a code of the app is given again",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				mocks.mockAuthRepo.EXPECT().UseAccountMFAStep(gomock.Any(), int64(1), step).Return(false, nil).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+clientIP, 1)
+			},
+			wantErr: errcodes.ErrInvalidMFACode,
+		},
+		{
+			name: "Should count a failed login of an account stored without a blind index by its cpf",
+			code: wrongCode(code),
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				legacy := account
+				legacy.CPFIndex = ""
+				legacy.CPF = "01234567890"
+
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(legacy, nil).Times(1)
+				mocks.mockCrypto.EXPECT().DecryptField("01234567890").Return("01234567890", nil).Times(1)
+				mocks.mockCrypto.EXPECT().BlindIndex("01234567890").Return("cpf-index").Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+clientIP, 1)
+			},
+			wantErr: errcodes.ErrInvalidMFACode,
+		},
+		{
+			name: "Should return error if the cpf of an account stored without a blind index can't be decrypted",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				legacy := account
+				legacy.CPFIndex = ""
+				legacy.CPF = "encrypted-cpf"
+
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(legacy, nil).Times(1)
+				mocks.mockCrypto.EXPECT().DecryptField("encrypted-cpf").Return("", assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name: "Should count a failed login when the code is wrong",
+			code: wrongCode(code),
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+clientIP, 1)
+			},
+			wantErr: errcodes.ErrInvalidMFACode,
+		},
+		{
+			name: "Should count a failed login when the recovery code is spent",
+			code: "abcde-fghij",
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, 1)
+				mocks.mockCrypto.EXPECT().BlindIndex("abcdefghij").Return("code-hash").Times(1)
+				mocks.mockAuthRepo.EXPECT().UseMFARecoveryCode(gomock.Any(), int64(1), "code-hash").Return(false, nil).Times(1)
+				expectLoginFailure(mocks, "login-failures:cpf:cpf-index", 1)
+				expectLoginFailure(mocks, "login-failures:ip:"+clientIP, 1)
+			},
+			wantErr: errcodes.ErrInvalidMFACode,
+		},
+		{
+			name: "Should refuse a code once the token took all its attempts",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, mfa)
+				expectMFATokenAttempt(mocks, mfaTokenAttempts+1)
+			},
+			wantErr: errcodes.ErrMFATokenSpent,
+		},
+		{
+			name: "Should return the account locked error while locked",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(gomock.Any(), "login-lock:cpf:cpf-index").Return(time.Minute, nil).Times(1)
+				mocks.mockCacheManager.EXPECT().GetExpiration(gomock.Any(), "login-lock:ip:"+clientIP).Return(time.Duration(-2), nil).Times(1)
+			},
+			wantErr: errcodes.ErrAccountLocked,
+		},
+		{
+			name: "Should return error when the account is not active",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				inactive := account
+				inactive.Active = false
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(inactive, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+			},
+			wantErr: errcodes.ErrDeactivatedAccount,
+		},
+		{
+			name: "Should return error when the mfa is still pending",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(account, nil).Times(1)
+				expectMFALoginNotLocked(mocks, clientIP)
+				expectMFA(mocks, entity.AccountMFA{AccountID: 1, Secret: "encrypted-secret"})
+			},
+			wantErr: errcodes.ErrMFANotEnrolled,
+		},
+		{
+			name: "Should return error when the account is gone",
+			code: code,
+			buildMock: func(ctx context.Context, mocks allMocks) {
+				mocks.mockAccountRepo.EXPECT().GetAccountByUUID(ctx, accountUUID).Return(entity.Account{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(ctx, m)
+
+			s := newAuthApp(m.mockDomain, m.mockAccountSvc, time.Minute, time.Hour, testLoginThrottle, "test", 0)
+
+			got, err := s.LoginMFA(ctx, dto.LoginMFAInput{
+				AccountUUID:    accountUUID,
+				Code:           tt.code,
+				ClientIP:       clientIP,
+				TokenID:        "token-id",
+				TokenExpiresAt: time.Now().Add(5 * time.Minute),
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, accountUUID, got.UUID)
+		})
+	}
+}
+
+// wrongCode is a code that is none of the steps around code.
+func wrongCode(code string) string {
+	for _, c := range []string{"000000", "111111", "222222", "333333"} {
+		if c != code && !isNearMFACode(c) {
+			return c
+		}
+	}
+	return "999999"
+}
+
+func isNearMFACode(code string) bool {
+	_, ok, _ := totp.Validate(testMFASecret, code, time.Now(), mfaSkew)
+	return ok
+}
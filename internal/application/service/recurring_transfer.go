@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/logger"
+	"github.com/diegoclair/appvalidator/apperrmap"
+	"github.com/google/uuid"
+)
+
+type recurringTransferService struct {
+	accountSvc  contract.AccountApp
+	transferSvc *transferService
+	dm          contract.DataManager
+	log         logger.Logger
+	validator   apperrmap.Validator
+}
+
+func newRecurringTransferService(infra domain.Infrastructure, accountSvc contract.AccountApp, transferSvc *transferService) contract.RecurringTransferApp {
+	return &recurringTransferService{
+		accountSvc:  accountSvc,
+		transferSvc: transferSvc,
+		dm:          infra.DataManager(),
+		log:         infra.Logger(),
+		validator:   infra.Validator(),
+	}
+}
+
+// CreateRecurringTransfer records a standing order of the logged account. The
+// destination is checked now, and the funds on every occurrence.
+func (s *recurringTransferService) CreateRecurringTransfer(ctx context.Context, input dto.RecurringTransferInput) (recurring entity.RecurringTransfer, err error) {
+	recurring, err = input.ToEntityValidate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return recurring, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("destination_account_uuid", recurring.AccountDestinationUUID))
+
+	transfer, err := s.transferSvc.resolveTransferAccounts(ctx, entity.Transfer{
+		AccountDestinationUUID: recurring.AccountDestinationUUID,
+		Amount:                 recurring.Amount,
+	})
+	if err != nil {
+		return entity.RecurringTransfer{}, err
+	}
+
+	recurring.RecurringTransferUUID = uuid.Must(uuid.NewV7()).String()
+	recurring.AccountOriginID = transfer.AccountOriginID
+	recurring.AccountDestinationID = transfer.AccountDestinationID
+	recurring.Reschedule()
+	ctx = logger.WithAttrs(ctx, logger.Attr("recurring_transfer_uuid", recurring.RecurringTransferUUID))
+
+	recurring.ID, err = s.dm.RecurringTransfer().AddRecurringTransfer(ctx, recurring)
+	if err != nil {
+		s.log.Error(ctx, "error to add recurring transfer", logger.Err(err))
+		return entity.RecurringTransfer{}, err
+	}
+
+	stored, err := s.dm.RecurringTransfer().GetRecurringTransferByUUID(ctx, recurring.RecurringTransferUUID)
+	if err != nil {
+		// it is recorded all the same, so this is no reason to fail the request
+		s.log.Error(ctx, "error to read back recurring transfer", logger.Err(err))
+		return recurring, nil
+	}
+
+	return stored, nil
+}
+
+func (s *recurringTransferService) GetRecurringTransfers(ctx context.Context, take, skip int64) (recurring []entity.RecurringTransfer, totalRecords int64, err error) {
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return recurring, totalRecords, err
+	}
+
+	recurring, totalRecords, err = s.dm.RecurringTransfer().GetRecurringTransfersByAccountID(ctx, accountID, take, skip)
+	if err != nil {
+		s.log.Error(ctx, "error to get recurring transfers", logger.Err(err))
+		return recurring, totalRecords, err
+	}
+
+	return recurring, totalRecords, nil
+}
+
+func (s *recurringTransferService) GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (recurring entity.RecurringTransfer, err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("recurring_transfer_uuid", recurringTransferUUID))
+
+	return s.getOwnedRecurringTransfer(ctx, recurringTransferUUID)
+}
+
+// UpdateRecurringTransfer changes a running standing order. The occurrences
+// already generated are left as they are.
+func (s *recurringTransferService) UpdateRecurringTransfer(ctx context.Context, input dto.RecurringTransferUpdateInput) (recurring entity.RecurringTransfer, err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("recurring_transfer_uuid", input.RecurringTransferUUID))
+
+	owned, err := s.getOwnedRecurringTransfer(ctx, input.RecurringTransferUUID)
+	if err != nil {
+		return recurring, err
+	}
+
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		// read again under the lock, the scheduler may have moved it on
+		recurring, err = tx.RecurringTransfer().LockRecurringTransfer(ctx, owned.ID)
+		if err != nil {
+			s.log.Error(ctx, "error to lock recurring transfer", logger.Err(err))
+			return err
+		}
+
+		if !recurring.IsActive() {
+			return errcodes.ErrRecurringTransferNotActive
+		}
+
+		err = input.Apply(ctx, s.validator, &recurring)
+		if err != nil {
+			s.log.Error(ctx, "error or invalid input", logger.Err(err))
+			return err
+		}
+
+		// a new end may come before the next occurrence
+		recurring.Reschedule()
+
+		err = tx.RecurringTransfer().UpdateRecurringTransfer(ctx, recurring)
+		if err != nil {
+			s.log.Error(ctx, "error to update recurring transfer", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.RecurringTransfer{}, err
+	}
+
+	return recurring, nil
+}
+
+// CancelRecurringTransfer stops a standing order, along with any occurrence
+// still waiting for a retry.
+func (s *recurringTransferService) CancelRecurringTransfer(ctx context.Context, recurringTransferUUID string) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("recurring_transfer_uuid", recurringTransferUUID))
+
+	owned, err := s.getOwnedRecurringTransfer(ctx, recurringTransferUUID)
+	if err != nil {
+		return err
+	}
+
+	return s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		recurring, err := tx.RecurringTransfer().LockRecurringTransfer(ctx, owned.ID)
+		if err != nil {
+			s.log.Error(ctx, "error to lock recurring transfer", logger.Err(err))
+			return err
+		}
+
+		if !recurring.IsActive() {
+			return errcodes.ErrRecurringTransferNotActive
+		}
+
+		canceledAt := time.Now()
+		recurring.Status = entity.RecurringTransferCanceled
+		recurring.NextRunAt = nil
+		recurring.CanceledAt = &canceledAt
+
+		err = tx.RecurringTransfer().UpdateRecurringTransfer(ctx, recurring)
+		if err != nil {
+			s.log.Error(ctx, "error to cancel recurring transfer", logger.Err(err))
+			return err
+		}
+
+		_, err = tx.ScheduledTransfer().CancelScheduledTransfersByRecurringID(ctx, recurring.ID)
+		if err != nil {
+			s.log.Error(ctx, "error to cancel pending occurrences", logger.Err(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GenerateDueOccurrences turns the due occurrence of up to batchSize standing
+// orders into scheduled transfers, for the scheduled transfer worker to run.
+// The rules stay locked until the occurrences are recorded and the rules moved
+// on, in one transaction, so no occurrence is generated twice or missed.
+func (s *recurringTransferService) GenerateDueOccurrences(ctx context.Context, batchSize int64) (generated int, err error) {
+	err = s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		due, err := tx.RecurringTransfer().LockDueRecurringTransfers(ctx, batchSize)
+		if err != nil {
+			s.log.Error(ctx, "error to lock due recurring transfers", logger.Err(err))
+			return err
+		}
+
+		for _, recurring := range due {
+			occurrence := recurring.NextOccurrence()
+			occurrence.ScheduledTransferUUID = uuid.Must(uuid.NewV7()).String()
+			occurrence.TransferUUID = uuid.Must(uuid.NewV7()).String()
+
+			_, err = tx.ScheduledTransfer().AddScheduledTransfer(ctx, occurrence)
+			if err != nil {
+				s.log.Error(ctx, "error to add occurrence of recurring transfer", logger.Err(err),
+					logger.Attr("recurring_transfer_uuid", recurring.RecurringTransferUUID))
+				return err
+			}
+
+			recurring.Advance()
+
+			err = tx.RecurringTransfer().UpdateRecurringTransfer(ctx, recurring)
+			if err != nil {
+				s.log.Error(ctx, "error to advance recurring transfer", logger.Err(err),
+					logger.Attr("recurring_transfer_uuid", recurring.RecurringTransferUUID))
+				return err
+			}
+		}
+
+		generated = len(due)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return generated, nil
+}
+
+// getOwnedRecurringTransfer reports someone else's standing order as missing,
+// not as forbidden.
+func (s *recurringTransferService) getOwnedRecurringTransfer(ctx context.Context, recurringTransferUUID string) (recurring entity.RecurringTransfer, err error) {
+	if _, err = uuid.Parse(recurringTransferUUID); err != nil {
+		return recurring, apperr.ErrInvalidInput.WithMessage("invalid recurring_transfer_uuid")
+	}
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return recurring, err
+	}
+
+	recurring, err = s.dm.RecurringTransfer().GetRecurringTransferByUUID(ctx, recurringTransferUUID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return entity.RecurringTransfer{}, errcodes.ErrRecurringTransferNotFound
+		}
+		s.log.Error(ctx, "error to get recurring transfer by uuid", logger.Err(err))
+		return entity.RecurringTransfer{}, err
+	}
+
+	if recurring.AccountOriginID != accountID {
+		return entity.RecurringTransfer{}, errcodes.ErrRecurringTransferNotFound
+	}
+
+	return recurring, nil
+}
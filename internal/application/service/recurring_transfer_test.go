@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestRecurringTransferService(m allMocks) *recurringTransferService {
+	return newRecurringTransferService(m.mockDomain, m.mockAccountSvc, newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})).(*recurringTransferService)
+}
+
+const testRecurringTransferUUID = "0190f7a4-52d1-7a3c-9f5e-2b6c8d1e4a80"
+
+// activeRecurringTransfer is a weekly standing order of account 1 with one
+// occurrence made
+func activeRecurringTransfer() entity.RecurringTransfer {
+	startsAt := time.Now().Add(-24 * time.Hour)
+	nextRunAt := startsAt.AddDate(0, 0, 7)
+
+	return entity.RecurringTransfer{
+		ID:                    5,
+		RecurringTransferUUID: testRecurringTransferUUID,
+		AccountOriginID:       1,
+		AccountDestinationID:  2,
+		Amount:                entity.NewMoney(500, entity.BRL),
+		Rule:                  entity.RecurrenceRule{Frequency: entity.RecurrenceWeekly, StartsAt: startsAt},
+		InsufficientFunds:     entity.InsufficientFundsSkip,
+		Status:                entity.RecurringTransferActive,
+		Occurrences:           1,
+		NextRunAt:             &nextRunAt,
+	}
+}
+
+func Test_recurringTransferService_CreateRecurringTransfer(t *testing.T) {
+	const destUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	startsAt := time.Now().Add(time.Hour)
+	validInput := dto.RecurringTransferInput{
+		TransferInput: dto.TransferInput{
+			AccountDestinationUUID: destUUID,
+			Amount:                 entity.NewMoney(500, entity.BRL),
+		},
+		Frequency: string(entity.RecurrenceWeekly),
+		StartsAt:  startsAt,
+	}
+
+	// toBeAdded matches the rule as it is first recorded, due on its start
+	toBeAdded := gomock.Cond(func(recurring entity.RecurringTransfer) bool {
+		return recurring.RecurringTransferUUID != "" &&
+			recurring.AccountOriginID == 1 &&
+			recurring.AccountDestinationID == 2 &&
+			recurring.Status == entity.RecurringTransferActive &&
+			recurring.NextRunAt != nil && recurring.NextRunAt.Equal(startsAt)
+	})
+
+	tests := []struct {
+		name      string
+		input     dto.RecurringTransferInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should create the recurring transfer",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().AddRecurringTransfer(gomock.Any(), toBeAdded).Return(int64(5), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), gomock.Not("")).
+						DoAndReturn(func(_ context.Context, recurringTransferUUID string) (entity.RecurringTransfer, error) {
+							return entity.RecurringTransfer{ID: 5, RecurringTransferUUID: recurringTransferUUID, Status: entity.RecurringTransferActive}, nil
+						}).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return the recurring transfer if it can't be read back",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().AddRecurringTransfer(gomock.Any(), toBeAdded).Return(int64(5), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), gomock.Not("")).
+						Return(entity.RecurringTransfer{}, assert.AnError).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the destination is the logged account",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(1), nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrSelfTransfer,
+		},
+		{
+			name:  "Should return error if there is some error to add the recurring transfer",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().AddRecurringTransfer(gomock.Any(), toBeAdded).
+						Return(int64(0), assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "Should return error if the input is invalid",
+			input:   dto.RecurringTransferInput{TransferInput: validInput.TransferInput, Frequency: "daily", StartsAt: startsAt},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTestRecurringTransferService(m)
+
+			recurring, err := s.CreateRecurringTransfer(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEmpty(t, recurring.RecurringTransferUUID)
+			require.Equal(t, entity.RecurringTransferActive, recurring.Status)
+		})
+	}
+}
+
+func Test_recurringTransferService_GetRecurringTransferByUUID(t *testing.T) {
+	tests := []struct {
+		name          string
+		recurringUUID string
+		buildMock     func(mocks allMocks)
+		wantErr       error
+	}{
+		{
+			name:          "Should return a recurring transfer of the logged account",
+			recurringUUID: testRecurringTransferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+					Return(activeRecurringTransfer(), nil).Times(1)
+			},
+		},
+		{
+			name:          "Should not return someone else's recurring transfer",
+			recurringUUID: testRecurringTransferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+					Return(activeRecurringTransfer(), nil).Times(1)
+			},
+			wantErr: errcodes.ErrRecurringTransferNotFound,
+		},
+		{
+			name:          "Should return error when the recurring transfer doesn't exist",
+			recurringUUID: testRecurringTransferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+					Return(entity.RecurringTransfer{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrRecurringTransferNotFound,
+		},
+		{
+			name:          "Should return error when the recurring transfer uuid is invalid",
+			recurringUUID: "invalid",
+			wantErr:       apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTestRecurringTransferService(m)
+
+			recurring, err := s.GetRecurringTransferByUUID(ctx, tt.recurringUUID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testRecurringTransferUUID, recurring.RecurringTransferUUID)
+		})
+	}
+}
+
+func Test_recurringTransferService_GetRecurringTransfers(t *testing.T) {
+	m, ctrl := newServiceTestMock(t)
+	defer ctrl.Finish()
+
+	gomock.InOrder(
+		m.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+		m.mockRecurringTransferRepo.EXPECT().GetRecurringTransfersByAccountID(gomock.Any(), int64(1), int64(10), int64(0)).
+			Return([]entity.RecurringTransfer{activeRecurringTransfer()}, int64(1), nil).Times(1),
+	)
+
+	s := newTestRecurringTransferService(m)
+
+	recurring, totalRecords, err := s.GetRecurringTransfers(context.Background(), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, recurring, 1)
+	require.Equal(t, int64(1), totalRecords)
+}
+
+func Test_recurringTransferService_UpdateRecurringTransfer(t *testing.T) {
+	validInput := dto.RecurringTransferUpdateInput{
+		RecurringTransferUUID: testRecurringTransferUUID,
+		Amount:                entity.NewMoney(900, entity.BRL),
+		OnInsufficient:        string(entity.InsufficientFundsRetry),
+	}
+
+	ownedAndLocked := func(mocks allMocks, locked entity.RecurringTransfer) {
+		gomock.InOrder(
+			mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+			mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+				Return(activeRecurringTransfer(), nil).Times(1),
+			withTransaction(mocks),
+			mocks.mockRecurringTransferRepo.EXPECT().LockRecurringTransfer(gomock.Any(), int64(5)).Return(locked, nil).Times(1),
+		)
+	}
+
+	tests := []struct {
+		name       string
+		input      dto.RecurringTransferUpdateInput
+		buildMock  func(mocks allMocks)
+		wantStatus entity.RecurringTransferStatus
+		wantErr    error
+	}{
+		{
+			name:  "Should update the recurring transfer",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				ownedAndLocked(mocks, activeRecurringTransfer())
+				mocks.mockRecurringTransferRepo.EXPECT().UpdateRecurringTransfer(gomock.Any(), gomock.Cond(func(recurring entity.RecurringTransfer) bool {
+					return recurring.Amount == validInput.Amount &&
+						recurring.InsufficientFunds == entity.InsufficientFundsRetry &&
+						recurring.MaxRetries > 0 &&
+						recurring.Occurrences == 1 &&
+						recurring.Status == entity.RecurringTransferActive
+				})).Return(nil).Times(1)
+			},
+			wantStatus: entity.RecurringTransferActive,
+		},
+		{
+			name: "Should finish the recurring transfer when the new end already passed",
+			input: dto.RecurringTransferUpdateInput{
+				RecurringTransferUUID: testRecurringTransferUUID,
+				Amount:                entity.NewMoney(900, entity.BRL),
+				MaxOccurrences:        1,
+			},
+			buildMock: func(mocks allMocks) {
+				ownedAndLocked(mocks, activeRecurringTransfer())
+				mocks.mockRecurringTransferRepo.EXPECT().UpdateRecurringTransfer(gomock.Any(), gomock.Cond(func(recurring entity.RecurringTransfer) bool {
+					return recurring.Status == entity.RecurringTransferFinished && recurring.NextRunAt == nil
+				})).Return(nil).Times(1)
+			},
+			wantStatus: entity.RecurringTransferFinished,
+		},
+		{
+			name:  "Should return error if the recurring transfer is no longer active",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				canceled := activeRecurringTransfer()
+				canceled.Status = entity.RecurringTransferCanceled
+				ownedAndLocked(mocks, canceled)
+			},
+			wantErr: errcodes.ErrRecurringTransferNotActive,
+		},
+		{
+			name: "Should return error if the input is invalid",
+			input: dto.RecurringTransferUpdateInput{
+				RecurringTransferUUID: testRecurringTransferUUID,
+			},
+			buildMock: func(mocks allMocks) {
+				ownedAndLocked(mocks, activeRecurringTransfer())
+			},
+			wantErr: apperr.ErrInvalidInput,
+		},
+		{
+			name:  "Should return error if there is some error to update",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				ownedAndLocked(mocks, activeRecurringTransfer())
+				mocks.mockRecurringTransferRepo.EXPECT().UpdateRecurringTransfer(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newTestRecurringTransferService(m)
+
+			recurring, err := s.UpdateRecurringTransfer(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, recurring.Status)
+			require.Equal(t, tt.input.Amount, recurring.Amount)
+		})
+	}
+}
+
+func Test_recurringTransferService_CancelRecurringTransfer(t *testing.T) {
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name: "Should cancel the recurring transfer and its pending occurrences",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+						Return(activeRecurringTransfer(), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockRecurringTransfer(gomock.Any(), int64(5)).
+						Return(activeRecurringTransfer(), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().UpdateRecurringTransfer(gomock.Any(), gomock.Cond(func(recurring entity.RecurringTransfer) bool {
+						return recurring.Status == entity.RecurringTransferCanceled &&
+							recurring.CanceledAt != nil &&
+							recurring.NextRunAt == nil
+					})).Return(nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfersByRecurringID(gomock.Any(), int64(5)).
+						Return(int64(1), nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error if the recurring transfer already finished",
+			buildMock: func(mocks allMocks) {
+				finished := activeRecurringTransfer()
+				finished.Status = entity.RecurringTransferFinished
+
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+						Return(finished, nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockRecurringTransfer(gomock.Any(), int64(5)).
+						Return(finished, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrRecurringTransferNotActive,
+		},
+		{
+			name: "Should not cancel someone else's recurring transfer",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(3), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().GetRecurringTransferByUUID(gomock.Any(), testRecurringTransferUUID).
+						Return(activeRecurringTransfer(), nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrRecurringTransferNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newTestRecurringTransferService(m)
+
+			err := s.CancelRecurringTransfer(ctx, testRecurringTransferUUID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_recurringTransferService_GenerateDueOccurrences(t *testing.T) {
+	due := activeRecurringTransfer()
+	due.InsufficientFunds = entity.InsufficientFundsRetry
+	due.MaxRetries = 2
+	dueAt := *due.NextRunAt
+
+	tests := []struct {
+		name          string
+		buildMock     func(mocks allMocks)
+		wantGenerated int
+		wantErr       bool
+	}{
+		{
+			name: "Should generate the due occurrence and move the rule on",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockDueRecurringTransfers(gomock.Any(), int64(10)).
+						Return([]entity.RecurringTransfer{due}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().AddScheduledTransfer(gomock.Any(), gomock.Cond(func(occurrence entity.ScheduledTransfer) bool {
+						return occurrence.RecurringTransferID == due.ID &&
+							occurrence.Occurrence == 2 &&
+							occurrence.ScheduledFor.Equal(dueAt) &&
+							occurrence.MaxRetries == 2 &&
+							occurrence.ScheduledTransferUUID != "" &&
+							occurrence.TransferUUID != ""
+					})).Return(int64(9), nil).Times(1),
+					mocks.mockRecurringTransferRepo.EXPECT().UpdateRecurringTransfer(gomock.Any(), gomock.Cond(func(recurring entity.RecurringTransfer) bool {
+						return recurring.Occurrences == 2 && recurring.NextRunAt.Equal(dueAt.AddDate(0, 0, 7))
+					})).Return(nil).Times(1),
+				)
+			},
+			wantGenerated: 1,
+		},
+		{
+			name: "Should generate nothing when nothing is due",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockDueRecurringTransfers(gomock.Any(), int64(10)).Return(nil, nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should return error and generate nothing if an occurrence can't be added",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockDueRecurringTransfers(gomock.Any(), int64(10)).
+						Return([]entity.RecurringTransfer{due}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().AddScheduledTransfer(gomock.Any(), gomock.Any()).
+						Return(int64(0), assert.AnError).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error if the due rules can't be locked",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					withTransaction(mocks),
+					mocks.mockRecurringTransferRepo.EXPECT().LockDueRecurringTransfers(gomock.Any(), int64(10)).
+						Return(nil, assert.AnError).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newTestRecurringTransferService(m)
+
+			generated, err := s.GenerateDueOccurrences(ctx, 10)
+			if tt.wantErr {
+				require.ErrorIs(t, err, assert.AnError)
+				require.Zero(t, generated)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantGenerated, generated)
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/logger"
+	"github.com/diegoclair/appvalidator/apperrmap"
+	"github.com/google/uuid"
+)
+
+// scheduledTransferClaimTimeout is how long a worker may hold a claimed
+// schedule before another one takes it over. An execution takes well under a
+// second, so a claim this old belongs to a worker that is gone.
+const scheduledTransferClaimTimeout = 5 * time.Minute
+
+// interruptedExecutionReason is recorded when a worker stopped between
+// recording the transfer and settling it. The transaction never committed, so
+// no money moved and the schedule runs again.
+const interruptedExecutionReason = "TRANSFER_EXECUTION_INTERRUPTED"
+
+// insufficientFundsRetryInterval is how long a schedule that may be retried
+// waits after a run the account couldn't afford.
+const insufficientFundsRetryInterval = 24 * time.Hour
+
+// requeueInterval is how long a schedule waits after a run that didn't go
+// through for a reason of the system, such as the database being unreachable.
+const requeueInterval = time.Minute
+
+type scheduledTransferService struct {
+	accountSvc  contract.AccountApp
+	transferSvc *transferService
+	dm          contract.DataManager
+	log         logger.Logger
+	validator   apperrmap.Validator
+}
+
+func newScheduledTransferService(infra domain.Infrastructure, accountSvc contract.AccountApp, transferSvc *transferService) contract.ScheduledTransferApp {
+	return &scheduledTransferService{
+		accountSvc:  accountSvc,
+		transferSvc: transferSvc,
+		dm:          infra.DataManager(),
+		log:         infra.Logger(),
+		validator:   infra.Validator(),
+	}
+}
+
+// ScheduleTransfer records a transfer from the logged account to be made at
+// input.ScheduledFor. The destination is checked now, and the funds only when
+// it runs.
+func (s *scheduledTransferService) ScheduleTransfer(ctx context.Context, input dto.ScheduledTransferInput) (scheduled entity.ScheduledTransfer, err error) {
+	scheduled, err = input.ToEntityValidate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return scheduled, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("destination_account_uuid", scheduled.AccountDestinationUUID))
+
+	transfer, err := s.transferSvc.resolveTransferAccounts(ctx, scheduled.Transfer())
+	if err != nil {
+		return entity.ScheduledTransfer{}, err
+	}
+
+	scheduled.ScheduledTransferUUID = uuid.Must(uuid.NewV7()).String()
+	scheduled.TransferUUID = uuid.Must(uuid.NewV7()).String()
+	scheduled.AccountOriginID = transfer.AccountOriginID
+	scheduled.AccountDestinationID = transfer.AccountDestinationID
+	scheduled.Status = entity.ScheduledTransferPending
+	ctx = logger.WithAttrs(ctx, logger.Attr("scheduled_transfer_uuid", scheduled.ScheduledTransferUUID))
+
+	scheduled.ID, err = s.dm.ScheduledTransfer().AddScheduledTransfer(ctx, scheduled)
+	if err != nil {
+		s.log.Error(ctx, "error to add scheduled transfer", logger.Err(err))
+		return entity.ScheduledTransfer{}, err
+	}
+
+	stored, err := s.dm.ScheduledTransfer().GetScheduledTransferByUUID(ctx, scheduled.ScheduledTransferUUID)
+	if err != nil {
+		// it is scheduled all the same, so this is no reason to fail the request
+		s.log.Error(ctx, "error to read back scheduled transfer", logger.Err(err))
+		return scheduled, nil
+	}
+
+	return stored, nil
+}
+
+// GetScheduledTransfers lists what the logged account scheduled. An empty
+// status lists them all.
+func (s *scheduledTransferService) GetScheduledTransfers(ctx context.Context, status entity.ScheduledTransferStatus, take, skip int64) (scheduled []entity.ScheduledTransfer, totalRecords int64, err error) {
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return scheduled, totalRecords, err
+	}
+
+	scheduled, totalRecords, err = s.dm.ScheduledTransfer().GetScheduledTransfersByAccountID(ctx, accountID, status, take, skip)
+	if err != nil {
+		s.log.Error(ctx, "error to get scheduled transfers", logger.Err(err))
+		return scheduled, totalRecords, err
+	}
+
+	return scheduled, totalRecords, nil
+}
+
+// CancelScheduledTransfer calls off a schedule of the logged account that no
+// worker picked up yet.
+func (s *scheduledTransferService) CancelScheduledTransfer(ctx context.Context, scheduledTransferUUID string) (err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("scheduled_transfer_uuid", scheduledTransferUUID))
+
+	if _, err = uuid.Parse(scheduledTransferUUID); err != nil {
+		return apperr.ErrInvalidInput.WithMessage("invalid scheduled_transfer_uuid")
+	}
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return err
+	}
+
+	scheduled, err := s.dm.ScheduledTransfer().GetScheduledTransferByUUID(ctx, scheduledTransferUUID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return errcodes.ErrScheduledTransferNotFound
+		}
+		s.log.Error(ctx, "error to get scheduled transfer by uuid", logger.Err(err))
+		return err
+	}
+
+	// someone else's schedule is reported as missing, not as forbidden
+	if scheduled.AccountOriginID != accountID {
+		return errcodes.ErrScheduledTransferNotFound
+	}
+
+	if !scheduled.CanCancel() {
+		return errcodes.ErrScheduledTransferNotCancelable
+	}
+
+	// a worker may claim it between the read and here, which the update sees
+	canceled, err := s.dm.ScheduledTransfer().CancelScheduledTransfer(ctx, scheduled.ID)
+	if err != nil {
+		s.log.Error(ctx, "error to cancel scheduled transfer", logger.Err(err))
+		return err
+	}
+
+	if !canceled {
+		return errcodes.ErrScheduledTransferNotCancelable
+	}
+
+	return nil
+}
+
+// ExecuteDueTransfers claims up to batchSize due schedules and runs each one.
+// A schedule whose transfer is declined is recorded as failed with the reason,
+// one that fails for a reason of the system runs again later, and the rest of
+// the batch goes on; err is only about claiming the batch.
+func (s *scheduledTransferService) ExecuteDueTransfers(ctx context.Context, batchSize int64) (executed int, err error) {
+	claimed, err := s.dm.ScheduledTransfer().ClaimDueScheduledTransfers(ctx, batchSize, time.Now().Add(-scheduledTransferClaimTimeout))
+	if err != nil {
+		s.log.Error(ctx, "error to claim due scheduled transfers", logger.Err(err))
+		return executed, err
+	}
+
+	for _, scheduled := range claimed {
+		err = s.executeScheduledTransfer(ctx, scheduled)
+		if err != nil {
+			// left as processing, to be claimed again once the claim is stale
+			continue
+		}
+		executed++
+	}
+
+	return executed, nil
+}
+
+// executeScheduledTransfer runs a claimed schedule and settles it. The
+// transfer is made under the UUID chosen when it was scheduled, so when a
+// previous claim got as far as recording it, that transfer settles the
+// schedule instead of a second one being made.
+func (s *scheduledTransferService) executeScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer) error {
+	ctx = logger.WithAttrs(ctx,
+		logger.Attr("scheduled_transfer_uuid", scheduled.ScheduledTransferUUID),
+		logger.Attr("transfer_uuid", scheduled.TransferUUID),
+		logger.Attr("account_uuid", scheduled.AccountOriginUUID),
+	)
+
+	transfer, err := s.dm.Account().GetTransferByUUID(ctx, scheduled.TransferUUID)
+	switch {
+	case apperr.IsNotFound(err):
+		transfer, err = s.transferSvc.executeTransfer(ctx, scheduled.Transfer())
+		if err != nil {
+			return s.handleFailedRun(ctx, scheduled, err)
+		}
+	case err != nil:
+		s.log.Error(ctx, "error to get transfer of scheduled transfer", logger.Err(err))
+		return err
+	case transfer.Status == entity.TransferPending:
+		s.transferSvc.failTransfer(ctx, transfer, interruptedExecutionReason)
+		return s.requeueScheduledTransfer(ctx, scheduled, interruptedExecutionReason)
+	}
+
+	if transfer.Status == entity.TransferFailed {
+		if transfer.FailureReason == transferFailedReason {
+			// it failed without being declined, and a failed transfer moved nothing
+			return s.requeueScheduledTransfer(ctx, scheduled, transfer.FailureReason)
+		}
+		return s.settleScheduledTransfer(ctx, scheduled, entity.ScheduledTransferFailed, transfer.FailureReason)
+	}
+
+	return s.settleScheduledTransfer(ctx, scheduled, entity.ScheduledTransferCompleted, "")
+}
+
+// handleFailedRun settles a schedule whose transfer returned err. Only a
+// decline is final. Any other error leaves the schedule to run again: right
+// away when the transfer was rolled back, or else once its claim is stale,
+// when the next claim settles it by what was recorded under its transfer UUID.
+func (s *scheduledTransferService) handleFailedRun(ctx context.Context, scheduled entity.ScheduledTransfer, err error) error {
+	reason := transferFailureReason(err)
+
+	switch {
+	case errors.Is(err, errcodes.ErrInsufficientFunds) && scheduled.CanRetry():
+		return s.retryScheduledTransfer(ctx, scheduled, reason)
+	case reason != transferFailedReason:
+		return s.settleScheduledTransfer(ctx, scheduled, entity.ScheduledTransferFailed, reason)
+	case errcodes.IsUnapplied(err):
+		return s.requeueScheduledTransfer(ctx, scheduled, reason)
+	default:
+		s.log.Error(ctx, "error to execute scheduled transfer", logger.Err(err))
+		return err
+	}
+}
+
+func (s *scheduledTransferService) settleScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, status entity.ScheduledTransferStatus, reason string) error {
+	executedAt := time.Now()
+	scheduled.Status = status
+	scheduled.FailureReason = reason
+	scheduled.ExecutedAt = &executedAt
+
+	if status == entity.ScheduledTransferFailed {
+		s.log.Warn(ctx, "scheduled transfer failed", logger.Attr("reason", reason))
+	}
+
+	updated, err := s.dm.ScheduledTransfer().UpdateScheduledTransferStatus(ctx, scheduled, entity.ScheduledTransferProcessing)
+	if err != nil {
+		s.log.Error(ctx, "error to settle scheduled transfer", logger.Err(err))
+		return err
+	}
+
+	if !updated {
+		err = fmt.Errorf("scheduled transfer %s is no longer %s", scheduled.ScheduledTransferUUID, entity.ScheduledTransferProcessing)
+		s.log.Error(ctx, "error to settle scheduled transfer", logger.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// retryScheduledTransfer puts the schedule back for another run later, under a
+// new transfer UUID since the failed transfer keeps the one it had.
+func (s *scheduledTransferService) retryScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, reason string) error {
+	scheduled.Retry(time.Now().Add(insufficientFundsRetryInterval), uuid.Must(uuid.NewV7()).String(), reason)
+
+	s.log.Warn(ctx, "scheduled transfer will be retried",
+		logger.Attr("reason", reason),
+		logger.Attr("retry", scheduled.Retries),
+		logger.Attr("max_retries", scheduled.MaxRetries),
+	)
+
+	return s.rescheduleScheduledTransfer(ctx, scheduled)
+}
+
+// requeueScheduledTransfer puts the schedule back for another run soon, without
+// using up a retry, under a new transfer UUID like retryScheduledTransfer.
+func (s *scheduledTransferService) requeueScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, reason string) error {
+	scheduled.Requeue(time.Now().Add(requeueInterval), uuid.Must(uuid.NewV7()).String(), reason)
+
+	s.log.Warn(ctx, "scheduled transfer will run again", logger.Attr("reason", reason))
+
+	return s.rescheduleScheduledTransfer(ctx, scheduled)
+}
+
+func (s *scheduledTransferService) rescheduleScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer) error {
+	updated, err := s.dm.ScheduledTransfer().RescheduleScheduledTransfer(ctx, scheduled, entity.ScheduledTransferProcessing)
+	if err != nil {
+		s.log.Error(ctx, "error to reschedule scheduled transfer", logger.Err(err))
+		return err
+	}
+
+	if !updated {
+		err = fmt.Errorf("scheduled transfer %s is no longer %s", scheduled.ScheduledTransferUUID, entity.ScheduledTransferProcessing)
+		s.log.Error(ctx, "error to reschedule scheduled transfer", logger.Err(err))
+		return err
+	}
+
+	return nil
+}
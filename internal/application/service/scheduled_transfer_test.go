@@ -0,0 +1,630 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestScheduledTransferService(m allMocks) *scheduledTransferService {
+	return newScheduledTransferService(m.mockDomain, m.mockAccountSvc, newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})).(*scheduledTransferService)
+}
+
+func Test_scheduledTransferService_ScheduleTransfer(t *testing.T) {
+	const destUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	validInput := dto.ScheduledTransferInput{
+		TransferInput: dto.TransferInput{
+			AccountDestinationUUID: destUUID,
+			Amount:                 entity.NewMoney(500, entity.BRL),
+		},
+		ScheduledFor: time.Now().Add(24 * time.Hour),
+	}
+
+	// toBeAdded matches the schedule as it is first recorded
+	toBeAdded := gomock.Cond(func(scheduled entity.ScheduledTransfer) bool {
+		return scheduled.ScheduledTransferUUID != "" &&
+			scheduled.TransferUUID != "" &&
+			scheduled.ScheduledTransferUUID != scheduled.TransferUUID &&
+			scheduled.AccountOriginID == 1 &&
+			scheduled.AccountDestinationID == 2 &&
+			scheduled.Amount == validInput.Amount &&
+			scheduled.Status == entity.ScheduledTransferPending
+	})
+
+	tests := []struct {
+		name      string
+		input     dto.ScheduledTransferInput
+		buildMock func(mocks allMocks)
+		wantErr   error
+	}{
+		{
+			name:  "Should schedule the transfer",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().AddScheduledTransfer(gomock.Any(), toBeAdded).Return(int64(3), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), gomock.Not("")).
+						DoAndReturn(func(_ context.Context, scheduledTransferUUID string) (entity.ScheduledTransfer, error) {
+							return entity.ScheduledTransfer{ID: 3, ScheduledTransferUUID: scheduledTransferUUID, Status: entity.ScheduledTransferPending}, nil
+						}).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return the schedule if it can't be read back",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().AddScheduledTransfer(gomock.Any(), toBeAdded).Return(int64(3), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), gomock.Not("")).
+						Return(entity.ScheduledTransfer{}, assert.AnError).Times(1),
+				)
+			},
+		},
+		{
+			name:  "Should return error if the destination account is not found",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).
+						Return(int64(0), apperr.ErrRecordNotFound).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrInvalidDestinationAccount,
+		},
+		{
+			name:  "Should return error if there is some error to add the scheduled transfer",
+			input: validInput,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().AddScheduledTransfer(gomock.Any(), toBeAdded).
+						Return(int64(0), assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name: "Should return error if the transfer is scheduled for the past",
+			input: dto.ScheduledTransferInput{
+				TransferInput: validInput.TransferInput,
+				ScheduledFor:  time.Now().Add(-time.Minute),
+			},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTestScheduledTransferService(m)
+
+			scheduled, err := s.ScheduleTransfer(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEmpty(t, scheduled.ScheduledTransferUUID)
+			require.Equal(t, entity.ScheduledTransferPending, scheduled.Status)
+		})
+	}
+}
+
+func Test_scheduledTransferService_GetScheduledTransfers(t *testing.T) {
+	tests := []struct {
+		name      string
+		buildMock func(mocks allMocks)
+		wantLen   int
+		wantErr   bool
+	}{
+		{
+			name: "Should return the logged account's scheduled transfers",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						GetScheduledTransfersByAccountID(gomock.Any(), int64(1), entity.ScheduledTransferPending, int64(10), int64(0)).
+						Return([]entity.ScheduledTransfer{{ID: 1}, {ID: 2}}, int64(2), nil).Times(1),
+				)
+			},
+			wantLen: 2,
+		},
+		{
+			name: "Should return error if the logged account can't be read",
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(0), assert.AnError).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error if there is some error to get the scheduled transfers",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						GetScheduledTransfersByAccountID(gomock.Any(), int64(1), entity.ScheduledTransferPending, int64(10), int64(0)).
+						Return(nil, int64(0), assert.AnError).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newTestScheduledTransferService(m)
+
+			scheduled, totalRecords, err := s.GetScheduledTransfers(ctx, entity.ScheduledTransferPending, 10, 0)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, scheduled, tt.wantLen)
+			require.Equal(t, int64(tt.wantLen), totalRecords)
+		})
+	}
+}
+
+func Test_scheduledTransferService_CancelScheduledTransfer(t *testing.T) {
+	const scheduledUUID = "0190f7a4-52d1-7a3c-9f5e-2b6c8d1e4a70"
+
+	scheduled := entity.ScheduledTransfer{
+		ID:                    3,
+		ScheduledTransferUUID: scheduledUUID,
+		AccountOriginID:       1,
+		Status:                entity.ScheduledTransferPending,
+	}
+
+	tests := []struct {
+		name          string
+		scheduledUUID string
+		buildMock     func(mocks allMocks)
+		wantErr       error
+	}{
+		{
+			name:          "Should cancel a pending scheduled transfer",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).Return(scheduled, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfer(gomock.Any(), int64(3)).Return(true, nil).Times(1),
+				)
+			},
+		},
+		{
+			name:          "Should return error if a worker claimed it before the cancel",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).Return(scheduled, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfer(gomock.Any(), int64(3)).Return(false, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrScheduledTransferNotCancelable,
+		},
+		{
+			name:          "Should return error if the scheduled transfer already ran",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				completed := scheduled
+				completed.Status = entity.ScheduledTransferCompleted
+
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).Return(completed, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrScheduledTransferNotCancelable,
+		},
+		{
+			name:          "Should not cancel someone else's scheduled transfer",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).Return(scheduled, nil).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrScheduledTransferNotFound,
+		},
+		{
+			name:          "Should return error when the scheduled transfer doesn't exist",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).
+						Return(entity.ScheduledTransfer{}, apperr.ErrRecordNotFound).Times(1),
+				)
+			},
+			wantErr: errcodes.ErrScheduledTransferNotFound,
+		},
+		{
+			name:          "Should return error if there is some error to cancel",
+			scheduledUUID: scheduledUUID,
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().GetScheduledTransferByUUID(gomock.Any(), scheduledUUID).Return(scheduled, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().CancelScheduledTransfer(gomock.Any(), int64(3)).Return(false, assert.AnError).Times(1),
+				)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:          "Should return error when the scheduled transfer uuid is invalid",
+			scheduledUUID: "invalid",
+			wantErr:       apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTestScheduledTransferService(m)
+
+			err := s.CancelScheduledTransfer(ctx, tt.scheduledUUID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_scheduledTransferService_ExecuteDueTransfers(t *testing.T) {
+	const transferUUID = "0190f7a4-52d1-7a3c-9f5e-2b6c8d1e4a71"
+
+	scheduled := entity.ScheduledTransfer{
+		ID:                    3,
+		ScheduledTransferUUID: "0190f7a4-52d1-7a3c-9f5e-2b6c8d1e4a70",
+		TransferUUID:          transferUUID,
+		AccountOriginID:       1,
+		AccountDestinationID:  2,
+		Amount:                entity.NewMoney(500, entity.BRL),
+		Status:                entity.ScheduledTransferProcessing,
+	}
+
+	// settled matches the schedule being settled as status
+	settled := func(status entity.ScheduledTransferStatus, reason string) gomock.Matcher {
+		return gomock.Cond(func(s entity.ScheduledTransfer) bool {
+			return s.ID == scheduled.ID &&
+				s.Status == status &&
+				s.FailureReason == reason &&
+				s.ExecutedAt != nil
+		})
+	}
+
+	// requeued matches the schedule being put back to run again soon, without
+	// using up a retry
+	requeued := func(reason string) gomock.Matcher {
+		return gomock.Cond(func(s entity.ScheduledTransfer) bool {
+			return s.ID == scheduled.ID &&
+				s.Status == entity.ScheduledTransferPending &&
+				s.Retries == scheduled.Retries &&
+				s.FailureReason == reason &&
+				s.TransferUUID != transferUUID &&
+				s.ScheduledFor.Before(time.Now().Add(insufficientFundsRetryInterval))
+		})
+	}
+
+	claim := func(mocks allMocks, claimed ...entity.ScheduledTransfer) *gomock.Call {
+		return mocks.mockScheduledTransferRepo.EXPECT().ClaimDueScheduledTransfers(gomock.Any(), int64(10), gomock.Any()).
+			Return(claimed, nil).Times(1)
+	}
+
+	tests := []struct {
+		name         string
+		buildMock    func(mocks allMocks)
+		wantExecuted int
+		wantErr      bool
+	}{
+		{
+			name: "Should make the transfer and complete the schedule",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Cond(func(transfer entity.Transfer) bool {
+						return transfer.TransferUUID == transferUUID && transfer.Status == entity.TransferPending
+					})).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(500, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), gomock.Any()).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), gomock.Any()).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferCompleted}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferCompleted, ""), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should fail the schedule when the account can't afford it",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(100, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferFailed, errcodes.ErrInsufficientFunds.Code), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should fail the schedule when its account was deactivated",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: false, Balance: entity.NewMoney(500, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferFailed, errcodes.ErrOriginAccountDeactivated.Code), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should put the schedule back for a retry when the account can't afford it",
+			buildMock: func(mocks allMocks) {
+				retrying := scheduled
+				retrying.MaxRetries = 2
+				retrying.Retries = 1
+
+				gomock.InOrder(
+					claim(mocks, retrying),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(100, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().RescheduleScheduledTransfer(gomock.Any(), gomock.Cond(func(s entity.ScheduledTransfer) bool {
+						return s.Status == entity.ScheduledTransferPending &&
+							s.Retries == 2 &&
+							s.TransferUUID != transferUUID &&
+							s.ScheduledFor.After(time.Now())
+					}), entity.ScheduledTransferProcessing).Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should fail the schedule when it ran out of retries",
+			buildMock: func(mocks allMocks) {
+				exhausted := scheduled
+				exhausted.MaxRetries = 2
+				exhausted.Retries = 2
+
+				gomock.InOrder(
+					claim(mocks, exhausted),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(100, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferFailed, errcodes.ErrInsufficientFunds.Code), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should settle with the transfer a previous claim already made",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferCompleted}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferCompleted, ""), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should run the schedule again when its transfer was rolled back for an error of the system",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return(nil, assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						RescheduleScheduledTransfer(gomock.Any(), requeued(transferFailedReason), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should leave the schedule claimed when its transfer may have gone through",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+					withFailedCommit(mocks, assert.AnError),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(500, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), gomock.Any()).Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), gomock.Any()).Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1),
+					// the commit went through after all, so the transfer can't be failed
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(false, nil).Times(1),
+				)
+			},
+			wantExecuted: 0,
+		},
+		{
+			name: "Should fail the transfer of an interrupted claim and run the schedule again",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferPending}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Cond(func(transfer entity.Transfer) bool {
+						return transfer.Status == entity.TransferFailed && transfer.FailureReason == interruptedExecutionReason
+					}), entity.TransferPending).Return(true, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						RescheduleScheduledTransfer(gomock.Any(), requeued(interruptedExecutionReason), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should run the schedule again when a previous claim's transfer failed without a decline",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferFailed, FailureReason: transferFailedReason}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						RescheduleScheduledTransfer(gomock.Any(), requeued(transferFailedReason), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should fail the schedule when a previous claim's transfer was declined",
+			buildMock: func(mocks allMocks) {
+				gomock.InOrder(
+					claim(mocks, scheduled),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferFailed, FailureReason: errcodes.ErrInsufficientFunds.Code}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), settled(entity.ScheduledTransferFailed, errcodes.ErrInsufficientFunds.Code), entity.ScheduledTransferProcessing).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Should go on with the batch when a schedule can't be settled",
+			buildMock: func(mocks allMocks) {
+				other := scheduled
+				other.ID = 4
+				other.TransferUUID = "0190f7a4-52d1-7a3c-9f5e-2b6c8d1e4a72"
+
+				gomock.InOrder(
+					claim(mocks, scheduled, other),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+						Return(entity.Transfer{}, assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), other.TransferUUID).
+						Return(entity.Transfer{ID: 8, TransferUUID: other.TransferUUID, Status: entity.TransferCompleted}, nil).Times(1),
+					mocks.mockScheduledTransferRepo.EXPECT().
+						UpdateScheduledTransferStatus(gomock.Any(), gomock.Any(), entity.ScheduledTransferProcessing).
+						Return(false, nil).Times(1),
+				)
+			},
+			wantExecuted: 0,
+		},
+		{
+			name: "Should return error if the batch can't be claimed",
+			buildMock: func(mocks allMocks) {
+				mocks.mockScheduledTransferRepo.EXPECT().ClaimDueScheduledTransfers(gomock.Any(), int64(10), gomock.Any()).
+					Return(nil, assert.AnError).Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			tt.buildMock(m)
+
+			s := newTestScheduledTransferService(m)
+
+			executed, err := s.ExecuteDueTransfers(ctx, 10)
+			if tt.wantErr {
+				require.ErrorIs(t, err, assert.AnError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantExecuted, executed)
+		})
+	}
+}
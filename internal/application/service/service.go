@@ -1,31 +1,49 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/diegoclair/go_boilerplate/internal/domain"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 )
 
 type Apps struct {
-	AccountService  contract.AccountApp
-	AuthService     contract.AuthApp
-	TransferService contract.TransferApp
+	AccountService     contract.AccountApp
+	AuthService        contract.AuthApp
+	IdempotencyService contract.IdempotencyApp
+	TransferService    contract.TransferApp
+
+	RecurringTransferService contract.RecurringTransferApp
+	ScheduledTransferService contract.ScheduledTransferApp
 }
 
-// New to get instance of all services
-func New(infra domain.Infrastructure, accessTokenDuration time.Duration) (*Apps, error) {
+// New to get instance of all services. passwordResetDuration is how long a
+// password reset token lasts, and transferLimits are the limits of the
+// accounts that don't override them. loginThrottle is how failed logins are
+// slowed down and locked out, and mfaIssuer is the name authenticator apps
+// list the account under. maxSessions is how many sessions an account can have
+// at once, zero for no cap.
+func New(infra domain.Infrastructure, accessTokenDuration, passwordResetDuration time.Duration, transferLimits entity.TransferLimits,
+	loginThrottle entity.LoginThrottle, mfaIssuer string, maxSessions int64) (*Apps, error) {
 	if err := validateInfrastructure(infra); err != nil {
 		return nil, err
 	}
 
-	accSvc := newAccountService(infra)
+	accSvc := newAccountService(infra, accessTokenDuration)
+	transferSvc := newTransferService(infra, accSvc, transferLimits)
 
 	return &Apps{
-		AccountService:  accSvc,
-		AuthService:     newAuthApp(infra, accSvc, accessTokenDuration),
-		TransferService: newTransferService(infra, accSvc),
+		AccountService:     accSvc,
+		AuthService:        newAuthApp(infra, accSvc, accessTokenDuration, passwordResetDuration, loginThrottle, mfaIssuer, maxSessions),
+		IdempotencyService: newIdempotencyService(infra),
+		TransferService:    transferSvc,
+
+		RecurringTransferService: newRecurringTransferService(infra, accSvc, transferSvc),
+		ScheduledTransferService: newScheduledTransferService(infra, accSvc, transferSvc),
 	}, nil
 }
 
@@ -47,9 +65,29 @@ func validateInfrastructure(infra domain.Infrastructure) error {
 		return errors.New("crypto is required")
 	}
 
+	if infra.Notifier() == nil {
+		return errors.New("notifier is required")
+	}
+
 	if infra.Validator() == nil {
 		return errors.New("validator is required")
 	}
 
 	return nil
 }
+
+// withRollback runs fn in a transaction, for the services behind idempotent
+// routes. An error of fn is marked unapplied, as the transaction was rolled
+// back; a failed commit is not, the changes may have been kept all the same.
+func withRollback(ctx context.Context, dm contract.DataManager, fn func(tx contract.Repos) error) error {
+	var fnErr error
+	err := dm.WithTransaction(ctx, func(tx contract.Repos) error {
+		fnErr = fn(tx)
+		return fnErr
+	})
+	if err != nil && fnErr != nil {
+		return errcodes.NewUnappliedError(err)
+	}
+
+	return err
+}
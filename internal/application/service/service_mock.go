@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/diegoclair/go_boilerplate/infra/configmock"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/mocks"
 	"github.com/diegoclair/logger"
 	"github.com/diegoclair/appvalidator/apperrmap"
@@ -15,11 +18,17 @@ import (
 type allMocks struct {
 	mockDataManager *mocks.MockDataManager
 
-	mockAuthRepo    *mocks.MockAuthRepo
-	mockAccountRepo *mocks.MockAccountRepo
+	mockAuthRepo        *mocks.MockAuthRepo
+	mockAccountRepo     *mocks.MockAccountRepo
+	mockIdempotencyRepo *mocks.MockIdempotencyRepo
+	mockLedgerRepo      *mocks.MockLedgerRepo
+
+	mockRecurringTransferRepo *mocks.MockRecurringTransferRepo
+	mockScheduledTransferRepo *mocks.MockScheduledTransferRepo
 
 	mockCacheManager *mocks.MockCacheManager
 	mockCrypto       *mocks.MockCrypto
+	mockNotifier     *mocks.MockNotifier
 	mockValidator    apperrmap.Validator
 	mockLogger       logger.Logger
 
@@ -42,8 +51,21 @@ func newServiceTestMock(t *testing.T) (m allMocks, ctrl *gomock.Controller) {
 	authRepo := mocks.NewMockAuthRepo(ctrl)
 	dm.EXPECT().Auth().Return(authRepo).AnyTimes()
 
+	idempotencyRepo := mocks.NewMockIdempotencyRepo(ctrl)
+	dm.EXPECT().Idempotency().Return(idempotencyRepo).AnyTimes()
+
+	ledgerRepo := mocks.NewMockLedgerRepo(ctrl)
+	dm.EXPECT().Ledger().Return(ledgerRepo).AnyTimes()
+
+	recurringTransferRepo := mocks.NewMockRecurringTransferRepo(ctrl)
+	dm.EXPECT().RecurringTransfer().Return(recurringTransferRepo).AnyTimes()
+
+	scheduledTransferRepo := mocks.NewMockScheduledTransferRepo(ctrl)
+	dm.EXPECT().ScheduledTransfer().Return(scheduledTransferRepo).AnyTimes()
+
 	cm := cfg.GetCacheManager(ctrl)
 	crypto := cfg.GetCrypto(ctrl)
+	notifier := cfg.GetNotifier(ctrl)
 	log := cfg.GetLogger()
 	v := cfg.GetValidator(t)
 
@@ -54,24 +76,54 @@ func newServiceTestMock(t *testing.T) (m allMocks, ctrl *gomock.Controller) {
 	domainMock.EXPECT().Logger().Return(log).AnyTimes()
 	domainMock.EXPECT().CacheManager().Return(cm).AnyTimes()
 	domainMock.EXPECT().Crypto().Return(crypto).AnyTimes()
+	domainMock.EXPECT().Notifier().Return(notifier).AnyTimes()
 	domainMock.EXPECT().Validator().Return(v).AnyTimes()
 
 	m = allMocks{
-		mockDataManager:  dm,
-		mockAccountRepo:  accountRepo,
-		mockCacheManager: cm,
-		mockAuthRepo:     authRepo,
-		mockCrypto:       crypto,
-		mockAccountSvc:   accountSvc,
-		mockDomain:       domainMock,
-		mockValidator:    v,
-		mockLogger:       log,
+		mockDataManager:     dm,
+		mockAccountRepo:     accountRepo,
+		mockCacheManager:    cm,
+		mockAuthRepo:        authRepo,
+		mockIdempotencyRepo: idempotencyRepo,
+		mockLedgerRepo:      ledgerRepo,
+
+		mockRecurringTransferRepo: recurringTransferRepo,
+		mockScheduledTransferRepo: scheduledTransferRepo,
+		mockCrypto:          crypto,
+		mockNotifier:        notifier,
+		mockAccountSvc:      accountSvc,
+		mockDomain:          domainMock,
+		mockValidator:       v,
+		mockLogger:          log,
 	}
 
 	// validate func New
-	s, err := New(domainMock, time.Minute)
+	s, err := New(domainMock, time.Minute, time.Minute, entity.TransferLimits{}, entity.LoginThrottle{}, "test", 0)
 	require.NoError(t, err)
 	require.NotNil(t, s)
 
 	return
 }
+
+// withTransaction runs the callback against the mocked repositories, the way
+// WithTransaction does against the transaction's.
+func withTransaction(m allMocks) *gomock.Call {
+	return m.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(r contract.Repos) error) error {
+			return fn(m.mockDataManager)
+		},
+	).Times(1)
+}
+
+// withFailedCommit runs the callback like withTransaction, but fails with err
+// once it returns, as a transaction that can't commit.
+func withFailedCommit(m allMocks, err error) *gomock.Call {
+	return m.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(r contract.Repos) error) error {
+			if fnErr := fn(m.mockDataManager); fnErr != nil {
+				return fnErr
+			}
+			return err
+		},
+	).Times(1)
+}
@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -13,7 +17,7 @@ func TestNew(t *testing.T) {
 		m, ctrl := newServiceTestMock(t)
 		defer ctrl.Finish()
 
-		apps, err := New(m.mockDomain, time.Hour)
+		apps, err := New(m.mockDomain, time.Hour, time.Hour, entity.TransferLimits{}, entity.LoginThrottle{}, "test", 0)
 		assert.NoError(t, err)
 		assert.NotNil(t, apps)
 	})
@@ -24,7 +28,7 @@ func TestNew(t *testing.T) {
 		m.mockDomain.EXPECT().Logger().Return(nil)
 		defer ctrl.Finish()
 
-		apps, err := New(m.mockDomain, time.Hour)
+		apps, err := New(m.mockDomain, time.Hour, time.Hour, entity.TransferLimits{}, entity.LoginThrottle{}, "test", 0)
 		assert.Error(t, err)
 		assert.Nil(t, apps)
 	})
@@ -48,6 +52,7 @@ func TestValidateInfrastructure(t *testing.T) {
 				m.mockDomain.EXPECT().DataManager().Return(m.mockDataManager)
 				m.mockDomain.EXPECT().CacheManager().Return(m.mockCacheManager)
 				m.mockDomain.EXPECT().Crypto().Return(m.mockCrypto)
+				m.mockDomain.EXPECT().Notifier().Return(m.mockNotifier)
 				m.mockDomain.EXPECT().Validator().Return(m.mockValidator)
 			},
 			wantErr: "",
@@ -86,6 +91,17 @@ func TestValidateInfrastructure(t *testing.T) {
 			},
 			wantErr: "crypto is required",
 		},
+		{
+			name: "Missing notifier",
+			setup: func(m allMocks) {
+				m.mockDomain.EXPECT().Logger().Return(m.mockLogger)
+				m.mockDomain.EXPECT().DataManager().Return(m.mockDataManager)
+				m.mockDomain.EXPECT().CacheManager().Return(m.mockCacheManager)
+				m.mockDomain.EXPECT().Crypto().Return(m.mockCrypto)
+				m.mockDomain.EXPECT().Notifier().Return(nil)
+			},
+			wantErr: "notifier is required",
+		},
 		{
 			name: "Missing validator",
 			setup: func(m allMocks) {
@@ -93,6 +109,7 @@ func TestValidateInfrastructure(t *testing.T) {
 				m.mockDomain.EXPECT().DataManager().Return(m.mockDataManager)
 				m.mockDomain.EXPECT().CacheManager().Return(m.mockCacheManager)
 				m.mockDomain.EXPECT().Crypto().Return(m.mockCrypto)
+				m.mockDomain.EXPECT().Notifier().Return(m.mockNotifier)
 				m.mockDomain.EXPECT().Validator().Return(nil)
 			},
 			wantErr: "validator is required",
@@ -116,3 +133,45 @@ func TestValidateInfrastructure(t *testing.T) {
 		})
 	}
 }
+
+func TestWithRollback(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should mark the error of the callback as unapplied", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		withTransaction(m)
+
+		err := withRollback(ctx, m.mockDataManager, func(tx contract.Repos) error {
+			return errcodes.ErrInsufficientFunds
+		})
+		assert.True(t, errcodes.IsUnapplied(err))
+		assert.ErrorIs(t, err, errcodes.ErrInsufficientFunds)
+	})
+
+	t.Run("Should not mark a failed commit as unapplied", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		withFailedCommit(m, assert.AnError)
+
+		err := withRollback(ctx, m.mockDataManager, func(tx contract.Repos) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, errcodes.IsUnapplied(err))
+	})
+
+	t.Run("Should return nil when the transaction commits", func(t *testing.T) {
+		m, ctrl := newServiceTestMock(t)
+		defer ctrl.Finish()
+
+		withTransaction(m)
+
+		err := withRollback(ctx, m.mockDataManager, func(tx contract.Repos) error {
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
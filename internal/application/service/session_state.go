@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/diegoclair/apperr"
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/infra/cache"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/logger"
+)
+
+const (
+	sessionStateActive  = "active"
+	sessionStateRevoked = "revoked"
+)
+
+// sessionStateTTL is how long the state of a session read from the database
+// is cached. The sessions blocked through the services are marked revoked as
+// soon as the block commits, so it only bounds how long an active state can
+// outlive a block made some other way, or one whose mark failed.
+const sessionStateTTL = time.Minute
+
+func (s *authApp) IsSessionActive(ctx context.Context, sessionUUID string) (active bool, err error) {
+	key := infra.SessionStateKey(sessionUUID)
+
+	state, err := s.cache.GetString(ctx, key)
+	if err == nil {
+		return state == sessionStateActive, nil
+	}
+
+	// with the cache down every request would fall on the database, so it
+	// is left to the caller to decide what to do without the state
+	if !errors.Is(err, cache.ErrCacheMiss) {
+		s.log.Error(ctx, "error getting session state", logger.Err(err))
+		return false, err
+	}
+
+	session, err := s.dm.Auth().GetSessionByUUID(ctx, sessionUUID)
+	if err != nil && !apperr.IsNotFound(err) {
+		s.log.Error(ctx, "error getting session by uuid", logger.Err(err))
+		return false, err
+	}
+
+	active = err == nil && !session.IsBlocked
+
+	if active {
+		// the session may have been revoked since it was read, and the
+		// revoked state written then must not be overwritten
+		_, err = s.cache.SetNX(ctx, key, sessionStateActive, sessionStateTTL)
+	} else {
+		err = s.cache.Set(ctx, key, sessionStateRevoked, sessionStateTTL)
+	}
+	if err != nil {
+		s.log.Error(ctx, "error setting session state", logger.Err(err))
+	}
+
+	return active, nil
+}
+
+// markSessionsRevoked writes the revoked state of the sessions to the cache,
+// for as long as the access tokens they handed out can live, so those are
+// refused from the next request on. It runs once the sessions are blocked for
+// good, after the commit, so a rollback never leaves a session revoked in the
+// cache alone. A failure is only logged: the block stands, and an active
+// state cached before it outlives it by sessionStateTTL at most.
+func markSessionsRevoked(ctx context.Context, cache contract.CacheManager, log logger.Logger, accessTokenDuration time.Duration, sessionUUIDs []string) {
+	for _, sessionUUID := range sessionUUIDs {
+		err := cache.Set(ctx, infra.SessionStateKey(sessionUUID), sessionStateRevoked, accessTokenDuration+accessTokenGrace)
+		if err != nil {
+			log.Error(ctx, "error marking session as revoked", logger.Attr("session_uuid", sessionUUID), logger.Err(err))
+		}
+	}
+}
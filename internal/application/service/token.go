@@ -0,0 +1,12 @@
+package service
+
+import (
+	"crypto/rand"
+)
+
+// newOpaqueToken returns a random token to hand out once, as a password reset
+// token. It means nothing by itself: it is only good for finding its hash,
+// which is all that is stored of it.
+func newOpaqueToken() string {
+	return rand.Text()
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
@@ -19,103 +22,560 @@ type transferService struct {
 	dm         contract.DataManager
 	log        logger.Logger
 	validator  apperrmap.Validator
+	limits     entity.TransferLimits
 }
 
-func newTransferService(infra domain.Infrastructure, accountSvc contract.AccountApp) *transferService {
+// newTransferService takes the limits of the accounts without an override of
+// their own.
+func newTransferService(infra domain.Infrastructure, accountSvc contract.AccountApp, limits entity.TransferLimits) *transferService {
 	return &transferService{
 		accountSvc: accountSvc,
 		dm:         infra.DataManager(),
 		log:        infra.Logger(),
 		validator:  infra.Validator(),
+		limits:     limits,
 	}
 }
 
-func (s *transferService) CreateTransfer(ctx context.Context, input dto.TransferInput) (err error) {
+// CreateTransfer records the transfer as pending before moving any money, so a
+// declined transfer is left as failed, with its reason, instead of leaving no
+// trace.
+func (s *transferService) CreateTransfer(ctx context.Context, input dto.TransferInput) (created entity.Transfer, err error) {
 	transfer, err := input.ToEntityValidate(ctx, s.validator)
 	if err != nil {
 		s.log.Error(ctx, "error or invalid input", logger.Err(err))
-		return err
+		return created, err
 	}
 
 	ctx = logger.WithAttrs(ctx, logger.Attr("destination_account_uuid", transfer.AccountDestinationUUID))
 
-	fromAccount, err := s.accountSvc.GetLoggedAccount(ctx)
+	transfer, err = s.resolveTransferAccounts(ctx, transfer)
 	if err != nil {
-		s.log.Error(ctx, "error to get logged account", logger.Err(err))
-		return err
+		return created, err
+	}
+
+	transfer.TransferUUID = uuid.Must(uuid.NewV7()).String()
+	ctx = logger.WithAttrs(ctx, logger.Attr("transfer_uuid", transfer.TransferUUID))
+
+	return s.executeTransfer(ctx, transfer)
+}
+
+// resolveTransferAccounts fills in the IDs of the logged account, as origin,
+// and of the destination, refusing the destinations no transfer can go to.
+func (s *transferService) resolveTransferAccounts(ctx context.Context, transfer entity.Transfer) (resolved entity.Transfer, err error) {
+	if transfer.AccountDestinationUUID == entity.FundingAccountUUID {
+		return resolved, errcodes.ErrInvalidDestinationAccount
 	}
 
-	if !fromAccount.HasSufficientFunds(transfer.Amount) {
-		return errcodes.ErrInsufficientFunds
+	fromAccountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return resolved, errcodes.NewUnappliedError(err)
 	}
 
-	destAccount, err := s.dm.Account().GetAccountByUUID(ctx, transfer.AccountDestinationUUID)
+	destAccountID, err := s.dm.Account().GetAccountIDByUUID(ctx, transfer.AccountDestinationUUID)
 	if err != nil {
 		if apperr.IsNotFound(err) {
 			s.log.Error(ctx, "destination account not found", logger.Err(err))
-			return errcodes.ErrInvalidDestinationAccount
+			return resolved, errcodes.ErrInvalidDestinationAccount
 		}
-		s.log.Error(ctx, "error to get destination account by uuid", logger.Err(err))
+		s.log.Error(ctx, "error to get destination account id by uuid", logger.Err(err))
+		return resolved, errcodes.NewUnappliedError(err)
+	}
+
+	if fromAccountID == destAccountID {
+		return resolved, errcodes.ErrSelfTransfer
+	}
+
+	transfer.AccountOriginID = fromAccountID
+	transfer.AccountDestinationID = destAccountID
+	return transfer, nil
+}
+
+// executeTransfer makes transfer, whose UUID and account IDs are already set,
+// and returns it as it ended up.
+func (s *transferService) executeTransfer(ctx context.Context, transfer entity.Transfer) (created entity.Transfer, err error) {
+	fromAccountID := transfer.AccountOriginID
+	destAccountID := transfer.AccountDestinationID
+	transfer.Status = entity.TransferPending
+
+	// committed on its own: the transaction below rolls back on a decline, and
+	// the failure still has to be recorded against this row
+	transfer.ID, err = s.dm.Account().AddTransfer(ctx, transfer)
+	if err != nil {
+		s.log.Error(ctx, "error to add transfer", logger.Err(err))
+		return created, err
+	}
+
+	completed := transfer
+	err = withRollback(ctx, s.dm, func(tx contract.Repos) error {
+		// The balance is only read once both rows are locked: a balance read
+		// before the transaction could be spent by a concurrent transfer in
+		// between the check and the debit.
+		fromAccount, err := s.lockTransferAccounts(ctx, tx, fromAccountID, destAccountID)
+		if err != nil {
+			return err
+		}
+
+		// the lock on the origin also holds back its other transfers, so the
+		// usage can't change until this one commits
+		err = s.checkTransferLimits(ctx, tx, transfer)
+		if err != nil {
+			return err
+		}
+
+		if !fromAccount.HasSufficientFunds(transfer.Amount) {
+			return errcodes.ErrInsufficientFunds
+		}
+
+		// both postings share the transfer UUID as their journal
+		posting := entity.LedgerEntry{
+			JournalUUID: transfer.TransferUUID,
+			TransferID:  transfer.ID,
+			Type:        entity.LedgerEntryTransfer,
+			Amount:      transfer.Amount,
+		}
+
+		posting.AccountID = fromAccountID
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
+		if err != nil {
+			s.log.Error(ctx, "error to debit origin account balance", logger.Err(err))
+			return err
+		}
+
+		if !debited {
+			return errcodes.ErrInsufficientFunds
+		}
+
+		posting.AccountID = destAccountID
+		err = tx.Account().CreditAccountBalance(ctx, posting)
+		if err != nil {
+			s.log.Error(ctx, "error to credit destination account balance", logger.Err(err))
+			return err
+		}
+
+		err = completed.TransitionTo(entity.TransferCompleted, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return s.updateTransferStatus(ctx, tx, completed, entity.TransferPending)
+	})
+	if err != nil {
+		// the rollback undid any posting, so nothing moved whatever went wrong
+		s.failTransfer(ctx, transfer, transferFailureReason(err))
+		return created, err
+	}
+
+	created, err = s.dm.Account().GetTransferByUUID(ctx, transfer.TransferUUID)
+	if err != nil {
+		// the money moved, so this is no reason to fail the request
+		s.log.Error(ctx, "error to read back created transfer", logger.Err(err))
+		return completed, nil
+	}
+
+	return created, nil
+}
+
+// checkTransferLimits refuses a transfer over a limit of its origin account,
+// saying how much of that limit is left. It must run with the origin locked.
+func (s *transferService) checkTransferLimits(ctx context.Context, tx contract.Repos, transfer entity.Transfer) error {
+	limits := s.limits
+
+	override, err := tx.Account().GetAccountLimit(ctx, transfer.AccountOriginID)
+	switch {
+	case apperr.IsNotFound(err):
+	case err != nil:
+		s.log.Error(ctx, "error to get account limit", logger.Err(err))
 		return err
+	default:
+		limits = limits.WithOverride(override)
 	}
 
-	if fromAccount.ID == destAccount.ID {
-		return errcodes.ErrSelfTransfer
+	periods := limits.Periods(time.Now())
+
+	var usage entity.TransferUsage
+	if limits.IsCumulative() {
+		usage, err = tx.Account().GetTransferUsage(ctx, transfer.AccountOriginID, transfer.Amount.Currency(), periods)
+		if err != nil {
+			s.log.Error(ctx, "error to get transfer usage", logger.Err(err))
+			return err
+		}
 	}
 
-	transfer.TransferUUID = uuid.Must(uuid.NewV7()).String()
-	ctx = logger.WithAttrs(ctx, logger.Attr("transfer_uuid", transfer.TransferUUID))
+	exceeded, remaining, err := limits.Check(transfer.Amount, usage, periods)
+	if err != nil {
+		s.log.Error(ctx, "error to check transfer limits", logger.Err(err))
+		return err
+	}
+
+	switch exceeded {
+	case entity.TransferLimitPerTransaction:
+		return errcodes.ErrTransferPerTransactionLimitExceeded.WithMessage(
+			fmt.Sprintf("the amount is over the limit of %s for a single transfer", remaining))
+	case entity.TransferLimitNightTime:
+		return errcodes.ErrTransferNightTimeLimitExceeded.WithMessage(
+			fmt.Sprintf("the amount is over the night-time transfer limit, %s is left for tonight", remaining))
+	case entity.TransferLimitDaily:
+		return errcodes.ErrTransferDailyLimitExceeded.WithMessage(
+			fmt.Sprintf("the amount is over the daily transfer limit, %s is left for today", remaining))
+	case entity.TransferLimitMonthly:
+		return errcodes.ErrTransferMonthlyLimitExceeded.WithMessage(
+			fmt.Sprintf("the amount is over the monthly transfer limit, %s is left for this month", remaining))
+	}
+
+	return nil
+}
+
+// transferFailedReason is recorded for a transfer that failed on anything
+// other than one of transferDeclines.
+const transferFailedReason = "TRANSFER_FAILED"
+
+// transferDeclines are the errors a transfer is refused with whose code may be
+// recorded as its failure reason. Other errors, from the database for one,
+// could leak details of the system, so their text only goes to the logs.
+var transferDeclines = []*apperr.Error{
+	errcodes.ErrInsufficientFunds,
+	errcodes.ErrAccountClosed,
+	errcodes.ErrOriginAccountDeactivated,
+	errcodes.ErrInvalidDestinationAccount,
+	errcodes.ErrDestinationAccountClosed,
+	errcodes.ErrTransferPerTransactionLimitExceeded,
+	errcodes.ErrTransferDailyLimitExceeded,
+	errcodes.ErrTransferMonthlyLimitExceeded,
+	errcodes.ErrTransferNightTimeLimitExceeded,
+}
+
+// transferFailureReason is the code recorded as the failure reason of a
+// transfer that failed with err.
+func transferFailureReason(err error) string {
+	for _, decline := range transferDeclines {
+		if errors.Is(err, decline) {
+			return decline.Code
+		}
+	}
+
+	return transferFailedReason
+}
 
-	return s.dm.WithTransaction(ctx, func(tx contract.Repos) error {
+// failTransfer records why a pending transfer didn't go through. The caller
+// returns the original error either way, so a failure here is only logged.
+func (s *transferService) failTransfer(ctx context.Context, transfer entity.Transfer, reason string) {
+	// the request may be gone already, and the record is what support reads
+	ctx = context.WithoutCancel(ctx)
 
-		_, err = tx.Account().AddTransfer(ctx, transfer.TransferUUID, fromAccount.ID, destAccount.ID, transfer.Amount)
+	err := transfer.Fail(reason, time.Now())
+	if err != nil {
+		s.log.Error(ctx, "error to fail transfer", logger.Err(err))
+		return
+	}
+
+	err = s.updateTransferStatus(ctx, s.dm, transfer, entity.TransferPending)
+	if err != nil {
+		s.log.Error(ctx, "error to record failed transfer", logger.Err(err))
+	}
+}
+
+// updateTransferStatus writes a transition the entity already allowed, and
+// fails if the row moved out of from in the meantime.
+func (s *transferService) updateTransferStatus(ctx context.Context, repos contract.Repos, transfer entity.Transfer, from entity.TransferStatus) error {
+	updated, err := repos.Account().UpdateTransferStatus(ctx, transfer, from)
+	if err != nil {
+		s.log.Error(ctx, "error to update transfer status", logger.Err(err))
+		return err
+	}
+
+	if !updated {
+		err = fmt.Errorf("transfer %s is no longer %s", transfer.TransferUUID, from)
+		s.log.Error(ctx, "error to update transfer status", logger.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetTransferByUUID returns a transfer the logged account sent or received.
+func (s *transferService) GetTransferByUUID(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error) {
+	ctx = logger.WithAttrs(ctx, logger.Attr("transfer_uuid", transferUUID))
+
+	if _, err = uuid.Parse(transferUUID); err != nil {
+		return transfer, apperr.ErrInvalidInput.WithMessage("invalid transfer_uuid")
+	}
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return transfer, err
+	}
+
+	transfer, err = s.dm.Account().GetTransferByUUID(ctx, transferUUID)
+	if err != nil {
+		if apperr.IsNotFound(err) {
+			return transfer, errcodes.ErrTransferNotFound
+		}
+		s.log.Error(ctx, "error to get transfer by uuid", logger.Err(err))
+		return transfer, err
+	}
+
+	// someone else's transfer is reported as missing, not as forbidden
+	if transfer.AccountOriginID != accountID && transfer.AccountDestinationID != accountID {
+		return entity.Transfer{}, errcodes.ErrTransferNotFound
+	}
+
+	return transfer, nil
+}
+
+// ReverseTransfer gives back all or part of a transfer the logged account
+// received, as a new transfer in the opposite direction linked to the original.
+// Only the destination can reverse: it is the account that pays for it.
+func (s *transferService) ReverseTransfer(ctx context.Context, input dto.TransferReversalInput) (reversal entity.Transfer, err error) {
+	err = input.Validate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return reversal, err
+	}
+
+	ctx = logger.WithAttrs(ctx, logger.Attr("reversed_transfer_uuid", input.TransferUUID))
+
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return reversal, errcodes.NewUnappliedError(err)
+	}
+
+	reversal.TransferUUID = uuid.Must(uuid.NewV7()).String()
+	ctx = logger.WithAttrs(ctx, logger.Attr("transfer_uuid", reversal.TransferUUID))
+
+	err = withRollback(ctx, s.dm, func(tx contract.Repos) error {
+		// the lock on the original serializes its reversals, so the amount
+		// left to reverse can't change until this one commits
+		original, err := tx.Account().GetTransferByUUIDForUpdate(ctx, input.TransferUUID)
+		if err != nil {
+			if apperr.IsNotFound(err) {
+				return errcodes.ErrTransferNotFound
+			}
+			s.log.Error(ctx, "error to get transfer to reverse", logger.Err(err))
+			return err
+		}
+
+		// someone else's transfer is reported as missing, not as forbidden
+		if original.AccountDestinationID != accountID {
+			return errcodes.ErrTransferNotFound
+		}
+
+		if original.IsReversal() {
+			return errcodes.ErrReversalNotReversible
+		}
+
+		switch original.Status {
+		case entity.TransferCompleted:
+		case entity.TransferReversed:
+			return errcodes.ErrTransferAlreadyReversed
+		default:
+			return errcodes.ErrTransferNotCompleted
+		}
+
+		amount, remaining, err := s.reversalAmount(ctx, tx, original, input.Amount)
+		if err != nil {
+			return err
+		}
+
+		fromAccount, err := s.lockTransferAccounts(ctx, tx, original.AccountDestinationID, original.AccountOriginID)
+		if err != nil {
+			return err
+		}
+
+		if !fromAccount.HasSufficientFunds(amount) {
+			return errcodes.ErrInsufficientFunds
+		}
+
+		now := time.Now()
+
+		// a reversal is created and settled in this one transaction, so it
+		// never exists as pending
+		reversal.AccountOriginID = original.AccountDestinationID
+		reversal.AccountOriginUUID = original.AccountDestinationUUID
+		reversal.AccountDestinationID = original.AccountOriginID
+		reversal.AccountDestinationUUID = original.AccountOriginUUID
+		reversal.Amount = amount
+		reversal.Status = entity.TransferCompleted
+		reversal.CompletedAt = &now
+		reversal.CreatedAt = now
+		reversal.ReversedTransferID = original.ID
+		reversal.ReversedTransferUUID = original.TransferUUID
+
+		reversal.ID, err = tx.Account().AddTransfer(ctx, reversal)
 		if err != nil {
-			s.log.Error(ctx, "error to add transfer", logger.Err(err))
+			s.log.Error(ctx, "error to add transfer reversal", logger.Err(err))
 			return err
 		}
 
-		fromAccount.SubtractBalance(transfer.Amount)
+		posting := entity.LedgerEntry{
+			JournalUUID: reversal.TransferUUID,
+			TransferID:  reversal.ID,
+			Type:        entity.LedgerEntryReversal,
+			Amount:      amount,
+		}
 
-		err = tx.Account().UpdateAccountBalance(ctx, fromAccount.ID, fromAccount.Balance)
+		posting.AccountID = original.AccountDestinationID
+		debited, err := tx.Account().DebitAccountBalance(ctx, posting)
 		if err != nil {
-			s.log.Error(ctx, "error to update origin account balance", logger.Err(err))
+			s.log.Error(ctx, "error to debit reversal origin account balance", logger.Err(err))
 			return err
 		}
 
-		destAccount.AddBalance(transfer.Amount)
+		if !debited {
+			return errcodes.ErrInsufficientFunds
+		}
 
-		err = tx.Account().UpdateAccountBalance(ctx, destAccount.ID, destAccount.Balance)
+		posting.AccountID = original.AccountOriginID
+		err = tx.Account().CreditAccountBalance(ctx, posting)
 		if err != nil {
-			s.log.Error(ctx, "error to update destination account balance", logger.Err(err))
+			s.log.Error(ctx, "error to credit reversal destination account balance", logger.Err(err))
 			return err
 		}
-		return nil
+
+		if amount != remaining {
+			return nil
+		}
+
+		err = original.TransitionTo(entity.TransferReversed, now)
+		if err != nil {
+			return err
+		}
+
+		return s.updateTransferStatus(ctx, tx, original, entity.TransferCompleted)
 	})
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+
+	return reversal, nil
 }
 
-func (s *transferService) GetTransfers(ctx context.Context, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error) {
+// reversalAmount is what the reversal of original moves: requested, or all
+// that is left when requested is zero. It never exceeds remaining, what was
+// left to reverse before it.
+func (s *transferService) reversalAmount(ctx context.Context, tx contract.Repos, original entity.Transfer, requested entity.Money) (amount, remaining entity.Money, err error) {
+	reversed, err := tx.Account().GetReversedAmount(ctx, original.ID)
+	if err != nil {
+		s.log.Error(ctx, "error to get reversed amount", logger.Err(err))
+		return amount, remaining, err
+	}
+
+	remaining, err = original.Amount.Sub(reversed)
+	if err != nil {
+		s.log.Error(ctx, "error to compute amount left to reverse", logger.Err(err))
+		return amount, remaining, err
+	}
+
+	if !remaining.IsPositive() {
+		return amount, remaining, errcodes.ErrTransferAlreadyReversed
+	}
+
+	if requested.IsZero() {
+		return remaining, remaining, nil
+	}
+
+	cmp, err := requested.Cmp(remaining)
+	if err != nil {
+		return amount, remaining, apperr.ErrInvalidInput.WithMessage("the reversal must be in the currency of the transfer")
+	}
+
+	if cmp > 0 {
+		return amount, remaining, errcodes.ErrReversalExceedsAmount
+	}
+
+	return requested, remaining, nil
+}
+
+// lockTransferAccounts locks the origin and destination rows for the rest of
+// tx and returns the origin account as it is under the lock. A closed account
+// neither sends nor receives, and it is only seen as closed under the lock. A
+// deactivated account still receives, but sends nothing until it is
+// reactivated, not even what it scheduled before.
+func (s *transferService) lockTransferAccounts(ctx context.Context, tx contract.Repos, fromAccountID, destAccountID int64) (fromAccount entity.Account, err error) {
+	accounts, err := tx.Account().GetAccountsByIDForUpdate(ctx, []int64{fromAccountID, destAccountID})
+	if err != nil {
+		s.log.Error(ctx, "error to lock transfer accounts", logger.Err(err))
+		return fromAccount, err
+	}
+
+	var destAccount entity.Account
+	for _, account := range accounts {
+		switch account.ID {
+		case fromAccountID:
+			fromAccount = account
+		case destAccountID:
+			destAccount = account
+		}
+	}
+
+	if fromAccount.ID == 0 {
+		s.log.Error(ctx, "origin account not found while locking")
+		return fromAccount, apperr.ErrRecordNotFound
+	}
+
+	if destAccount.ID == 0 {
+		s.log.Error(ctx, "destination account not found while locking")
+		return fromAccount, errcodes.ErrInvalidDestinationAccount
+	}
+
+	if fromAccount.IsClosed() {
+		return fromAccount, errcodes.ErrAccountClosed
+	}
+
+	if !fromAccount.Active {
+		return fromAccount, errcodes.ErrOriginAccountDeactivated
+	}
+
+	if destAccount.IsClosed() {
+		return fromAccount, errcodes.ErrDestinationAccountClosed
+	}
+
+	return fromAccount, nil
+}
+
+func (s *transferService) GetTransfers(ctx context.Context, input dto.TransferHistoryInput, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error) {
+	filter, err := input.ToEntityValidate(ctx, s.validator)
+	if err != nil {
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return transfers, totalRecords, err
+	}
+
 	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
 	if err != nil {
 		s.log.Error(ctx, "error to get logged account", logger.Err(err))
 		return transfers, totalRecords, err
 	}
 
-	madeTransfers, madeTotalRecords, err := s.dm.Account().GetTransfersByAccountID(ctx, accountID, take, skip, true)
+	transfers, totalRecords, err = s.dm.Account().GetTransfersByAccountID(ctx, accountID, filter, take, skip)
 	if err != nil {
-		s.log.Error(ctx, "error to get made transfers", logger.Err(err))
+		s.log.Error(ctx, "error to get transfers", logger.Err(err))
 		return transfers, totalRecords, err
 	}
 
-	transfers = append(transfers, madeTransfers...)
+	return transfers, totalRecords, nil
+}
 
-	receivedTransfers, receivedTotalRecords, err := s.dm.Account().GetTransfersByAccountID(ctx, accountID, take, skip, false)
+func (s *transferService) GetTransfersByCursor(ctx context.Context, input dto.TransferHistoryInput, page entity.CursorPage) (transfers []entity.Transfer, result entity.CursorResult, err error) {
+	filter, err := input.ToEntityValidate(ctx, s.validator)
 	if err != nil {
-		s.log.Error(ctx, "error to get received transfers", logger.Err(err))
-		return transfers, totalRecords, err
+		s.log.Error(ctx, "error or invalid input", logger.Err(err))
+		return transfers, result, err
 	}
 
-	transfers = append(transfers, receivedTransfers...)
-	totalRecords = madeTotalRecords + receivedTotalRecords
+	accountID, err := s.accountSvc.GetLoggedAccountID(ctx)
+	if err != nil {
+		s.log.Error(ctx, "error to get logged account", logger.Err(err))
+		return transfers, result, err
+	}
 
-	return transfers, totalRecords, nil
+	transfers, result, err = s.dm.Account().GetTransfersByAccountIDCursor(ctx, accountID, filter, page)
+	if err != nil {
+		s.log.Error(ctx, "error to get transfers", logger.Err(err))
+		return transfers, result, err
+	}
+
+	return transfers, result, nil
 }
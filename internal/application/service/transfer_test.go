@@ -2,16 +2,18 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
-	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
-	"github.com/diegoclair/go_boilerplate/util/number"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -21,308 +23,536 @@ func Test_newTransferService(t *testing.T) {
 
 	want := &transferService{dm: m.mockDataManager, accountSvc: m.mockAccountSvc, log: m.mockLogger, validator: m.mockValidator}
 
-	if got := newTransferService(m.mockDomain, m.mockAccountSvc); !reflect.DeepEqual(got, want) {
+	if got := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{}); !reflect.DeepEqual(got, want) {
 		t.Errorf("newTransferService() = %v, want %v", got, want)
 	}
 }
 
+// withoutAccountLimit has accountID on the limits the service was built with
+func withoutAccountLimit(m allMocks, accountID int64) *gomock.Call {
+	return m.mockAccountRepo.EXPECT().GetAccountLimit(gomock.Any(), accountID).
+		Return(entity.AccountLimit{}, apperr.ErrRecordNotFound).Times(1)
+}
+
 func Test_transferService_CreateTransfer(t *testing.T) {
 	type args struct {
 		accountUUIDFromContext string
 		transfer               dto.TransferInput
 	}
 
+	const destUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	// posting matches one leg of the transfer's journal, whose UUID is only
+	// known once the service generates it
+	posting := func(accountID, transferID int64, amount entity.Money) gomock.Matcher {
+		return gomock.Cond(func(entry entity.LedgerEntry) bool {
+			return entry.JournalUUID != "" &&
+				entry.AccountID == accountID &&
+				entry.TransferID == transferID &&
+				entry.Type == entity.LedgerEntryTransfer &&
+				entry.Amount == amount
+		})
+	}
+
+	// pending matches the transfer as it is first recorded
+	pending := func(fromID, toID int64, amount entity.Money) gomock.Matcher {
+		return gomock.Cond(func(transfer entity.Transfer) bool {
+			return transfer.TransferUUID != "" &&
+				transfer.AccountOriginID == fromID &&
+				transfer.AccountDestinationID == toID &&
+				transfer.Amount == amount &&
+				transfer.Status == entity.TransferPending
+		})
+	}
+
+	// inStatus matches the transfer being moved to status
+	inStatus := func(status entity.TransferStatus) gomock.Matcher {
+		return gomock.Cond(func(transfer entity.Transfer) bool {
+			switch status {
+			case entity.TransferCompleted:
+				return transfer.Status == status && transfer.CompletedAt != nil
+			case entity.TransferFailed:
+				return transfer.Status == status && transfer.FailedAt != nil && transfer.FailureReason != ""
+			}
+			return false
+		})
+	}
+
+	// readBack expects the completed transfer to be read back by its UUID
+	readBack := func(mocks allMocks) *gomock.Call {
+		return mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), gomock.Not("")).
+			DoAndReturn(func(_ context.Context, transferUUID string) (entity.Transfer, error) {
+				return entity.Transfer{ID: 7, TransferUUID: transferUUID, Status: entity.TransferCompleted}, nil
+			}).Times(1)
+	}
+
 	tests := []struct {
-		name      string
-		args      args
-		buildMock func(ctx context.Context, mocks allMocks, args args)
-		wantErr   bool
+		name       string
+		args       args
+		buildMock  func(ctx context.Context, mocks allMocks, args args)
+		wantStatus entity.TransferStatus
+		wantErr    bool
+		wantErrIs  error
 	}{
 		{
 			name: "Should pass without error",
 			args: args{
 				accountUUIDFromContext: "account-from-123",
 				transfer: dto.TransferInput{
-					Amount:                 5.0,
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+					Amount:                 entity.NewMoney(500, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{
-							ID:      1,
-							Balance: 10.50,
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{
+							{ID: 1, Active: true, Balance: entity.NewMoney(1050, entity.BRL)},
+							{ID: 2, Active: true, Balance: entity.NewMoney(2550, entity.BRL)},
 						}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{
-							ID:      2,
-							Balance: 25.50,
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(1, 7, args.transfer.Amount)).
+						Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(2, 7, args.transfer.Amount)).
+						Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferCompleted), entity.TransferPending).
+						Return(true, nil).Times(1),
+					readBack(mocks),
+				)
+			},
+			wantStatus: entity.TransferCompleted,
+		},
+		{
+			name: "Should find the origin account whatever order the locked rows come back in",
+			args: args{
+				accountUUIDFromContext: "account-from-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(500, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(9), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(9, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{9, 2}).
+						Return([]entity.Account{
+							{ID: 2, Active: true, Balance: entity.NewMoney(0, entity.BRL)},
+							{ID: 9, Active: true, Balance: entity.NewMoney(500, entity.BRL)},
 						}, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
-						func(ctx context.Context, fn func(r contract.Repos) error) error {
-							return fn(mocks.mockDataManager)
-						},
-					).Times(1),
-					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Not(""), int64(1), int64(2), args.transfer.Amount).
-						Return(int64(0), nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(1), 5.50).
+					withoutAccountLimit(mocks, 9),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(9, 7, args.transfer.Amount)).
+						Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(2, 7, args.transfer.Amount)).
 						Return(nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(2), 30.50).
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferCompleted), entity.TransferPending).
+						Return(true, nil).Times(1),
+					readBack(mocks),
+				)
+			},
+			wantStatus: entity.TransferCompleted,
+		},
+		{
+			name: "Should return the completed transfer if it can't be read back",
+			args: args{
+				accountUUIDFromContext: "account-from-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(500, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(500, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(1, 7, args.transfer.Amount)).
+						Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(2, 7, args.transfer.Amount)).
 						Return(nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferCompleted), entity.TransferPending).
+						Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), gomock.Not("")).
+						Return(entity.Transfer{}, assert.AnError).Times(1),
 				)
 			},
+			wantStatus: entity.TransferCompleted,
 		},
 		{
-			name: "Should return error if accountUUID does not exists on database",
+			name: "Should return error if the logged account can't be read",
 			args: args{
 				accountUUIDFromContext: "account-non-exists",
 				transfer: dto.TransferInput{
-					Amount:                 5.0,
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+					Amount:                 entity.NewMoney(500, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-					Return(entity.Account{}, fmt.Errorf("account not found")).Times(1)
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).
+					Return(int64(0), assert.AnError).Times(1)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if from account has not sufficient balance to transfer",
+			name: "Should return error if there is some error to get the destination account id",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					Amount:                 18,
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-					Return(entity.Account{
-						Balance: 15,
-					}, nil).Times(1)
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).
+						Return(int64(0), assert.AnError).Times(1),
+				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should not receive value with floating point problem",
+			name: "Should return error if the destination account is not found",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					Amount:                 0.1,
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{
-							ID:      1,
-							Balance: 0.3,
-						}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{
-							ID:      2,
-							Balance: 0.2,
-						}, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
-						func(ctx context.Context, fn func(r contract.Repos) error) error {
-							return fn(mocks.mockDataManager)
-						},
-					).Times(1),
-					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Not(""), int64(1), int64(2), args.transfer.Amount).
-						Return(int64(0), nil).Times(1),
-					//if we remove the number.RoundFloat of destination balance, here we would have 0.19999999999999998 instead of 0.2
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(1), 0.2).
-						Return(nil).Times(1),
-					//if we remove the number.RoundFloat of destination balance, here we would have 0.30000000000000004 instead of 0.3
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(2), 0.3).
-						Return(nil).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).
+						Return(int64(0), apperr.ErrRecordNotFound).Times(1),
 				)
 			},
-			wantErr: false,
+			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to get account by uuid",
+			name: "Should return error if the destination account is the same as the origin account",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-					Return(entity.Account{}, assert.AnError).Times(1)
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(1), nil).Times(1),
+				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to get account destination by uuid",
+			name: "Should return error if there is some error to add transfer",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{Balance: 5}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{}, assert.AnError).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(0), assert.AnError).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if the destination account is not found",
+			name: "Should record the transfer as failed if there is some error to begin transaction",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{ID: 1, Balance: 5}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{}, fmt.Errorf("no rows in result set")).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if the destination account is the same as the origin account",
+			name: "Should record the transfer as failed if there is some error to lock the accounts",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{ID: 1, Balance: 5}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{ID: 1}, nil).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return(nil, assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to begin transaction",
+			name: "Should record the transfer as failed if the destination account is gone once locked",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{ID: 1, Balance: 5}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{ID: 2}, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).Return(assert.AnError).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(500, entity.BRL)}}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to add transfer",
+			name: "Should record the transfer as failed if the destination account is closed once locked",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				closedAt := time.Now()
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(entity.Account{ID: 1, Balance: 5}, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{ID: 2}, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
-						func(ctx context.Context, fn func(r contract.Repos) error) error {
-							return fn(mocks.mockDataManager)
-						},
-					).Times(1),
-					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Not(""), int64(1), int64(2), args.transfer.Amount).
-						Return(int64(0), assert.AnError).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{
+							{ID: 1, Active: true, Balance: entity.NewMoney(500, entity.BRL)},
+							{ID: 2, Active: true, Balance: entity.NewMoney(0, entity.BRL), ClosedAt: &closedAt},
+						}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantErr:   true,
+			wantErrIs: errcodes.ErrDestinationAccountClosed,
+		},
+		{
+			name: "Should record the transfer as failed if the origin account is deactivated once locked",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{
+							{ID: 1, Active: false, Balance: entity.NewMoney(500, entity.BRL)},
+							{ID: 2, Active: true, Balance: entity.NewMoney(0, entity.BRL)},
+						}, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Cond(func(transfer entity.Transfer) bool {
+						return transfer.Status == entity.TransferFailed && transfer.FailureReason == errcodes.ErrOriginAccountDeactivated.Code
+					}), entity.TransferPending).Return(true, nil).Times(1),
+				)
+			},
+			wantErr:   true,
+			wantErrIs: errcodes.ErrOriginAccountDeactivated,
+		},
+		{
+			name: "Should record the transfer as failed if the locked origin account has not sufficient balance to transfer",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(1800, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{
+							{ID: 1, Active: true, Balance: entity.NewMoney(1500, entity.BRL)},
+							{ID: 2, Active: true},
+						}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to update origin account balance",
+			name: "Should record the transfer as failed if there is some error to debit origin account balance",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				respAccountFrom := entity.Account{ID: 1, Balance: 4}
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(respAccountFrom, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(entity.Account{ID: 2}, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
-						func(ctx context.Context, fn func(r contract.Repos) error) error {
-							return fn(mocks.mockDataManager)
-						},
-					).Times(1),
-					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Not(""), int64(1), int64(2), args.transfer.Amount).
-						Return(int64(0), nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(1), number.RoundFloat(respAccountFrom.Balance-args.transfer.Amount, 2)).
-						Return(assert.AnError).Times(1),
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(400, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(1, 7, args.transfer.Amount)).
+						Return(false, assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
 				)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to update destination account balance",
+			name: "Should return insufficient funds if the guarded debit doesn't apply",
 			args: args{
 				accountUUIDFromContext: "account-123",
 				transfer: dto.TransferInput{
-					AccountDestinationUUID: "d152a340-9a87-4d32-85ad-19df4c9934cd",
-					Amount:                 2,
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
 				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
-				respAccountFrom := entity.Account{ID: 1, Balance: 4}
-				respAccountDest := entity.Account{ID: 2, Balance: 5}
 				gomock.InOrder(
-					mocks.mockAccountSvc.EXPECT().GetLoggedAccount(gomock.Any()).
-						Return(respAccountFrom, nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetAccountByUUID(gomock.Any(), args.transfer.AccountDestinationUUID).
-						Return(respAccountDest, nil).Times(1),
-					mocks.mockDataManager.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
-						func(ctx context.Context, fn func(r contract.Repos) error) error {
-							return fn(mocks.mockDataManager)
-						},
-					).Times(1),
-					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Not(""), int64(1), int64(2), args.transfer.Amount).
-						Return(int64(0), nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(1), number.RoundFloat(respAccountFrom.Balance-args.transfer.Amount, 2)).
-						Return(nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().UpdateAccountBalance(gomock.Any(), int64(2), number.RoundFloat(respAccountDest.Balance+args.transfer.Amount, 2)).
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(400, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(1, 7, args.transfer.Amount)).
+						Return(false, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should record the transfer as failed if there is some error to credit destination account balance",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(400, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(1, 7, args.transfer.Amount)).
+						Return(true, nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(2, 7, args.transfer.Amount)).
 						Return(assert.AnError).Times(1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(true, nil).Times(1),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return the original error if the failure can't be recorded",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(1800, entity.BRL),
+					AccountDestinationUUID: destUUID,
+				},
+			},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), pending(1, 2, args.transfer.Amount)).
+						Return(int64(7), nil).Times(1),
+					withTransaction(mocks),
+					mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+						Return([]entity.Account{{ID: 1, Active: true, Balance: entity.NewMoney(1500, entity.BRL)}, {ID: 2, Active: true}}, nil).Times(1),
+					withoutAccountLimit(mocks, 1),
+					mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), inStatus(entity.TransferFailed), entity.TransferPending).
+						Return(false, assert.AnError).Times(1),
 				)
 			},
 			wantErr: true,
 		},
+		{
+			name: "Should return error if the destination is the funding account",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				transfer: dto.TransferInput{
+					Amount:                 entity.NewMoney(200, entity.BRL),
+					AccountDestinationUUID: entity.FundingAccountUUID,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name:    "Should return error if we have invalid transfer input",
 			args:    args{},
@@ -335,7 +565,7 @@ func Test_transferService_CreateTransfer(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newTransferService(m.mockDomain, m.mockAccountSvc)
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})
 
 			if tt.args.accountUUIDFromContext != "" {
 				ctx = context.WithValue(ctx, infra.AccountUUIDKey, tt.args.accountUUIDFromContext)
@@ -345,16 +575,180 @@ func Test_transferService_CreateTransfer(t *testing.T) {
 				tt.buildMock(ctx, m, tt.args)
 			}
 
-			if err := s.CreateTransfer(ctx, tt.args.transfer); (err != nil) != tt.wantErr {
+			created, err := s.CreateTransfer(ctx, tt.args.transfer)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("transferService.CreateTransfer() error = %v, wantErr %v", err, tt.wantErr)
 			}
+
+			if tt.wantErrIs != nil {
+				require.ErrorIs(t, err, tt.wantErrIs)
+			}
+
+			if !tt.wantErr {
+				require.NotEmpty(t, created.TransferUUID)
+				require.Equal(t, tt.wantStatus, created.Status)
+			}
+		})
+	}
+}
+
+func Test_transferService_CreateTransfer_limits(t *testing.T) {
+	const destUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	brl := func(amount int64) entity.Money { return entity.NewMoney(amount, entity.BRL) }
+
+	// no night, so the test doesn't depend on when it runs
+	limits := entity.TransferLimits{
+		PerTransaction: brl(5000),
+		Daily:          brl(10000),
+		Monthly:        brl(50000),
+	}
+
+	// upToLimits takes a transfer of amount up to the limit check, with the
+	// origin well funded
+	upToLimits := func(mocks allMocks, amount entity.Money) {
+		gomock.InOrder(
+			mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1),
+			mocks.mockAccountRepo.EXPECT().GetAccountIDByUUID(gomock.Any(), destUUID).Return(int64(2), nil).Times(1),
+			mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(7), nil).Times(1),
+			withTransaction(mocks),
+			mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{1, 2}).
+				Return([]entity.Account{{ID: 1, Active: true, Balance: brl(1_000_000)}, {ID: 2, Active: true}}, nil).Times(1),
+		)
+	}
+
+	// declined expects the transfer to be recorded as failed
+	declined := func(mocks allMocks) *gomock.Call {
+		return mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Cond(func(transfer entity.Transfer) bool {
+			return transfer.Status == entity.TransferFailed
+		}), entity.TransferPending).Return(true, nil).Times(1)
+	}
+
+	// completed expects the money to move
+	completed := func(mocks allMocks) {
+		mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+		mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferPending).Return(true, nil).Times(1)
+		mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), gomock.Any()).
+			Return(entity.Transfer{Status: entity.TransferCompleted}, nil).Times(1)
+	}
+
+	tests := []struct {
+		name        string
+		amount      entity.Money
+		buildMock   func(mocks allMocks)
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name:   "Should make a transfer within the limits",
+			amount: brl(5000),
+			buildMock: func(mocks allMocks) {
+				withoutAccountLimit(mocks, 1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{Daily: brl(5000), Monthly: brl(5000)}, nil).Times(1)
+				completed(mocks)
+			},
+		},
+		{
+			name:   "Should decline a transfer over the per-transaction limit",
+			amount: brl(5001),
+			buildMock: func(mocks allMocks) {
+				withoutAccountLimit(mocks, 1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{}, nil).Times(1)
+				declined(mocks)
+			},
+			wantErr:     errcodes.ErrTransferPerTransactionLimitExceeded,
+			wantMessage: "50.00",
+		},
+		{
+			name:   "Should decline a transfer over what is left of the daily limit",
+			amount: brl(3000),
+			buildMock: func(mocks allMocks) {
+				withoutAccountLimit(mocks, 1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{Daily: brl(8000), Monthly: brl(8000)}, nil).Times(1)
+				declined(mocks)
+			},
+			wantErr:     errcodes.ErrTransferDailyLimitExceeded,
+			wantMessage: "20.00 is left for today",
+		},
+		{
+			name:   "Should decline a transfer over what is left of the monthly limit",
+			amount: brl(3000),
+			buildMock: func(mocks allMocks) {
+				withoutAccountLimit(mocks, 1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{Monthly: brl(49000)}, nil).Times(1)
+				declined(mocks)
+			},
+			wantErr:     errcodes.ErrTransferMonthlyLimitExceeded,
+			wantMessage: "10.00 is left for this month",
+		},
+		{
+			name:   "Should apply the limits the account overrides",
+			amount: brl(9000),
+			buildMock: func(mocks allMocks) {
+				perTransaction := brl(20000)
+				mocks.mockAccountRepo.EXPECT().GetAccountLimit(gomock.Any(), int64(1)).
+					Return(entity.AccountLimit{AccountID: 1, PerTransaction: &perTransaction}, nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{}, nil).Times(1)
+				completed(mocks)
+			},
+		},
+		{
+			name:   "Should not read the usage when the account lifts every cumulative limit",
+			amount: brl(3000),
+			buildMock: func(mocks allMocks) {
+				lifted := brl(0)
+				mocks.mockAccountRepo.EXPECT().GetAccountLimit(gomock.Any(), int64(1)).
+					Return(entity.AccountLimit{AccountID: 1, Daily: &lifted, Monthly: &lifted}, nil).Times(1)
+				completed(mocks)
+			},
+		},
+		{
+			name:   "Should decline the transfer if the usage can't be read",
+			amount: brl(3000),
+			buildMock: func(mocks allMocks) {
+				withoutAccountLimit(mocks, 1)
+				mocks.mockAccountRepo.EXPECT().GetTransferUsage(gomock.Any(), int64(1), entity.BRL, gomock.Any()).
+					Return(entity.TransferUsage{}, assert.AnError).Times(1)
+				declined(mocks)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			upToLimits(m, tt.amount)
+			tt.buildMock(m)
+
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, limits)
+
+			_, err := s.CreateTransfer(ctx, dto.TransferInput{AccountDestinationUUID: destUUID, Amount: tt.amount})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Contains(t, err.Error(), tt.wantMessage)
+				return
+			}
+
+			require.NoError(t, err)
 		})
 	}
 }
 
 func Test_transferService_GetTransfers(t *testing.T) {
+	const counterpartyUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
 	type args struct {
 		accountUUIDFromContext string
+		input                  dto.TransferHistoryInput
 	}
 	tests := []struct {
 		name      string
@@ -371,48 +765,60 @@ func Test_transferService_GetTransfers(t *testing.T) {
 				gomock.InOrder(
 					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
 						Return(int64(1), nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), int64(0), int64(0), true).
-						Return([]entity.Transfer{}, int64(0), nil).Times(1),
-					mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), int64(0), int64(0), false).
+					mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), entity.TransferFilter{}, int64(0), int64(0)).
 						Return([]entity.Transfer{}, int64(0), nil).Times(1),
 				)
 			},
 		},
 		{
-			name: "Should return error if there is some error to get account by uuid",
+			name: "Should pass the filters to the repository",
 			args: args{
 				accountUUIDFromContext: "account-123",
+				input: dto.TransferHistoryInput{
+					Direction:        "sent",
+					MinAmount:        entity.NewMoney(100, entity.BRL),
+					CounterpartyUUID: counterpartyUUID,
+				},
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
-					Return(int64(0), assert.AnError).Times(1)
+					Return(int64(1), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), entity.TransferFilter{
+					Direction:        entity.TransferSent,
+					MinAmount:        entity.NewMoney(100, entity.BRL),
+					CounterpartyUUID: counterpartyUUID,
+				}, int64(0), int64(0)).
+					Return([]entity.Transfer{}, int64(0), nil).Times(1)
+			},
+		},
+		{
+			name: "Should return error if the filters are invalid",
+			args: args{
+				accountUUIDFromContext: "account-123",
+				input:                  dto.TransferHistoryInput{Direction: "both"},
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to get transfers made by account id",
+			name: "Should return error if there is some error to get account by uuid",
 			args: args{
 				accountUUIDFromContext: "account-123",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
-					Return(int64(1), nil).Times(1)
-				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), int64(0), int64(0), true).
-					Return([]entity.Transfer{}, int64(0), assert.AnError).Times(1)
+					Return(int64(0), assert.AnError).Times(1)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Should return error if there is some error to get transfers received by account id",
+			name: "Should return error if there is some error to get transfers by account id",
 			args: args{
 				accountUUIDFromContext: "account-123",
 			},
 			buildMock: func(ctx context.Context, mocks allMocks, args args) {
 				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
 					Return(int64(1), nil).Times(1)
-				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), int64(0), int64(0), true).
-					Return([]entity.Transfer{}, int64(0), nil).Times(1)
-				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), int64(0), int64(0), false).
+				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountID(ctx, int64(1), entity.TransferFilter{}, int64(0), int64(0)).
 					Return([]entity.Transfer{}, int64(0), assert.AnError).Times(1)
 			},
 			wantErr: true,
@@ -425,7 +831,7 @@ func Test_transferService_GetTransfers(t *testing.T) {
 			m, ctrl := newServiceTestMock(t)
 			defer ctrl.Finish()
 
-			s := newTransferService(m.mockDomain, m.mockAccountSvc)
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})
 
 			if tt.args.accountUUIDFromContext != "" {
 				ctx = context.WithValue(ctx, infra.AccountUUIDKey, tt.args.accountUUIDFromContext)
@@ -435,9 +841,443 @@ func Test_transferService_GetTransfers(t *testing.T) {
 				tt.buildMock(ctx, m, tt.args)
 			}
 
-			if _, _, err := s.GetTransfers(ctx, 0, 0); (err != nil) != tt.wantErr {
+			if _, _, err := s.GetTransfers(ctx, tt.args.input, 0, 0); (err != nil) != tt.wantErr {
 				t.Errorf("transferService.GetTransfers() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_transferService_GetTransfersByCursor(t *testing.T) {
+	page := entity.CursorPage{Limit: 10, WithCount: true}
+	total := int64(1)
+
+	type args struct {
+		input dto.TransferHistoryInput
+	}
+	tests := []struct {
+		name      string
+		args      args
+		buildMock func(ctx context.Context, mocks allMocks, args args)
+		wantErr   bool
+	}{
+		{
+			name: "Should pass the filters and the page to the repository",
+			args: args{input: dto.TransferHistoryInput{Direction: "received"}},
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				gomock.InOrder(
+					mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
+						Return(int64(1), nil).Times(1),
+					mocks.mockAccountRepo.EXPECT().GetTransfersByAccountIDCursor(ctx, int64(1), entity.TransferFilter{Direction: entity.TransferReceived}, page).
+						Return([]entity.Transfer{{ID: 1}}, entity.CursorResult{TotalRecords: &total}, nil).Times(1),
+				)
+			},
+		},
+		{
+			name:    "Should return error if the filters are invalid",
+			args:    args{input: dto.TransferHistoryInput{Direction: "both"}},
+			wantErr: true,
+		},
+		{
+			name: "Should return error if there is some error to get the logged account",
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
+					Return(int64(0), assert.AnError).Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should return error if there is some error to get transfers by account id",
+			buildMock: func(ctx context.Context, mocks allMocks, args args) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(ctx).
+					Return(int64(1), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransfersByAccountIDCursor(ctx, int64(1), entity.TransferFilter{}, page).
+					Return(nil, entity.CursorResult{}, assert.AnError).Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), infra.AccountUUIDKey, "account-123")
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})
+
+			if tt.buildMock != nil {
+				tt.buildMock(ctx, m, tt.args)
+			}
+
+			if _, _, err := s.GetTransfersByCursor(ctx, tt.args.input, page); (err != nil) != tt.wantErr {
+				t.Errorf("transferService.GetTransfersByCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_transferService_ReverseTransfer(t *testing.T) {
+	const transferUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	original := entity.Transfer{
+		ID:                     7,
+		TransferUUID:           transferUUID,
+		AccountOriginID:        1,
+		AccountOriginUUID:      "origin-uuid",
+		AccountDestinationID:   2,
+		AccountDestinationUUID: "destination-uuid",
+		Amount:                 entity.NewMoney(1000, entity.BRL),
+		Status:                 entity.TransferCompleted,
+	}
+
+	// reversalOf matches the reversal being recorded, settled from the start
+	reversalOf := func(amount entity.Money) gomock.Matcher {
+		return gomock.Cond(func(transfer entity.Transfer) bool {
+			return transfer.TransferUUID != "" &&
+				transfer.AccountOriginID == 2 &&
+				transfer.AccountDestinationID == 1 &&
+				transfer.Amount == amount &&
+				transfer.Status == entity.TransferCompleted &&
+				transfer.CompletedAt != nil &&
+				transfer.ReversedTransferID == 7
+		})
+	}
+
+	// posting matches one leg of the reversal's journal
+	posting := func(accountID int64, amount entity.Money) gomock.Matcher {
+		return gomock.Cond(func(entry entity.LedgerEntry) bool {
+			return entry.JournalUUID != "" &&
+				entry.AccountID == accountID &&
+				entry.TransferID == 8 &&
+				entry.Type == entity.LedgerEntryReversal &&
+				entry.Amount == amount
+		})
+	}
+
+	// reverseUpTo expects the reads every reversal does before moving money
+	reverseUpTo := func(mocks allMocks, transfer entity.Transfer, reversed entity.Money) {
+		mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+		withTransaction(mocks)
+		mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).Return(transfer, nil).Times(1)
+		mocks.mockAccountRepo.EXPECT().GetReversedAmount(gomock.Any(), transfer.ID).Return(reversed, nil).Times(1)
+	}
+
+	lockAccounts := func(mocks allMocks, destinationBalance int64) {
+		mocks.mockAccountRepo.EXPECT().GetAccountsByIDForUpdate(gomock.Any(), []int64{2, 1}).
+			Return([]entity.Account{
+				{ID: 1, Active: true, Balance: entity.NewMoney(0, entity.BRL)},
+				{ID: 2, Active: true, Balance: entity.NewMoney(destinationBalance, entity.BRL)},
+			}, nil).Times(1)
+	}
+
+	tests := []struct {
+		name       string
+		input      dto.TransferReversalInput
+		buildMock  func(mocks allMocks)
+		wantAmount entity.Money
+		wantErr    error
+	}{
+		{
+			name:  "Should reverse what is left of the transfer when no amount is given",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(400, entity.BRL))
+				lockAccounts(mocks, 5000)
+				amount := entity.NewMoney(600, entity.BRL)
+				mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), reversalOf(amount)).Return(int64(8), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(2, amount)).Return(true, nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(1, amount)).Return(nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Cond(func(transfer entity.Transfer) bool {
+					return transfer.ID == 7 && transfer.Status == entity.TransferReversed && transfer.ReversedAt != nil
+				}), entity.TransferCompleted).Return(true, nil).Times(1)
+			},
+			wantAmount: entity.NewMoney(600, entity.BRL),
+		},
+		{
+			name:  "Should reverse part of the transfer",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID, Amount: entity.NewMoney(250, entity.BRL)},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(0, entity.BRL))
+				lockAccounts(mocks, 5000)
+				amount := entity.NewMoney(250, entity.BRL)
+				mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), reversalOf(amount)).Return(int64(8), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), posting(2, amount)).Return(true, nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), posting(1, amount)).Return(nil).Times(1)
+			},
+			wantAmount: entity.NewMoney(250, entity.BRL),
+		},
+		{
+			name:  "Should return error when the original can't be marked as reversed",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(0, entity.BRL))
+				lockAccounts(mocks, 5000)
+				mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(8), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().CreditAccountBalance(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().UpdateTransferStatus(gomock.Any(), gomock.Any(), entity.TransferCompleted).
+					Return(false, assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:  "Should return error when the reversal exceeds what is left",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID, Amount: entity.NewMoney(700, entity.BRL)},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(400, entity.BRL))
+			},
+			wantErr: errcodes.ErrReversalExceedsAmount,
+		},
+		{
+			name:  "Should return error when the transfer was already fully reversed",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(1000, entity.BRL))
+			},
+			wantErr: errcodes.ErrTransferAlreadyReversed,
+		},
+		{
+			name:  "Should return error when the transfer doesn't exist",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				withTransaction(mocks)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).
+					Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrTransferNotFound,
+		},
+		{
+			name:  "Should not let the origin reverse a transfer it made",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				withTransaction(mocks)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).Return(original, nil).Times(1)
+			},
+			wantErr: errcodes.ErrTransferNotFound,
+		},
+		{
+			name:  "Should not reverse a reversal",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reversal := original
+				reversal.ReversedTransferID = 3
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				withTransaction(mocks)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).Return(reversal, nil).Times(1)
+			},
+			wantErr: errcodes.ErrReversalNotReversible,
+		},
+		{
+			name:  "Should return error when the transfer is marked as reversed",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reversed := original
+				reversed.Status = entity.TransferReversed
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				withTransaction(mocks)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).Return(reversed, nil).Times(1)
+			},
+			wantErr: errcodes.ErrTransferAlreadyReversed,
+		},
+		{
+			name:  "Should not reverse a transfer that didn't complete",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				failed := original
+				failed.Status = entity.TransferFailed
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				withTransaction(mocks)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUIDForUpdate(gomock.Any(), transferUUID).Return(failed, nil).Times(1)
+			},
+			wantErr: errcodes.ErrTransferNotCompleted,
+		},
+		{
+			name:  "Should return error when the destination no longer has the funds",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(0, entity.BRL))
+				lockAccounts(mocks, 999)
+			},
+			wantErr: errcodes.ErrInsufficientFunds,
+		},
+		{
+			name:  "Should return error when the guarded debit refuses",
+			input: dto.TransferReversalInput{TransferUUID: transferUUID},
+			buildMock: func(mocks allMocks) {
+				reverseUpTo(mocks, original, entity.NewMoney(0, entity.BRL))
+				lockAccounts(mocks, 5000)
+				mocks.mockAccountRepo.EXPECT().AddTransfer(gomock.Any(), gomock.Any()).Return(int64(8), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().DebitAccountBalance(gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+			},
+			wantErr: errcodes.ErrInsufficientFunds,
+		},
+		{
+			name:    "Should return error when the amount is negative",
+			input:   dto.TransferReversalInput{TransferUUID: transferUUID, Amount: entity.NewMoney(-1, entity.BRL)},
+			wantErr: apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})
+
+			reversal, err := s.ReverseTransfer(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEmpty(t, reversal.TransferUUID)
+			require.Equal(t, tt.wantAmount, reversal.Amount)
+			require.Equal(t, original.AccountDestinationUUID, reversal.AccountOriginUUID)
+			require.Equal(t, original.AccountOriginUUID, reversal.AccountDestinationUUID)
+			require.Equal(t, transferUUID, reversal.ReversedTransferUUID)
+		})
+	}
+}
+
+func Test_transferService_GetTransferByUUID(t *testing.T) {
+	const transferUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	transfer := entity.Transfer{
+		ID:                   7,
+		TransferUUID:         transferUUID,
+		AccountOriginID:      1,
+		AccountDestinationID: 2,
+		Amount:               entity.NewMoney(1000, entity.BRL),
+		Status:               entity.TransferFailed,
+		FailureReason:        "insufficient funds",
+	}
+
+	tests := []struct {
+		name         string
+		transferUUID string
+		buildMock    func(mocks allMocks)
+		wantErr      error
+	}{
+		{
+			name:         "Should return a transfer the logged account sent",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).Return(transfer, nil).Times(1)
+			},
+		},
+		{
+			name:         "Should return a transfer the logged account received",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(2), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).Return(transfer, nil).Times(1)
+			},
+		},
+		{
+			name:         "Should not return someone else's transfer",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(3), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).Return(transfer, nil).Times(1)
+			},
+			wantErr: errcodes.ErrTransferNotFound,
+		},
+		{
+			name:         "Should return error when the transfer doesn't exist",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+					Return(entity.Transfer{}, apperr.ErrRecordNotFound).Times(1)
+			},
+			wantErr: errcodes.ErrTransferNotFound,
+		},
+		{
+			name:         "Should return error if there is some error to get the transfer",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(1), nil).Times(1)
+				mocks.mockAccountRepo.EXPECT().GetTransferByUUID(gomock.Any(), transferUUID).
+					Return(entity.Transfer{}, assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:         "Should return error if the logged account can't be read",
+			transferUUID: transferUUID,
+			buildMock: func(mocks allMocks) {
+				mocks.mockAccountSvc.EXPECT().GetLoggedAccountID(gomock.Any()).Return(int64(0), assert.AnError).Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+		{
+			name:         "Should return error when the transfer uuid is invalid",
+			transferUUID: "invalid",
+			wantErr:      apperr.ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, ctrl := newServiceTestMock(t)
+			defer ctrl.Finish()
+
+			if tt.buildMock != nil {
+				tt.buildMock(m)
+			}
+
+			s := newTransferService(m.mockDomain, m.mockAccountSvc, entity.TransferLimits{})
+
+			got, err := s.GetTransferByUUID(ctx, tt.transferUUID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, transfer, got)
+		})
+	}
+}
+
+func Test_transferFailureReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Should record the code of a decline",
+			err:  errcodes.ErrInsufficientFunds,
+			want: "TRANSFER_INSUFFICIENT_FUNDS",
+		},
+		{
+			name: "Should record the code of a decline that carries its own message",
+			err:  errcodes.ErrTransferDailyLimitExceeded.WithMessage("the amount is over the daily transfer limit, 10.00 is left for today"),
+			want: "TRANSFER_DAILY_LIMIT_EXCEEDED",
+		},
+		{
+			name: "Should record a generic reason for any other error",
+			err:  errors.New(`pq: value too long for type character varying(500) in "tab_transfer"`),
+			want: transferFailedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, transferFailureReason(tt.err))
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 //   - Set expiration is variadic: omit for default, pass for custom
 type CacheManager interface {
 	Set(ctx context.Context, key string, data any, expiration ...time.Duration) error
+	// SetNX is Set only when key isn't there, telling whether it was set
+	SetNX(ctx context.Context, key string, data any, expiration ...time.Duration) (set bool, err error)
 
 	Get(ctx context.Context, key string) ([]byte, error)
 	GetString(ctx context.Context, key string) (string, error)
@@ -19,6 +21,11 @@ type CacheManager interface {
 
 	Increase(ctx context.Context, key string) error
 
+	// RateLimit counts a hit of key in a sliding window, atomically, unless
+	// there are limit hits in it already. remaining is how many more hits the
+	// window takes, and resetAfter how long until its oldest hit leaves it.
+	RateLimit(ctx context.Context, key string, limit int64, window time.Duration) (allowed bool, remaining int64, resetAfter time.Duration, err error)
+
 	GetExpiration(ctx context.Context, key string) (time.Duration, error)
 	SetExpiration(ctx context.Context, key string, expiration time.Duration) error
 
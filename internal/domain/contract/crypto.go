@@ -3,4 +3,15 @@ package contract
 type Crypto interface {
 	HashPassword(password string) (string, error)
 	CheckPassword(password, hashedPassword string) error
+	// EncryptField encrypts a value to be stored, under the active key
+	EncryptField(value string) (string, error)
+	// DecryptField decrypts a value from EncryptField, whatever key it was
+	// encrypted with
+	DecryptField(encrypted string) (string, error)
+	// NeedsReencryption tells whether a stored value isn't encrypted with the
+	// active key yet
+	NeedsReencryption(encrypted string) bool
+	// BlindIndex is a keyed hash of value, to look up an encrypted field by
+	// exact match
+	BlindIndex(value string) string
 }
@@ -0,0 +1,13 @@
+package contract
+
+import (
+	"context"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+)
+
+// Notifier delivers notifications to the owner of an account, out of band of
+// the request that made them.
+type Notifier interface {
+	Notify(ctx context.Context, notification entity.Notification) (err error)
+}
@@ -2,6 +2,7 @@ package contract
 
 import (
 	"context"
+	"time"
 
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
@@ -12,6 +13,10 @@ import (
 type Repos interface {
 	Account() AccountRepo
 	Auth() AuthRepo
+	Idempotency() IdempotencyRepo
+	Ledger() LedgerRepo
+	RecurringTransfer() RecurringTransferRepo
+	ScheduledTransfer() ScheduledTransferRepo
 }
 
 // DataManager holds the methods that manipulates the main data.
@@ -21,18 +26,136 @@ type DataManager interface {
 }
 
 type AuthRepo interface {
+	AddMFARecoveryCodes(ctx context.Context, accountID int64, codeHashes []string) (err error)
+	AddSecurityEvent(ctx context.Context, event entity.SecurityEvent) (eventID int64, err error)
+	// ConfirmAccountMFA turns on the pending MFA of the account, taking step
+	// as its first code. confirmed is false when there is no pending one.
+	ConfirmAccountMFA(ctx context.Context, accountID, step int64) (confirmed bool, err error)
+	// ConsumePasswordReset spends the reset token with the hash and returns the
+	// account it resets, or a not found error when no unspent, unexpired token
+	// has it. Consuming is atomic, so a token can't be used twice.
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (accountID int64, err error)
+	CreatePasswordReset(ctx context.Context, reset entity.PasswordReset) (resetID int64, err error)
 	CreateSession(ctx context.Context, session dto.Session) (sessionID int64, err error)
+	GetAccountMFA(ctx context.Context, accountID int64) (mfa entity.AccountMFA, err error)
+	// GetActiveSessionsByAccountID lists the sessions of the account that are
+	// neither blocked nor past their refresh token
+	GetActiveSessionsByAccountID(ctx context.Context, accountID int64) (sessions []dto.Session, err error)
 	GetSessionByUUID(ctx context.Context, sessionUUID string) (session dto.Session, err error)
+	// IsRotatedRefreshToken tells whether the session rotated out a refresh
+	// token with the hash
+	IsRotatedRefreshToken(ctx context.Context, sessionUUID, refreshTokenHash string) (rotated bool, err error)
+	// RotateSessionRefreshToken replaces the refresh token of the session, and
+	// keeps the hash of the old one among those it rotated out. rotated is
+	// false, and nothing changes, unless the session is active and its refresh
+	// token is still the one of rotation.RefreshTokenHash.
+	RotateSessionRefreshToken(ctx context.Context, rotation dto.RefreshTokenRotation) (rotated bool, err error)
+	// SetPendingAccountMFA starts the MFA of the account over with secret,
+	// encrypted. set is false when the account has a confirmed one, which is
+	// never replaced.
+	SetPendingAccountMFA(ctx context.Context, accountID int64, secret string) (set bool, err error)
 	SetSessionAsBlocked(ctx context.Context, sessionUUID string) (err error)
+	// SetSessionsAsBlockedByAccountID blocks every session of the account that
+	// isn't blocked yet, and returns the UUIDs of the ones it blocked
+	SetSessionsAsBlockedByAccountID(ctx context.Context, accountID int64) (sessionUUIDs []string, err error)
+	// UseAccountMFAStep takes a code of the confirmed MFA of the account,
+	// from time step step. used is false when a code of that step or a later
+	// one was taken already, so a code can't be replayed.
+	UseAccountMFAStep(ctx context.Context, accountID, step int64) (used bool, err error)
+	// UseMFARecoveryCode spends the recovery code of the account with the
+	// hash. used is false when it doesn't have one unspent.
+	UseMFARecoveryCode(ctx context.Context, accountID int64, codeHash string) (used bool, err error)
+	// VoidPasswordResets spends every reset token of the account still unspent
+	VoidPasswordResets(ctx context.Context, accountID int64) (err error)
 }
 
 type AccountRepo interface {
-	AddTransfer(ctx context.Context, transferUUID string, accountOriginID, accountDestinationID int64, amount float64) (transferID int64, err error)
+	AddAccountStatusChange(ctx context.Context, change entity.AccountStatusChange) (changeID int64, err error)
+	AddTransfer(ctx context.Context, transfer entity.Transfer) (transferID int64, err error)
 	CreateAccount(ctx context.Context, account entity.Account) (createdID int64, err error)
-	GetAccountByDocument(ctx context.Context, encryptedCPF string) (account entity.Account, err error)
-	GetAccounts(ctx context.Context, take, skip int64) (accounts []entity.Account, totalRecords int64, err error)
+	CreditAccountBalance(ctx context.Context, entry entity.LedgerEntry) (err error)
+	DebitAccountBalance(ctx context.Context, entry entity.LedgerEntry) (debited bool, err error)
+	// GetAccountByDocument finds the account by the blind index of its CPF.
+	// legacyCPF finds the rows the encrypt-cpf command hasn't indexed yet; it
+	// can go once every row has an index.
+	GetAccountByDocument(ctx context.Context, cpfIndex, legacyCPF string) (account entity.Account, err error)
+	GetAccounts(ctx context.Context, filter entity.AccountFilter, take, skip int64) (accounts []entity.Account, totalRecords int64, err error)
+	GetAccountsByCursor(ctx context.Context, filter entity.AccountFilter, page entity.CursorPage) (accounts []entity.Account, result entity.CursorResult, err error)
 	GetAccountByUUID(ctx context.Context, accountUUID string) (account entity.Account, err error)
 	GetAccountIDByUUID(ctx context.Context, accountUUID string) (accountID int64, err error)
-	GetTransfersByAccountID(ctx context.Context, accountID, take, skip int64, origin bool) (transfers []entity.Transfer, totalRecords int64, err error)
-	UpdateAccountBalance(ctx context.Context, accountID int64, balance float64) (err error)
+	// GetAccountsAfterID walks every account, system ones included, in order
+	// of id, take at a time.
+	GetAccountsAfterID(ctx context.Context, afterID, take int64) (accounts []entity.Account, err error)
+	// GetAccountLimit returns the limits overridden for the account, or a not
+	// found error when it is on the defaults.
+	GetAccountLimit(ctx context.Context, accountID int64) (limit entity.AccountLimit, err error)
+	GetAccountsByIDForUpdate(ctx context.Context, accountIDs []int64) (accounts []entity.Account, err error)
+	GetReversedAmount(ctx context.Context, transferID int64) (reversed entity.Money, err error)
+	// GetTransferUsage sums what the account sent in currency since each of
+	// periods. It is only stable while the account row is locked.
+	GetTransferUsage(ctx context.Context, accountID int64, currency entity.Currency, periods entity.TransferPeriods) (usage entity.TransferUsage, err error)
+	GetTransferByUUID(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error)
+	GetTransferByUUIDForUpdate(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error)
+	// GetTransfersByAccountID lists what the account sent and received, newest
+	// first, narrowed by filter.
+	GetTransfersByAccountID(ctx context.Context, accountID int64, filter entity.TransferFilter, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error)
+	GetTransfersByAccountIDCursor(ctx context.Context, accountID int64, filter entity.TransferFilter, page entity.CursorPage) (transfers []entity.Transfer, result entity.CursorResult, err error)
+	// UpdateAccountCPF writes the CPF and its index, as long as the stored CPF
+	// is still fromCPF.
+	UpdateAccountCPF(ctx context.Context, account entity.Account, fromCPF string) (updated bool, err error)
+	UpdateAccountPassword(ctx context.Context, accountID int64, hashedPassword string) (err error)
+	// UpdateAccountProfile writes the fields the owner can change on their own.
+	UpdateAccountProfile(ctx context.Context, account entity.Account) (err error)
+	UpdateAccountRole(ctx context.Context, accountID int64, role entity.Role) (err error)
+	// UpdateAccountStatus writes whether the account is active and when it
+	// was closed. The caller decides the change with the row locked.
+	UpdateAccountStatus(ctx context.Context, account entity.Account) (err error)
+	UpdateTransferStatus(ctx context.Context, transfer entity.Transfer, from entity.TransferStatus) (updated bool, err error)
+}
+
+// LedgerRepo works on the journal AccountRepo posts to. The balance cached on
+// tab_account is a projection of it and can always be rebuilt from here.
+type LedgerRepo interface {
+	RebuildAccountBalances(ctx context.Context) (rebuilt int64, err error)
+}
+
+// IdempotencyRepo is the durable record of the requests made under an
+// Idempotency-Key. Claiming a key is the single point where two concurrent
+// requests race, so it has to be atomic here and not in the cache.
+type IdempotencyRepo interface {
+	ClaimIdempotencyKey(ctx context.Context, request entity.IdempotentRequest) (claimed bool, err error)
+	CompleteIdempotentRequest(ctx context.Context, request entity.IdempotentRequest) (err error)
+	GetIdempotentRequest(ctx context.Context, scope, key string) (request entity.IdempotentRequest, err error)
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) (err error)
+}
+
+// RecurringTransferRepo keeps the standing orders. The Lock methods take row
+// locks, and only mean something inside a transaction.
+type RecurringTransferRepo interface {
+	AddRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) (recurringID int64, err error)
+	CancelRecurringTransfersByAccountID(ctx context.Context, accountID int64) (canceled int64, err error)
+	GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (recurring entity.RecurringTransfer, err error)
+	GetRecurringTransfersByAccountID(ctx context.Context, accountID int64, take, skip int64) (recurring []entity.RecurringTransfer, totalRecords int64, err error)
+	// LockDueRecurringTransfers locks up to limit active rules whose next
+	// occurrence is due, skipping those another transaction holds
+	LockDueRecurringTransfers(ctx context.Context, limit int64) (due []entity.RecurringTransfer, err error)
+	LockRecurringTransfer(ctx context.Context, recurringID int64) (recurring entity.RecurringTransfer, err error)
+	UpdateRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) (err error)
+}
+
+// ScheduledTransferRepo keeps the transfers waiting for their date. Any number
+// of workers may poll it at once: a claimed row is invisible to the others
+// until its claim goes stale.
+type ScheduledTransferRepo interface {
+	AddScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer) (scheduledID int64, err error)
+	CancelScheduledTransfer(ctx context.Context, scheduledID int64) (canceled bool, err error)
+	CancelScheduledTransfersByAccountID(ctx context.Context, accountID int64) (canceled int64, err error)
+	CancelScheduledTransfersByRecurringID(ctx context.Context, recurringID int64) (canceled int64, err error)
+	ClaimDueScheduledTransfers(ctx context.Context, limit int64, staleClaimBefore time.Time) (claimed []entity.ScheduledTransfer, err error)
+	GetScheduledTransferByUUID(ctx context.Context, scheduledTransferUUID string) (scheduled entity.ScheduledTransfer, err error)
+	GetScheduledTransfersByAccountID(ctx context.Context, accountID int64, status entity.ScheduledTransferStatus, take, skip int64) (scheduled []entity.ScheduledTransfer, totalRecords int64, err error)
+	// RescheduleScheduledTransfer puts a claimed schedule back to pending for
+	// a retry, as long as it is still in from
+	RescheduleScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (updated bool, err error)
+	UpdateScheduledTransferStatus(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (updated bool, err error)
 }
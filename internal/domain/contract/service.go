@@ -7,23 +7,110 @@ import (
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 )
 
+// AccountApp keeps the accounts. An account can be deactivated and
+// reactivated any number of times, but once closed it stays closed.
 type AccountApp interface {
 	CreateAccount(ctx context.Context, input dto.AccountInput) (err error)
 	AddBalance(ctx context.Context, input dto.AddBalanceInput) (err error)
-	GetAccounts(ctx context.Context, take, skip int64) (accounts []entity.Account, totalRecords int64, err error)
+	CloseAccount(ctx context.Context, input dto.CloseAccountInput) (err error)
+	DeactivateAccount(ctx context.Context, input dto.AccountStatusInput) (err error)
+	ReactivateAccount(ctx context.Context, input dto.AccountStatusInput) (err error)
+	// ChangeRole also signs the account out of every session, as the role
+	// goes in the tokens it was given.
+	ChangeRole(ctx context.Context, input dto.RoleInput) (err error)
+	GetAccounts(ctx context.Context, input dto.AccountSearchInput, take, skip int64) (accounts []entity.Account, totalRecords int64, err error)
+	GetAccountsByCursor(ctx context.Context, input dto.AccountSearchInput, page entity.CursorPage) (accounts []entity.Account, result entity.CursorResult, err error)
 	GetAccountByUUID(ctx context.Context, accountUUID string) (account entity.Account, err error)
 	GetLoggedAccount(ctx context.Context) (account entity.Account, err error)
 	GetLoggedAccountID(ctx context.Context) (accountID int64, err error)
+	UpdateProfile(ctx context.Context, input dto.ProfileInput) (account entity.Account, err error)
+	// ChangePassword also signs the logged account out of every other session.
+	ChangePassword(ctx context.Context, input dto.PasswordChangeInput) (err error)
 }
 
 type AuthApp interface {
 	Login(ctx context.Context, input dto.LoginInput) (account entity.Account, err error)
 	CreateSession(ctx context.Context, session dto.Session) (err error)
 	GetSessionByUUID(ctx context.Context, sessionUUID string) (session dto.Session, err error)
-	Logout(ctx context.Context, accessToken string) (err error)
+	// RotateRefreshToken swaps the refresh token of the session for a new one,
+	// which the old one can't be used for again. Presenting it again after
+	// that is taken as a theft: the session is signed out and the event is
+	// recorded, and it returns ErrRefreshTokenReused.
+	RotateRefreshToken(ctx context.Context, input dto.RefreshTokenRotation) (err error)
+	// IsSessionActive tells whether the access tokens of the session are
+	// still good. The state is read from the cache, and from the database
+	// when it isn't there. An error means it couldn't be read at all.
+	IsSessionActive(ctx context.Context, sessionUUID string) (active bool, err error)
+	Logout(ctx context.Context) (err error)
+	// ListSessions lists the sessions of the logged account that are still
+	// active, the one of the caller marked as Current.
+	ListSessions(ctx context.Context) (sessions []dto.Session, err error)
+	// RevokeSession signs the logged account out of one of its sessions,
+	// which may be the current one.
+	RevokeSession(ctx context.Context, sessionUUID string) (err error)
+	// RevokeOtherSessions signs the logged account out of every session but
+	// the current one.
+	RevokeOtherSessions(ctx context.Context) (err error)
+	// RequestPasswordReset sends a reset token to the owner of the account.
+	// It answers the same, and as fast, whether the account exists or not, so
+	// it can't be used to find out. A CPF or ip whose login is locked is
+	// refused with an AccountLockedError.
+	RequestPasswordReset(ctx context.Context, input dto.PasswordResetInput) (err error)
+	// ConfirmPasswordReset sets the new password and signs the account out of
+	// every session.
+	ConfirmPasswordReset(ctx context.Context, input dto.PasswordResetConfirmInput) (err error)
+	// IsMFAEnabled tells whether the login of the account takes a second
+	// step, LoginMFA.
+	IsMFAEnabled(ctx context.Context, accountID int64) (enabled bool, err error)
+	// EnrollMFA starts over the TOTP second factor of the logged account with
+	// a new secret. It is pending, not asked for at login, until ConfirmMFA.
+	EnrollMFA(ctx context.Context) (enrollment dto.MFAEnrollment, err error)
+	// ConfirmMFA turns on the pending second factor of the logged account
+	// with a first code of it, and hands out its recovery codes. They are only
+	// stored hashed, so this is the one time they can be shown.
+	ConfirmMFA(ctx context.Context, input dto.MFACodeInput) (recoveryCodes []string, err error)
+	// LoginMFA checks the code of an account that passed Login, either a TOTP
+	// code or a recovery code. Either is taken only once.
+	LoginMFA(ctx context.Context, input dto.LoginMFAInput) (account entity.Account, err error)
+}
+
+// IdempotencyApp guards the requests a client may retry. Begin either claims
+// the key for the caller or hands back what a previous request under it got;
+// the caller then settles the claim with Complete or, when the request failed
+// in a way worth retrying, with Release.
+type IdempotencyApp interface {
+	Begin(ctx context.Context, input dto.IdempotencyInput) (stored entity.IdempotentRequest, replay bool, err error)
+	Complete(ctx context.Context, request entity.IdempotentRequest) (err error)
+	Release(ctx context.Context, scope, key string) (err error)
+}
+
+// RecurringTransferApp keeps the standing orders of the logged account.
+// GenerateDueOccurrences is what the worker calls: it turns each occurrence
+// that is due into a scheduled transfer, once, however many workers call it.
+type RecurringTransferApp interface {
+	CancelRecurringTransfer(ctx context.Context, recurringTransferUUID string) (err error)
+	CreateRecurringTransfer(ctx context.Context, input dto.RecurringTransferInput) (recurring entity.RecurringTransfer, err error)
+	GenerateDueOccurrences(ctx context.Context, batchSize int64) (generated int, err error)
+	GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (recurring entity.RecurringTransfer, err error)
+	GetRecurringTransfers(ctx context.Context, take, skip int64) (recurring []entity.RecurringTransfer, totalRecords int64, err error)
+	UpdateRecurringTransfer(ctx context.Context, input dto.RecurringTransferUpdateInput) (recurring entity.RecurringTransfer, err error)
+}
+
+// ScheduledTransferApp keeps the transfers to be made on a later date.
+// ExecuteDueTransfers is what the worker calls: it runs the due ones through
+// the same path as TransferApp.CreateTransfer, as the account that scheduled
+// them.
+type ScheduledTransferApp interface {
+	CancelScheduledTransfer(ctx context.Context, scheduledTransferUUID string) (err error)
+	ExecuteDueTransfers(ctx context.Context, batchSize int64) (executed int, err error)
+	GetScheduledTransfers(ctx context.Context, status entity.ScheduledTransferStatus, take, skip int64) (scheduled []entity.ScheduledTransfer, totalRecords int64, err error)
+	ScheduleTransfer(ctx context.Context, input dto.ScheduledTransferInput) (scheduled entity.ScheduledTransfer, err error)
 }
 
 type TransferApp interface {
-	CreateTransfer(ctx context.Context, transfer dto.TransferInput) (err error)
-	GetTransfers(ctx context.Context, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error)
+	CreateTransfer(ctx context.Context, transfer dto.TransferInput) (created entity.Transfer, err error)
+	GetTransferByUUID(ctx context.Context, transferUUID string) (transfer entity.Transfer, err error)
+	GetTransfers(ctx context.Context, input dto.TransferHistoryInput, take, skip int64) (transfers []entity.Transfer, totalRecords int64, err error)
+	GetTransfersByCursor(ctx context.Context, input dto.TransferHistoryInput, page entity.CursorPage) (transfers []entity.Transfer, result entity.CursorResult, err error)
+	ReverseTransfer(ctx context.Context, input dto.TransferReversalInput) (reversal entity.Transfer, err error)
 }
@@ -2,29 +2,106 @@ package entity
 
 import (
 	"time"
-
-	"github.com/diegoclair/go_boilerplate/util/number"
 )
 
+// Account is encrypted from the service down to the database as far as its
+// CPF goes; CPFIndex is the blind index that finds the account by the CPF.
 type Account struct {
 	ID        int64
 	UUID      string
 	Name      string
 	CPF       string
-	Balance   float64
+	CPFIndex  string
+	Balance   Money
 	Password  string
 	CreatedAT time.Time
 	Active    bool
+	Role      Role
+	// ClosedAt is set once the account is closed, for good
+	ClosedAt *time.Time
+}
+
+// Role is what an account is allowed to do.
+type Role string
+
+const (
+	// RoleCustomer only reaches its own account
+	RoleCustomer Role = "customer"
+	// RoleSupport reads any account, to help its owner
+	RoleSupport Role = "support"
+	// RoleAdmin runs the bank: lists the accounts and adds balance to them
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleCustomer, RoleSupport, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// AccountStatusAction is a change of the status of an account.
+type AccountStatusAction string
+
+const (
+	AccountDeactivated AccountStatusAction = "deactivated"
+	AccountReactivated AccountStatusAction = "reactivated"
+	AccountClosed      AccountStatusAction = "closed"
+)
+
+// AccountStatusChange is the record of an account being deactivated,
+// reactivated or closed, and why.
+type AccountStatusChange struct {
+	ID        int64
+	AccountID int64
+	Action    AccountStatusAction
+	Reason    string
+	// SweepTransferID is the transfer that moved the balance out of an
+	// account closed with one, zero otherwise
+	SweepTransferID int64
+	CreatedAt       time.Time
+}
+
+// AccountFilter narrows and sorts a list of accounts. A zero field filters
+// nothing.
+type AccountFilter struct {
+	// Name matches part of the name, whatever the case
+	Name string
+	// CPFIndex matches the blind index of a whole CPF, as an encrypted one
+	// can't be searched by part
+	CPFIndex    string
+	Active      *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        []SortField
 }
 
-func (a *Account) AddBalance(amount float64) {
-	a.Balance = number.RoundFloat(a.Balance+amount, 2)
+func (a Account) IsClosed() bool {
+	return a.ClosedAt != nil
 }
 
-func (a *Account) SubtractBalance(amount float64) {
-	a.Balance = number.RoundFloat(a.Balance-amount, 2)
+func (a *Account) AddBalance(amount Money) error {
+	balance, err := a.Balance.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	a.Balance = balance
+	return nil
+}
+
+func (a *Account) SubtractBalance(amount Money) error {
+	balance, err := a.Balance.Sub(amount)
+	if err != nil {
+		return err
+	}
+
+	a.Balance = balance
+	return nil
 }
 
-func (a *Account) HasSufficientFunds(amount float64) bool {
-	return a.Balance >= amount
+func (a *Account) HasSufficientFunds(amount Money) bool {
+	cmp, err := a.Balance.Cmp(amount)
+	return err == nil && cmp >= 0
 }
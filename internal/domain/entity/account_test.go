@@ -5,21 +5,29 @@ import "testing"
 func TestAccount_AddBalance(t *testing.T) {
 	tests := []struct {
 		name           string
-		initialBalance float64
-		amount         float64
-		expectedResult float64
+		initialBalance Money
+		amount         Money
+		expectedResult Money
+		wantErr        bool
 	}{
 		{
 			name:           "Should add balance to account",
-			initialBalance: 10.0,
-			amount:         5.0,
-			expectedResult: 15.0,
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(500, BRL),
+			expectedResult: NewMoney(1500, BRL),
 		},
 		{
 			name:           "Should not change balance if amount is zero",
-			initialBalance: 10.0,
-			amount:         0.0,
-			expectedResult: 10.0,
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(0, BRL),
+			expectedResult: NewMoney(1000, BRL),
+		},
+		{
+			name:           "Should not mix currencies",
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(500, "USD"),
+			expectedResult: NewMoney(1000, BRL),
+			wantErr:        true,
 		},
 	}
 
@@ -29,7 +37,10 @@ func TestAccount_AddBalance(t *testing.T) {
 				Balance: tt.initialBalance,
 			}
 
-			account.AddBalance(tt.amount)
+			err := account.AddBalance(tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Account.AddBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
 
 			if account.Balance != tt.expectedResult {
 				t.Errorf("Account.AddBalance() = %v, expected %v", account.Balance, tt.expectedResult)
@@ -41,21 +52,29 @@ func TestAccount_AddBalance(t *testing.T) {
 func TestAccount_SubtractBalance(t *testing.T) {
 	tests := []struct {
 		name           string
-		initialBalance float64
-		amount         float64
-		expectedResult float64
+		initialBalance Money
+		amount         Money
+		expectedResult Money
+		wantErr        bool
 	}{
 		{
 			name:           "Should subtract balance from account",
-			initialBalance: 10.0,
-			amount:         5.0,
-			expectedResult: 5.0,
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(500, BRL),
+			expectedResult: NewMoney(500, BRL),
 		},
 		{
 			name:           "Should not change balance if amount is zero",
-			initialBalance: 10.0,
-			amount:         0.0,
-			expectedResult: 10.0,
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(0, BRL),
+			expectedResult: NewMoney(1000, BRL),
+		},
+		{
+			name:           "Should not mix currencies",
+			initialBalance: NewMoney(1000, BRL),
+			amount:         NewMoney(500, "USD"),
+			expectedResult: NewMoney(1000, BRL),
+			wantErr:        true,
 		},
 	}
 
@@ -65,7 +84,10 @@ func TestAccount_SubtractBalance(t *testing.T) {
 				Balance: tt.initialBalance,
 			}
 
-			account.SubtractBalance(tt.amount)
+			err := account.SubtractBalance(tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Account.SubtractBalance() error = %v, wantErr %v", err, tt.wantErr)
+			}
 
 			if account.Balance != tt.expectedResult {
 				t.Errorf("Account.SubtractBalance() = %v, expected %v", account.Balance, tt.expectedResult)
@@ -78,33 +100,41 @@ func TestAccount_HasSufficientFunds(t *testing.T) {
 	tests := []struct {
 		name    string
 		account Account
-		amount  float64
+		amount  Money
 		want    bool
 	}{
 		{
 			name: "Should return true when account balance is greater than or equal to the amount",
 			account: Account{
-				Balance: 100.0,
+				Balance: NewMoney(10000, BRL),
 			},
-			amount: 50.0,
+			amount: NewMoney(5000, BRL),
 			want:   true,
 		},
 		{
 			name: "Should return false when account balance is less than the amount",
 			account: Account{
-				Balance: 100.0,
+				Balance: NewMoney(10000, BRL),
 			},
-			amount: 150.0,
+			amount: NewMoney(15000, BRL),
 			want:   false,
 		},
 		{
 			name: "Should return true when account balance is equal to the amount",
 			account: Account{
-				Balance: 100.0,
+				Balance: NewMoney(10000, BRL),
 			},
-			amount: 100.0,
+			amount: NewMoney(10000, BRL),
 			want:   true,
 		},
+		{
+			name: "Should return false when the amount is in another currency",
+			account: Account{
+				Balance: NewMoney(10000, BRL),
+			},
+			amount: NewMoney(100, "USD"),
+			want:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+type IdempotencyState string
+
+const (
+	IdempotencyInFlight  IdempotencyState = "in_flight"
+	IdempotencyCompleted IdempotencyState = "completed"
+)
+
+// IdempotentRequest is a request made under an Idempotency-Key. Scope is who
+// the key belongs to, so two clients picking the same key never see each
+// other's responses, and Fingerprint identifies the request the key was first
+// used with. An in-flight request holds the key until InFlightUntil: past
+// that it is taken for dead and the key can be claimed again.
+type IdempotentRequest struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	State       IdempotencyState
+
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+
+	ExpiresAt     time.Time
+	InFlightUntil time.Time
+}
+
+// MatchesRequest reports whether fingerprint is the request the key was first
+// used with.
+func (r IdempotentRequest) MatchesRequest(fingerprint string) bool {
+	return r.Fingerprint == fingerprint
+}
+
+func (r IdempotentRequest) IsCompleted() bool {
+	return r.State == IdempotencyCompleted
+}
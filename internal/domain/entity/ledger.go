@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// FundingAccountUUID is the system account deposits are posted against, so the
+// ledger has an origin for money that enters from outside. Its balance is the
+// negative of everything ever deposited.
+const FundingAccountUUID = "00000000-0000-0000-0000-000000000001"
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryTransfer       LedgerEntryType = "transfer"
+	LedgerEntryDeposit        LedgerEntryType = "deposit"
+	LedgerEntryReversal       LedgerEntryType = "reversal"
+	LedgerEntryOpeningBalance LedgerEntryType = "opening_balance"
+)
+
+// LedgerEntry is one posting of a journal. A credit has a positive amount and a
+// debit a negative one, and the postings sharing a JournalUUID sum to zero.
+type LedgerEntry struct {
+	ID          int64
+	JournalUUID string
+	AccountID   int64
+	TransferID  int64
+	Type        LedgerEntryType
+	Amount      Money
+	CreatedAt   time.Time
+}
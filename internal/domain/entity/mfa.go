@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// AccountMFA is the TOTP second factor of an account. Secret is only in plain
+// text in memory; it is stored encrypted. It is pending until ConfirmedAt is
+// set, and asked for at every login from then on.
+type AccountMFA struct {
+	ID        int64
+	AccountID int64
+	Secret    string
+	// ConfirmedAt is set once a first code of the secret was given
+	ConfirmedAt *time.Time
+	// LastUsedStep is the time step of the last code taken, so none is taken
+	// twice
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// Enabled tells whether the factor is asked for at login.
+func (m AccountMFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
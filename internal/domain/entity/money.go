@@ -0,0 +1,227 @@
+package entity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	BRL Currency = "BRL"
+
+	// DefaultCurrency is the currency of every account until the API takes one.
+	DefaultCurrency = BRL
+)
+
+// minorUnitDigits is how many decimal places a minor unit represents. Every
+// currency we support has cents, so it's a constant rather than a table.
+const minorUnitDigits = 2
+
+var minorUnitFactor = int64(math.Pow10(minorUnitDigits))
+
+var (
+	ErrMoneyOverflow         = errors.New("money: amount out of range")
+	ErrMoneyCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrMoneyInvalidAmount    = errors.New("money: invalid amount")
+)
+
+// Money is an amount in minor units (cents) of a currency. The zero value is
+// zero of no currency; it only mixes with amounts of the same currency or with
+// another zero value, so arithmetic never has to guess a currency.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// NewMoney builds a Money from an amount already in minor units.
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{amount: amount, currency: currency}
+}
+
+// ParseMoney reads a decimal string such as "12.34" without going through a
+// float, so "0.1" is exactly 10 cents. More decimal places than the currency
+// has is an error instead of a silent rounding.
+func ParseMoney(value string, currency Currency) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, ErrMoneyInvalidAmount
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > minorUnitDigits {
+		return Money{}, ErrMoneyInvalidAmount
+	}
+	fraction += strings.Repeat("0", minorUnitDigits-len(fraction))
+
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, ErrMoneyInvalidAmount
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Money{}, ErrMoneyOverflow
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	if units > (math.MaxInt64-cents)/minorUnitFactor {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	amount := units*minorUnitFactor + cents
+	if negative {
+		amount = -amount
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Amount returns the value in minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns m + other, failing on a currency mismatch or an int64 overflow.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if (other.amount > 0 && m.amount > math.MaxInt64-other.amount) ||
+		(other.amount < 0 && m.amount < math.MinInt64-other.amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return NewMoney(m.amount+other.amount, currency), nil
+}
+
+// Sub returns m - other, failing on a currency mismatch or an int64 overflow.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return m.Add(other.Neg())
+}
+
+// Neg returns the amount with the sign flipped. MinInt64 has no positive
+// counterpart and is never produced by the checked arithmetic above.
+func (m Money) Neg() Money {
+	return NewMoney(-m.amount, m.currency)
+}
+
+// Cmp compares two amounts of the same currency: -1 if m < other, 0 if equal,
+// +1 if m > other.
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.commonCurrency(other); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// commonCurrency lets a zero value without a currency take part in arithmetic,
+// so an empty accumulator can be summed into.
+func (m Money) commonCurrency(other Money) (Currency, error) {
+	switch {
+	case m.currency == other.currency:
+		return m.currency, nil
+	case m.currency == "" && m.amount == 0:
+		return other.currency, nil
+	case other.currency == "" && other.amount == 0:
+		return m.currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrMoneyCurrencyMismatch, m.currency, other.currency)
+}
+
+// String formats the amount with its decimal places, without the currency.
+func (m Money) String() string {
+	amount := m.amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+
+	// uint64 holds the magnitude of MinInt64, which int64 cannot.
+	magnitude := uint64(amount)
+	if amount < 0 {
+		magnitude = uint64(-(amount + 1)) + 1
+	}
+
+	factor := uint64(minorUnitFactor)
+	return fmt.Sprintf("%s%d.%0*d", sign, magnitude/factor, minorUnitDigits, magnitude%factor)
+}
+
+// MarshalJSON encodes the amount as a JSON number with the currency's decimal
+// places, keeping the wire format the API had when amounts were floats.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string and reads it in the
+// default currency. The token is parsed as text, never as a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	raw := string(data)
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	parsed, err := ParseMoney(raw, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
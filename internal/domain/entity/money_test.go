@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Money
+		wantErr error
+	}{
+		{name: "Should parse an amount with cents", value: "12.34", want: NewMoney(1234, BRL)},
+		{name: "Should parse an amount without cents", value: "12", want: NewMoney(1200, BRL)},
+		{name: "Should parse an amount with one decimal place", value: "0.1", want: NewMoney(10, BRL)},
+		{name: "Should parse a negative amount", value: "-5.05", want: NewMoney(-505, BRL)},
+		{name: "Should parse an amount above the old DECIMAL(7,2) limit", value: "1000000.00", want: NewMoney(100000000, BRL)},
+		{name: "Should reject more decimal places than the currency has", value: "1.005", wantErr: ErrMoneyInvalidAmount},
+		{name: "Should reject an empty value", value: "", wantErr: ErrMoneyInvalidAmount},
+		{name: "Should reject a trailing point", value: "1.", wantErr: ErrMoneyInvalidAmount},
+		{name: "Should reject a non numeric value", value: "1a.00", wantErr: ErrMoneyInvalidAmount},
+		{name: "Should reject exponent notation", value: "1e2", wantErr: ErrMoneyInvalidAmount},
+		{name: "Should reject an amount that does not fit in int64 cents", value: "92233720368547758.08", wantErr: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, BRL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr error
+	}{
+		{name: "Should add two amounts", a: NewMoney(20, BRL), b: NewMoney(10, BRL), want: NewMoney(30, BRL)},
+		{name: "Should add to a zero value without currency", a: Money{}, b: NewMoney(10, BRL), want: NewMoney(10, BRL)},
+		{name: "Should fail on a currency mismatch", a: NewMoney(20, BRL), b: NewMoney(10, "USD"), wantErr: ErrMoneyCurrencyMismatch},
+		{name: "Should fail on overflow", a: NewMoney(math.MaxInt64, BRL), b: NewMoney(1, BRL), wantErr: ErrMoneyOverflow},
+		{name: "Should fail on underflow", a: NewMoney(math.MinInt64, BRL), b: NewMoney(-1, BRL), wantErr: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Money.Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Money.Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Sub(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr error
+	}{
+		{name: "Should subtract two amounts", a: NewMoney(30, BRL), b: NewMoney(10, BRL), want: NewMoney(20, BRL)},
+		{name: "Should go below zero", a: NewMoney(10, BRL), b: NewMoney(30, BRL), want: NewMoney(-20, BRL)},
+		{name: "Should fail on a currency mismatch", a: NewMoney(30, BRL), b: NewMoney(10, "USD"), wantErr: ErrMoneyCurrencyMismatch},
+		{name: "Should fail when subtracting MinInt64", a: NewMoney(0, BRL), b: NewMoney(math.MinInt64, BRL), wantErr: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Sub(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Money.Sub() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Money.Sub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(0, BRL), want: "0.00"},
+		{money: NewMoney(5, BRL), want: "0.05"},
+		{money: NewMoney(555, BRL), want: "5.55"},
+		{money: NewMoney(-1050, BRL), want: "-10.50"},
+		{money: NewMoney(math.MinInt64, BRL), want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("Money.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	type payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	t.Run("Should encode as a number with two decimal places", func(t *testing.T) {
+		got, err := json.Marshal(payload{Amount: NewMoney(1050, BRL)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != `{"amount":10.50}` {
+			t.Errorf("json.Marshal() = %s", got)
+		}
+	})
+
+	t.Run("Should decode a number without going through a float", func(t *testing.T) {
+		var p payload
+		if err := json.Unmarshal([]byte(`{"amount":0.3}`), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Amount != NewMoney(30, DefaultCurrency) {
+			t.Errorf("json.Unmarshal() = %v", p.Amount)
+		}
+	})
+
+	t.Run("Should decode a numeric string", func(t *testing.T) {
+		var p payload
+		if err := json.Unmarshal([]byte(`{"amount":"150000.99"}`), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Amount != NewMoney(15000099, DefaultCurrency) {
+			t.Errorf("json.Unmarshal() = %v", p.Amount)
+		}
+	})
+
+	t.Run("Should reject sub-cent precision", func(t *testing.T) {
+		var p payload
+		if err := json.Unmarshal([]byte(`{"amount":0.001}`), &p); err == nil {
+			t.Errorf("json.Unmarshal() expected an error")
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package entity
+
+// NotificationKind is what a notification tells the owner of an account.
+type NotificationKind string
+
+const (
+	// NotificationPasswordReset carries the token to reset the password, under
+	// the "token" data key, and when it expires under "expires_at"
+	NotificationPasswordReset NotificationKind = "password_reset"
+)
+
+// Notification is a message to the owner of an account. Data is what the
+// message is written from, by kind, so every channel words it its own way.
+type Notification struct {
+	Kind        NotificationKind
+	AccountUUID string
+	Name        string
+	Data        map[string]string
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// Cursor is a position in a list ordered by creation, newest first. The id
+// breaks ties between rows created at the same time.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// CursorPage asks for Limit items of a list ordered newest first: the ones
+// right after After, the ones right before Before, or the first ones when
+// neither is set. Unlike an offset, a cursor keeps its place when rows are
+// added in between requests.
+type CursorPage struct {
+	After  *Cursor
+	Before *Cursor
+	Limit  int64
+	// WithCount also counts the whole list, which costs a second read
+	WithCount bool
+}
+
+// CursorResult tells where the pages around the one read begin. A nil cursor
+// means there is no page that way, and TotalRecords is only set when the
+// count was asked for.
+type CursorResult struct {
+	Next         *Cursor
+	Prev         *Cursor
+	TotalRecords *int64
+}
+
+// SortField is one key a list is sorted by.
+type SortField struct {
+	Field      string
+	Descending bool
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// PasswordReset lets the owner of an account set a new password without the
+// current one. Only the hash of its token is kept; the token itself goes to the
+// owner and nowhere else.
+type PasswordReset struct {
+	ID        int64
+	AccountID int64
+	TokenHash string
+	ExpiresAt time.Time
+	// ConsumedAt is set once the token is used, or voided by a newer one
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,174 @@
+package entity
+
+import "time"
+
+type RecurrenceFrequency string
+
+const (
+	RecurrenceWeekly  RecurrenceFrequency = "weekly"
+	RecurrenceMonthly RecurrenceFrequency = "monthly"
+	// RecurrenceInterval repeats every IntervalDays days
+	RecurrenceInterval RecurrenceFrequency = "interval"
+)
+
+// InsufficientFundsPolicy is what a standing order does with an occurrence the
+// account can't afford.
+type InsufficientFundsPolicy string
+
+const (
+	// InsufficientFundsSkip fails the occurrence and waits for the next one
+	InsufficientFundsSkip InsufficientFundsPolicy = "skip"
+	// InsufficientFundsRetry tries the occurrence again, up to MaxRetries times
+	InsufficientFundsRetry InsufficientFundsPolicy = "retry"
+)
+
+type RecurringTransferStatus string
+
+const (
+	RecurringTransferActive   RecurringTransferStatus = "active"
+	RecurringTransferFinished RecurringTransferStatus = "finished"
+	RecurringTransferCanceled RecurringTransferStatus = "canceled"
+)
+
+// RecurrenceRule says when a standing order runs. Every occurrence is worked
+// out from StartsAt rather than from the one before, so a short month doesn't
+// move the day of the months after it. Days are counted in UTC.
+type RecurrenceRule struct {
+	Frequency RecurrenceFrequency
+	// IntervalDays is only used by RecurrenceInterval
+	IntervalDays int
+	// DayOfMonth is only used by RecurrenceMonthly; in a month without that
+	// day the occurrence falls on its last day
+	DayOfMonth int
+	StartsAt   time.Time
+	// EndsAt and MaxOccurrences are optional, and whichever comes first ends
+	// the rule
+	EndsAt         *time.Time
+	MaxOccurrences int
+}
+
+// Occurrence returns when the nth occurrence (from 0) runs. ok is false when
+// the rule ended before it.
+func (r RecurrenceRule) Occurrence(n int) (at time.Time, ok bool) {
+	if n < 0 || (r.MaxOccurrences > 0 && n >= r.MaxOccurrences) {
+		return at, false
+	}
+
+	start := r.StartsAt.UTC()
+
+	switch r.Frequency {
+	case RecurrenceWeekly:
+		at = start.AddDate(0, 0, 7*n)
+	case RecurrenceInterval:
+		if r.IntervalDays <= 0 {
+			return at, false
+		}
+		at = start.AddDate(0, 0, r.IntervalDays*n)
+	case RecurrenceMonthly:
+		if r.DayOfMonth <= 0 {
+			return at, false
+		}
+		// the first occurrence is in the month it starts, unless that day is
+		// already gone
+		if dayOfMonth(start, 0, r.DayOfMonth).Before(start) {
+			n++
+		}
+		at = dayOfMonth(start, n, r.DayOfMonth)
+	default:
+		return at, false
+	}
+
+	if r.EndsAt != nil && at.After(*r.EndsAt) {
+		return time.Time{}, false
+	}
+
+	return at, true
+}
+
+// dayOfMonth returns day of the month months after start, at the time of day
+// of start, or the last day of that month when it is shorter.
+func dayOfMonth(start time.Time, months, day int) time.Time {
+	first := time.Date(start.Year(), start.Month()+time.Month(months), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// RecurringTransfer is a standing order: the same transfer, repeated as Rule
+// says. Each occurrence becomes a ScheduledTransfer when it is due, and is
+// executed as one.
+type RecurringTransfer struct {
+	ID                     int64
+	RecurringTransferUUID  string
+	AccountOriginID        int64
+	AccountOriginUUID      string
+	AccountDestinationID   int64
+	AccountDestinationUUID string
+	Amount                 Money
+	Rule                   RecurrenceRule
+	CreatedAt              time.Time
+
+	InsufficientFunds InsufficientFundsPolicy
+	MaxRetries        int
+
+	Status RecurringTransferStatus
+	// Occurrences is how many were generated so far
+	Occurrences int
+	// NextRunAt is when the next occurrence is due, nil once there is none
+	NextRunAt  *time.Time
+	CanceledAt *time.Time
+}
+
+func (r RecurringTransfer) IsActive() bool {
+	return r.Status == RecurringTransferActive
+}
+
+// Reschedule works out NextRunAt from the occurrences generated so far, and
+// finishes the rule when there are no more to come.
+func (r *RecurringTransfer) Reschedule() {
+	next, ok := r.Rule.Occurrence(r.Occurrences)
+	if !ok {
+		r.NextRunAt = nil
+		r.Status = RecurringTransferFinished
+		return
+	}
+
+	r.NextRunAt = &next
+	r.Status = RecurringTransferActive
+}
+
+// NextOccurrence is the scheduled transfer for the occurrence due at
+// NextRunAt. It has no UUIDs yet.
+func (r RecurringTransfer) NextOccurrence() ScheduledTransfer {
+	occurrence := ScheduledTransfer{
+		RecurringTransferID:    r.ID,
+		Occurrence:             r.Occurrences + 1,
+		AccountOriginID:        r.AccountOriginID,
+		AccountOriginUUID:      r.AccountOriginUUID,
+		AccountDestinationID:   r.AccountDestinationID,
+		AccountDestinationUUID: r.AccountDestinationUUID,
+		Amount:                 r.Amount,
+		Status:                 ScheduledTransferPending,
+	}
+
+	if r.NextRunAt != nil {
+		occurrence.ScheduledFor = *r.NextRunAt
+	}
+
+	if r.InsufficientFunds == InsufficientFundsRetry {
+		occurrence.MaxRetries = r.MaxRetries
+	}
+
+	return occurrence
+}
+
+// Advance records that the occurrence at NextRunAt was generated and moves on
+// to the next one.
+func (r *RecurringTransfer) Advance() {
+	r.Occurrences++
+	r.Reschedule()
+}
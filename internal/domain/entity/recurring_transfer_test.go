@@ -0,0 +1,163 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrenceRule_Occurrence(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 9, 30, 0, 0, time.UTC)
+	endsAt := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		rule   RecurrenceRule
+		n      int
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "Weekly starts on the start date",
+			rule:   RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start},
+			n:      0,
+			want:   start,
+			wantOk: true,
+		},
+		{
+			name:   "Weekly repeats every seven days",
+			rule:   RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start},
+			n:      2,
+			want:   time.Date(2026, time.February, 14, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "Interval repeats every interval days",
+			rule:   RecurrenceRule{Frequency: RecurrenceInterval, IntervalDays: 10, StartsAt: start},
+			n:      3,
+			want:   time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "Interval without days never runs",
+			rule: RecurrenceRule{Frequency: RecurrenceInterval, StartsAt: start},
+		},
+		{
+			name:   "Monthly falls on the last day of a shorter month",
+			rule:   RecurrenceRule{Frequency: RecurrenceMonthly, DayOfMonth: 31, StartsAt: start},
+			n:      1,
+			want:   time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "Monthly goes back to its day after a shorter month",
+			rule:   RecurrenceRule{Frequency: RecurrenceMonthly, DayOfMonth: 31, StartsAt: start},
+			n:      2,
+			want:   time.Date(2026, time.March, 31, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "Monthly starts next month when the day is already gone",
+			rule:   RecurrenceRule{Frequency: RecurrenceMonthly, DayOfMonth: 5, StartsAt: start},
+			n:      0,
+			want:   time.Date(2026, time.February, 5, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "Monthly crosses the year",
+			rule:   RecurrenceRule{Frequency: RecurrenceMonthly, DayOfMonth: 5, StartsAt: start},
+			n:      11,
+			want:   time.Date(2027, time.January, 5, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "Should end after max occurrences",
+			rule: RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start, MaxOccurrences: 2},
+			n:    2,
+		},
+		{
+			name:   "Should run up to the end date",
+			rule:   RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start, EndsAt: &endsAt},
+			n:      4,
+			want:   time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "Should end after the end date",
+			rule: RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start, EndsAt: &endsAt},
+			n:    5,
+		},
+		{
+			name: "Should not run on an unknown frequency",
+			rule: RecurrenceRule{Frequency: "daily", StartsAt: start},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.rule.Occurrence(tt.n)
+			if ok != tt.wantOk {
+				t.Fatalf("Occurrence() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Occurrence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringTransfer_Advance(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	recurring := RecurringTransfer{
+		ID:                1,
+		Amount:            NewMoney(500, BRL),
+		Rule:              RecurrenceRule{Frequency: RecurrenceWeekly, StartsAt: start, MaxOccurrences: 2},
+		InsufficientFunds: InsufficientFundsRetry,
+		MaxRetries:        3,
+	}
+	recurring.Reschedule()
+
+	if recurring.Status != RecurringTransferActive || recurring.NextRunAt == nil || !recurring.NextRunAt.Equal(start) {
+		t.Fatalf("Reschedule() = %v at %v, want active at %v", recurring.Status, recurring.NextRunAt, start)
+	}
+
+	occurrence := recurring.NextOccurrence()
+	if occurrence.Occurrence != 1 || !occurrence.ScheduledFor.Equal(start) || occurrence.MaxRetries != 3 || occurrence.RecurringTransferID != 1 {
+		t.Errorf("NextOccurrence() = %+v", occurrence)
+	}
+
+	recurring.Advance()
+	if recurring.Occurrences != 1 || !recurring.NextRunAt.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("Advance() = %d occurrences, next at %v", recurring.Occurrences, recurring.NextRunAt)
+	}
+
+	recurring.Advance()
+	if recurring.Status != RecurringTransferFinished || recurring.NextRunAt != nil {
+		t.Errorf("Advance() past the last occurrence = %v at %v, want finished", recurring.Status, recurring.NextRunAt)
+	}
+}
+
+func TestRecurringTransfer_NextOccurrence_skip(t *testing.T) {
+	recurring := RecurringTransfer{InsufficientFunds: InsufficientFundsSkip, MaxRetries: 3}
+
+	if got := recurring.NextOccurrence(); got.MaxRetries != 0 {
+		t.Errorf("NextOccurrence().MaxRetries = %d, want 0 when skipping", got.MaxRetries)
+	}
+}
+
+func TestScheduledTransfer_Retry(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	scheduled := ScheduledTransfer{Status: ScheduledTransferProcessing, TransferUUID: "first", MaxRetries: 1}
+
+	if !scheduled.CanRetry() {
+		t.Fatal("CanRetry() = false, want true")
+	}
+
+	scheduled.Retry(at, "second", "insufficient funds")
+	if scheduled.Status != ScheduledTransferPending || scheduled.TransferUUID != "second" || !scheduled.ScheduledFor.Equal(at) || scheduled.Retries != 1 {
+		t.Errorf("Retry() = %+v", scheduled)
+	}
+
+	if scheduled.CanRetry() {
+		t.Error("CanRetry() = true after the last retry, want false")
+	}
+}
@@ -0,0 +1,88 @@
+package entity
+
+import "time"
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferPending    ScheduledTransferStatus = "pending"
+	ScheduledTransferProcessing ScheduledTransferStatus = "processing"
+	ScheduledTransferCompleted  ScheduledTransferStatus = "completed"
+	ScheduledTransferFailed     ScheduledTransferStatus = "failed"
+	ScheduledTransferCanceled   ScheduledTransferStatus = "canceled"
+)
+
+// ScheduledTransfer is a transfer to be made at ScheduledFor. TransferUUID is
+// chosen when it is scheduled and is the UUID of the transfer that executes
+// it, so an execution that was interrupted can tell whether it got that far.
+type ScheduledTransfer struct {
+	ID                     int64
+	ScheduledTransferUUID  string
+	AccountOriginID        int64
+	AccountOriginUUID      string
+	AccountDestinationID   int64
+	AccountDestinationUUID string
+	Amount                 Money
+	ScheduledFor           time.Time
+	TransferUUID           string
+	CreatedAt              time.Time
+
+	Status        ScheduledTransferStatus
+	FailureReason string
+	Attempts      int
+	ExecutedAt    *time.Time
+	CanceledAt    *time.Time
+
+	// RecurringTransferID is set on an occurrence of a standing order, and
+	// Occurrence is which one it is, from 1
+	RecurringTransferID   int64
+	RecurringTransferUUID string
+	Occurrence            int
+
+	// MaxRetries is how many more times it is tried when the account can't
+	// afford it, and Retries how many it was
+	MaxRetries int
+	Retries    int
+}
+
+// CanCancel reports whether the schedule can still be called off. Once a
+// worker claims it the transfer may already be under way.
+func (s ScheduledTransfer) CanCancel() bool {
+	return s.Status == ScheduledTransferPending
+}
+
+// CanRetry reports whether a run the account couldn't afford is tried again.
+func (s ScheduledTransfer) CanRetry() bool {
+	return s.Retries < s.MaxRetries
+}
+
+// Retry puts the schedule back to pending for another run at at, counting it
+// against MaxRetries. The failed transfer of the run before keeps its UUID, so
+// the new run needs another.
+func (s *ScheduledTransfer) Retry(at time.Time, transferUUID, reason string) {
+	s.Retries++
+	s.Requeue(at, transferUUID, reason)
+}
+
+// Requeue puts the schedule back to pending for another run at at, like Retry
+// but without counting it: the run before didn't go through for a reason of
+// the system, not of the account.
+func (s *ScheduledTransfer) Requeue(at time.Time, transferUUID, reason string) {
+	s.Status = ScheduledTransferPending
+	s.ScheduledFor = at
+	s.TransferUUID = transferUUID
+	s.FailureReason = reason
+}
+
+// Transfer is the pending transfer that executes the schedule.
+func (s ScheduledTransfer) Transfer() Transfer {
+	return Transfer{
+		TransferUUID:           s.TransferUUID,
+		AccountOriginID:        s.AccountOriginID,
+		AccountOriginUUID:      s.AccountOriginUUID,
+		AccountDestinationID:   s.AccountDestinationID,
+		AccountDestinationUUID: s.AccountDestinationUUID,
+		Amount:                 s.Amount,
+		Status:                 TransferPending,
+	}
+}
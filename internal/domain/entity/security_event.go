@@ -0,0 +1,73 @@
+package entity
+
+import "time"
+
+// SecurityEventType names something worth looking into later, such as a
+// login locked by too many failures.
+type SecurityEventType string
+
+const (
+	// SecurityEventLoginLockedCPF is the login of a CPF locked by its failures
+	SecurityEventLoginLockedCPF SecurityEventType = "login_locked_cpf"
+	// SecurityEventLoginLockedIP is the login from an ip locked by its
+	// failures, whatever the CPF
+	SecurityEventLoginLockedIP SecurityEventType = "login_locked_ip"
+	// SecurityEventRefreshTokenReused is a refresh token presented after it
+	// was rotated out, which signs its session out
+	SecurityEventRefreshTokenReused SecurityEventType = "refresh_token_reused"
+)
+
+// SecurityEvent is the record of a SecurityEventType. The CPF goes by its
+// blind index, and AccountID is zero when the event has no known account.
+type SecurityEvent struct {
+	ID          int64
+	Type        SecurityEventType
+	AccountID   int64
+	CPFIndex    string
+	ClientIP    string
+	SessionUUID string
+	LockedUntil *time.Time
+	CreatedAt   time.Time
+}
+
+// LoginThrottle slows down the guessing of passwords. Past FreeAttempts
+// failures in a row, each failure makes the next login wait BaseDelay, doubled
+// at every failure up to MaxDelay. Reaching LockoutAttempts locks the login
+// for LockoutDuration. The failures of a CPF and of an ip are counted apart,
+// and forgotten once Window goes by without one; an ip is only locked out at
+// IPLockoutAttempts, higher as many customers can share one.
+type LoginThrottle struct {
+	FreeAttempts      int64
+	BaseDelay         time.Duration
+	MaxDelay          time.Duration
+	LockoutAttempts   int64
+	LockoutDuration   time.Duration
+	Window            time.Duration
+	IPLockoutAttempts int64
+}
+
+// Delay is how long the login waits after failures failures in a row, with
+// lockout telling whether it is a lockout rather than a back-off. It is zero
+// while there are free attempts left.
+func (l LoginThrottle) Delay(failures, lockoutAttempts int64) (delay time.Duration, lockout bool) {
+	if lockoutAttempts > 0 && failures >= lockoutAttempts {
+		return l.LockoutDuration, true
+	}
+
+	if failures <= l.FreeAttempts || l.BaseDelay <= 0 {
+		return 0, false
+	}
+
+	delay = l.BaseDelay
+	for i := l.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if l.MaxDelay > 0 && delay >= l.MaxDelay {
+			return l.MaxDelay, false
+		}
+	}
+
+	if l.MaxDelay > 0 && delay > l.MaxDelay {
+		return l.MaxDelay, false
+	}
+	return delay, false
+}
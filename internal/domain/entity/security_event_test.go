@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle_Delay(t *testing.T) {
+	throttle := LoginThrottle{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAttempts: 10,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		throttle        LoginThrottle
+		failures        int64
+		lockoutAttempts int64
+		wantDelay       time.Duration
+		wantLockout     bool
+	}{
+		{
+			name:            "Should not wait while there are free attempts",
+			throttle:        throttle,
+			failures:        3,
+			lockoutAttempts: 10,
+		},
+		{
+			name:            "Should wait the base delay after the free attempts",
+			throttle:        throttle,
+			failures:        4,
+			lockoutAttempts: 10,
+			wantDelay:       time.Second,
+		},
+		{
+			name:            "Should double the delay at every failure",
+			throttle:        throttle,
+			failures:        6,
+			lockoutAttempts: 10,
+			wantDelay:       4 * time.Second,
+		},
+		{
+			name:            "Should not wait more than the max delay",
+			throttle:        throttle,
+			failures:        80,
+			lockoutAttempts: 0,
+			wantDelay:       time.Minute,
+		},
+		{
+			name:            "Should lock out at the lockout attempts",
+			throttle:        throttle,
+			failures:        10,
+			lockoutAttempts: 10,
+			wantDelay:       15 * time.Minute,
+			wantLockout:     true,
+		},
+		{
+			name:            "Should not wait without a base delay",
+			throttle:        LoginThrottle{FreeAttempts: 3},
+			failures:        5,
+			lockoutAttempts: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, lockout := tt.throttle.Delay(tt.failures, tt.lockoutAttempts)
+			if delay != tt.wantDelay {
+				t.Errorf("Delay() delay = %v, want %v", delay, tt.wantDelay)
+			}
+			if lockout != tt.wantLockout {
+				t.Errorf("Delay() lockout = %v, want %v", lockout, tt.wantLockout)
+			}
+		})
+	}
+}
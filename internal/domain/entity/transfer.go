@@ -1,12 +1,114 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TransferStatus string
+
+const (
+	TransferPending   TransferStatus = "pending"
+	TransferCompleted TransferStatus = "completed"
+	TransferFailed    TransferStatus = "failed"
+	TransferReversed  TransferStatus = "reversed"
+)
+
+var ErrInvalidTransferTransition = errors.New("transfer: invalid status transition")
+
+// transferTransitions lists where each status may go. Failed and reversed are
+// final, and a partial reversal leaves the transfer completed.
+var transferTransitions = map[TransferStatus][]TransferStatus{
+	TransferPending:   {TransferCompleted, TransferFailed},
+	TransferCompleted: {TransferReversed},
+}
+
+func (s TransferStatus) CanTransitionTo(next TransferStatus) bool {
+	for _, allowed := range transferTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
 
 type Transfer struct {
 	ID                     int64
 	TransferUUID           string
+	AccountOriginID        int64
 	AccountOriginUUID      string
+	AccountDestinationID   int64
 	AccountDestinationUUID string
-	Amount                 float64
+	Amount                 Money
 	CreatedAt              time.Time
+
+	Status        TransferStatus
+	FailureReason string
+	CompletedAt   *time.Time
+	FailedAt      *time.Time
+	ReversedAt    *time.Time
+
+	// ReversedTransferID is set on a reversal and points to the transfer it
+	// gives money back for.
+	ReversedTransferID   int64
+	ReversedTransferUUID string
+}
+
+// TransferDirection tells a transfer an account sent from one it received.
+type TransferDirection string
+
+const (
+	TransferSent     TransferDirection = "sent"
+	TransferReceived TransferDirection = "received"
+)
+
+// TransferFilter narrows the transfers of an account. A zero field filters
+// nothing, so the zero value lists every transfer the account sent or
+// received.
+type TransferFilter struct {
+	Direction TransferDirection
+	// CreatedFrom is inclusive and CreatedTo is exclusive
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinAmount   Money
+	MaxAmount   Money
+	// CounterpartyUUID is the other account of the transfer
+	CounterpartyUUID string
+}
+
+func (t Transfer) IsReversal() bool {
+	return t.ReversedTransferID != 0
+}
+
+// TransitionTo moves the transfer to next and stamps when it happened, or
+// returns ErrInvalidTransferTransition leaving it untouched.
+func (t *Transfer) TransitionTo(next TransferStatus, at time.Time) error {
+	if !t.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransferTransition, t.Status, next)
+	}
+
+	t.Status = next
+
+	switch next {
+	case TransferCompleted:
+		t.CompletedAt = &at
+	case TransferFailed:
+		t.FailedAt = &at
+	case TransferReversed:
+		t.ReversedAt = &at
+	}
+
+	return nil
+}
+
+// Fail moves a pending transfer to failed, keeping why it was declined.
+func (t *Transfer) Fail(reason string, at time.Time) error {
+	err := t.TransitionTo(TransferFailed, at)
+	if err != nil {
+		return err
+	}
+
+	t.FailureReason = reason
+	return nil
 }
@@ -0,0 +1,175 @@
+package entity
+
+import "time"
+
+// TransferLimitKind names one of the caps on what an account sends.
+type TransferLimitKind string
+
+const (
+	TransferLimitPerTransaction TransferLimitKind = "per_transaction"
+	TransferLimitDaily          TransferLimitKind = "daily"
+	TransferLimitMonthly        TransferLimitKind = "monthly"
+	TransferLimitNightTime      TransferLimitKind = "night_time"
+)
+
+// TransferLimits caps what an account sends, the way Brazilian banks cap Pix:
+// a maximum per transfer, a daily and a monthly total, and a lower total for
+// the night, from NightStartHour to NightEndHour. A zero cap is no cap, and
+// equal night hours mean there is no night.
+type TransferLimits struct {
+	PerTransaction Money
+	Daily          Money
+	Monthly        Money
+	NightTime      Money
+	NightStartHour int
+	NightEndHour   int
+	// Location is where days, months and nights begin; UTC when nil
+	Location *time.Location
+}
+
+// AccountLimit overrides the default limits for one account. A nil cap keeps
+// the default one, and a zero cap lifts it.
+type AccountLimit struct {
+	AccountID      int64
+	PerTransaction *Money
+	Daily          *Money
+	Monthly        *Money
+	NightTime      *Money
+}
+
+// TransferPeriods are the moments the usage of each cumulative cap is counted
+// from.
+type TransferPeriods struct {
+	DayStart   time.Time
+	MonthStart time.Time
+	// NightStart is nil outside the night
+	NightStart *time.Time
+}
+
+// TransferUsage is what an account already sent in each period.
+type TransferUsage struct {
+	Daily     Money
+	Monthly   Money
+	NightTime Money
+}
+
+// WithOverride returns the limits with the caps the account overrides.
+func (l TransferLimits) WithOverride(override AccountLimit) TransferLimits {
+	if override.PerTransaction != nil {
+		l.PerTransaction = *override.PerTransaction
+	}
+	if override.Daily != nil {
+		l.Daily = *override.Daily
+	}
+	if override.Monthly != nil {
+		l.Monthly = *override.Monthly
+	}
+	if override.NightTime != nil {
+		l.NightTime = *override.NightTime
+	}
+	return l
+}
+
+// IsCumulative tells whether any cap depends on what was already sent, which
+// is what makes the usage worth reading.
+func (l TransferLimits) IsCumulative() bool {
+	return l.Daily.IsPositive() || l.Monthly.IsPositive() || (l.NightTime.IsPositive() && l.hasNight())
+}
+
+func (l TransferLimits) hasNight() bool {
+	return l.NightStartHour != l.NightEndHour
+}
+
+func (l TransferLimits) location() *time.Location {
+	if l.Location == nil {
+		return time.UTC
+	}
+	return l.Location
+}
+
+// Periods returns where the day, the month and, if at is in one, the night
+// that at falls in began.
+func (l TransferLimits) Periods(at time.Time) (periods TransferPeriods) {
+	at = at.In(l.location())
+	year, month, day := at.Date()
+
+	periods.DayStart = time.Date(year, month, day, 0, 0, 0, 0, at.Location())
+	periods.MonthStart = time.Date(year, month, 1, 0, 0, 0, 0, at.Location())
+
+	if !l.hasNight() {
+		return periods
+	}
+
+	nightStart := time.Date(year, month, day, l.NightStartHour, 0, 0, 0, at.Location())
+	hour := at.Hour()
+
+	switch {
+	case l.NightStartHour < l.NightEndHour:
+		// the night is within the day, as from midnight to 6
+		if hour < l.NightStartHour || hour >= l.NightEndHour {
+			return periods
+		}
+	case hour >= l.NightStartHour:
+		// the night crosses midnight and began today
+	case hour < l.NightEndHour:
+		// the night crosses midnight and began yesterday
+		nightStart = nightStart.AddDate(0, 0, -1)
+	default:
+		return periods
+	}
+
+	periods.NightStart = &nightStart
+	return periods
+}
+
+// limitCap is one cap of TransferLimits, along with what already counts
+// against it.
+type limitCap struct {
+	kind  TransferLimitKind
+	limit Money
+	used  Money
+}
+
+// Check returns the first cap amount goes over, and how much of that cap was
+// left, given what was already sent in periods. exceeded is empty when amount
+// fits every cap.
+func (l TransferLimits) Check(amount Money, usage TransferUsage, periods TransferPeriods) (exceeded TransferLimitKind, remaining Money, err error) {
+	caps := []limitCap{{kind: TransferLimitPerTransaction, limit: l.PerTransaction}}
+
+	// the night cap is the tighter one, so it goes first and its remaining is
+	// the one reported
+	if periods.NightStart != nil {
+		caps = append(caps, limitCap{kind: TransferLimitNightTime, limit: l.NightTime, used: usage.NightTime})
+	}
+
+	caps = append(caps,
+		limitCap{kind: TransferLimitDaily, limit: l.Daily, used: usage.Daily},
+		limitCap{kind: TransferLimitMonthly, limit: l.Monthly, used: usage.Monthly},
+	)
+
+	for _, c := range caps {
+		if !c.limit.IsPositive() {
+			continue
+		}
+
+		remaining, err = c.limit.Sub(c.used)
+		if err != nil {
+			return exceeded, remaining, err
+		}
+
+		if remaining.IsNegative() {
+			remaining = NewMoney(0, remaining.Currency())
+		}
+
+		cmp, err := amount.Cmp(remaining)
+		if err != nil {
+			return exceeded, remaining, err
+		}
+
+		if cmp > 0 {
+			return c.kind, remaining, nil
+		}
+	}
+
+	return "", Money{}, nil
+}
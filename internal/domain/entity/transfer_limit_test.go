@@ -0,0 +1,192 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransferLimits_Periods(t *testing.T) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	limits := TransferLimits{NightStartHour: 20, NightEndHour: 6, Location: saoPaulo}
+
+	tests := []struct {
+		name           string
+		limits         TransferLimits
+		at             time.Time
+		wantDayStart   time.Time
+		wantNightStart *time.Time
+	}{
+		{
+			name:         "Should be day in the afternoon",
+			limits:       limits,
+			at:           time.Date(2026, time.March, 10, 15, 0, 0, 0, saoPaulo),
+			wantDayStart: time.Date(2026, time.March, 10, 0, 0, 0, 0, saoPaulo),
+		},
+		{
+			name:           "Should be a night that began today in the evening",
+			limits:         limits,
+			at:             time.Date(2026, time.March, 10, 22, 0, 0, 0, saoPaulo),
+			wantDayStart:   time.Date(2026, time.March, 10, 0, 0, 0, 0, saoPaulo),
+			wantNightStart: ptr(time.Date(2026, time.March, 10, 20, 0, 0, 0, saoPaulo)),
+		},
+		{
+			name:           "Should be a night that began yesterday before dawn",
+			limits:         limits,
+			at:             time.Date(2026, time.March, 1, 3, 0, 0, 0, saoPaulo),
+			wantDayStart:   time.Date(2026, time.March, 1, 0, 0, 0, 0, saoPaulo),
+			wantNightStart: ptr(time.Date(2026, time.February, 28, 20, 0, 0, 0, saoPaulo)),
+		},
+		{
+			name:         "Should count the day where the limits are, not in UTC",
+			limits:       limits,
+			at:           time.Date(2026, time.March, 11, 1, 0, 0, 0, time.UTC),
+			wantDayStart: time.Date(2026, time.March, 10, 0, 0, 0, 0, saoPaulo),
+			// 22h in São Paulo
+			wantNightStart: ptr(time.Date(2026, time.March, 10, 20, 0, 0, 0, saoPaulo)),
+		},
+		{
+			name:           "Should handle a night within the day",
+			limits:         TransferLimits{NightStartHour: 0, NightEndHour: 6},
+			at:             time.Date(2026, time.March, 10, 5, 59, 0, 0, time.UTC),
+			wantDayStart:   time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC),
+			wantNightStart: ptr(time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:         "Should have no night when its hours are equal",
+			limits:       TransferLimits{},
+			at:           time.Date(2026, time.March, 10, 23, 0, 0, 0, time.UTC),
+			wantDayStart: time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods := tt.limits.Periods(tt.at)
+
+			if !periods.DayStart.Equal(tt.wantDayStart) {
+				t.Errorf("Periods().DayStart = %v, want %v", periods.DayStart, tt.wantDayStart)
+			}
+
+			wantMonthStart := time.Date(tt.wantDayStart.Year(), tt.wantDayStart.Month(), 1, 0, 0, 0, 0, tt.wantDayStart.Location())
+			if !periods.MonthStart.Equal(wantMonthStart) {
+				t.Errorf("Periods().MonthStart = %v, want %v", periods.MonthStart, wantMonthStart)
+			}
+
+			switch {
+			case tt.wantNightStart == nil && periods.NightStart != nil:
+				t.Errorf("Periods().NightStart = %v, want none", *periods.NightStart)
+			case tt.wantNightStart != nil && (periods.NightStart == nil || !periods.NightStart.Equal(*tt.wantNightStart)):
+				t.Errorf("Periods().NightStart = %v, want %v", periods.NightStart, *tt.wantNightStart)
+			}
+		})
+	}
+}
+
+func TestTransferLimits_Check(t *testing.T) {
+	brl := func(amount int64) Money { return NewMoney(amount, BRL) }
+	night := time.Now()
+
+	limits := TransferLimits{
+		PerTransaction: brl(5000),
+		Daily:          brl(10000),
+		Monthly:        brl(30000),
+		NightTime:      brl(1000),
+	}
+
+	tests := []struct {
+		name          string
+		limits        TransferLimits
+		amount        Money
+		usage         TransferUsage
+		periods       TransferPeriods
+		wantExceeded  TransferLimitKind
+		wantRemaining Money
+	}{
+		{
+			name:   "Should fit every cap",
+			limits: limits,
+			amount: brl(5000),
+			usage:  TransferUsage{Daily: brl(5000), Monthly: brl(25000)},
+		},
+		{
+			name:          "Should go over the per-transaction cap",
+			limits:        limits,
+			amount:        brl(5001),
+			wantExceeded:  TransferLimitPerTransaction,
+			wantRemaining: brl(5000),
+		},
+		{
+			name:          "Should go over the daily cap",
+			limits:        limits,
+			amount:        brl(3000),
+			usage:         TransferUsage{Daily: brl(8000), Monthly: brl(8000)},
+			wantExceeded:  TransferLimitDaily,
+			wantRemaining: brl(2000),
+		},
+		{
+			name:          "Should go over the monthly cap",
+			limits:        limits,
+			amount:        brl(3000),
+			usage:         TransferUsage{Monthly: brl(28000)},
+			wantExceeded:  TransferLimitMonthly,
+			wantRemaining: brl(2000),
+		},
+		{
+			name:          "Should go over the night cap at night",
+			limits:        limits,
+			amount:        brl(600),
+			usage:         TransferUsage{Daily: brl(600), Monthly: brl(600), NightTime: brl(600)},
+			periods:       TransferPeriods{NightStart: &night},
+			wantExceeded:  TransferLimitNightTime,
+			wantRemaining: brl(400),
+		},
+		{
+			name:   "Should ignore the night cap during the day",
+			limits: limits,
+			amount: brl(600),
+			usage:  TransferUsage{Daily: brl(600), Monthly: brl(600), NightTime: brl(600)},
+		},
+		{
+			name:          "Should have nothing left when the usage is over a lowered cap",
+			limits:        limits,
+			amount:        brl(1),
+			usage:         TransferUsage{Daily: brl(12000), Monthly: brl(12000)},
+			wantExceeded:  TransferLimitDaily,
+			wantRemaining: brl(0),
+		},
+		{
+			name:   "Should not cap anything without limits",
+			amount: brl(1_000_000),
+			usage:  TransferUsage{Daily: brl(1_000_000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded, remaining, err := tt.limits.Check(tt.amount, tt.usage, tt.periods)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if exceeded != tt.wantExceeded {
+				t.Errorf("Check() exceeded = %q, want %q", exceeded, tt.wantExceeded)
+			}
+			if remaining != tt.wantRemaining {
+				t.Errorf("Check() remaining = %v, want %v", remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestTransferLimits_WithOverride(t *testing.T) {
+	daily := NewMoney(50000, BRL)
+	lifted := NewMoney(0, BRL)
+
+	limits := TransferLimits{PerTransaction: NewMoney(5000, BRL), Daily: NewMoney(10000, BRL), NightTime: NewMoney(1000, BRL)}
+
+	got := limits.WithOverride(AccountLimit{Daily: &daily, NightTime: &lifted})
+	if got.Daily != daily || got.PerTransaction != limits.PerTransaction || !got.NightTime.IsZero() {
+		t.Errorf("WithOverride() = %+v", got)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransferStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from TransferStatus
+		to   TransferStatus
+		want bool
+	}{
+		{from: TransferPending, to: TransferCompleted, want: true},
+		{from: TransferPending, to: TransferFailed, want: true},
+		{from: TransferCompleted, to: TransferReversed, want: true},
+		{from: TransferPending, to: TransferReversed},
+		{from: TransferCompleted, to: TransferFailed},
+		{from: TransferCompleted, to: TransferPending},
+		{from: TransferFailed, to: TransferCompleted},
+		{from: TransferReversed, to: TransferCompleted},
+		{from: TransferCompleted, to: TransferCompleted},
+		{from: "", to: TransferCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransfer_TransitionTo(t *testing.T) {
+	at := time.Now()
+
+	transfer := Transfer{Status: TransferPending}
+	if err := transfer.TransitionTo(TransferCompleted, at); err != nil {
+		t.Fatalf("TransitionTo() error = %v", err)
+	}
+	if transfer.Status != TransferCompleted || transfer.CompletedAt == nil || !transfer.CompletedAt.Equal(at) {
+		t.Errorf("TransitionTo() did not complete the transfer: %+v", transfer)
+	}
+
+	if err := transfer.TransitionTo(TransferReversed, at); err != nil {
+		t.Fatalf("TransitionTo() error = %v", err)
+	}
+	if transfer.ReversedAt == nil {
+		t.Errorf("TransitionTo() did not stamp the reversal")
+	}
+
+	err := transfer.TransitionTo(TransferCompleted, at)
+	if !errors.Is(err, ErrInvalidTransferTransition) {
+		t.Errorf("TransitionTo() error = %v, want %v", err, ErrInvalidTransferTransition)
+	}
+	if transfer.Status != TransferReversed {
+		t.Errorf("an invalid transition changed the status to %s", transfer.Status)
+	}
+}
+
+func TestTransfer_Fail(t *testing.T) {
+	transfer := Transfer{Status: TransferPending}
+	if err := transfer.Fail("insufficient funds", time.Now()); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	if transfer.Status != TransferFailed || transfer.FailedAt == nil || transfer.FailureReason != "insufficient funds" {
+		t.Errorf("Fail() did not fail the transfer: %+v", transfer)
+	}
+
+	completed := Transfer{Status: TransferCompleted}
+	if err := completed.Fail("late", time.Now()); !errors.Is(err, ErrInvalidTransferTransition) {
+		t.Errorf("Fail() error = %v, want %v", err, ErrInvalidTransferTransition)
+	}
+	if completed.FailureReason != "" {
+		t.Errorf("Fail() kept a reason on a transfer it could not fail")
+	}
+}
@@ -10,12 +10,59 @@ var (
 	ErrSessionBlocked      = apperr.Define(apperr.KindAuthentication, "AUTH_SESSION_BLOCKED", "session blocked")
 	ErrSessionTokenMismatch = apperr.Define(apperr.KindAuthentication, "AUTH_SESSION_TOKEN_MISMATCH", "mismatched session token")
 	ErrSessionExpired      = apperr.Define(apperr.KindAuthentication, "AUTH_SESSION_EXPIRED", "session has expired")
+	ErrUnknownSession      = apperr.Define(apperr.KindNotFound, "AUTH_UNKNOWN_SESSION", "the account has no such session")
+	ErrRefreshTokenReused  = apperr.Define(apperr.KindAuthentication, "AUTH_REFRESH_TOKEN_REUSED", "the refresh token was already used, the session was signed out")
+	ErrInvalidResetToken   = apperr.Define(apperr.KindValidation, "AUTH_INVALID_RESET_TOKEN", "the password reset token is invalid or has expired")
+	ErrForbidden           = apperr.Define(apperr.KindAuthentication, "AUTH_FORBIDDEN", "the account is not allowed to do this")
+	ErrSessionCheckUnavailable = apperr.Define(apperr.KindInternal, "AUTH_SESSION_CHECK_UNAVAILABLE", "the session can't be checked right now, try again later")
+	ErrAccountLocked       = apperr.Define(apperr.KindAuthentication, "AUTH_ACCOUNT_LOCKED", "too many failed logins, try again later")
+	ErrInvalidMFACode      = apperr.Define(apperr.KindAuthentication, "AUTH_INVALID_MFA_CODE", "the code is wrong or was already used")
+	ErrMFAAlreadyEnabled   = apperr.Define(apperr.KindConflict, "AUTH_MFA_ALREADY_ENABLED", "two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = apperr.Define(apperr.KindConflict, "AUTH_MFA_NOT_ENROLLED", "two-factor authentication wasn't enrolled")
+	ErrMFATokenSpent = apperr.Define(apperr.KindAuthentication, "AUTH_MFA_TOKEN_SPENT", "the login token took too many codes, log in again")
+	ErrWrongMFACode        = apperr.Define(apperr.KindValidation, "AUTH_WRONG_MFA_CODE", "the code is wrong")
 
 	// Account errors
-	ErrCPFAlreadyInUse = apperr.Define(apperr.KindConflict, "ACCOUNT_CPF_EXISTS", "the CPF is already in use")
+	ErrCPFAlreadyInUse           = apperr.Define(apperr.KindConflict, "ACCOUNT_CPF_EXISTS", "the CPF is already in use")
+	ErrAccountNotFound           = apperr.Define(apperr.KindNotFound, "ACCOUNT_NOT_FOUND", "account not found")
+	ErrAccountClosed             = apperr.Define(apperr.KindConflict, "ACCOUNT_CLOSED", "the account is closed")
+	ErrAccountAlreadyDeactivated = apperr.Define(apperr.KindConflict, "ACCOUNT_ALREADY_DEACTIVATED", "the account is already deactivated")
+	ErrAccountAlreadyActive      = apperr.Define(apperr.KindConflict, "ACCOUNT_ALREADY_ACTIVE", "the account is already active")
+	ErrAccountBalanceNotZero     = apperr.Define(apperr.KindConflict, "ACCOUNT_BALANCE_NOT_ZERO", "the account still has a balance, it must be zero or be swept to another account")
+	ErrInvalidSweepAccount       = apperr.Define(apperr.KindValidation, "ACCOUNT_INVALID_SWEEP_ACCOUNT", "the balance can't be swept to this account")
+	ErrWrongCurrentPassword      = apperr.Define(apperr.KindValidation, "ACCOUNT_WRONG_CURRENT_PASSWORD", "the current password is wrong")
+
+	// Rate limit errors
+	ErrTooManyRequests = apperr.Define(apperr.KindValidation, "RATE_LIMITED", "too many requests, try again later")
+
+	// Idempotency errors
+	ErrIdempotencyKeyInFlight = apperr.Define(apperr.KindConflict, "IDEMPOTENCY_KEY_IN_FLIGHT", "a request with this idempotency key is still being processed")
+	ErrIdempotencyKeyReused   = apperr.Define(apperr.KindValidation, "IDEMPOTENCY_KEY_REUSED", "this idempotency key was already used with a different request")
 
 	// Transfer errors
 	ErrInsufficientFunds       = apperr.Define(apperr.KindConflict, "TRANSFER_INSUFFICIENT_FUNDS", "your account doesn't have sufficient funds to do this operation")
 	ErrSelfTransfer            = apperr.Define(apperr.KindValidation, "TRANSFER_SELF_TRANSFER", "you can't transfer to yourself")
 	ErrInvalidDestinationAccount = apperr.Define(apperr.KindNotFound, "TRANSFER_DEST_ACCOUNT_NOT_FOUND", "invalid destination account")
+	ErrDestinationAccountClosed  = apperr.Define(apperr.KindConflict, "TRANSFER_DEST_ACCOUNT_CLOSED", "the destination account is closed")
+	ErrOriginAccountDeactivated  = apperr.Define(apperr.KindConflict, "TRANSFER_ORIGIN_ACCOUNT_DEACTIVATED", "the account is deactivated, it can't send transfers until it is reactivated")
+	ErrTransferNotFound          = apperr.Define(apperr.KindNotFound, "TRANSFER_NOT_FOUND", "transfer not found")
+	ErrTransferAlreadyReversed   = apperr.Define(apperr.KindConflict, "TRANSFER_ALREADY_REVERSED", "the transfer was already fully reversed")
+	ErrReversalExceedsAmount     = apperr.Define(apperr.KindValidation, "TRANSFER_REVERSAL_EXCEEDS_AMOUNT", "the reversal is greater than what is left to reverse of the transfer")
+	ErrTransferNotCompleted      = apperr.Define(apperr.KindConflict, "TRANSFER_NOT_COMPLETED", "only a completed transfer can be reversed")
+	ErrReversalNotReversible     = apperr.Define(apperr.KindValidation, "TRANSFER_REVERSAL_NOT_REVERSIBLE", "a reversal can't be reversed")
+
+	// Transfer limit errors, returned with a message saying how much of the
+	// limit is left
+	ErrTransferPerTransactionLimitExceeded = apperr.Define(apperr.KindValidation, "TRANSFER_PER_TRANSACTION_LIMIT_EXCEEDED", "the amount is over the limit for a single transfer")
+	ErrTransferDailyLimitExceeded          = apperr.Define(apperr.KindConflict, "TRANSFER_DAILY_LIMIT_EXCEEDED", "the amount is over what is left of the daily transfer limit")
+	ErrTransferMonthlyLimitExceeded        = apperr.Define(apperr.KindConflict, "TRANSFER_MONTHLY_LIMIT_EXCEEDED", "the amount is over what is left of the monthly transfer limit")
+	ErrTransferNightTimeLimitExceeded      = apperr.Define(apperr.KindConflict, "TRANSFER_NIGHT_TIME_LIMIT_EXCEEDED", "the amount is over what is left of the night-time transfer limit")
+
+	// Scheduled transfer errors
+	ErrScheduledTransferNotFound      = apperr.Define(apperr.KindNotFound, "SCHEDULED_TRANSFER_NOT_FOUND", "scheduled transfer not found")
+	ErrScheduledTransferNotCancelable = apperr.Define(apperr.KindConflict, "SCHEDULED_TRANSFER_NOT_CANCELABLE", "only a scheduled transfer that hasn't started can be canceled")
+
+	// Recurring transfer errors
+	ErrRecurringTransferNotFound  = apperr.Define(apperr.KindNotFound, "RECURRING_TRANSFER_NOT_FOUND", "recurring transfer not found")
+	ErrRecurringTransferNotActive = apperr.Define(apperr.KindConflict, "RECURRING_TRANSFER_NOT_ACTIVE", "the recurring transfer already finished or was canceled")
 )
@@ -0,0 +1,30 @@
+package errcodes
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AccountLockedError is ErrAccountLocked along with how long until the login
+// can be tried again.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func NewAccountLockedError(retryAfter time.Duration) *AccountLockedError {
+	return &AccountLockedError{RetryAfter: retryAfter}
+}
+
+// RetryAfterSeconds is RetryAfter rounded up, as the Retry-After header takes it.
+func (e *AccountLockedError) RetryAfterSeconds() int64 {
+	return int64(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+func (e *AccountLockedError) Error() string {
+	return e.Unwrap().Error()
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked.WithMessage(fmt.Sprintf("too many failed logins, try again in %d seconds", e.RetryAfterSeconds()))
+}
@@ -0,0 +1,32 @@
+package errcodes
+
+import "errors"
+
+// UnappliedError is the error of a request that failed before it changed
+// anything, so running it again is safe. The idempotency middleware gives back
+// the key of such a request instead of keeping its outcome.
+type UnappliedError struct {
+	err error
+}
+
+// NewUnappliedError marks err as unapplied. A nil err stays nil.
+func NewUnappliedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UnappliedError{err: err}
+}
+
+func (e *UnappliedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *UnappliedError) Unwrap() error {
+	return e.err
+}
+
+// IsUnapplied reports whether err was marked by NewUnappliedError.
+func IsUnapplied(err error) bool {
+	var unapplied *UnappliedError
+	return errors.As(err, &unapplied)
+}
@@ -11,6 +11,7 @@ type Infrastructure interface {
 	DataManager() contract.DataManager
 	Logger() logger.Logger
 	Crypto() contract.Crypto
+	Notifier() contract.Notifier
 	Validator() apperrmap.Validator
 }
 
@@ -19,6 +20,7 @@ type infrastructureServices struct {
 	dataManager  contract.DataManager
 	logger       logger.Logger
 	crypto       contract.Crypto
+	notifier     contract.Notifier
 	validator    apperrmap.Validator
 }
 
@@ -48,6 +50,12 @@ func WithCrypto(crypto contract.Crypto) InfraOption {
 	}
 }
 
+func WithNotifier(notifier contract.Notifier) InfraOption {
+	return func(i *infrastructureServices) {
+		i.notifier = notifier
+	}
+}
+
 func WithValidator(v apperrmap.Validator) InfraOption {
 	return func(i *infrastructureServices) {
 		i.validator = v
@@ -78,6 +86,10 @@ func (i *infrastructureServices) Crypto() contract.Crypto {
 	return i.crypto
 }
 
+func (i *infrastructureServices) Notifier() contract.Notifier {
+	return i.notifier
+}
+
 func (i *infrastructureServices) Validator() apperrmap.Validator {
 	return i.validator
 }
@@ -3,7 +3,11 @@ package accountroute
 import (
 	"sync"
 
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 
@@ -68,26 +72,165 @@ func (s *Handler) handleAddBalance(c echo.Context) error {
 	return routeutils.ResponseCreated(c)
 }
 
+func (s *Handler) handleDeactivateAccount(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.ChangeAccountStatus{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	accountUUID, err := routeutils.GetRequiredStringPathParam(c, "account_uuid", "account_uuid is required")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.accountService.DeactivateAccount(ctx, input.ToDto(accountUUID))
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handleReactivateAccount(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.ChangeAccountStatus{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	accountUUID, err := routeutils.GetRequiredStringPathParam(c, "account_uuid", "account_uuid is required")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.accountService.ReactivateAccount(ctx, input.ToDto(accountUUID))
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handleCloseAccount(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.CloseAccount{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	accountUUID, err := routeutils.GetRequiredStringPathParam(c, "account_uuid", "account_uuid is required")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.accountService.CloseAccount(ctx, input.ToDto(accountUUID))
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
 func (s *Handler) handleGetAccounts(c echo.Context) error {
 	ctx := routeutils.GetContext(c)
 
+	input, err := accountSearchInput(c)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	page, byCursor, err := routeutils.GetCursorParams(c, "cursor", "limit")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	if byCursor {
+		page.WithCount = routeutils.GetBoolQueryParam(c, "with_count")
+
+		accounts, result, err := s.accountService.GetAccountsByCursor(ctx, input, page)
+		if err != nil {
+			return routeutils.HandleError(c, err)
+		}
+
+		return routeutils.ResponseAPIOk(c, viewmodel.BuildCursorResponse(accountsResponse(c, accounts), result))
+	}
+
 	take, skip := routeutils.GetPagingParams(c, "page", "quantity")
 
-	accounts, totalRecords, err := s.accountService.GetAccounts(ctx, take, skip)
+	accounts, totalRecords, err := s.accountService.GetAccounts(ctx, input, take, skip)
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
+	responsePaginated := viewmodel.BuildPaginatedResponse(accountsResponse(c, accounts), skip, take, totalRecords)
+
+	return routeutils.ResponseAPIOk(c, responsePaginated)
+}
+
+// accountSearchInput reads the filters and the sort of the accounts list from
+// the query params, leaving the validation of their values to the service.
+func accountSearchInput(c echo.Context) (input dto.AccountSearchInput, err error) {
+	input.Name = c.QueryParam("name")
+	input.CPF = c.QueryParam("cpf")
+	if sort := routeutils.GetStringArrayQueryParam(c, "sort", ","); len(sort) > 0 {
+		input.Sort = sort
+	}
+
+	active, ok, err := routeutils.GetOptionalParam(c.QueryParam("active"), routeutils.BoolConverter, "invalid active, it must be true or false")
+	if err != nil {
+		return input, err
+	}
+	if ok {
+		input.Active = &active
+	}
+
+	input.CreatedFrom, err = routeutils.GetTimeQueryParam(c, "from", "invalid from, it must be an RFC 3339 time")
+	if err != nil {
+		return input, err
+	}
+
+	input.CreatedTo, err = routeutils.GetTimeQueryParam(c, "to", "invalid to, it must be an RFC 3339 time")
+	if err != nil {
+		return input, err
+	}
+
+	return input, nil
+}
+
+// canSeeWholeCPF tells whether the caller is the holder of account or an
+// admin, the only ones who see its CPF unmasked.
+func canSeeWholeCPF(c echo.Context, account entity.Account) bool {
+	role, _ := c.Get(infra.RoleKey.String()).(entity.Role)
+	return role == entity.RoleAdmin || isLoggedAccount(c, account.UUID)
+}
+
+// canReadAccount tells whether the caller may read the account of
+// accountUUID: a customer only reads its own, the staff reads any.
+func canReadAccount(c echo.Context, accountUUID string) bool {
+	role, _ := c.Get(infra.RoleKey.String()).(entity.Role)
+	return role == entity.RoleSupport || role == entity.RoleAdmin || isLoggedAccount(c, accountUUID)
+}
+
+func isLoggedAccount(c echo.Context, accountUUID string) bool {
+	loggedAccountUUID, _ := c.Get(infra.AccountUUIDKey.String()).(string)
+	return loggedAccountUUID != "" && loggedAccountUUID == accountUUID
+}
+
+func accountsResponse(c echo.Context, accounts []entity.Account) []viewmodel.AccountResponse {
 	response := []viewmodel.AccountResponse{}
 	for _, account := range accounts {
 		item := viewmodel.AccountResponse{}
-		item.FillFromEntity(account)
+		item.FillFromEntity(account, canSeeWholeCPF(c, account))
 		response = append(response, item)
 	}
-
-	responsePaginated := viewmodel.BuildPaginatedResponse(response, skip, take, totalRecords)
-
-	return routeutils.ResponseAPIOk(c, responsePaginated)
+	return response
 }
 
 func (s *Handler) handleGetAccountByID(c echo.Context) error {
@@ -98,13 +241,56 @@ func (s *Handler) handleGetAccountByID(c echo.Context) error {
 		return routeutils.HandleError(c, err)
 	}
 
+	// someone else's account is reported as missing, not as forbidden, so
+	// its uuid can't be probed for
+	if !canReadAccount(c, accountUUID) {
+		return routeutils.HandleError(c, errcodes.ErrAccountNotFound)
+	}
+
 	account, err := s.accountService.GetAccountByUUID(ctx, accountUUID)
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
 	response := viewmodel.AccountResponse{}
-	response.FillFromEntity(account)
+	response.FillFromEntity(account, canSeeWholeCPF(c, account))
 
 	return routeutils.ResponseAPIOk(c, response)
 }
+
+func (s *Handler) handleUpdateProfile(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.UpdateProfile{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	account, err := s.accountService.UpdateProfile(ctx, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.AccountResponse{}
+	response.FillFromEntity(account, canSeeWholeCPF(c, account))
+
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handleChangePassword(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.ChangePassword{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	err = s.accountService.ChangePassword(ctx, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
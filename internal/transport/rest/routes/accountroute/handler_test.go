@@ -11,15 +11,19 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/accountroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/test"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	echo "github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func TestHandler_handleAddAccount(t *testing.T) {
@@ -111,7 +115,7 @@ func TestHandler_handleAddAccount(t *testing.T) {
 }
 
 func buildAccountByID(id int) entity.Account {
-	return entity.Account{UUID: "random", Name: "diego" + strconv.Itoa(id)}
+	return entity.Account{UUID: "random", Name: "diego" + strconv.Itoa(id), CPF: "12345678909"}
 }
 
 func buildAccountsByQuantity(qtd int) (accounts []entity.Account) {
@@ -142,7 +146,7 @@ func TestHandler_GetAccounts(t *testing.T) {
 			},
 			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
 				accounts := buildAccountsByQuantity(args.accountsToBuild)
-				mock.AccountAppMock.EXPECT().GetAccounts(ctx, int64(10), int64(0)).Times(1).Return(accounts, int64(2), nil)
+				mock.AccountAppMock.EXPECT().GetAccounts(ctx, dto.AccountSearchInput{}, int64(10), int64(0)).Times(1).Return(accounts, int64(2), nil)
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder, mock test.SvcMocks, args args) {
 				require.Equal(t, http.StatusOK, resp.Code)
@@ -152,7 +156,7 @@ func TestHandler_GetAccounts(t *testing.T) {
 				response := []viewmodel.AccountResponse{}
 				for _, account := range accounts {
 					item := viewmodel.AccountResponse{}
-					item.FillFromEntity(account)
+					item.FillFromEntity(account, true)
 					response = append(response, item)
 				}
 
@@ -169,7 +173,7 @@ func TestHandler_GetAccounts(t *testing.T) {
 			},
 			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
 				accounts := buildAccountsByQuantity(args.accountsToBuild)
-				mock.AccountAppMock.EXPECT().GetAccounts(ctx, int64(10), int64(0)).Times(1).Return(accounts, int64(0), fmt.Errorf("some service error"))
+				mock.AccountAppMock.EXPECT().GetAccounts(ctx, dto.AccountSearchInput{}, int64(10), int64(0)).Times(1).Return(accounts, int64(0), fmt.Errorf("some service error"))
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder, mock test.SvcMocks, args args) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
@@ -188,7 +192,8 @@ func TestHandler_GetAccounts(t *testing.T) {
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
-			ctx := test.GetTestContext(t, req, recorder, false)
+			ctx := test.GetTestContextWithRole(t, req, recorder, entity.RoleAdmin)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, entity.RoleAdmin)
 
 			if tt.buildMocks != nil {
 				tt.buildMocks(ctx, accountMock, tt.args)
@@ -203,8 +208,145 @@ func TestHandler_GetAccounts(t *testing.T) {
 	}
 }
 
+func TestHandler_GetAccounts_search(t *testing.T) {
+	active := false
+	from := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		query         string
+		buildMocks    func(ctx context.Context, mock test.SvcMocks)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Should pass the filters and the sort to the service",
+			query: "?name=john&cpf=123.456.789-09&active=false&from=2026-01-01T00:00:00Z&sort=name,created_at:desc&page=2&quantity=5",
+			buildMocks: func(ctx context.Context, mock test.SvcMocks) {
+				input := dto.AccountSearchInput{
+					Name:        "john",
+					CPF:         "123.456.789-09",
+					Active:      &active,
+					CreatedFrom: &from,
+					Sort:        []string{"name", "created_at:desc"},
+				}
+				mock.AccountAppMock.EXPECT().GetAccounts(ctx, input, int64(5), int64(5)).Times(1).Return([]entity.Account{}, int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			name:  "Should return error if active is not a bool",
+			query: "?active=maybe",
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, resp.Code)
+				require.Contains(t, resp.Body.String(), "invalid active")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountroute.Once = sync.Once{}
+			accountMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s%s", accountroute.GroupRouteName, accountroute.RootRoute, tt.query)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContextWithRole(t, req, recorder, entity.RoleAdmin)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, entity.RoleAdmin)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, accountMock)
+			}
+
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_GetAccounts_cursor(t *testing.T) {
+	prev := &entity.Cursor{CreatedAt: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), ID: 5}
+
+	tests := []struct {
+		name          string
+		query         string
+		buildMocks    func(ctx context.Context, mock test.SvcMocks)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Should read the page before the cursor",
+			query: "?limit=2&cursor=" + viewmodel.EncodeCursor(prev, true),
+			buildMocks: func(ctx context.Context, mock test.SvcMocks) {
+				mock.AccountAppMock.EXPECT().GetAccountsByCursor(ctx, dto.AccountSearchInput{}, entity.CursorPage{Before: prev, Limit: 2}).Times(1).
+					Return(buildAccountsByQuantity(2), entity.CursorResult{Next: prev}, nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+
+				response := []viewmodel.AccountResponse{}
+				for _, account := range buildAccountsByQuantity(2) {
+					item := viewmodel.AccountResponse{}
+					item.FillFromEntity(account, true)
+					response = append(response, item)
+				}
+
+				expectedResp, err := json.Marshal(viewmodel.BuildCursorResponse(response, entity.CursorResult{Next: prev}))
+				require.NoError(t, err)
+				require.Contains(t, resp.Body.String(), string(expectedResp))
+			},
+		},
+		{
+			name:  "Should return error if we have some error with service",
+			query: "?limit=2",
+			buildMocks: func(ctx context.Context, mock test.SvcMocks) {
+				mock.AccountAppMock.EXPECT().GetAccountsByCursor(ctx, dto.AccountSearchInput{}, entity.CursorPage{Limit: 2}).Times(1).
+					Return(nil, entity.CursorResult{}, fmt.Errorf("some service error"))
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountroute.Once = sync.Once{}
+			accountMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s%s", accountroute.GroupRouteName, accountroute.RootRoute, tt.query)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContextWithRole(t, req, recorder, entity.RoleAdmin)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, entity.RoleAdmin)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, accountMock)
+			}
+
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
 func TestHandler_GetAccountByID(t *testing.T) {
 	type args struct {
+		role entity.Role
+		// accountUUID is left empty to read the logged account
 		accountUUID string
 	}
 
@@ -212,45 +354,74 @@ func TestHandler_GetAccountByID(t *testing.T) {
 		name          string
 		args          args
 		buildMocks    func(ctx context.Context, mock test.SvcMocks, args args)
-		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mock test.SvcMocks, args args)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Should complete request with no error",
-			args: args{
-				accountUUID: "random",
+			name: "Should let a customer read its own account with the whole cpf",
+			args: args{role: entity.RoleCustomer},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				loggedAccountUUID, _ := ctx.Value(infra.AccountUUIDKey).(string)
+				account := entity.Account{UUID: loggedAccountUUID, Name: "diego", CPF: "12345678909"}
+				mock.AccountAppMock.EXPECT().GetAccountByUUID(ctx, loggedAccountUUID).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+				require.Contains(t, resp.Body.String(), `"cpf":"12345678909"`)
 			},
+		},
+		{
+			name: "Should report the account of someone else as not found to a customer",
+			args: args{role: entity.RoleCustomer, accountUUID: "random"},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, resp.Code)
+				require.Contains(t, resp.Body.String(), "account not found")
+			},
+		},
+		{
+			name: "Should let support read any account with the cpf masked",
+			args: args{role: entity.RoleSupport, accountUUID: "random"},
 			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
 				account := buildAccountByID(1)
 				mock.AccountAppMock.EXPECT().GetAccountByUUID(ctx, args.accountUUID).Times(1).Return(account, nil)
 			},
-			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder, mock test.SvcMocks, args args) {
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, resp.Code)
 				account := buildAccountByID(1)
 
 				response := viewmodel.AccountResponse{}
-				response.FillFromEntity(account)
+				response.FillFromEntity(account, false)
 
 				expectedResp, err := json.Marshal(response)
 				require.NoError(t, err)
 				require.Contains(t, resp.Body.String(), string(expectedResp))
+				require.Contains(t, resp.Body.String(), `"cpf":"***.456.789-**"`)
 			},
 		},
 		{
-			name: "Should return error if we have some error with service",
-			args: args{
-				accountUUID: "random",
+			name: "Should let an admin read any account with the whole cpf",
+			args: args{role: entity.RoleAdmin, accountUUID: "random"},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				mock.AccountAppMock.EXPECT().GetAccountByUUID(ctx, args.accountUUID).Times(1).Return(buildAccountByID(1), nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+				require.Contains(t, resp.Body.String(), `"cpf":"12345678909"`)
 			},
+		},
+		{
+			name: "Should return error if we have some error with service",
+			args: args{role: entity.RoleAdmin, accountUUID: "random"},
 			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
 				mock.AccountAppMock.EXPECT().GetAccountByUUID(ctx, args.accountUUID).Times(1).Return(entity.Account{}, fmt.Errorf("some service error"))
 			},
-			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder, mock test.SvcMocks, args args) {
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
 			},
 		},
 		{
 			name: "Should return error if we have an invalid uuid",
-			args: args{accountUUID: " "},
-			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder, mock test.SvcMocks, args args) {
+			args: args{role: entity.RoleAdmin, accountUUID: " "},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, resp.Code)
 				require.Contains(t, resp.Body.String(), "Invalid account_uuid")
 			},
@@ -264,12 +435,18 @@ func TestHandler_GetAccountByID(t *testing.T) {
 			defer ctrl.Finish()
 
 			recorder := httptest.NewRecorder()
-			url := fmt.Sprintf("/%s/%s/", accountroute.GroupRouteName, tt.args.accountUUID)
 
-			req, err := http.NewRequest(http.MethodGet, url, nil)
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
 			require.NoError(t, err)
 
-			ctx := test.GetTestContext(t, req, recorder, false)
+			ctx := test.GetTestContextWithRole(t, req, recorder, tt.args.role)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, tt.args.role)
+
+			accountUUID := tt.args.accountUUID
+			if accountUUID == "" {
+				accountUUID, _ = ctx.Value(infra.AccountUUIDKey).(string)
+			}
+			req.URL.Path = fmt.Sprintf("/%s/%s/", accountroute.GroupRouteName, accountUUID)
 
 			if tt.buildMocks != nil {
 				tt.buildMocks(ctx, accountMock, tt.args)
@@ -278,7 +455,7 @@ func TestHandler_GetAccountByID(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			server.Echo().ServeHTTP(recorder, req)
 			if tt.checkResponse != nil {
-				tt.checkResponse(t, recorder, accountMock, tt.args)
+				tt.checkResponse(t, recorder)
 			}
 		})
 	}
@@ -288,6 +465,8 @@ func TestHandler_handleAddBalance(t *testing.T) {
 	type args struct {
 		body        any
 		accountUUID string
+		// role is the admin's when empty
+		role entity.Role
 	}
 
 	tests := []struct {
@@ -300,7 +479,7 @@ func TestHandler_handleAddBalance(t *testing.T) {
 			name: "Should complete request with no error",
 			args: args{
 				body: viewmodel.AddBalance{
-					Amount: 100,
+					Amount: entity.NewMoney(10000, entity.BRL),
 				},
 				accountUUID: "random",
 			},
@@ -328,7 +507,7 @@ func TestHandler_handleAddBalance(t *testing.T) {
 			name: "Should return error if we do not have an account_uuid in the url",
 			args: args{
 				body: viewmodel.AddBalance{
-					Amount: 100,
+					Amount: entity.NewMoney(10000, entity.BRL),
 				},
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
@@ -340,7 +519,7 @@ func TestHandler_handleAddBalance(t *testing.T) {
 			name: "Should return error if we have any error with service",
 			args: args{
 				body: viewmodel.AddBalance{
-					Amount: 100,
+					Amount: entity.NewMoney(10000, entity.BRL),
 				},
 				accountUUID: "random",
 			},
@@ -355,6 +534,20 @@ func TestHandler_handleAddBalance(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
 			},
 		},
+		{
+			name: "Should forbid a customer to add balance",
+			args: args{
+				body: viewmodel.AddBalance{
+					Amount: entity.NewMoney(10000, entity.BRL),
+				},
+				accountUUID: "random",
+				role:        entity.RoleCustomer,
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, resp.Code)
+				require.Contains(t, resp.Body.String(), "the account is not allowed to do this")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -372,7 +565,12 @@ func TestHandler_handleAddBalance(t *testing.T) {
 			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 			require.NoError(t, err)
 
-			ctx := test.GetTestContext(t, req, recorder, false)
+			role := entity.RoleAdmin
+			if tt.args.role != "" {
+				role = tt.args.role
+			}
+			ctx := test.GetTestContextWithRole(t, req, recorder, role)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, role)
 
 			if tt.buildMocks != nil {
 				tt.buildMocks(ctx, accountMock, tt.args)
@@ -386,3 +584,274 @@ func TestHandler_handleAddBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_handleChangeAccountStatus(t *testing.T) {
+	const accountUUID = "d152a340-9a87-4d32-85ad-19df4c9934cd"
+
+	type args struct {
+		role   entity.Role
+		action string
+		body   any
+	}
+
+	tests := []struct {
+		name          string
+		args          args
+		buildMocks    func(ctx context.Context, mock test.SvcMocks, args args)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Should deactivate the account",
+			args: args{role: entity.RoleSupport, action: "deactivate", body: viewmodel.ChangeAccountStatus{Reason: "customer asked"}},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				input := dto.AccountStatusInput{AccountUUID: accountUUID, Reason: "customer asked"}
+				mock.AccountAppMock.EXPECT().DeactivateAccount(ctx, input).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			name: "Should reactivate the account",
+			args: args{role: entity.RoleSupport, action: "reactivate", body: viewmodel.ChangeAccountStatus{Reason: "fraud check cleared"}},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				input := dto.AccountStatusInput{AccountUUID: accountUUID, Reason: "fraud check cleared"}
+				mock.AccountAppMock.EXPECT().ReactivateAccount(ctx, input).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			name: "Should return conflict when a closed account is reactivated",
+			args: args{role: entity.RoleSupport, action: "reactivate", body: viewmodel.ChangeAccountStatus{Reason: "fraud check cleared"}},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				mock.AccountAppMock.EXPECT().ReactivateAccount(ctx, gomock.Any()).Times(1).Return(errcodes.ErrAccountClosed)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, resp.Code)
+				require.Contains(t, resp.Body.String(), "the account is closed")
+			},
+		},
+		{
+			name: "Should close the account sweeping its balance",
+			args: args{role: entity.RoleAdmin, action: "close", body: viewmodel.CloseAccount{
+				Reason:           "customer asked",
+				SweepAccountUUID: "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1",
+			}},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				input := dto.CloseAccountInput{
+					AccountUUID:      accountUUID,
+					Reason:           "customer asked",
+					SweepAccountUUID: "0d5b7c55-2b8e-4a4e-9d0f-53e1f3a4a9b1",
+				}
+				mock.AccountAppMock.EXPECT().CloseAccount(ctx, input).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			name: "Should return conflict when the account still has a balance",
+			args: args{role: entity.RoleAdmin, action: "close", body: viewmodel.CloseAccount{Reason: "customer asked"}},
+			buildMocks: func(ctx context.Context, mock test.SvcMocks, args args) {
+				mock.AccountAppMock.EXPECT().CloseAccount(ctx, gomock.Any()).Times(1).Return(errcodes.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, resp.Code)
+				require.Contains(t, resp.Body.String(), "the account still has a balance")
+			},
+		},
+		{
+			name: "Should return error when body is invalid",
+			args: args{role: entity.RoleAdmin, action: "close", body: "invalid body"},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, resp.Code)
+				require.Contains(t, resp.Body.String(), "invalid request body")
+			},
+		},
+		{
+			name: "Should forbid a customer to deactivate an account",
+			args: args{role: entity.RoleCustomer, action: "deactivate", body: viewmodel.ChangeAccountStatus{Reason: "customer asked"}},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, resp.Code)
+			},
+		},
+		{
+			name: "Should forbid support to close an account",
+			args: args{role: entity.RoleSupport, action: "close", body: viewmodel.CloseAccount{Reason: "customer asked"}},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountroute.Once = sync.Once{}
+			accountMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s/%s/%s", accountroute.GroupRouteName, accountUUID, tt.args.action)
+
+			body, err := json.Marshal(tt.args.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			ctx := test.GetTestContextWithRole(t, req, recorder, tt.args.role)
+			test.AddAuthorizationWithRole(ctx, t, req, accountMock, tt.args.role)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, accountMock, tt.args)
+			}
+
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleUpdateProfile(t *testing.T) {
+	name := "Jane Doe"
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should update the profile",
+			Body: viewmodel.UpdateProfile{Name: &name},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				loggedAccountUUID, _ := ctx.Value(infra.AccountUUIDKey).(string)
+				m.AccountAppMock.EXPECT().UpdateProfile(ctx, dto.ProfileInput{Name: &name}).
+					Return(entity.Account{UUID: loggedAccountUUID, Name: name, CPF: "12345678909"}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+				require.Contains(t, resp.Body.String(), `"name":"Jane Doe"`)
+				require.Contains(t, resp.Body.String(), `"cpf":"12345678909"`, "the holder sees the whole cpf")
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error if the service fails",
+			Body: viewmodel.UpdateProfile{},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AccountAppMock.EXPECT().UpdateProfile(ctx, dto.ProfileInput{}).
+					Return(entity.Account{}, errors.New("some error")).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			accountroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", accountroute.GroupRouteName, accountroute.MeRoute)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleChangePassword(t *testing.T) {
+	body := viewmodel.ChangePassword{CurrentPassword: "12345678", NewPassword: "87654321"}
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should change the password",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AccountAppMock.EXPECT().ChangePassword(ctx, dto.PasswordChangeInput{CurrentPassword: "12345678", NewPassword: "87654321"}).
+					Return(nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error if the current password is wrong",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AccountAppMock.EXPECT().ChangePassword(ctx, gomock.Any()).Return(errcodes.ErrWrongCurrentPassword).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, resp.Code)
+				require.Contains(t, resp.Body.String(), "the current password is wrong")
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			accountroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", accountroute.GroupRouteName, accountroute.MePasswordRoute)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package accountroute
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/diegoclair/apperr/httpmap"
+	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	"github.com/diegoclair/goswag"
@@ -15,6 +18,11 @@ const (
 	RootRoute               = ""
 	AccountByIDRoute        = "/:account_uuid/"
 	AccountBalanceByIDRoute = "/:account_uuid/balance"
+	DeactivateAccountRoute  = "/:account_uuid/deactivate"
+	ReactivateAccountRoute  = "/:account_uuid/reactivate"
+	CloseAccountRoute       = "/:account_uuid/close"
+	MeRoute                 = "/me"
+	MePasswordRoute         = "/me/password"
 )
 
 type AccountRouter struct {
@@ -29,39 +37,117 @@ func NewRouter(ctrl *Handler) *AccountRouter {
 
 func (r *AccountRouter) RegisterRoutes(g *routeutils.EchoGroups) {
 	router := g.AppGroup.Group(GroupRouteName)
+	privateRouter := g.PrivateGroup.Group(GroupRouteName)
+	supportRouter := g.SupportGroup.Group(GroupRouteName)
+	adminRouter := g.AdminGroup.Group(GroupRouteName)
 
-	router.POST(RootRoute, r.ctrl.handleAddAccount).
+	router.POST(RootRoute, r.ctrl.handleAddAccount, g.RateLimit(10, time.Hour)).
 		Summary("Add a new account").
 		Read(viewmodel.AddAccount{}).
 		Returns([]models.ReturnType{{StatusCode: http.StatusCreated}})
 
-	router.POST(AccountBalanceByIDRoute, r.ctrl.handleAddBalance).
+	adminRouter.POST(AccountBalanceByIDRoute, r.ctrl.handleAddBalance, g.RateLimit(60, time.Minute), g.Idempotent).
 		Summary("Add balance to an account").
-		Description("Add balance to an account by account_uuid").
+		Description("Add balance to an account by account_uuid. Admin only").
 		Read(viewmodel.AddBalance{}).
-		Returns([]models.ReturnType{{StatusCode: http.StatusCreated}}).
-		PathParam("account_uuid", "account uuid", goswag.StringType, true)
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusCreated},
+			{StatusCode: http.StatusUnprocessableEntity, Body: httpmap.ErrorResponse{}},
+		}).
+		PathParam("account_uuid", "account uuid", goswag.StringType, true).
+		HeaderParam(infra.IdempotencyKey.String(), infra.IdempotencyKeyDescription, goswag.StringType, false).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	supportRouter.POST(DeactivateAccountRoute, r.ctrl.handleDeactivateAccount, g.RateLimit(30, time.Minute)).
+		Summary("Deactivate an account").
+		Description("Keep the account from logging in until it is reactivated, and block every session it has. Support or admin only").
+		Read(viewmodel.ChangeAccountStatus{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		}).
+		PathParam("account_uuid", "account uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	supportRouter.POST(ReactivateAccountRoute, r.ctrl.handleReactivateAccount, g.RateLimit(30, time.Minute)).
+		Summary("Reactivate an account").
+		Description("Let a deactivated account log in again. A closed account can't be reactivated. Support or admin only").
+		Read(viewmodel.ChangeAccountStatus{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		}).
+		PathParam("account_uuid", "account uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	adminRouter.POST(CloseAccountRoute, r.ctrl.handleCloseAccount, g.RateLimit(30, time.Minute)).
+		Summary("Close an account").
+		Description("Close the account for good. An account with a balance is only closed with a sweep_account_id, "+
+			"the account its whole balance is transferred to. Admin only").
+		Read(viewmodel.CloseAccount{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		}).
+		PathParam("account_uuid", "account uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
 
-	router.GET(RootRoute, r.ctrl.handleGetAccounts).
+	adminRouter.GET(RootRoute, r.ctrl.handleGetAccounts, g.RateLimit(60, time.Minute)).
 		Summary("Get all accounts").
-		Description("Get all accounts with paginated response").
+		Description("Get all accounts with paginated response. With cursor or limit the page is read by cursor, "+
+			"newest first, and the response has next_cursor and prev_cursor instead of page numbers. Admin only").
 		Returns([]models.ReturnType{
 			{
 				StatusCode: http.StatusOK,
 				Body:       viewmodel.PaginatedResponse[[]viewmodel.AccountResponse]{},
 			},
 		}).
+		QueryParam("name", "part of the name, whatever the case", goswag.StringType, false).
+		QueryParam("cpf", "the whole cpf, it can not be matched by part", goswag.StringType, false).
+		QueryParam("active", "true or false", goswag.StringType, false).
+		QueryParam("from", "RFC 3339 time the accounts were created from, inclusive", goswag.StringType, false).
+		QueryParam("to", "RFC 3339 time the accounts were created until, exclusive", goswag.StringType, false).
+		QueryParam("sort", "comma separated name, created_at or balance, each one optionally followed by :asc or :desc; "+
+			"not allowed when paging by cursor", goswag.StringType, false).
 		QueryParam("page", "number of page you want", goswag.StringType, false).
-		QueryParam("quantity", "quantity of items per page", goswag.StringType, false)
+		QueryParam("quantity", "quantity of items per page", goswag.StringType, false).
+		QueryParam("cursor", "next_cursor or prev_cursor of the page read before; pages by cursor instead of by page number", goswag.StringType, false).
+		QueryParam("limit", "quantity of items per page when paging by cursor", goswag.StringType, false).
+		QueryParam("with_count", "also count every account when paging by cursor", goswag.StringType, false).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
 
-	router.GET(AccountByIDRoute, r.ctrl.handleGetAccountByID).
+	privateRouter.GET(AccountByIDRoute, r.ctrl.handleGetAccountByID, g.RateLimit(120, time.Minute)).
 		Summary("Get account by ID").
-		Description("Get account by it UUID value").
+		Description("Get account by it UUID value. A customer only gets its own account, "+
+			"the CPF is only shown whole to its holder and to an admin").
 		Returns([]models.ReturnType{
 			{
 				StatusCode: http.StatusOK,
 				Body:       viewmodel.AccountResponse{},
 			},
 		}).
-		PathParam("account_uuid", "account uuid", goswag.StringType, true)
+		PathParam("account_uuid", "account uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.PATCH(MeRoute, r.ctrl.handleUpdateProfile, g.RateLimit(30, time.Minute)).
+		Summary("Update the logged account profile").
+		Description("Change the profile fields sent, keeping the ones left out").
+		Read(viewmodel.UpdateProfile{}).
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.AccountResponse{},
+			},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.POST(MePasswordRoute, r.ctrl.handleChangePassword, g.RateLimit(5, 15*time.Minute)).
+		Summary("Change the logged account password").
+		Description("Change the password, given the current one, and sign out of every other session").
+		Read(viewmodel.ChangePassword{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusBadRequest, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
 }
@@ -2,16 +2,20 @@ package authroute
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strconv"
 	"sync"
-	"time"
 
 	"github.com/diegoclair/go_boilerplate/infra"
 	infraContract "github.com/diegoclair/go_boilerplate/infra/contract"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
+	"github.com/diegoclair/go_boilerplate/util/tokenhash"
 	"github.com/google/uuid"
 
 	echo "github.com/labstack/echo/v4"
@@ -47,15 +51,81 @@ func (s *Handler) handleLogin(c echo.Context) error {
 		return routeutils.ResponseInvalidRequestBody(c, err)
 	}
 
-	account, err := s.authService.Login(ctx, input.ToDto())
+	loginInput := input.ToDto()
+	loginInput.ClientIP = c.RealIP()
+
+	account, err := s.authService.Login(ctx, loginInput)
+	if err != nil {
+		var lockedErr *errcodes.AccountLockedError
+		if errors.As(err, &lockedErr) {
+			return responseAccountLocked(c, lockedErr)
+		}
+		return routeutils.HandleError(c, err)
+	}
+
+	mfaEnabled, err := s.authService.IsMFAEnabled(ctx, account.ID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	// the password alone isn't enough: the tokens are only handed out by
+	// handleLoginMFA, for a code along with this token
+	if mfaEnabled {
+		mfaToken, mfaPayload, err := s.authToken.CreateMFAToken(ctx, infraContract.TokenPayloadInput{
+			AccountUUID: account.UUID,
+		})
+		if err != nil {
+			return routeutils.HandleError(c, err)
+		}
+
+		return routeutils.ResponseAccepted(c, viewmodel.LoginMFARequiredResponse{
+			MFAToken:          mfaToken,
+			MFATokenExpiresAt: mfaPayload.ExpiredAt,
+		})
+	}
+
+	return s.startSession(ctx, c, account)
+}
+
+func (s *Handler) handleLoginMFA(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.LoginMFA{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	mfaPayload, err := s.authToken.VerifyMFAToken(ctx, input.MFAToken)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	loginInput := input.ToDto(mfaPayload.AccountUUID)
+	loginInput.ClientIP = c.RealIP()
+	loginInput.TokenID = mfaPayload.ID
+	loginInput.TokenExpiresAt = mfaPayload.ExpiredAt
+
+	account, err := s.authService.LoginMFA(ctx, loginInput)
 	if err != nil {
+		var lockedErr *errcodes.AccountLockedError
+		if errors.As(err, &lockedErr) {
+			return responseAccountLocked(c, lockedErr)
+		}
 		return routeutils.HandleError(c, err)
 	}
 
+	return s.startSession(ctx, c, account)
+}
+
+// startSession opens a session for the account that logged in, and answers
+// with its tokens.
+func (s *Handler) startSession(ctx context.Context, c echo.Context, account entity.Account) error {
 	sessionUUID := uuid.Must(uuid.NewV7()).String()
 	req := infraContract.TokenPayloadInput{
 		AccountUUID: account.UUID,
 		SessionUUID: sessionUUID,
+		Role:        string(account.Role),
 	}
 	token, tokenPayload, err := s.authToken.CreateAccessToken(ctx, req)
 	if err != nil {
@@ -70,7 +140,7 @@ func (s *Handler) handleLogin(c echo.Context) error {
 	sessionReq := dto.Session{
 		SessionUUID:           sessionUUID,
 		AccountID:             account.ID,
-		RefreshToken:          refreshToken,
+		RefreshTokenHash:      tokenhash.Sum(refreshToken),
 		UserAgent:             c.Request().UserAgent(),
 		ClientIP:              c.RealIP(),
 		RefreshTokenExpiredAt: refreshTokenPayload.ExpiredAt,
@@ -109,48 +179,176 @@ func (s *Handler) handleRefreshToken(c echo.Context) error {
 	ctx = context.WithValue(ctx, infra.AccountUUIDKey, refreshPayload.AccountUUID)
 	ctx = context.WithValue(ctx, infra.SessionKey, refreshPayload.SessionUUID)
 
-	session, err := s.authService.GetSessionByUUID(ctx, refreshPayload.SessionUUID)
+	req := infraContract.TokenPayloadInput{
+		AccountUUID: refreshPayload.AccountUUID,
+		SessionUUID: refreshPayload.SessionUUID,
+		Role:        refreshPayload.Role,
+	}
+	accessToken, accessPayload, err := s.authToken.CreateAccessToken(ctx, req)
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
-	if session.IsBlocked {
-		return routeutils.HandleError(c, errcodes.ErrSessionBlocked)
+	refreshToken, newRefreshPayload, err := s.authToken.CreateRefreshToken(ctx, req)
+	if err != nil {
+		return routeutils.HandleError(c, err)
 	}
 
-	if session.RefreshToken != input.RefreshToken {
-		return routeutils.HandleError(c, errcodes.ErrSessionTokenMismatch)
+	// the new tokens are only handed out once the session took the new
+	// refresh token in place of the one of the request. Past this handler the
+	// refresh tokens only go by their sums.
+	err = s.authService.RotateRefreshToken(ctx, dto.RefreshTokenRotation{
+		SessionUUID:              refreshPayload.SessionUUID,
+		RefreshTokenHash:         tokenhash.Sum(input.RefreshToken),
+		NewRefreshTokenHash:      tokenhash.Sum(refreshToken),
+		NewRefreshTokenExpiredAt: newRefreshPayload.ExpiredAt,
+		ClientIP:                 c.RealIP(),
+	})
+	if err != nil {
+		return routeutils.HandleError(c, err)
 	}
 
-	if time.Now().After(session.RefreshTokenExpiredAt) {
-		return routeutils.HandleError(c, errcodes.ErrSessionExpired)
+	response := viewmodel.RefreshTokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 
-	req := infraContract.TokenPayloadInput{
-		AccountUUID: refreshPayload.AccountUUID,
-		SessionUUID: refreshPayload.SessionUUID,
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handlePasswordReset(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.PasswordResetRequest{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
 	}
-	accessToken, accessPayload, err := s.authToken.CreateAccessToken(ctx, req)
+
+	resetInput := input.ToDto()
+	resetInput.ClientIP = c.RealIP()
+
+	err = s.authService.RequestPasswordReset(ctx, resetInput)
 	if err != nil {
+		var lockedErr *errcodes.AccountLockedError
+		if errors.As(err, &lockedErr) {
+			return responseAccountLocked(c, lockedErr)
+		}
 		return routeutils.HandleError(c, err)
 	}
 
-	response := viewmodel.RefreshTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handlePasswordResetConfirm(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.PasswordResetConfirm{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	err = s.authService.ConfirmPasswordReset(ctx, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handleMFAEnroll(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	enrollment, err := s.authService.EnrollMFA(ctx)
+	if err != nil {
+		return routeutils.HandleError(c, err)
 	}
 
+	response := viewmodel.MFAEnrollmentResponse{}
+	response.FillFromDto(enrollment)
+
 	return routeutils.ResponseAPIOk(c, response)
 }
 
+func (s *Handler) handleMFAConfirm(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	input := viewmodel.MFAConfirm{}
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	recoveryCodes, err := s.authService.ConfirmMFA(ctx, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseAPIOk(c, viewmodel.MFAConfirmResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (s *Handler) handleListSessions(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	sessions, err := s.authService.ListSessions(ctx)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := []viewmodel.SessionResp{}
+	for _, session := range sessions {
+		resp := viewmodel.SessionResp{}
+		resp.FillFromDto(session)
+		response = append(response, resp)
+	}
+
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handleRevokeSession(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	sessionUUID, err := routeutils.GetRequiredStringPathParam(c, "session_uuid", "invalid session_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.authService.RevokeSession(ctx, sessionUUID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handleRevokeOtherSessions(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	err := s.authService.RevokeOtherSessions(ctx)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
 func (s *Handler) handleLogout(c echo.Context) error {
-	accessToken := c.Request().Header.Get(infra.TokenKey.String())
 	ctx := routeutils.GetContext(c)
 
-	err := s.authService.Logout(ctx, accessToken)
+	err := s.authService.Logout(ctx)
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
 	return routeutils.ResponseAPIOk(c, struct{}{})
 }
+
+// responseAccountLocked answers a locked login with 429 and a Retry-After of
+// when it can be tried again.
+func responseAccountLocked(c echo.Context, err *errcodes.AccountLockedError) error {
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(err.RetryAfterSeconds(), 10))
+	return routeutils.HandleErrorWithStatus(c, err, http.StatusTooManyRequests)
+}
@@ -15,14 +15,25 @@ import (
 	"github.com/diegoclair/go_boilerplate/infra/contract"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/authroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/test"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
+	"github.com/diegoclair/go_boilerplate/util/tokenhash"
 	echo "github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
+const testClientIP = "10.0.0.1"
+
+// loginInput is the input the login handler gives the service for body.
+func loginInput(body viewmodel.Login) dto.LoginInput {
+	input := body.ToDto()
+	input.ClientIP = testClientIP
+	return input
+}
+
 func TestHandler_handleLogin(t *testing.T) {
 	type args struct {
 		body any
@@ -45,14 +56,15 @@ func TestHandler_handleLogin(t *testing.T) {
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				body := args.body.(viewmodel.Login)
 
-				m.AuthAppMock.EXPECT().Login(ctx, body.ToDto()).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().IsMFAEnabled(ctx, int64(1)).Return(false, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, gomock.Any()).Return("a123", contract.TokenPayload{}, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateRefreshToken(ctx, gomock.Any()).Return("r123", contract.TokenPayload{ExpiredAt: time.Now()}, nil).Times(1)
 				m.AuthAppMock.EXPECT().CreateSession(ctx, gomock.Any()).DoAndReturn(
 					func(ctx context.Context, req dto.Session) error {
 						require.NotEmpty(t, req.SessionUUID)
 						require.Equal(t, int64(1), req.AccountID)
-						require.Equal(t, "r123", req.RefreshToken)
+						require.Equal(t, tokenhash.Sum("r123"), req.RefreshTokenHash)
 						require.NotEmpty(t, req.RefreshTokenExpiredAt)
 
 						return nil
@@ -85,12 +97,57 @@ func TestHandler_handleLogin(t *testing.T) {
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				body := args.body.(viewmodel.Login)
 
-				m.AuthAppMock.EXPECT().Login(ctx, body.ToDto()).Return(entity.Account{}, fmt.Errorf("error to login")).Times(1)
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{}, fmt.Errorf("error to login")).Times(1)
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
 			},
 		},
+		{
+			name: "Should return too many requests with retry after when the login is locked",
+			args: args{
+				body: viewmodel.Login{
+					CPF:      "01234567890",
+					Password: "12345678",
+				},
+			},
+			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
+				body := args.body.(viewmodel.Login)
+
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).
+					Return(entity.Account{}, errcodes.NewAccountLockedError(90500*time.Millisecond)).Times(1)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, resp.Code)
+				require.Equal(t, "91", resp.Header().Get(echo.HeaderRetryAfter))
+				require.Contains(t, resp.Body.String(), "try again in 91 seconds")
+			},
+		},
+		{
+			name: "Should ask for a second factor when the account has mfa",
+			args: args{
+				body: viewmodel.Login{
+					CPF:      "01234567890",
+					Password: "12345678",
+				},
+			},
+			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
+				body := args.body.(viewmodel.Login)
+
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().IsMFAEnabled(ctx, int64(1)).Return(true, nil).Times(1)
+				m.AuthTokenMock.EXPECT().CreateMFAToken(ctx, contract.TokenPayloadInput{AccountUUID: "uuid"}).
+					Return("m123", contract.TokenPayload{ExpiredAt: time.Now()}, nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, resp.Code)
+
+				var body viewmodel.LoginMFARequiredResponse
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+				require.Equal(t, "m123", body.MFAToken)
+				require.NotContains(t, resp.Body.String(), "access_token")
+			},
+		},
 		{
 			name: "Should return error when create access token fails",
 			args: args{
@@ -102,7 +159,8 @@ func TestHandler_handleLogin(t *testing.T) {
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				body := args.body.(viewmodel.Login)
 
-				m.AuthAppMock.EXPECT().Login(ctx, body.ToDto()).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().IsMFAEnabled(ctx, int64(1)).Return(false, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, gomock.Any()).Return("", contract.TokenPayload{}, fmt.Errorf("error to create access token")).Times(1)
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
@@ -120,7 +178,8 @@ func TestHandler_handleLogin(t *testing.T) {
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				body := args.body.(viewmodel.Login)
 
-				m.AuthAppMock.EXPECT().Login(ctx, body.ToDto()).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().IsMFAEnabled(ctx, int64(1)).Return(false, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, gomock.Any()).Return("a123", contract.TokenPayload{}, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateRefreshToken(ctx, gomock.Any()).Return("", contract.TokenPayload{}, fmt.Errorf("error to create refresh token")).Times(1)
 			},
@@ -139,7 +198,8 @@ func TestHandler_handleLogin(t *testing.T) {
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				body := args.body.(viewmodel.Login)
 
-				m.AuthAppMock.EXPECT().Login(ctx, body.ToDto()).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().Login(ctx, loginInput(body)).Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().IsMFAEnabled(ctx, int64(1)).Return(false, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, gomock.Any()).Return("a123", contract.TokenPayload{}, nil).Times(1)
 				m.AuthTokenMock.EXPECT().CreateRefreshToken(ctx, gomock.Any()).Return("r123", contract.TokenPayload{ExpiredAt: time.Now()}, nil).Times(1)
 				m.AuthAppMock.EXPECT().CreateSession(ctx, gomock.Any()).Return(fmt.Errorf("error to create session")).Times(1)
@@ -164,6 +224,7 @@ func TestHandler_handleLogin(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 			require.NoError(t, err)
+			req.RemoteAddr = testClientIP + ":43210"
 
 			ctx := test.GetTestContext(t, req, recorder, false)
 
@@ -188,6 +249,40 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 		body        any
 	}
 
+	// expectNewTokens expects the payload of the refresh token to be verified
+	// and new tokens to be created for its session, and returns the context
+	// the handler goes on with
+	expectNewTokens := func(ctx context.Context, m test.SvcMocks, args args) context.Context {
+		input := args.body.(viewmodel.RefreshTokenRequest)
+		m.AuthTokenMock.EXPECT().VerifyToken(ctx, input.RefreshToken).
+			Return(contract.TokenPayload{
+				SessionUUID: args.sessionUUID,
+				AccountUUID: args.accountUUID,
+			}, nil).Times(1)
+
+		ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
+		ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
+
+		req := contract.TokenPayloadInput{
+			AccountUUID: args.accountUUID,
+			SessionUUID: args.sessionUUID,
+		}
+		m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, req).
+			Return("a123", contract.TokenPayload{}, nil).Times(1)
+		m.AuthTokenMock.EXPECT().CreateRefreshToken(ctx, req).
+			Return("r456", contract.TokenPayload{ExpiredAt: time.Now().Add(24 * time.Hour)}, nil).Times(1)
+
+		return ctx
+	}
+
+	validArgs := args{
+		accountUUID: "aUuid",
+		sessionUUID: "sUuid",
+		body: viewmodel.RefreshTokenRequest{
+			RefreshToken: "r123",
+		},
+	}
+
 	tests := []struct {
 		name          string
 		args          args
@@ -196,40 +291,29 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 	}{
 		{
 			name: "Should complete request with no error",
-			args: args{
-				accountUUID: "aUuid",
-				sessionUUID: "sUuid",
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
+			args: validArgs,
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
-				input := args.body.(viewmodel.RefreshTokenRequest)
-				m.AuthTokenMock.EXPECT().VerifyToken(ctx, input.RefreshToken).
-					Return(contract.TokenPayload{
-						SessionUUID: args.sessionUUID,
-						AccountUUID: args.accountUUID,
-					}, nil).Times(1)
-
-				ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
-				ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
-
-				m.AuthAppMock.EXPECT().GetSessionByUUID(ctx, args.sessionUUID).
-					Return(dto.Session{
-						RefreshTokenExpiredAt: time.Now().Add(2 * time.Hour),
-						RefreshToken:          "r123",
-					}, nil).Times(1)
+				ctx = expectNewTokens(ctx, m, args)
 
-				req := contract.TokenPayloadInput{
-					AccountUUID: args.accountUUID,
-					SessionUUID: args.sessionUUID,
-				}
-				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, req).
-					Return("a123", contract.TokenPayload{}, nil).Times(1)
+				m.AuthAppMock.EXPECT().RotateRefreshToken(ctx, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, rotation dto.RefreshTokenRotation) error {
+						require.Equal(t, args.sessionUUID, rotation.SessionUUID)
+						require.Equal(t, tokenhash.Sum("r123"), rotation.RefreshTokenHash)
+						require.Equal(t, tokenhash.Sum("r456"), rotation.NewRefreshTokenHash)
+						require.NotZero(t, rotation.NewRefreshTokenExpiredAt)
+						require.Equal(t, testClientIP, rotation.ClientIP)
+						return nil
+					},
+				).Times(1)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				require.Contains(t, recorder.Body.String(), "a123")
+
+				var body viewmodel.RefreshTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, "a123", body.AccessToken)
+				require.Equal(t, "r456", body.RefreshToken)
+				require.NotZero(t, body.RefreshTokenExpiresAt)
 			},
 		},
 		{
@@ -244,11 +328,7 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 		},
 		{
 			name: "Should return error when verify token fails",
-			args: args{
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
+			args: validArgs,
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				m.AuthTokenMock.EXPECT().VerifyToken(ctx, gomock.Any()).Return(contract.TokenPayload{}, fmt.Errorf("error to verify token")).Times(1)
 			},
@@ -256,93 +336,35 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
 			},
 		},
-		{
-			name: "Should return error when get session by uuid fails",
-			args: args{
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
-			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
-				input := args.body.(viewmodel.RefreshTokenRequest)
-				m.AuthTokenMock.EXPECT().VerifyToken(ctx, input.RefreshToken).
-					Return(contract.TokenPayload{
-						SessionUUID: args.sessionUUID,
-						AccountUUID: args.accountUUID,
-					}, nil).Times(1)
-
-				ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
-				ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
-
-				m.AuthAppMock.EXPECT().GetSessionByUUID(ctx, args.sessionUUID).
-					Return(dto.Session{}, fmt.Errorf("error to get session by uuid")).Times(1)
-			},
-			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, resp.Code)
-			},
-		},
 		{
 			name: "Should return when session token is mismatched",
-			args: args{
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
+			args: validArgs,
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
-				m.AuthTokenMock.EXPECT().VerifyToken(ctx, gomock.Any()).
-					Return(contract.TokenPayload{
-						SessionUUID: args.sessionUUID,
-						AccountUUID: args.accountUUID,
-					}, nil).Times(1)
-
-				ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
-				ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
-
-				m.AuthAppMock.EXPECT().GetSessionByUUID(ctx, args.sessionUUID).
-					Return(dto.Session{
-						RefreshToken: "r456",
-					}, nil).Times(1)
+				ctx = expectNewTokens(ctx, m, args)
+				m.AuthAppMock.EXPECT().RotateRefreshToken(ctx, gomock.Any()).Return(errcodes.ErrSessionTokenMismatch).Times(1)
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, resp.Code)
 				require.Contains(t, resp.Body.String(), "mismatched session token")
+				require.NotContains(t, resp.Body.String(), "r456")
 			},
 		},
 		{
-			name: "Should return error when refresh token is expired",
-			args: args{
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
+			name: "Should return error when the refresh token was already rotated out",
+			args: validArgs,
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
-				m.AuthTokenMock.EXPECT().VerifyToken(ctx, gomock.Any()).
-					Return(contract.TokenPayload{
-						SessionUUID: args.sessionUUID,
-						AccountUUID: args.accountUUID,
-					}, nil).Times(1)
-
-				ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
-				ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
-
-				m.AuthAppMock.EXPECT().GetSessionByUUID(ctx, args.sessionUUID).
-					Return(dto.Session{
-						RefreshTokenExpiredAt: time.Now().Add(-2 * time.Hour),
-						RefreshToken:          "r123",
-					}, nil).Times(1)
+				ctx = expectNewTokens(ctx, m, args)
+				m.AuthAppMock.EXPECT().RotateRefreshToken(ctx, gomock.Any()).Return(errcodes.ErrRefreshTokenReused).Times(1)
 			},
 			checkResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, resp.Code)
-				require.Contains(t, resp.Body.String(), "session has expired")
+				require.Contains(t, resp.Body.String(), "the session was signed out")
+				require.NotContains(t, resp.Body.String(), "a123")
 			},
 		},
 		{
 			name: "Should return error when create access token fails",
-			args: args{
-				body: viewmodel.RefreshTokenRequest{
-					RefreshToken: "r123",
-				},
-			},
+			args: validArgs,
 			buildMocks: func(ctx context.Context, m test.SvcMocks, args args) {
 				m.AuthTokenMock.EXPECT().VerifyToken(ctx, gomock.Any()).
 					Return(contract.TokenPayload{
@@ -353,12 +375,6 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 				ctx = context.WithValue(ctx, infra.AccountUUIDKey, args.accountUUID)
 				ctx = context.WithValue(ctx, infra.SessionKey, args.sessionUUID)
 
-				m.AuthAppMock.EXPECT().GetSessionByUUID(ctx, args.sessionUUID).
-					Return(dto.Session{
-						RefreshTokenExpiredAt: time.Now().Add(2 * time.Hour),
-						RefreshToken:          "r123",
-					}, nil).Times(1)
-
 				req := contract.TokenPayloadInput{
 					AccountUUID: args.accountUUID,
 					SessionUUID: args.sessionUUID,
@@ -387,6 +403,7 @@ func TestHandler_handleRefreshToken(t *testing.T) {
 
 			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 			require.NoError(t, err)
+			req.RemoteAddr = testClientIP + ":43210"
 
 			ctx := test.GetTestContext(t, req, recorder, false)
 
@@ -412,7 +429,7 @@ func TestHandler_handleLogout(t *testing.T) {
 				test.AddAuthorization(ctx, t, req, m)
 			},
 			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
-				m.AuthAppMock.EXPECT().Logout(ctx, gomock.Any()).Return(nil).Times(1)
+				m.AuthAppMock.EXPECT().Logout(ctx).Return(nil).Times(1)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -424,7 +441,7 @@ func TestHandler_handleLogout(t *testing.T) {
 				test.AddAuthorization(ctx, t, req, m)
 			},
 			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
-				m.AuthAppMock.EXPECT().Logout(ctx, gomock.Any()).Return(fmt.Errorf("error to logout")).Times(1)
+				m.AuthAppMock.EXPECT().Logout(ctx).Return(fmt.Errorf("error to logout")).Times(1)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -461,3 +478,555 @@ func TestHandler_handleLogout(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_handlePasswordReset(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          any
+		buildMocks    func(ctx context.Context, m test.SvcMocks)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Should request the reset",
+			body: viewmodel.PasswordResetRequest{CPF: "01234567890"},
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthAppMock.EXPECT().RequestPasswordReset(ctx, dto.PasswordResetInput{CPF: "01234567890"}).Return(nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "Should return too many requests with retry after when the login is locked",
+			body: viewmodel.PasswordResetRequest{CPF: "01234567890"},
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthAppMock.EXPECT().RequestPasswordReset(ctx, dto.PasswordResetInput{CPF: "01234567890"}).
+					Return(errcodes.NewAccountLockedError(time.Minute)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get(echo.HeaderRetryAfter))
+			},
+		},
+		{
+			name: "Should return error when body is invalid",
+			body: "invalid body",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			authMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.PasswordResetRoute)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, false)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, authMock)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handlePasswordResetConfirm(t *testing.T) {
+	body := viewmodel.PasswordResetConfirm{Token: "reset-token", NewPassword: "87654321"}
+
+	tests := []struct {
+		name          string
+		body          any
+		buildMocks    func(ctx context.Context, m test.SvcMocks)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Should set the new password",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthAppMock.EXPECT().ConfirmPasswordReset(ctx, dto.PasswordResetConfirmInput{Token: "reset-token", NewPassword: "87654321"}).
+					Return(nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "Should return error when the token is invalid",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthAppMock.EXPECT().ConfirmPasswordReset(ctx, gomock.Any()).Return(errcodes.ErrInvalidResetToken).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "the password reset token is invalid or has expired")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			authMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.PasswordResetConfirmRoute)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, false)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, authMock)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleLoginMFA(t *testing.T) {
+	body := viewmodel.LoginMFA{MFAToken: "m123", Code: "123456"}
+
+	tests := []struct {
+		name          string
+		body          any
+		buildMocks    func(ctx context.Context, m test.SvcMocks)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Should open a session once the code is right",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				expiresAt := time.Now().Add(5 * time.Minute)
+				m.AuthTokenMock.EXPECT().VerifyMFAToken(ctx, "m123").Return(contract.TokenPayload{ID: "token-id", AccountUUID: "uuid", ExpiredAt: expiresAt}, nil).Times(1)
+				m.AuthAppMock.EXPECT().LoginMFA(ctx, dto.LoginMFAInput{AccountUUID: "uuid", Code: "123456", ClientIP: testClientIP, TokenID: "token-id", TokenExpiresAt: expiresAt}).
+					Return(entity.Account{ID: 1, UUID: "uuid"}, nil).Times(1)
+				m.AuthTokenMock.EXPECT().CreateAccessToken(ctx, gomock.Any()).Return("a123", contract.TokenPayload{}, nil).Times(1)
+				m.AuthTokenMock.EXPECT().CreateRefreshToken(ctx, gomock.Any()).Return("r123", contract.TokenPayload{ExpiredAt: time.Now()}, nil).Times(1)
+				m.AuthAppMock.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "a123")
+			},
+		},
+		{
+			name: "Should return error when the mfa token is invalid",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthTokenMock.EXPECT().VerifyMFAToken(ctx, "m123").Return(contract.TokenPayload{}, errcodes.ErrSessionExpired).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Should return error when the code is wrong",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthTokenMock.EXPECT().VerifyMFAToken(ctx, "m123").Return(contract.TokenPayload{AccountUUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().LoginMFA(ctx, gomock.Any()).Return(entity.Account{}, errcodes.ErrInvalidMFACode).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "the code is wrong or was already used")
+			},
+		},
+		{
+			name: "Should return too many requests with retry after when the login is locked",
+			body: body,
+			buildMocks: func(ctx context.Context, m test.SvcMocks) {
+				m.AuthTokenMock.EXPECT().VerifyMFAToken(ctx, "m123").Return(contract.TokenPayload{AccountUUID: "uuid"}, nil).Times(1)
+				m.AuthAppMock.EXPECT().LoginMFA(ctx, gomock.Any()).Return(entity.Account{}, errcodes.NewAccountLockedError(time.Minute)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get(echo.HeaderRetryAfter))
+			},
+		},
+		{
+			name: "Should return error when body is invalid",
+			body: "invalid body",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			authMock, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.LoginMFARoute)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.RemoteAddr = testClientIP + ":43210"
+
+			ctx := test.GetTestContext(t, req, recorder, false)
+
+			if tt.buildMocks != nil {
+				tt.buildMocks(ctx, authMock)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleMFAEnroll(t *testing.T) {
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should return the secret and its provisioning uri",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().EnrollMFA(ctx).Return(dto.MFAEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/x"}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body viewmodel.MFAEnrollmentResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, "SECRET", body.Secret)
+				require.Equal(t, "otpauth://totp/x", body.ProvisioningURI)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error when it is already enabled",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().EnrollMFA(ctx).Return(dto.MFAEnrollment{}, errcodes.ErrMFAAlreadyEnabled).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.MFAEnrollRoute)
+
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleMFAConfirm(t *testing.T) {
+	body := viewmodel.MFAConfirm{Code: "123456"}
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should return the recovery codes",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().ConfirmMFA(ctx, dto.MFACodeInput{Code: "123456"}).Return([]string{"abcde-fghij"}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body viewmodel.MFAConfirmResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, []string{"abcde-fghij"}, body.RecoveryCodes)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error when the code is wrong",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().ConfirmMFA(ctx, gomock.Any()).Return(nil, errcodes.ErrWrongMFACode).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "the code is wrong")
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.MFAConfirmRoute)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleListSessions(t *testing.T) {
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should list the sessions with the current one marked",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().ListSessions(ctx).Return([]dto.Session{
+					{SessionUUID: "other", UserAgent: "curl", ClientIP: "10.0.0.2", RefreshTokenHash: tokenhash.Sum("r123")},
+					{SessionUUID: "current", UserAgent: "browser", ClientIP: testClientIP, Current: true},
+				}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), tokenhash.Sum("r123"))
+
+				var body []viewmodel.SessionResp
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body, 2)
+				require.Equal(t, "curl", body[0].UserAgent)
+				require.False(t, body[0].Current)
+				require.True(t, body[1].Current)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error when the sessions can't be listed",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().ListSessions(ctx).Return(nil, fmt.Errorf("error to list sessions")).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.SessionsRoute)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleRevokeSession(t *testing.T) {
+	const sessionUUID = "0f8e7a7c-5d43-4a4b-9a3d-2b1c0d9e8f7a"
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should sign out of the session",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().RevokeSession(ctx, sessionUUID).Return(nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return not found when the account has no such session",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().RevokeSession(ctx, sessionUUID).Return(errcodes.ErrUnknownSession).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s/%s", authroute.GroupRouteName, authroute.SessionsRoute, sessionUUID)
+
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleRevokeOtherSessions(t *testing.T) {
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should sign out of the other sessions",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().RevokeOtherSessions(ctx).Return(nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error when the sessions can't be signed out",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.AuthAppMock.EXPECT().RevokeOtherSessions(ctx).Return(fmt.Errorf("error to revoke sessions")).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			authroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/%s%s", authroute.GroupRouteName, authroute.SessionsRoute)
+
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
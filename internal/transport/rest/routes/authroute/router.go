@@ -2,7 +2,9 @@ package authroute
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/diegoclair/apperr/httpmap"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	"github.com/diegoclair/goswag"
@@ -15,8 +17,18 @@ const GroupRouteName = "auth"
 
 const (
 	LoginRoute        = "/login"
+	LoginMFARoute     = "/login/mfa"
 	LogoutRoute       = "/logout"
 	RefreshTokenRoute = "/refresh-token"
+
+	PasswordResetRoute        = "/password-reset"
+	PasswordResetConfirmRoute = "/password-reset/confirm"
+
+	MFAEnrollRoute  = "/mfa/enroll"
+	MFAConfirmRoute = "/mfa/confirm"
+
+	SessionsRoute      = "/sessions"
+	SessionByUUIDRoute = "/sessions/:session_uuid"
 )
 
 type AuthRouter struct {
@@ -33,9 +45,30 @@ func (r *AuthRouter) RegisterRoutes(g *routeutils.EchoGroups) {
 	router := g.AppGroup.Group(GroupRouteName)
 	privateRouter := g.PrivateGroup.Group(GroupRouteName)
 
-	router.POST(LoginRoute, r.ctrl.handleLogin).
+	router.POST(LoginRoute, r.ctrl.handleLogin, g.RateLimit(20, time.Minute)).
 		Summary("Login").
+		Description("Failed logins make the next ones of the same CPF or ip wait, and too many of them lock the login " +
+			"for a while. A login that has to wait gets 429 with a Retry-After header in seconds. " +
+			"An account with two-factor authentication gets 202 with an mfa token instead of the tokens, " +
+			"to be sent to " + GroupRouteName + LoginMFARoute + " along with a code").
 		Read(viewmodel.Login{}).
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.LoginResponse{},
+			},
+			{
+				StatusCode: http.StatusAccepted,
+				Body:       viewmodel.LoginMFARequiredResponse{},
+			},
+		})
+
+	router.POST(LoginMFARoute, r.ctrl.handleLoginMFA, g.RateLimit(10, time.Minute)).
+		Summary("Login with a second factor").
+		Description("Finish the login of an account with two-factor authentication, with the mfa token of the login " +
+			"and either a code of the authenticator app or a recovery code. Each code is taken once, and wrong ones " +
+			"count as failed logins").
+		Read(viewmodel.LoginMFA{}).
 		Returns([]models.ReturnType{
 			{
 				StatusCode: http.StatusOK,
@@ -43,9 +76,10 @@ func (r *AuthRouter) RegisterRoutes(g *routeutils.EchoGroups) {
 			},
 		})
 
-	router.POST("/refresh-token", r.ctrl.handleRefreshToken).
+	router.POST("/refresh-token", r.ctrl.handleRefreshToken, g.RateLimit(30, time.Minute)).
 		Summary("Refresh Token").
-		Description("Generate a new token using the refresh token").
+		Description("Generate a new access token and a new refresh token using the refresh token, which can't be used " +
+			"again. Presenting a refresh token after it was replaced signs its session out").
 		Read(viewmodel.RefreshTokenRequest{}).
 		Returns([]models.ReturnType{
 			{
@@ -54,7 +88,82 @@ func (r *AuthRouter) RegisterRoutes(g *routeutils.EchoGroups) {
 			},
 		})
 
-	privateRouter.POST(LogoutRoute, r.ctrl.handleLogout).
+	router.POST(PasswordResetRoute, r.ctrl.handlePasswordReset, g.RateLimit(5, 15*time.Minute)).
+		Summary("Request a password reset").
+		Description("Send a token to reset the password to the owner of the account. "+
+			"The response is the same whether the account exists or not. While the login of the CPF or of the ip "+
+			"is locked, the request gets 429 with a Retry-After header in seconds").
+		Read(viewmodel.PasswordResetRequest{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusTooManyRequests, Body: httpmap.ErrorResponse{}},
+		})
+
+	router.POST(PasswordResetConfirmRoute, r.ctrl.handlePasswordResetConfirm, g.RateLimit(10, 15*time.Minute)).
+		Summary("Confirm a password reset").
+		Description("Set a new password with a reset token, which can be used once, and sign out of every session").
+		Read(viewmodel.PasswordResetConfirm{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusBadRequest, Body: httpmap.ErrorResponse{}},
+		})
+
+	privateRouter.POST(MFAEnrollRoute, r.ctrl.handleMFAEnroll, g.RateLimit(5, 15*time.Minute)).
+		Summary("Enroll in two-factor authentication").
+		Description("Start a new TOTP secret for the account. It isn't asked for at login until confirmed, and enrolling " +
+			"again before that replaces it. The provisioning uri is what the QR code for the authenticator app holds").
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.MFAEnrollmentResponse{},
+			},
+			{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.POST(MFAConfirmRoute, r.ctrl.handleMFAConfirm, g.RateLimit(10, 15*time.Minute)).
+		Summary("Confirm two-factor authentication").
+		Description("Turn on two-factor authentication with a first code of the authenticator app. The recovery codes " +
+			"are shown only this once, each of them can stand in for a code at login once").
+		Read(viewmodel.MFAConfirm{}).
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.MFAConfirmResponse{},
+			},
+			{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.GET(SessionsRoute, r.ctrl.handleListSessions, g.RateLimit(60, time.Minute)).
+		Summary("List sessions").
+		Description("List the active sessions of the account, with the device each was opened from. " +
+			"The session of the request is marked as current").
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       []viewmodel.SessionResp{},
+			},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.DELETE(SessionsRoute, r.ctrl.handleRevokeOtherSessions, g.RateLimit(10, time.Minute)).
+		Summary("Sign out of the other sessions").
+		Description("Sign the account out of every session but the one of the request").
+		Returns([]models.ReturnType{{StatusCode: http.StatusNoContent}}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.DELETE(SessionByUUIDRoute, r.ctrl.handleRevokeSession, g.RateLimit(30, time.Minute)).
+		Summary("Sign out of a session").
+		Description("Sign the account out of one of its sessions, which may be the one of the request").
+		PathParam("session_uuid", "session uuid", goswag.StringType, true).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+			{StatusCode: http.StatusNotFound, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	privateRouter.POST(LogoutRoute, r.ctrl.handleLogout, g.RateLimit(30, time.Minute)).
 		Summary("Logout").
 		Description("Logout the user").
 		Returns([]models.ReturnType{
@@ -13,6 +13,7 @@ import (
 	"github.com/diegoclair/go_boilerplate/infra/configmock"
 	"github.com/diegoclair/go_boilerplate/infra/contract"
 	infraMocks "github.com/diegoclair/go_boilerplate/infra/mocks"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/accountroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/authroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/transferroute"
@@ -27,11 +28,15 @@ import (
 )
 
 type SvcMocks struct {
-	AccountAppMock  *mocks.MockAccountApp
-	AuthAppMock     *mocks.MockAuthApp
-	AuthTokenMock   *infraMocks.MockAuthToken
-	CacheMock       *mocks.MockCacheManager
-	TransferAppMock *mocks.MockTransferApp
+	AccountAppMock     *mocks.MockAccountApp
+	AuthAppMock        *mocks.MockAuthApp
+	AuthTokenMock      *infraMocks.MockAuthToken
+	CacheMock          *mocks.MockCacheManager
+	IdempotencyAppMock *mocks.MockIdempotencyApp
+	TransferAppMock    *mocks.MockTransferApp
+
+	RecurringTransferAppMock *mocks.MockRecurringTransferApp
+	ScheduledTransferAppMock *mocks.MockScheduledTransferApp
 }
 
 func GetServerTest(t *testing.T) (m SvcMocks, server goswag.Echo, ctrl *gomock.Controller) {
@@ -39,11 +44,15 @@ func GetServerTest(t *testing.T) (m SvcMocks, server goswag.Echo, ctrl *gomock.C
 
 	ctrl = gomock.NewController(t)
 	m = SvcMocks{
-		AccountAppMock:  mocks.NewMockAccountApp(ctrl),
-		AuthAppMock:     mocks.NewMockAuthApp(ctrl),
-		AuthTokenMock:   infraMocks.NewMockAuthToken(ctrl),
-		CacheMock:       mocks.NewMockCacheManager(ctrl),
-		TransferAppMock: mocks.NewMockTransferApp(ctrl),
+		AccountAppMock:     mocks.NewMockAccountApp(ctrl),
+		AuthAppMock:        mocks.NewMockAuthApp(ctrl),
+		AuthTokenMock:      infraMocks.NewMockAuthToken(ctrl),
+		CacheMock:          mocks.NewMockCacheManager(ctrl),
+		IdempotencyAppMock: mocks.NewMockIdempotencyApp(ctrl),
+		TransferAppMock:    mocks.NewMockTransferApp(ctrl),
+
+		RecurringTransferAppMock: mocks.NewMockRecurringTransferApp(ctrl),
+		ScheduledTransferAppMock: mocks.NewMockScheduledTransferApp(ctrl),
 	}
 
 	server = goswag.NewEcho()
@@ -52,19 +61,24 @@ func GetServerTest(t *testing.T) (m SvcMocks, server goswag.Echo, ctrl *gomock.C
 	}
 	appGroup := server.Group("/")
 	privateGroup := appGroup.Group("",
-		servermiddleware.AuthMiddlewarePrivateRoute(getTestTokenMaker(t), m.CacheMock),
+		servermiddleware.AuthMiddlewarePrivateRoute(getTestTokenMaker(t), m.AuthAppMock, false),
 	)
 
 	g := &routeutils.EchoGroups{
 		AppGroup:     appGroup,
 		PrivateGroup: privateGroup,
+		SupportGroup: privateGroup.Group("", servermiddleware.RequireRole(entity.RoleSupport, entity.RoleAdmin)),
+		AdminGroup:   privateGroup.Group("", servermiddleware.RequireRole(entity.RoleAdmin)),
+		Idempotent:   servermiddleware.IdempotencyMiddleware(m.IdempotencyAppMock),
+		// counted in memory, so the cache mock only sees what the tests expect
+		RateLimit: servermiddleware.NewRateLimiter(nil).Limit,
 	}
 
 	accountHandler := accountroute.NewHandler(m.AccountAppMock)
 	accountRoute := accountroute.NewRouter(accountHandler)
 	authHandler := authroute.NewHandler(m.AuthAppMock, m.AuthTokenMock)
 	authRoute := authroute.NewRouter(authHandler)
-	transferHandler := transferroute.NewHandler(m.TransferAppMock)
+	transferHandler := transferroute.NewHandler(m.TransferAppMock, m.ScheduledTransferAppMock, m.RecurringTransferAppMock)
 	transferRoute := transferroute.NewRouter(transferHandler)
 
 	accountRoute.RegisterRoutes(g)
@@ -90,6 +104,7 @@ func getTestTokenMaker(t *testing.T) contract.AuthToken {
 
 		tokenMaker, err = auth.NewAuthToken(cfg.Auth.AccessTokenDuration,
 			cfg.Auth.RefreshTokenDuration,
+			cfg.Auth.MFATokenDuration,
 			cfg.Auth.PasetoSymmetricKey,
 			cfg.GetLogger(),
 		)
@@ -104,19 +119,26 @@ var (
 	sessionUUID = uuid.Must(uuid.NewV7()).String()
 )
 
+// AddAuthorization logs the request in as a customer.
 func AddAuthorization(ctx context.Context, t *testing.T, req *http.Request, m SvcMocks) {
 	t.Helper()
 
-	token := addAuthorizationWithNoCache(ctx, t, req)
-	m.CacheMock.EXPECT().GetString(gomock.Any(), token).Return("", nil).Times(1)
+	AddAuthorizationWithRole(ctx, t, req, m, entity.RoleCustomer)
 }
 
-func addAuthorizationWithNoCache(ctx context.Context, t *testing.T, req *http.Request) (token string) {
+func AddAuthorizationWithRole(ctx context.Context, t *testing.T, req *http.Request, m SvcMocks, role entity.Role) {
+	t.Helper()
+
+	addAuthorizationToken(ctx, t, req, role)
+	m.AuthAppMock.EXPECT().IsSessionActive(gomock.Any(), sessionUUID).Return(true, nil).Times(1)
+}
+
+func addAuthorizationToken(ctx context.Context, t *testing.T, req *http.Request, role entity.Role) (token string) {
 	t.Helper()
 
 	tokenMaker := getTestTokenMaker(t)
 
-	token, _, err := tokenMaker.CreateAccessToken(ctx, contract.TokenPayloadInput{AccountUUID: accountUUID, SessionUUID: sessionUUID})
+	token, _, err := tokenMaker.CreateAccessToken(ctx, contract.TokenPayloadInput{AccountUUID: accountUUID, SessionUUID: sessionUUID, Role: string(role)})
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	req.Header.Set(infra.TokenKey.String(), token)
@@ -126,11 +148,23 @@ func addAuthorizationWithNoCache(ctx context.Context, t *testing.T, req *http.Re
 func GetTestContext(t *testing.T, req *http.Request, w http.ResponseWriter, authEndpoint bool) context.Context {
 	t.Helper()
 
-	c := echo.New().NewContext(req, w)
 	if authEndpoint {
-		c.Set(infra.AccountUUIDKey.String(), accountUUID)
-		c.Set(infra.SessionKey.String(), sessionUUID)
+		return GetTestContextWithRole(t, req, w, entity.RoleCustomer)
 	}
+
+	c := echo.New().NewContext(req, w)
+	return routeutils.GetContext(c)
+}
+
+// GetTestContextWithRole is the context of a request logged in with role, as
+// AddAuthorizationWithRole sets it up.
+func GetTestContextWithRole(t *testing.T, req *http.Request, w http.ResponseWriter, role entity.Role) context.Context {
+	t.Helper()
+
+	c := echo.New().NewContext(req, w)
+	c.Set(infra.AccountUUIDKey.String(), accountUUID)
+	c.Set(infra.SessionKey.String(), sessionUUID)
+	c.Set(infra.RoleKey.String(), role)
 	return routeutils.GetContext(c)
 }
 
@@ -165,12 +199,12 @@ var PrivateEndpointValidations = []PrivateEndpointTest{
 		},
 	},
 	{
-		Name: "Should return error when token is invalid",
+		Name: "Should return error when the session is no longer active",
 		SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m SvcMocks) {
-			addAuthorizationWithNoCache(ctx, t, req)
+			addAuthorizationToken(ctx, t, req, entity.RoleCustomer)
 		},
 		BuildMocks: func(ctx context.Context, m SvcMocks, body any) {
-			m.CacheMock.EXPECT().GetString(gomock.Any(), gomock.Any()).Return("invalid", nil).Times(1)
+			m.AuthAppMock.EXPECT().IsSessionActive(gomock.Any(), sessionUUID).Return(false, nil).Times(1)
 		},
 		CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 			require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -3,7 +3,10 @@ package transferroute
 import (
 	"sync"
 
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 
@@ -16,13 +19,17 @@ var (
 )
 
 type Handler struct {
-	transferService contract.TransferApp
+	transferService          contract.TransferApp
+	scheduledTransferService contract.ScheduledTransferApp
+	recurringTransferService contract.RecurringTransferApp
 }
 
-func NewHandler(transferService contract.TransferApp) *Handler {
+func NewHandler(transferService contract.TransferApp, scheduledTransferService contract.ScheduledTransferApp, recurringTransferService contract.RecurringTransferApp) *Handler {
 	Once.Do(func() {
 		instance = &Handler{
-			transferService: transferService,
+			transferService:          transferService,
+			scheduledTransferService: scheduledTransferService,
+			recurringTransferService: recurringTransferService,
 		}
 	})
 
@@ -39,28 +46,175 @@ func (s *Handler) handleAddTransfer(c echo.Context) error {
 
 	appContext := routeutils.GetContext(c)
 
-	err = s.transferService.CreateTransfer(appContext, input.ToDto())
+	if input.IsScheduled() {
+		scheduled, err := s.scheduledTransferService.ScheduleTransfer(appContext, input.ToScheduledDto())
+		if err != nil {
+			return routeutils.HandleError(c, err)
+		}
+
+		response := viewmodel.ScheduledTransferResp{}
+		response.FillFromEntity(scheduled)
+
+		return routeutils.ResponseAccepted(c, response)
+	}
+
+	transfer, err := s.transferService.CreateTransfer(appContext, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.TransferResp{}
+	response.FillFromEntity(transfer, isTransferOrigin(c, transfer))
+
+	return routeutils.ResponseCreated(c, response)
+}
+
+func (s *Handler) handleGetTransferByID(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	transferUUID, err := routeutils.GetRequiredStringPathParam(c, "transfer_uuid", "invalid transfer_uuid")
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
-	return routeutils.ResponseCreated(c)
+	transfer, err := s.transferService.GetTransferByUUID(ctx, transferUUID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.TransferResp{}
+	response.FillFromEntity(transfer, isTransferOrigin(c, transfer))
+
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handleReverseTransfer(c echo.Context) error {
+	transferUUID, err := routeutils.GetRequiredStringPathParam(c, "transfer_uuid", "invalid transfer_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	input := viewmodel.TransferReversalReq{}
+
+	err = c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	appContext := routeutils.GetContext(c)
+
+	reversal, err := s.transferService.ReverseTransfer(appContext, input.ToDto(transferUUID))
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.TransferResp{}
+	response.FillFromEntity(reversal, isTransferOrigin(c, reversal))
+
+	return routeutils.ResponseCreated(c, response)
 }
 
 func (s *Handler) handleGetTransfers(c echo.Context) error {
 	ctx := routeutils.GetContext(c)
 
+	input, err := transferHistoryInput(c)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	page, byCursor, err := routeutils.GetCursorParams(c, "cursor", "limit")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	if byCursor {
+		page.WithCount = routeutils.GetBoolQueryParam(c, "with_count")
+
+		transfers, result, err := s.transferService.GetTransfersByCursor(ctx, input, page)
+		if err != nil {
+			return routeutils.HandleError(c, err)
+		}
+
+		return routeutils.ResponseAPIOk(c, viewmodel.BuildCursorResponse(transfersResponse(c, transfers), result))
+	}
+
 	take, skip := routeutils.GetPagingParams(c, "page", "quantity")
 
-	transfers, totalRecords, err := s.transferService.GetTransfers(ctx, take, skip)
+	transfers, totalRecords, err := s.transferService.GetTransfers(ctx, input, take, skip)
 	if err != nil {
 		return routeutils.HandleError(c, err)
 	}
 
+	responsePaginated := viewmodel.BuildPaginatedResponse(transfersResponse(c, transfers), skip, take, totalRecords)
+
+	return routeutils.ResponseAPIOk(c, responsePaginated)
+}
+
+func transfersResponse(c echo.Context, transfers []entity.Transfer) []viewmodel.TransferResp {
 	response := []viewmodel.TransferResp{}
 	for _, transfer := range transfers {
 		resp := viewmodel.TransferResp{}
-		resp.FillFromEntity(transfer)
+		resp.FillFromEntity(transfer, isTransferOrigin(c, transfer))
+		response = append(response, resp)
+	}
+	return response
+}
+
+// isTransferOrigin tells whether the logged account is the origin of
+// transfer, which is the only one that sees why it failed.
+func isTransferOrigin(c echo.Context, transfer entity.Transfer) bool {
+	loggedAccountUUID, _ := c.Get(infra.AccountUUIDKey.String()).(string)
+	return loggedAccountUUID != "" && loggedAccountUUID == transfer.AccountOriginUUID
+}
+
+// transferHistoryInput reads the filters of the transfer history from the
+// query params, leaving the validation of their values to the service.
+func transferHistoryInput(c echo.Context) (input dto.TransferHistoryInput, err error) {
+	input.Direction = c.QueryParam("direction")
+	input.CounterpartyUUID = c.QueryParam("counterparty_id")
+
+	input.CreatedFrom, err = routeutils.GetTimeQueryParam(c, "from", "invalid from, it must be an RFC 3339 time")
+	if err != nil {
+		return input, err
+	}
+
+	input.CreatedTo, err = routeutils.GetTimeQueryParam(c, "to", "invalid to, it must be an RFC 3339 time")
+	if err != nil {
+		return input, err
+	}
+
+	input.MinAmount, err = routeutils.GetMoneyQueryParam(c, "min_amount", "invalid min_amount")
+	if err != nil {
+		return input, err
+	}
+
+	input.MaxAmount, err = routeutils.GetMoneyQueryParam(c, "max_amount", "invalid max_amount")
+	if err != nil {
+		return input, err
+	}
+
+	return input, nil
+}
+
+func (s *Handler) handleGetScheduledTransfers(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	take, skip := routeutils.GetPagingParams(c, "page", "quantity")
+
+	status := entity.ScheduledTransferPending
+	if c.QueryParam("status") != "" {
+		status = entity.ScheduledTransferStatus(c.QueryParam("status"))
+	}
+
+	scheduled, totalRecords, err := s.scheduledTransferService.GetScheduledTransfers(ctx, status, take, skip)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := []viewmodel.ScheduledTransferResp{}
+	for _, item := range scheduled {
+		resp := viewmodel.ScheduledTransferResp{}
+		resp.FillFromEntity(item)
 		response = append(response, resp)
 	}
 
@@ -68,3 +222,123 @@ func (s *Handler) handleGetTransfers(c echo.Context) error {
 
 	return routeutils.ResponseAPIOk(c, responsePaginated)
 }
+
+func (s *Handler) handleCancelScheduledTransfer(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	scheduledTransferUUID, err := routeutils.GetRequiredStringPathParam(c, "scheduled_transfer_uuid", "invalid scheduled_transfer_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.scheduledTransferService.CancelScheduledTransfer(ctx, scheduledTransferUUID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
+
+func (s *Handler) handleAddRecurringTransfer(c echo.Context) error {
+	input := viewmodel.RecurringTransferReq{}
+
+	err := c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	appContext := routeutils.GetContext(c)
+
+	recurring, err := s.recurringTransferService.CreateRecurringTransfer(appContext, input.ToDto())
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.RecurringTransferResp{}
+	response.FillFromEntity(recurring)
+
+	return routeutils.ResponseCreated(c, response)
+}
+
+func (s *Handler) handleGetRecurringTransfers(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	take, skip := routeutils.GetPagingParams(c, "page", "quantity")
+
+	recurring, totalRecords, err := s.recurringTransferService.GetRecurringTransfers(ctx, take, skip)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := []viewmodel.RecurringTransferResp{}
+	for _, item := range recurring {
+		resp := viewmodel.RecurringTransferResp{}
+		resp.FillFromEntity(item)
+		response = append(response, resp)
+	}
+
+	responsePaginated := viewmodel.BuildPaginatedResponse(response, skip, take, totalRecords)
+
+	return routeutils.ResponseAPIOk(c, responsePaginated)
+}
+
+func (s *Handler) handleGetRecurringTransferByID(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	recurringTransferUUID, err := routeutils.GetRequiredStringPathParam(c, "recurring_transfer_uuid", "invalid recurring_transfer_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	recurring, err := s.recurringTransferService.GetRecurringTransferByUUID(ctx, recurringTransferUUID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.RecurringTransferResp{}
+	response.FillFromEntity(recurring)
+
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handleUpdateRecurringTransfer(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	recurringTransferUUID, err := routeutils.GetRequiredStringPathParam(c, "recurring_transfer_uuid", "invalid recurring_transfer_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	input := viewmodel.RecurringTransferUpdateReq{}
+
+	err = c.Bind(&input)
+	if err != nil {
+		return routeutils.ResponseInvalidRequestBody(c, err)
+	}
+
+	recurring, err := s.recurringTransferService.UpdateRecurringTransfer(ctx, input.ToDto(recurringTransferUUID))
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	response := viewmodel.RecurringTransferResp{}
+	response.FillFromEntity(recurring)
+
+	return routeutils.ResponseAPIOk(c, response)
+}
+
+func (s *Handler) handleCancelRecurringTransfer(c echo.Context) error {
+	ctx := routeutils.GetContext(c)
+
+	recurringTransferUUID, err := routeutils.GetRequiredStringPathParam(c, "recurring_transfer_uuid", "invalid recurring_transfer_uuid")
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	err = s.recurringTransferService.CancelRecurringTransfer(ctx, recurringTransferUUID)
+	if err != nil {
+		return routeutils.HandleError(c, err)
+	}
+
+	return routeutils.ResponseNoContent(c)
+}
@@ -11,21 +11,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/test"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/transferroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	echo "github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/require"
 	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
 )
 
 func TestHandler_handleAddTransfer(t *testing.T) {
 	body := viewmodel.TransferReq{
 		AccountDestinationUUID: "randomUUID",
-		Amount:                 5.55,
+		Amount:                 entity.NewMoney(555, entity.BRL),
 	}
+	scheduledFor := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
 
 	tests := append(test.PrivateEndpointValidations,
 		test.PrivateEndpointTest{
@@ -38,11 +42,65 @@ func TestHandler_handleAddTransfer(t *testing.T) {
 				b := body.(viewmodel.TransferReq)
 				m.TransferAppMock.EXPECT().CreateTransfer(ctx,
 					dto.TransferInput{AccountDestinationUUID: b.AccountDestinationUUID, Amount: b.Amount}).
-					Return(nil).MinTimes(1)
+					Return(entity.Transfer{
+						TransferUUID: "created-uuid",
+						Amount:       b.Amount,
+						Status:       entity.TransferCompleted,
+					}, nil).MinTimes(1)
 			},
 			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, resp.Code)
-				require.Empty(t, resp.Body)
+				require.Contains(t, resp.Body.String(), `"id":"created-uuid"`)
+				require.Contains(t, resp.Body.String(), `"status":"completed"`)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should replay the first response when the idempotency key was already used",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+				req.Header.Set(infra.IdempotencyKey.String(), "retry-key")
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.IdempotencyAppMock.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{
+					State:          entity.IdempotencyCompleted,
+					ResponseStatus: http.StatusCreated,
+				}, true, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, resp.Code)
+				require.Equal(t, "true", resp.Header().Get(infra.IdempotentReplayed.String()))
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should schedule the transfer when scheduled_for is given",
+			Body: viewmodel.TransferReq{
+				AccountDestinationUUID: body.AccountDestinationUUID,
+				Amount:                 body.Amount,
+				ScheduledFor:           &scheduledFor,
+			},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				b := body.(viewmodel.TransferReq)
+				m.ScheduledTransferAppMock.EXPECT().ScheduleTransfer(ctx, gomock.Cond(func(input dto.ScheduledTransferInput) bool {
+					return input.AccountDestinationUUID == b.AccountDestinationUUID &&
+						input.Amount == b.Amount &&
+						input.ScheduledFor.Equal(scheduledFor)
+				})).Return(entity.ScheduledTransfer{
+					ScheduledTransferUUID: "scheduled-uuid",
+					TransferUUID:          "reserved-uuid",
+					Amount:                b.Amount,
+					ScheduledFor:          scheduledFor,
+					Status:                entity.ScheduledTransferPending,
+				}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, resp.Code)
+				require.Contains(t, resp.Body.String(), `"id":"scheduled-uuid"`)
+				require.Contains(t, resp.Body.String(), `"status":"pending"`)
+				require.NotContains(t, resp.Body.String(), "reserved-uuid")
 			},
 		},
 		test.PrivateEndpointTest{
@@ -66,7 +124,7 @@ func TestHandler_handleAddTransfer(t *testing.T) {
 				b := body.(viewmodel.TransferReq)
 				m.TransferAppMock.EXPECT().CreateTransfer(ctx,
 					dto.TransferInput{AccountDestinationUUID: b.AccountDestinationUUID, Amount: b.Amount}).
-					Return(fmt.Errorf("error to create transfer")).MinTimes(1)
+					Return(entity.Transfer{}, fmt.Errorf("error to create transfer")).MinTimes(1)
 			},
 			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, resp.Code)
@@ -114,9 +172,9 @@ func TestHandler_handleGetTransfers(t *testing.T) {
 		test.PrivateEndpointTest{
 			Name: "Should pass with success",
 			BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
-				m.TransferAppMock.EXPECT().GetTransfers(ctx, int64(10), int64(0)).Return([]entity.Transfer{
-					{TransferUUID: uuid.Must(uuid.NewV7()).String(), AccountOriginUUID: uuid.Must(uuid.NewV7()).String(), AccountDestinationUUID: uuid.Must(uuid.NewV7()).String(), Amount: 5.55, CreatedAt: time.Now()},
-					{TransferUUID: uuid.Must(uuid.NewV7()).String(), AccountOriginUUID: uuid.Must(uuid.NewV7()).String(), AccountDestinationUUID: uuid.Must(uuid.NewV7()).String(), Amount: 7.77, CreatedAt: time.Now()},
+				m.TransferAppMock.EXPECT().GetTransfers(ctx, dto.TransferHistoryInput{}, int64(10), int64(0)).Return([]entity.Transfer{
+					{TransferUUID: uuid.Must(uuid.NewV7()).String(), AccountOriginUUID: uuid.Must(uuid.NewV7()).String(), AccountDestinationUUID: uuid.Must(uuid.NewV7()).String(), Amount: entity.NewMoney(555, entity.BRL), CreatedAt: time.Now()},
+					{TransferUUID: uuid.Must(uuid.NewV7()).String(), AccountOriginUUID: uuid.Must(uuid.NewV7()).String(), AccountDestinationUUID: uuid.Must(uuid.NewV7()).String(), Amount: entity.NewMoney(777, entity.BRL), CreatedAt: time.Now()},
 				}, int64(0), nil).Times(1)
 			},
 			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
@@ -132,7 +190,7 @@ func TestHandler_handleGetTransfers(t *testing.T) {
 		test.PrivateEndpointTest{
 			Name: "Should return error if service get transfer returns error",
 			BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
-				m.TransferAppMock.EXPECT().GetTransfers(ctx, int64(10), int64(0)).Return(nil, int64(0), fmt.Errorf("error to get transfers")).Times(1)
+				m.TransferAppMock.EXPECT().GetTransfers(ctx, dto.TransferHistoryInput{}, int64(10), int64(0)).Return(nil, int64(0), fmt.Errorf("error to get transfers")).Times(1)
 			},
 			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
 				test.AddAuthorization(ctx, t, req, m)
@@ -175,3 +233,758 @@ func TestHandler_handleGetTransfers(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_handleGetTransfers_filters(t *testing.T) {
+	counterpartyUUID := uuid.Must(uuid.NewV7()).String()
+	from := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test.PrivateEndpointTest
+		query string
+	}{
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should pass every filter to the service",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.TransferAppMock.EXPECT().GetTransfers(ctx, dto.TransferHistoryInput{
+						Direction:        "received",
+						CreatedFrom:      &from,
+						CreatedTo:        &to,
+						MinAmount:        entity.NewMoney(1000, entity.BRL),
+						MaxAmount:        entity.NewMoney(5050, entity.BRL),
+						CounterpartyUUID: counterpartyUUID,
+					}, int64(10), int64(0)).
+						Return([]entity.Transfer{}, int64(0), nil).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, resp.Code)
+				},
+			},
+			query: "?direction=received&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z&min_amount=10&max_amount=50.50&counterparty_id=" + counterpartyUUID,
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should return error if a date is not RFC 3339",
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, resp.Code)
+					require.Contains(t, resp.Body.String(), "invalid from")
+				},
+			},
+			query: "?from=01/03/2026",
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should return error if an amount is not a number",
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, resp.Code)
+					require.Contains(t, resp.Body.String(), "invalid max_amount")
+				},
+			},
+			query: "?max_amount=ten",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers%s%s", transferroute.RootRoute, tt.query)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+			test.AddAuthorization(ctx, t, req, m)
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleGetTransfers_cursor(t *testing.T) {
+	next := &entity.Cursor{CreatedAt: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), ID: 9}
+	total := int64(30)
+
+	tests := []struct {
+		test.PrivateEndpointTest
+		query string
+	}{
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should read the first page by cursor",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.TransferAppMock.EXPECT().GetTransfersByCursor(ctx, dto.TransferHistoryInput{Direction: "sent"}, entity.CursorPage{Limit: 20, WithCount: true}).
+						Return([]entity.Transfer{{TransferUUID: "transfer-uuid", Amount: entity.NewMoney(555, entity.BRL)}}, entity.CursorResult{Next: next, TotalRecords: &total}, nil).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, resp.Code)
+					require.Contains(t, resp.Body.String(), `"next_cursor":"`+viewmodel.EncodeCursor(next, false)+`"`)
+					require.Contains(t, resp.Body.String(), `"total_records":30`)
+					require.NotContains(t, resp.Body.String(), "prev_cursor")
+					require.Contains(t, resp.Body.String(), `"id":"transfer-uuid"`)
+				},
+			},
+			query: "?direction=sent&limit=20&with_count=true",
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should read the page after the cursor",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.TransferAppMock.EXPECT().GetTransfersByCursor(ctx, dto.TransferHistoryInput{}, entity.CursorPage{After: next, Limit: 10}).
+						Return([]entity.Transfer{}, entity.CursorResult{}, nil).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, resp.Code)
+					require.NotContains(t, resp.Body.String(), "total_records")
+				},
+			},
+			query: "?cursor=" + viewmodel.EncodeCursor(next, false),
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should return error if the cursor is invalid",
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, resp.Code)
+				},
+			},
+			query: "?cursor=invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers%s%s", transferroute.RootRoute, tt.query)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+			test.AddAuthorization(ctx, t, req, m)
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleReverseTransfer(t *testing.T) {
+	transferUUID := uuid.Must(uuid.NewV7()).String()
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should reverse the transfer and return the reversal",
+			Body: viewmodel.TransferReversalReq{Amount: entity.NewMoney(250, entity.BRL)},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.TransferAppMock.EXPECT().ReverseTransfer(ctx,
+					dto.TransferReversalInput{TransferUUID: transferUUID, Amount: entity.NewMoney(250, entity.BRL)}).
+					Return(entity.Transfer{
+						TransferUUID:         "reversal-uuid",
+						Amount:               entity.NewMoney(250, entity.BRL),
+						ReversedTransferUUID: transferUUID,
+					}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, resp.Code)
+				require.Contains(t, resp.Body.String(), `"id":"reversal-uuid"`)
+				require.Contains(t, resp.Body.String(), `"reversed_transfer_id":"`+transferUUID+`"`)
+				require.Contains(t, resp.Body.String(), "2.5")
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should reverse the whole transfer when no amount is sent",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.TransferAppMock.EXPECT().ReverseTransfer(ctx, dto.TransferReversalInput{TransferUUID: transferUUID}).
+					Return(entity.Transfer{TransferUUID: "reversal-uuid"}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, resp.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return conflict when the transfer was already reversed",
+			Body: viewmodel.TransferReversalReq{},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.TransferAppMock.EXPECT().ReverseTransfer(ctx, gomock.Any()).
+					Return(entity.Transfer{}, errcodes.ErrTransferAlreadyReversed).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, resp.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return not found when the transfer doesn't exist",
+			Body: viewmodel.TransferReversalReq{},
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.TransferAppMock.EXPECT().ReverseTransfer(ctx, gomock.Any()).
+					Return(entity.Transfer{}, errcodes.ErrTransferNotFound).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers/%s/reversal", transferUUID)
+
+			var body []byte
+			if tt.Body != nil {
+				var err error
+				body, err = json.Marshal(tt.Body)
+				require.NoError(t, err)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleGetTransferByID(t *testing.T) {
+	transferUUID := uuid.Must(uuid.NewV7()).String()
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should return the transfer with its status",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				failedAt := time.Now()
+				loggedAccountUUID, _ := ctx.Value(infra.AccountUUIDKey).(string)
+				m.TransferAppMock.EXPECT().GetTransferByUUID(ctx, transferUUID).
+					Return(entity.Transfer{
+						TransferUUID:      transferUUID,
+						AccountOriginUUID: loggedAccountUUID,
+						Amount:            entity.NewMoney(250, entity.BRL),
+						Status:            entity.TransferFailed,
+						FailureReason:     "TRANSFER_INSUFFICIENT_FUNDS",
+						FailedAt:          &failedAt,
+					}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+
+				var transfer viewmodel.TransferResp
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &transfer))
+				require.Equal(t, transferUUID, transfer.TransferUUID)
+				require.Equal(t, string(entity.TransferFailed), transfer.Status)
+				require.Equal(t, "TRANSFER_INSUFFICIENT_FUNDS", transfer.FailureReason)
+				require.NotNil(t, transfer.FailedAt)
+				require.Nil(t, transfer.CompletedAt)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should not tell the destination account why the transfer failed",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				failedAt := time.Now()
+				loggedAccountUUID, _ := ctx.Value(infra.AccountUUIDKey).(string)
+				m.TransferAppMock.EXPECT().GetTransferByUUID(ctx, transferUUID).
+					Return(entity.Transfer{
+						TransferUUID:           transferUUID,
+						AccountOriginUUID:      uuid.Must(uuid.NewV7()).String(),
+						AccountDestinationUUID: loggedAccountUUID,
+						Amount:                 entity.NewMoney(250, entity.BRL),
+						Status:                 entity.TransferFailed,
+						FailureReason:          "TRANSFER_INSUFFICIENT_FUNDS",
+						FailedAt:               &failedAt,
+					}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+
+				var transfer viewmodel.TransferResp
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &transfer))
+				require.Equal(t, string(entity.TransferFailed), transfer.Status)
+				require.Empty(t, transfer.FailureReason)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return not found when the transfer doesn't exist",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.TransferAppMock.EXPECT().GetTransferByUUID(ctx, transferUUID).
+					Return(entity.Transfer{}, errcodes.ErrTransferNotFound).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers/%s", transferUUID)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleGetScheduledTransfers(t *testing.T) {
+	tests := []struct {
+		test.PrivateEndpointTest
+		query string
+	}{
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should list the pending scheduled transfers by default",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.ScheduledTransferAppMock.EXPECT().GetScheduledTransfers(ctx, entity.ScheduledTransferPending, int64(10), int64(0)).
+						Return([]entity.ScheduledTransfer{
+							{ScheduledTransferUUID: uuid.Must(uuid.NewV7()).String(), Amount: entity.NewMoney(555, entity.BRL), Status: entity.ScheduledTransferPending},
+						}, int64(1), nil).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, resp.Code)
+					require.Contains(t, resp.Body.String(), "5.55")
+				},
+			},
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should list the scheduled transfers in the status asked for",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.ScheduledTransferAppMock.EXPECT().GetScheduledTransfers(ctx, entity.ScheduledTransferFailed, int64(10), int64(0)).
+						Return([]entity.ScheduledTransfer{}, int64(0), nil).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, resp.Code)
+				},
+			},
+			query: "?status=failed",
+		},
+		{
+			PrivateEndpointTest: test.PrivateEndpointTest{
+				Name: "Should return error if service get scheduled transfers returns error",
+				BuildMocks: func(ctx context.Context, m test.SvcMocks, _ any) {
+					m.ScheduledTransferAppMock.EXPECT().GetScheduledTransfers(ctx, entity.ScheduledTransferPending, int64(10), int64(0)).
+						Return(nil, int64(0), fmt.Errorf("error to get scheduled transfers")).Times(1)
+				},
+				CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, resp.Code)
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers%s%s", transferroute.ScheduledRoute, tt.query)
+
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+			test.AddAuthorization(ctx, t, req, m)
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, nil)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleCancelScheduledTransfer(t *testing.T) {
+	scheduledTransferUUID := uuid.Must(uuid.NewV7()).String()
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should cancel the scheduled transfer",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.ScheduledTransferAppMock.EXPECT().CancelScheduledTransfer(ctx, scheduledTransferUUID).Return(nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return conflict when the scheduled transfer already started",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.ScheduledTransferAppMock.EXPECT().CancelScheduledTransfer(ctx, scheduledTransferUUID).
+					Return(errcodes.ErrScheduledTransferNotCancelable).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers/scheduled/%s", scheduledTransferUUID)
+
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleAddRecurringTransfer(t *testing.T) {
+	startsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	body := viewmodel.RecurringTransferReq{
+		AccountDestinationUUID: uuid.Must(uuid.NewV7()).String(),
+		Amount:                 entity.NewMoney(555, entity.BRL),
+		Frequency:              string(entity.RecurrenceMonthly),
+		DayOfMonth:             5,
+		StartsAt:               startsAt,
+	}
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should create the recurring transfer",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				b := body.(viewmodel.RecurringTransferReq)
+				m.RecurringTransferAppMock.EXPECT().CreateRecurringTransfer(ctx, gomock.Cond(func(input dto.RecurringTransferInput) bool {
+					return input.AccountDestinationUUID == b.AccountDestinationUUID &&
+						input.Frequency == b.Frequency &&
+						input.DayOfMonth == 5 &&
+						input.StartsAt.Equal(startsAt)
+				})).Return(entity.RecurringTransfer{
+					RecurringTransferUUID: "recurring-uuid",
+					Amount:                b.Amount,
+					Rule:                  entity.RecurrenceRule{Frequency: entity.RecurrenceMonthly, DayOfMonth: 5, StartsAt: startsAt},
+					InsufficientFunds:     entity.InsufficientFundsSkip,
+					Status:                entity.RecurringTransferActive,
+				}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, resp.Code)
+				require.Contains(t, resp.Body.String(), `"id":"recurring-uuid"`)
+				require.Contains(t, resp.Body.String(), `"status":"active"`)
+				require.Contains(t, resp.Body.String(), `"day_of_month":5`)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error if body is invalid",
+			Body: "invalid body",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, resp.Code)
+				require.Contains(t, resp.Body.String(), "invalid request body")
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return error if the destination is the logged account",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.RecurringTransferAppMock.EXPECT().CreateRecurringTransfer(ctx, gomock.Any()).
+					Return(entity.RecurringTransfer{}, errcodes.ErrSelfTransfer).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers%s", transferroute.RecurringRoute)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleUpdateRecurringTransfer(t *testing.T) {
+	recurringTransferUUID := uuid.Must(uuid.NewV7()).String()
+
+	body := viewmodel.RecurringTransferUpdateReq{
+		Amount:              entity.NewMoney(900, entity.BRL),
+		OnInsufficientFunds: string(entity.InsufficientFundsRetry),
+		MaxRetries:          2,
+	}
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should update the recurring transfer",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				b := body.(viewmodel.RecurringTransferUpdateReq)
+				m.RecurringTransferAppMock.EXPECT().UpdateRecurringTransfer(ctx, b.ToDto(recurringTransferUUID)).
+					Return(entity.RecurringTransfer{
+						RecurringTransferUUID: recurringTransferUUID,
+						Amount:                b.Amount,
+						InsufficientFunds:     entity.InsufficientFundsRetry,
+						MaxRetries:            2,
+						Status:                entity.RecurringTransferActive,
+					}, nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, resp.Code)
+				require.Contains(t, resp.Body.String(), "9")
+				require.Contains(t, resp.Body.String(), `"on_insufficient_funds":"retry"`)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return conflict when the recurring transfer is no longer active",
+			Body: body,
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.RecurringTransferAppMock.EXPECT().UpdateRecurringTransfer(ctx, gomock.Any()).
+					Return(entity.RecurringTransfer{}, errcodes.ErrRecurringTransferNotActive).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers/recurring/%s", recurringTransferUUID)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestHandler_handleCancelRecurringTransfer(t *testing.T) {
+	recurringTransferUUID := uuid.Must(uuid.NewV7()).String()
+
+	tests := append(test.PrivateEndpointValidations,
+		test.PrivateEndpointTest{
+			Name: "Should cancel the recurring transfer",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.RecurringTransferAppMock.EXPECT().CancelRecurringTransfer(ctx, recurringTransferUUID).Return(nil).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		test.PrivateEndpointTest{
+			Name: "Should return not found for someone else's recurring transfer",
+			SetupAuth: func(ctx context.Context, t *testing.T, req *http.Request, m test.SvcMocks) {
+				test.AddAuthorization(ctx, t, req, m)
+			},
+			BuildMocks: func(ctx context.Context, m test.SvcMocks, body any) {
+				m.RecurringTransferAppMock.EXPECT().CancelRecurringTransfer(ctx, recurringTransferUUID).
+					Return(errcodes.ErrRecurringTransferNotFound).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			transferroute.Once = sync.Once{}
+			m, server, ctrl := test.GetServerTest(t)
+			defer ctrl.Finish()
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/transfers/recurring/%s", recurringTransferUUID)
+
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			ctx := test.GetTestContext(t, req, recorder, true)
+
+			if tt.SetupAuth != nil {
+				tt.SetupAuth(ctx, t, req, m)
+			}
+
+			if tt.BuildMocks != nil {
+				tt.BuildMocks(ctx, m, tt.Body)
+			}
+
+			server.Echo().ServeHTTP(recorder, req)
+			if tt.CheckResponse != nil {
+				tt.CheckResponse(t, recorder)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package transferroute
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/diegoclair/apperr/httpmap"
 	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
@@ -13,7 +15,13 @@ import (
 const GroupRouteName = "transfers"
 
 const (
-	RootRoute = ""
+	RootRoute                  = ""
+	TransferByIDRoute          = "/:transfer_uuid"
+	ReversalRoute              = "/:transfer_uuid/reversal"
+	ScheduledRoute             = "/scheduled"
+	ScheduledTransferByIDRoute = "/scheduled/:scheduled_transfer_uuid"
+	RecurringRoute             = "/recurring"
+	RecurringTransferByIDRoute = "/recurring/:recurring_transfer_uuid"
 )
 
 type TransferRouter struct {
@@ -29,20 +37,137 @@ func NewRouter(ctrl *Handler) *TransferRouter {
 func (r *TransferRouter) RegisterRoutes(g *routeutils.EchoGroups) {
 	router := g.PrivateGroup.Group(GroupRouteName)
 
-	router.POST(RootRoute, r.ctrl.handleAddTransfer).
+	router.POST(RootRoute, r.ctrl.handleAddTransfer, g.RateLimit(30, time.Minute), g.Idempotent).
 		Summary("Add a new transfer").
+		Description("Returns the transfer as completed, or an error; a declined transfer is kept as failed. "+
+			"With scheduled_for the transfer is only scheduled, and made at that time").
 		Read(viewmodel.TransferReq{}).
-		Returns([]models.ReturnType{{StatusCode: http.StatusCreated}}).
-		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusCreated, Body: viewmodel.TransferResp{}},
+			{StatusCode: http.StatusAccepted, Body: viewmodel.ScheduledTransferResp{}},
+			{StatusCode: http.StatusUnprocessableEntity, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true).
+		HeaderParam(infra.IdempotencyKey.String(), infra.IdempotencyKeyDescription, goswag.StringType, false)
+
+	router.POST(ReversalRoute, r.ctrl.handleReverseTransfer, g.RateLimit(30, time.Minute), g.Idempotent).
+		Summary("Reverse a transfer").
+		Description("Give back all or part of a received transfer. Without an amount, whatever is left to reverse is given back").
+		Read(viewmodel.TransferReversalReq{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusCreated, Body: viewmodel.TransferResp{}},
+			{StatusCode: http.StatusUnprocessableEntity, Body: httpmap.ErrorResponse{}},
+		}).
+		PathParam("transfer_uuid", "transfer uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true).
+		HeaderParam(infra.IdempotencyKey.String(), infra.IdempotencyKeyDescription, goswag.StringType, false)
 
-	router.GET(RootRoute, r.ctrl.handleGetTransfers).
+	router.GET(RootRoute, r.ctrl.handleGetTransfers, g.RateLimit(120, time.Minute)).
 		Summary("Get all transfers").
-		Description("Get all transfers with paginated response").
+		Description("Get the transfers the logged account sent and received, newest first, with paginated response. "+
+			"With cursor or limit the page is read by cursor and the response has next_cursor and prev_cursor instead of page numbers").
 		Returns([]models.ReturnType{
 			{
 				StatusCode: http.StatusOK,
 				Body:       viewmodel.PaginatedResponse[[]viewmodel.TransferResp]{},
 			},
 		}).
+		QueryParam("direction", "sent or received; both when empty", goswag.StringType, false).
+		QueryParam("from", "RFC 3339 time the transfers were made from, inclusive", goswag.StringType, false).
+		QueryParam("to", "RFC 3339 time the transfers were made until, exclusive", goswag.StringType, false).
+		QueryParam("min_amount", "smallest amount, inclusive", goswag.StringType, false).
+		QueryParam("max_amount", "largest amount, inclusive", goswag.StringType, false).
+		QueryParam("counterparty_id", "uuid of the other account of the transfer", goswag.StringType, false).
+		QueryParam("page", "number of page you want", goswag.StringType, false).
+		QueryParam("quantity", "quantity of items per page", goswag.StringType, false).
+		QueryParam("cursor", "next_cursor or prev_cursor of the page read before; pages by cursor instead of by page number", goswag.StringType, false).
+		QueryParam("limit", "quantity of items per page when paging by cursor", goswag.StringType, false).
+		QueryParam("with_count", "also count every transfer when paging by cursor", goswag.StringType, false).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.GET(TransferByIDRoute, r.ctrl.handleGetTransferByID, g.RateLimit(120, time.Minute)).
+		Summary("Get transfer by ID").
+		Description("Get a transfer the logged account sent or received, with its current status and when it got there").
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.TransferResp{},
+			},
+		}).
+		PathParam("transfer_uuid", "transfer uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.GET(ScheduledRoute, r.ctrl.handleGetScheduledTransfers, g.RateLimit(120, time.Minute)).
+		Summary("Get scheduled transfers").
+		Description("Get the transfers the logged account scheduled, soonest first, with paginated response").
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.PaginatedResponse[[]viewmodel.ScheduledTransferResp]{},
+			},
+		}).
+		QueryParam("status", "pending (default), processing, completed, failed or canceled", goswag.StringType, false).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.DELETE(ScheduledTransferByIDRoute, r.ctrl.handleCancelScheduledTransfer, g.RateLimit(30, time.Minute)).
+		Summary("Cancel a scheduled transfer").
+		Description("Cancel a scheduled transfer that didn't start yet").
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+		}).
+		PathParam("scheduled_transfer_uuid", "scheduled transfer uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.POST(RecurringRoute, r.ctrl.handleAddRecurringTransfer, g.RateLimit(30, time.Minute), g.Idempotent).
+		Summary("Add a recurring transfer").
+		Description("Create a standing order: the same transfer made weekly, monthly on day_of_month, or every interval_days days, "+
+			"until ends_at or max_occurrences. When the account can't afford an occurrence it is skipped, or retried once a day "+
+			"up to max_retries times with on_insufficient_funds set to retry").
+		Read(viewmodel.RecurringTransferReq{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusCreated, Body: viewmodel.RecurringTransferResp{}},
+			{StatusCode: http.StatusUnprocessableEntity, Body: httpmap.ErrorResponse{}},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true).
+		HeaderParam(infra.IdempotencyKey.String(), infra.IdempotencyKeyDescription, goswag.StringType, false)
+
+	router.GET(RecurringRoute, r.ctrl.handleGetRecurringTransfers, g.RateLimit(120, time.Minute)).
+		Summary("Get recurring transfers").
+		Description("Get the standing orders of the logged account, newest first, with paginated response").
+		Returns([]models.ReturnType{
+			{
+				StatusCode: http.StatusOK,
+				Body:       viewmodel.PaginatedResponse[[]viewmodel.RecurringTransferResp]{},
+			},
+		}).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.GET(RecurringTransferByIDRoute, r.ctrl.handleGetRecurringTransferByID, g.RateLimit(120, time.Minute)).
+		Summary("Get a recurring transfer").
+		Description("Get a standing order of the logged account, with how many occurrences were made and when the next one is").
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusOK, Body: viewmodel.RecurringTransferResp{}},
+		}).
+		PathParam("recurring_transfer_uuid", "recurring transfer uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.PUT(RecurringTransferByIDRoute, r.ctrl.handleUpdateRecurringTransfer, g.RateLimit(30, time.Minute)).
+		Summary("Update a recurring transfer").
+		Description("Change the amount, the end or what happens on insufficient funds of an active standing order. "+
+			"To change when it runs, cancel it and add another").
+		Read(viewmodel.RecurringTransferUpdateReq{}).
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusOK, Body: viewmodel.RecurringTransferResp{}},
+		}).
+		PathParam("recurring_transfer_uuid", "recurring transfer uuid", goswag.StringType, true).
+		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
+
+	router.DELETE(RecurringTransferByIDRoute, r.ctrl.handleCancelRecurringTransfer, g.RateLimit(30, time.Minute)).
+		Summary("Cancel a recurring transfer").
+		Description("Stop a standing order, along with any occurrence waiting for a retry").
+		Returns([]models.ReturnType{
+			{StatusCode: http.StatusNoContent},
+		}).
+		PathParam("recurring_transfer_uuid", "recurring transfer uuid", goswag.StringType, true).
 		HeaderParam(infra.TokenKey.String(), infra.TokenKeyDescription, goswag.StringType, true)
 }
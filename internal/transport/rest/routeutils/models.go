@@ -2,9 +2,11 @@ package routeutils
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/diegoclair/apperr/httpmap"
 	"github.com/diegoclair/goswag/models"
+	echo "github.com/labstack/echo/v4"
 )
 
 // EchoGroups is the struct that holds the echo groups for the routes
@@ -13,6 +15,15 @@ type EchoGroups struct {
 	AppGroup models.EchoGroup
 	// PrivateGroup is the group for routes that need to be authenticated (login required)
 	PrivateGroup models.EchoGroup
+	// SupportGroup is the group for private routes of the support and admin roles
+	SupportGroup models.EchoGroup
+	// AdminGroup is the group for private routes of the admin role only
+	AdminGroup models.EchoGroup
+	// Idempotent is added to the routes that honor an Idempotency-Key header
+	Idempotent echo.MiddlewareFunc
+	// RateLimit is added to a route to take at most limit requests per window
+	// of each account, or of each ip on the public routes
+	RateLimit func(limit int64, window time.Duration) echo.MiddlewareFunc
 }
 
 // DefaultSwaggerErrors returns the standard error responses for Swagger documentation.
@@ -23,6 +34,7 @@ func DefaultSwaggerErrors() []models.ReturnType {
 		{StatusCode: http.StatusForbidden, Body: httpmap.ErrorResponse{}},
 		{StatusCode: http.StatusNotFound, Body: httpmap.ErrorResponse{}},
 		{StatusCode: http.StatusConflict, Body: httpmap.ErrorResponse{}},
+		{StatusCode: http.StatusTooManyRequests, Body: httpmap.ErrorResponse{}},
 		{StatusCode: http.StatusInternalServerError, Body: httpmap.ErrorResponse{}},
 	}
 }
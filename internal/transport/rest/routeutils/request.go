@@ -4,8 +4,11 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	"github.com/diegoclair/apperr"
 	echo "github.com/labstack/echo/v4"
 )
@@ -14,6 +17,7 @@ func GetContext(c echo.Context) (ctx context.Context) {
 	ctx = c.Request().Context()
 	ctx = context.WithValue(ctx, infra.AccountUUIDKey, c.Get(infra.AccountUUIDKey.String()))
 	ctx = context.WithValue(ctx, infra.SessionKey, c.Get(infra.SessionKey.String()))
+	ctx = context.WithValue(ctx, infra.RoleKey, c.Get(infra.RoleKey.String()))
 	return ctx
 }
 
@@ -43,6 +47,20 @@ func GetRequiredParam[T comparable](rawValue string, converter ArrayConverter[T]
 	return value, nil
 }
 
+// GetOptionalParam converts a parameter value that may be missing, in which case ok is false
+func GetOptionalParam[T any](rawValue string, converter ArrayConverter[T], errorMessage string) (value T, ok bool, err error) {
+	if strings.TrimSpace(rawValue) == "" {
+		return value, false, nil
+	}
+
+	value, err = converter(strings.TrimSpace(rawValue))
+	if err != nil {
+		return value, false, apperr.ErrInvalidInput.WithMessage(errorMessage)
+	}
+
+	return value, true, nil
+}
+
 // Convenience functions using the generic base function with existing converters
 func GetRequiredInt64PathParam(c echo.Context, paramName string, errorMessage string) (int64, error) {
 	return GetRequiredParam(c.Param(paramName), Int64Converter, errorMessage)
@@ -74,6 +92,30 @@ func GetPagingParams(c echo.Context, pageParameter, quantityParameter string) (t
 	return GetTakeSkipFromPageQuantity(page, quantity)
 }
 
+// GetCursorParams gets the keyset paging params from the URL. ok is false when
+// neither is there, so a route can keep paging by offset for the clients that
+// didn't move to cursors yet.
+func GetCursorParams(c echo.Context, cursorParameter, limitParameter string) (page entity.CursorPage, ok bool, err error) {
+	token := c.QueryParam(cursorParameter)
+	rawLimit := c.QueryParam(limitParameter)
+
+	if token == "" && rawLimit == "" {
+		return page, false, nil
+	}
+
+	if token != "" {
+		page, err = viewmodel.DecodeCursor(token)
+		if err != nil {
+			return page, false, apperr.ErrInvalidInput.WithMessage("invalid " + cursorParameter)
+		}
+	}
+
+	limit, _ := strconv.ParseInt(rawLimit, 10, 64)
+	page.Limit, _ = GetTakeSkipFromPageQuantity(1, limit)
+
+	return page, true, nil
+}
+
 func GetTakeSkipFromPageQuantity(page, quantity int64) (take, skip int64) {
 	if page < 1 {
 		page = 1
@@ -131,6 +173,16 @@ func BoolConverter(value string) (bool, error) {
 	return strconv.ParseBool(value)
 }
 
+// Time converter - RFC 3339, the way times are written in JSON
+func TimeConverter(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+// Money converter - a decimal amount in the default currency
+func MoneyConverter(value string) (entity.Money, error) {
+	return entity.ParseMoney(value, entity.DefaultCurrency)
+}
+
 // Convenience functions using the generic base function
 func GetStringArrayQueryParam(c echo.Context, paramName, separator string) []string {
 	result, _ := GetArrayParam(c.QueryParam(paramName), separator, StringConverter)
@@ -150,3 +202,18 @@ func GetBoolQueryParam(c echo.Context, paramName string) bool {
 	result, _ := BoolConverter(param)
 	return result
 }
+
+// GetTimeQueryParam returns nil when the param is missing
+func GetTimeQueryParam(c echo.Context, paramName string, errorMessage string) (*time.Time, error) {
+	value, ok, err := GetOptionalParam(c.QueryParam(paramName), TimeConverter, errorMessage)
+	if !ok {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// GetMoneyQueryParam returns a zero amount when the param is missing
+func GetMoneyQueryParam(c echo.Context, paramName string, errorMessage string) (entity.Money, error) {
+	value, _, err := GetOptionalParam(c.QueryParam(paramName), MoneyConverter, errorMessage)
+	return value, err
+}
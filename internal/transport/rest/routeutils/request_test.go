@@ -6,9 +6,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
+	"github.com/diegoclair/go_boilerplate/internal/transport/rest/viewmodel"
 	echo "github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -898,3 +901,159 @@ func TestGetTakeSkipFromPageQuantity(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTimeQueryParam(t *testing.T) {
+	tests := []struct {
+		name       string
+		paramValue string
+		wantResult *time.Time
+		wantErr    bool
+	}{
+		{
+			name:       "Valid RFC 3339 time",
+			paramValue: "2026-03-10T15:04:05Z",
+			wantResult: func() *time.Time { v := time.Date(2026, time.March, 10, 15, 4, 5, 0, time.UTC); return &v }(),
+		},
+		{
+			name:       "Empty parameter returns nil",
+			paramValue: "",
+		},
+		{
+			name:       "Date without time returns error",
+			paramValue: "2026-03-10",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryParams := map[string]string{}
+			if tt.paramValue != "" {
+				queryParams["test"] = tt.paramValue
+			}
+
+			c := setupEchoContext(queryParams)
+
+			got, err := routeutils.GetTimeQueryParam(c, "test", "invalid test")
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid test")
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.wantResult == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.True(t, tt.wantResult.Equal(*got))
+		})
+	}
+}
+
+func TestGetMoneyQueryParam(t *testing.T) {
+	tests := []struct {
+		name       string
+		paramValue string
+		wantResult entity.Money
+		wantErr    bool
+	}{
+		{
+			name:       "Valid decimal amount",
+			paramValue: "12.34",
+			wantResult: entity.NewMoney(1234, entity.DefaultCurrency),
+		},
+		{
+			name:       "Empty parameter returns zero",
+			paramValue: "",
+			wantResult: entity.Money{},
+		},
+		{
+			name:       "Too many decimal places returns error",
+			paramValue: "1.234",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryParams := map[string]string{}
+			if tt.paramValue != "" {
+				queryParams["test"] = tt.paramValue
+			}
+
+			c := setupEchoContext(queryParams)
+
+			got, err := routeutils.GetMoneyQueryParam(c, "test", "invalid test")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantResult, got)
+		})
+	}
+}
+
+func TestGetCursorParams(t *testing.T) {
+	cursor := &entity.Cursor{CreatedAt: time.Date(2026, time.March, 10, 15, 4, 5, 123456000, time.UTC), ID: 42}
+
+	tests := []struct {
+		name        string
+		queryParams map[string]string
+		wantOk      bool
+		wantAfter   *entity.Cursor
+		wantBefore  *entity.Cursor
+		wantLimit   int64
+		wantErr     bool
+	}{
+		{
+			name: "Should page by offset without cursor nor limit",
+		},
+		{
+			name:        "Should read the first page with only a limit",
+			queryParams: map[string]string{"limit": "25"},
+			wantOk:      true,
+			wantLimit:   25,
+		},
+		{
+			name:        "Should read the page after a next cursor",
+			queryParams: map[string]string{"cursor": viewmodel.EncodeCursor(cursor, false)},
+			wantOk:      true,
+			wantAfter:   cursor,
+			wantLimit:   10,
+		},
+		{
+			name:        "Should read the page before a previous cursor",
+			queryParams: map[string]string{"cursor": viewmodel.EncodeCursor(cursor, true), "limit": "5000"},
+			wantOk:      true,
+			wantBefore:  cursor,
+			wantLimit:   10,
+		},
+		{
+			name:        "Should return error for a cursor it did not make",
+			queryParams: map[string]string{"cursor": "not-a-cursor"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := setupEchoContext(tt.queryParams)
+
+			page, ok, err := routeutils.GetCursorParams(c, "cursor", "limit")
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid cursor")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantLimit, page.Limit)
+			assert.Equal(t, tt.wantAfter, page.After)
+			assert.Equal(t, tt.wantBefore, page.Before)
+		})
+	}
+}
@@ -22,6 +22,12 @@ func ResponseCreated(c echo.Context, data ...interface{}) error {
 	return c.NoContent(http.StatusCreated)
 }
 
+// ResponseAccepted returns a 202 Accepted response, for what is taken now and
+// done later
+func ResponseAccepted(c echo.Context, data interface{}) error {
+	return c.JSON(http.StatusAccepted, data)
+}
+
 func ResponseAPIOk(c echo.Context, data interface{}) error {
 	return c.JSON(http.StatusOK, data)
 }
@@ -38,3 +44,12 @@ func HandleError(c echo.Context, errorToHandle error) error {
 	status, body := httpmap.ToHTTP(errorToHandle)
 	return c.JSON(status, body)
 }
+
+// HandleErrorWithStatus answers like HandleError but with status, for the
+// statuses apperr has no kind for.
+func HandleErrorWithStatus(c echo.Context, errorToHandle error, status int) error {
+	_, body := httpmap.ToHTTP(errorToHandle)
+	body.StatusCode = status
+	body.Error = http.StatusText(status)
+	return c.JSON(status, body)
+}
@@ -3,6 +3,7 @@ package rest
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/diegoclair/go_boilerplate/infra/config"
@@ -10,6 +11,7 @@ import (
 	"github.com/diegoclair/go_boilerplate/internal/application/service"
 	"github.com/diegoclair/go_boilerplate/internal/domain"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/accountroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/authroute"
 	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routes/pingroute"
@@ -29,8 +31,8 @@ type Server struct {
 	cache  contract.CacheManager
 }
 
-func StartRestServer(ctx context.Context, cfg *config.Config, infra domain.Infrastructure, services *service.Apps, appName, port string) *Server {
-	server := NewRestServer(services, cfg.GetAuthToken(), infra.CacheManager(), appName)
+func StartRestServer(ctx context.Context, cfg *config.Config, infra domain.Infrastructure, services *service.Apps, appName, port string, trustedProxies []*net.IPNet) *Server {
+	server := NewRestServer(services, cfg.GetAuthToken(), infra.CacheManager(), appName, cfg.App.Auth.SessionCheckFailOpen, trustedProxies)
 	if port == "" {
 		port = "5000"
 	}
@@ -50,8 +52,9 @@ func StartRestServer(ctx context.Context, cfg *config.Config, infra domain.Infra
 	return server
 }
 
-func NewRestServer(services *service.Apps, authToken infraContract.AuthToken, cache contract.CacheManager, appName string) *Server {
+func NewRestServer(services *service.Apps, authToken infraContract.AuthToken, cache contract.CacheManager, appName string, sessionCheckFailOpen bool, trustedProxies []*net.IPNet) *Server {
 	router := goswag.NewEcho(routeutils.DefaultSwaggerErrors()...)
+	router.Echo().IPExtractor = servermiddleware.ClientIPExtractor(trustedProxies)
 	router.Echo().Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
 	router.Echo().HTTPErrorHandler = func(err error, c echo.Context) {
 		_ = routeutils.HandleError(c, err)
@@ -60,7 +63,7 @@ func NewRestServer(services *service.Apps, authToken infraContract.AuthToken, ca
 	pingHandler := pingroute.NewHandler()
 	accountHandler := accountroute.NewHandler(services.AccountService)
 	authHandler := authroute.NewHandler(services.AuthService, authToken)
-	transferHandler := transferroute.NewHandler(services.TransferService)
+	transferHandler := transferroute.NewHandler(services.TransferService, services.ScheduledTransferService, services.RecurringTransferService)
 
 	pingRoute := pingroute.NewRouter(pingHandler)
 	accountRoute := accountroute.NewRouter(accountHandler)
@@ -75,7 +78,7 @@ func NewRestServer(services *service.Apps, authToken infraContract.AuthToken, ca
 	server.addRouters(pingRoute)
 	server.addRouters(transferRoute)
 	server.addRouters(swaggerRoute)
-	server.registerAppRouters(authToken)
+	server.registerAppRouters(authToken, services.AuthService, sessionCheckFailOpen, services.IdempotencyService)
 
 	server.setupPrometheus(appName)
 
@@ -86,12 +89,16 @@ func (r *Server) addRouters(router routeutils.IRoute) {
 	r.routes = append(r.routes, router)
 }
 
-func (r *Server) registerAppRouters(authToken infraContract.AuthToken) {
+func (r *Server) registerAppRouters(authToken infraContract.AuthToken, authApp contract.AuthApp, sessionCheckFailOpen bool, idempotencyApp contract.IdempotencyApp) {
 	g := &routeutils.EchoGroups{}
 	g.AppGroup = r.Router.Group("/")
 	g.PrivateGroup = g.AppGroup.Group("",
-		servermiddleware.AuthMiddlewarePrivateRoute(authToken, r.cache),
+		servermiddleware.AuthMiddlewarePrivateRoute(authToken, authApp, sessionCheckFailOpen),
 	)
+	g.SupportGroup = g.PrivateGroup.Group("", servermiddleware.RequireRole(entity.RoleSupport, entity.RoleAdmin))
+	g.AdminGroup = g.PrivateGroup.Group("", servermiddleware.RequireRole(entity.RoleAdmin))
+	g.Idempotent = servermiddleware.IdempotencyMiddleware(idempotencyApp)
+	g.RateLimit = servermiddleware.NewRateLimiter(r.cache).Limit
 
 	for _, appRouter := range r.routes {
 		appRouter.RegisterRoutes(g)
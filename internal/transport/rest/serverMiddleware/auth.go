@@ -1,14 +1,24 @@
 package servermiddleware
 
 import (
+	"net/http"
+	"slices"
+
 	"github.com/diegoclair/apperr"
 	"github.com/diegoclair/go_boilerplate/infra"
 	infraContract "github.com/diegoclair/go_boilerplate/infra/contract"
 	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
 	echo "github.com/labstack/echo/v4"
 )
 
-func AuthMiddlewarePrivateRoute(authToken infraContract.AuthToken, cache contract.CacheManager) echo.MiddlewareFunc {
+// AuthMiddlewarePrivateRoute lets through the requests with a valid access
+// token of a session that is still active. When the state of the session
+// can't be read, failOpen takes the token alone, and otherwise the request is
+// refused as unavailable.
+func AuthMiddlewarePrivateRoute(authToken infraContract.AuthToken, authApp contract.AuthApp, failOpen bool) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 
@@ -22,14 +32,43 @@ func AuthMiddlewarePrivateRoute(authToken infraContract.AuthToken, cache contrac
 				return err
 			}
 
-			valid, _ := cache.GetString(ctx.Request().Context(), accessToken)
-			if valid != "" {
+			active, err := authApp.IsSessionActive(ctx.Request().Context(), payload.SessionUUID)
+			if err != nil && !failOpen {
+				return routeutils.HandleErrorWithStatus(ctx, errcodes.ErrSessionCheckUnavailable, http.StatusServiceUnavailable)
+			}
+
+			if err == nil && !active {
 				return apperr.ErrTokenInvalid
 			}
 
 			// Add information to the echo context
 			ctx.Set(infra.AccountUUIDKey.String(), payload.AccountUUID)
 			ctx.Set(infra.SessionKey.String(), payload.SessionUUID)
+			ctx.Set(infra.RoleKey.String(), tokenRole(payload))
+
+			return next(ctx)
+		}
+	}
+}
+
+// tokenRole is the role the token was issued with. The tokens issued before
+// the accounts had roles carry none, and are taken as a customer's.
+func tokenRole(payload infraContract.TokenPayload) entity.Role {
+	if payload.Role == "" {
+		return entity.RoleCustomer
+	}
+	return entity.Role(payload.Role)
+}
+
+// RequireRole lets through only the accounts with one of roles. It goes after
+// AuthMiddlewarePrivateRoute, which sets the role of the logged account.
+func RequireRole(roles ...entity.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			role, _ := ctx.Get(infra.RoleKey.String()).(entity.Role)
+			if !slices.Contains(roles, role) {
+				return routeutils.HandleErrorWithStatus(ctx, errcodes.ErrForbidden, http.StatusForbidden)
+			}
 
 			return next(ctx)
 		}
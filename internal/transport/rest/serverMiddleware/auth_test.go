@@ -1,6 +1,7 @@
 package servermiddleware
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/diegoclair/go_boilerplate/infra"
 	"github.com/diegoclair/go_boilerplate/infra/contract"
 	infraMocks "github.com/diegoclair/go_boilerplate/infra/mocks"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
 	"github.com/diegoclair/go_boilerplate/mocks"
 	"github.com/diegoclair/apperr/httpmap"
 	echo "github.com/labstack/echo/v4"
@@ -19,8 +21,8 @@ import (
 func TestAuthMiddleware(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockAuthToken := infraMocks.NewMockAuthToken(ctrl)
-	cacheMock := mocks.NewMockCacheManager(ctrl)
-	middleware := AuthMiddlewarePrivateRoute(mockAuthToken, cacheMock)
+	authAppMock := mocks.NewMockAuthApp(ctrl)
+	middleware := AuthMiddlewarePrivateRoute(mockAuthToken, authAppMock, false)
 
 	t.Run("Should complete the middleware without errors", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -31,9 +33,10 @@ func TestAuthMiddleware(t *testing.T) {
 		mockAuthToken.EXPECT().VerifyToken(gomock.Any(), "Bearer").Return(contract.TokenPayload{
 			AccountUUID: "uuid",
 			SessionUUID: "session",
+			Role:        "admin",
 		}, nil)
 
-		cacheMock.EXPECT().GetString(gomock.Any(), "Bearer").Return("", nil)
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(true, nil)
 		err := middleware(func(c echo.Context) error {
 			return nil
 		})(c)
@@ -41,6 +44,26 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, "uuid", c.Get(infra.AccountUUIDKey.String()))
 		assert.Equal(t, "session", c.Get(infra.SessionKey.String()))
+		assert.Equal(t, entity.RoleAdmin, c.Get(infra.RoleKey.String()))
+	})
+	t.Run("Should take a token without a role as a customer's", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(infra.TokenKey.String(), "Bearer")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		mockAuthToken.EXPECT().VerifyToken(gomock.Any(), "Bearer").Return(contract.TokenPayload{
+			AccountUUID: "uuid",
+			SessionUUID: "session",
+		}, nil)
+
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(true, nil)
+		err := middleware(func(c echo.Context) error {
+			return nil
+		})(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, entity.RoleCustomer, c.Get(infra.RoleKey.String()))
 	})
 	t.Run("Should return error when access token is required", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -73,7 +96,7 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, status)
 	})
 
-	t.Run("Should return error when token is already invalid", func(t *testing.T) {
+	t.Run("Should return error when the session is no longer active", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Header.Set(infra.TokenKey.String(), "Bearer")
 		rec := httptest.NewRecorder()
@@ -84,7 +107,7 @@ func TestAuthMiddleware(t *testing.T) {
 			SessionUUID: "session",
 		}, nil)
 
-		cacheMock.EXPECT().GetString(gomock.Any(), "Bearer").Return("invalid", nil)
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(false, nil)
 		err := middleware(func(c echo.Context) error {
 			return nil
 		})(c)
@@ -93,4 +116,108 @@ func TestAuthMiddleware(t *testing.T) {
 		status, _ := httpmap.ToHTTP(err)
 		assert.Equal(t, http.StatusUnauthorized, status)
 	})
+
+	t.Run("Should refuse the request when the session state can't be read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(infra.TokenKey.String(), "Bearer")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		mockAuthToken.EXPECT().VerifyToken(gomock.Any(), "Bearer").Return(contract.TokenPayload{
+			AccountUUID: "uuid",
+			SessionUUID: "session",
+		}, nil)
+
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(false, fmt.Errorf("connection refused"))
+		err := middleware(func(c echo.Context) error {
+			t.Fatal("the request should not go through")
+			return nil
+		})(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
+
+func TestAuthMiddlewareFailOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthToken := infraMocks.NewMockAuthToken(ctrl)
+	authAppMock := mocks.NewMockAuthApp(ctrl)
+	middleware := AuthMiddlewarePrivateRoute(mockAuthToken, authAppMock, true)
+
+	t.Run("Should take the token alone when the session state can't be read", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(infra.TokenKey.String(), "Bearer")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		mockAuthToken.EXPECT().VerifyToken(gomock.Any(), "Bearer").Return(contract.TokenPayload{
+			AccountUUID: "uuid",
+			SessionUUID: "session",
+		}, nil)
+
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(false, fmt.Errorf("connection refused"))
+		err := middleware(func(c echo.Context) error {
+			return nil
+		})(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "session", c.Get(infra.SessionKey.String()))
+	})
+
+	t.Run("Should still refuse a session that is no longer active", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(infra.TokenKey.String(), "Bearer")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		mockAuthToken.EXPECT().VerifyToken(gomock.Any(), "Bearer").Return(contract.TokenPayload{
+			AccountUUID: "uuid",
+			SessionUUID: "session",
+		}, nil)
+
+		authAppMock.EXPECT().IsSessionActive(gomock.Any(), "session").Return(false, nil)
+		err := middleware(func(c echo.Context) error {
+			return nil
+		})(c)
+
+		status, _ := httpmap.ToHTTP(err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	middleware := RequireRole(entity.RoleSupport, entity.RoleAdmin)
+
+	tests := []struct {
+		name       string
+		role       any
+		wantStatus int
+	}{
+		{name: "Should let an admin through", role: entity.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "Should let support through", role: entity.RoleSupport, wantStatus: http.StatusOK},
+		{name: "Should forbid a customer", role: entity.RoleCustomer, wantStatus: http.StatusForbidden},
+		{name: "Should forbid a request without a role", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			if tt.role != nil {
+				c.Set(infra.RoleKey.String(), tt.role)
+			}
+
+			err := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), "the account is not allowed to do this")
+			}
+		})
+	}
 }
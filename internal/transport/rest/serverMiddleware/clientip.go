@@ -0,0 +1,29 @@
+package servermiddleware
+
+import (
+	"net"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+// ClientIPExtractor tells echo where c.RealIP() takes the ip of the client
+// from. Without trusted proxies it is the address of the connection, since any
+// client can send an X-Forwarded-For. Behind proxies it is the last address of
+// the X-Forwarded-For that isn't one of them, so a client can't pass for
+// another ip by prepending it to the header.
+func ClientIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, ipRange := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,70 @@
+package servermiddleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPExtractor(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		req            *http.Request
+		want           string
+	}{
+		{
+			name: "Should take the address of the connection without trusted proxies",
+			req:  newRequest("203.0.113.7:43210", "198.51.100.1"),
+			want: "203.0.113.7",
+		},
+		{
+			name:           "Should take the address before the trusted proxy",
+			trustedProxies: []*net.IPNet{proxies},
+			req:            newRequest("10.0.0.1:43210", "198.51.100.1"),
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "Should skip the addresses the client prepended to the header",
+			trustedProxies: []*net.IPNet{proxies},
+			req:            newRequest("10.0.0.1:43210", "198.51.100.1, 203.0.113.7"),
+			want:           "203.0.113.7",
+		},
+		{
+			name:           "Should ignore the header of a connection that isn't from a trusted proxy",
+			trustedProxies: []*net.IPNet{proxies},
+			req:            newRequest("203.0.113.7:43210", "198.51.100.1"),
+			want:           "203.0.113.7",
+		},
+		{
+			name:           "Should not trust private addresses that aren't listed",
+			trustedProxies: []*net.IPNet{proxies},
+			req:            newRequest("192.168.0.1:43210", "198.51.100.1"),
+			want:           "192.168.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClientIPExtractor(tt.trustedProxies)(tt.req))
+		})
+	}
+}
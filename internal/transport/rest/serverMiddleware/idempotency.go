@@ -0,0 +1,142 @@
+package servermiddleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
+	echo "github.com/labstack/echo/v4"
+)
+
+// IdempotencyMiddleware runs a request sent with an Idempotency-Key at most
+// once: a retry with the same body replays the first response, a retry with
+// another body gets a 422 and one that arrives while the first is still
+// running gets a 409. Only a request that failed before changing anything
+// can run again with its key, or one that died before its claim was settled,
+// once the claim's lease runs out. Requests without the header pass straight
+// through.
+func IdempotencyMiddleware(idempotencyApp contract.IdempotencyApp) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(infra.IdempotencyKey.String())
+			if key == "" {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return routeutils.ResponseInvalidRequestBody(c, err)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := routeutils.GetContext(c)
+			input := dto.IdempotencyInput{
+				Scope:       idempotencyScope(c),
+				Key:         key,
+				Fingerprint: requestFingerprint(c.Request(), body),
+			}
+
+			request, replay, err := idempotencyApp.Begin(ctx, input)
+			if err != nil {
+				if errors.Is(err, errcodes.ErrIdempotencyKeyReused) {
+					return routeutils.HandleErrorWithStatus(c, err, http.StatusUnprocessableEntity)
+				}
+				return routeutils.HandleError(c, err)
+			}
+
+			if replay {
+				c.Response().Header().Set(infra.IdempotentReplayed.String(), "true")
+				return c.Blob(request.ResponseStatus, request.ResponseContentType, request.ResponseBody)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// The client may be gone by now, which is the case this exists for;
+			// the claim still has to be settled for its retry.
+			settleCtx := context.WithoutCancel(ctx)
+
+			// A panic must not leave the claim in flight either. It may well
+			// come after a change was kept, so it is stored as the server error
+			// it is answered with before it goes on up.
+			defer func() {
+				if r := recover(); r != nil {
+					err := fmt.Errorf("panic: %v", r)
+					if !c.Response().Committed {
+						c.Error(err)
+					}
+					settleIdempotentRequest(settleCtx, c, idempotencyApp, input, request, recorder, err)
+					panic(r)
+				}
+			}()
+
+			err = next(c)
+			if err != nil {
+				// write the error response now so it is the one stored
+				c.Error(err)
+			}
+
+			settleIdempotentRequest(settleCtx, c, idempotencyApp, input, request, recorder, err)
+			return nil
+		}
+	}
+}
+
+// settleIdempotentRequest stores the response the claimed request got, or
+// releases the key when the request failed before changing anything. Errors
+// are logged by the service and the response is out either way.
+func settleIdempotentRequest(ctx context.Context, c echo.Context, idempotencyApp contract.IdempotencyApp, input dto.IdempotencyInput, request entity.IdempotentRequest, recorder *responseRecorder, err error) {
+	status := c.Response().Status
+	if status >= http.StatusInternalServerError && errcodes.IsUnapplied(err) {
+		// nothing was changed, so the retry should run for real
+		_ = idempotencyApp.Release(ctx, input.Scope, input.Key)
+		return
+	}
+
+	// any other server error may have come after a change was kept, so its
+	// retry gets the same error back instead of running again
+	request.ResponseStatus = status
+	request.ResponseContentType = c.Response().Header().Get(echo.HeaderContentType)
+	request.ResponseBody = recorder.body.Bytes()
+	_ = idempotencyApp.Complete(ctx, request)
+}
+
+// idempotencyScope keeps keys apart per account. A public route has no account,
+// so its keys are kept apart per path instead.
+func idempotencyScope(c echo.Context) string {
+	accountUUID, _ := c.Get(infra.AccountUUIDKey.String()).(string)
+	if accountUUID != "" {
+		return "account:" + accountUUID
+	}
+
+	return "route:" + c.Request().URL.Path
+}
+
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the body written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,211 @@
+package servermiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/application/dto"
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/mocks"
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	const body = `{"account_destination_uuid":"uuid","amount":10}`
+
+	newContext := func(key string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(infra.IdempotencyKey.String(), key)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.Set(infra.AccountUUIDKey.String(), "account-uuid")
+		return c, rec
+	}
+
+	handler := func(status int) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			read, err := io.ReadAll(c.Request().Body)
+			require.NoError(t, err)
+			require.Equal(t, body, string(read), "the handler must still see the body")
+			return c.JSON(status, map[string]string{"result": "done"})
+		}
+	}
+
+	t.Run("Should pass through when there is no key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		c, rec := newContext("")
+		err := IdempotencyMiddleware(app)(handler(http.StatusCreated))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("Should run the request once and store its response", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		claim := entity.IdempotentRequest{Scope: "account:account-uuid", Key: "key", State: entity.IdempotencyInFlight}
+
+		gomock.InOrder(
+			app.EXPECT().Begin(gomock.Any(), gomock.Cond(func(input dto.IdempotencyInput) bool {
+				return input.Scope == "account:account-uuid" && input.Key == "key" && len(input.Fingerprint) == 64
+			})).Return(claim, false, nil).Times(1),
+			app.EXPECT().Complete(gomock.Any(), gomock.Cond(func(r entity.IdempotentRequest) bool {
+				return r.Key == "key" && r.ResponseStatus == http.StatusCreated &&
+					strings.HasPrefix(r.ResponseContentType, echo.MIMEApplicationJSON) &&
+					strings.Contains(string(r.ResponseBody), `"result":"done"`)
+			})).Return(nil).Times(1),
+		)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(handler(http.StatusCreated))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(infra.IdempotentReplayed.String()))
+	})
+
+	t.Run("Should replay the stored response without running the handler", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		stored := entity.IdempotentRequest{
+			Key:                 "key",
+			State:               entity.IdempotencyCompleted,
+			ResponseStatus:      http.StatusCreated,
+			ResponseContentType: echo.MIMEApplicationJSON,
+			ResponseBody:        []byte(`{"result":"first"}`),
+		}
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(stored, true, nil).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(func(c echo.Context) error {
+			t.Fatal("handler must not run on a replay")
+			return nil
+		})(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"result":"first"}`, rec.Body.String())
+		assert.Equal(t, "true", rec.Header().Get(infra.IdempotentReplayed.String()))
+	})
+
+	t.Run("Should return 422 when the key was used with another body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{}, false, errcodes.ErrIdempotencyKeyReused).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(handler(http.StatusCreated))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Should return 409 when the key is still in flight", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{}, false, errcodes.ErrIdempotencyKeyInFlight).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(handler(http.StatusCreated))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	failing := func(err error) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return err
+		}
+	}
+
+	t.Run("Should release the key when the request failed before changing anything", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{Key: "key"}, false, nil).Times(1)
+		app.EXPECT().Release(gomock.Any(), "account:account-uuid", "key").Return(nil).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(failing(errcodes.NewUnappliedError(assert.AnError)))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("Should store the server error when the request may have changed something", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{Key: "key"}, false, nil).Times(1)
+		app.EXPECT().Complete(gomock.Any(), gomock.Cond(func(r entity.IdempotentRequest) bool {
+			return r.Key == "key" && r.ResponseStatus == http.StatusInternalServerError
+		})).Return(nil).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(failing(assert.AnError))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("Should store a server error written without an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{Key: "key"}, false, nil).Times(1)
+		app.EXPECT().Complete(gomock.Any(), gomock.Cond(func(r entity.IdempotentRequest) bool {
+			return r.ResponseStatus == http.StatusInternalServerError
+		})).Return(nil).Times(1)
+
+		c, rec := newContext("key")
+		err := IdempotencyMiddleware(app)(handler(http.StatusInternalServerError))(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("Should store the server error before passing a panic on", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		app := mocks.NewMockIdempotencyApp(ctrl)
+
+		app.EXPECT().Begin(gomock.Any(), gomock.Any()).Return(entity.IdempotentRequest{Key: "key"}, false, nil).Times(1)
+		app.EXPECT().Complete(gomock.Any(), gomock.Cond(func(r entity.IdempotentRequest) bool {
+			return r.Key == "key" && r.ResponseStatus == http.StatusInternalServerError
+		})).Return(nil).Times(1)
+
+		c, rec := newContext("key")
+		require.PanicsWithValue(t, "boom", func() {
+			_ = IdempotencyMiddleware(app)(func(c echo.Context) error {
+				panic("boom")
+			})(c)
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("Should give the same fingerprint only to the same request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+		other := httptest.NewRequest(http.MethodPost, "/accounts/uuid/balance", nil)
+
+		assert.Equal(t, requestFingerprint(req, []byte(body)), requestFingerprint(req, []byte(body)))
+		assert.NotEqual(t, requestFingerprint(req, []byte(body)), requestFingerprint(req, []byte(`{}`)))
+		assert.NotEqual(t, requestFingerprint(req, []byte(body)), requestFingerprint(other, []byte(body)))
+	})
+}
@@ -0,0 +1,160 @@
+package servermiddleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/go_boilerplate/internal/domain/errcodes"
+	"github.com/diegoclair/go_boilerplate/internal/transport/rest/routeutils"
+	echo "github.com/labstack/echo/v4"
+)
+
+// RateLimiter counts the requests of each route in a sliding window kept in
+// the cache, so that all the instances share it. When the cache can't be
+// reached each instance counts on its own, in memory, rather than letting
+// everything through or turning everything away.
+type RateLimiter struct {
+	cache contract.CacheManager
+	local *localRateLimit
+}
+
+// NewRateLimiter returns a RateLimiter on cache. Without a cache the requests
+// are only counted in memory.
+func NewRateLimiter(cache contract.CacheManager) *RateLimiter {
+	return &RateLimiter{
+		cache: cache,
+		local: &localRateLimit{windows: make(map[string]*localWindow)},
+	}
+}
+
+// Limit lets through at most limit requests per window to the route, counted
+// per account on private routes and per ip on public ones. Every response
+// tells the limit and what is left of it, and one over it gets a 429 with a
+// Retry-After.
+func (l *RateLimiter) Limit(limit int64, window time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := rateLimitKey(c)
+			allowed, remaining, resetAfter := l.hit(c.Request().Context(), key, limit, window)
+
+			header := c.Response().Header()
+			header.Set(infra.RateLimitLimit.String(), strconv.FormatInt(limit, 10))
+			header.Set(infra.RateLimitRemaining.String(), strconv.FormatInt(remaining, 10))
+			header.Set(infra.RateLimitReset.String(), secondsHeader(resetAfter))
+
+			if !allowed {
+				header.Set(echo.HeaderRetryAfter, secondsHeader(resetAfter))
+
+				return routeutils.HandleErrorWithStatus(c, errcodes.ErrTooManyRequests, http.StatusTooManyRequests)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (l *RateLimiter) hit(ctx context.Context, key string, limit int64, window time.Duration) (allowed bool, remaining int64, resetAfter time.Duration) {
+	if l.cache != nil {
+		allowed, remaining, resetAfter, err := l.cache.RateLimit(ctx, key, limit, window)
+		if err == nil {
+			return allowed, remaining, resetAfter
+		}
+	}
+
+	return l.local.hit(key, limit, window, time.Now())
+}
+
+// rateLimitKey keeps the requests apart per route, and then per account or,
+// on a public route, per ip. It goes after the auth middleware, which sets the
+// account of a private route, and the ip is the one ClientIPExtractor gives,
+// so a client can't dodge the limit with a forged X-Forwarded-For.
+func rateLimitKey(c echo.Context) string {
+	route := "rate-limit:" + c.Request().Method + ":" + c.Path()
+
+	accountUUID, _ := c.Get(infra.AccountUUIDKey.String()).(string)
+	if accountUUID != "" {
+		return route + ":account:" + accountUUID
+	}
+
+	return route + ":ip:" + c.RealIP()
+}
+
+// secondsHeader rounds d up to whole seconds, so a client waiting that long
+// never comes back early.
+func secondsHeader(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// localRateLimit is the in memory fallback of the RateLimiter, with the same
+// sliding window.
+type localRateLimit struct {
+	mu        sync.Mutex
+	windows   map[string]*localWindow
+	lastSweep time.Time
+}
+
+type localWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+// localSweepInterval is how often the windows that went idle are dropped.
+const localSweepInterval = time.Minute
+
+func (l *localRateLimit) hit(key string, limit int64, window time.Duration, now time.Time) (allowed bool, remaining int64, resetAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &localWindow{window: window}
+		l.windows[key] = w
+	}
+	w.drop(now)
+
+	if int64(len(w.hits)) < limit {
+		w.hits = append(w.hits, now)
+		allowed = true
+	}
+
+	remaining = max(limit-int64(len(w.hits)), 0)
+	resetAfter = window
+	if len(w.hits) > 0 {
+		resetAfter = w.hits[0].Add(window).Sub(now)
+	}
+
+	return allowed, remaining, resetAfter
+}
+
+func (l *localRateLimit) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < localSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, w := range l.windows {
+		w.drop(now)
+		if len(w.hits) == 0 {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// drop removes the hits that left the window.
+func (w *localWindow) drop(now time.Time) {
+	start := now.Add(-w.window)
+
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(start) {
+		i++
+	}
+	w.hits = w.hits[i:]
+}
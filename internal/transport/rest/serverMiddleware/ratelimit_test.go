@@ -0,0 +1,175 @@
+package servermiddleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/infra"
+	"github.com/diegoclair/go_boilerplate/mocks"
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateLimiter_Limit(t *testing.T) {
+	newContext := func(accountUUID string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+		req.RemoteAddr = "10.0.0.1:43210"
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/transfers")
+		if accountUUID != "" {
+			c.Set(infra.AccountUUIDKey.String(), accountUUID)
+		}
+		return c, rec
+	}
+
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	t.Run("Should let the request through and tell what is left of the limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := mocks.NewMockCacheManager(ctrl)
+
+		c, rec := newContext("account-uuid")
+		cache.EXPECT().RateLimit(gomock.Any(), "rate-limit:POST:/transfers:account:account-uuid", int64(10), time.Minute).
+			Return(true, int64(7), 42*time.Second, nil).Times(1)
+
+		err := NewRateLimiter(cache).Limit(10, time.Minute)(handler)(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get(infra.RateLimitLimit.String()))
+		assert.Equal(t, "7", rec.Header().Get(infra.RateLimitRemaining.String()))
+		assert.Equal(t, "42", rec.Header().Get(infra.RateLimitReset.String()))
+		assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("Should count a public route by ip", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := mocks.NewMockCacheManager(ctrl)
+
+		c, rec := newContext("")
+		cache.EXPECT().RateLimit(gomock.Any(), "rate-limit:POST:/transfers:ip:10.0.0.1", int64(10), time.Minute).
+			Return(true, int64(9), time.Minute, nil).Times(1)
+
+		err := NewRateLimiter(cache).Limit(10, time.Minute)(handler)(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("Should count a public route by the ip of the connection whatever the client forwards", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := mocks.NewMockCacheManager(ctrl)
+
+		e := echo.New()
+		e.IPExtractor = ClientIPExtractor(nil)
+
+		cache.EXPECT().RateLimit(gomock.Any(), "rate-limit:POST:/transfers:ip:10.0.0.1", int64(10), time.Minute).
+			Return(true, int64(9), time.Minute, nil).Times(2)
+
+		limit := NewRateLimiter(cache).Limit(10, time.Minute)(handler)
+		for _, forwardedFor := range []string{"198.51.100.1", "203.0.113.7"} {
+			req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+			req.RemoteAddr = "10.0.0.1:43210"
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+			req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/transfers")
+
+			require.NoError(t, limit(c))
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+	})
+
+	t.Run("Should return too many requests with retry after when over the limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := mocks.NewMockCacheManager(ctrl)
+
+		c, rec := newContext("account-uuid")
+		cache.EXPECT().RateLimit(gomock.Any(), gomock.Any(), int64(10), time.Minute).
+			Return(false, int64(0), 1500*time.Millisecond, nil).Times(1)
+
+		err := NewRateLimiter(cache).Limit(10, time.Minute)(func(c echo.Context) error {
+			t.Fatal("the handler must not run over the limit")
+			return nil
+		})(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get(infra.RateLimitRemaining.String()))
+		assert.Equal(t, "2", rec.Header().Get(echo.HeaderRetryAfter))
+		assert.Contains(t, rec.Body.String(), "too many requests")
+	})
+
+	t.Run("Should count in memory when the cache fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cache := mocks.NewMockCacheManager(ctrl)
+		cache.EXPECT().RateLimit(gomock.Any(), gomock.Any(), int64(2), time.Minute).
+			Return(false, int64(0), time.Duration(0), errors.New("some error")).Times(3)
+
+		limit := NewRateLimiter(cache).Limit(2, time.Minute)(handler)
+		for _, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+			c, rec := newContext("account-uuid")
+			require.NoError(t, limit(c))
+			assert.Equal(t, want, rec.Code)
+		}
+	})
+}
+
+func TestLocalRateLimit_hit(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Should take hits until the window is full", func(t *testing.T) {
+		l := &localRateLimit{windows: make(map[string]*localWindow)}
+
+		allowed, remaining, resetAfter := l.hit("key", 2, time.Minute, now)
+		assert.True(t, allowed)
+		assert.Equal(t, int64(1), remaining)
+		assert.Equal(t, time.Minute, resetAfter)
+
+		allowed, remaining, resetAfter = l.hit("key", 2, time.Minute, now.Add(10*time.Second))
+		assert.True(t, allowed)
+		assert.Equal(t, int64(0), remaining)
+		assert.Equal(t, 50*time.Second, resetAfter)
+
+		allowed, remaining, resetAfter = l.hit("key", 2, time.Minute, now.Add(20*time.Second))
+		assert.False(t, allowed)
+		assert.Equal(t, int64(0), remaining)
+		assert.Equal(t, 40*time.Second, resetAfter)
+
+		allowed, _, _ = l.hit("other-key", 2, time.Minute, now.Add(20*time.Second))
+		assert.True(t, allowed, "each key has its own window")
+	})
+
+	t.Run("Should take hits again once the oldest leave the window", func(t *testing.T) {
+		l := &localRateLimit{windows: make(map[string]*localWindow)}
+
+		allowed, _, _ := l.hit("key", 1, time.Minute, now)
+		assert.True(t, allowed)
+
+		allowed, _, _ = l.hit("key", 1, time.Minute, now.Add(59*time.Second))
+		assert.False(t, allowed)
+
+		allowed, _, _ = l.hit("key", 1, time.Minute, now.Add(time.Minute))
+		assert.True(t, allowed)
+	})
+
+	t.Run("Should drop the windows that went idle", func(t *testing.T) {
+		l := &localRateLimit{windows: make(map[string]*localWindow)}
+
+		l.hit("idle", 1, time.Second, now)
+		l.hit("busy", 1, time.Hour, now)
+		l.hit("busy", 1, time.Hour, now.Add(2*localSweepInterval))
+
+		assert.NotContains(t, l.windows, "idle")
+		assert.Contains(t, l.windows, "busy")
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/diegoclair/go_boilerplate/internal/application/dto"
 	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+	"github.com/diegoclair/go_boilerplate/util/mask"
 )
 
 // validate tags are necessary to generate swagger correctly
@@ -24,23 +25,30 @@ func (a *AddAccount) ToDto() dto.AccountInput {
 }
 
 type AccountResponse struct {
-	UUID      string    `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	CPF       string    `json:"cpf,omitempty"`
-	Balance   float64   `json:"balance"`
-	CreatedAT time.Time `json:"create_at,omitempty"`
+	UUID      string       `json:"id,omitempty"`
+	Name      string       `json:"name,omitempty"`
+	CPF       string       `json:"cpf,omitempty"`
+	Balance   entity.Money `json:"balance" swaggertype:"number"`
+	Currency  string       `json:"currency,omitempty"`
+	CreatedAT time.Time    `json:"create_at,omitempty"`
 }
 
-func (a *AccountResponse) FillFromEntity(account entity.Account) {
+// FillFromEntity fills the response with the CPF masked, as in ***.456.789-**,
+// unless wholeCPF. Only the holder of the account should see it whole.
+func (a *AccountResponse) FillFromEntity(account entity.Account, wholeCPF bool) {
 	a.UUID = account.UUID
 	a.Name = account.Name
-	a.CPF = account.CPF
+	a.CPF = mask.CPF(account.CPF)
+	if wholeCPF {
+		a.CPF = account.CPF
+	}
 	a.Balance = account.Balance
+	a.Currency = string(account.Balance.Currency())
 	a.CreatedAT = account.CreatedAT
 }
 
 type AddBalance struct {
-	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Amount entity.Money `json:"amount" swaggertype:"number"`
 }
 
 func (a *AddBalance) ToDto(accountUUID string) dto.AddBalanceInput {
@@ -49,3 +57,51 @@ func (a *AddBalance) ToDto(accountUUID string) dto.AddBalanceInput {
 		Amount:      a.Amount,
 	}
 }
+
+type ChangeAccountStatus struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+func (a *ChangeAccountStatus) ToDto(accountUUID string) dto.AccountStatusInput {
+	return dto.AccountStatusInput{
+		AccountUUID: accountUUID,
+		Reason:      a.Reason,
+	}
+}
+
+type CloseAccount struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+	// SweepAccountUUID is where the balance is moved to, needed unless the
+	// balance is zero
+	SweepAccountUUID string `json:"sweep_account_id,omitempty"`
+}
+
+func (a *CloseAccount) ToDto(accountUUID string) dto.CloseAccountInput {
+	return dto.CloseAccountInput{
+		AccountUUID:      accountUUID,
+		Reason:           a.Reason,
+		SweepAccountUUID: a.SweepAccountUUID,
+	}
+}
+
+type UpdateProfile struct {
+	Name *string `json:"name,omitempty" validate:"omitempty,min=3"`
+}
+
+func (a *UpdateProfile) ToDto() dto.ProfileInput {
+	return dto.ProfileInput{
+		Name: a.Name,
+	}
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+func (a *ChangePassword) ToDto() dto.PasswordChangeInput {
+	return dto.PasswordChangeInput{
+		CurrentPassword: a.CurrentPassword,
+		NewPassword:     a.NewPassword,
+	}
+}
@@ -27,11 +27,100 @@ type LoginResponse struct {
 	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// LoginMFARequiredResponse is the answer to the login of an account with
+// two-factor authentication: MFAToken goes to LoginMFA along with a code.
+type LoginMFARequiredResponse struct {
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+type LoginMFA struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+func (l *LoginMFA) ToDto(accountUUID string) dto.LoginMFAInput {
+	return dto.LoginMFAInput{
+		AccountUUID: accountUUID,
+		Code:        l.Code,
+	}
+}
+
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func (m *MFAEnrollmentResponse) FillFromDto(enrollment dto.MFAEnrollment) {
+	m.Secret = enrollment.Secret
+	m.ProvisioningURI = enrollment.ProvisioningURI
+}
+
+type MFAConfirm struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+func (m *MFAConfirm) ToDto() dto.MFACodeInput {
+	return dto.MFACodeInput{
+		Code: m.Code,
+	}
+}
+
+type MFAConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SessionResp is a session of the account, with the device it was opened
+// from. The refresh token is never part of it.
+type SessionResp struct {
+	SessionUUID string    `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	ClientIP    string    `json:"client_ip"`
+	Current     bool      `json:"current"`
+	CreateAt    time.Time `json:"create_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (s *SessionResp) FillFromDto(session dto.Session) {
+	s.SessionUUID = session.SessionUUID
+	s.UserAgent = session.UserAgent
+	s.ClientIP = session.ClientIP
+	s.Current = session.Current
+	s.CreateAt = session.CreatedAt
+	s.ExpiresAt = session.RefreshTokenExpiredAt
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+// RefreshTokenResponse carries a new refresh token as well: the one of the
+// request can't be used again.
 type RefreshTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type PasswordResetRequest struct {
+	CPF string `json:"cpf" validate:"required,min=11,max=11"`
+}
+
+func (p *PasswordResetRequest) ToDto() dto.PasswordResetInput {
+	return dto.PasswordResetInput{
+		CPF: p.CPF,
+	}
+}
+
+type PasswordResetConfirm struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+func (p *PasswordResetConfirm) ToDto() dto.PasswordResetConfirmInput {
+	return dto.PasswordResetConfirmInput{
+		Token:       p.Token,
+		NewPassword: p.NewPassword,
+	}
 }
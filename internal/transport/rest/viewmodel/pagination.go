@@ -1,6 +1,14 @@
 package viewmodel
 
-import "math"
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/entity"
+)
 
 type ReturnPagination struct {
 	TotalRecords   int64 `json:"total_records"`
@@ -29,3 +37,84 @@ func BuildPaginatedResponse[T any](list T, skip int64, take int64, totalRecords
 		},
 	}
 }
+
+type ReturnCursorPagination struct {
+	// NextCursor and PrevCursor are empty when there is no page that way
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// TotalRecords is only sent when the count was asked for
+	TotalRecords *int64 `json:"total_records,omitempty"`
+}
+
+type CursorResponse[T any] struct {
+	Pagination ReturnCursorPagination `json:"pagination"`
+	List       T                      `json:"data"`
+}
+
+// BuildCursorResponse builds a page read by cursor, turning the cursors of the
+// pages around it into the tokens the client sends back.
+func BuildCursorResponse[T any](list T, result entity.CursorResult) CursorResponse[T] {
+	return CursorResponse[T]{
+		List: list,
+		Pagination: ReturnCursorPagination{
+			NextCursor:   EncodeCursor(result.Next, false),
+			PrevCursor:   EncodeCursor(result.Prev, true),
+			TotalRecords: result.TotalRecords,
+		},
+	}
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	cursorAfter  = "a"
+	cursorBefore = "b"
+)
+
+// EncodeCursor turns cursor into an opaque token that asks for the page after
+// it, or before it when before is true. A nil cursor is an empty token.
+func EncodeCursor(cursor *entity.Cursor, before bool) string {
+	if cursor == nil {
+		return ""
+	}
+
+	direction := cursorAfter
+	if before {
+		direction = cursorBefore
+	}
+
+	raw := fmt.Sprintf("%s:%d:%d", direction, cursor.CreatedAt.UnixMicro(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reads a token made by EncodeCursor into the page it asks for,
+// leaving the limit to the caller.
+func DecodeCursor(token string) (page entity.CursorPage, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return page, ErrInvalidCursor
+	}
+
+	var (
+		direction string
+		micros    int64
+		cursor    entity.Cursor
+	)
+
+	_, err = fmt.Sscanf(string(raw), "%1s:%d:%d", &direction, &micros, &cursor.ID)
+	if err != nil || cursor.ID <= 0 {
+		return page, ErrInvalidCursor
+	}
+	cursor.CreatedAt = time.UnixMicro(micros).UTC()
+
+	switch direction {
+	case cursorAfter:
+		page.After = &cursor
+	case cursorBefore:
+		page.Before = &cursor
+	default:
+		return page, ErrInvalidCursor
+	}
+
+	return page, nil
+}
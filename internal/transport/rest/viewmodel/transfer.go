@@ -10,8 +10,15 @@ import (
 // validate tags are necessary to generate swagger correctly
 
 type TransferReq struct {
-	AccountDestinationUUID string  `json:"account_destination_id" validate:"required,uuid"`
-	Amount                 float64 `json:"amount" validate:"required,gt=0"`
+	AccountDestinationUUID string       `json:"account_destination_id" validate:"required,uuid"`
+	Amount                 entity.Money `json:"amount" swaggertype:"number"`
+	// ScheduledFor is optional, with it the transfer is scheduled for that time
+	// instead of being made now
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+}
+
+func (t *TransferReq) IsScheduled() bool {
+	return t.ScheduledFor != nil
 }
 
 func (t *TransferReq) ToDto() dto.TransferInput {
@@ -21,18 +28,200 @@ func (t *TransferReq) ToDto() dto.TransferInput {
 	}
 }
 
+func (t *TransferReq) ToScheduledDto() dto.ScheduledTransferInput {
+	input := dto.ScheduledTransferInput{TransferInput: t.ToDto()}
+	if t.ScheduledFor != nil {
+		input.ScheduledFor = *t.ScheduledFor
+	}
+
+	return input
+}
+
+type ScheduledTransferResp struct {
+	ScheduledTransferUUID  string       `json:"id"`
+	AccountOriginUUID      string       `json:"account_origin_id,omitempty"`
+	AccountDestinationUUID string       `json:"account_destination_id,omitempty"`
+	Amount                 entity.Money `json:"amount" swaggertype:"number"`
+	Currency               string       `json:"currency,omitempty"`
+	ScheduledFor           time.Time    `json:"scheduled_for"`
+	Status                 string       `json:"status" enums:"pending,processing,completed,failed,canceled"`
+	FailureReason          string       `json:"failure_reason,omitempty"`
+	// TransferUUID is the transfer that executes the schedule, once it ran
+	TransferUUID string     `json:"transfer_id,omitempty"`
+	CreateAt     time.Time  `json:"create_at,omitempty"`
+	ExecutedAt   *time.Time `json:"executed_at,omitempty"`
+	CanceledAt   *time.Time `json:"canceled_at,omitempty"`
+	// RecurringTransferUUID is set on an occurrence of a standing order
+	RecurringTransferUUID string `json:"recurring_transfer_id,omitempty"`
+	Occurrence            int    `json:"occurrence,omitempty"`
+	Retries               int    `json:"retries,omitempty"`
+}
+
+func (t *ScheduledTransferResp) FillFromEntity(scheduled entity.ScheduledTransfer) {
+	t.ScheduledTransferUUID = scheduled.ScheduledTransferUUID
+	t.AccountOriginUUID = scheduled.AccountOriginUUID
+	t.AccountDestinationUUID = scheduled.AccountDestinationUUID
+	t.Amount = scheduled.Amount
+	t.Currency = string(scheduled.Amount.Currency())
+	t.ScheduledFor = scheduled.ScheduledFor
+	t.Status = string(scheduled.Status)
+	t.FailureReason = scheduled.FailureReason
+	t.CreateAt = scheduled.CreatedAt
+	t.ExecutedAt = scheduled.ExecutedAt
+	t.CanceledAt = scheduled.CanceledAt
+	t.RecurringTransferUUID = scheduled.RecurringTransferUUID
+	t.Occurrence = scheduled.Occurrence
+	t.Retries = scheduled.Retries
+
+	// the UUID is reserved up front, but there is no transfer behind it before
+	// the schedule runs
+	if scheduled.ExecutedAt != nil {
+		t.TransferUUID = scheduled.TransferUUID
+	}
+}
+
+type RecurringTransferReq struct {
+	AccountDestinationUUID string       `json:"account_destination_id" validate:"required,uuid"`
+	Amount                 entity.Money `json:"amount" swaggertype:"number"`
+	Frequency              string       `json:"frequency" validate:"required" enums:"weekly,monthly,interval"`
+	// IntervalDays is required by the interval frequency
+	IntervalDays int `json:"interval_days,omitempty"`
+	// DayOfMonth is required by the monthly frequency; in a shorter month the
+	// transfer is made on its last day
+	DayOfMonth int       `json:"day_of_month,omitempty"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	// EndsAt and MaxOccurrences are optional, whichever comes first ends it
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	MaxOccurrences int        `json:"max_occurrences,omitempty"`
+	// OnInsufficientFunds is skip by default
+	OnInsufficientFunds string `json:"on_insufficient_funds,omitempty" enums:"skip,retry"`
+	// MaxRetries is how many times a retry is made, one a day
+	MaxRetries int `json:"max_retries,omitempty"`
+}
+
+func (t *RecurringTransferReq) ToDto() dto.RecurringTransferInput {
+	return dto.RecurringTransferInput{
+		TransferInput: dto.TransferInput{
+			AccountDestinationUUID: t.AccountDestinationUUID,
+			Amount:                 t.Amount,
+		},
+		Frequency:      t.Frequency,
+		IntervalDays:   t.IntervalDays,
+		DayOfMonth:     t.DayOfMonth,
+		StartsAt:       t.StartsAt,
+		EndsAt:         t.EndsAt,
+		MaxOccurrences: t.MaxOccurrences,
+		OnInsufficient: t.OnInsufficientFunds,
+		MaxRetries:     t.MaxRetries,
+	}
+}
+
+type RecurringTransferUpdateReq struct {
+	Amount              entity.Money `json:"amount" swaggertype:"number"`
+	EndsAt              *time.Time   `json:"ends_at,omitempty"`
+	MaxOccurrences      int          `json:"max_occurrences,omitempty"`
+	OnInsufficientFunds string       `json:"on_insufficient_funds,omitempty" enums:"skip,retry"`
+	MaxRetries          int          `json:"max_retries,omitempty"`
+}
+
+func (t *RecurringTransferUpdateReq) ToDto(recurringTransferUUID string) dto.RecurringTransferUpdateInput {
+	return dto.RecurringTransferUpdateInput{
+		RecurringTransferUUID: recurringTransferUUID,
+		Amount:                t.Amount,
+		EndsAt:                t.EndsAt,
+		MaxOccurrences:        t.MaxOccurrences,
+		OnInsufficient:        t.OnInsufficientFunds,
+		MaxRetries:            t.MaxRetries,
+	}
+}
+
+type RecurringTransferResp struct {
+	RecurringTransferUUID  string       `json:"id"`
+	AccountOriginUUID      string       `json:"account_origin_id,omitempty"`
+	AccountDestinationUUID string       `json:"account_destination_id,omitempty"`
+	Amount                 entity.Money `json:"amount" swaggertype:"number"`
+	Currency               string       `json:"currency,omitempty"`
+	Frequency              string       `json:"frequency" enums:"weekly,monthly,interval"`
+	IntervalDays           int          `json:"interval_days,omitempty"`
+	DayOfMonth             int          `json:"day_of_month,omitempty"`
+	StartsAt               time.Time    `json:"starts_at"`
+	EndsAt                 *time.Time   `json:"ends_at,omitempty"`
+	MaxOccurrences         int          `json:"max_occurrences,omitempty"`
+	OnInsufficientFunds    string       `json:"on_insufficient_funds" enums:"skip,retry"`
+	MaxRetries             int          `json:"max_retries,omitempty"`
+	Status                 string       `json:"status" enums:"active,finished,canceled"`
+	// Occurrences is how many were made so far, and NextRunAt when the next
+	// one is, if any
+	Occurrences int        `json:"occurrences"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	CreateAt    time.Time  `json:"create_at,omitempty"`
+	CanceledAt  *time.Time `json:"canceled_at,omitempty"`
+}
+
+func (t *RecurringTransferResp) FillFromEntity(recurring entity.RecurringTransfer) {
+	t.RecurringTransferUUID = recurring.RecurringTransferUUID
+	t.AccountOriginUUID = recurring.AccountOriginUUID
+	t.AccountDestinationUUID = recurring.AccountDestinationUUID
+	t.Amount = recurring.Amount
+	t.Currency = string(recurring.Amount.Currency())
+	t.Frequency = string(recurring.Rule.Frequency)
+	t.IntervalDays = recurring.Rule.IntervalDays
+	t.DayOfMonth = recurring.Rule.DayOfMonth
+	t.StartsAt = recurring.Rule.StartsAt
+	t.EndsAt = recurring.Rule.EndsAt
+	t.MaxOccurrences = recurring.Rule.MaxOccurrences
+	t.OnInsufficientFunds = string(recurring.InsufficientFunds)
+	t.MaxRetries = recurring.MaxRetries
+	t.Status = string(recurring.Status)
+	t.Occurrences = recurring.Occurrences
+	t.NextRunAt = recurring.NextRunAt
+	t.CreateAt = recurring.CreatedAt
+	t.CanceledAt = recurring.CanceledAt
+}
+
+type TransferReversalReq struct {
+	// Amount is optional, without it whatever is left of the transfer is reversed
+	Amount entity.Money `json:"amount,omitempty" swaggertype:"number"`
+}
+
+func (t *TransferReversalReq) ToDto(transferUUID string) dto.TransferReversalInput {
+	return dto.TransferReversalInput{
+		TransferUUID: transferUUID,
+		Amount:       t.Amount,
+	}
+}
+
 type TransferResp struct {
-	TransferUUID           string    `json:"id"`
-	AccountOriginUUID      string    `json:"account_origin_id,omitempty"`
-	AccountDestinationUUID string    `json:"account_destination_id,omitempty"`
-	Amount                 float64   `json:"amount,omitempty"`
-	CreateAt               time.Time `json:"create_at,omitempty"`
+	TransferUUID           string       `json:"id"`
+	AccountOriginUUID      string       `json:"account_origin_id,omitempty"`
+	AccountDestinationUUID string       `json:"account_destination_id,omitempty"`
+	Amount                 entity.Money `json:"amount" swaggertype:"number"`
+	Currency               string       `json:"currency,omitempty"`
+	CreateAt               time.Time    `json:"create_at,omitempty"`
+	ReversedTransferUUID   string       `json:"reversed_transfer_id,omitempty"`
+	Status                 string       `json:"status,omitempty" enums:"pending,completed,failed,reversed"`
+	FailureReason          string       `json:"failure_reason,omitempty"`
+	CompletedAt            *time.Time   `json:"completed_at,omitempty"`
+	FailedAt               *time.Time   `json:"failed_at,omitempty"`
+	ReversedAt             *time.Time   `json:"reversed_at,omitempty"`
 }
 
-func (t *TransferResp) FillFromEntity(transfer entity.Transfer) {
+// FillFromEntity fills the response with transfer. The failure reason is only
+// for the origin account, the destination sees that the transfer failed but
+// not why.
+func (t *TransferResp) FillFromEntity(transfer entity.Transfer, withFailureReason bool) {
 	t.TransferUUID = transfer.TransferUUID
 	t.AccountOriginUUID = transfer.AccountOriginUUID
 	t.AccountDestinationUUID = transfer.AccountDestinationUUID
 	t.Amount = transfer.Amount
+	t.Currency = string(transfer.Amount.Currency())
 	t.CreateAt = transfer.CreatedAt
+	t.ReversedTransferUUID = transfer.ReversedTransferUUID
+	t.Status = string(transfer.Status)
+	if withFailureReason {
+		t.FailureReason = transfer.FailureReason
+	}
+	t.CompletedAt = transfer.CompletedAt
+	t.FailedAt = transfer.FailedAt
+	t.ReversedAt = transfer.ReversedAt
 }
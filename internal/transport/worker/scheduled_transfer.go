@@ -0,0 +1,132 @@
+// Package worker holds the background processes that run next to the REST
+// server, driving the application services on a timer instead of a request.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/internal/domain/contract"
+	"github.com/diegoclair/logger"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultBatchSize    = 50
+)
+
+// ScheduledTransferWorker executes the scheduled transfers once they are due,
+// first generating those the standing orders owe. Any number of instances can
+// run one: claiming is what keeps a transfer from being executed twice, not
+// there being a single worker.
+type ScheduledTransferWorker struct {
+	app          contract.ScheduledTransferApp
+	recurringApp contract.RecurringTransferApp
+	log          logger.Logger
+	pollInterval time.Duration
+	batchSize    int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewScheduledTransferWorker(app contract.ScheduledTransferApp, recurringApp contract.RecurringTransferApp, log logger.Logger, pollInterval time.Duration, batchSize int64) *ScheduledTransferWorker {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return &ScheduledTransferWorker{
+		app:          app,
+		recurringApp: recurringApp,
+		log:          log,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		done:         make(chan struct{}),
+	}
+}
+
+// StartScheduledTransferWorker starts a worker polling in the background until
+// Stop is called.
+func StartScheduledTransferWorker(ctx context.Context, app contract.ScheduledTransferApp, recurringApp contract.RecurringTransferApp, log logger.Logger, pollInterval time.Duration, batchSize int64) *ScheduledTransferWorker {
+	w := NewScheduledTransferWorker(app, recurringApp, log, pollInterval, batchSize)
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.run(ctx)
+
+	log.Info(ctx, fmt.Sprintf("Scheduled transfer worker started, polling every %s", w.pollInterval))
+	return w
+}
+
+func (w *ScheduledTransferWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.generateDue(ctx)
+		w.executeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generateDue turns the due occurrences of the standing orders into scheduled
+// transfers a batch at a time, so executeDue picks them up right after.
+func (w *ScheduledTransferWorker) generateDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		generated, err := w.recurringApp.GenerateDueOccurrences(ctx, w.batchSize)
+		if err != nil {
+			w.log.Error(ctx, "error to generate due recurring transfers", logger.Err(err))
+			return
+		}
+
+		if int64(generated) < w.batchSize {
+			return
+		}
+	}
+}
+
+// executeDue works through the due transfers a batch at a time, until a batch
+// comes back short or the worker is stopped.
+func (w *ScheduledTransferWorker) executeDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		// a batch is not cut short by Stop: a transfer canceled halfway would
+		// be recorded as failed for a reason that has nothing to do with it
+		executed, err := w.app.ExecuteDueTransfers(context.WithoutCancel(ctx), w.batchSize)
+		if err != nil {
+			w.log.Error(ctx, "error to execute due scheduled transfers", logger.Err(err))
+			return
+		}
+
+		if int64(executed) < w.batchSize {
+			return
+		}
+	}
+}
+
+// Stop stops polling and waits for the batch under way to finish, or for ctx
+// to be done, whichever comes first.
+func (w *ScheduledTransferWorker) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+
+	w.cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
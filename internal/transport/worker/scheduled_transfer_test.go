@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diegoclair/go_boilerplate/infra/configmock"
+	"github.com/diegoclair/go_boilerplate/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScheduledTransferWorker_executeDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		buildMock func(app *mocks.MockScheduledTransferApp)
+	}{
+		{
+			name: "Should stop at the first batch that comes back short",
+			buildMock: func(app *mocks.MockScheduledTransferApp) {
+				gomock.InOrder(
+					app.EXPECT().ExecuteDueTransfers(gomock.Any(), int64(2)).Return(2, nil).Times(1),
+					app.EXPECT().ExecuteDueTransfers(gomock.Any(), int64(2)).Return(2, nil).Times(1),
+					app.EXPECT().ExecuteDueTransfers(gomock.Any(), int64(2)).Return(1, nil).Times(1),
+				)
+			},
+		},
+		{
+			name: "Should stop when the batch can't be claimed",
+			buildMock: func(app *mocks.MockScheduledTransferApp) {
+				app.EXPECT().ExecuteDueTransfers(gomock.Any(), int64(2)).Return(0, assert.AnError).Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			app := mocks.NewMockScheduledTransferApp(ctrl)
+			tt.buildMock(app)
+
+			w := NewScheduledTransferWorker(app, mocks.NewMockRecurringTransferApp(ctrl), configmock.New().GetLogger(), time.Minute, 2)
+			w.executeDue(context.Background())
+		})
+	}
+}
+
+func TestScheduledTransferWorker_generateDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recurringApp := mocks.NewMockRecurringTransferApp(ctrl)
+	gomock.InOrder(
+		recurringApp.EXPECT().GenerateDueOccurrences(gomock.Any(), int64(2)).Return(2, nil).Times(1),
+		recurringApp.EXPECT().GenerateDueOccurrences(gomock.Any(), int64(2)).Return(0, nil).Times(1),
+	)
+
+	w := NewScheduledTransferWorker(mocks.NewMockScheduledTransferApp(ctrl), recurringApp, configmock.New().GetLogger(), time.Minute, 2)
+	w.generateDue(context.Background())
+}
+
+func TestScheduledTransferWorker_Stop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	polled := make(chan struct{}, 1)
+
+	app := mocks.NewMockScheduledTransferApp(ctrl)
+	app.EXPECT().ExecuteDueTransfers(gomock.Any(), int64(50)).DoAndReturn(func(context.Context, int64) (int, error) {
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+		return 0, nil
+	}).MinTimes(1)
+
+	recurringApp := mocks.NewMockRecurringTransferApp(ctrl)
+	recurringApp.EXPECT().GenerateDueOccurrences(gomock.Any(), int64(50)).Return(0, nil).MinTimes(1)
+
+	w := StartScheduledTransferWorker(context.Background(), app, recurringApp, configmock.New().GetLogger(), time.Millisecond, 0)
+
+	select {
+	case <-polled:
+	case <-time.After(time.Second):
+		t.Fatal("worker didn't poll")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Stop(ctx))
+}
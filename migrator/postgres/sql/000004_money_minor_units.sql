@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE tab_account
+    ALTER COLUMN balance DROP DEFAULT,
+    ALTER COLUMN balance TYPE BIGINT USING (COALESCE(balance, 0) * 100)::BIGINT,
+    ALTER COLUMN balance SET DEFAULT 0,
+    ALTER COLUMN balance SET NOT NULL,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
+
+ALTER TABLE tab_transfer
+    ALTER COLUMN amount TYPE BIGINT USING (amount * 100)::BIGINT,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
+
+-- +goose Down
+ALTER TABLE tab_transfer
+    DROP COLUMN currency,
+    ALTER COLUMN amount TYPE DECIMAL(7,2) USING (amount / 100.0)::DECIMAL(7,2);
+
+ALTER TABLE tab_account
+    DROP COLUMN currency,
+    ALTER COLUMN balance DROP NOT NULL,
+    ALTER COLUMN balance DROP DEFAULT,
+    ALTER COLUMN balance TYPE DECIMAL(7,2) USING (balance / 100.0)::DECIMAL(7,2),
+    ALTER COLUMN balance SET DEFAULT 0.00;
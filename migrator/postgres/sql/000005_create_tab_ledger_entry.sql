@@ -0,0 +1,123 @@
+-- +goose Up
+ALTER TABLE tab_account
+    ADD COLUMN is_system BOOLEAN NOT NULL DEFAULT false;
+
+-- The funding account is the counterpart of every deposit. It can't log in:
+-- the CPF fails validation, the secret is not a hash and it is inactive.
+INSERT INTO tab_account (account_uuid, cpf, name, secret, active, is_system)
+VALUES ('00000000-0000-0000-0000-000000000001', '00000000000', 'Funding', '', false, true);
+
+CREATE TABLE IF NOT EXISTS tab_ledger_entry (
+    ledger_entry_id BIGSERIAL PRIMARY KEY,
+    journal_uuid UUID NOT NULL,
+    account_id INT NOT NULL,
+    transfer_id INT,
+    entry_type VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_tab_ledger_entry_amount CHECK (amount <> 0),
+
+    CONSTRAINT fk_tab_ledger_entry_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION,
+
+    CONSTRAINT fk_tab_ledger_entry_tab_transfer
+        FOREIGN KEY (transfer_id)
+        REFERENCES tab_transfer (transfer_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_ledger_entry_account ON tab_ledger_entry (account_id, created_at);
+CREATE INDEX idx_tab_ledger_entry_journal ON tab_ledger_entry (journal_uuid);
+CREATE INDEX idx_tab_ledger_entry_transfer ON tab_ledger_entry (transfer_id);
+
+-- Postings of a journal must sum to zero per currency. The check runs at
+-- commit, once every posting of the journal is in.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION fn_ledger_journal_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM tab_ledger_entry
+        WHERE journal_uuid = NEW.journal_uuid
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger journal % does not sum to zero', NEW.journal_uuid
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER trg_ledger_journal_balanced
+    AFTER INSERT ON tab_ledger_entry
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION fn_ledger_journal_balanced();
+
+-- Postings are never changed, a correction is a new journal.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION fn_ledger_entry_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'tab_ledger_entry is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_ledger_entry_append_only
+    BEFORE UPDATE OR DELETE ON tab_ledger_entry
+    FOR EACH ROW EXECUTE FUNCTION fn_ledger_entry_append_only();
+
+-- Journal the history that predates the ledger: each transfer becomes its pair
+-- of postings, and whatever balance the transfers don't explain is posted as an
+-- opening balance against the funding account.
+INSERT INTO tab_ledger_entry (journal_uuid, account_id, transfer_id, entry_type, amount, currency, created_at)
+SELECT tt.transfer_uuid, posting.account_id, tt.transfer_id, 'transfer', posting.amount, tt.currency, tt.created_at
+FROM tab_transfer tt
+CROSS JOIN LATERAL (
+    VALUES (tt.account_origin_id, -tt.amount),
+           (tt.account_destination_id, tt.amount)
+) AS posting (account_id, amount);
+
+WITH opening AS (
+    SELECT ta.account_id,
+           ta.currency,
+           ta.balance - COALESCE(SUM(tle.amount), 0) AS amount,
+           gen_random_uuid() AS journal_uuid
+    FROM tab_account ta
+    LEFT JOIN tab_ledger_entry tle ON tle.account_id = ta.account_id
+    WHERE NOT ta.is_system
+    GROUP BY ta.account_id, ta.currency, ta.balance
+    HAVING ta.balance - COALESCE(SUM(tle.amount), 0) <> 0
+)
+INSERT INTO tab_ledger_entry (journal_uuid, account_id, entry_type, amount, currency)
+SELECT opening.journal_uuid, posting.account_id, 'opening_balance', posting.amount, opening.currency
+FROM opening
+CROSS JOIN LATERAL (
+    VALUES (opening.account_id, opening.amount),
+           ((SELECT account_id FROM tab_account WHERE account_uuid = '00000000-0000-0000-0000-000000000001'), -opening.amount)
+) AS posting (account_id, amount);
+
+UPDATE tab_account
+SET balance = (
+    SELECT COALESCE(SUM(amount), 0)
+    FROM tab_ledger_entry
+    WHERE account_id = tab_account.account_id
+)
+WHERE is_system;
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_ledger_entry;
+DROP FUNCTION IF EXISTS fn_ledger_entry_append_only();
+DROP FUNCTION IF EXISTS fn_ledger_journal_balanced();
+
+DELETE FROM tab_account WHERE is_system;
+
+ALTER TABLE tab_account
+    DROP COLUMN is_system;
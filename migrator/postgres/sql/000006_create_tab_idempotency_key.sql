@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tab_idempotency_key (
+    idempotency_key_id SERIAL PRIMARY KEY,
+    scope VARCHAR(500) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'in_flight',
+    response_status INT,
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    update_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_idempotency_key_scope_key UNIQUE (scope, idempotency_key),
+    CONSTRAINT ck_tab_idempotency_key_state CHECK (state IN ('in_flight', 'completed'))
+);
+
+CREATE INDEX idx_tab_idempotency_key_expires_at ON tab_idempotency_key (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_idempotency_key;
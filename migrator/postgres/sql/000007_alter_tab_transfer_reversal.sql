@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE tab_transfer
+    ADD COLUMN reversed_transfer_id INT NULL,
+    ADD CONSTRAINT fk_tab_transfer_reversed_transfer
+        FOREIGN KEY (reversed_transfer_id)
+        REFERENCES tab_transfer (transfer_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION,
+    ADD CONSTRAINT chk_tab_transfer_reversed_transfer CHECK (reversed_transfer_id <> transfer_id);
+
+CREATE INDEX idx_tab_transfer_reversed_transfer ON tab_transfer (reversed_transfer_id)
+    WHERE reversed_transfer_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_transfer_reversed_transfer;
+
+ALTER TABLE tab_transfer
+    DROP CONSTRAINT IF EXISTS chk_tab_transfer_reversed_transfer,
+    DROP CONSTRAINT IF EXISTS fk_tab_transfer_reversed_transfer,
+    DROP COLUMN IF EXISTS reversed_transfer_id;
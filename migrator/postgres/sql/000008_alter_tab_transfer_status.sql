@@ -0,0 +1,50 @@
+-- +goose Up
+ALTER TABLE tab_transfer
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    ADD COLUMN failure_reason VARCHAR(500) NULL,
+    ADD COLUMN completed_at TIMESTAMPTZ NULL,
+    ADD COLUMN failed_at TIMESTAMPTZ NULL,
+    ADD COLUMN reversed_at TIMESTAMPTZ NULL,
+    ADD CONSTRAINT chk_tab_transfer_status CHECK (status IN ('pending', 'completed', 'failed', 'reversed'));
+
+-- every row so far is a transfer that went through
+UPDATE tab_transfer
+SET completed_at = created_at;
+
+-- and the ones reversed in full are reversed
+UPDATE tab_transfer tt
+SET status      = 'reversed',
+    reversed_at = rev.last_reversed_at
+FROM (
+    SELECT
+        reversed_transfer_id,
+        SUM(amount)     AS amount,
+        MAX(created_at) AS last_reversed_at
+    FROM tab_transfer
+    WHERE reversed_transfer_id IS NOT NULL
+    GROUP BY reversed_transfer_id
+) rev
+WHERE rev.reversed_transfer_id = tt.transfer_id
+  AND rev.amount >= tt.amount;
+
+-- new rows say what they are
+ALTER TABLE tab_transfer
+    ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX idx_tab_transfer_status ON tab_transfer (status)
+    WHERE status IN ('pending', 'failed');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_transfer_status;
+
+-- transfers that never went through had no row before
+DELETE FROM tab_transfer
+WHERE status IN ('pending', 'failed');
+
+ALTER TABLE tab_transfer
+    DROP CONSTRAINT IF EXISTS chk_tab_transfer_status,
+    DROP COLUMN IF EXISTS reversed_at,
+    DROP COLUMN IF EXISTS failed_at,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,51 @@
+-- +goose Up
+
+-- a scheduled transfer is executed under the transfer_uuid it was given, and
+-- this is what keeps a retried execution from making a second transfer
+CREATE UNIQUE INDEX IF NOT EXISTS uq_tab_transfer_transfer_uuid ON tab_transfer (transfer_uuid);
+
+CREATE TABLE IF NOT EXISTS tab_scheduled_transfer (
+    scheduled_transfer_id SERIAL PRIMARY KEY,
+    scheduled_transfer_uuid UUID NOT NULL,
+    account_origin_id INT NOT NULL,
+    account_destination_id INT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    transfer_uuid UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    failure_reason VARCHAR(500) NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    claimed_at TIMESTAMPTZ NULL,
+    executed_at TIMESTAMPTZ NULL,
+    canceled_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    update_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_scheduled_transfer_uuid UNIQUE (scheduled_transfer_uuid),
+    CONSTRAINT uq_tab_scheduled_transfer_transfer_uuid UNIQUE (transfer_uuid),
+    CONSTRAINT chk_tab_scheduled_transfer_amount CHECK (amount > 0),
+    CONSTRAINT chk_tab_scheduled_transfer_status CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'canceled')),
+
+    CONSTRAINT fk_tab_scheduled_transfer_origin
+        FOREIGN KEY (account_origin_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION,
+
+    CONSTRAINT fk_tab_scheduled_transfer_destination
+        FOREIGN KEY (account_destination_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_scheduled_transfer_account_origin ON tab_scheduled_transfer (account_origin_id, scheduled_for);
+
+-- what the workers poll: the rows still to run, or stuck with a worker
+CREATE INDEX idx_tab_scheduled_transfer_due ON tab_scheduled_transfer (scheduled_for)
+    WHERE status IN ('pending', 'processing');
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_scheduled_transfer;
+DROP INDEX IF EXISTS uq_tab_transfer_transfer_uuid;
@@ -0,0 +1,75 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tab_recurring_transfer (
+    recurring_transfer_id SERIAL PRIMARY KEY,
+    recurring_transfer_uuid UUID NOT NULL,
+    account_origin_id INT NOT NULL,
+    account_destination_id INT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    frequency VARCHAR(20) NOT NULL,
+    interval_days INT NULL,
+    day_of_month INT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NULL,
+    max_occurrences INT NULL,
+    insufficient_funds_policy VARCHAR(20) NOT NULL DEFAULT 'skip',
+    max_retries INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    occurrences INT NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMPTZ NULL,
+    canceled_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    update_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_recurring_transfer_uuid UNIQUE (recurring_transfer_uuid),
+    CONSTRAINT chk_tab_recurring_transfer_amount CHECK (amount > 0),
+    CONSTRAINT chk_tab_recurring_transfer_frequency CHECK (frequency IN ('weekly', 'monthly', 'interval')),
+    CONSTRAINT chk_tab_recurring_transfer_interval_days CHECK (frequency <> 'interval' OR interval_days > 0),
+    CONSTRAINT chk_tab_recurring_transfer_day_of_month CHECK (frequency <> 'monthly' OR day_of_month BETWEEN 1 AND 31),
+    CONSTRAINT chk_tab_recurring_transfer_policy CHECK (insufficient_funds_policy IN ('skip', 'retry')),
+    CONSTRAINT chk_tab_recurring_transfer_status CHECK (status IN ('active', 'finished', 'canceled')),
+
+    CONSTRAINT fk_tab_recurring_transfer_origin
+        FOREIGN KEY (account_origin_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION,
+
+    CONSTRAINT fk_tab_recurring_transfer_destination
+        FOREIGN KEY (account_destination_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_recurring_transfer_account_origin ON tab_recurring_transfer (account_origin_id, created_at);
+
+-- what the scheduler polls for occurrences to generate
+CREATE INDEX idx_tab_recurring_transfer_due ON tab_recurring_transfer (next_run_at)
+    WHERE status = 'active';
+
+ALTER TABLE tab_scheduled_transfer
+    ADD COLUMN recurring_transfer_id INT NULL,
+    ADD COLUMN occurrence INT NULL,
+    ADD COLUMN max_retries INT NOT NULL DEFAULT 0,
+    ADD COLUMN retries INT NOT NULL DEFAULT 0,
+
+    -- the last line of defense for generating each occurrence only once
+    ADD CONSTRAINT uq_tab_scheduled_transfer_occurrence UNIQUE (recurring_transfer_id, occurrence),
+
+    ADD CONSTRAINT fk_tab_scheduled_transfer_recurring
+        FOREIGN KEY (recurring_transfer_id)
+        REFERENCES tab_recurring_transfer (recurring_transfer_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION;
+
+-- +goose Down
+ALTER TABLE tab_scheduled_transfer
+    DROP CONSTRAINT IF EXISTS fk_tab_scheduled_transfer_recurring,
+    DROP CONSTRAINT IF EXISTS uq_tab_scheduled_transfer_occurrence,
+    DROP COLUMN IF EXISTS retries,
+    DROP COLUMN IF EXISTS max_retries,
+    DROP COLUMN IF EXISTS occurrence,
+    DROP COLUMN IF EXISTS recurring_transfer_id;
+
+DROP TABLE IF EXISTS tab_recurring_transfer;
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- overrides of the transfer limits set in the config; a NULL cap keeps the
+-- default and a zero one lifts it
+CREATE TABLE IF NOT EXISTS tab_account_limit (
+    account_id INT PRIMARY KEY,
+    currency CHAR(3) NOT NULL DEFAULT 'BRL',
+    per_transaction BIGINT NULL,
+    daily BIGINT NULL,
+    monthly BIGINT NULL,
+    night_time BIGINT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    update_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_tab_account_limit_amounts CHECK (
+        COALESCE(per_transaction, 0) >= 0 AND
+        COALESCE(daily, 0) >= 0 AND
+        COALESCE(monthly, 0) >= 0 AND
+        COALESCE(night_time, 0) >= 0
+    ),
+
+    CONSTRAINT fk_tab_account_limit_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+-- what the usage of the limits is summed from on every transfer
+CREATE INDEX idx_tab_transfer_account_origin_created_at ON tab_transfer (account_origin_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_transfer_account_origin_created_at;
+DROP TABLE IF EXISTS tab_account_limit;
@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- the transfer history reads both directions of an account newest first;
+-- the origin side is already indexed by created_at
+CREATE INDEX idx_tab_transfer_account_destination_created_at ON tab_transfer (account_destination_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_transfer_account_destination_created_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- the accounts are paged by cursor over (created_at, account_id)
+CREATE INDEX idx_tab_account_created_at_account_id ON tab_account (created_at, account_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_account_created_at_account_id;
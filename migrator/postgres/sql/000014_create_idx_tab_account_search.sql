@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- accounts are searched by part of the name, whatever the case, which only a
+-- trigram index can serve
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_tab_account_name_trgm ON tab_account USING GIN (name gin_trgm_ops);
+
+-- and by the beginning of the cpf, a LIKE the unique index can't serve unless
+-- the collation is C
+CREATE INDEX idx_tab_account_cpf_pattern ON tab_account (cpf varchar_pattern_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tab_account_cpf_pattern;
+DROP INDEX IF EXISTS idx_tab_account_name_trgm;
@@ -0,0 +1,40 @@
+-- +goose Up
+
+-- a closed account is never opened again, and keeps no balance
+ALTER TABLE tab_account
+    ADD COLUMN closed_at TIMESTAMPTZ NULL,
+    ADD CONSTRAINT chk_tab_account_closed CHECK (closed_at IS NULL OR (NOT active AND balance = 0));
+
+-- why each deactivation, reactivation and closure was made; a closure that
+-- moved the balance out links the transfer that did it
+CREATE TABLE IF NOT EXISTS tab_account_status_change (
+    account_status_change_id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    sweep_transfer_id INT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_tab_account_status_change_action CHECK (action IN ('deactivated', 'reactivated', 'closed')),
+
+    CONSTRAINT fk_tab_account_status_change_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION,
+
+    CONSTRAINT fk_tab_account_status_change_tab_transfer
+        FOREIGN KEY (sweep_transfer_id)
+        REFERENCES tab_transfer (transfer_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_account_status_change_account ON tab_account_status_change (account_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_account_status_change;
+
+ALTER TABLE tab_account
+    DROP CONSTRAINT IF EXISTS chk_tab_account_closed,
+    DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- only the hash of a reset token is kept, so a leaked row can't reset a
+-- password; a token is spent once consumed_at is set
+CREATE TABLE IF NOT EXISTS tab_password_reset (
+    password_reset_id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_password_reset_token_hash UNIQUE (token_hash),
+
+    CONSTRAINT fk_tab_password_reset_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_password_reset_account ON tab_password_reset (account_id) WHERE consumed_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_password_reset;
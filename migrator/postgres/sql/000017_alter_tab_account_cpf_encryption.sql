@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- the cpf is stored encrypted, and a random nonce makes the same cpf encrypt
+-- differently every time, so it is looked up and kept unique through a keyed
+-- hash of it instead; rows written before are filled in by the encrypt-cpf
+-- command, which is why the index column starts out nullable
+ALTER TABLE tab_account ALTER COLUMN cpf TYPE VARCHAR(255);
+ALTER TABLE tab_account ADD COLUMN IF NOT EXISTS cpf_index CHAR(64) NULL;
+
+CREATE UNIQUE INDEX uq_tab_account_cpf_index ON tab_account (cpf_index);
+
+-- the beginning of a ciphertext says nothing about the cpf
+DROP INDEX IF EXISTS idx_tab_account_cpf_pattern;
+
+-- +goose Down
+
+-- the cpf only fits back in its column while no row is encrypted yet
+CREATE INDEX idx_tab_account_cpf_pattern ON tab_account (cpf varchar_pattern_ops);
+DROP INDEX IF EXISTS uq_tab_account_cpf_index;
+ALTER TABLE tab_account DROP COLUMN IF EXISTS cpf_index;
+ALTER TABLE tab_account ALTER COLUMN cpf TYPE VARCHAR(11);
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- what an account is allowed to do; every account is a customer until it is
+-- granted another role with the grant-role command
+ALTER TABLE tab_account
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer',
+    ADD CONSTRAINT chk_tab_account_role CHECK (role IN ('customer', 'support', 'admin'));
+
+-- +goose Down
+ALTER TABLE tab_account
+    DROP CONSTRAINT IF EXISTS chk_tab_account_role,
+    DROP COLUMN IF EXISTS role;
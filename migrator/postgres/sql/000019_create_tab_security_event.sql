@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- events worth looking into later, such as a login locked by too many
+-- failures; the cpf is kept by its blind index, and an event of an ip may
+-- have no account
+CREATE TABLE IF NOT EXISTS tab_security_event (
+    security_event_id SERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    account_id INT NULL,
+    cpf_index CHAR(64) NULL,
+    client_ip VARCHAR(45) NULL,
+    locked_until TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_tab_security_event_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+CREATE INDEX idx_tab_security_event_account ON tab_security_event (account_id, created_at);
+CREATE INDEX idx_tab_security_event_created_at ON tab_security_event (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_security_event;
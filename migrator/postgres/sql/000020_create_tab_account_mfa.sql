@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- the TOTP second factor of an account, at most one each; the secret is
+-- encrypted like the cpf, and the factor is only asked for at login once
+-- confirmed_at is set. last_used_step is the time step of the last code
+-- taken, so a code can't be used twice
+CREATE TABLE IF NOT EXISTS tab_account_mfa (
+    account_mfa_id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    update_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_account_mfa_account UNIQUE (account_id),
+
+    CONSTRAINT fk_tab_account_mfa_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+-- the codes that stand in for the app when it is lost; only their keyed hash
+-- is kept, and each one is spent once used_at is set
+CREATE TABLE IF NOT EXISTS tab_mfa_recovery_code (
+    mfa_recovery_code_id SERIAL PRIMARY KEY,
+    account_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tab_mfa_recovery_code_account_code UNIQUE (account_id, code_hash),
+
+    CONSTRAINT fk_tab_mfa_recovery_code_tab_account
+        FOREIGN KEY (account_id)
+        REFERENCES tab_account (account_id)
+        ON DELETE NO ACTION
+        ON UPDATE NO ACTION
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS tab_mfa_recovery_code;
+DROP TABLE IF EXISTS tab_account_mfa;
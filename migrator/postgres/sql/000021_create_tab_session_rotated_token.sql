@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- the refresh tokens a session rotated out, by their sha-256. A session is
+-- the family of the tokens it handed out, and one of them presented again
+-- means it was stolen
+CREATE TABLE IF NOT EXISTS tab_session_rotated_token (
+    session_rotated_token_id SERIAL PRIMARY KEY,
+    session_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_tab_session_rotated_token_tab_session
+        FOREIGN KEY (session_id)
+        REFERENCES tab_session (session_id)
+        ON DELETE CASCADE
+        ON UPDATE NO ACTION,
+
+    CONSTRAINT uq_tab_session_rotated_token UNIQUE (session_id, token_hash)
+);
+
+ALTER TABLE tab_security_event ADD COLUMN IF NOT EXISTS session_uuid UUID NULL;
+
+-- +goose Down
+ALTER TABLE tab_security_event DROP COLUMN IF EXISTS session_uuid;
+
+DROP TABLE IF EXISTS tab_session_rotated_token;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- a session keeps only the sha-256 of its refresh token, in hex, so the table
+-- leaking doesn't hand out tokens that still work
+UPDATE tab_session
+SET refresh_token = encode(sha256(convert_to(refresh_token, 'UTF8')), 'hex');
+
+ALTER TABLE tab_session RENAME COLUMN refresh_token TO refresh_token_hash;
+ALTER TABLE tab_session ALTER COLUMN refresh_token_hash TYPE CHAR(64);
+
+-- +goose Down
+-- the tokens can't be had back from their hashes, so the sessions in the
+-- table won't refresh again and their accounts have to log in once more
+ALTER TABLE tab_session ALTER COLUMN refresh_token_hash TYPE VARCHAR(1500);
+ALTER TABLE tab_session RENAME COLUMN refresh_token_hash TO refresh_token;
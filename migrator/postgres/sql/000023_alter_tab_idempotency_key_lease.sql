@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- an in-flight claim is a lease: one nobody settled by in_flight_until belongs
+-- to a request that died, and the next request with the key takes it over
+ALTER TABLE tab_idempotency_key ADD COLUMN in_flight_until TIMESTAMPTZ;
+
+UPDATE tab_idempotency_key
+SET in_flight_until = update_at + INTERVAL '1 minute'
+WHERE state = 'in_flight';
+
+-- +goose Down
+ALTER TABLE tab_idempotency_key DROP COLUMN IF EXISTS in_flight_until;
@@ -177,6 +177,23 @@ func (mr *MockCacheManagerMockRecorder) Increase(ctx, key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increase", reflect.TypeOf((*MockCacheManager)(nil).Increase), ctx, key)
 }
 
+// RateLimit mocks base method.
+func (m *MockCacheManager) RateLimit(ctx context.Context, key string, limit int64, window time.Duration) (bool, int64, time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateLimit", ctx, key, limit, window)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(time.Duration)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// RateLimit indicates an expected call of RateLimit.
+func (mr *MockCacheManagerMockRecorder) RateLimit(ctx, key, limit, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateLimit", reflect.TypeOf((*MockCacheManager)(nil).RateLimit), ctx, key, limit, window)
+}
+
 // Set mocks base method.
 func (m *MockCacheManager) Set(ctx context.Context, key string, data any, expiration ...time.Duration) error {
 	m.ctrl.T.Helper()
@@ -209,3 +226,23 @@ func (mr *MockCacheManagerMockRecorder) SetExpiration(ctx, key, expiration any)
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExpiration", reflect.TypeOf((*MockCacheManager)(nil).SetExpiration), ctx, key, expiration)
 }
+
+// SetNX mocks base method.
+func (m *MockCacheManager) SetNX(ctx context.Context, key string, data any, expiration ...time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, data}
+	for _, a := range expiration {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetNX", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockCacheManagerMockRecorder) SetNX(ctx, key, data any, expiration ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, data}, expiration...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockCacheManager)(nil).SetNX), varargs...)
+}
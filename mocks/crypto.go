@@ -39,6 +39,20 @@ func (m *MockCrypto) EXPECT() *MockCryptoMockRecorder {
 	return m.recorder
 }
 
+// BlindIndex mocks base method.
+func (m *MockCrypto) BlindIndex(value string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlindIndex", value)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlindIndex indicates an expected call of BlindIndex.
+func (mr *MockCryptoMockRecorder) BlindIndex(value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlindIndex", reflect.TypeOf((*MockCrypto)(nil).BlindIndex), value)
+}
+
 // CheckPassword mocks base method.
 func (m *MockCrypto) CheckPassword(password, hashedPassword string) error {
 	m.ctrl.T.Helper()
@@ -53,6 +67,36 @@ func (mr *MockCryptoMockRecorder) CheckPassword(password, hashedPassword any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPassword", reflect.TypeOf((*MockCrypto)(nil).CheckPassword), password, hashedPassword)
 }
 
+// DecryptField mocks base method.
+func (m *MockCrypto) DecryptField(encrypted string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecryptField", encrypted)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecryptField indicates an expected call of DecryptField.
+func (mr *MockCryptoMockRecorder) DecryptField(encrypted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecryptField", reflect.TypeOf((*MockCrypto)(nil).DecryptField), encrypted)
+}
+
+// EncryptField mocks base method.
+func (m *MockCrypto) EncryptField(value string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncryptField", value)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EncryptField indicates an expected call of EncryptField.
+func (mr *MockCryptoMockRecorder) EncryptField(value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncryptField", reflect.TypeOf((*MockCrypto)(nil).EncryptField), value)
+}
+
 // HashPassword mocks base method.
 func (m *MockCrypto) HashPassword(password string) (string, error) {
 	m.ctrl.T.Helper()
@@ -67,3 +111,17 @@ func (mr *MockCryptoMockRecorder) HashPassword(password any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockCrypto)(nil).HashPassword), password)
 }
+
+// NeedsReencryption mocks base method.
+func (m *MockCrypto) NeedsReencryption(encrypted string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsReencryption", encrypted)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsReencryption indicates an expected call of NeedsReencryption.
+func (mr *MockCryptoMockRecorder) NeedsReencryption(encrypted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsReencryption", reflect.TypeOf((*MockCrypto)(nil).NeedsReencryption), encrypted)
+}
@@ -98,6 +98,20 @@ func (mr *MockInfrastructureMockRecorder) Logger() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockInfrastructure)(nil).Logger))
 }
 
+// Notifier mocks base method.
+func (m *MockInfrastructure) Notifier() contract.Notifier {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notifier")
+	ret0, _ := ret[0].(contract.Notifier)
+	return ret0
+}
+
+// Notifier indicates an expected call of Notifier.
+func (mr *MockInfrastructureMockRecorder) Notifier() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notifier", reflect.TypeOf((*MockInfrastructure)(nil).Notifier))
+}
+
 // Validator mocks base method.
 func (m *MockInfrastructure) Validator() apperrmap.Validator {
 	m.ctrl.T.Helper()
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	dto "github.com/diegoclair/go_boilerplate/internal/application/dto"
 	contract "github.com/diegoclair/go_boilerplate/internal/domain/contract"
@@ -71,6 +72,62 @@ func (mr *MockReposMockRecorder) Auth() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Auth", reflect.TypeOf((*MockRepos)(nil).Auth))
 }
 
+// Idempotency mocks base method.
+func (m *MockRepos) Idempotency() contract.IdempotencyRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Idempotency")
+	ret0, _ := ret[0].(contract.IdempotencyRepo)
+	return ret0
+}
+
+// Idempotency indicates an expected call of Idempotency.
+func (mr *MockReposMockRecorder) Idempotency() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Idempotency", reflect.TypeOf((*MockRepos)(nil).Idempotency))
+}
+
+// Ledger mocks base method.
+func (m *MockRepos) Ledger() contract.LedgerRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ledger")
+	ret0, _ := ret[0].(contract.LedgerRepo)
+	return ret0
+}
+
+// Ledger indicates an expected call of Ledger.
+func (mr *MockReposMockRecorder) Ledger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ledger", reflect.TypeOf((*MockRepos)(nil).Ledger))
+}
+
+// RecurringTransfer mocks base method.
+func (m *MockRepos) RecurringTransfer() contract.RecurringTransferRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecurringTransfer")
+	ret0, _ := ret[0].(contract.RecurringTransferRepo)
+	return ret0
+}
+
+// RecurringTransfer indicates an expected call of RecurringTransfer.
+func (mr *MockReposMockRecorder) RecurringTransfer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringTransfer", reflect.TypeOf((*MockRepos)(nil).RecurringTransfer))
+}
+
+// ScheduledTransfer mocks base method.
+func (m *MockRepos) ScheduledTransfer() contract.ScheduledTransferRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduledTransfer")
+	ret0, _ := ret[0].(contract.ScheduledTransferRepo)
+	return ret0
+}
+
+// ScheduledTransfer indicates an expected call of ScheduledTransfer.
+func (mr *MockReposMockRecorder) ScheduledTransfer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduledTransfer", reflect.TypeOf((*MockRepos)(nil).ScheduledTransfer))
+}
+
 // MockDataManager is a mock of DataManager interface.
 type MockDataManager struct {
 	ctrl     *gomock.Controller
@@ -123,6 +180,62 @@ func (mr *MockDataManagerMockRecorder) Auth() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Auth", reflect.TypeOf((*MockDataManager)(nil).Auth))
 }
 
+// Idempotency mocks base method.
+func (m *MockDataManager) Idempotency() contract.IdempotencyRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Idempotency")
+	ret0, _ := ret[0].(contract.IdempotencyRepo)
+	return ret0
+}
+
+// Idempotency indicates an expected call of Idempotency.
+func (mr *MockDataManagerMockRecorder) Idempotency() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Idempotency", reflect.TypeOf((*MockDataManager)(nil).Idempotency))
+}
+
+// Ledger mocks base method.
+func (m *MockDataManager) Ledger() contract.LedgerRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ledger")
+	ret0, _ := ret[0].(contract.LedgerRepo)
+	return ret0
+}
+
+// Ledger indicates an expected call of Ledger.
+func (mr *MockDataManagerMockRecorder) Ledger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ledger", reflect.TypeOf((*MockDataManager)(nil).Ledger))
+}
+
+// RecurringTransfer mocks base method.
+func (m *MockDataManager) RecurringTransfer() contract.RecurringTransferRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecurringTransfer")
+	ret0, _ := ret[0].(contract.RecurringTransferRepo)
+	return ret0
+}
+
+// RecurringTransfer indicates an expected call of RecurringTransfer.
+func (mr *MockDataManagerMockRecorder) RecurringTransfer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecurringTransfer", reflect.TypeOf((*MockDataManager)(nil).RecurringTransfer))
+}
+
+// ScheduledTransfer mocks base method.
+func (m *MockDataManager) ScheduledTransfer() contract.ScheduledTransferRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduledTransfer")
+	ret0, _ := ret[0].(contract.ScheduledTransferRepo)
+	return ret0
+}
+
+// ScheduledTransfer indicates an expected call of ScheduledTransfer.
+func (mr *MockDataManagerMockRecorder) ScheduledTransfer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduledTransfer", reflect.TypeOf((*MockDataManager)(nil).ScheduledTransfer))
+}
+
 // WithTransaction mocks base method.
 func (m *MockDataManager) WithTransaction(ctx context.Context, fn func(contract.Repos) error) error {
 	m.ctrl.T.Helper()
@@ -161,6 +274,80 @@ func (m *MockAuthRepo) EXPECT() *MockAuthRepoMockRecorder {
 	return m.recorder
 }
 
+// AddMFARecoveryCodes mocks base method.
+func (m *MockAuthRepo) AddMFARecoveryCodes(ctx context.Context, accountID int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMFARecoveryCodes", ctx, accountID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMFARecoveryCodes indicates an expected call of AddMFARecoveryCodes.
+func (mr *MockAuthRepoMockRecorder) AddMFARecoveryCodes(ctx, accountID, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMFARecoveryCodes", reflect.TypeOf((*MockAuthRepo)(nil).AddMFARecoveryCodes), ctx, accountID, codeHashes)
+}
+
+// AddSecurityEvent mocks base method.
+func (m *MockAuthRepo) AddSecurityEvent(ctx context.Context, event entity.SecurityEvent) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSecurityEvent", ctx, event)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSecurityEvent indicates an expected call of AddSecurityEvent.
+func (mr *MockAuthRepoMockRecorder) AddSecurityEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSecurityEvent", reflect.TypeOf((*MockAuthRepo)(nil).AddSecurityEvent), ctx, event)
+}
+
+// ConfirmAccountMFA mocks base method.
+func (m *MockAuthRepo) ConfirmAccountMFA(ctx context.Context, accountID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmAccountMFA", ctx, accountID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmAccountMFA indicates an expected call of ConfirmAccountMFA.
+func (mr *MockAuthRepoMockRecorder) ConfirmAccountMFA(ctx, accountID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmAccountMFA", reflect.TypeOf((*MockAuthRepo)(nil).ConfirmAccountMFA), ctx, accountID, step)
+}
+
+// ConsumePasswordReset mocks base method.
+func (m *MockAuthRepo) ConsumePasswordReset(ctx context.Context, tokenHash string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePasswordReset", ctx, tokenHash)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePasswordReset indicates an expected call of ConsumePasswordReset.
+func (mr *MockAuthRepoMockRecorder) ConsumePasswordReset(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePasswordReset", reflect.TypeOf((*MockAuthRepo)(nil).ConsumePasswordReset), ctx, tokenHash)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockAuthRepo) CreatePasswordReset(ctx context.Context, reset entity.PasswordReset) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", ctx, reset)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockAuthRepoMockRecorder) CreatePasswordReset(ctx, reset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockAuthRepo)(nil).CreatePasswordReset), ctx, reset)
+}
+
 // CreateSession mocks base method.
 func (m *MockAuthRepo) CreateSession(ctx context.Context, session dto.Session) (int64, error) {
 	m.ctrl.T.Helper()
@@ -176,6 +363,36 @@ func (mr *MockAuthRepoMockRecorder) CreateSession(ctx, session any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockAuthRepo)(nil).CreateSession), ctx, session)
 }
 
+// GetAccountMFA mocks base method.
+func (m *MockAuthRepo) GetAccountMFA(ctx context.Context, accountID int64) (entity.AccountMFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountMFA", ctx, accountID)
+	ret0, _ := ret[0].(entity.AccountMFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountMFA indicates an expected call of GetAccountMFA.
+func (mr *MockAuthRepoMockRecorder) GetAccountMFA(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountMFA", reflect.TypeOf((*MockAuthRepo)(nil).GetAccountMFA), ctx, accountID)
+}
+
+// GetActiveSessionsByAccountID mocks base method.
+func (m *MockAuthRepo) GetActiveSessionsByAccountID(ctx context.Context, accountID int64) ([]dto.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveSessionsByAccountID", ctx, accountID)
+	ret0, _ := ret[0].([]dto.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveSessionsByAccountID indicates an expected call of GetActiveSessionsByAccountID.
+func (mr *MockAuthRepoMockRecorder) GetActiveSessionsByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveSessionsByAccountID", reflect.TypeOf((*MockAuthRepo)(nil).GetActiveSessionsByAccountID), ctx, accountID)
+}
+
 // GetSessionByUUID mocks base method.
 func (m *MockAuthRepo) GetSessionByUUID(ctx context.Context, sessionUUID string) (dto.Session, error) {
 	m.ctrl.T.Helper()
@@ -191,6 +408,51 @@ func (mr *MockAuthRepoMockRecorder) GetSessionByUUID(ctx, sessionUUID any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByUUID", reflect.TypeOf((*MockAuthRepo)(nil).GetSessionByUUID), ctx, sessionUUID)
 }
 
+// IsRotatedRefreshToken mocks base method.
+func (m *MockAuthRepo) IsRotatedRefreshToken(ctx context.Context, sessionUUID, refreshTokenHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRotatedRefreshToken", ctx, sessionUUID, refreshTokenHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRotatedRefreshToken indicates an expected call of IsRotatedRefreshToken.
+func (mr *MockAuthRepoMockRecorder) IsRotatedRefreshToken(ctx, sessionUUID, refreshTokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRotatedRefreshToken", reflect.TypeOf((*MockAuthRepo)(nil).IsRotatedRefreshToken), ctx, sessionUUID, refreshTokenHash)
+}
+
+// RotateSessionRefreshToken mocks base method.
+func (m *MockAuthRepo) RotateSessionRefreshToken(ctx context.Context, rotation dto.RefreshTokenRotation) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSessionRefreshToken", ctx, rotation)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSessionRefreshToken indicates an expected call of RotateSessionRefreshToken.
+func (mr *MockAuthRepoMockRecorder) RotateSessionRefreshToken(ctx, rotation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionRefreshToken", reflect.TypeOf((*MockAuthRepo)(nil).RotateSessionRefreshToken), ctx, rotation)
+}
+
+// SetPendingAccountMFA mocks base method.
+func (m *MockAuthRepo) SetPendingAccountMFA(ctx context.Context, accountID int64, secret string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingAccountMFA", ctx, accountID, secret)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPendingAccountMFA indicates an expected call of SetPendingAccountMFA.
+func (mr *MockAuthRepoMockRecorder) SetPendingAccountMFA(ctx, accountID, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingAccountMFA", reflect.TypeOf((*MockAuthRepo)(nil).SetPendingAccountMFA), ctx, accountID, secret)
+}
+
 // SetSessionAsBlocked mocks base method.
 func (m *MockAuthRepo) SetSessionAsBlocked(ctx context.Context, sessionUUID string) error {
 	m.ctrl.T.Helper()
@@ -205,6 +467,65 @@ func (mr *MockAuthRepoMockRecorder) SetSessionAsBlocked(ctx, sessionUUID any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSessionAsBlocked", reflect.TypeOf((*MockAuthRepo)(nil).SetSessionAsBlocked), ctx, sessionUUID)
 }
 
+// SetSessionsAsBlockedByAccountID mocks base method.
+func (m *MockAuthRepo) SetSessionsAsBlockedByAccountID(ctx context.Context, accountID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSessionsAsBlockedByAccountID", ctx, accountID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSessionsAsBlockedByAccountID indicates an expected call of SetSessionsAsBlockedByAccountID.
+func (mr *MockAuthRepoMockRecorder) SetSessionsAsBlockedByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSessionsAsBlockedByAccountID", reflect.TypeOf((*MockAuthRepo)(nil).SetSessionsAsBlockedByAccountID), ctx, accountID)
+}
+
+// UseAccountMFAStep mocks base method.
+func (m *MockAuthRepo) UseAccountMFAStep(ctx context.Context, accountID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAccountMFAStep", ctx, accountID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseAccountMFAStep indicates an expected call of UseAccountMFAStep.
+func (mr *MockAuthRepoMockRecorder) UseAccountMFAStep(ctx, accountID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAccountMFAStep", reflect.TypeOf((*MockAuthRepo)(nil).UseAccountMFAStep), ctx, accountID, step)
+}
+
+// UseMFARecoveryCode mocks base method.
+func (m *MockAuthRepo) UseMFARecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFARecoveryCode", ctx, accountID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseMFARecoveryCode indicates an expected call of UseMFARecoveryCode.
+func (mr *MockAuthRepoMockRecorder) UseMFARecoveryCode(ctx, accountID, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFARecoveryCode", reflect.TypeOf((*MockAuthRepo)(nil).UseMFARecoveryCode), ctx, accountID, codeHash)
+}
+
+// VoidPasswordResets mocks base method.
+func (m *MockAuthRepo) VoidPasswordResets(ctx context.Context, accountID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidPasswordResets", ctx, accountID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VoidPasswordResets indicates an expected call of VoidPasswordResets.
+func (mr *MockAuthRepoMockRecorder) VoidPasswordResets(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidPasswordResets", reflect.TypeOf((*MockAuthRepo)(nil).VoidPasswordResets), ctx, accountID)
+}
+
 // MockAccountRepo is a mock of AccountRepo interface.
 type MockAccountRepo struct {
 	ctrl     *gomock.Controller
@@ -229,19 +550,34 @@ func (m *MockAccountRepo) EXPECT() *MockAccountRepoMockRecorder {
 	return m.recorder
 }
 
+// AddAccountStatusChange mocks base method.
+func (m *MockAccountRepo) AddAccountStatusChange(ctx context.Context, change entity.AccountStatusChange) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountStatusChange", ctx, change)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountStatusChange indicates an expected call of AddAccountStatusChange.
+func (mr *MockAccountRepoMockRecorder) AddAccountStatusChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountStatusChange", reflect.TypeOf((*MockAccountRepo)(nil).AddAccountStatusChange), ctx, change)
+}
+
 // AddTransfer mocks base method.
-func (m *MockAccountRepo) AddTransfer(ctx context.Context, transferUUID string, accountOriginID, accountDestinationID int64, amount float64) (int64, error) {
+func (m *MockAccountRepo) AddTransfer(ctx context.Context, transfer entity.Transfer) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddTransfer", ctx, transferUUID, accountOriginID, accountDestinationID, amount)
+	ret := m.ctrl.Call(m, "AddTransfer", ctx, transfer)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddTransfer indicates an expected call of AddTransfer.
-func (mr *MockAccountRepoMockRecorder) AddTransfer(ctx, transferUUID, accountOriginID, accountDestinationID, amount any) *gomock.Call {
+func (mr *MockAccountRepoMockRecorder) AddTransfer(ctx, transfer any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransfer", reflect.TypeOf((*MockAccountRepo)(nil).AddTransfer), ctx, transferUUID, accountOriginID, accountDestinationID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransfer", reflect.TypeOf((*MockAccountRepo)(nil).AddTransfer), ctx, transfer)
 }
 
 // CreateAccount mocks base method.
@@ -259,19 +595,48 @@ func (mr *MockAccountRepoMockRecorder) CreateAccount(ctx, account any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepo)(nil).CreateAccount), ctx, account)
 }
 
+// CreditAccountBalance mocks base method.
+func (m *MockAccountRepo) CreditAccountBalance(ctx context.Context, entry entity.LedgerEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreditAccountBalance", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreditAccountBalance indicates an expected call of CreditAccountBalance.
+func (mr *MockAccountRepoMockRecorder) CreditAccountBalance(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreditAccountBalance", reflect.TypeOf((*MockAccountRepo)(nil).CreditAccountBalance), ctx, entry)
+}
+
+// DebitAccountBalance mocks base method.
+func (m *MockAccountRepo) DebitAccountBalance(ctx context.Context, entry entity.LedgerEntry) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebitAccountBalance", ctx, entry)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebitAccountBalance indicates an expected call of DebitAccountBalance.
+func (mr *MockAccountRepoMockRecorder) DebitAccountBalance(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebitAccountBalance", reflect.TypeOf((*MockAccountRepo)(nil).DebitAccountBalance), ctx, entry)
+}
+
 // GetAccountByDocument mocks base method.
-func (m *MockAccountRepo) GetAccountByDocument(ctx context.Context, encryptedCPF string) (entity.Account, error) {
+func (m *MockAccountRepo) GetAccountByDocument(ctx context.Context, cpfIndex, legacyCPF string) (entity.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountByDocument", ctx, encryptedCPF)
+	ret := m.ctrl.Call(m, "GetAccountByDocument", ctx, cpfIndex, legacyCPF)
 	ret0, _ := ret[0].(entity.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAccountByDocument indicates an expected call of GetAccountByDocument.
-func (mr *MockAccountRepoMockRecorder) GetAccountByDocument(ctx, encryptedCPF any) *gomock.Call {
+func (mr *MockAccountRepoMockRecorder) GetAccountByDocument(ctx, cpfIndex, legacyCPF any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByDocument", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountByDocument), ctx, encryptedCPF)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByDocument", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountByDocument), ctx, cpfIndex, legacyCPF)
 }
 
 // GetAccountByUUID mocks base method.
@@ -304,10 +669,25 @@ func (mr *MockAccountRepoMockRecorder) GetAccountIDByUUID(ctx, accountUUID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountIDByUUID", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountIDByUUID), ctx, accountUUID)
 }
 
+// GetAccountLimit mocks base method.
+func (m *MockAccountRepo) GetAccountLimit(ctx context.Context, accountID int64) (entity.AccountLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountLimit", ctx, accountID)
+	ret0, _ := ret[0].(entity.AccountLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountLimit indicates an expected call of GetAccountLimit.
+func (mr *MockAccountRepoMockRecorder) GetAccountLimit(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountLimit", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountLimit), ctx, accountID)
+}
+
 // GetAccounts mocks base method.
-func (m *MockAccountRepo) GetAccounts(ctx context.Context, take, skip int64) ([]entity.Account, int64, error) {
+func (m *MockAccountRepo) GetAccounts(ctx context.Context, filter entity.AccountFilter, take, skip int64) ([]entity.Account, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccounts", ctx, take, skip)
+	ret := m.ctrl.Call(m, "GetAccounts", ctx, filter, take, skip)
 	ret0, _ := ret[0].([]entity.Account)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -315,37 +695,641 @@ func (m *MockAccountRepo) GetAccounts(ctx context.Context, take, skip int64) ([]
 }
 
 // GetAccounts indicates an expected call of GetAccounts.
-func (mr *MockAccountRepoMockRecorder) GetAccounts(ctx, take, skip any) *gomock.Call {
+func (mr *MockAccountRepoMockRecorder) GetAccounts(ctx, filter, take, skip any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockAccountRepo)(nil).GetAccounts), ctx, take, skip)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockAccountRepo)(nil).GetAccounts), ctx, filter, take, skip)
 }
 
-// GetTransfersByAccountID mocks base method.
-func (m *MockAccountRepo) GetTransfersByAccountID(ctx context.Context, accountID, take, skip int64, origin bool) ([]entity.Transfer, int64, error) {
+// GetAccountsAfterID mocks base method.
+func (m *MockAccountRepo) GetAccountsAfterID(ctx context.Context, afterID, take int64) ([]entity.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfersByAccountID", ctx, accountID, take, skip, origin)
-	ret0, _ := ret[0].([]entity.Transfer)
-	ret1, _ := ret[1].(int64)
+	ret := m.ctrl.Call(m, "GetAccountsAfterID", ctx, afterID, take)
+	ret0, _ := ret[0].([]entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountsAfterID indicates an expected call of GetAccountsAfterID.
+func (mr *MockAccountRepoMockRecorder) GetAccountsAfterID(ctx, afterID, take any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsAfterID", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountsAfterID), ctx, afterID, take)
+}
+
+// GetAccountsByCursor mocks base method.
+func (m *MockAccountRepo) GetAccountsByCursor(ctx context.Context, filter entity.AccountFilter, page entity.CursorPage) ([]entity.Account, entity.CursorResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsByCursor", ctx, filter, page)
+	ret0, _ := ret[0].([]entity.Account)
+	ret1, _ := ret[1].(entity.CursorResult)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
-// GetTransfersByAccountID indicates an expected call of GetTransfersByAccountID.
-func (mr *MockAccountRepoMockRecorder) GetTransfersByAccountID(ctx, accountID, take, skip, origin any) *gomock.Call {
+// GetAccountsByCursor indicates an expected call of GetAccountsByCursor.
+func (mr *MockAccountRepoMockRecorder) GetAccountsByCursor(ctx, filter, page any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersByAccountID", reflect.TypeOf((*MockAccountRepo)(nil).GetTransfersByAccountID), ctx, accountID, take, skip, origin)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsByCursor", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountsByCursor), ctx, filter, page)
 }
 
-// UpdateAccountBalance mocks base method.
-func (m *MockAccountRepo) UpdateAccountBalance(ctx context.Context, accountID int64, balance float64) error {
+// GetAccountsByIDForUpdate mocks base method.
+func (m *MockAccountRepo) GetAccountsByIDForUpdate(ctx context.Context, accountIDs []int64) ([]entity.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccountBalance", ctx, accountID, balance)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetAccountsByIDForUpdate", ctx, accountIDs)
+	ret0, _ := ret[0].([]entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountsByIDForUpdate indicates an expected call of GetAccountsByIDForUpdate.
+func (mr *MockAccountRepoMockRecorder) GetAccountsByIDForUpdate(ctx, accountIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsByIDForUpdate", reflect.TypeOf((*MockAccountRepo)(nil).GetAccountsByIDForUpdate), ctx, accountIDs)
+}
+
+// GetReversedAmount mocks base method.
+func (m *MockAccountRepo) GetReversedAmount(ctx context.Context, transferID int64) (entity.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversedAmount", ctx, transferID)
+	ret0, _ := ret[0].(entity.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversedAmount indicates an expected call of GetReversedAmount.
+func (mr *MockAccountRepoMockRecorder) GetReversedAmount(ctx, transferID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversedAmount", reflect.TypeOf((*MockAccountRepo)(nil).GetReversedAmount), ctx, transferID)
+}
+
+// GetTransferByUUID mocks base method.
+func (m *MockAccountRepo) GetTransferByUUID(ctx context.Context, transferUUID string) (entity.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferByUUID", ctx, transferUUID)
+	ret0, _ := ret[0].(entity.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferByUUID indicates an expected call of GetTransferByUUID.
+func (mr *MockAccountRepoMockRecorder) GetTransferByUUID(ctx, transferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByUUID", reflect.TypeOf((*MockAccountRepo)(nil).GetTransferByUUID), ctx, transferUUID)
+}
+
+// GetTransferByUUIDForUpdate mocks base method.
+func (m *MockAccountRepo) GetTransferByUUIDForUpdate(ctx context.Context, transferUUID string) (entity.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferByUUIDForUpdate", ctx, transferUUID)
+	ret0, _ := ret[0].(entity.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferByUUIDForUpdate indicates an expected call of GetTransferByUUIDForUpdate.
+func (mr *MockAccountRepoMockRecorder) GetTransferByUUIDForUpdate(ctx, transferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByUUIDForUpdate", reflect.TypeOf((*MockAccountRepo)(nil).GetTransferByUUIDForUpdate), ctx, transferUUID)
+}
+
+// GetTransferUsage mocks base method.
+func (m *MockAccountRepo) GetTransferUsage(ctx context.Context, accountID int64, currency entity.Currency, periods entity.TransferPeriods) (entity.TransferUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferUsage", ctx, accountID, currency, periods)
+	ret0, _ := ret[0].(entity.TransferUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferUsage indicates an expected call of GetTransferUsage.
+func (mr *MockAccountRepoMockRecorder) GetTransferUsage(ctx, accountID, currency, periods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferUsage", reflect.TypeOf((*MockAccountRepo)(nil).GetTransferUsage), ctx, accountID, currency, periods)
+}
+
+// GetTransfersByAccountID mocks base method.
+func (m *MockAccountRepo) GetTransfersByAccountID(ctx context.Context, accountID int64, filter entity.TransferFilter, take, skip int64) ([]entity.Transfer, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfersByAccountID", ctx, accountID, filter, take, skip)
+	ret0, _ := ret[0].([]entity.Transfer)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransfersByAccountID indicates an expected call of GetTransfersByAccountID.
+func (mr *MockAccountRepoMockRecorder) GetTransfersByAccountID(ctx, accountID, filter, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersByAccountID", reflect.TypeOf((*MockAccountRepo)(nil).GetTransfersByAccountID), ctx, accountID, filter, take, skip)
+}
+
+// GetTransfersByAccountIDCursor mocks base method.
+func (m *MockAccountRepo) GetTransfersByAccountIDCursor(ctx context.Context, accountID int64, filter entity.TransferFilter, page entity.CursorPage) ([]entity.Transfer, entity.CursorResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfersByAccountIDCursor", ctx, accountID, filter, page)
+	ret0, _ := ret[0].([]entity.Transfer)
+	ret1, _ := ret[1].(entity.CursorResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransfersByAccountIDCursor indicates an expected call of GetTransfersByAccountIDCursor.
+func (mr *MockAccountRepoMockRecorder) GetTransfersByAccountIDCursor(ctx, accountID, filter, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersByAccountIDCursor", reflect.TypeOf((*MockAccountRepo)(nil).GetTransfersByAccountIDCursor), ctx, accountID, filter, page)
+}
+
+// UpdateAccountCPF mocks base method.
+func (m *MockAccountRepo) UpdateAccountCPF(ctx context.Context, account entity.Account, fromCPF string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountCPF", ctx, account, fromCPF)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountCPF indicates an expected call of UpdateAccountCPF.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountCPF(ctx, account, fromCPF any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountCPF", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountCPF), ctx, account, fromCPF)
+}
+
+// UpdateAccountPassword mocks base method.
+func (m *MockAccountRepo) UpdateAccountPassword(ctx context.Context, accountID int64, hashedPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountPassword", ctx, accountID, hashedPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountPassword indicates an expected call of UpdateAccountPassword.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountPassword(ctx, accountID, hashedPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountPassword", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountPassword), ctx, accountID, hashedPassword)
+}
+
+// UpdateAccountProfile mocks base method.
+func (m *MockAccountRepo) UpdateAccountProfile(ctx context.Context, account entity.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountProfile", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountProfile indicates an expected call of UpdateAccountProfile.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountProfile(ctx, account any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountProfile", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountProfile), ctx, account)
+}
+
+// UpdateAccountRole mocks base method.
+func (m *MockAccountRepo) UpdateAccountRole(ctx context.Context, accountID int64, role entity.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountRole", ctx, accountID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountRole indicates an expected call of UpdateAccountRole.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountRole(ctx, accountID, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountRole", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountRole), ctx, accountID, role)
+}
+
+// UpdateAccountStatus mocks base method.
+func (m *MockAccountRepo) UpdateAccountStatus(ctx context.Context, account entity.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountStatus(ctx, account any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountStatus), ctx, account)
+}
+
+// UpdateTransferStatus mocks base method.
+func (m *MockAccountRepo) UpdateTransferStatus(ctx context.Context, transfer entity.Transfer, from entity.TransferStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransferStatus", ctx, transfer, from)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransferStatus indicates an expected call of UpdateTransferStatus.
+func (mr *MockAccountRepoMockRecorder) UpdateTransferStatus(ctx, transfer, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransferStatus", reflect.TypeOf((*MockAccountRepo)(nil).UpdateTransferStatus), ctx, transfer, from)
+}
+
+// MockLedgerRepo is a mock of LedgerRepo interface.
+type MockLedgerRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockLedgerRepoMockRecorder is the mock recorder for MockLedgerRepo.
+type MockLedgerRepoMockRecorder struct {
+	mock *MockLedgerRepo
+}
+
+// NewMockLedgerRepo creates a new mock instance.
+func NewMockLedgerRepo(ctrl *gomock.Controller) *MockLedgerRepo {
+	mock := &MockLedgerRepo{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerRepo) EXPECT() *MockLedgerRepoMockRecorder {
+	return m.recorder
+}
+
+// RebuildAccountBalances mocks base method.
+func (m *MockLedgerRepo) RebuildAccountBalances(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildAccountBalances", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildAccountBalances indicates an expected call of RebuildAccountBalances.
+func (mr *MockLedgerRepoMockRecorder) RebuildAccountBalances(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildAccountBalances", reflect.TypeOf((*MockLedgerRepo)(nil).RebuildAccountBalances), ctx)
+}
+
+// MockIdempotencyRepo is a mock of IdempotencyRepo interface.
+type MockIdempotencyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyRepoMockRecorder is the mock recorder for MockIdempotencyRepo.
+type MockIdempotencyRepoMockRecorder struct {
+	mock *MockIdempotencyRepo
+}
+
+// NewMockIdempotencyRepo creates a new mock instance.
+func NewMockIdempotencyRepo(ctrl *gomock.Controller) *MockIdempotencyRepo {
+	mock := &MockIdempotencyRepo{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepo) EXPECT() *MockIdempotencyRepoMockRecorder {
+	return m.recorder
+}
+
+// ClaimIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepo) ClaimIdempotencyKey(ctx context.Context, request entity.IdempotentRequest) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimIdempotencyKey", ctx, request)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimIdempotencyKey indicates an expected call of ClaimIdempotencyKey.
+func (mr *MockIdempotencyRepoMockRecorder) ClaimIdempotencyKey(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepo)(nil).ClaimIdempotencyKey), ctx, request)
+}
+
+// CompleteIdempotentRequest mocks base method.
+func (m *MockIdempotencyRepo) CompleteIdempotentRequest(ctx context.Context, request entity.IdempotentRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotentRequest", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotentRequest indicates an expected call of CompleteIdempotentRequest.
+func (mr *MockIdempotencyRepoMockRecorder) CompleteIdempotentRequest(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotentRequest", reflect.TypeOf((*MockIdempotencyRepo)(nil).CompleteIdempotentRequest), ctx, request)
+}
+
+// GetIdempotentRequest mocks base method.
+func (m *MockIdempotencyRepo) GetIdempotentRequest(ctx context.Context, scope, key string) (entity.IdempotentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotentRequest", ctx, scope, key)
+	ret0, _ := ret[0].(entity.IdempotentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotentRequest indicates an expected call of GetIdempotentRequest.
+func (mr *MockIdempotencyRepoMockRecorder) GetIdempotentRequest(ctx, scope, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotentRequest", reflect.TypeOf((*MockIdempotencyRepo)(nil).GetIdempotentRequest), ctx, scope, key)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, scope, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockIdempotencyRepoMockRecorder) ReleaseIdempotencyKey(ctx, scope, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepo)(nil).ReleaseIdempotencyKey), ctx, scope, key)
+}
+
+// MockRecurringTransferRepo is a mock of RecurringTransferRepo interface.
+type MockRecurringTransferRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecurringTransferRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockRecurringTransferRepoMockRecorder is the mock recorder for MockRecurringTransferRepo.
+type MockRecurringTransferRepoMockRecorder struct {
+	mock *MockRecurringTransferRepo
+}
+
+// NewMockRecurringTransferRepo creates a new mock instance.
+func NewMockRecurringTransferRepo(ctrl *gomock.Controller) *MockRecurringTransferRepo {
+	mock := &MockRecurringTransferRepo{ctrl: ctrl}
+	mock.recorder = &MockRecurringTransferRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecurringTransferRepo) EXPECT() *MockRecurringTransferRepoMockRecorder {
+	return m.recorder
+}
+
+// AddRecurringTransfer mocks base method.
+func (m *MockRecurringTransferRepo) AddRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecurringTransfer", ctx, recurring)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRecurringTransfer indicates an expected call of AddRecurringTransfer.
+func (mr *MockRecurringTransferRepoMockRecorder) AddRecurringTransfer(ctx, recurring any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecurringTransfer", reflect.TypeOf((*MockRecurringTransferRepo)(nil).AddRecurringTransfer), ctx, recurring)
+}
+
+// CancelRecurringTransfersByAccountID mocks base method.
+func (m *MockRecurringTransferRepo) CancelRecurringTransfersByAccountID(ctx context.Context, accountID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelRecurringTransfersByAccountID", ctx, accountID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelRecurringTransfersByAccountID indicates an expected call of CancelRecurringTransfersByAccountID.
+func (mr *MockRecurringTransferRepoMockRecorder) CancelRecurringTransfersByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRecurringTransfersByAccountID", reflect.TypeOf((*MockRecurringTransferRepo)(nil).CancelRecurringTransfersByAccountID), ctx, accountID)
+}
+
+// GetRecurringTransferByUUID mocks base method.
+func (m *MockRecurringTransferRepo) GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurringTransferByUUID", ctx, recurringTransferUUID)
+	ret0, _ := ret[0].(entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecurringTransferByUUID indicates an expected call of GetRecurringTransferByUUID.
+func (mr *MockRecurringTransferRepoMockRecorder) GetRecurringTransferByUUID(ctx, recurringTransferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurringTransferByUUID", reflect.TypeOf((*MockRecurringTransferRepo)(nil).GetRecurringTransferByUUID), ctx, recurringTransferUUID)
+}
+
+// GetRecurringTransfersByAccountID mocks base method.
+func (m *MockRecurringTransferRepo) GetRecurringTransfersByAccountID(ctx context.Context, accountID, take, skip int64) ([]entity.RecurringTransfer, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurringTransfersByAccountID", ctx, accountID, take, skip)
+	ret0, _ := ret[0].([]entity.RecurringTransfer)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRecurringTransfersByAccountID indicates an expected call of GetRecurringTransfersByAccountID.
+func (mr *MockRecurringTransferRepoMockRecorder) GetRecurringTransfersByAccountID(ctx, accountID, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurringTransfersByAccountID", reflect.TypeOf((*MockRecurringTransferRepo)(nil).GetRecurringTransfersByAccountID), ctx, accountID, take, skip)
+}
+
+// LockDueRecurringTransfers mocks base method.
+func (m *MockRecurringTransferRepo) LockDueRecurringTransfers(ctx context.Context, limit int64) ([]entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockDueRecurringTransfers", ctx, limit)
+	ret0, _ := ret[0].([]entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockDueRecurringTransfers indicates an expected call of LockDueRecurringTransfers.
+func (mr *MockRecurringTransferRepoMockRecorder) LockDueRecurringTransfers(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockDueRecurringTransfers", reflect.TypeOf((*MockRecurringTransferRepo)(nil).LockDueRecurringTransfers), ctx, limit)
+}
+
+// LockRecurringTransfer mocks base method.
+func (m *MockRecurringTransferRepo) LockRecurringTransfer(ctx context.Context, recurringID int64) (entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRecurringTransfer", ctx, recurringID)
+	ret0, _ := ret[0].(entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockRecurringTransfer indicates an expected call of LockRecurringTransfer.
+func (mr *MockRecurringTransferRepoMockRecorder) LockRecurringTransfer(ctx, recurringID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRecurringTransfer", reflect.TypeOf((*MockRecurringTransferRepo)(nil).LockRecurringTransfer), ctx, recurringID)
+}
+
+// UpdateRecurringTransfer mocks base method.
+func (m *MockRecurringTransferRepo) UpdateRecurringTransfer(ctx context.Context, recurring entity.RecurringTransfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecurringTransfer", ctx, recurring)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecurringTransfer indicates an expected call of UpdateRecurringTransfer.
+func (mr *MockRecurringTransferRepoMockRecorder) UpdateRecurringTransfer(ctx, recurring any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecurringTransfer", reflect.TypeOf((*MockRecurringTransferRepo)(nil).UpdateRecurringTransfer), ctx, recurring)
+}
+
+// MockScheduledTransferRepo is a mock of ScheduledTransferRepo interface.
+type MockScheduledTransferRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTransferRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockScheduledTransferRepoMockRecorder is the mock recorder for MockScheduledTransferRepo.
+type MockScheduledTransferRepoMockRecorder struct {
+	mock *MockScheduledTransferRepo
+}
+
+// NewMockScheduledTransferRepo creates a new mock instance.
+func NewMockScheduledTransferRepo(ctrl *gomock.Controller) *MockScheduledTransferRepo {
+	mock := &MockScheduledTransferRepo{ctrl: ctrl}
+	mock.recorder = &MockScheduledTransferRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTransferRepo) EXPECT() *MockScheduledTransferRepoMockRecorder {
+	return m.recorder
+}
+
+// AddScheduledTransfer mocks base method.
+func (m *MockScheduledTransferRepo) AddScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddScheduledTransfer", ctx, scheduled)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddScheduledTransfer indicates an expected call of AddScheduledTransfer.
+func (mr *MockScheduledTransferRepoMockRecorder) AddScheduledTransfer(ctx, scheduled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddScheduledTransfer", reflect.TypeOf((*MockScheduledTransferRepo)(nil).AddScheduledTransfer), ctx, scheduled)
+}
+
+// CancelScheduledTransfer mocks base method.
+func (m *MockScheduledTransferRepo) CancelScheduledTransfer(ctx context.Context, scheduledID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", ctx, scheduledID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockScheduledTransferRepoMockRecorder) CancelScheduledTransfer(ctx, scheduledID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockScheduledTransferRepo)(nil).CancelScheduledTransfer), ctx, scheduledID)
+}
+
+// CancelScheduledTransfersByAccountID mocks base method.
+func (m *MockScheduledTransferRepo) CancelScheduledTransfersByAccountID(ctx context.Context, accountID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfersByAccountID", ctx, accountID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfersByAccountID indicates an expected call of CancelScheduledTransfersByAccountID.
+func (mr *MockScheduledTransferRepoMockRecorder) CancelScheduledTransfersByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfersByAccountID", reflect.TypeOf((*MockScheduledTransferRepo)(nil).CancelScheduledTransfersByAccountID), ctx, accountID)
+}
+
+// CancelScheduledTransfersByRecurringID mocks base method.
+func (m *MockScheduledTransferRepo) CancelScheduledTransfersByRecurringID(ctx context.Context, recurringID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfersByRecurringID", ctx, recurringID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfersByRecurringID indicates an expected call of CancelScheduledTransfersByRecurringID.
+func (mr *MockScheduledTransferRepoMockRecorder) CancelScheduledTransfersByRecurringID(ctx, recurringID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfersByRecurringID", reflect.TypeOf((*MockScheduledTransferRepo)(nil).CancelScheduledTransfersByRecurringID), ctx, recurringID)
+}
+
+// ClaimDueScheduledTransfers mocks base method.
+func (m *MockScheduledTransferRepo) ClaimDueScheduledTransfers(ctx context.Context, limit int64, staleClaimBefore time.Time) ([]entity.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueScheduledTransfers", ctx, limit, staleClaimBefore)
+	ret0, _ := ret[0].([]entity.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueScheduledTransfers indicates an expected call of ClaimDueScheduledTransfers.
+func (mr *MockScheduledTransferRepoMockRecorder) ClaimDueScheduledTransfers(ctx, limit, staleClaimBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueScheduledTransfers", reflect.TypeOf((*MockScheduledTransferRepo)(nil).ClaimDueScheduledTransfers), ctx, limit, staleClaimBefore)
+}
+
+// GetScheduledTransferByUUID mocks base method.
+func (m *MockScheduledTransferRepo) GetScheduledTransferByUUID(ctx context.Context, scheduledTransferUUID string) (entity.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransferByUUID", ctx, scheduledTransferUUID)
+	ret0, _ := ret[0].(entity.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransferByUUID indicates an expected call of GetScheduledTransferByUUID.
+func (mr *MockScheduledTransferRepoMockRecorder) GetScheduledTransferByUUID(ctx, scheduledTransferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransferByUUID", reflect.TypeOf((*MockScheduledTransferRepo)(nil).GetScheduledTransferByUUID), ctx, scheduledTransferUUID)
+}
+
+// GetScheduledTransfersByAccountID mocks base method.
+func (m *MockScheduledTransferRepo) GetScheduledTransfersByAccountID(ctx context.Context, accountID int64, status entity.ScheduledTransferStatus, take, skip int64) ([]entity.ScheduledTransfer, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfersByAccountID", ctx, accountID, status, take, skip)
+	ret0, _ := ret[0].([]entity.ScheduledTransfer)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetScheduledTransfersByAccountID indicates an expected call of GetScheduledTransfersByAccountID.
+func (mr *MockScheduledTransferRepoMockRecorder) GetScheduledTransfersByAccountID(ctx, accountID, status, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfersByAccountID", reflect.TypeOf((*MockScheduledTransferRepo)(nil).GetScheduledTransfersByAccountID), ctx, accountID, status, take, skip)
+}
+
+// RescheduleScheduledTransfer mocks base method.
+func (m *MockScheduledTransferRepo) RescheduleScheduledTransfer(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleScheduledTransfer", ctx, scheduled, from)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescheduleScheduledTransfer indicates an expected call of RescheduleScheduledTransfer.
+func (mr *MockScheduledTransferRepoMockRecorder) RescheduleScheduledTransfer(ctx, scheduled, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleScheduledTransfer", reflect.TypeOf((*MockScheduledTransferRepo)(nil).RescheduleScheduledTransfer), ctx, scheduled, from)
+}
+
+// UpdateScheduledTransferStatus mocks base method.
+func (m *MockScheduledTransferRepo) UpdateScheduledTransferStatus(ctx context.Context, scheduled entity.ScheduledTransfer, from entity.ScheduledTransferStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransferStatus", ctx, scheduled, from)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// UpdateAccountBalance indicates an expected call of UpdateAccountBalance.
-func (mr *MockAccountRepoMockRecorder) UpdateAccountBalance(ctx, accountID, balance any) *gomock.Call {
+// UpdateScheduledTransferStatus indicates an expected call of UpdateScheduledTransferStatus.
+func (mr *MockScheduledTransferRepoMockRecorder) UpdateScheduledTransferStatus(ctx, scheduled, from any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountBalance", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountBalance), ctx, accountID, balance)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransferStatus", reflect.TypeOf((*MockScheduledTransferRepo)(nil).UpdateScheduledTransferStatus), ctx, scheduled, from)
 }
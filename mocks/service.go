@@ -56,6 +56,48 @@ func (mr *MockAccountAppMockRecorder) AddBalance(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBalance", reflect.TypeOf((*MockAccountApp)(nil).AddBalance), ctx, input)
 }
 
+// ChangePassword mocks base method.
+func (m *MockAccountApp) ChangePassword(ctx context.Context, input dto.PasswordChangeInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAccountAppMockRecorder) ChangePassword(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAccountApp)(nil).ChangePassword), ctx, input)
+}
+
+// ChangeRole mocks base method.
+func (m *MockAccountApp) ChangeRole(ctx context.Context, input dto.RoleInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeRole", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeRole indicates an expected call of ChangeRole.
+func (mr *MockAccountAppMockRecorder) ChangeRole(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeRole", reflect.TypeOf((*MockAccountApp)(nil).ChangeRole), ctx, input)
+}
+
+// CloseAccount mocks base method.
+func (m *MockAccountApp) CloseAccount(ctx context.Context, input dto.CloseAccountInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAccount", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseAccount indicates an expected call of CloseAccount.
+func (mr *MockAccountAppMockRecorder) CloseAccount(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAccount", reflect.TypeOf((*MockAccountApp)(nil).CloseAccount), ctx, input)
+}
+
 // CreateAccount mocks base method.
 func (m *MockAccountApp) CreateAccount(ctx context.Context, input dto.AccountInput) error {
 	m.ctrl.T.Helper()
@@ -70,6 +112,20 @@ func (mr *MockAccountAppMockRecorder) CreateAccount(ctx, input any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountApp)(nil).CreateAccount), ctx, input)
 }
 
+// DeactivateAccount mocks base method.
+func (m *MockAccountApp) DeactivateAccount(ctx context.Context, input dto.AccountStatusInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateAccount", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateAccount indicates an expected call of DeactivateAccount.
+func (mr *MockAccountAppMockRecorder) DeactivateAccount(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateAccount", reflect.TypeOf((*MockAccountApp)(nil).DeactivateAccount), ctx, input)
+}
+
 // GetAccountByUUID mocks base method.
 func (m *MockAccountApp) GetAccountByUUID(ctx context.Context, accountUUID string) (entity.Account, error) {
 	m.ctrl.T.Helper()
@@ -86,9 +142,9 @@ func (mr *MockAccountAppMockRecorder) GetAccountByUUID(ctx, accountUUID any) *go
 }
 
 // GetAccounts mocks base method.
-func (m *MockAccountApp) GetAccounts(ctx context.Context, take, skip int64) ([]entity.Account, int64, error) {
+func (m *MockAccountApp) GetAccounts(ctx context.Context, input dto.AccountSearchInput, take, skip int64) ([]entity.Account, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccounts", ctx, take, skip)
+	ret := m.ctrl.Call(m, "GetAccounts", ctx, input, take, skip)
 	ret0, _ := ret[0].([]entity.Account)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -96,9 +152,25 @@ func (m *MockAccountApp) GetAccounts(ctx context.Context, take, skip int64) ([]e
 }
 
 // GetAccounts indicates an expected call of GetAccounts.
-func (mr *MockAccountAppMockRecorder) GetAccounts(ctx, take, skip any) *gomock.Call {
+func (mr *MockAccountAppMockRecorder) GetAccounts(ctx, input, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockAccountApp)(nil).GetAccounts), ctx, input, take, skip)
+}
+
+// GetAccountsByCursor mocks base method.
+func (m *MockAccountApp) GetAccountsByCursor(ctx context.Context, input dto.AccountSearchInput, page entity.CursorPage) ([]entity.Account, entity.CursorResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsByCursor", ctx, input, page)
+	ret0, _ := ret[0].([]entity.Account)
+	ret1, _ := ret[1].(entity.CursorResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAccountsByCursor indicates an expected call of GetAccountsByCursor.
+func (mr *MockAccountAppMockRecorder) GetAccountsByCursor(ctx, input, page any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccounts", reflect.TypeOf((*MockAccountApp)(nil).GetAccounts), ctx, take, skip)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsByCursor", reflect.TypeOf((*MockAccountApp)(nil).GetAccountsByCursor), ctx, input, page)
 }
 
 // GetLoggedAccount mocks base method.
@@ -131,6 +203,35 @@ func (mr *MockAccountAppMockRecorder) GetLoggedAccountID(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoggedAccountID", reflect.TypeOf((*MockAccountApp)(nil).GetLoggedAccountID), ctx)
 }
 
+// ReactivateAccount mocks base method.
+func (m *MockAccountApp) ReactivateAccount(ctx context.Context, input dto.AccountStatusInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateAccount", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReactivateAccount indicates an expected call of ReactivateAccount.
+func (mr *MockAccountAppMockRecorder) ReactivateAccount(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateAccount", reflect.TypeOf((*MockAccountApp)(nil).ReactivateAccount), ctx, input)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockAccountApp) UpdateProfile(ctx context.Context, input dto.ProfileInput) (entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, input)
+	ret0, _ := ret[0].(entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockAccountAppMockRecorder) UpdateProfile(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockAccountApp)(nil).UpdateProfile), ctx, input)
+}
+
 // MockAuthApp is a mock of AuthApp interface.
 type MockAuthApp struct {
 	ctrl     *gomock.Controller
@@ -155,6 +256,35 @@ func (m *MockAuthApp) EXPECT() *MockAuthAppMockRecorder {
 	return m.recorder
 }
 
+// ConfirmMFA mocks base method.
+func (m *MockAuthApp) ConfirmMFA(ctx context.Context, input dto.MFACodeInput) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMFA", ctx, input)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmMFA indicates an expected call of ConfirmMFA.
+func (mr *MockAuthAppMockRecorder) ConfirmMFA(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMFA", reflect.TypeOf((*MockAuthApp)(nil).ConfirmMFA), ctx, input)
+}
+
+// ConfirmPasswordReset mocks base method.
+func (m *MockAuthApp) ConfirmPasswordReset(ctx context.Context, input dto.PasswordResetConfirmInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPasswordReset", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmPasswordReset indicates an expected call of ConfirmPasswordReset.
+func (mr *MockAuthAppMockRecorder) ConfirmPasswordReset(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPasswordReset", reflect.TypeOf((*MockAuthApp)(nil).ConfirmPasswordReset), ctx, input)
+}
+
 // CreateSession mocks base method.
 func (m *MockAuthApp) CreateSession(ctx context.Context, session dto.Session) error {
 	m.ctrl.T.Helper()
@@ -169,6 +299,21 @@ func (mr *MockAuthAppMockRecorder) CreateSession(ctx, session any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockAuthApp)(nil).CreateSession), ctx, session)
 }
 
+// EnrollMFA mocks base method.
+func (m *MockAuthApp) EnrollMFA(ctx context.Context) (dto.MFAEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFA", ctx)
+	ret0, _ := ret[0].(dto.MFAEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFA indicates an expected call of EnrollMFA.
+func (mr *MockAuthAppMockRecorder) EnrollMFA(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFA", reflect.TypeOf((*MockAuthApp)(nil).EnrollMFA), ctx)
+}
+
 // GetSessionByUUID mocks base method.
 func (m *MockAuthApp) GetSessionByUUID(ctx context.Context, sessionUUID string) (dto.Session, error) {
 	m.ctrl.T.Helper()
@@ -184,6 +329,51 @@ func (mr *MockAuthAppMockRecorder) GetSessionByUUID(ctx, sessionUUID any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByUUID", reflect.TypeOf((*MockAuthApp)(nil).GetSessionByUUID), ctx, sessionUUID)
 }
 
+// IsMFAEnabled mocks base method.
+func (m *MockAuthApp) IsMFAEnabled(ctx context.Context, accountID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsMFAEnabled", ctx, accountID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsMFAEnabled indicates an expected call of IsMFAEnabled.
+func (mr *MockAuthAppMockRecorder) IsMFAEnabled(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMFAEnabled", reflect.TypeOf((*MockAuthApp)(nil).IsMFAEnabled), ctx, accountID)
+}
+
+// IsSessionActive mocks base method.
+func (m *MockAuthApp) IsSessionActive(ctx context.Context, sessionUUID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionActive", ctx, sessionUUID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSessionActive indicates an expected call of IsSessionActive.
+func (mr *MockAuthAppMockRecorder) IsSessionActive(ctx, sessionUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionActive", reflect.TypeOf((*MockAuthApp)(nil).IsSessionActive), ctx, sessionUUID)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthApp) ListSessions(ctx context.Context) ([]dto.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx)
+	ret0, _ := ret[0].([]dto.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthAppMockRecorder) ListSessions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthApp)(nil).ListSessions), ctx)
+}
+
 // Login mocks base method.
 func (m *MockAuthApp) Login(ctx context.Context, input dto.LoginInput) (entity.Account, error) {
 	m.ctrl.T.Helper()
@@ -199,18 +389,355 @@ func (mr *MockAuthAppMockRecorder) Login(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthApp)(nil).Login), ctx, input)
 }
 
+// LoginMFA mocks base method.
+func (m *MockAuthApp) LoginMFA(ctx context.Context, input dto.LoginMFAInput) (entity.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMFA", ctx, input)
+	ret0, _ := ret[0].(entity.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMFA indicates an expected call of LoginMFA.
+func (mr *MockAuthAppMockRecorder) LoginMFA(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMFA", reflect.TypeOf((*MockAuthApp)(nil).LoginMFA), ctx, input)
+}
+
 // Logout mocks base method.
-func (m *MockAuthApp) Logout(ctx context.Context, accessToken string) error {
+func (m *MockAuthApp) Logout(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Logout", ctx, accessToken)
+	ret := m.ctrl.Call(m, "Logout", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Logout indicates an expected call of Logout.
-func (mr *MockAuthAppMockRecorder) Logout(ctx, accessToken any) *gomock.Call {
+func (mr *MockAuthAppMockRecorder) Logout(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthApp)(nil).Logout), ctx)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockAuthApp) RequestPasswordReset(ctx context.Context, input dto.PasswordResetInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAuthAppMockRecorder) RequestPasswordReset(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockAuthApp)(nil).RequestPasswordReset), ctx, input)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockAuthApp) RevokeOtherSessions(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockAuthAppMockRecorder) RevokeOtherSessions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockAuthApp)(nil).RevokeOtherSessions), ctx)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthApp) RevokeSession(ctx context.Context, sessionUUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionUUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthAppMockRecorder) RevokeSession(ctx, sessionUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthApp)(nil).RevokeSession), ctx, sessionUUID)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockAuthApp) RotateRefreshToken(ctx context.Context, input dto.RefreshTokenRotation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockAuthAppMockRecorder) RotateRefreshToken(ctx, input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthApp)(nil).Logout), ctx, accessToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockAuthApp)(nil).RotateRefreshToken), ctx, input)
+}
+
+// MockIdempotencyApp is a mock of IdempotencyApp interface.
+type MockIdempotencyApp struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyAppMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyAppMockRecorder is the mock recorder for MockIdempotencyApp.
+type MockIdempotencyAppMockRecorder struct {
+	mock *MockIdempotencyApp
+}
+
+// NewMockIdempotencyApp creates a new mock instance.
+func NewMockIdempotencyApp(ctrl *gomock.Controller) *MockIdempotencyApp {
+	mock := &MockIdempotencyApp{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyAppMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyApp) EXPECT() *MockIdempotencyAppMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockIdempotencyApp) Begin(ctx context.Context, input dto.IdempotencyInput) (entity.IdempotentRequest, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, input)
+	ret0, _ := ret[0].(entity.IdempotentRequest)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockIdempotencyAppMockRecorder) Begin(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIdempotencyApp)(nil).Begin), ctx, input)
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyApp) Complete(ctx context.Context, request entity.IdempotentRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyAppMockRecorder) Complete(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyApp)(nil).Complete), ctx, request)
+}
+
+// Release mocks base method.
+func (m *MockIdempotencyApp) Release(ctx context.Context, scope, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, scope, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyAppMockRecorder) Release(ctx, scope, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyApp)(nil).Release), ctx, scope, key)
+}
+
+// MockRecurringTransferApp is a mock of RecurringTransferApp interface.
+type MockRecurringTransferApp struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecurringTransferAppMockRecorder
+	isgomock struct{}
+}
+
+// MockRecurringTransferAppMockRecorder is the mock recorder for MockRecurringTransferApp.
+type MockRecurringTransferAppMockRecorder struct {
+	mock *MockRecurringTransferApp
+}
+
+// NewMockRecurringTransferApp creates a new mock instance.
+func NewMockRecurringTransferApp(ctrl *gomock.Controller) *MockRecurringTransferApp {
+	mock := &MockRecurringTransferApp{ctrl: ctrl}
+	mock.recorder = &MockRecurringTransferAppMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecurringTransferApp) EXPECT() *MockRecurringTransferAppMockRecorder {
+	return m.recorder
+}
+
+// CancelRecurringTransfer mocks base method.
+func (m *MockRecurringTransferApp) CancelRecurringTransfer(ctx context.Context, recurringTransferUUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelRecurringTransfer", ctx, recurringTransferUUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelRecurringTransfer indicates an expected call of CancelRecurringTransfer.
+func (mr *MockRecurringTransferAppMockRecorder) CancelRecurringTransfer(ctx, recurringTransferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRecurringTransfer", reflect.TypeOf((*MockRecurringTransferApp)(nil).CancelRecurringTransfer), ctx, recurringTransferUUID)
+}
+
+// CreateRecurringTransfer mocks base method.
+func (m *MockRecurringTransferApp) CreateRecurringTransfer(ctx context.Context, input dto.RecurringTransferInput) (entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecurringTransfer", ctx, input)
+	ret0, _ := ret[0].(entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRecurringTransfer indicates an expected call of CreateRecurringTransfer.
+func (mr *MockRecurringTransferAppMockRecorder) CreateRecurringTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecurringTransfer", reflect.TypeOf((*MockRecurringTransferApp)(nil).CreateRecurringTransfer), ctx, input)
+}
+
+// GenerateDueOccurrences mocks base method.
+func (m *MockRecurringTransferApp) GenerateDueOccurrences(ctx context.Context, batchSize int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateDueOccurrences", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateDueOccurrences indicates an expected call of GenerateDueOccurrences.
+func (mr *MockRecurringTransferAppMockRecorder) GenerateDueOccurrences(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateDueOccurrences", reflect.TypeOf((*MockRecurringTransferApp)(nil).GenerateDueOccurrences), ctx, batchSize)
+}
+
+// GetRecurringTransferByUUID mocks base method.
+func (m *MockRecurringTransferApp) GetRecurringTransferByUUID(ctx context.Context, recurringTransferUUID string) (entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurringTransferByUUID", ctx, recurringTransferUUID)
+	ret0, _ := ret[0].(entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecurringTransferByUUID indicates an expected call of GetRecurringTransferByUUID.
+func (mr *MockRecurringTransferAppMockRecorder) GetRecurringTransferByUUID(ctx, recurringTransferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurringTransferByUUID", reflect.TypeOf((*MockRecurringTransferApp)(nil).GetRecurringTransferByUUID), ctx, recurringTransferUUID)
+}
+
+// GetRecurringTransfers mocks base method.
+func (m *MockRecurringTransferApp) GetRecurringTransfers(ctx context.Context, take, skip int64) ([]entity.RecurringTransfer, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecurringTransfers", ctx, take, skip)
+	ret0, _ := ret[0].([]entity.RecurringTransfer)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRecurringTransfers indicates an expected call of GetRecurringTransfers.
+func (mr *MockRecurringTransferAppMockRecorder) GetRecurringTransfers(ctx, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecurringTransfers", reflect.TypeOf((*MockRecurringTransferApp)(nil).GetRecurringTransfers), ctx, take, skip)
+}
+
+// UpdateRecurringTransfer mocks base method.
+func (m *MockRecurringTransferApp) UpdateRecurringTransfer(ctx context.Context, input dto.RecurringTransferUpdateInput) (entity.RecurringTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecurringTransfer", ctx, input)
+	ret0, _ := ret[0].(entity.RecurringTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecurringTransfer indicates an expected call of UpdateRecurringTransfer.
+func (mr *MockRecurringTransferAppMockRecorder) UpdateRecurringTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecurringTransfer", reflect.TypeOf((*MockRecurringTransferApp)(nil).UpdateRecurringTransfer), ctx, input)
+}
+
+// MockScheduledTransferApp is a mock of ScheduledTransferApp interface.
+type MockScheduledTransferApp struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTransferAppMockRecorder
+	isgomock struct{}
+}
+
+// MockScheduledTransferAppMockRecorder is the mock recorder for MockScheduledTransferApp.
+type MockScheduledTransferAppMockRecorder struct {
+	mock *MockScheduledTransferApp
+}
+
+// NewMockScheduledTransferApp creates a new mock instance.
+func NewMockScheduledTransferApp(ctrl *gomock.Controller) *MockScheduledTransferApp {
+	mock := &MockScheduledTransferApp{ctrl: ctrl}
+	mock.recorder = &MockScheduledTransferAppMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTransferApp) EXPECT() *MockScheduledTransferAppMockRecorder {
+	return m.recorder
+}
+
+// CancelScheduledTransfer mocks base method.
+func (m *MockScheduledTransferApp) CancelScheduledTransfer(ctx context.Context, scheduledTransferUUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", ctx, scheduledTransferUUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockScheduledTransferAppMockRecorder) CancelScheduledTransfer(ctx, scheduledTransferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockScheduledTransferApp)(nil).CancelScheduledTransfer), ctx, scheduledTransferUUID)
+}
+
+// ExecuteDueTransfers mocks base method.
+func (m *MockScheduledTransferApp) ExecuteDueTransfers(ctx context.Context, batchSize int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteDueTransfers", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteDueTransfers indicates an expected call of ExecuteDueTransfers.
+func (mr *MockScheduledTransferAppMockRecorder) ExecuteDueTransfers(ctx, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteDueTransfers", reflect.TypeOf((*MockScheduledTransferApp)(nil).ExecuteDueTransfers), ctx, batchSize)
+}
+
+// GetScheduledTransfers mocks base method.
+func (m *MockScheduledTransferApp) GetScheduledTransfers(ctx context.Context, status entity.ScheduledTransferStatus, take, skip int64) ([]entity.ScheduledTransfer, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfers", ctx, status, take, skip)
+	ret0, _ := ret[0].([]entity.ScheduledTransfer)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetScheduledTransfers indicates an expected call of GetScheduledTransfers.
+func (mr *MockScheduledTransferAppMockRecorder) GetScheduledTransfers(ctx, status, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfers", reflect.TypeOf((*MockScheduledTransferApp)(nil).GetScheduledTransfers), ctx, status, take, skip)
+}
+
+// ScheduleTransfer mocks base method.
+func (m *MockScheduledTransferApp) ScheduleTransfer(ctx context.Context, input dto.ScheduledTransferInput) (entity.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleTransfer", ctx, input)
+	ret0, _ := ret[0].(entity.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleTransfer indicates an expected call of ScheduleTransfer.
+func (mr *MockScheduledTransferAppMockRecorder) ScheduleTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleTransfer", reflect.TypeOf((*MockScheduledTransferApp)(nil).ScheduleTransfer), ctx, input)
 }
 
 // MockTransferApp is a mock of TransferApp interface.
@@ -238,11 +765,12 @@ func (m *MockTransferApp) EXPECT() *MockTransferAppMockRecorder {
 }
 
 // CreateTransfer mocks base method.
-func (m *MockTransferApp) CreateTransfer(ctx context.Context, transfer dto.TransferInput) error {
+func (m *MockTransferApp) CreateTransfer(ctx context.Context, transfer dto.TransferInput) (entity.Transfer, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateTransfer", ctx, transfer)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateTransfer indicates an expected call of CreateTransfer.
@@ -251,10 +779,25 @@ func (mr *MockTransferAppMockRecorder) CreateTransfer(ctx, transfer any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockTransferApp)(nil).CreateTransfer), ctx, transfer)
 }
 
+// GetTransferByUUID mocks base method.
+func (m *MockTransferApp) GetTransferByUUID(ctx context.Context, transferUUID string) (entity.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferByUUID", ctx, transferUUID)
+	ret0, _ := ret[0].(entity.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferByUUID indicates an expected call of GetTransferByUUID.
+func (mr *MockTransferAppMockRecorder) GetTransferByUUID(ctx, transferUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferByUUID", reflect.TypeOf((*MockTransferApp)(nil).GetTransferByUUID), ctx, transferUUID)
+}
+
 // GetTransfers mocks base method.
-func (m *MockTransferApp) GetTransfers(ctx context.Context, take, skip int64) ([]entity.Transfer, int64, error) {
+func (m *MockTransferApp) GetTransfers(ctx context.Context, input dto.TransferHistoryInput, take, skip int64) ([]entity.Transfer, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfers", ctx, take, skip)
+	ret := m.ctrl.Call(m, "GetTransfers", ctx, input, take, skip)
 	ret0, _ := ret[0].([]entity.Transfer)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -262,7 +805,38 @@ func (m *MockTransferApp) GetTransfers(ctx context.Context, take, skip int64) ([
 }
 
 // GetTransfers indicates an expected call of GetTransfers.
-func (mr *MockTransferAppMockRecorder) GetTransfers(ctx, take, skip any) *gomock.Call {
+func (mr *MockTransferAppMockRecorder) GetTransfers(ctx, input, take, skip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfers", reflect.TypeOf((*MockTransferApp)(nil).GetTransfers), ctx, input, take, skip)
+}
+
+// GetTransfersByCursor mocks base method.
+func (m *MockTransferApp) GetTransfersByCursor(ctx context.Context, input dto.TransferHistoryInput, page entity.CursorPage) ([]entity.Transfer, entity.CursorResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfersByCursor", ctx, input, page)
+	ret0, _ := ret[0].([]entity.Transfer)
+	ret1, _ := ret[1].(entity.CursorResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransfersByCursor indicates an expected call of GetTransfersByCursor.
+func (mr *MockTransferAppMockRecorder) GetTransfersByCursor(ctx, input, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfersByCursor", reflect.TypeOf((*MockTransferApp)(nil).GetTransfersByCursor), ctx, input, page)
+}
+
+// ReverseTransfer mocks base method.
+func (m *MockTransferApp) ReverseTransfer(ctx context.Context, input dto.TransferReversalInput) (entity.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransfer", ctx, input)
+	ret0, _ := ret[0].(entity.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransfer indicates an expected call of ReverseTransfer.
+func (mr *MockTransferAppMockRecorder) ReverseTransfer(ctx, input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfers", reflect.TypeOf((*MockTransferApp)(nil).GetTransfers), ctx, take, skip)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransfer", reflect.TypeOf((*MockTransferApp)(nil).ReverseTransfer), ctx, input)
 }
@@ -0,0 +1,41 @@
+package mask
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/diegoclair/go_boilerplate/util/number"
+)
+
+// CPF keeps only the middle digits of cpf, as in ***.456.789-**, the way
+// Brazilian institutions show a CPF to whoever isn't its holder. Anything that
+// isn't a CPF is masked whole.
+func CPF(cpf string) string {
+	digits := number.CleanNumber(cpf)
+	if len(digits) != 11 {
+		return strings.Repeat("*", len(cpf))
+	}
+
+	return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+}
+
+// IP drops the host part of ip, keeping the /24 of an IPv4 address or the /48
+// of an IPv6 one: enough to tell networks apart, not enough to find a person.
+func IP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return strings.Repeat("*", len(ip))
+	}
+
+	bits := 48
+	if addr.Is4() || addr.Is4In6() {
+		addr, bits = addr.Unmap(), 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return strings.Repeat("*", len(ip))
+	}
+
+	return prefix.String()
+}
@@ -0,0 +1,21 @@
+package mask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPF(t *testing.T) {
+	require.Equal(t, "***.456.789-**", CPF("12345678909"))
+	require.Equal(t, "***.456.789-**", CPF("123.456.789-09"))
+	require.Equal(t, "*****", CPF("12345"))
+	require.Equal(t, "", CPF(""))
+}
+
+func TestIP(t *testing.T) {
+	require.Equal(t, "189.40.12.0/24", IP("189.40.12.7"))
+	require.Equal(t, "189.40.12.0/24", IP("::ffff:189.40.12.7"))
+	require.Equal(t, "2001:db8:1::/48", IP("2001:db8:1:2::1"))
+	require.Equal(t, "*******", IP("unknown"))
+}
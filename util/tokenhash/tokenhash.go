@@ -0,0 +1,21 @@
+package tokenhash
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// Sum is what is stored of a token: enough to find it when it is presented,
+// and useless to whoever reads it from the database. It is the hex of the
+// sha-256 of token.
+func Sum(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Equal compares two sums in constant time, so how long it takes tells
+// nothing of how much of them matched.
+func Equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
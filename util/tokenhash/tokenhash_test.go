@@ -0,0 +1,20 @@
+package tokenhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSum(t *testing.T) {
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Sum(""))
+	require.Equal(t, Sum("token"), Sum("token"))
+	require.NotEqual(t, Sum("token"), Sum("token2"))
+	require.Len(t, Sum("token"), 64)
+}
+
+func TestEqual(t *testing.T) {
+	require.True(t, Equal(Sum("token"), Sum("token")))
+	require.False(t, Equal(Sum("token"), Sum("token2")))
+	require.False(t, Equal(Sum("token"), ""))
+}
@@ -0,0 +1,110 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 with
+// the settings every authenticator app takes by default: HMAC-SHA1, 6 digits
+// and a new code every 30 seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the length of a code
+	Digits = 6
+	// Period is how long a code lasts
+	Period = 30 * time.Second
+
+	// modulo cuts a code to Digits, 10^Digits
+	modulo = 1_000_000
+
+	// secretSize is the size of the HMAC-SHA1 key the RFC recommends
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, in the base32 the apps take.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI is the otpauth URI of the secret, usually shown as a QR
+// code for the app to scan. The app lists it as issuer and accountName.
+func ProvisioningURI(issuer, accountName, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// Step is the number of the period t is in, counted from the Unix epoch.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code is the code of the secret for step.
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// the dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo), nil
+}
+
+// Validate tells whether code is the code of the secret at t, or of one of
+// the skew steps before or after it, for a clock a little off. The step it
+// matched is returned so the caller can refuse the code from then on.
+func Validate(secret, code string, t time.Time, skew int64) (step int64, ok bool, err error) {
+	if len(code) != Digits {
+		return step, false, nil
+	}
+
+	current := Step(t)
+	for step = current - skew; step <= current+skew; step++ {
+		want, err := Code(secret, step)
+		if err != nil {
+			return step, false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors of RFC 6238, in base32.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the 8 digit codes of the RFC, cut to their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("Code() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current, _ := Code(rfcSecret, Step(now))
+	previous, _ := Code(rfcSecret, Step(now)-1)
+	tooOld, _ := Code(rfcSecret, Step(now)-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "Should take the code of now", code: current, wantStep: Step(now), wantOk: true},
+		{name: "Should take the code of a step within the skew", code: previous, wantStep: Step(now) - 1, wantOk: true},
+		{name: "Should refuse the code of a step past the skew", code: tooOld},
+		{name: "Should refuse a code of the wrong length", code: "12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, 1)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if ok != tt.wantOk {
+				t.Fatalf("Validate() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && step != tt.wantStep {
+				t.Errorf("Validate() step = %v, want %v", step, tt.wantStep)
+			}
+		})
+	}
+
+	if _, _, err := Validate("not base32!", "123456", now, 1); err == nil {
+		t.Error("Validate() should fail for an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("the secret should make codes, got %v", err)
+	}
+
+	other, _ := GenerateSecret()
+	if secret == other {
+		t.Error("GenerateSecret() should not repeat a secret")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	got := ProvisioningURI("go_boilerplate", "John Doe", "SECRET")
+
+	uri, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("ProvisioningURI() is not a URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("ProvisioningURI() = %v, want an otpauth://totp URI", got)
+	}
+	if uri.Path != "/go_boilerplate:John Doe" {
+		t.Errorf("ProvisioningURI() label = %v", uri.Path)
+	}
+	if uri.Query().Get("secret") != "SECRET" || uri.Query().Get("issuer") != "go_boilerplate" {
+		t.Errorf("ProvisioningURI() query = %v", uri.RawQuery)
+	}
+}